@@ -0,0 +1,630 @@
+// Package cheader parses C headers into the C types and declarations of PS1
+// symbol files; the inverse of the C headers output by sym_dump. Hand-maintained
+// headers may thereby be compared against the types of SYM files, or encoded as
+// SYM symbols (using csym.Parser.Encode) to add type information to SYM files
+// lacking it.
+package cheader
+
+import (
+	"embed"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+	"modernc.org/cc/v4"
+)
+
+// A Header is a C header.
+type Header struct {
+	// Header path; headers included using quotes are located relative to the
+	// directory of the header.
+	Path string
+	// Header contents.
+	Src string
+}
+
+// ParseFiles parses the given C header files, as a single translation unit.
+func ParseFiles(paths ...string) (*csym.Parser, error) {
+	var headers []*Header
+	for _, path := range paths {
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		headers = append(headers, &Header{Path: path, Src: string(buf)})
+	}
+	return Parse(headers...)
+}
+
+// Parse parses the given C headers, as a single translation unit, into the
+// equivalent C types and declarations of a parser.
+//
+// Headers are parsed for the PS1 (32-bit little-endian MIPS); system headers
+// included using angle brackets are limited to the embedded stdint.h, stddef.h,
+// stdbool.h and stdarg.h. Types and declarations of system headers are not
+// recorded by the parser, and the system headers in use are recorded as
+// includes.
+//
+// Structs, unions and enums are recorded in order of definition, and anonymous
+// structs, unions and enums are given fake tags (e.g. "_0fake"). Type
+// qualifiers are omitted.
+//
+// Global variables and functions are recorded with the metadata of the comments
+// preceding their declarations, as output by sym_dump (e.g. "address:
+// 0x80010000" and "size: 0x10"). Parameters are located in the argument
+// registers ($a0-$a3) and on the stack, as specified by the MIPS calling
+// convention. Local variables of function definitions are not recorded.
+func Parse(headers ...*Header) (*csym.Parser, error) {
+	cfg, err := newConfig()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	sources := []cc.Source{
+		{Name: "<predefined>", Value: predefined},
+		{Name: "<builtin>", Value: cc.Builtin},
+	}
+	for _, h := range headers {
+		sources = append(sources, cc.Source{Name: h.Path, Value: h.Src})
+	}
+	ast, err := cc.Translate(cfg, sources)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse C headers")
+	}
+	tr := newTranslator()
+	if err := tr.translateUnit(ast.TranslationUnit); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return tr.p, nil
+}
+
+// sysInclude is the directory of the embedded system headers.
+const sysInclude = "sysinclude"
+
+// sysHeaders holds the embedded system headers.
+//
+//go:embed sysinclude/*.h
+var sysHeaders embed.FS
+
+// predefined holds the predefined macros of the PS1 C compiler (GCC targeting
+// 32-bit little-endian MIPS), as required by the builtin definitions of the C
+// frontend.
+const predefined = `
+#define __STDC__ 1
+#define __STDC_VERSION__ 199901L
+#define __STDC_HOSTED__ 0
+#define __GNUC__ 2
+#define __mips__ 1
+#define __mips 1
+#define __MIPSEL__ 1
+#define __CHAR_BIT__ 8
+#define __SIZEOF_POINTER__ 4
+#define __SIZEOF_INT__ 4
+#define __SIZEOF_LONG__ 4
+#define __SIZE_TYPE__ unsigned int
+#define __PTRDIFF_TYPE__ int
+#define __WCHAR_TYPE__ int
+#define __UINT16_TYPE__ unsigned short
+#define __UINT32_TYPE__ unsigned int
+#define __UINT64_TYPE__ unsigned long long
+`
+
+// newConfig returns the configuration of the C frontend for the PS1; a 32-bit
+// little-endian target with 8-byte aligned doubles and long longs, as specified
+// by the MIPS o32 ABI.
+func newConfig() (*cc.Config, error) {
+	abi, err := cc.NewABI("linux", "386")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, kind := range []cc.Kind{cc.Double, cc.LongDouble, cc.LongLong, cc.ULongLong} {
+		abi.Types[kind] = cc.AbiType{Size: 8, Align: 8, FieldAlign: 8}
+	}
+	abi.SignedChar = true
+	cfg := &cc.Config{
+		ABI:             abi,
+		FS:              sysHeaders,
+		IncludePaths:    []string{""},
+		SysIncludePaths: []string{sysInclude},
+		// Skip type checking of function bodies.
+		Header: true,
+	}
+	return cfg, nil
+}
+
+// translator tracks the state of the translation of C headers.
+type translator struct {
+	// Parser holding the translated types and declarations.
+	p *csym.Parser
+	// types maps from struct, union and enum type (see typeKey) to translated C
+	// type.
+	types map[interface{}]c.Type
+	// typedefs maps from type definition declarator to translated C type
+	// definition.
+	typedefs map[*cc.Declarator]*c.VarDecl
+	// vars maps from name to global variable.
+	vars map[string]*c.VarDecl
+	// funcs maps from name to function.
+	funcs map[string]*c.FuncDecl
+	// Number of fake tags of anonymous struct, union and enum types.
+	nfakes int
+}
+
+// newTranslator returns a new translator of C headers.
+func newTranslator() *translator {
+	return &translator{
+		p:        csym.NewParser(),
+		types:    make(map[interface{}]c.Type),
+		typedefs: make(map[*cc.Declarator]*c.VarDecl),
+		vars:     make(map[string]*c.VarDecl),
+		funcs:    make(map[string]*c.FuncDecl),
+	}
+}
+
+// --- [ Declarations ] --------------------------------------------------------
+
+// translateUnit translates the external declarations of the given translation
+// unit.
+func (tr *translator) translateUnit(tu *cc.TranslationUnit) error {
+	for ; tu != nil; tu = tu.TranslationUnit {
+		ed := tu.ExternalDeclaration
+		if isSystem(ed.Position().Filename) {
+			continue
+		}
+		var err error
+		switch ed.Case {
+		case cc.ExternalDeclarationDecl:
+			err = tr.translateDecl(ed.Declaration, metadata(ed))
+		case cc.ExternalDeclarationFuncDef:
+			err = tr.translateFunc(ed.FunctionDefinition.Declarator, metadata(ed))
+		}
+		if err != nil {
+			return errors.Wrapf(err, "%v", ed.Position())
+		}
+	}
+	return nil
+}
+
+// translateDecl translates the given declaration, with the metadata of its
+// preceding comments.
+func (tr *translator) translateDecl(decl *cc.Declaration, meta map[string]uint32) error {
+	if decl.Case != cc.DeclarationDecl {
+		return nil
+	}
+	// Translate structs, unions and enums defined or declared by the type
+	// specifier of the declaration.
+	for ds := decl.DeclarationSpecifiers; ds != nil; ds = ds.DeclarationSpecifiers {
+		spec := ds.TypeSpecifier
+		if spec == nil {
+			continue
+		}
+		switch {
+		case spec.StructOrUnionSpecifier != nil:
+			if _, err := tr.translateUnderlying(spec.StructOrUnionSpecifier.Type()); err != nil {
+				return errors.WithStack(err)
+			}
+		case spec.EnumSpecifier != nil:
+			if _, err := tr.translateUnderlying(spec.EnumSpecifier.Type()); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	for list := decl.InitDeclaratorList; list != nil; list = list.InitDeclaratorList {
+		d := list.InitDeclarator.Declarator
+		switch {
+		case d.IsTypename():
+			if _, err := tr.typedef(d); err != nil {
+				return errors.WithStack(err)
+			}
+		case d.Type().Kind() == cc.Function:
+			if err := tr.translateFunc(d, meta); err != nil {
+				return errors.WithStack(err)
+			}
+		default:
+			if err := tr.translateVar(d, meta); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	return nil
+}
+
+// translateVar translates the given global variable declarator. Repeated
+// declarations of a variable only contribute their metadata.
+func (tr *translator) translateVar(d *cc.Declarator, meta map[string]uint32) error {
+	if v, ok := tr.vars[d.Name()]; ok {
+		if v.Addr == 0 {
+			v.Addr = meta["address"]
+		}
+		return nil
+	}
+	t, err := tr.translateType(d.Type())
+	if err != nil {
+		return errors.Wrapf(err, "unable to translate type of variable %q", d.Name())
+	}
+	v := &c.VarDecl{
+		Addr:  meta["address"],
+		Size:  meta["size"],
+		Class: c.Extern,
+		Var: c.Var{
+			Type: t,
+			Name: d.Name(),
+		},
+	}
+	if d.IsStatic() {
+		v.Class = c.Static
+	}
+	if v.Size == 0 {
+		v.Size = c.Sizeof(t)
+	}
+	tr.p.Vars = append(tr.p.Vars, v)
+	tr.vars[v.Name] = v
+	return nil
+}
+
+// translateFunc translates the given function declarator. Repeated
+// declarations of a function (e.g. prototypes and definitions) only contribute
+// their metadata.
+func (tr *translator) translateFunc(d *cc.Declarator, meta map[string]uint32) error {
+	if f, ok := tr.funcs[d.Name()]; ok {
+		if f.Addr == 0 {
+			f.Addr, f.Size = meta["address"], meta["size"]
+			f.LineStart, f.LineEnd = meta["line start"], meta["line end"]
+		}
+		return nil
+	}
+	t, err := tr.translateType(d.Type())
+	if err != nil {
+		return errors.Wrapf(err, "unable to translate type of function %q", d.Name())
+	}
+	funcType, ok := t.(*c.FuncType)
+	if !ok {
+		return errors.Errorf("invalid type of function %q; expected *c.FuncType, got %T", d.Name(), t)
+	}
+	// The first four parameters are passed in $a0-$a3 (registers 4-7), and the
+	// remaining parameters on the stack.
+	for i, param := range funcType.Params {
+		if len(param.Name) == 0 {
+			param.Name = fmt.Sprintf("param_%d", i+1)
+		}
+		if i < 4 {
+			param.Class = c.Register
+			param.Addr = uint32(4 + i)
+		} else {
+			param.Addr = uint32(4 * i)
+		}
+	}
+	f := &c.FuncDecl{
+		Addr:      meta["address"],
+		Size:      meta["size"],
+		Class:     c.Extern,
+		LineStart: meta["line start"],
+		LineEnd:   meta["line end"],
+		Var: c.Var{
+			Type: funcType,
+			Name: d.Name(),
+		},
+	}
+	if d.IsStatic() {
+		f.Class = c.Static
+	}
+	tr.p.Funcs = append(tr.p.Funcs, f)
+	tr.funcs[f.Name] = f
+	return nil
+}
+
+// --- [ Types ] ---------------------------------------------------------------
+
+// translateType returns the C type of the given type of the C frontend,
+// translating it if not yet translated.
+func (tr *translator) translateType(t cc.Type) (c.Type, error) {
+	if def := t.Typedef(); def != nil {
+		return tr.typedef(def)
+	}
+	return tr.translateUnderlying(t)
+}
+
+// translateUnderlying returns the C type of the given type of the C frontend,
+// disregarding its type definition.
+func (tr *translator) translateUnderlying(t cc.Type) (c.Type, error) {
+	if ct, ok := tr.types[typeKey(t)]; ok {
+		return ct, nil
+	}
+	switch t := t.(type) {
+	case *cc.StructType:
+		return tr.structType(t)
+	case *cc.UnionType:
+		return tr.unionType(t)
+	case *cc.EnumType:
+		return tr.enumType(t)
+	case *cc.PointerType:
+		elem, err := tr.translateType(t.Elem())
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return &c.PointerType{Elem: elem}, nil
+	case *cc.ArrayType:
+		elem, err := tr.translateType(t.Elem())
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		n := 0
+		if t.Len() > 0 {
+			n = int(t.Len())
+		}
+		return &c.ArrayType{Elem: elem, Len: n}, nil
+	case *cc.FunctionType:
+		retType, err := tr.translateType(t.Result())
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		funcType := &c.FuncType{RetType: retType, Variadic: t.IsVariadic()}
+		for _, param := range t.Parameters() {
+			if param.Type().Kind() == cc.Void {
+				// Parameter list of (void).
+				continue
+			}
+			paramType, err := tr.translateType(param.Type())
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to translate type of parameter %q", param.Name())
+			}
+			funcType.Params = append(funcType.Params, &c.VarDecl{Var: c.Var{Type: paramType, Name: param.Name()}})
+		}
+		return funcType, nil
+	}
+	if bt, ok := baseTypes[t.Kind()]; ok {
+		return bt, nil
+	}
+	return nil, errors.Errorf("support for C type %v (%v) not yet implemented", t, t.Kind())
+}
+
+// structType returns the C struct type of the given struct type of the C
+// frontend. Structs of the same tag are translated once; the first complete
+// definition is used.
+func (tr *translator) structType(t *cc.StructType) (c.Type, error) {
+	tag := tagName(t.Tag())
+	if len(tag) == 0 {
+		tag = tr.fakeTag()
+	}
+	st, ok := tr.p.Structs[tag]
+	if !ok {
+		st = &c.StructType{Tag: tag}
+		tr.p.Structs[tag] = st
+		tr.p.StructTags = append(tr.p.StructTags, tag)
+	}
+	tr.types[typeKey(t)] = st
+	if t.IsIncomplete() || st.Size != 0 || len(st.Fields) != 0 {
+		return st, nil
+	}
+	st.Size = uint32(t.Size())
+	for i := 0; i < t.NumFields(); i++ {
+		field, err := tr.field(t.FieldByIndex(i))
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to translate field of struct %q", tag)
+		}
+		st.Fields = append(st.Fields, field)
+	}
+	return st, nil
+}
+
+// unionType returns the C union type of the given union type of the C frontend.
+// Unions of the same tag are translated once; the first complete definition is
+// used.
+func (tr *translator) unionType(t *cc.UnionType) (c.Type, error) {
+	tag := tagName(t.Tag())
+	if len(tag) == 0 {
+		tag = tr.fakeTag()
+	}
+	ut, ok := tr.p.Unions[tag]
+	if !ok {
+		ut = &c.UnionType{Tag: tag}
+		tr.p.Unions[tag] = ut
+		tr.p.UnionTags = append(tr.p.UnionTags, tag)
+	}
+	tr.types[typeKey(t)] = ut
+	if t.IsIncomplete() || ut.Size != 0 || len(ut.Fields) != 0 {
+		return ut, nil
+	}
+	ut.Size = uint32(t.Size())
+	for i := 0; i < t.NumFields(); i++ {
+		field, err := tr.field(t.FieldByIndex(i))
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to translate field of union %q", tag)
+		}
+		ut.Fields = append(ut.Fields, field)
+	}
+	return ut, nil
+}
+
+// field returns the C field of the given struct or union field of the C
+// frontend.
+func (tr *translator) field(f *cc.Field) (c.Field, error) {
+	t, err := tr.translateType(f.Type())
+	if err != nil {
+		return c.Field{}, errors.Wrapf(err, "unable to translate type of field %q", f.Name())
+	}
+	field := c.Field{
+		Offset: uint32(f.Offset()),
+		Size:   c.Sizeof(t),
+		Var: c.Var{
+			Type: t,
+			Name: f.Name(),
+		},
+	}
+	if f.IsBitfield() {
+		// The offset of bitfields is the offset of their storage unit.
+		bitPos := uint32(f.Offset())*8 + uint32(f.OffsetBits())
+		field.Offset = bitPos / 8
+		field.Size = 0
+		field.BitOffset = bitPos % 8
+		field.BitWidth = uint32(f.ValueBits())
+	}
+	return field, nil
+}
+
+// enumType returns the C enum type of the given enum type of the C frontend.
+// Enums of the same tag are translated once; the first complete definition is
+// used.
+func (tr *translator) enumType(t *cc.EnumType) (c.Type, error) {
+	tag := tagName(t.Tag())
+	if len(tag) == 0 {
+		tag = tr.fakeTag()
+	}
+	et, ok := tr.p.Enums[tag]
+	if !ok {
+		et = &c.EnumType{Tag: tag}
+		tr.p.Enums[tag] = et
+		tr.p.EnumTags = append(tr.p.EnumTags, tag)
+	}
+	tr.types[typeKey(t)] = et
+	if t.IsIncomplete() || len(et.Members) != 0 {
+		return et, nil
+	}
+	for _, e := range t.Enumerators() {
+		member := &c.EnumMember{Name: e.Token.SrcStr()}
+		switch v := e.Value().(type) {
+		case cc.Int64Value:
+			if v < 0 {
+				et.Signed = true
+			}
+			member.Value = uint32(v)
+		case cc.UInt64Value:
+			member.Value = uint32(v)
+		default:
+			return nil, errors.Errorf("invalid value of enum member %q; expected integer constant, got %v", member.Name, e.Value())
+		}
+		et.Members = append(et.Members, member)
+	}
+	return et, nil
+}
+
+// typedef returns the C type definition of the given type definition
+// declarator, translating it if not yet translated. Type definitions of system
+// headers are not recorded by the parser; instead, the system header is
+// recorded as an include.
+func (tr *translator) typedef(d *cc.Declarator) (*c.VarDecl, error) {
+	if def, ok := tr.typedefs[d]; ok {
+		return def, nil
+	}
+	def := &c.VarDecl{
+		Class: c.Typedef,
+		Var: c.Var{
+			Name: d.Name(),
+		},
+	}
+	tr.typedefs[d] = def
+	t, err := tr.translateUnderlying(d.Type())
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to translate type of type definition %q", d.Name())
+	}
+	def.Type = t
+	if pos := d.Position(); isSystem(pos.Filename) {
+		tr.addInclude(path.Base(pos.Filename))
+		return def, nil
+	}
+	if _, ok := tr.p.Types[def.Name]; !ok {
+		tr.p.Types[def.Name] = def
+		tr.p.Typedefs = append(tr.p.Typedefs, def)
+	}
+	return def, nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// addInclude records the given system header as an include of the parser.
+func (tr *translator) addInclude(header string) {
+	for _, include := range tr.p.Includes {
+		if include == header {
+			return
+		}
+	}
+	tr.p.Includes = append(tr.p.Includes, header)
+}
+
+// fakeTag returns a new fake tag for an anonymous struct, union or enum type.
+func (tr *translator) fakeTag() string {
+	tag := fmt.Sprintf("_%dfake", tr.nfakes)
+	tr.nfakes++
+	return tag
+}
+
+// typeKey returns the key identifying the given type of the C frontend. Types
+// carrying type definitions are copies of the underlying type, sharing its
+// fields and enum members; anonymous struct, union and enum types are
+// therefore identified by their first field or enum member.
+func typeKey(t cc.Type) interface{} {
+	switch t := t.(type) {
+	case *cc.StructType:
+		if t.NumFields() > 0 {
+			return t.FieldByIndex(0)
+		}
+	case *cc.UnionType:
+		if t.NumFields() > 0 {
+			return t.FieldByIndex(0)
+		}
+	case *cc.EnumType:
+		if enums := t.Enumerators(); len(enums) > 0 {
+			return enums[0]
+		}
+	}
+	return t
+}
+
+// tagName returns the tag name of the given tag token; or the empty string if
+// anonymous.
+func tagName(tag cc.Token) string {
+	return tag.SrcStr()
+}
+
+// isSystem reports whether the given source file is predefined, builtin or an
+// embedded system header.
+func isSystem(filename string) bool {
+	return strings.HasPrefix(filename, "<") || strings.HasPrefix(filename, sysInclude+"/")
+}
+
+// metadataRegexp matches metadata of the comments preceding declarations, as
+// output by sym_dump; e.g. "address: 0x80010000" and "line start: 12".
+var metadataRegexp = regexp.MustCompile(`(address|size|line start|line end):\s*(0x[0-9A-Fa-f]+|[0-9]+)`)
+
+// metadata returns the metadata of the comments preceding the given external
+// declaration; e.g. "address" and "size".
+func metadata(ed *cc.ExternalDeclaration) map[string]uint32 {
+	meta := make(map[string]uint32)
+	toks := cc.NodeTokens(ed)
+	if len(toks) == 0 {
+		return meta
+	}
+	for _, subs := range metadataRegexp.FindAllStringSubmatch(string(toks[0].Sep()), -1) {
+		x, err := strconv.ParseUint(subs[2], 0, 32)
+		if err != nil {
+			continue
+		}
+		meta[subs[1]] = uint32(x)
+	}
+	return meta
+}
+
+// baseTypes maps from kind of C frontend type to C base type.
+var baseTypes = map[cc.Kind]c.Type{
+	cc.Void:       c.Void,
+	cc.Char:       c.Char,
+	cc.SChar:      c.SChar,
+	cc.UChar:      c.UChar,
+	cc.Short:      c.Short,
+	cc.UShort:     c.UShort,
+	cc.Int:        c.Int,
+	cc.UInt:       c.UInt,
+	cc.Long:       c.Long,
+	cc.ULong:      c.ULong,
+	cc.LongLong:   c.LongLong,
+	cc.ULongLong:  c.ULongLong,
+	cc.Float:      c.Float,
+	cc.Double:     c.Double,
+	cc.LongDouble: c.LongDouble,
+	cc.Bool:       c.Bool,
+}
@@ -0,0 +1,113 @@
+package cheader_test
+
+import (
+	"testing"
+
+	"github.com/sanctuary/sym/cheader"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+func TestParseFiles(t *testing.T) {
+	p, err := cheader.ParseFiles("testdata/decls.h")
+	if err != nil {
+		t.Fatalf("unable to parse C headers; %+v", err)
+	}
+	if len(p.Includes) != 1 || p.Includes[0] != "stdint.h" {
+		t.Errorf("includes mismatch; expected [stdint.h], got %v", p.Includes)
+	}
+	point, ok := p.Structs["point"]
+	if !ok {
+		t.Fatalf("unable to locate struct point")
+	}
+	wantFields := []struct {
+		def       string
+		offset    uint32
+		bitOffset uint32
+	}{
+		{def: "s32 x", offset: 0},
+		{def: "short pad[2]", offset: 4},
+		{def: "point_t *next", offset: 8},
+		{def: "unsigned int flags : 3", offset: 12, bitOffset: 0},
+		{def: "unsigned int kind : 5", offset: 12, bitOffset: 3},
+	}
+	if point.Size != 16 || len(point.Fields) != len(wantFields)+1 {
+		t.Fatalf("struct point mismatch; expected size 16 with %d fields, got %v", len(wantFields)+1, point.Def())
+	}
+	for i, want := range wantFields {
+		got := point.Fields[i]
+		if got.String() != want.def || got.Offset != want.offset || got.BitOffset != want.bitOffset {
+			t.Errorf("field %d mismatch; expected %q at %d.%d, got %q at %d.%d", i, want.def, want.offset, want.bitOffset, got.String(), got.Offset, got.BitOffset)
+		}
+	}
+	if rg := point.Fields[5]; rg.Name != "rg" || rg.Offset != 13 || rg.Type != p.Structs["_0fake"] {
+		t.Errorf("field 5 mismatch; expected rg of struct _0fake at 13, got %q at %d", rg.String(), rg.Offset)
+	}
+	// Anonymous structs are translated once; also when referenced through type
+	// definitions.
+	wantTags := []string{"point", "_0fake", "_1fake"}
+	if len(p.StructTags) != len(wantTags) {
+		t.Fatalf("struct tags mismatch; expected %v, got %v", wantTags, p.StructTags)
+	}
+	for i, want := range wantTags {
+		if p.StructTags[i] != want {
+			t.Errorf("struct tag %d mismatch; expected %q, got %q", i, want, p.StructTags[i])
+		}
+	}
+	if value := p.Unions["value"]; value == nil || value.Size != 4 || len(value.Fields) != 2 {
+		t.Errorf("union mismatch; expected union value of size 4 with 2 fields, got %v", value)
+	}
+	if color := p.Enums["color"]; color == nil || !color.Signed || len(color.Members) != 3 || color.Members[2].Value != 0xFFFFFFFF {
+		t.Errorf("enum mismatch; expected signed enum color with member NONE = -1, got %v", color)
+	}
+	wantTypedefs := []string{"bool", "s32", "point_t", "entry_t"}
+	if len(p.Typedefs) != len(wantTypedefs) {
+		t.Fatalf("number of type definitions mismatch; expected %d, got %d", len(wantTypedefs), len(p.Typedefs))
+	}
+	for i, want := range wantTypedefs {
+		if got := p.Typedefs[i].(*c.VarDecl).Name; got != want {
+			t.Errorf("type definition %d mismatch; expected %q, got %q", i, want, got)
+		}
+	}
+	wantVars := []struct {
+		def   string
+		class c.StorageClass
+		addr  uint32
+	}{
+		{def: "struct point origin", class: c.Extern, addr: 0x80010000},
+		{def: "entry_t entries[4]", class: c.Static, addr: 0x80010020},
+		{def: "char *msg", class: c.Extern},
+	}
+	if len(p.Vars) != len(wantVars) {
+		t.Fatalf("number of global variables mismatch; expected %d, got %d", len(wantVars), len(p.Vars))
+	}
+	for i, want := range wantVars {
+		got := p.Vars[i]
+		if got.Var.String() != want.def || got.Class != want.class || got.Addr != want.addr {
+			t.Errorf("global variable %d mismatch; expected %s %q at 0x%08X, got %s %q at 0x%08X", i, want.class, want.def, want.addr, got.Class, got.Var.String(), got.Addr)
+		}
+	}
+	if len(p.Funcs) != 2 {
+		t.Fatalf("number of functions mismatch; expected 2, got %d", len(p.Funcs))
+	}
+	// Metadata of the function definition, following its prototype.
+	add := p.Funcs[0]
+	if add.Addr != 0x80010100 || add.Size != 0x40 || add.LineStart != 26 || add.LineEnd != 35 {
+		t.Errorf("function mismatch; expected add of lines 26-35 at 0x80010100 of size 0x40, got %s of lines %d-%d at 0x%08X of size 0x%X", add.Name, add.LineStart, add.LineEnd, add.Addr, add.Size)
+	}
+	if param := add.Type.(*c.FuncType).Params[1]; param.Class != c.Register || param.Addr != 5 {
+		t.Errorf("parameter location mismatch; expected register 5, got %v %d", param.Class, param.Addr)
+	}
+	if f := p.Funcs[1]; len(f.Type.(*c.FuncType).Params) != 0 {
+		t.Errorf("parameters of %q mismatch; expected none, got %v", f.Name, f.Type)
+	}
+	if _, err := p.Encode(); err != nil {
+		t.Errorf("unable to encode translated C headers; %+v", err)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	h := &cheader.Header{Path: "invalid.h", Src: "struct foo { int x; };\nstruct foo { int y; };\n"}
+	if _, err := cheader.Parse(h); err == nil {
+		t.Errorf("expected error for redefinition of struct foo")
+	}
+}
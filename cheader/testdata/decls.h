@@ -0,0 +1,23 @@
+#include "types.h"
+
+// address: 0x80010000
+// size: 0x14
+extern struct point origin;
+
+// address: 0x80010020
+static entry_t entries[4];
+
+extern const char *msg;
+
+int add(point_t *p, int n);
+
+// address: 0x80010100
+// size: 0x40
+// line start: 26
+// line end:   35
+int add(point_t *p, int n) {
+	// register: 16
+	int sum;
+}
+
+void f(void);
@@ -0,0 +1,33 @@
+#include <stdint.h>
+
+typedef int bool;
+
+typedef int s32;
+typedef struct point point_t;
+
+enum color {
+	RED,
+	GREEN,
+	NONE = -1,
+};
+
+struct point {
+	s32 x;
+	short pad[2];
+	point_t *next;
+	unsigned int flags : 3;
+	unsigned int kind : 5;
+	struct {
+		uint8_t r, g;
+	} rg;
+};
+
+typedef struct {
+	enum color c;
+	void (*cb)(int, ...);
+} entry_t;
+
+union value {
+	int i;
+	float f;
+};
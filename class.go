@@ -35,8 +35,39 @@ const (
 	ClassMOE Class = 0x0010 // MOE
 	// Function parameter passed in register.
 	ClassREGPARM Class = 0x0011 // REGPARM
-	// TODO: Figure out when FIELD is used.
+	// Bitfield member of struct; value specifies the bit offset and size
+	// specifies the width in bits.
 	ClassFIELD Class = 0x0012 // FIELD
 	// End of symbol.
 	ClassEOS Class = 0x0066 // EOS
 )
+
+// Classes lists the known definition classes, in ascending order.
+var Classes = []Class{
+	ClassAUTO,
+	ClassEXT,
+	ClassSTAT,
+	ClassREG,
+	ClassLABEL,
+	ClassMOS,
+	ClassARG,
+	ClassSTRTAG,
+	ClassMOU,
+	ClassUNTAG,
+	ClassTPDEF,
+	ClassENTAG,
+	ClassMOE,
+	ClassREGPARM,
+	ClassFIELD,
+	ClassEOS,
+}
+
+// isKnownClass reports whether the given definition class is known.
+func isKnownClass(class Class) bool {
+	switch class {
+	case ClassAUTO, ClassEXT, ClassSTAT, ClassREG, ClassLABEL, ClassMOS, ClassARG, ClassSTRTAG, ClassMOU, ClassUNTAG, ClassTPDEF, ClassENTAG, ClassMOE, ClassREGPARM, ClassFIELD, ClassEOS:
+		return true
+	default:
+		return false
+	}
+}
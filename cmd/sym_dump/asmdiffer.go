@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// asm-differ file names.
+const (
+	// Linker map.
+	asmDifferMapName = "asm_differ.map"
+	// Settings script.
+	asmDifferSettingsName = "diff_settings.py"
+)
+
+// psexeHeaderSize is the size in bytes of the PS-EXE header, preceding the text
+// section in executable files.
+const psexeHeaderSize = 0x800
+
+// dumpAsmDiffer outputs the declarations recorded by the parser to asm-differ
+// linker maps and settings scripts stored in the output directory; one of each
+// for the default binary, and for each overlay (stored in overlay_N).
+func dumpAsmDiffer(p *csym.Parser, outputDir string) error {
+	// Create files of default binary.
+	if err := dumpAsmDifferOverlay(p.Overlay, outputDir); err != nil {
+		return errors.WithStack(err)
+	}
+	// Create files of overlays.
+	for _, overlay := range p.Overlays {
+		if err := dumpAsmDifferOverlay(overlay, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// dumpAsmDifferOverlay outputs the declarations of the overlay to a linker map
+// in GNU ld format and a diff_settings.py script, as read by asm-differ to
+// locate functions by name (e.g. `diff.py main`).
+//
+// Each function is recorded as an input section of its size and object file,
+// followed by the function symbol. The load address of sections maps addresses
+// to file offsets; the text section of the default binary is presumed to start
+// at the lowest function address, directly following the PS-EXE header, and
+// overlays are presumed to be raw binaries loaded at their base address.
+func dumpAsmDifferOverlay(overlay *csym.Overlay, outputDir string) error {
+	dir := outputDir
+	if overlay.ID != 0 {
+		overlayDir := fmt.Sprintf("overlay_%x", overlay.ID)
+		dir = filepath.Join(outputDir, overlayDir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	// Symbols without address are omitted.
+	var funcs []*c.FuncDecl
+	for _, f := range overlay.Funcs {
+		if f.Addr != 0 {
+			funcs = append(funcs, f)
+		}
+	}
+	sort.SliceStable(funcs, func(i, j int) bool {
+		return funcs[i].Addr < funcs[j].Addr
+	})
+	var vars []*c.VarDecl
+	for _, v := range overlay.Vars {
+		if v.Addr != 0 {
+			vars = append(vars, v)
+		}
+	}
+	sort.SliceStable(vars, func(i, j int) bool {
+		return vars[i].Addr < vars[j].Addr
+	})
+	var text, data elfRange
+	for _, f := range funcs {
+		text.add(f.Addr, f.Size)
+	}
+	for _, v := range vars {
+		data.add(v.Addr, v.Size)
+	}
+	// Offset from address to file offset.
+	base := text.start
+	offset := uint32(psexeHeaderSize)
+	if overlay.ID != 0 {
+		base, offset = overlay.Addr, 0
+	}
+	// Create linker map.
+	mapPath := filepath.Join(dir, asmDifferMapName)
+	fmt.Println("creating:", mapPath)
+	f, err := os.Create(mapPath)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create asm-differ linker map %q", mapPath)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	w.WriteString("Linker script and memory map\n\n")
+	if len(funcs) > 0 {
+		fmt.Fprintf(w, ".text\n                0x%08x %10s load address 0x%08x\n", text.start, fmt.Sprintf("0x%x", text.size()), text.start-base+offset)
+		for _, f := range funcs {
+			fmt.Fprintf(w, " .text.%s 0x%08x %10s %s\n", f.Name, f.Addr, fmt.Sprintf("0x%x", f.Size), asmDifferObjFile(f.Path))
+			fmt.Fprintf(w, "                0x%08x                %s\n", f.Addr, f.Name)
+		}
+		w.WriteString("\n")
+	}
+	if len(vars) > 0 {
+		fmt.Fprintf(w, ".data\n                0x%08x %10s load address 0x%08x\n", data.start, fmt.Sprintf("0x%x", data.size()), data.start-base+offset)
+		for _, v := range vars {
+			fmt.Fprintf(w, "                0x%08x                %s\n", v.Addr, v.Name)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+	// Create settings script.
+	settingsPath := filepath.Join(dir, asmDifferSettingsName)
+	fmt.Println("creating:", settingsPath)
+	if err := ioutil.WriteFile(settingsPath, []byte(asmDifferSettings), 0644); err != nil {
+		return errors.Wrapf(err, "unable to create asm-differ settings script %q", settingsPath)
+	}
+	return nil
+}
+
+// asmDifferSettings is the diff_settings.py script of asm-differ.
+const asmDifferSettings = `# asm-differ settings, as generated by sym_dump.
+#
+# Update baseimg and myimg to the paths of the original and rebuilt
+# executables, respectively.
+
+def apply(config, args):
+    config["arch"] = "mipsel"
+    config["baseimg"] = "orig/MAIN.EXE"
+    config["myimg"] = "build/MAIN.EXE"
+    config["mapfile"] = "asm_differ.map"
+    config["map_format"] = "gnu"
+    config["source_directories"] = ["src"]
+    config["objdump_executable"] = "mipsel-linux-gnu-objdump"
+`
+
+// ### [ Helper functions ] ####################################################
+
+// asmDifferObjFile returns the object file name of the given source file.
+func asmDifferObjFile(srcPath string) string {
+	if len(srcPath) == 0 {
+		return "unknown.o"
+	}
+	// Source paths of SYM files are DOS paths; e.g. "C:\\PSX\\MAIN.C".
+	base := path.Base(strings.Replace(srcPath, "\\", "/", -1))
+	return strings.TrimSuffix(base, path.Ext(base)) + ".o"
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+)
+
+// Binary Ninja script name.
+const binjaName = "binja_import_symbols.py"
+
+// dumpBinja outputs the declarations and type information recorded by the
+// parser to a Binary Ninja Python script stored in the output directory. When
+// run in Binary Ninja (File > Run Script), the script:
+//
+//   - parses the C types of the SYM file (as output by -types) and defines
+//     them as user types;
+//   - names functions, creating them if not present, and applies their
+//     function signatures; and
+//   - names global variables and defines their data variables.
+//
+// Declarations of the default binary are always applied; declarations of an
+// overlay are applied if OVERLAY is set to the ID of the overlay at the top of
+// the script (e.g. when analyzing the overlay loaded at its load address).
+func dumpBinja(p *csym.Parser, outputDir string) error {
+	if err := createOutputFile(outputDir, binjaName, func(f io.Writer) error {
+		w := bufio.NewWriter(f)
+		w.WriteString(binjaHeader)
+		// Types.
+		writePythonTypes(w, p)
+		// Declarations.
+		overlays := append([]*csym.Overlay{p.Overlay}, p.Overlays...)
+		for _, overlay := range overlays {
+			fmt.Fprintf(w, "\ndef overlay_%x():\n", overlay.ID)
+			if len(overlay.Funcs) == 0 && len(overlay.Vars) == 0 {
+				w.WriteString("\tpass\n")
+			}
+			for _, fn := range overlay.Funcs {
+				fmt.Fprintf(w, "\tfunc(0x%08X, %q, %q)\n", fn.Addr, fn.Name, declString(fn.Var))
+			}
+			for _, v := range overlay.Vars {
+				fmt.Fprintf(w, "\tvar(0x%08X, %q, %q)\n", v.Addr, v.Name, typeString(v.Type))
+			}
+		}
+		w.WriteString(binjaFooter)
+		return w.Flush()
+	}); err != nil {
+		return errors.Wrap(err, "unable to create Binary Ninja script")
+	}
+	return nil
+}
+
+// binjaHeader is the header of the Binary Ninja script, defining the helper
+// functions used to define types and symbols.
+const binjaHeader = `# Import symbols of PS1 SYM file, as generated by sym_dump.
+#
+# Run from Binary Ninja (File > Run Script) with the binary view of the PS1
+# executable (or overlay) open.
+
+from binaryninja import Symbol, SymbolType
+
+# ID of the overlay of the binary view; or 0 for the default binary.
+OVERLAY = 0
+
+def parse_types():
+	result = bv.parse_types_from_string(TYPES)
+	for name, t in result.types.items():
+		bv.define_user_type(name, t)
+
+def func(addr, name, decl):
+	bv.define_user_symbol(Symbol(SymbolType.FunctionSymbol, addr, name))
+	f = bv.get_function_at(addr)
+	if f is None:
+		bv.add_function(addr)
+		f = bv.get_function_at(addr)
+	if f is not None:
+		t, _ = bv.parse_type_string(decl)
+		f.type = t
+
+def var(addr, name, decl):
+	t, _ = bv.parse_type_string(decl)
+	bv.define_user_data_var(addr, t)
+	bv.define_user_symbol(Symbol(SymbolType.DataSymbol, addr, name))
+`
+
+// binjaFooter is the footer of the Binary Ninja script, applying the types and
+// declarations.
+const binjaFooter = `
+parse_types()
+overlay_0()
+if OVERLAY != 0:
+	globals()["overlay_%x" % OVERLAY]()
+`
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+const (
+	// Function prototypes header file name.
+	functionsName = "functions.h"
+	// Global variable declarations header file name.
+	variablesName = "variables.h"
+)
+
+// dumpCategories outputs the type definitions and declarations recorded by the
+// parser to C headers stored in the output directory, separated by category;
+// type definitions to types.h, function prototypes to functions.h and extern
+// declarations of global variables to variables.h. If check is set, the header
+// of type definitions is re-parsed to validate its syntax. The locations of
+// type definitions and declarations are recorded in tags.
+//
+// Declarations of overlays follow the declarations of the base executable.
+// Duplicate identifiers are renamed, as all overlays share the same headers.
+func dumpCategories(p *csym.Parser, outputDir string, check bool, tags *tagsFile, style c.Style, guard string) error {
+	header, spans := defsHeader(p.Includes, nil, typeDefs(p), typesName, style, guard)
+	if err := dumpHeader(outputDir, typesName, header, spans, check, tags, style); err != nil {
+		return errors.WithStack(err)
+	}
+	overlays := append([]*csym.Overlay{p.Overlay}, p.Overlays...)
+	// Rename duplicate identifiers.
+	src := &SourceFile{}
+	for _, overlay := range overlays {
+		src.vars = append(src.vars, overlay.Vars...)
+		src.funcs = append(src.funcs, overlay.Funcs...)
+	}
+	src.uniqueNames()
+	if err := createOutputFile(outputDir, functionsName, func(w io.Writer) error {
+		return dumpFunctions(w, overlays, functionsName, tags, style, guard)
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := createOutputFile(outputDir, variablesName, func(w io.Writer) error {
+		return dumpVariables(w, overlays, variablesName, tags, style, guard)
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// dumpFunctions outputs the function prototypes of the given overlays, writing
+// to w. The locations of declarations are recorded in tags, as output to the
+// given path relative to the output directory.
+func dumpFunctions(w io.Writer, overlays []*csym.Overlay, path string, tags *tagsFile, style c.Style, guard string) error {
+	lw := newLineWriter(w)
+	w = lw
+	if _, err := io.WriteString(w, guardStart(path, guard)); err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := fmt.Fprintf(w, "#include %q\n\n", typesName); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, overlay := range overlays {
+		if len(overlay.Funcs) == 0 {
+			continue
+		}
+		if err := dumpOverlayHeading(w, overlay); err != nil {
+			return errors.WithStack(err)
+		}
+		for _, f := range overlay.Funcs {
+			proto := *f
+			proto.Blocks = nil
+			def := proto.DefStyle(style)
+			tags.addDef(path, lw.line, lw.offset, def, f.Name, tagKindFunc, f.Addr)
+			if _, err := fmt.Fprintf(w, "%s\n\n", def); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	if _, err := io.WriteString(w, guardEnd(path, guard)); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// dumpVariables outputs the global variable declarations of the given
+// overlays, writing to w. The locations of declarations are recorded in tags,
+// as output to the given path relative to the output directory.
+func dumpVariables(w io.Writer, overlays []*csym.Overlay, path string, tags *tagsFile, style c.Style, guard string) error {
+	lw := newLineWriter(w)
+	w = lw
+	if _, err := io.WriteString(w, guardStart(path, guard)); err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := fmt.Fprintf(w, "#include %q\n\n", typesName); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, overlay := range overlays {
+		if len(overlay.Vars) == 0 {
+			continue
+		}
+		if err := dumpOverlayHeading(w, overlay); err != nil {
+			return errors.WithStack(err)
+		}
+		for _, v := range overlay.Vars {
+			def := v.DefStyle(style)
+			tags.addDef(path, lw.line, lw.offset, def, v.Name, tagKindVar, v.Addr)
+			if _, err := fmt.Fprintf(w, "%s;\n\n", def); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	if _, err := io.WriteString(w, guardEnd(path, guard)); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// dumpOverlayHeading outputs a heading comment of the overlay, writing to w.
+// No heading is output for the base executable.
+func dumpOverlayHeading(w io.Writer, overlay *csym.Overlay) error {
+	if overlay.Addr != 0 || overlay.ID != 0 || overlay.Length != 0 {
+		if _, err := fmt.Fprintf(w, "// === [ Overlay ID %x ] ===\n\n", overlay.ID); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
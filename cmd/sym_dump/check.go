@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"modernc.org/cc/v4"
+)
+
+// checkHeader re-parses the given C header using an embedded C frontend, and
+// returns an error reporting the syntax errors of the header and the type
+// definitions containing them, as located by the given lines of type
+// definitions.
+//
+// The header is parsed using the predefined macros and include paths of the
+// host C compiler, to resolve system headers (e.g. stdint.h).
+func checkHeader(name, header string, spans []defSpan) error {
+	cfg, err := cc.NewConfig("", "")
+	if err != nil {
+		return errors.Wrap(err, "unable to configure C frontend")
+	}
+	// Skip type checking of function bodies.
+	cfg.Header = true
+	sources := []cc.Source{
+		{Name: "<predefined>", Value: cfg.Predefined},
+		{Name: "<builtin>", Value: cc.Builtin},
+		{Name: name, Value: header},
+	}
+	if _, err := cc.Translate(cfg, sources); err != nil {
+		return errors.Errorf("invalid C header %q:\n%s", name, describeErrors(name, err.Error(), spans))
+	}
+	return nil
+}
+
+// describeErrors returns the given C frontend errors of the named header, one
+// per line, each annotated with the type definition containing the error.
+func describeErrors(name, errs string, spans []defSpan) string {
+	// Position of error; e.g. "types.h:12:5: ...".
+	re := regexp.MustCompile("^" + regexp.QuoteMeta(name) + `:(\d+):`)
+	var lines []string
+	for _, e := range strings.Split(errs, "\n") {
+		subs := re.FindStringSubmatch(e)
+		if subs == nil {
+			lines = append(lines, e)
+			continue
+		}
+		line, err := strconv.Atoi(subs[1])
+		if err != nil {
+			lines = append(lines, e)
+			continue
+		}
+		// Locate type definition containing the line.
+		i := sort.Search(len(spans), func(i int) bool {
+			return spans[i].line > line
+		})
+		if i == 0 {
+			lines = append(lines, e)
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s (in definition of %v)", e, spans[i-1].def))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// inputFormats specifies the input formats of the convert command, in order of
+// presentation.
+var inputFormats = []struct {
+	// Format name.
+	name string
+	// Format description.
+	desc string
+}{
+	{name: formatSYM, desc: "Playstation 1 SYM file"},
+	{name: formatJSON, desc: "JSON encoded symbol file (*.json)"},
+	{name: formatGhidraCSV, desc: "Ghidra symbol table (*.csv)"},
+	{name: formatGhidraXML, desc: "Ghidra program (*.xml)"},
+	{name: formatELF, desc: "ELF file with DWARF debug information"},
+	{name: formatC, desc: "C header (*.h)"},
+	{name: formatPsyqMap, desc: "Psy-Q linker MAP file"},
+	{name: formatNocash, desc: "no$psx symbol file"},
+	{name: formatIDAMap, desc: "IDA MAP file"},
+}
+
+// An outputFormat is an output format of the convert command.
+type outputFormat struct {
+	// Format name.
+	name string
+	// Format description.
+	desc string
+	// dumpFile outputs the given symbol file, as parsed from path, to the output
+	// directory; or nil if output from C types and declarations.
+	dumpFile func(f *sym.File, path, outputDir string) error
+	// dump outputs the C types and declarations recorded by the parser to the
+	// output directory; or nil if output from symbol files.
+	dump func(p *csym.Parser, outputDir string) error
+	// Output files of the output format; output to standard output is limited
+	// to a single output file.
+	files outputFiles
+}
+
+// outputFiles specifies the output files of an output format.
+type outputFiles uint8
+
+// Output files.
+const (
+	// Single output file.
+	singleFile outputFiles = iota
+	// One output file for the default binary and one for each overlay, stored in
+	// subdirectories of the overlays.
+	filePerOverlay
+	// Multiple output files.
+	multipleFiles
+)
+
+// outputFormats specifies the output formats of the convert command, in order
+// of presentation.
+var outputFormats = []*outputFormat{
+	{name: "sym", desc: "Playstation 1 SYM file (*.sym)", dumpFile: dumpSYM},
+	{name: "json", desc: "JSON encoded symbol file (*.json)", dumpFile: dumpJSON},
+	{name: "psyq", desc: "Psy-Q DUMPSYM.EXE output (*.txt)", dumpFile: dumpPsyq},
+	{name: "c", desc: "C types and declarations (types.h and decls.h)", dump: func(p *csym.Parser, outputDir string) error {
+		if err := dumpTypes(p, outputDir, false, nil, c.DefaultStyle, guardIfndef); err != nil {
+			return errors.WithStack(err)
+		}
+		return dumpDecls(p, outputDir, nil, c.DefaultStyle, guardIfndef)
+	}, files: multipleFiles},
+	{name: "types", desc: "C types (types.h)", dump: func(p *csym.Parser, outputDir string) error {
+		return dumpTypes(p, outputDir, false, nil, c.DefaultStyle, guardIfndef)
+	}},
+	{name: "ida", desc: "IDA scripts", dump: func(p *csym.Parser, outputDir string) error {
+		if err := dumpIDAScripts(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		pruneIDATypes(p)
+		return dumpTypes(p, outputDir, false, nil, c.DefaultStyle, guardIfndef)
+	}, files: multipleFiles},
+	{name: "idc", desc: "IDC scripts (symbols.idc)", dump: dumpIDC, files: filePerOverlay},
+	{name: "idapython", desc: "IDAPython script (ida_import_symbols.py)", dump: func(p *csym.Parser, outputDir string) error {
+		pruneIDATypes(p)
+		return dumpIDAPython(p, outputDir)
+	}},
+	{name: "ghidra", desc: "Ghidra symbol scripts and data type archive", dump: func(p *csym.Parser, outputDir string) error {
+		if err := dumpGhidraSymbols(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		return dumpGhidraTypes(p, outputDir)
+	}, files: multipleFiles},
+	{name: "r2", desc: "radare2 script (symbols.r2)", dump: dumpR2},
+	{name: "binja", desc: "Binary Ninja script (binja_import_symbols.py)", dump: dumpBinja},
+	{name: "yaml", desc: "YAML file (symbols.yaml)", dump: dumpYAML},
+	{name: "csv", desc: "CSV symbol table (symbols.csv)", dump: dumpCSV},
+	{name: "proto", desc: "Protocol Buffers file (symbols.pb)", dump: dumpProto},
+	{name: "html", desc: "HTML report (index.html)", dump: dumpHTML, files: multipleFiles},
+	{name: "dot", desc: "Graphviz DOT type dependency graph (types.dot)", dump: func(p *csym.Parser, outputDir string) error {
+		return dumpDOT(p, outputDir, "")
+	}},
+	{name: "nocash", desc: "no$psx symbol files (nocash.sym)", dump: dumpNocash, files: filePerOverlay},
+	{name: "redux", desc: "PCSX-Redux symbol maps and Lua scripts", dump: dumpRedux, files: multipleFiles},
+	{name: "gdb", desc: "GDB scripts (symbols.gdb)", dump: dumpGDB, files: filePerOverlay},
+	{name: "elf", desc: "ELF symbol files (symbols.elf)", dump: dumpELF, files: filePerOverlay},
+	{name: "dwarf", desc: "ELF files with DWARF debug information (debug.elf)", dump: dumpDWARF, files: filePerOverlay},
+	{name: "stabs", desc: "assembly files with stabs debug information (stabs.s)", dump: dumpStabs, files: filePerOverlay},
+	{name: "splat", desc: "splat symbol_addrs.txt and undefined symbol linker scripts", dump: dumpSplat, files: multipleFiles},
+	{name: "m2c", desc: "m2c context files (m2c_ctx.c)", dump: dumpM2C, files: filePerOverlay},
+	{name: "asmdiffer", desc: "asm-differ linker maps and settings scripts", dump: dumpAsmDiffer, files: multipleFiles},
+	{name: "labels", desc: "armips and asmpsx label include files", dump: dumpLabels, files: multipleFiles},
+	{name: "stubs", desc: "GNU assembler symbol stub files (symbols.s)", dump: dumpStubs, files: filePerOverlay},
+}
+
+// convertUsage prints usage information of the convert command.
+func convertUsage(fs *flag.FlagSet) {
+	const use = `
+Convert symbol files between supported formats.
+
+Usage:
+
+	sym_dump convert [OPTION]... -to FORMAT FILE...
+
+Examples:
+
+	# Convert a SYM file read from standard input to JSON, written to standard
+	# output.
+	cat FILE.sym | sym_dump convert -to json -dir - - | jq .
+
+	# Convert a gzip compressed SYM file to a C header.
+	sym_dump convert -to types -dir - FILE.sym.gz > types.h
+`
+	fmt.Println(use[1:])
+	fmt.Println("Input formats (detected if not specified):")
+	fmt.Println()
+	for _, format := range inputFormats {
+		fmt.Printf("\t%-10s %s\n", format.name, format.desc)
+	}
+	fmt.Println()
+	fmt.Println("Output formats:")
+	fmt.Println()
+	for _, format := range outputFormats {
+		fmt.Printf("\t%-10s %s\n", format.name, format.desc)
+	}
+	fmt.Println()
+	fs.PrintDefaults()
+}
+
+// convert converts the symbol files specified by the given command line
+// arguments of the convert command to the specified output format.
+func convert(args []string) error {
+	// Command line flags.
+	var (
+		// Input format.
+		from string
+		// Output format.
+		to string
+		// Output directory.
+		outputDir string
+		// Text encoding of symbol names.
+		encodingName string
+		// Byte order of SYM files.
+		endian string
+	)
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	fs.StringVar(&from, "from", "", "input format; detected if not specified")
+	fs.StringVar(&to, "to", "", "output format")
+	fs.StringVar(&outputDir, "dir", dumpDir, "output directory (- for standard output)")
+	fs.StringVar(&encodingName, "encoding", "", "text encoding of symbol names (sjis, or auto to detect)")
+	fs.StringVar(&endian, "endian", "", "byte order of SYM files (big or little); detected if not specified")
+	fs.Usage = func() { convertUsage(fs) }
+	if err := fs.Parse(args); err != nil {
+		return errors.WithStack(err)
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if len(from) > 0 && !isInputFormat(from) {
+		return errors.Errorf("invalid input format %q", from)
+	}
+	format := lookupOutputFormat(to)
+	if format == nil {
+		return errors.Errorf("invalid output format %q", to)
+	}
+	enc, err := parseEncoding(encodingName)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	order, err := parseByteOrder(endian)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := initOutputDir(outputDir); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, path := range fs.Args() {
+		opts := &sym.ParseOptions{
+			Encoding:       enc,
+			Order:          order,
+			DetectEncoding: strings.EqualFold(encodingName, "auto"),
+		}
+		f, err := parseFile(path, from, opts)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err := outputFile(format, f, path, outputDir, fs.NArg() > 1); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// outputFile outputs the given symbol file, as parsed from path, in the
+// specified output format to the output directory. Output of C types and
+// declarations is written to a separate directory of each file if split is set,
+// as output file names are fixed.
+//
+// The output is written to standard output if outputDir is "-", provided that
+// the output format produces a single output file.
+func outputFile(format *outputFormat, f *sym.File, path, outputDir string, split bool) error {
+	path = inputName(path)
+	if outputDir == stdio && format.files == multipleFiles {
+		return errors.Errorf("unable to write %q output to standard output; output format produces multiple output files", format.name)
+	}
+	if format.dumpFile != nil {
+		return format.dumpFile(f, path, outputDir)
+	}
+	dir := outputDir
+	if split && outputDir != stdio {
+		dir = filepath.Join(outputDir, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	p := parseSyms(f)
+	if outputDir == stdio && format.files == filePerOverlay && len(p.Overlays) > 0 {
+		return errors.Errorf("unable to write %q output to standard output; output format produces one output file per overlay", format.name)
+	}
+	return format.dump(p, dir)
+}
+
+// parseSyms returns a parser of the C types and declarations of the given
+// symbol file.
+func parseSyms(f *sym.File) *csym.Parser {
+	p := csym.NewParser()
+	if f.Dialect != nil {
+		p.Dims = f.Dialect.Dims
+	}
+	p.ParseTypes(f.Syms)
+	p.Canonicalize()
+	p.ParseDecls(f.Syms)
+	return p
+}
+
+// isInputFormat reports whether the given name is an input format of the
+// convert command.
+func isInputFormat(name string) bool {
+	for _, format := range inputFormats {
+		if format.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupOutputFormat returns the output format of the given name, or nil if not
+// present.
+func lookupOutputFormat(name string) *outputFormat {
+	for _, format := range outputFormats {
+		if format.name == name {
+			return format
+		}
+	}
+	return nil
+}
+
+// dumpJSON outputs the given symbol file, as parsed from path, in JSON format
+// to the output directory.
+func dumpJSON(f *sym.File, path, outputDir string) error {
+	buf, err := json.MarshalIndent(f, "", "\t")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + ".json"
+	return createOutputFile(outputDir, name, func(w io.Writer) error {
+		_, err := w.Write(append(buf, '\n'))
+		return err
+	})
+}
+
+// dumpPsyq outputs the given symbol file, as parsed from path, in Psy-Q
+// DUMPSYM.EXE format to the output directory.
+func dumpPsyq(f *sym.File, path, outputDir string) error {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + ".txt"
+	return createOutputFile(outputDir, name, func(w io.Writer) error {
+		_, err := io.WriteString(w, f.String())
+		return err
+	})
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// cscope cross-reference database file name.
+const cscopeName = "cscope.out"
+
+// cscope marks of symbols.
+const (
+	cscopeFuncDef   = '$'
+	cscopeFuncEnd   = '}'
+	cscopeGlobalDef = 'g'
+	cscopeEnumDef   = 'e'
+	cscopeMemberDef = 'm'
+	cscopeStructDef = 's'
+	cscopeTypedef   = 't'
+	cscopeUnionDef  = 'u'
+	cscopeLocalDef  = 'l'
+	cscopeInclude   = '~'
+)
+
+// dumpCscope outputs a cscope cross-reference database (uncompressed, as
+// created by `cscope -b -c`) of the generated C headers and source files
+// recorded in tags, to the output directory.
+//
+// Definitions of types, global variables and functions are located by tags,
+// and definitions of struct, union and enum members and local variables are
+// located by their enclosing definitions. Other identifiers are recorded as
+// references, to enable queries of the uses of types and variables.
+func dumpCscope(outputDir string, tags *tagsFile) error {
+	// Group tags by file, in order of occurrence.
+	var paths []string
+	files := make(map[string][]*tagEntry)
+	for _, tag := range tags.tags {
+		if _, ok := files[tag.path]; !ok {
+			paths = append(paths, tag.path)
+		}
+		files[tag.path] = append(files[tag.path], tag)
+	}
+	buf := &bytes.Buffer{}
+	// Header; the offset of the trailer is updated once known.
+	dir, err := filepath.Abs(outputDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	const headerFormat = "cscope 15 %s -c %010d\n"
+	fmt.Fprintf(buf, headerFormat, dir, 0)
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(filepath.Join(outputDir, filepath.FromSlash(path)))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		fmt.Fprintf(buf, "\t@%s\n\n", path)
+		xref := newCscopeXref(files[path])
+		for i, line := range strings.Split(string(content), "\n") {
+			xref.putLine(buf, i+1, line)
+		}
+	}
+	// End of files.
+	buf.WriteString("\t@\n")
+	trailer := buf.Len()
+	// Source directories.
+	buf.WriteString("1\n.\n")
+	// Include directories.
+	buf.WriteString("0\n")
+	// Source files.
+	n := 0
+	for _, path := range paths {
+		n += len(path) + 1
+	}
+	fmt.Fprintf(buf, "%d\n%d\n", len(paths), n)
+	for _, path := range paths {
+		fmt.Fprintf(buf, "%s\n", path)
+	}
+	// Update offset of trailer.
+	b := buf.Bytes()
+	copy(b, fmt.Sprintf(headerFormat, dir, trailer))
+	cscopePath := filepath.Join(outputDir, cscopeName)
+	fmt.Println("creating:", cscopePath)
+	if err := ioutil.WriteFile(cscopePath, b, 0644); err != nil {
+		return errors.Wrapf(err, "unable to create cscope database %q", cscopePath)
+	}
+	return nil
+}
+
+// cscopeXref tracks the state of the cross-reference of a generated file.
+type cscopeXref struct {
+	// defs maps from line number to definition tag of the line.
+	defs map[int]*tagEntry
+	// Within block comment.
+	comment bool
+	// Brace nesting depth.
+	depth int
+	// Mark of definitions of identifiers within braces; e.g. members of struct
+	// definition.
+	innerMark byte
+	// Within function body.
+	funcBody bool
+}
+
+// newCscopeXref returns a new cross-reference of the generated file with the
+// given tags.
+func newCscopeXref(tags []*tagEntry) *cscopeXref {
+	xref := &cscopeXref{
+		defs: make(map[int]*tagEntry),
+	}
+	for _, tag := range tags {
+		xref.defs[tag.line] = tag
+	}
+	return xref
+}
+
+// A cscopeToken is a token of a line of source code.
+type cscopeToken struct {
+	// Token text.
+	text string
+	// Identifier.
+	ident bool
+	// Mark of identifier; or 0 if reference.
+	mark byte
+}
+
+// putLine writes the cross-reference of the given line to buf. Lines without
+// symbols are omitted.
+func (xref *cscopeXref) putLine(buf *bytes.Buffer, lineNum int, line string) {
+	tokens := xref.tokenize(line)
+	def := xref.defs[lineNum]
+	// Mark of member definitions within the line.
+	memberMark := byte(0)
+	if xref.depth > 0 {
+		memberMark = xref.innerMark
+	}
+	hasSym := false
+	member := -1
+	for i := range tokens {
+		tok := &tokens[i]
+		if !tok.ident {
+			if member != -1 && strings.ContainsAny(tok.text, ")[;:=,") {
+				tokens[member].mark = memberMark
+				memberMark = 0
+			}
+			xref.trackBraces(tok.text, def)
+			if strings.HasPrefix(strings.TrimSpace(tok.text), "}") && xref.depth == 0 && xref.funcBody {
+				// End of function body.
+				tok.mark = cscopeFuncEnd
+				xref.funcBody = false
+				hasSym = true
+			}
+			continue
+		}
+		hasSym = true
+		switch {
+		case def != nil && tok.text == def.name && tok.mark == 0:
+			tok.mark = cscopeDefMark(def, line)
+			def = nil
+		case memberMark != 0:
+			member = i
+		}
+	}
+	if !hasSym {
+		return
+	}
+	// Line number and text preceding the first symbol.
+	fmt.Fprintf(buf, "%d ", lineNum)
+	for _, tok := range tokens {
+		switch {
+		case tok.ident:
+			buf.WriteString("\n")
+			if tok.mark != 0 {
+				fmt.Fprintf(buf, "\t%c", tok.mark)
+			}
+			fmt.Fprintf(buf, "%s\n", tok.text)
+		case tok.mark == cscopeFuncEnd:
+			fmt.Fprintf(buf, "\n\t%c\n%s", tok.mark, tok.text)
+		default:
+			buf.WriteString(tok.text)
+		}
+	}
+	buf.WriteString("\n\n")
+}
+
+// trackBraces tracks the brace nesting depth of the given non-identifier text,
+// as part of the given definition (optional).
+func (xref *cscopeXref) trackBraces(text string, def *tagEntry) {
+	for _, r := range text {
+		switch r {
+		case '{':
+			if xref.depth == 0 {
+				xref.innerMark = cscopeMemberDef
+				xref.funcBody = false
+				if def != nil && def.kind == tagKindFunc {
+					xref.innerMark = cscopeLocalDef
+					xref.funcBody = true
+				}
+			}
+			xref.depth++
+		case '}':
+			if xref.depth > 0 {
+				xref.depth--
+			}
+		}
+	}
+}
+
+// tokenize splits the given line into identifiers and other text, skipping
+// comments, string literals and keywords. Include directives are marked.
+func (xref *cscopeXref) tokenize(line string) []cscopeToken {
+	if strings.HasPrefix(line, "#include ") {
+		name := strings.TrimPrefix(line, "#include ")
+		if len(name) < 2 {
+			return []cscopeToken{{text: line}}
+		}
+		return []cscopeToken{
+			{text: "#include "},
+			{text: name[:len(name)-1], ident: true, mark: cscopeInclude},
+			{text: name[len(name)-1:]},
+		}
+	}
+	var tokens []cscopeToken
+	text := &strings.Builder{}
+	flush := func() {
+		if text.Len() > 0 {
+			tokens = append(tokens, cscopeToken{text: text.String()})
+			text.Reset()
+		}
+	}
+	for i := 0; i < len(line); {
+		switch {
+		case xref.comment:
+			end := strings.Index(line[i:], "*/")
+			if end == -1 {
+				text.WriteString(line[i:])
+				i = len(line)
+				continue
+			}
+			text.WriteString(line[i : i+end+2])
+			i += end + 2
+			xref.comment = false
+		case strings.HasPrefix(line[i:], "/*"):
+			text.WriteString("/*")
+			i += 2
+			xref.comment = true
+		case strings.HasPrefix(line[i:], "//"):
+			text.WriteString(line[i:])
+			i = len(line)
+		case line[i] == '"':
+			end := strings.IndexByte(line[i+1:], '"')
+			if end == -1 {
+				text.WriteString(line[i:])
+				i = len(line)
+				continue
+			}
+			text.WriteString(line[i : i+1+end+1])
+			i += end + 2
+		case isIdentStart(line[i]):
+			j := i + 1
+			for j < len(line) && isIdentChar(line[j]) {
+				j++
+			}
+			ident := line[i:j]
+			if cKeywords[ident] {
+				text.WriteString(ident)
+			} else {
+				flush()
+				tokens = append(tokens, cscopeToken{text: ident, ident: true})
+			}
+			i = j
+		case isDigit(line[i]):
+			// Skip numeric literals (e.g. 0x80010000).
+			j := i + 1
+			for j < len(line) && isIdentChar(line[j]) {
+				j++
+			}
+			text.WriteString(line[i:j])
+			i = j
+		default:
+			text.WriteByte(line[i])
+			i++
+		}
+	}
+	flush()
+	return tokens
+}
+
+// cscopeDefMark returns the cscope mark of the given definition tag, as
+// located on the given line.
+func cscopeDefMark(def *tagEntry, line string) byte {
+	switch def.kind {
+	case tagKindStruct:
+		return cscopeStructDef
+	case tagKindUnion:
+		return cscopeUnionDef
+	case tagKindEnum:
+		return cscopeEnumDef
+	case tagKindTypedef:
+		return cscopeTypedef
+	case tagKindFunc:
+		if strings.HasSuffix(strings.TrimSpace(line), ";") {
+			// Function prototype.
+			return cscopeGlobalDef
+		}
+		return cscopeFuncDef
+	}
+	return cscopeGlobalDef
+}
+
+// cKeywords is the set of C keywords, which are not recorded as symbols.
+var cKeywords = map[string]bool{
+	"auto": true, "break": true, "case": true, "char": true, "const": true,
+	"continue": true, "default": true, "do": true, "double": true, "else": true,
+	"enum": true, "extern": true, "float": true, "for": true, "goto": true,
+	"if": true, "int": true, "long": true, "register": true, "return": true,
+	"short": true, "signed": true, "sizeof": true, "static": true, "struct": true,
+	"switch": true, "typedef": true, "union": true, "unsigned": true, "void": true,
+	"volatile": true, "while": true, "_Static_assert": true,
+}
+
+// isIdentStart reports whether the given character may start an identifier.
+func isIdentStart(b byte) bool {
+	return b == '_' || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
+
+// isIdentChar reports whether the given character may be part of an
+// identifier.
+func isIdentChar(b byte) bool {
+	return isIdentStart(b) || isDigit(b)
+}
+
+// isDigit reports whether the given character is a decimal digit.
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// CSV file name.
+const csvName = "symbols.csv"
+
+// csvHeader specifies the column names of the CSV symbol table.
+var csvHeader = []string{"address", "kind", "class", "type", "size", "name", "overlay", "source file"}
+
+// dumpCSV outputs the global variables and functions recorded by the parser to
+// a flat CSV symbol table stored in the output directory, sorted by overlay and
+// address.
+func dumpCSV(p *csym.Parser, outputDir string) error {
+	// Create output file.
+	if err := createOutputFile(outputDir, csvName, func(f io.Writer) error {
+		w := csv.NewWriter(f)
+		if err := w.Write(csvHeader); err != nil {
+			return errors.WithStack(err)
+		}
+		overlays := append([]*csym.Overlay{p.Overlay}, p.Overlays...)
+		for _, overlay := range overlays {
+			var rows []csvRow
+			for _, v := range overlay.Vars {
+				row := csvRow{
+					addr:    v.Addr,
+					kind:    "var",
+					class:   v.Class,
+					typ:     v.Type,
+					size:    v.Size,
+					name:    v.Name,
+					overlay: overlay.ID,
+				}
+				rows = append(rows, row)
+			}
+			for _, fn := range overlay.Funcs {
+				row := csvRow{
+					addr:    fn.Addr,
+					kind:    "func",
+					class:   fn.Class,
+					typ:     fn.Type,
+					size:    fn.Size,
+					name:    fn.Name,
+					overlay: overlay.ID,
+					path:    fn.Path,
+				}
+				rows = append(rows, row)
+			}
+			sort.SliceStable(rows, func(i, j int) bool {
+				return rows[i].addr < rows[j].addr
+			})
+			for _, row := range rows {
+				if err := w.Write(row.record()); err != nil {
+					return errors.WithStack(err)
+				}
+			}
+		}
+		w.Flush()
+		return w.Error()
+	}); err != nil {
+		return errors.Wrap(err, "unable to create CSV file")
+	}
+	return nil
+}
+
+// A csvRow is a row of the CSV symbol table.
+type csvRow struct {
+	// Address.
+	addr uint32
+	// Symbol kind; "var" or "func".
+	kind string
+	// Storage class.
+	class c.StorageClass
+	// Type of variable or function.
+	typ c.Type
+	// Size in bytes.
+	size uint32
+	// Symbol name.
+	name string
+	// Overlay ID (0 for the default binary).
+	overlay uint32
+	// Source file.
+	path string
+}
+
+// record returns the CSV record of the row.
+func (row csvRow) record() []string {
+	class := ""
+	if row.class != 0 {
+		class = row.class.String()
+	}
+	return []string{
+		fmt.Sprintf("0x%08X", row.addr),
+		row.kind,
+		class,
+		typeString(row.typ),
+		strconv.FormatUint(uint64(row.size), 10),
+		row.name,
+		fmt.Sprintf("%X", row.overlay),
+		row.path,
+	}
+}
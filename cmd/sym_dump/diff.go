@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym"
+	"github.com/sanctuary/sym/csym"
+)
+
+// diffUsage prints usage information of the diff command.
+func diffUsage(fs *flag.FlagSet) {
+	const use = `
+Compare the types and declarations of two symbol files, reporting added, removed and renamed definitions, moved addresses, and changed types and sizes.
+
+Usage:
+
+	sym_dump diff [OPTION]... OLD NEW
+`
+	fmt.Println(use[1:])
+	fs.PrintDefaults()
+}
+
+// diff compares the symbol files specified by the given command line arguments
+// of the diff command, and prints their differences to standard output.
+func diff(args []string) error {
+	// Command line flags.
+	var (
+		// Input format.
+		from string
+		// Output in JSON format.
+		outputJSON bool
+		// Text encoding of symbol names.
+		encodingName string
+		// Byte order of SYM files.
+		endian string
+	)
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	fs.StringVar(&from, "from", "", "input format; detected if not specified")
+	fs.BoolVar(&outputJSON, "json", false, "output in JSON format")
+	fs.StringVar(&encodingName, "encoding", "", "text encoding of symbol names (sjis, or auto to detect)")
+	fs.StringVar(&endian, "endian", "", "byte order of SYM files (big or little); detected if not specified")
+	fs.Usage = func() { diffUsage(fs) }
+	if err := fs.Parse(args); err != nil {
+		return errors.WithStack(err)
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if len(from) > 0 && !isInputFormat(from) {
+		return errors.Errorf("invalid input format %q", from)
+	}
+	enc, err := parseEncoding(encodingName)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	order, err := parseByteOrder(endian)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var ps []*csym.Parser
+	for _, path := range fs.Args() {
+		opts := &sym.ParseOptions{
+			Encoding:       enc,
+			Order:          order,
+			DetectEncoding: strings.EqualFold(encodingName, "auto"),
+		}
+		f, err := parseFile(path, from, opts)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		ps = append(ps, parseSyms(f))
+	}
+	changes := csym.Diff(ps[0], ps[1])
+	if outputJSON {
+		if changes == nil {
+			changes = []*csym.Change{}
+		}
+		buf, err := json.MarshalIndent(changes, "", "\t")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		fmt.Println(string(buf))
+		return nil
+	}
+	for _, change := range changes {
+		fmt.Println(change)
+		if change.Kind == csym.ChangeType {
+			// Print old and new definitions in unified diff style.
+			printDef("-", change.Def)
+			printDef("+", change.NewDef)
+		}
+	}
+	return nil
+}
+
+// printDef prints the given C syntax representation of a definition, with each
+// line indented and prefixed by the given marker.
+func printDef(marker, def string) {
+	for _, line := range strings.Split(strings.TrimSuffix(def, "\n"), "\n") {
+		fmt.Printf("\t%s %s\n", marker, line)
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// Graphviz DOT file name.
+const dotName = "types.dot"
+
+// dumpDOT outputs the type dependency graph of the type information recorded by
+// the parser to a Graphviz DOT file stored in the output directory. Nodes are
+// structs, unions, enums and type definitions, and edges are references of
+// struct and union fields (labelled by field name) and of the underlying types
+// of type definitions. References through pointers are drawn as dashed edges.
+//
+// If root is non-empty, the graph is limited to the types reachable from the
+// root type, as specified by struct, union or enum tag, or by type definition
+// name.
+func dumpDOT(p *csym.Parser, outputDir, root string) error {
+	g := newTypeGraph(p)
+	nodes := g.nodes
+	if len(root) > 0 {
+		t, ok := g.lookup(root)
+		if !ok {
+			return errors.Errorf("unable to locate root type %q of type graph", root)
+		}
+		nodes = g.reachable(t)
+	}
+	buf := &strings.Builder{}
+	buf.WriteString("digraph types {\n")
+	buf.WriteString("\tnode [shape=box];\n")
+	ids := make(map[c.Type]int)
+	for i, t := range nodes {
+		ids[t] = i
+		fmt.Fprintf(buf, "\tt%d [label=%s];\n", i, strconv.Quote(nodeLabel(t)))
+	}
+	for _, t := range nodes {
+		for _, ref := range g.refs[t] {
+			to, ok := ids[ref.to]
+			if !ok {
+				continue
+			}
+			var attrs []string
+			if len(ref.label) > 0 {
+				attrs = append(attrs, "label="+strconv.Quote(ref.label))
+			}
+			if ref.indirect {
+				attrs = append(attrs, "style=dashed")
+			}
+			fmt.Fprintf(buf, "\tt%d -> t%d", ids[t], to)
+			if len(attrs) > 0 {
+				fmt.Fprintf(buf, " [%s]", strings.Join(attrs, ", "))
+			}
+			buf.WriteString(";\n")
+		}
+	}
+	buf.WriteString("}\n")
+	// Create output file.
+	if err := createOutputFile(outputDir, dotName, func(w io.Writer) error {
+		_, err := io.WriteString(w, buf.String())
+		return err
+	}); err != nil {
+		return errors.Wrap(err, "unable to create DOT file")
+	}
+	return nil
+}
+
+// typeGraph is a type dependency graph.
+type typeGraph struct {
+	// Nodes; structs, unions, enums and type definitions in order of
+	// occurrence.
+	nodes []c.Type
+	// refs maps from node to outgoing edges.
+	refs map[c.Type][]typeRef
+	// names maps from struct, union or enum tag or type definition name to
+	// node.
+	names map[string]c.Type
+}
+
+// typeRef is an edge of a type dependency graph.
+type typeRef struct {
+	// Referenced type.
+	to c.Type
+	// Edge label (field name); or empty if reference of type definition.
+	label string
+	// Reference through pointer.
+	indirect bool
+}
+
+// newTypeGraph returns the type dependency graph of the type information
+// recorded by the parser.
+func newTypeGraph(p *csym.Parser) *typeGraph {
+	g := &typeGraph{
+		refs:  make(map[c.Type][]typeRef),
+		names: make(map[string]c.Type),
+	}
+	addFields := func(t c.Type, fields []c.Field) {
+		for _, field := range fields {
+			for _, ref := range typeRefs(field.Type, false) {
+				ref.label = field.Name
+				g.refs[t] = append(g.refs[t], ref)
+			}
+		}
+	}
+	for _, tag := range p.StructTags {
+		t := p.Structs[tag]
+		g.add(t, tag)
+		addFields(t, t.Fields)
+	}
+	for _, tag := range p.UnionTags {
+		t := p.Unions[tag]
+		g.add(t, tag)
+		addFields(t, t.Fields)
+	}
+	for _, tag := range p.EnumTags {
+		g.add(p.Enums[tag], tag)
+	}
+	for _, def := range p.Typedefs {
+		v, ok := def.(*c.VarDecl)
+		if !ok {
+			continue
+		}
+		g.add(v, v.Name)
+		g.refs[v] = typeRefs(v.Type, false)
+	}
+	return g
+}
+
+// add adds the given node of the given name to the type graph.
+func (g *typeGraph) add(t c.Type, name string) {
+	g.nodes = append(g.nodes, t)
+	if _, ok := g.names[name]; !ok {
+		g.names[name] = t
+	}
+}
+
+// lookup returns the node of the given struct, union or enum tag or type
+// definition name.
+func (g *typeGraph) lookup(name string) (c.Type, bool) {
+	t, ok := g.names[name]
+	return t, ok
+}
+
+// reachable returns the nodes reachable from the given root node, in order of
+// occurrence.
+func (g *typeGraph) reachable(root c.Type) []c.Type {
+	visited := map[c.Type]bool{root: true}
+	queue := []c.Type{root}
+	for len(queue) > 0 {
+		t := queue[0]
+		queue = queue[1:]
+		for _, ref := range g.refs[t] {
+			if !visited[ref.to] {
+				visited[ref.to] = true
+				queue = append(queue, ref.to)
+			}
+		}
+	}
+	var nodes []c.Type
+	for _, t := range g.nodes {
+		if visited[t] {
+			nodes = append(nodes, t)
+		}
+	}
+	return nodes
+}
+
+// typeRefs returns the structs, unions, enums and type definitions referenced
+// by the given type, either directly or through derived types.
+func typeRefs(t c.Type, indirect bool) []typeRef {
+	switch t := t.(type) {
+	case *c.StructType, *c.UnionType, *c.EnumType:
+		return []typeRef{{to: t, indirect: indirect}}
+	case *c.VarDecl:
+		// Type definition.
+		return []typeRef{{to: t, indirect: indirect}}
+	case *c.PointerType:
+		return typeRefs(t.Elem, true)
+	case *c.ArrayType:
+		return typeRefs(t.Elem, indirect)
+	case *c.QualType:
+		return typeRefs(t.Type, indirect)
+	case *c.FuncType:
+		refs := typeRefs(t.RetType, true)
+		for _, param := range t.Params {
+			refs = append(refs, typeRefs(param.Type, true)...)
+		}
+		return refs
+	}
+	return nil
+}
+
+// nodeLabel returns the label of the given node of a type graph.
+func nodeLabel(t c.Type) string {
+	switch t := t.(type) {
+	case *c.StructType:
+		return fmt.Sprintf("struct %s\nsize 0x%X", t.Tag, t.Size)
+	case *c.UnionType:
+		return fmt.Sprintf("union %s\nsize 0x%X", t.Tag, t.Size)
+	case *c.EnumType:
+		return fmt.Sprintf("enum %s", t.Tag)
+	case *c.VarDecl:
+		return fmt.Sprintf("typedef %s", t.Name)
+	}
+	return t.String()
+}
@@ -0,0 +1,756 @@
+package main
+
+import (
+	"bytes"
+	"debug/dwarf"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// ELF file name with DWARF debug information.
+const dwarfName = "debug.elf"
+
+// dumpDWARF outputs the declarations and type information recorded by the
+// parser to ELF files with DWARF debug information stored in the output
+// directory; one for the default binary, and one for each overlay (stored in
+// overlay_N).
+func dumpDWARF(p *csym.Parser, outputDir string) error {
+	// Create ELF file of default binary.
+	if err := dumpDWARFOverlay(p, p.Overlay, outputDir); err != nil {
+		return errors.WithStack(err)
+	}
+	// Create ELF files of overlays.
+	for _, overlay := range p.Overlays {
+		if err := dumpDWARFOverlay(p, overlay, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// dumpDWARFOverlay outputs the type information recorded by the parser and the
+// declarations of the overlay to an ELF file with DWARF debug information.
+//
+// The ELF file contains the symbol table of the overlay (as output by -elf),
+// and DWARF version 2 debug information of a single compile unit, comprising:
+//
+//   - the structs, unions, enums and type definitions of the SYM file;
+//   - global variables;
+//   - functions, with their parameters and the local variables of all their
+//     blocks (block scopes have no address ranges in SYM files); and
+//   - the line table of the overlay.
+//
+// Locations of parameters and local variables on the stack are relative to the
+// frame base, which is taken to be the stack pointer ($sp), the frame pointer
+// register of most functions compiled by the PsyQ SDK.
+func dumpDWARFOverlay(p *csym.Parser, overlay *csym.Overlay, outputDir string) error {
+	dir := outputDir
+	name := "symbols"
+	if overlay.ID != 0 {
+		name = fmt.Sprintf("overlay_%x", overlay.ID)
+		dir = filepath.Join(outputDir, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	sects := newDWARFBuilder(p, overlay, name).sections()
+	if err := createOutputFile(dir, dwarfName, func(w io.Writer) error {
+		_, err := w.Write(symbolsELF(overlay, sects))
+		return err
+	}); err != nil {
+		return errors.Wrap(err, "unable to create ELF file")
+	}
+	return nil
+}
+
+// DWARF attribute forms.
+const (
+	dwFormAddr   = 0x01
+	dwFormData4  = 0x06
+	dwFormString = 0x08
+	dwFormBlock1 = 0x0A
+	dwFormFlag   = 0x0C
+	dwFormSdata  = 0x0D
+	dwFormUdata  = 0x0F
+	dwFormRef4   = 0x13
+)
+
+// DWARF base type encodings.
+const (
+	dwATEBoolean      = 0x02
+	dwATEFloat        = 0x04
+	dwATESigned       = 0x05
+	dwATESignedChar   = 0x06
+	dwATEUnsigned     = 0x07
+	dwATEUnsignedChar = 0x08
+)
+
+// DWARF location operations.
+const (
+	dwOpAddr       = 0x03
+	dwOpPlusUconst = 0x23
+	dwOpReg0       = 0x50
+	dwOpBreg0      = 0x70
+	dwOpFbreg      = 0x91
+)
+
+// DWARF line number operations.
+const (
+	dwLNSCopy        = 0x01
+	dwLNSAdvancePC   = 0x02
+	dwLNSAdvanceLine = 0x03
+	dwLNSSetFile     = 0x04
+	dwLNEEndSequence = 0x01
+	dwLNESetAddress  = 0x02
+)
+
+// DWARF language of the compile unit (ANSI C).
+const dwLangC89 = 0x0001
+
+// Stack pointer register of MIPS.
+const mipsSP = 29
+
+// --- [ Debugging information entries ] ---------------------------------------
+
+// A dwarfDIE is a debugging information entry.
+type dwarfDIE struct {
+	// Tag.
+	tag dwarf.Tag
+	// Attributes.
+	attrs []dwarfAttr
+	// Child entries.
+	children []*dwarfDIE
+	// Offset relative to the start of the compile unit; set by layout.
+	offset uint32
+	// Abbreviation code; set by layout.
+	code uint64
+}
+
+// A dwarfAttr is an attribute of a debugging information entry.
+type dwarfAttr struct {
+	// Attribute.
+	attr dwarf.Attr
+	// Attribute form.
+	form uint64
+	// Attribute value; uint32 (address, data4), bool (flag), int64 (sdata),
+	// uint64 (udata), string (string), []byte (block1) or *dwarfDIE (ref4).
+	val interface{}
+}
+
+// newDIE returns a new debugging information entry of the given tag.
+func newDIE(tag dwarf.Tag) *dwarfDIE {
+	return &dwarfDIE{tag: tag}
+}
+
+// add adds the attribute of the given form and value to the entry.
+func (d *dwarfDIE) add(attr dwarf.Attr, form uint64, val interface{}) {
+	d.attrs = append(d.attrs, dwarfAttr{attr: attr, form: form, val: val})
+}
+
+// addChild adds the given child entry to the entry.
+func (d *dwarfDIE) addChild(child *dwarfDIE) {
+	d.children = append(d.children, child)
+}
+
+// abbrevKey returns the abbreviation declaration key of the entry.
+func (d *dwarfDIE) abbrevKey() string {
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "%d:%t", d.tag, len(d.children) > 0)
+	for _, a := range d.attrs {
+		fmt.Fprintf(buf, ":%d/%d", a.attr, a.form)
+	}
+	return buf.String()
+}
+
+// size returns the size in bytes of the encoded attribute value.
+func (a dwarfAttr) size() uint32 {
+	switch val := a.val.(type) {
+	case uint32, *dwarfDIE:
+		return 4
+	case bool:
+		return 1
+	case int64:
+		return uint32(len(appendSleb(nil, val)))
+	case uint64:
+		return uint32(len(appendUleb(nil, val)))
+	case string:
+		return uint32(len(val)) + 1
+	case []byte:
+		return 1 + uint32(len(val))
+	}
+	panic(fmt.Errorf("support for attribute value %T not yet implemented", a.val))
+}
+
+// append appends the encoded attribute value to b.
+func (a dwarfAttr) append(b []byte) []byte {
+	switch val := a.val.(type) {
+	case uint32:
+		return binary.LittleEndian.AppendUint32(b, val)
+	case *dwarfDIE:
+		return binary.LittleEndian.AppendUint32(b, val.offset)
+	case bool:
+		if val {
+			return append(b, 1)
+		}
+		return append(b, 0)
+	case int64:
+		return appendSleb(b, val)
+	case uint64:
+		return appendUleb(b, val)
+	case string:
+		b = append(b, val...)
+		return append(b, 0)
+	case []byte:
+		b = append(b, byte(len(val)))
+		return append(b, val...)
+	}
+	panic(fmt.Errorf("support for attribute value %T not yet implemented", a.val))
+}
+
+// --- [ Builder ] -------------------------------------------------------------
+
+// dwarfBuilder builds the DWARF debug information of an overlay.
+type dwarfBuilder struct {
+	// Overlay.
+	overlay *csym.Overlay
+	// Compile unit.
+	cu *dwarfDIE
+	// types maps from type to debugging information entry.
+	types map[c.Type]*dwarfDIE
+	// files maps from source file path to index in the file table of the line
+	// table (1-based).
+	files map[string]uint64
+	// Source file paths in order of index.
+	paths []string
+}
+
+// newDWARFBuilder returns the DWARF debug information of the type information
+// recorded by the parser and the declarations of the overlay, as a compile
+// unit of the given name.
+func newDWARFBuilder(p *csym.Parser, overlay *csym.Overlay, name string) *dwarfBuilder {
+	b := &dwarfBuilder{
+		overlay: overlay,
+		cu:      newDIE(dwarf.TagCompileUnit),
+		types:   make(map[c.Type]*dwarfDIE),
+		files:   make(map[string]uint64),
+	}
+	var text elfRange
+	for _, f := range overlay.Funcs {
+		if f.Addr != 0 {
+			text.add(f.Addr, f.Size)
+		}
+	}
+	b.cu.add(dwarf.AttrName, dwFormString, name)
+	b.cu.add(dwarf.AttrProducer, dwFormString, "sym_dump")
+	b.cu.add(dwarf.AttrLanguage, dwFormUdata, uint64(dwLangC89))
+	b.cu.add(dwarf.AttrLowpc, dwFormAddr, text.start)
+	b.cu.add(dwarf.AttrHighpc, dwFormAddr, text.end)
+	b.cu.add(dwarf.AttrStmtList, dwFormData4, uint32(0))
+	// Types.
+	for _, tag := range p.EnumTags {
+		b.typeDIE(p.Enums[tag])
+	}
+	for _, tag := range p.StructTags {
+		b.typeDIE(p.Structs[tag])
+	}
+	for _, tag := range p.UnionTags {
+		b.typeDIE(p.Unions[tag])
+	}
+	for _, def := range p.Typedefs {
+		b.typeDIE(def)
+	}
+	// Declarations.
+	for _, v := range overlay.Vars {
+		b.cu.addChild(b.varDIE(v))
+	}
+	for _, f := range overlay.Funcs {
+		b.cu.addChild(b.funcDIE(f))
+	}
+	return b
+}
+
+// file returns the index of the given source file in the file table of the
+// line table.
+func (b *dwarfBuilder) file(path string) uint64 {
+	if index, ok := b.files[path]; ok {
+		return index
+	}
+	b.paths = append(b.paths, path)
+	index := uint64(len(b.paths))
+	b.files[path] = index
+	return index
+}
+
+// addType adds the type attribute of the given type to the entry, unless void.
+func (b *dwarfBuilder) addType(d *dwarfDIE, t c.Type) {
+	if die := b.typeDIE(t); die != nil {
+		d.add(dwarf.AttrType, dwFormRef4, die)
+	}
+}
+
+// typeDIE returns the debugging information entry of the given type, adding
+// it to the compile unit if not yet present; or nil if void.
+func (b *dwarfBuilder) typeDIE(t c.Type) *dwarfDIE {
+	if die, ok := b.types[t]; ok {
+		return die
+	}
+	var die *dwarfDIE
+	switch t := t.(type) {
+	case c.BaseType:
+		if t == c.Void {
+			return nil
+		}
+		die = newDIE(dwarf.TagBaseType)
+		die.add(dwarf.AttrName, dwFormString, t.String())
+		die.add(dwarf.AttrEncoding, dwFormUdata, uint64(dwarfEncoding(t)))
+		die.add(dwarf.AttrByteSize, dwFormUdata, uint64(c.Sizeof(t)))
+	case *c.StructType:
+		die = newDIE(dwarf.TagStructType)
+		// Register before fields, to resolve recursive types.
+		b.types[t] = die
+		b.composite(die, t.Tag, t.Size, t.Fields, false)
+	case *c.UnionType:
+		die = newDIE(dwarf.TagUnionType)
+		b.types[t] = die
+		b.composite(die, t.Tag, t.Size, t.Fields, true)
+	case *c.EnumType:
+		die = newDIE(dwarf.TagEnumerationType)
+		if !c.IsFakeTag(t.Tag) {
+			die.add(dwarf.AttrName, dwFormString, t.Tag)
+		}
+		die.add(dwarf.AttrByteSize, dwFormUdata, uint64(c.Sizeof(t)))
+		for _, member := range t.Members {
+			value := int64(member.Value)
+			if t.Signed {
+				value = int64(int32(member.Value))
+			}
+			enumerator := newDIE(dwarf.TagEnumerator)
+			enumerator.add(dwarf.AttrName, dwFormString, member.Name)
+			enumerator.add(dwarf.AttrConstValue, dwFormSdata, value)
+			die.addChild(enumerator)
+		}
+	case *c.VarDecl:
+		// Type definition.
+		die = newDIE(dwarf.TagTypedef)
+		b.types[t] = die
+		die.add(dwarf.AttrName, dwFormString, t.Name)
+		b.addType(die, t.Type)
+	case *c.PointerType:
+		die = newDIE(dwarf.TagPointerType)
+		b.types[t] = die
+		die.add(dwarf.AttrByteSize, dwFormUdata, uint64(c.Sizeof(t)))
+		b.addType(die, t.Elem)
+	case *c.ArrayType:
+		die = newDIE(dwarf.TagArrayType)
+		b.addType(die, t.Elem)
+		subrange := newDIE(dwarf.TagSubrangeType)
+		if t.Len > 0 {
+			subrange.add(dwarf.AttrUpperBound, dwFormUdata, uint64(t.Len-1))
+		}
+		die.addChild(subrange)
+	case *c.QualType:
+		// Volatile qualifier wraps the underlying type, and const qualifier
+		// wraps the volatile qualified type.
+		elem := b.typeDIE(t.Type)
+		if t.Quals&c.Volatile != 0 {
+			die = newDIE(dwarf.TagVolatileType)
+			if elem != nil {
+				die.add(dwarf.AttrType, dwFormRef4, elem)
+			}
+			if t.Quals&c.Const == 0 {
+				break
+			}
+			b.cu.addChild(die)
+			elem = die
+		}
+		die = newDIE(dwarf.TagConstType)
+		if elem != nil {
+			die.add(dwarf.AttrType, dwFormRef4, elem)
+		}
+	case *c.FuncType:
+		die = newDIE(dwarf.TagSubroutineType)
+		b.types[t] = die
+		die.add(dwarf.AttrPrototyped, dwFormFlag, true)
+		b.addType(die, t.RetType)
+		for _, param := range t.Params {
+			p := newDIE(dwarf.TagFormalParameter)
+			b.addType(p, param.Type)
+			die.addChild(p)
+		}
+		if t.Variadic {
+			die.addChild(newDIE(dwarf.TagUnspecifiedParameters))
+		}
+	default:
+		panic(fmt.Errorf("support for type %T not yet implemented", t))
+	}
+	b.types[t] = die
+	b.cu.addChild(die)
+	return die
+}
+
+// composite adds the attributes and members of the struct or union type with
+// the given tag, size and fields to the entry.
+func (b *dwarfBuilder) composite(die *dwarfDIE, tag string, size uint32, fields []c.Field, union bool) {
+	if !c.IsFakeTag(tag) {
+		die.add(dwarf.AttrName, dwFormString, tag)
+	}
+	if size == 0 && len(fields) == 0 {
+		// Forward declaration.
+		die.add(dwarf.AttrDeclaration, dwFormFlag, true)
+		return
+	}
+	die.add(dwarf.AttrByteSize, dwFormUdata, uint64(size))
+	for _, field := range fields {
+		member := newDIE(dwarf.TagMember)
+		if len(field.Name) > 0 {
+			member.add(dwarf.AttrName, dwFormString, field.Name)
+		}
+		b.addType(member, field.Type)
+		offset := field.Offset
+		if union {
+			offset = 0
+		}
+		if field.BitWidth > 0 {
+			// Bitfields are located relative to their storage unit of the size
+			// of the underlying type; bit offsets are counted from the most
+			// significant bit of the storage unit, as per DWARF version 2.
+			unit := c.Sizeof(field.Type)
+			if unit == 0 {
+				unit = 4
+			}
+			unitOffset := offset &^ (unit - 1)
+			bitPos := (offset-unitOffset)*8 + field.BitOffset
+			member.add(dwarf.AttrByteSize, dwFormUdata, uint64(unit))
+			member.add(dwarf.AttrBitSize, dwFormUdata, uint64(field.BitWidth))
+			member.add(dwarf.AttrBitOffset, dwFormUdata, uint64(unit*8-bitPos-field.BitWidth))
+			offset = unitOffset
+		}
+		loc := appendUleb([]byte{dwOpPlusUconst}, uint64(offset))
+		member.add(dwarf.AttrDataMemberLoc, dwFormBlock1, loc)
+		die.addChild(member)
+	}
+}
+
+// varDIE returns the debugging information entry of the given global, local or
+// parameter variable declaration.
+func (b *dwarfBuilder) varDIE(v *c.VarDecl) *dwarfDIE {
+	die := newDIE(dwarf.TagVariable)
+	die.add(dwarf.AttrName, dwFormString, v.Name)
+	b.addType(die, v.Type)
+	b.addLocation(die, v)
+	return die
+}
+
+// addLocation adds the location attribute of the given variable declaration to
+// the entry; or marks the entry as a declaration if the variable is external.
+func (b *dwarfBuilder) addLocation(die *dwarfDIE, v *c.VarDecl) {
+	var loc []byte
+	switch v.Class {
+	case c.Extern:
+		if die.tag == dwarf.TagVariable && v.Addr == 0 {
+			// Declaration of external variable.
+			die.add(dwarf.AttrExternal, dwFormFlag, true)
+			die.add(dwarf.AttrDeclaration, dwFormFlag, true)
+			return
+		}
+		die.add(dwarf.AttrExternal, dwFormFlag, true)
+		loc = binary.LittleEndian.AppendUint32([]byte{dwOpAddr}, v.Addr)
+	case c.Static:
+		loc = binary.LittleEndian.AppendUint32([]byte{dwOpAddr}, v.Addr)
+	case c.Register:
+		if v.Addr >= 32 {
+			return
+		}
+		loc = []byte{dwOpReg0 + byte(v.Addr)}
+	case c.Typedef:
+		return
+	default:
+		// Auto variables and stack parameters; frame base relative.
+		loc = appendSleb([]byte{dwOpFbreg}, int64(int32(v.Addr)))
+	}
+	die.add(dwarf.AttrLocation, dwFormBlock1, loc)
+}
+
+// funcDIE returns the debugging information entry of the given function
+// declaration.
+func (b *dwarfBuilder) funcDIE(f *c.FuncDecl) *dwarfDIE {
+	die := newDIE(dwarf.TagSubprogram)
+	die.add(dwarf.AttrName, dwFormString, f.Name)
+	if f.Class != c.Static {
+		die.add(dwarf.AttrExternal, dwFormFlag, true)
+	}
+	if len(f.Path) > 0 {
+		die.add(dwarf.AttrDeclFile, dwFormUdata, b.file(f.Path))
+		die.add(dwarf.AttrDeclLine, dwFormUdata, uint64(f.LineStart))
+	}
+	die.add(dwarf.AttrPrototyped, dwFormFlag, true)
+	t, ok := f.Type.(*c.FuncType)
+	if ok {
+		b.addType(die, t.RetType)
+	}
+	die.add(dwarf.AttrLowpc, dwFormAddr, f.Addr)
+	die.add(dwarf.AttrHighpc, dwFormAddr, f.Addr+f.Size)
+	die.add(dwarf.AttrFrameBase, dwFormBlock1, []byte{dwOpBreg0 + mipsSP, 0})
+	if ok {
+		for _, param := range t.Params {
+			p := newDIE(dwarf.TagFormalParameter)
+			p.add(dwarf.AttrName, dwFormString, param.Name)
+			b.addType(p, param.Type)
+			b.addLocation(p, param)
+			die.addChild(p)
+		}
+	}
+	for _, block := range f.Blocks {
+		for _, local := range block.Locals {
+			if local.Class == c.Typedef {
+				continue
+			}
+			die.addChild(b.varDIE(local))
+		}
+	}
+	return die
+}
+
+// --- [ Sections ] ------------------------------------------------------------
+
+// sections returns the DWARF debug sections of the compile unit.
+func (b *dwarfBuilder) sections() []elfSection {
+	// Source files of the line table, before encoding the compile unit.
+	for _, line := range b.overlay.Lines {
+		b.file(line.Path)
+	}
+	info, abbrev := b.info()
+	return []elfSection{
+		{name: ".debug_abbrev", data: abbrev},
+		{name: ".debug_info", data: info},
+		{name: ".debug_line", data: b.line()},
+		{name: ".debug_aranges", data: b.aranges()},
+	}
+}
+
+// info returns the contents of the .debug_info and .debug_abbrev sections of
+// the compile unit.
+func (b *dwarfBuilder) info() (info, abbrev []byte) {
+	// Assign abbreviation codes.
+	codes := make(map[string]uint64)
+	var assign func(d *dwarfDIE)
+	assign = func(d *dwarfDIE) {
+		key := d.abbrevKey()
+		code, ok := codes[key]
+		if !ok {
+			code = uint64(len(codes) + 1)
+			codes[key] = code
+			abbrev = appendUleb(abbrev, code)
+			abbrev = appendUleb(abbrev, uint64(d.tag))
+			if len(d.children) > 0 {
+				abbrev = append(abbrev, 1)
+			} else {
+				abbrev = append(abbrev, 0)
+			}
+			for _, a := range d.attrs {
+				abbrev = appendUleb(abbrev, uint64(a.attr))
+				abbrev = appendUleb(abbrev, a.form)
+			}
+			abbrev = append(abbrev, 0, 0)
+		}
+		d.code = code
+		for _, child := range d.children {
+			assign(child)
+		}
+	}
+	assign(b.cu)
+	abbrev = append(abbrev, 0)
+	// Compute offsets; the compile unit header is 11 bytes.
+	const cuHeaderSize = 11
+	var layout func(d *dwarfDIE, off uint32) uint32
+	layout = func(d *dwarfDIE, off uint32) uint32 {
+		d.offset = off
+		off += uint32(len(appendUleb(nil, d.code)))
+		for _, a := range d.attrs {
+			off += a.size()
+		}
+		if len(d.children) > 0 {
+			for _, child := range d.children {
+				off = layout(child, off)
+			}
+			// Null entry terminating the children.
+			off++
+		}
+		return off
+	}
+	end := layout(b.cu, cuHeaderSize)
+	// Encode compile unit.
+	var encode func(buf []byte, d *dwarfDIE) []byte
+	encode = func(buf []byte, d *dwarfDIE) []byte {
+		buf = appendUleb(buf, d.code)
+		for _, a := range d.attrs {
+			buf = a.append(buf)
+		}
+		if len(d.children) > 0 {
+			for _, child := range d.children {
+				buf = encode(buf, child)
+			}
+			buf = append(buf, 0)
+		}
+		return buf
+	}
+	order := binary.LittleEndian
+	info = order.AppendUint32(info, end-4)
+	// DWARF version.
+	info = order.AppendUint16(info, 2)
+	// Offset of abbreviations.
+	info = order.AppendUint32(info, 0)
+	// Address size.
+	info = append(info, 4)
+	info = encode(info, b.cu)
+	return info, abbrev
+}
+
+// line returns the contents of the .debug_line section of the compile unit; a
+// single sequence of the line numbers of the overlay, sorted by address.
+func (b *dwarfBuilder) line() []byte {
+	lines := make([]*csym.Line, len(b.overlay.Lines))
+	copy(lines, b.overlay.Lines)
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Addr < lines[j].Addr
+	})
+	// Header, following the header length field.
+	const (
+		lineBase   = -5
+		lineRange  = 14
+		opcodeBase = 10
+	)
+	var hdr []byte
+	// Minimum instruction length.
+	hdr = append(hdr, 1)
+	// Default is_stmt.
+	hdr = append(hdr, 1)
+	hdr = append(hdr, byte(256+lineBase), lineRange, opcodeBase)
+	// Number of operands of standard opcodes.
+	hdr = append(hdr, 0, 1, 1, 1, 1, 0, 0, 0, 1)
+	// Include directories.
+	hdr = append(hdr, 0)
+	// File names.
+	for _, path := range b.paths {
+		hdr = append(hdr, path...)
+		// Directory index, modification time and length.
+		hdr = append(hdr, 0, 0, 0, 0)
+	}
+	hdr = append(hdr, 0)
+	// Line number program.
+	var prog []byte
+	if len(lines) > 0 {
+		prog = append(prog, 0, 5, dwLNESetAddress)
+		prog = binary.LittleEndian.AppendUint32(prog, lines[0].Addr)
+		addr, line, file := lines[0].Addr, int64(1), uint64(1)
+		for _, l := range lines {
+			if index := b.file(l.Path); index != file {
+				prog = append(prog, dwLNSSetFile)
+				prog = appendUleb(prog, index)
+				file = index
+			}
+			if delta := int64(l.Line) - line; delta != 0 {
+				prog = append(prog, dwLNSAdvanceLine)
+				prog = appendSleb(prog, delta)
+				line = int64(l.Line)
+			}
+			if delta := l.Addr - addr; delta != 0 {
+				prog = append(prog, dwLNSAdvancePC)
+				prog = appendUleb(prog, uint64(delta))
+				addr = l.Addr
+			}
+			prog = append(prog, dwLNSCopy)
+		}
+		// End sequence after the last instruction.
+		prog = append(prog, dwLNSAdvancePC)
+		prog = appendUleb(prog, 4)
+		prog = append(prog, 0, 1, dwLNEEndSequence)
+	}
+	order := binary.LittleEndian
+	var buf []byte
+	// Unit length, version and header length.
+	buf = order.AppendUint32(buf, uint32(2+4+len(hdr)+len(prog)))
+	buf = order.AppendUint16(buf, 2)
+	buf = order.AppendUint32(buf, uint32(len(hdr)))
+	buf = append(buf, hdr...)
+	buf = append(buf, prog...)
+	return buf
+}
+
+// aranges returns the contents of the .debug_aranges section of the compile
+// unit, mapping the address ranges of functions to the compile unit.
+func (b *dwarfBuilder) aranges() []byte {
+	order := binary.LittleEndian
+	body := &bytes.Buffer{}
+	// Version, offset of compile unit, address size and segment size.
+	binary.Write(body, order, uint16(2))
+	binary.Write(body, order, uint32(0))
+	body.WriteByte(4)
+	body.WriteByte(0)
+	// Tuples are aligned to twice the address size, relative to the section
+	// start (including the unit length).
+	pad(body, 16-4)
+	for _, f := range b.overlay.Funcs {
+		if f.Size == 0 {
+			continue
+		}
+		binary.Write(body, order, f.Addr)
+		binary.Write(body, order, f.Size)
+	}
+	binary.Write(body, order, uint64(0))
+	var buf []byte
+	buf = order.AppendUint32(buf, uint32(body.Len()))
+	return append(buf, body.Bytes()...)
+}
+
+// ### [ Helper functions ] ####################################################
+
+// dwarfEncoding returns the DWARF encoding of the given base type.
+func dwarfEncoding(t c.BaseType) int {
+	switch t {
+	case c.Char, c.SChar:
+		return dwATESignedChar
+	case c.UChar:
+		return dwATEUnsignedChar
+	case c.UShort, c.UInt, c.ULong, c.ULongLong:
+		return dwATEUnsigned
+	case c.Float, c.Double, c.LongDouble:
+		return dwATEFloat
+	case c.Bool:
+		return dwATEBoolean
+	}
+	return dwATESigned
+}
+
+// appendUleb appends the unsigned LEB128 encoding of v to b.
+func appendUleb(b []byte, v uint64) []byte {
+	for {
+		x := byte(v & 0x7F)
+		v >>= 7
+		if v == 0 {
+			return append(b, x)
+		}
+		b = append(b, x|0x80)
+	}
+}
+
+// appendSleb appends the signed LEB128 encoding of v to b.
+func appendSleb(b []byte, v int64) []byte {
+	for {
+		x := byte(v & 0x7F)
+		v >>= 7
+		if (v == 0 && x&0x40 == 0) || (v == -1 && x&0x40 != 0) {
+			return append(b, x)
+		}
+		b = append(b, x|0x80)
+	}
+}
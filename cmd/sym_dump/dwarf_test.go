@@ -0,0 +1,245 @@
+package main
+
+import (
+	"debug/dwarf"
+	"debug/elf"
+	"path/filepath"
+	"testing"
+)
+
+func TestDumpDWARF(t *testing.T) {
+	p := parseTestFile(t)
+	dir := t.TempDir()
+	if err := dumpDWARF(p, dir); err != nil {
+		t.Fatalf("unable to output DWARF files; %+v", err)
+	}
+	f, err := elf.Open(filepath.Join(dir, dwarfName))
+	if err != nil {
+		t.Fatalf("unable to open ELF file; %v", err)
+	}
+	defer f.Close()
+	d, err := f.DWARF()
+	if err != nil {
+		t.Fatalf("unable to read DWARF debug information; %v", err)
+	}
+	// Index top-level entries of the compile unit by tag and name.
+	r := d.Reader()
+	cu, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cu == nil || cu.Tag != dwarf.TagCompileUnit {
+		t.Fatalf("expected compile unit entry, got %v", cu)
+	}
+	if got, want := cu.Val(dwarf.AttrName), "symbols"; got != want {
+		t.Errorf("compile unit name mismatch; expected %q, got %v", want, got)
+	}
+	// Functions without address do not extend the address range.
+	if got, want := cu.Val(dwarf.AttrLowpc), uint64(0x80010100); got != want {
+		t.Errorf("low PC of compile unit mismatch; expected 0x%08X, got %v", want, got)
+	}
+	type key struct {
+		tag  dwarf.Tag
+		name string
+	}
+	entries := make(map[key]*dwarf.Entry)
+	for {
+		e, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e == nil {
+			break
+		}
+		if e.Tag == 0 {
+			// End of compile unit.
+			continue
+		}
+		if name, ok := e.Val(dwarf.AttrName).(string); ok {
+			entries[key{tag: e.Tag, name: name}] = e
+		}
+		r.SkipChildren()
+	}
+
+	// Struct type with bitfields and an anonymous struct member.
+	point, ok := entries[key{tag: dwarf.TagStructType, name: "point"}]
+	if !ok {
+		t.Fatalf("missing entry of struct point")
+	}
+	typ, err := d.Type(point.Offset)
+	if err != nil {
+		t.Fatalf("unable to read type of struct point; %v", err)
+	}
+	st, ok := typ.(*dwarf.StructType)
+	if !ok {
+		t.Fatalf("type of struct point mismatch; expected *dwarf.StructType, got %T", typ)
+	}
+	if st.ByteSize != 16 {
+		t.Errorf("size of struct point mismatch; expected 16, got %d", st.ByteSize)
+	}
+	fields := []struct {
+		name      string
+		typ       string
+		offset    int64
+		bitSize   int64
+		bitOffset int64
+	}{
+		{name: "x", typ: "int", offset: 0},
+		{name: "pad", typ: "[2]short", offset: 4},
+		{name: "next", typ: "*struct point", offset: 8},
+		{name: "flags", typ: "unsigned int", offset: 12, bitSize: 3, bitOffset: 29},
+		{name: "kind", typ: "unsigned int", offset: 12, bitSize: 5, bitOffset: 24},
+		{name: "rg", typ: "struct {r unsigned char@0; g unsigned char@1}", offset: 13},
+	}
+	if len(st.Field) != len(fields) {
+		t.Fatalf("number of fields of struct point mismatch; expected %d, got %d", len(fields), len(st.Field))
+	}
+	for i, want := range fields {
+		got := st.Field[i]
+		if got.Name != want.name || got.Type.String() != want.typ || got.ByteOffset != want.offset || got.BitSize != want.bitSize || got.BitOffset != want.bitOffset {
+			t.Errorf("field %d of struct point mismatch; expected %+v, got {name:%s typ:%s offset:%d bitSize:%d bitOffset:%d}", i, want, got.Name, got.Type, got.ByteOffset, got.BitSize, got.BitOffset)
+		}
+	}
+
+	// Signed enum.
+	color, ok := entries[key{tag: dwarf.TagEnumerationType, name: "color"}]
+	if !ok {
+		t.Fatalf("missing entry of enum color")
+	}
+	typ, err = d.Type(color.Offset)
+	if err != nil {
+		t.Fatalf("unable to read type of enum color; %v", err)
+	}
+	values := make(map[string]int64)
+	for _, v := range typ.(*dwarf.EnumType).Val {
+		values[v.Name] = v.Val
+	}
+	if got, want := values["NONE"], int64(-1); got != want {
+		t.Errorf("value of enum member NONE mismatch; expected %d, got %d", want, got)
+	}
+
+	// Type definition of anonymous struct.
+	entry, ok := entries[key{tag: dwarf.TagTypedef, name: "entry_t"}]
+	if !ok {
+		t.Fatalf("missing entry of typedef entry_t")
+	}
+	typ, err = d.Type(entry.Offset)
+	if err != nil {
+		t.Fatalf("unable to read type of typedef entry_t; %v", err)
+	}
+	if got, want := typ.(*dwarf.TypedefType).Type.Size(), int64(8); got != want {
+		t.Errorf("size of typedef entry_t mismatch; expected %d, got %d", want, got)
+	}
+
+	// Global variable.
+	origin, ok := entries[key{tag: dwarf.TagVariable, name: "origin"}]
+	if !ok {
+		t.Fatalf("missing entry of variable origin")
+	}
+	wantLoc := []byte{dwOpAddr, 0x00, 0x00, 0x01, 0x80}
+	if got, ok := origin.Val(dwarf.AttrLocation).([]byte); !ok || string(got) != string(wantLoc) {
+		t.Errorf("location of variable origin mismatch; expected %X, got %X", wantLoc, got)
+	}
+	if origin.Val(dwarf.AttrExternal) != true {
+		t.Errorf("variable origin not marked as external")
+	}
+	// Static variables are not external.
+	if entries, ok := entries[key{tag: dwarf.TagVariable, name: "entries"}]; !ok {
+		t.Errorf("missing entry of variable entries")
+	} else if entries.Val(dwarf.AttrExternal) != nil {
+		t.Errorf("static variable entries marked as external")
+	}
+
+	// Function with register parameters and a local variable.
+	add, ok := entries[key{tag: dwarf.TagSubprogram, name: "add"}]
+	if !ok {
+		t.Fatalf("missing entry of function add")
+	}
+	if got, want := add.Val(dwarf.AttrLowpc), uint64(0x80010100); got != want {
+		t.Errorf("low PC of function add mismatch; expected 0x%08X, got %v", want, got)
+	}
+	if got, want := add.Val(dwarf.AttrHighpc), uint64(0x80010140); got != want {
+		t.Errorf("high PC of function add mismatch; expected 0x%08X, got %v", want, got)
+	}
+	if got, want := add.Val(dwarf.AttrDeclLine), int64(26); got != want {
+		t.Errorf("declaration line of function add mismatch; expected %d, got %v", want, got)
+	}
+	ranges, err := d.Ranges(add)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranges) != 1 || ranges[0] != [2]uint64{0x80010100, 0x80010140} {
+		t.Errorf("address ranges of function add mismatch; got %X", ranges)
+	}
+	r.Seek(add.Offset)
+	if _, err := r.Next(); err != nil {
+		t.Fatal(err)
+	}
+	children := []struct {
+		tag  dwarf.Tag
+		name string
+		typ  string
+		loc  []byte
+	}{
+		// $a0 and $a1.
+		{tag: dwarf.TagFormalParameter, name: "p", typ: "*struct point", loc: []byte{dwOpReg0 + 4}},
+		{tag: dwarf.TagFormalParameter, name: "n", typ: "int", loc: []byte{dwOpReg0 + 5}},
+		// 16($sp).
+		{tag: dwarf.TagVariable, name: "sum", typ: "int", loc: []byte{dwOpFbreg, 16}},
+	}
+	for _, want := range children {
+		e, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e == nil || e.Tag == 0 {
+			t.Errorf("missing child entry %q of function add", want.name)
+			break
+		}
+		var typ string
+		if off, ok := e.Val(dwarf.AttrType).(dwarf.Offset); ok {
+			if t, err := d.Type(off); err == nil {
+				typ = t.String()
+			}
+		}
+		loc, _ := e.Val(dwarf.AttrLocation).([]byte)
+		if e.Tag != want.tag || e.Val(dwarf.AttrName) != want.name || typ != want.typ || string(loc) != string(want.loc) {
+			t.Errorf("child entry of function add mismatch; expected %v %q of type %q at %X, got %v %v of type %q at %X", want.tag, want.name, want.typ, want.loc, e.Tag, e.Val(dwarf.AttrName), typ, loc)
+		}
+	}
+
+	// Compile unit of the overlay.
+	f4, err := elf.Open(filepath.Join(dir, "overlay_4", dwarfName))
+	if err != nil {
+		t.Fatalf("unable to open ELF file of overlay; %v", err)
+	}
+	defer f4.Close()
+	d4, err := f4.DWARF()
+	if err != nil {
+		t.Fatalf("unable to read DWARF debug information of overlay; %v", err)
+	}
+	r4 := d4.Reader()
+	var vars []string
+	for {
+		e, err := r4.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e == nil {
+			break
+		}
+		if e.Tag == dwarf.TagCompileUnit {
+			if got, want := e.Val(dwarf.AttrName), "overlay_4"; got != want {
+				t.Errorf("compile unit name of overlay mismatch; expected %q, got %v", want, got)
+			}
+			continue
+		}
+		if e.Tag == dwarf.TagVariable {
+			vars = append(vars, e.Val(dwarf.AttrName).(string))
+		}
+		r4.SkipChildren()
+	}
+	if len(vars) != 2 || vars[0] != "gState" || vars[1] != "gVal" {
+		t.Errorf("variables of overlay mismatch; expected [gState gVal], got %v", vars)
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// ELF file name.
+const elfName = "symbols.elf"
+
+// dumpELF outputs the declarations recorded by the parser to ELF files stored
+// in the output directory; one for the default binary, and one for each
+// overlay (stored in overlay_N).
+func dumpELF(p *csym.Parser, outputDir string) error {
+	// Create ELF file of default binary.
+	if err := dumpELFOverlay(p.Overlay, outputDir); err != nil {
+		return errors.WithStack(err)
+	}
+	// Create ELF files of overlays.
+	for _, overlay := range p.Overlays {
+		if err := dumpELFOverlay(overlay, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// dumpELFOverlay outputs the declarations of the overlay to an ELF file.
+func dumpELFOverlay(overlay *csym.Overlay, outputDir string) error {
+	dir := outputDir
+	if overlay.ID != 0 {
+		overlayDir := fmt.Sprintf("overlay_%x", overlay.ID)
+		dir = filepath.Join(outputDir, overlayDir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if err := createOutputFile(dir, elfName, func(w io.Writer) error {
+		_, err := w.Write(symbolsELF(overlay, nil))
+		return err
+	}); err != nil {
+		return errors.Wrap(err, "unable to create ELF file")
+	}
+	return nil
+}
+
+// Section indices of the ELF file; followed by extra sections and the section
+// header string table.
+const (
+	elfText = 1 + iota
+	elfData
+	elfSymtab
+	elfStrtab
+)
+
+// elfSymbol is a symbol of the ELF file.
+type elfSymbol struct {
+	// Symbol name.
+	name string
+	// Address.
+	addr uint32
+	// Size in bytes (optional).
+	size uint32
+	// Symbol type; function or object.
+	typ elf.SymType
+	// Symbol binding; local or global.
+	bind elf.SymBind
+	// Section index.
+	shndx uint16
+}
+
+// elfSection is a non-allocated section of the ELF file; e.g. debug
+// information.
+type elfSection struct {
+	// Section name.
+	name string
+	// Section contents.
+	data []byte
+}
+
+// symbolsELF returns the contents of a minimal MIPS ELF file (little-endian,
+// 32-bit) of the declarations of the overlay, containing a symbol table and the
+// given extra sections, for use by binutils (nm and objdump), GDB
+// (add-symbol-file) and emulators loading ELF symbols.
+//
+// Functions are recorded as FUNC symbols of the .text section, and global
+// variables as OBJECT symbols of the .data section. The sections span the
+// address ranges of the functions and global variables, respectively, and
+// have no contents (SHT_NOBITS). Symbols of static storage class have local
+// binding. Symbols without address (e.g. external declarations) are omitted.
+func symbolsELF(overlay *csym.Overlay, extra []elfSection) []byte {
+	var syms []elfSymbol
+	bind := func(class c.StorageClass) elf.SymBind {
+		if class == c.Static {
+			return elf.STB_LOCAL
+		}
+		return elf.STB_GLOBAL
+	}
+	var text, data elfRange
+	for _, f := range overlay.Funcs {
+		if f.Addr == 0 {
+			// Skip functions without address.
+			continue
+		}
+		syms = append(syms, elfSymbol{name: f.Name, addr: f.Addr, size: f.Size, typ: elf.STT_FUNC, bind: bind(f.Class), shndx: elfText})
+		text.add(f.Addr, f.Size)
+	}
+	for _, v := range overlay.Vars {
+		if v.Addr == 0 {
+			// Skip variables without address; e.g. external declarations.
+			continue
+		}
+		size := v.Size
+		if size == 0 {
+			size = c.Sizeof(v.Type)
+		}
+		syms = append(syms, elfSymbol{name: v.Name, addr: v.Addr, size: size, typ: elf.STT_OBJECT, bind: bind(v.Class), shndx: elfData})
+		data.add(v.Addr, size)
+	}
+	// Local symbols precede global symbols.
+	sort.SliceStable(syms, func(i, j int) bool {
+		return syms[i].bind == elf.STB_LOCAL && syms[j].bind != elf.STB_LOCAL
+	})
+	// String table; starts with the empty string.
+	strtab := &bytes.Buffer{}
+	strtab.WriteByte(0)
+	symtab := &bytes.Buffer{}
+	order := binary.LittleEndian
+	// Null symbol.
+	binary.Write(symtab, order, elf.Sym32{})
+	nlocals := 1
+	for _, sym := range syms {
+		if sym.bind == elf.STB_LOCAL {
+			nlocals++
+		}
+		s := elf.Sym32{
+			Name:  uint32(strtab.Len()),
+			Value: sym.addr,
+			Size:  sym.size,
+			Info:  elf.ST_INFO(sym.bind, sym.typ),
+			Shndx: sym.shndx,
+		}
+		strtab.WriteString(sym.name)
+		strtab.WriteByte(0)
+		binary.Write(symtab, order, s)
+	}
+	// Section header string table.
+	shstrtab := &bytes.Buffer{}
+	shstrtab.WriteByte(0)
+	shname := func(name string) uint32 {
+		off := uint32(shstrtab.Len())
+		shstrtab.WriteString(name)
+		shstrtab.WriteByte(0)
+		return off
+	}
+	const (
+		ehdrSize = 52
+		shdrSize = 40
+		symSize  = 16
+	)
+	// Section headers and contents, in order of section index.
+	shdrs := []elf.Section32{{}}
+	contents := [][]byte{nil}
+	add := func(shdr elf.Section32, data []byte) {
+		shdrs = append(shdrs, shdr)
+		contents = append(contents, data)
+	}
+	add(elf.Section32{
+		Name:      shname(".text"),
+		Type:      uint32(elf.SHT_NOBITS),
+		Flags:     uint32(elf.SHF_ALLOC | elf.SHF_EXECINSTR),
+		Addr:      text.start,
+		Size:      text.size(),
+		Addralign: 4,
+	}, nil)
+	add(elf.Section32{
+		Name:      shname(".data"),
+		Type:      uint32(elf.SHT_NOBITS),
+		Flags:     uint32(elf.SHF_ALLOC | elf.SHF_WRITE),
+		Addr:      data.start,
+		Size:      data.size(),
+		Addralign: 4,
+	}, nil)
+	add(elf.Section32{
+		Name:      shname(".symtab"),
+		Type:      uint32(elf.SHT_SYMTAB),
+		Link:      elfStrtab,
+		Info:      uint32(nlocals),
+		Addralign: 4,
+		Entsize:   symSize,
+	}, symtab.Bytes())
+	add(elf.Section32{
+		Name:      shname(".strtab"),
+		Type:      uint32(elf.SHT_STRTAB),
+		Addralign: 1,
+	}, strtab.Bytes())
+	for _, sect := range extra {
+		add(elf.Section32{
+			Name:      shname(sect.name),
+			Type:      uint32(elf.SHT_PROGBITS),
+			Addralign: 1,
+		}, sect.data)
+	}
+	shstrndx := len(shdrs)
+	add(elf.Section32{
+		Name:      shname(".shstrtab"),
+		Type:      uint32(elf.SHT_STRTAB),
+		Addralign: 1,
+	}, nil)
+	contents[shstrndx] = shstrtab.Bytes()
+	// Layout: ELF header, section contents, section headers.
+	off := uint32(ehdrSize)
+	for i := range shdrs {
+		if contents[i] == nil {
+			continue
+		}
+		if align := shdrs[i].Addralign; align > 1 {
+			off = alignUp(off, align)
+		}
+		shdrs[i].Off = off
+		shdrs[i].Size = uint32(len(contents[i]))
+		off += uint32(len(contents[i]))
+	}
+	shoff := alignUp(off, 4)
+	// ELF header.
+	ehdr := elf.Header32{
+		Type:      uint16(elf.ET_EXEC),
+		Machine:   uint16(elf.EM_MIPS),
+		Version:   uint32(elf.EV_CURRENT),
+		Shoff:     shoff,
+		Ehsize:    ehdrSize,
+		Shentsize: shdrSize,
+		Shnum:     uint16(len(shdrs)),
+		Shstrndx:  uint16(shstrndx),
+	}
+	copy(ehdr.Ident[:], elf.ELFMAG)
+	ehdr.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS32)
+	ehdr.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	ehdr.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+	buf := &bytes.Buffer{}
+	binary.Write(buf, order, ehdr)
+	for i := range shdrs {
+		if contents[i] == nil {
+			continue
+		}
+		pad(buf, shdrs[i].Off)
+		buf.Write(contents[i])
+	}
+	pad(buf, shoff)
+	for _, shdr := range shdrs {
+		binary.Write(buf, order, shdr)
+	}
+	return buf.Bytes()
+}
+
+// elfRange is the address range of an ELF section.
+type elfRange struct {
+	// Start address.
+	start uint32
+	// End address (exclusive).
+	end uint32
+	// The address range contains at least one symbol.
+	valid bool
+}
+
+// add extends the address range to include the given symbol.
+func (r *elfRange) add(addr, size uint32) {
+	if !r.valid {
+		r.start, r.end = addr, addr
+		r.valid = true
+	}
+	if addr < r.start {
+		r.start = addr
+	}
+	if end := addr + size; end > r.end {
+		r.end = end
+	}
+}
+
+// size returns the size in bytes of the address range.
+func (r *elfRange) size() uint32 {
+	return r.end - r.start
+}
+
+// ### [ Helper functions ] ####################################################
+
+// alignUp returns x rounded up to the nearest multiple of align.
+func alignUp(x, align uint32) uint32 {
+	return (x + align - 1) &^ (align - 1)
+}
+
+// pad pads buf with zero bytes up to the given offset.
+func pad(buf *bytes.Buffer, off uint32) {
+	for uint32(buf.Len()) < off {
+		buf.WriteByte(0)
+	}
+}
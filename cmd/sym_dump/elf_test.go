@@ -0,0 +1,92 @@
+package main
+
+import (
+	"debug/elf"
+	"path/filepath"
+	"testing"
+)
+
+func TestDumpELF(t *testing.T) {
+	p := parseTestFile(t)
+	dir := t.TempDir()
+	if err := dumpELF(p, dir); err != nil {
+		t.Fatalf("unable to output ELF files; %+v", err)
+	}
+	golden := []struct {
+		// Path of ELF file, relative to the output directory.
+		path string
+		// Expected symbols, in order of the symbol table.
+		syms []elf.Symbol
+		// Expected address ranges of the .text and .data sections.
+		text, data [2]uint64
+	}{
+		// Default binary.
+		{
+			path: elfName,
+			syms: []elf.Symbol{
+				// Local symbols precede global symbols.
+				{Name: "entries", Value: 0x80010020, Size: 32, Info: elf.ST_INFO(elf.STB_LOCAL, elf.STT_OBJECT), Section: elfData},
+				{Name: "add", Value: 0x80010100, Size: 64, Info: elf.ST_INFO(elf.STB_GLOBAL, elf.STT_FUNC), Section: elfText},
+				{Name: "origin", Value: 0x80010000, Size: 20, Info: elf.ST_INFO(elf.STB_GLOBAL, elf.STT_OBJECT), Section: elfData},
+				{Name: "gval", Value: 0x80010204, Size: 4, Info: elf.ST_INFO(elf.STB_GLOBAL, elf.STT_OBJECT), Section: elfData},
+				{Name: "gbar", Value: 0x80010208, Size: 4, Info: elf.ST_INFO(elf.STB_GLOBAL, elf.STT_OBJECT), Section: elfData},
+			},
+			// Symbols without address (f and msg) are omitted, and do not extend
+			// the address ranges of the sections.
+			text: [2]uint64{0x80010100, 0x80010140},
+			data: [2]uint64{0x80010000, 0x8001020C},
+		},
+		// Overlay.
+		{
+			path: filepath.Join("overlay_4", elfName),
+			syms: []elf.Symbol{
+				{Name: "gState", Value: 0x800B0020, Size: 8, Info: elf.ST_INFO(elf.STB_GLOBAL, elf.STT_OBJECT), Section: elfData},
+				{Name: "gVal", Value: 0x800B0024, Size: 4, Info: elf.ST_INFO(elf.STB_GLOBAL, elf.STT_OBJECT), Section: elfData},
+			},
+			// Empty .text section.
+			data: [2]uint64{0x800B0020, 0x800B0028},
+		},
+	}
+	for _, g := range golden {
+		f, err := elf.Open(filepath.Join(dir, g.path))
+		if err != nil {
+			t.Errorf("%q: unable to open ELF file; %v", g.path, err)
+			continue
+		}
+		if f.Class != elf.ELFCLASS32 || f.Data != elf.ELFDATA2LSB || f.Machine != elf.EM_MIPS || f.Type != elf.ET_EXEC {
+			t.Errorf("%q: ELF header mismatch; expected 32-bit little-endian MIPS executable, got %v %v %v %v", g.path, f.Class, f.Data, f.Machine, f.Type)
+		}
+		for _, sect := range []struct {
+			name string
+			want [2]uint64
+		}{
+			{name: ".text", want: g.text},
+			{name: ".data", want: g.data},
+		} {
+			s := f.Section(sect.name)
+			if s == nil {
+				t.Errorf("%q: missing %s section", g.path, sect.name)
+				continue
+			}
+			if got := [2]uint64{s.Addr, s.Addr + s.Size}; got != sect.want {
+				t.Errorf("%q: address range of %s section mismatch; expected [0x%08X, 0x%08X), got [0x%08X, 0x%08X)", g.path, sect.name, sect.want[0], sect.want[1], got[0], got[1])
+			}
+		}
+		syms, err := f.Symbols()
+		if err != nil {
+			t.Errorf("%q: unable to read symbol table; %v", g.path, err)
+			f.Close()
+			continue
+		}
+		if len(syms) != len(g.syms) {
+			t.Errorf("%q: number of symbols mismatch; expected %d, got %d", g.path, len(g.syms), len(syms))
+		}
+		for i := 0; i < len(syms) && i < len(g.syms); i++ {
+			got, want := syms[i], g.syms[i]
+			if got.Name != want.Name || got.Value != want.Value || got.Size != want.Size || got.Info != want.Info || got.Section != want.Section {
+				t.Errorf("%q: symbol %d mismatch; expected %+v, got %+v", g.path, i, want, got)
+			}
+		}
+		f.Close()
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym"
+)
+
+// extractUsage prints usage information of the extract command.
+func extractUsage(fs *flag.FlagSet) {
+	const use = `
+Extract the symbols of an overlay from symbol files, preserving the base address of the overlay, and output the extracted symbols in the specified output format (e.g. FILE.sym -> FILE_overlay_4.sym).
+
+Usage:
+
+	sym_dump extract [OPTION]... -overlay ID FILE...
+`
+	fmt.Println(use[1:])
+	fs.PrintDefaults()
+}
+
+// extract extracts the symbols of an overlay from the symbol files specified by
+// the given command line arguments of the extract command, and outputs the
+// extracted symbols in the specified output format.
+func extract(args []string) error {
+	// Command line flags.
+	var (
+		// Overlay ID.
+		overlay string
+		// Input format.
+		from string
+		// Output format.
+		to string
+		// Output directory.
+		outputDir string
+		// Text encoding of symbol names.
+		encodingName string
+		// Byte order of SYM files.
+		endian string
+	)
+	fs := flag.NewFlagSet("extract", flag.ExitOnError)
+	fs.StringVar(&overlay, "overlay", "", "overlay ID in hexadecimal")
+	fs.StringVar(&from, "from", "", "input format; detected if not specified")
+	fs.StringVar(&to, "to", "sym", "output format")
+	fs.StringVar(&outputDir, "dir", dumpDir, "output directory (- for standard output)")
+	fs.StringVar(&encodingName, "encoding", "", "text encoding of symbol names (sjis, or auto to detect)")
+	fs.StringVar(&endian, "endian", "", "byte order of SYM files (big or little); detected if not specified")
+	fs.Usage = func() { extractUsage(fs) }
+	if err := fs.Parse(args); err != nil {
+		return errors.WithStack(err)
+	}
+	if fs.NArg() == 0 || len(overlay) == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	id, err := parseHex(overlay)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if len(from) > 0 && !isInputFormat(from) {
+		return errors.Errorf("invalid input format %q", from)
+	}
+	format := lookupOutputFormat(to)
+	if format == nil {
+		return errors.Errorf("invalid output format %q", to)
+	}
+	enc, err := parseEncoding(encodingName)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	order, err := parseByteOrder(endian)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := initOutputDir(outputDir); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, path := range fs.Args() {
+		opts := &sym.ParseOptions{
+			Encoding:       enc,
+			Order:          order,
+			DetectEncoding: strings.EqualFold(encodingName, "auto"),
+		}
+		f, err := parseFile(path, from, opts)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if f.Syms, err = sym.ExtractOverlay(f.Syms, id); err != nil {
+			return errors.Wrapf(err, "unable to extract overlay of %q", path)
+		}
+		// Name output files after the overlay; e.g. "foo.sym" -> "foo_overlay_4.sym".
+		name := inputName(path)
+		ext := filepath.Ext(name)
+		overlayPath := fmt.Sprintf("%s_overlay_%x%s", strings.TrimSuffix(name, ext), id, ext)
+		if err := outputFile(format, f, overlayPath, outputDir, fs.NArg() > 1); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym"
+)
+
+// filterUsage prints usage information of the filter command.
+func filterUsage(fs *flag.FlagSet) {
+	const use = `
+Reduce symbol files to the symbols matching the specified criteria, or strip the matching symbols.
+
+Usage:
+
+	sym_dump filter [OPTION]... FILE...
+
+Examples:
+
+	# Keep only the names of functions and global variables.
+	sym_dump filter -kind 2 FILE
+
+	# Strip the symbols of overlay 4.
+	sym_dump filter -strip -overlay 4 FILE
+`
+	fmt.Println(use[1:])
+	fs.PrintDefaults()
+}
+
+// filter reduces the symbol files specified by the given command line arguments
+// of the filter command, and outputs the kept symbols in the specified output
+// format.
+func filter(args []string) error {
+	// Command line flags.
+	var (
+		// Input format.
+		from string
+		// Output format.
+		to string
+		// Output directory.
+		outputDir string
+		// Text encoding of symbol names.
+		encodingName string
+		// Byte order of SYM files.
+		endian string
+		// Filter criteria.
+		kinds    string
+		classes  string
+		minAddr  string
+		maxAddr  string
+		overlays string
+		name     string
+		strip    bool
+	)
+	fs := flag.NewFlagSet("filter", flag.ExitOnError)
+	fs.StringVar(&from, "from", "", "input format; detected if not specified")
+	fs.StringVar(&to, "to", "sym", "output format")
+	fs.StringVar(&outputDir, "dir", dumpDir, "output directory (- for standard output)")
+	fs.StringVar(&encodingName, "encoding", "", "text encoding of symbol names (sjis, or auto to detect)")
+	fs.StringVar(&endian, "endian", "", "byte order of SYM files (big or little); detected if not specified")
+	fs.StringVar(&kinds, "kind", "", "comma-separated list of symbol kinds to match (e.g. 2,8c,94)")
+	fs.StringVar(&classes, "class", "", "comma-separated list of definition classes to match (e.g. EXT,STAT)")
+	fs.StringVar(&minAddr, "minaddr", "", "start address of address range to match (inclusive)")
+	fs.StringVar(&maxAddr, "maxaddr", "", "end address of address range to match (exclusive)")
+	fs.StringVar(&overlays, "overlay", "", "comma-separated list of overlay IDs to match (0 for the default binary)")
+	fs.StringVar(&name, "name", "", "regular expression of symbol names to match")
+	fs.BoolVar(&strip, "strip", false, "strip matching symbols, keeping the remaining symbols")
+	fs.Usage = func() { filterUsage(fs) }
+	if err := fs.Parse(args); err != nil {
+		return errors.WithStack(err)
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if len(from) > 0 && !isInputFormat(from) {
+		return errors.Errorf("invalid input format %q", from)
+	}
+	format := lookupOutputFormat(to)
+	if format == nil {
+		return errors.Errorf("invalid output format %q", to)
+	}
+	enc, err := parseEncoding(encodingName)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	order, err := parseByteOrder(endian)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	filter := &sym.Filter{Strip: strip}
+	if filter.Kinds, err = parseKinds(kinds); err != nil {
+		return errors.WithStack(err)
+	}
+	if filter.Classes, err = parseClasses(classes); err != nil {
+		return errors.WithStack(err)
+	}
+	if filter.MinAddr, err = parseHex(minAddr); err != nil {
+		return errors.WithStack(err)
+	}
+	if filter.MaxAddr, err = parseHex(maxAddr); err != nil {
+		return errors.WithStack(err)
+	}
+	if filter.Overlays, err = parseOverlayIDs(overlays); err != nil {
+		return errors.WithStack(err)
+	}
+	if len(name) > 0 {
+		if filter.Name, err = regexp.Compile(name); err != nil {
+			return errors.Wrapf(err, "invalid regular expression of symbol names %q", name)
+		}
+	}
+	if err := initOutputDir(outputDir); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, path := range fs.Args() {
+		opts := &sym.ParseOptions{
+			Encoding:       enc,
+			Order:          order,
+			DetectEncoding: strings.EqualFold(encodingName, "auto"),
+		}
+		f, err := parseFile(path, from, opts)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		f.Syms = filter.Apply(f.Syms)
+		if err := outputFile(format, f, path, outputDir, fs.NArg() > 1); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// parseKinds returns the symbol kinds of the given comma-separated list of
+// kinds, as specified by their DUMPSYM representation (e.g. "2", "8c" or
+// "overlay"); or nil if no kinds were specified.
+func parseKinds(s string) ([]sym.Kind, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	var kinds []sym.Kind
+	for _, name := range strings.Split(s, ",") {
+		kind, ok := lookupKind(strings.TrimSpace(name))
+		if !ok {
+			return nil, errors.Errorf("invalid symbol kind %q", name)
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds, nil
+}
+
+// lookupKind returns the symbol kind of the given DUMPSYM representation. The
+// boolean return value reports whether the kind was found.
+func lookupKind(name string) (sym.Kind, bool) {
+	for _, kind := range sym.Kinds {
+		if strings.EqualFold(kind.String(), name) {
+			return kind, true
+		}
+	}
+	return 0, false
+}
+
+// parseClasses returns the definition classes of the given comma-separated list
+// of classes (e.g. "EXT,STAT"); or nil if no classes were specified.
+func parseClasses(s string) ([]sym.Class, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	var classes []sym.Class
+	for _, name := range strings.Split(s, ",") {
+		class, ok := lookupClass(strings.TrimSpace(name))
+		if !ok {
+			return nil, errors.Errorf("invalid definition class %q", name)
+		}
+		classes = append(classes, class)
+	}
+	return classes, nil
+}
+
+// lookupClass returns the definition class of the given name. The boolean
+// return value reports whether the class was found.
+func lookupClass(name string) (sym.Class, bool) {
+	for _, class := range sym.Classes {
+		if strings.EqualFold(class.String(), name) {
+			return class, true
+		}
+	}
+	return 0, false
+}
+
+// parseOverlayIDs returns the overlay IDs of the given comma-separated list of
+// hexadecimal overlay IDs; or nil if no overlay IDs were specified.
+func parseOverlayIDs(s string) ([]uint32, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	var ids []uint32
+	for _, field := range strings.Split(s, ",") {
+		id, err := parseHex(field)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parseHex returns the value of the given hexadecimal string, with optional
+// "0x" prefix; or 0 if empty.
+func parseHex(s string) (uint32, error) {
+	s = strings.TrimSpace(s)
+	if len(s) == 0 {
+		return 0, nil
+	}
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	x, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid hexadecimal value %q", s)
+	}
+	return uint32(x), nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+)
+
+// GDB script name.
+const gdbName = "symbols.gdb"
+
+// dumpGDB outputs the declarations recorded by the parser to GDB scripts stored
+// in the output directory; one for the default binary, and one for each
+// overlay (stored in overlay_N).
+func dumpGDB(p *csym.Parser, outputDir string) error {
+	// Create script of default binary.
+	if err := dumpGDBOverlay(p.Overlay, outputDir); err != nil {
+		return errors.WithStack(err)
+	}
+	// Create scripts of overlays.
+	for _, overlay := range p.Overlays {
+		if err := dumpGDBOverlay(overlay, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// dumpGDBOverlay outputs the declarations of the overlay to a GDB script, as
+// loaded by `gdb-multiarch -x symbols.gdb` before connecting to the GDB stub of
+// an emulator.
+//
+// The script sets the MIPS R3000 architecture, and defines a convenience
+// variable for each function and global variable, prefixed with sym_ to avoid
+// collisions with registers (e.g. `break *$sym_main`). The script also defines
+// the commands sym-break NAME, setting a breakpoint on a function by name, and
+// sym-info ADDRESS, printing the symbol containing an address; these require
+// GDB with Python support. Symbols without address (e.g. external declarations)
+// are omitted.
+func dumpGDBOverlay(overlay *csym.Overlay, outputDir string) error {
+	dir := outputDir
+	if overlay.ID != 0 {
+		overlayDir := fmt.Sprintf("overlay_%x", overlay.ID)
+		dir = filepath.Join(outputDir, overlayDir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	syms := definedSymbols(sortedSymbols(overlay))
+	if err := createOutputFile(dir, gdbName, func(f io.Writer) error {
+		w := bufio.NewWriter(f)
+		w.WriteString(gdbHeader)
+		for _, sym := range syms {
+			fmt.Fprintf(w, "set $sym_%s = 0x%08X\n", sym.name, sym.addr)
+		}
+		w.WriteString("\npython\nSYMBOLS = [\n")
+		for _, sym := range syms {
+			fmt.Fprintf(w, "\t(0x%08X, 0x%X, %q),\n", sym.addr, sym.size, sym.name)
+		}
+		w.WriteString("]\n")
+		w.WriteString(gdbPython)
+		w.WriteString("end\n")
+		return w.Flush()
+	}); err != nil {
+		return errors.Wrap(err, "unable to create GDB script")
+	}
+	return nil
+}
+
+// gdbHeader is the header of the GDB script.
+const gdbHeader = `# Symbols of PS1 SYM file, as generated by sym_dump.
+#
+# Usage:
+#
+#    gdb-multiarch -x symbols.gdb
+#    (gdb) target remote localhost:3333
+#    (gdb) sym-break main
+#
+# Alternatively, load the symbol table of the ELF file output by -elf, to break
+# on functions by name without Python support:
+#
+#    (gdb) add-symbol-file symbols.elf
+
+set architecture mips:3000
+set endian little
+
+`
+
+// gdbPython defines the commands of the GDB script, given the SYMBOLS list of
+// (address, size, name) tuples sorted by address.
+const gdbPython = `
+ADDRS = dict((name, addr) for addr, size, name in SYMBOLS)
+
+class SymBreak(gdb.Command):
+	"""Set a breakpoint on the function of the given name."""
+	def __init__(self):
+		super(SymBreak, self).__init__("sym-break", gdb.COMMAND_BREAKPOINTS)
+	def invoke(self, arg, from_tty):
+		name = arg.strip()
+		if name not in ADDRS:
+			raise gdb.GdbError("unable to locate symbol %r" % name)
+		gdb.Breakpoint("*0x%08X" % ADDRS[name])
+
+class SymInfo(gdb.Command):
+	"""Print the symbol containing the given address."""
+	def __init__(self):
+		super(SymInfo, self).__init__("sym-info", gdb.COMMAND_DATA)
+	def invoke(self, arg, from_tty):
+		addr = int(gdb.parse_and_eval(arg)) & 0xFFFFFFFF
+		found = None
+		for start, size, name in SYMBOLS:
+			if start > addr:
+				break
+			if addr < start + max(size, 1):
+				found = (start, name)
+		if found is None:
+			print("no symbol at 0x%08X" % addr)
+		else:
+			print("%s+0x%X" % (found[1], addr - found[0]))
+
+SymBreak()
+SymInfo()
+`
@@ -0,0 +1,487 @@
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// Ghidra script names.
+const (
+	// Symbol list in the format of the ImportSymbolsScript.py script of Ghidra.
+	ghidraSymbolsName = "ghidra_symbols.txt"
+	// Script creating overlay blocks and labeling symbols.
+	ghidraScriptName = "ghidra_import_symbols.py"
+	// Data type archive in the XML program format of Ghidra.
+	ghidraTypesName = "ghidra_types.xml"
+)
+
+// ghidraOverlayNameFormat is the format string of the names of Ghidra overlay
+// blocks (and thus address spaces) of overlays.
+const ghidraOverlayNameFormat = "overlay_%x"
+
+// --- [ Symbols ] -------------------------------------------------------------
+
+// dumpGhidraSymbols outputs the functions and global variables recorded by the
+// parser to Ghidra symbol scripts stored in the output directory:
+//
+//   - a symbol list (ghidra_symbols.txt) consumed by ImportSymbolsScript.py,
+//     one "name address type" line per symbol, where type is "f" for functions
+//     and "l" for labels; symbols of overlays are located in the address space
+//     of the overlay (e.g. "overlay_4::800b0400").
+//   - a Ghidra Python script (ghidra_import_symbols.py) which creates overlay
+//     blocks for each overlay at its load address, unless already present, and
+//     creates functions and labels of symbols in the address space of their
+//     overlay.
+func dumpGhidraSymbols(p *csym.Parser, outputDir string) error {
+	overlays := append([]*csym.Overlay{p.Overlay}, p.Overlays...)
+	// Create symbol list.
+	symbolsPath := filepath.Join(outputDir, ghidraSymbolsName)
+	fmt.Println("creating:", symbolsPath)
+	f, err := os.Create(symbolsPath)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Ghidra symbol list %q", symbolsPath)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	for _, overlay := range overlays {
+		for _, fn := range overlay.Funcs {
+			fmt.Fprintf(w, "%s %s f\n", fn.Name, ghidraAddr(overlay, fn.Addr))
+		}
+		for _, v := range overlay.Vars {
+			fmt.Fprintf(w, "%s %s l\n", v.Name, ghidraAddr(overlay, v.Addr))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+	// Create script.
+	scriptPath := filepath.Join(outputDir, ghidraScriptName)
+	fmt.Println("creating:", scriptPath)
+	f, err = os.Create(scriptPath)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Ghidra script %q", scriptPath)
+	}
+	defer f.Close()
+	w = bufio.NewWriter(f)
+	w.WriteString(ghidraScriptHeader)
+	for _, overlay := range overlays {
+		space := "None"
+		if isGhidraOverlay(overlay) {
+			space = fmt.Sprintf("space_%x", overlay.ID)
+			name := fmt.Sprintf(ghidraOverlayNameFormat, overlay.ID)
+			fmt.Fprintf(w, "\n%s = overlay(%q, 0x%08X, 0x%X)\n", space, name, overlay.Addr, overlay.Length)
+		}
+		for _, fn := range overlay.Funcs {
+			fmt.Fprintf(w, "label(%s, 0x%08X, %q, True)\n", space, fn.Addr, fn.Name)
+		}
+		for _, v := range overlay.Vars {
+			fmt.Fprintf(w, "label(%s, 0x%08X, %q, False)\n", space, v.Addr, v.Name)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// ghidraScriptHeader is the header of the Ghidra script, defining the helper
+// functions used to create overlay blocks and label symbols.
+const ghidraScriptHeader = `# Import symbols of PS1 SYM file, as generated by sym_dump.
+#
+# Overlays are created as uninitialized overlay blocks, unless a block of the
+# same name is already present (e.g. with the contents of the overlay).
+#
+#@category Symbol
+
+from ghidra.program.model.symbol import SourceType
+
+def overlay(name, addr, length):
+	mem = currentProgram.getMemory()
+	block = mem.getBlock(name)
+	if block is None:
+		block = mem.createUninitializedBlock(name, toAddr(addr), length, True)
+	return block.getStart().getAddressSpace()
+
+def label(space, addr, name, func):
+	if space is None:
+		a = toAddr(addr)
+	else:
+		a = space.getAddress(addr)
+	if func:
+		f = getFunctionAt(a)
+		if f is None:
+			createFunction(a, name)
+		else:
+			f.setName(name, SourceType.IMPORTED)
+	else:
+		createLabel(a, name, True, SourceType.IMPORTED)
+`
+
+// --- [ Data types ] ----------------------------------------------------------
+
+// dumpGhidraTypes outputs the type information recorded by the parser to a data
+// type archive (ghidra_types.xml) stored in the output directory, in the XML
+// program format of Ghidra. The archive contains structs and unions with member
+// offsets, enums, type definitions and function signatures, and is applied to
+// a program in one import (File > Add To Program).
+//
+// Bitfields are not represented, as they are not supported by the XML importer
+// of Ghidra. Anonymous function types (e.g. of function pointers) are output as
+// function signatures named _func_N.
+func dumpGhidraTypes(p *csym.Parser, outputDir string) error {
+	g := newGhidraTypes()
+	for _, tag := range p.EnumTags {
+		g.addEnum(p.Enums[tag])
+	}
+	for _, tag := range p.StructTags {
+		t := p.Structs[tag]
+		g.dts.Structs = append(g.dts.Structs, g.composite(t.Tag, t.Size, t.Fields))
+	}
+	for _, tag := range p.UnionTags {
+		t := p.Unions[tag]
+		g.dts.Unions = append(g.dts.Unions, g.composite(t.Tag, t.Size, t.Fields))
+	}
+	for _, def := range p.Typedefs {
+		if v, ok := def.(*c.VarDecl); ok {
+			g.addTypedef(v)
+		}
+	}
+	overlays := append([]*csym.Overlay{p.Overlay}, p.Overlays...)
+	for _, overlay := range overlays {
+		for _, f := range overlay.Funcs {
+			if t, ok := f.Type.(*c.FuncType); ok && !g.funcNames[f.Name] {
+				g.addFuncDef(f.Name, t)
+			}
+		}
+	}
+	prog := &ghidraProgram{
+		Name: "symbols",
+		Processor: ghidraProcessor{
+			Name:         "MIPS",
+			Language:     "MIPS:LE:32:default:default",
+			Endian:       "little",
+			AddressModel: "32-bit",
+		},
+		DataTypes: g.dts,
+	}
+	buf, err := xml.MarshalIndent(prog, "", "\t")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	typesPath := filepath.Join(outputDir, ghidraTypesName)
+	fmt.Println("creating:", typesPath)
+	f, err := os.Create(typesPath)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create Ghidra data type archive %q", typesPath)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	w.WriteString("<?xml version=\"1.0\" standalone=\"yes\"?>\n")
+	w.WriteString("<?program_dtd version=\"1\"?>\n")
+	w.Write(buf)
+	w.WriteString("\n")
+	if err := w.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// ghidraProgram is the root element of a file in the XML program format of
+// Ghidra.
+type ghidraProgram struct {
+	XMLName   xml.Name        `xml:"PROGRAM"`
+	Name      string          `xml:"NAME,attr"`
+	Processor ghidraProcessor `xml:"PROCESSOR"`
+	DataTypes ghidraDataTypes `xml:"DATATYPES"`
+}
+
+// ghidraProcessor specifies the language of a Ghidra program.
+type ghidraProcessor struct {
+	Name         string `xml:"NAME,attr"`
+	Language     string `xml:"LANGUAGE_PROVIDER,attr"`
+	Endian       string `xml:"ENDIAN,attr"`
+	AddressModel string `xml:"ADDRESS_MODEL,attr"`
+}
+
+// ghidraDataTypes is the data type section of a Ghidra program.
+type ghidraDataTypes struct {
+	Enums    []*ghidraEnum      `xml:"ENUM"`
+	Structs  []*ghidraComposite `xml:"STRUCTURE"`
+	Unions   []*ghidraComposite `xml:"UNION"`
+	Typedefs []*ghidraTypedef   `xml:"TYPE_DEF"`
+	Funcs    []*ghidraFuncDef   `xml:"FUNCTION_DEF"`
+}
+
+// ghidraEnum is a Ghidra enum data type.
+type ghidraEnum struct {
+	Name      string             `xml:"NAME,attr"`
+	Namespace string             `xml:"NAMESPACE,attr"`
+	Size      string             `xml:"SIZE,attr"`
+	Entries   []*ghidraEnumEntry `xml:"ENUM_ENTRY"`
+}
+
+// ghidraEnumEntry is a member of a Ghidra enum data type.
+type ghidraEnumEntry struct {
+	Name  string `xml:"NAME,attr"`
+	Value string `xml:"VALUE,attr"`
+}
+
+// ghidraComposite is a Ghidra structure or union data type.
+type ghidraComposite struct {
+	Name      string          `xml:"NAME,attr"`
+	Namespace string          `xml:"NAMESPACE,attr"`
+	Size      string          `xml:"SIZE,attr"`
+	Members   []*ghidraMember `xml:"MEMBER"`
+}
+
+// ghidraMember is a member of a Ghidra structure or union data type.
+type ghidraMember struct {
+	Offset            string `xml:"OFFSET,attr"`
+	DataType          string `xml:"DATATYPE,attr"`
+	DataTypeNamespace string `xml:"DATATYPE_NAMESPACE,attr"`
+	Name              string `xml:"NAME,attr,omitempty"`
+	Size              string `xml:"SIZE,attr"`
+}
+
+// ghidraTypedef is a Ghidra type definition.
+type ghidraTypedef struct {
+	Name              string `xml:"NAME,attr"`
+	Namespace         string `xml:"NAMESPACE,attr"`
+	DataType          string `xml:"DATATYPE,attr"`
+	DataTypeNamespace string `xml:"DATATYPE_NAMESPACE,attr"`
+}
+
+// ghidraFuncDef is a Ghidra function signature data type.
+type ghidraFuncDef struct {
+	Name      string         `xml:"NAME,attr"`
+	Namespace string         `xml:"NAMESPACE,attr"`
+	RetType   ghidraParam    `xml:"RETURN_TYPE"`
+	Params    []*ghidraParam `xml:"PARAMETER"`
+}
+
+// ghidraParam is the return type or a parameter of a Ghidra function
+// signature.
+type ghidraParam struct {
+	Ordinal           string `xml:"ORDINAL,attr,omitempty"`
+	DataType          string `xml:"DATATYPE,attr"`
+	DataTypeNamespace string `xml:"DATATYPE_NAMESPACE,attr"`
+	Name              string `xml:"NAME,attr,omitempty"`
+	Size              string `xml:"SIZE,attr"`
+}
+
+// ghidraNamespace is the category of the data types, and the namespace of
+// built-in data types.
+const ghidraNamespace = "/"
+
+// ghidraTypes records the Ghidra data types of C types.
+type ghidraTypes struct {
+	// Data types.
+	dts ghidraDataTypes
+	// funcTypes maps from anonymous function type (in C syntax) to name of
+	// function signature.
+	funcTypes map[string]string
+	// funcNames tracks the names of function signatures.
+	funcNames map[string]bool
+}
+
+// newGhidraTypes returns a new record of Ghidra data types.
+func newGhidraTypes() *ghidraTypes {
+	return &ghidraTypes{
+		funcTypes: make(map[string]string),
+		funcNames: make(map[string]bool),
+	}
+}
+
+// addEnum adds the Ghidra data type of the given enum type.
+func (g *ghidraTypes) addEnum(t *c.EnumType) {
+	enum := &ghidraEnum{
+		Name:      t.Tag,
+		Namespace: ghidraNamespace,
+		Size:      ghidraHex(c.Sizeof(t)),
+	}
+	for _, member := range t.Members {
+		value := fmt.Sprintf("0x%X", member.Value)
+		if t.Signed && int32(member.Value) < 0 {
+			value = fmt.Sprintf("-0x%X", -int64(int32(member.Value)))
+		}
+		entry := &ghidraEnumEntry{
+			Name:  member.Name,
+			Value: value,
+		}
+		enum.Entries = append(enum.Entries, entry)
+	}
+	g.dts.Enums = append(g.dts.Enums, enum)
+}
+
+// composite returns the Ghidra data type of the struct or union type with the
+// given tag, size and fields.
+func (g *ghidraTypes) composite(tag string, size uint32, fields []c.Field) *ghidraComposite {
+	composite := &ghidraComposite{
+		Name:      tag,
+		Namespace: ghidraNamespace,
+		Size:      ghidraHex(size),
+	}
+	for _, field := range fields {
+		if field.BitWidth > 0 {
+			continue
+		}
+		fieldSize := field.Size
+		if fieldSize == 0 {
+			fieldSize = c.Sizeof(field.Type)
+		}
+		member := &ghidraMember{
+			Offset:            ghidraHex(field.Offset),
+			DataType:          g.typeName(field.Type),
+			DataTypeNamespace: ghidraNamespace,
+			Name:              field.Name,
+			Size:              ghidraHex(fieldSize),
+		}
+		composite.Members = append(composite.Members, member)
+	}
+	return composite
+}
+
+// addTypedef adds the Ghidra data type of the given type definition. Type
+// definitions of the same name as their underlying struct, union or enum type
+// are omitted, as data types of Ghidra share one namespace.
+func (g *ghidraTypes) addTypedef(v *c.VarDecl) {
+	name := g.typeName(v.Type)
+	if name == v.Name {
+		return
+	}
+	def := &ghidraTypedef{
+		Name:              v.Name,
+		Namespace:         ghidraNamespace,
+		DataType:          name,
+		DataTypeNamespace: ghidraNamespace,
+	}
+	g.dts.Typedefs = append(g.dts.Typedefs, def)
+}
+
+// addFuncDef adds the Ghidra function signature of the given name and function
+// type.
+func (g *ghidraTypes) addFuncDef(name string, t *c.FuncType) {
+	g.funcNames[name] = true
+	def := &ghidraFuncDef{
+		Name:      name,
+		Namespace: ghidraNamespace,
+		RetType: ghidraParam{
+			DataType:          g.typeName(t.RetType),
+			DataTypeNamespace: ghidraNamespace,
+			Size:              ghidraHex(c.Sizeof(t.RetType)),
+		},
+	}
+	for i, param := range t.Params {
+		p := &ghidraParam{
+			Ordinal:           ghidraHex(uint32(i)),
+			DataType:          g.typeName(param.Type),
+			DataTypeNamespace: ghidraNamespace,
+			Name:              param.Name,
+			Size:              ghidraHex(c.Sizeof(param.Type)),
+		}
+		def.Params = append(def.Params, p)
+	}
+	g.dts.Funcs = append(g.dts.Funcs, def)
+}
+
+// typeName returns the name of the Ghidra data type of the given type. Names of
+// pointers and arrays are derived from their element types (e.g. "int *" and
+// "char[16]"), as resolved by the XML importer of Ghidra.
+func (g *ghidraTypes) typeName(t c.Type) string {
+	switch t := t.(type) {
+	case c.BaseType:
+		if name, ok := ghidraBaseTypes[t]; ok {
+			return name
+		}
+	case *c.StructType:
+		return t.Tag
+	case *c.UnionType:
+		return t.Tag
+	case *c.EnumType:
+		return t.Tag
+	case *c.VarDecl:
+		// Type definition.
+		return t.Name
+	case *c.PointerType:
+		return g.typeName(t.Elem) + " *"
+	case *c.ArrayType:
+		// Dimensions of arrays of arrays are named outermost first; e.g.
+		// "int[2][3]".
+		dims := fmt.Sprintf("[%d]", t.Len)
+		elem := t.Elem
+		for {
+			a, ok := elem.(*c.ArrayType)
+			if !ok {
+				break
+			}
+			dims += fmt.Sprintf("[%d]", a.Len)
+			elem = a.Elem
+		}
+		return g.typeName(elem) + dims
+	case *c.QualType:
+		// Type qualifiers are not represented.
+		return g.typeName(t.Type)
+	case *c.FuncType:
+		// Anonymous function type.
+		key := t.String()
+		if name, ok := g.funcTypes[key]; ok {
+			return name
+		}
+		name := fmt.Sprintf("_func_%d", len(g.funcTypes))
+		g.funcTypes[key] = name
+		g.addFuncDef(name, t)
+		return name
+	}
+	return "undefined"
+}
+
+// ghidraBaseTypes maps from base type to name of built-in Ghidra data type.
+var ghidraBaseTypes = map[c.BaseType]string{
+	c.Void:       "void",
+	c.Char:       "char",
+	c.Short:      "short",
+	c.Int:        "int",
+	c.Long:       "long",
+	c.UChar:      "uchar",
+	c.UShort:     "ushort",
+	c.UInt:       "uint",
+	c.ULong:      "ulong",
+	c.Float:      "float",
+	c.Double:     "double",
+	c.LongDouble: "longdouble",
+	c.LongLong:   "longlong",
+	c.ULongLong:  "ulonglong",
+	c.SChar:      "schar",
+	c.Bool:       "bool",
+}
+
+// ### [ Helper functions ] ####################################################
+
+// ghidraHex returns the hexadecimal representation of the given integer, as
+// used by the XML program format of Ghidra.
+func ghidraHex(v uint32) string {
+	return fmt.Sprintf("0x%X", v)
+}
+
+// isGhidraOverlay reports whether the given overlay is located in a Ghidra
+// overlay address space; i.e. whether it is an overlay with known load address
+// and length, rather than the default binary.
+func isGhidraOverlay(overlay *csym.Overlay) bool {
+	return overlay.ID != 0 && overlay.Length > 0
+}
+
+// ghidraAddr returns the Ghidra address of the given address of the overlay.
+func ghidraAddr(overlay *csym.Overlay, addr uint32) string {
+	if isGhidraOverlay(overlay) {
+		name := fmt.Sprintf(ghidraOverlayNameFormat, overlay.ID)
+		return fmt.Sprintf("%s::%08x", name, addr)
+	}
+	return fmt.Sprintf("%08x", addr)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// Include guard styles.
+const (
+	// #ifndef FOO_H, #define FOO_H and #endif, with the macro name derived from
+	// the path of the header.
+	guardIfndef = "ifndef"
+	// #pragma once
+	guardPragma = "pragma"
+	// No include guard.
+	guardNone = "none"
+)
+
+// parseGuardStyle returns the include guard style of the given name.
+func parseGuardStyle(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case guardIfndef:
+		return guardIfndef, nil
+	case guardPragma:
+		return guardPragma, nil
+	case guardNone:
+		return guardNone, nil
+	default:
+		return "", errors.Errorf("invalid include guard style %q; expected ifndef, pragma or none", name)
+	}
+}
+
+// guardStart returns the opening lines of the include guard of the given header
+// path, as specified by the given include guard style.
+func guardStart(path, guard string) string {
+	switch guard {
+	case guardIfndef:
+		macro := headerGuard(path)
+		return fmt.Sprintf("#ifndef %s\n#define %s\n\n", macro, macro)
+	case guardPragma:
+		return "#pragma once\n\n"
+	}
+	return ""
+}
+
+// guardEnd returns the closing line of the include guard of the given header
+// path, as specified by the given include guard style.
+func guardEnd(path, guard string) string {
+	if guard == guardIfndef {
+		return fmt.Sprintf("#endif // %s\n", headerGuard(path))
+	}
+	return ""
+}
+
+// headerGuard returns the include guard macro name of the given header path
+// (e.g. "game/bar.h" -> "GAME_BAR_H").
+func headerGuard(path string) string {
+	guard := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, path)
+	if len(guard) > 0 && unicode.IsDigit(rune(guard[0])) {
+		// Identifiers may not start with a digit.
+		guard = "_" + guard
+	}
+	return guard
+}
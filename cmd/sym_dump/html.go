@@ -0,0 +1,390 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// HTML report file names.
+const (
+	// Index page with searchable symbol table.
+	htmlIndexName = "index.html"
+	// Overlay page name format string.
+	htmlOverlayNameFormat = "overlay_%x.html"
+	// Struct and union layout page.
+	htmlTypesName = "types.html"
+	// Memory map page.
+	htmlMemMapName = "memmap.html"
+)
+
+// dumpHTML outputs a static HTML report of the declarations and type
+// information recorded by the parser to the output directory. The report is
+// self-contained, with styles and scripts embedded in each page.
+func dumpHTML(p *csym.Parser, outputDir string) error {
+	r := newHTMLReport(p)
+	// Pages of report; file name, template name and template data.
+	type htmlPage struct {
+		name string
+		tmpl string
+		data interface{}
+	}
+	pages := []htmlPage{
+		{name: htmlIndexName, tmpl: "index", data: r},
+		{name: htmlTypesName, tmpl: "types", data: r},
+		{name: htmlMemMapName, tmpl: "memmap", data: r},
+	}
+	for _, overlay := range r.Overlays {
+		pages = append(pages, htmlPage{name: overlay.Page, tmpl: "overlay", data: overlay})
+	}
+	for _, page := range pages {
+		htmlPath := filepath.Join(outputDir, page.name)
+		fmt.Println("creating:", htmlPath)
+		f, err := os.Create(htmlPath)
+		if err != nil {
+			return errors.Wrapf(err, "unable to create HTML page %q", htmlPath)
+		}
+		if err := htmlTemplates.ExecuteTemplate(f, page.tmpl, page.data); err != nil {
+			f.Close()
+			return errors.Wrapf(err, "unable to generate HTML page %q", htmlPath)
+		}
+		if err := f.Close(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// htmlReport is the data of an HTML report.
+type htmlReport struct {
+	// Overlays, starting with the default binary.
+	Overlays []*htmlOverlay
+	// Symbols of all overlays.
+	Syms []*htmlSym
+	// Struct and union types.
+	Types []*htmlType
+}
+
+// htmlOverlay is the data of an overlay page.
+type htmlOverlay struct {
+	// Overlay ID (0 for the default binary).
+	ID uint32
+	// Base address at which the overlay is loaded.
+	Addr uint32
+	// Overlay length in bytes.
+	Length uint32
+	// File name of overlay page.
+	Page string
+	// Symbols of overlay, sorted by address.
+	Syms []*htmlSym
+	// Memory map of overlay.
+	Regions []*htmlRegion
+}
+
+// Title returns the title of the overlay.
+func (o *htmlOverlay) Title() string {
+	if o.ID == 0 {
+		return "Default binary"
+	}
+	return fmt.Sprintf("Overlay %X", o.ID)
+}
+
+// htmlSym is a global variable or function of a report.
+type htmlSym struct {
+	// Symbol name.
+	Name string
+	// Symbol kind; "var" or "func".
+	Kind string
+	// Address.
+	Addr uint32
+	// Size in bytes.
+	Size uint32
+	// C syntax representation of the declaration.
+	Decl string
+	// Source file.
+	Path string
+	// Enclosing overlay.
+	Overlay *htmlOverlay
+}
+
+// htmlRegion is a region of the memory map of an overlay.
+type htmlRegion struct {
+	// Symbol occupying the region; or nil if a gap.
+	Sym *htmlSym
+	// Start address.
+	Start uint32
+	// End address (exclusive).
+	End uint32
+	// Offset and width of the region, in percent of the overlay.
+	Left, Width float64
+}
+
+// htmlType is a struct or union type of a report.
+type htmlType struct {
+	// Struct or union keyword.
+	Kind string
+	// Type tag.
+	Tag string
+	// Size in bytes.
+	Size uint32
+	// Fields and paddings, in order of offset.
+	Fields []*htmlField
+}
+
+// htmlField is a field or padding of a struct or union type.
+type htmlField struct {
+	// Offset in bytes.
+	Offset uint32
+	// Size in bytes.
+	Size uint32
+	// C syntax representation of the field; or empty if padding.
+	Decl string
+	// Bit offset and width of bitfield.
+	BitOffset, BitWidth uint32
+}
+
+// newHTMLReport returns the data of an HTML report of the declarations and type
+// information recorded by the parser.
+func newHTMLReport(p *csym.Parser) *htmlReport {
+	r := &htmlReport{}
+	overlays := append([]*csym.Overlay{p.Overlay}, p.Overlays...)
+	for _, overlay := range overlays {
+		o := &htmlOverlay{
+			ID:     overlay.ID,
+			Addr:   overlay.Addr,
+			Length: overlay.Length,
+			Page:   fmt.Sprintf(htmlOverlayNameFormat, overlay.ID),
+		}
+		for _, v := range overlay.Vars {
+			sym := &htmlSym{
+				Name:    v.Name,
+				Kind:    "var",
+				Addr:    v.Addr,
+				Size:    v.Size,
+				Decl:    declString(v.Var),
+				Overlay: o,
+			}
+			o.Syms = append(o.Syms, sym)
+		}
+		for _, f := range overlay.Funcs {
+			sym := &htmlSym{
+				Name:    f.Name,
+				Kind:    "func",
+				Addr:    f.Addr,
+				Size:    f.Size,
+				Decl:    declString(f.Var),
+				Path:    f.Path,
+				Overlay: o,
+			}
+			o.Syms = append(o.Syms, sym)
+		}
+		sort.SliceStable(o.Syms, func(i, j int) bool {
+			return o.Syms[i].Addr < o.Syms[j].Addr
+		})
+		o.Regions = memoryMap(o.Syms)
+		r.Overlays = append(r.Overlays, o)
+		r.Syms = append(r.Syms, o.Syms...)
+	}
+	for _, tag := range p.StructTags {
+		t := p.Structs[tag]
+		r.Types = append(r.Types, newHTMLType("struct", t.Tag, t.Size, t.Fields, t.Paddings()))
+	}
+	for _, tag := range p.UnionTags {
+		t := p.Unions[tag]
+		r.Types = append(r.Types, newHTMLType("union", t.Tag, t.Size, t.Fields, nil))
+	}
+	return r
+}
+
+// newHTMLType returns the struct or union type of a report, with the given
+// keyword, tag, size, fields and paddings.
+func newHTMLType(kind, tag string, size uint32, fields []c.Field, pads []c.Padding) *htmlType {
+	t := &htmlType{
+		Kind: kind,
+		Tag:  tag,
+		Size: size,
+	}
+	for _, field := range fields {
+		f := &htmlField{
+			Offset:    field.Offset,
+			Size:      field.Size,
+			Decl:      declString(field.Var),
+			BitOffset: field.BitOffset,
+			BitWidth:  field.BitWidth,
+		}
+		t.Fields = append(t.Fields, f)
+	}
+	for _, pad := range pads {
+		f := &htmlField{
+			Offset: pad.Offset,
+			Size:   pad.Size,
+		}
+		t.Fields = append(t.Fields, f)
+	}
+	sort.SliceStable(t.Fields, func(i, j int) bool {
+		return t.Fields[i].Offset < t.Fields[j].Offset
+	})
+	return t
+}
+
+// memoryMap returns the memory map of the given symbols sorted by address;
+// the regions occupied by symbols of known size, and the gaps between them.
+func memoryMap(syms []*htmlSym) []*htmlRegion {
+	var regions []*htmlRegion
+	for _, sym := range syms {
+		if sym.Size == 0 {
+			continue
+		}
+		start, end := sym.Addr, sym.Addr+sym.Size
+		if len(regions) > 0 {
+			prev := regions[len(regions)-1]
+			if start < prev.End {
+				// Skip overlapping symbols (e.g. aliases).
+				continue
+			}
+			if start > prev.End {
+				regions = append(regions, &htmlRegion{Start: prev.End, End: start})
+			}
+		}
+		regions = append(regions, &htmlRegion{Sym: sym, Start: start, End: end})
+	}
+	if len(regions) == 0 {
+		return nil
+	}
+	min, max := regions[0].Start, regions[len(regions)-1].End
+	span := float64(max - min)
+	for _, region := range regions {
+		region.Left = 100 * float64(region.Start-min) / span
+		region.Width = 100 * float64(region.End-region.Start) / span
+	}
+	return regions
+}
+
+// htmlTemplates holds the templates of the pages of an HTML report.
+var htmlTemplates = template.Must(template.New("html").Funcs(template.FuncMap{
+	"hex": func(v uint32) string {
+		return fmt.Sprintf("0x%08X", v)
+	},
+	"sub": func(a, b uint32) uint32 {
+		return a - b
+	},
+}).Parse(htmlTemplatesSrc))
+
+// htmlTemplatesSrc is the source of the templates of the pages of an HTML
+// report.
+const htmlTemplatesSrc = `
+{{- define "header" -}}
+<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ . }}</title>
+<style>
+body { font-family: sans-serif; margin: 1em 2em; }
+nav a { margin-right: 1em; }
+table { border-collapse: collapse; }
+th, td { padding: 2px 8px; text-align: left; vertical-align: top; }
+th { background: #eee; position: sticky; top: 0; }
+tr:nth-child(even) { background: #f8f8f8; }
+code, td.mono { font-family: monospace; }
+tr.pad { color: #a00; font-style: italic; }
+.map { position: relative; height: 1.2em; width: 40em; background: #eee; }
+.map div { position: absolute; top: 0; height: 100%; background: #48c; min-width: 1px; }
+.map div.gap { background: #e88; }
+</style>
+</head>
+<body>
+<nav><a href="index.html">Symbols</a><a href="types.html">Types</a><a href="memmap.html">Memory map</a></nav>
+<h1>{{ . }}</h1>
+{{- end -}}
+
+{{- define "footer" -}}
+</body>
+</html>
+{{ end -}}
+
+{{- define "symtable" -}}
+<table id="syms">
+<tr><th>Address</th><th>Kind</th><th>Size</th><th>Name</th><th>Declaration</th><th>Overlay</th><th>Source file</th></tr>
+{{- range . }}
+<tr><td class="mono">{{ hex .Addr }}</td><td>{{ .Kind }}</td><td>{{ if .Size }}{{ .Size }}{{ end }}</td><td class="mono">{{ .Name }}</td><td class="mono">{{ .Decl }}</td><td><a href="{{ .Overlay.Page }}">{{ .Overlay.Title }}</a></td><td class="mono">{{ .Path }}</td></tr>
+{{- end }}
+</table>
+{{- end -}}
+
+{{- define "index" -}}
+{{ template "header" "Symbols" }}
+<h2>Overlays</h2>
+<table>
+<tr><th>Overlay</th><th>Address</th><th>Length</th><th>Symbols</th></tr>
+{{- range .Overlays }}
+<tr><td><a href="{{ .Page }}">{{ .Title }}</a></td><td class="mono">{{ hex .Addr }}</td><td>{{ .Length }}</td><td>{{ len .Syms }}</td></tr>
+{{- end }}
+</table>
+<h2>Symbol table</h2>
+<p><input id="search" type="search" placeholder="Search symbols" size="40" autofocus></p>
+{{ template "symtable" .Syms }}
+<script>
+document.getElementById("search").addEventListener("input", function() {
+	var query = this.value.toLowerCase();
+	var rows = document.getElementById("syms").rows;
+	for (var i = 1; i < rows.length; i++) {
+		var text = rows[i].textContent.toLowerCase();
+		rows[i].style.display = text.indexOf(query) === -1 ? "none" : "";
+	}
+});
+</script>
+{{ template "footer" }}
+{{- end -}}
+
+{{- define "overlay" -}}
+{{ template "header" .Title }}
+<p>Address <code>{{ hex .Addr }}</code>, length {{ .Length }} bytes.</p>
+{{ template "symtable" .Syms }}
+{{ template "footer" }}
+{{- end -}}
+
+{{- define "types" -}}
+{{ template "header" "Types" }}
+<ul>
+{{- range .Types }}
+<li><a href="#{{ .Kind }}_{{ .Tag }}">{{ .Kind }} {{ .Tag }}</a></li>
+{{- end }}
+</ul>
+{{- range .Types }}
+<h2 id="{{ .Kind }}_{{ .Tag }}">{{ .Kind }} {{ .Tag }}</h2>
+<p>Size {{ .Size }} bytes.</p>
+<table>
+<tr><th>Offset</th><th>Size</th><th>Field</th></tr>
+{{- range .Fields }}
+{{- if .Decl }}
+<tr><td class="mono">{{ printf "0x%X" .Offset }}{{ if .BitWidth }}:{{ .BitOffset }}{{ end }}</td><td>{{ if .BitWidth }}{{ .BitWidth }} bits{{ else }}{{ .Size }}{{ end }}</td><td class="mono">{{ .Decl }}</td></tr>
+{{- else }}
+<tr class="pad"><td class="mono">{{ printf "0x%X" .Offset }}</td><td>{{ .Size }}</td><td>padding</td></tr>
+{{- end }}
+{{- end }}
+</table>
+{{- end }}
+{{ template "footer" }}
+{{- end -}}
+
+{{- define "memmap" -}}
+{{ template "header" "Memory map" }}
+{{- range .Overlays }}
+<h2><a href="{{ .Page }}">{{ .Title }}</a></h2>
+<table>
+<tr><th>Start</th><th>End</th><th>Size</th><th>Symbol</th><th>Map</th></tr>
+{{- range .Regions }}
+<tr{{ if not .Sym }} class="pad"{{ end }}><td class="mono">{{ hex .Start }}</td><td class="mono">{{ hex .End }}</td><td>{{ sub .End .Start }}</td><td class="mono">{{ if .Sym }}{{ .Sym.Name }}{{ else }}gap{{ end }}</td><td><div class="map"><div{{ if not .Sym }} class="gap"{{ end }} style="left: {{ printf "%.3f" .Left }}%; width: {{ printf "%.3f" .Width }}%"></div></div></td></tr>
+{{- end }}
+</table>
+{{- end }}
+{{ template "footer" }}
+{{- end -}}
+`
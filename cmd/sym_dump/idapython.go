@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// IDAPython script name.
+const idaPythonName = "ida_import_symbols.py"
+
+// dumpIDAPython outputs the declarations and type information recorded by the
+// parser to an IDAPython script stored in the output directory. When run in
+// IDA, the script:
+//
+//   - parses the C types of the SYM file (as output by -types) as local types;
+//   - creates a segment for each overlay at its load address, unless present;
+//   - names functions and global variables, and applies their types, in the
+//     segment of their overlay.
+//
+// Overlays share load addresses, while IDA segments may not overlap. An overlay
+// whose load address range is occupied by another segment is therefore created
+// at the next free address, and its symbols are located relative to the start
+// of its segment.
+func dumpIDAPython(p *csym.Parser, outputDir string) error {
+	if err := createOutputFile(outputDir, idaPythonName, func(f io.Writer) error {
+		w := bufio.NewWriter(f)
+		w.WriteString(idaPythonHeader)
+		// Types.
+		writePythonTypes(w, p)
+		w.WriteString("\nparse_types()\n")
+		// Declarations.
+		overlays := append([]*csym.Overlay{p.Overlay}, p.Overlays...)
+		for _, overlay := range overlays {
+			if overlay.ID == 0 || overlay.Length == 0 {
+				w.WriteString("\nd = 0\n")
+			} else {
+				name := fmt.Sprintf("overlay_%x", overlay.ID)
+				fmt.Fprintf(w, "\nd = overlay(%q, 0x%08X, 0x%X)\n", name, overlay.Addr, overlay.Length)
+			}
+			for _, fn := range overlay.Funcs {
+				fmt.Fprintf(w, "func(d + 0x%08X, %q, %q)\n", fn.Addr, fn.Name, declString(fn.Var)+";")
+			}
+			for _, v := range overlay.Vars {
+				fmt.Fprintf(w, "var(d + 0x%08X, %q, %q)\n", v.Addr, v.Name, declString(v.Var)+";")
+			}
+		}
+		return w.Flush()
+	}); err != nil {
+		return errors.Wrap(err, "unable to create IDAPython script")
+	}
+	return nil
+}
+
+// idaPythonHeader is the header of the IDAPython script, defining the helper
+// functions used to parse types, create overlay segments and name symbols.
+const idaPythonHeader = `# Import symbols of PS1 SYM file, as generated by sym_dump.
+#
+# Overlay segments are created uninitialized; load the contents of overlays
+# into their segments using File > Load file > Additional binary file.
+
+import ida_segment
+import idaapi
+import idautils
+import idc
+
+def parse_types():
+	n = idc.parse_decls(TYPES, idc.PT_SILENT)
+	if n > 0:
+		print("unable to parse %d type declarations" % n)
+
+def overlay(name, addr, length):
+	"""
+	Creates the segment of the overlay, unless present, and returns the delta
+	of its start address relative to its load address.
+	"""
+	seg = ida_segment.get_segm_by_name(name)
+	if seg is not None:
+		return seg.start_ea - addr
+	start = addr
+	end = addr
+	for ea in idautils.Segments():
+		s = ida_segment.getseg(ea)
+		if s.start_ea < addr + length and addr < s.end_ea:
+			start = None
+		end = max(end, s.end_ea)
+	if start is None:
+		# Load address range occupied; relocate to the next free address.
+		start = (end + 0xFFFF) & ~0xFFFF
+	idc.add_segm_ex(start, start + length, 0, 1, idaapi.saRelPara, idaapi.scPub, idc.ADDSEG_NOSREG)
+	idc.set_segm_name(start, name)
+	idc.set_segm_class(start, "CODE")
+	idc.set_cmt(start, "%s loaded at 0x%08X" % (name, addr), 1)
+	return start - addr
+
+def func(ea, name, decl):
+	idc.set_name(ea, name, idc.SN_NOWARN)
+	idc.add_func(ea)
+	idc.SetType(ea, decl)
+
+def var(ea, name, decl):
+	idc.set_name(ea, name, idc.SN_NOWARN)
+	idc.del_items(ea)
+	idc.SetType(ea, decl)
+`
+
+// ### [ Helper functions ] ####################################################
+
+// writePythonTypes writes the C types recorded by the parser (as output by
+// -types) to the TYPES raw string variable of a Python script. Includes of
+// system headers are omitted, as they are not resolved by the C parsers of
+// disassemblers.
+func writePythonTypes(w *bufio.Writer, p *csym.Parser) {
+	header, _ := typesHeader(p, c.DefaultStyle, guardIfndef)
+	w.WriteString("\nTYPES = r\"\"\"\n")
+	for _, line := range strings.Split(header, "\n") {
+		if strings.HasPrefix(line, "#include ") {
+			continue
+		}
+		w.WriteString(strings.Replace(line, `"""`, `" " "`, -1))
+		w.WriteString("\n")
+	}
+	w.WriteString("\"\"\"\n")
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// IDC script name.
+const idcName = "symbols.idc"
+
+// dumpIDC outputs the declarations and type information recorded by the parser
+// to IDC scripts stored in the output directory; one script for the default
+// binary, and one for each overlay (stored in overlay_N). Each script is
+// self-contained, and when run in IDA:
+//
+//   - creates enums, and structs and unions with member offsets of the SYM
+//     file;
+//   - creates type definitions;
+//   - names functions and global variables; and
+//   - applies function prototypes and global variable types.
+//
+// Bitfields are not represented, as they are not supported by the structure
+// API of IDC.
+func dumpIDC(p *csym.Parser, outputDir string) error {
+	// Create script for declarations of default binary.
+	if err := dumpIDCOverlay(p, p.Overlay, outputDir); err != nil {
+		return errors.WithStack(err)
+	}
+	// Create scripts for declarations of overlays.
+	for _, overlay := range p.Overlays {
+		if err := dumpIDCOverlay(p, overlay, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// dumpIDCOverlay outputs the type information recorded by the parser and the
+// declarations of the overlay to an IDC script.
+func dumpIDCOverlay(p *csym.Parser, overlay *csym.Overlay, outputDir string) error {
+	dir := outputDir
+	if overlay.ID != 0 {
+		overlayDir := fmt.Sprintf("overlay_%x", overlay.ID)
+		dir = filepath.Join(outputDir, overlayDir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if err := createOutputFile(dir, idcName, func(f io.Writer) error {
+		w := bufio.NewWriter(f)
+		w.WriteString("#include <idc.idc>\n")
+		idcEnums(w, p)
+		idcStructs(w, p)
+		idcTypedefs(w, p)
+		idcDecls(w, overlay)
+		w.WriteString("\nstatic main() {\n")
+		w.WriteString("\tcreate_enums();\n")
+		w.WriteString("\tcreate_structs();\n")
+		w.WriteString("\tcreate_typedefs();\n")
+		w.WriteString("\tset_member_types();\n")
+		w.WriteString("\tset_names();\n")
+		w.WriteString("\tset_types();\n")
+		w.WriteString("}\n")
+		return w.Flush()
+	}); err != nil {
+		return errors.Wrap(err, "unable to create IDC script")
+	}
+	return nil
+}
+
+// --- [ Types ] ---------------------------------------------------------------
+
+// idcEnums writes the create_enums function of the IDC script, which creates
+// the enums recorded by the parser.
+func idcEnums(w *bufio.Writer, p *csym.Parser) {
+	w.WriteString("\nstatic create_enums() {\n")
+	w.WriteString("\tauto id;\n")
+	for _, tag := range p.EnumTags {
+		t := p.Enums[tag]
+		fmt.Fprintf(w, "\t// enum %s\n", t.Tag)
+		fmt.Fprintf(w, "\tid = get_enum(%q);\n", t.Tag)
+		fmt.Fprintf(w, "\tif (id == -1) id = add_enum(-1, %q, 0);\n", t.Tag)
+		for _, member := range t.Members {
+			value := fmt.Sprintf("0x%X", member.Value)
+			if t.Signed && int32(member.Value) < 0 {
+				value = fmt.Sprintf("%d", int32(member.Value))
+			}
+			fmt.Fprintf(w, "\tadd_enum_member(id, %q, %s, -1);\n", member.Name, value)
+		}
+	}
+	w.WriteString("}\n")
+}
+
+// idcStructs writes the create_structs and set_member_types functions of the
+// IDC script. create_structs creates the structs and unions recorded by the
+// parser, with members of the data flags of their size at their offset.
+// set_member_types applies the C types of members, once type definitions are
+// created.
+func idcStructs(w *bufio.Writer, p *csym.Parser) {
+	w.WriteString("\nstatic create_structs() {\n")
+	w.WriteString("\tauto id;\n")
+	// Create structs and unions before adding members, to resolve member
+	// types of structs and unions defined later.
+	for _, tag := range p.StructTags {
+		fmt.Fprintf(w, "\tif (get_struc_id(%q) == -1) add_struc(-1, %q, 0);\n", tag, tag)
+	}
+	for _, tag := range p.UnionTags {
+		fmt.Fprintf(w, "\tif (get_struc_id(%q) == -1) add_struc(-1, %q, 1);\n", tag, tag)
+	}
+	for _, tag := range p.StructTags {
+		t := p.Structs[tag]
+		fmt.Fprintf(w, "\t// struct %s\n", t.Tag)
+		fmt.Fprintf(w, "\tid = get_struc_id(%q);\n", t.Tag)
+		for _, field := range t.Fields {
+			if field.BitWidth > 0 {
+				continue
+			}
+			idcMember(w, field, fmt.Sprintf("0x%X", field.Offset))
+		}
+		if t.Size > 0 {
+			fmt.Fprintf(w, "\tif (get_struc_size(id) < 0x%X) expand_struc(id, get_struc_size(id), 0x%X - get_struc_size(id), 0);\n", t.Size, t.Size)
+		}
+	}
+	for _, tag := range p.UnionTags {
+		t := p.Unions[tag]
+		fmt.Fprintf(w, "\t// union %s\n", t.Tag)
+		fmt.Fprintf(w, "\tid = get_struc_id(%q);\n", t.Tag)
+		for _, field := range t.Fields {
+			if field.BitWidth > 0 {
+				continue
+			}
+			idcMember(w, field, "-1")
+		}
+	}
+	w.WriteString("}\n")
+	w.WriteString("\nstatic set_member_types() {\n")
+	w.WriteString("\tauto id;\n")
+	setTypes := func(tag string, fields []c.Field) {
+		fmt.Fprintf(w, "\tid = get_struc_id(%q);\n", tag)
+		for _, field := range fields {
+			if field.BitWidth > 0 || len(field.Name) == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "\tSetType(get_member_id(id, get_member_offset(id, %q)), %q);\n", field.Name, typeString(field.Type))
+		}
+	}
+	for _, tag := range p.StructTags {
+		t := p.Structs[tag]
+		setTypes(t.Tag, t.Fields)
+	}
+	for _, tag := range p.UnionTags {
+		t := p.Unions[tag]
+		setTypes(t.Tag, t.Fields)
+	}
+	w.WriteString("}\n")
+}
+
+// idcMember writes the add_struc_member call adding the given field at the
+// given offset to the struct or union of the variable id.
+func idcMember(w *bufio.Writer, field c.Field, offset string) {
+	size := field.Size
+	if size == 0 {
+		size = c.Sizeof(field.Type)
+	}
+	if size == 0 {
+		// Flexible array members and fields of incomplete type.
+		return
+	}
+	// Element type of arrays.
+	elem := underlyingType(field.Type)
+	for {
+		t, ok := elem.(*c.ArrayType)
+		if !ok {
+			break
+		}
+		elem = underlyingType(t.Elem)
+	}
+	switch t := elem.(type) {
+	case *c.StructType:
+		fmt.Fprintf(w, "\tadd_struc_member(id, %q, %s, FF_STRUCT|FF_DATA, get_struc_id(%q), 0x%X);\n", field.Name, offset, t.Tag, size)
+	case *c.UnionType:
+		fmt.Fprintf(w, "\tadd_struc_member(id, %q, %s, FF_STRUCT|FF_DATA, get_struc_id(%q), 0x%X);\n", field.Name, offset, t.Tag, size)
+	default:
+		fmt.Fprintf(w, "\tadd_struc_member(id, %q, %s, %s|FF_DATA, -1, 0x%X);\n", field.Name, offset, idcDataFlag(c.Sizeof(elem)), size)
+	}
+}
+
+// idcTypedefs writes the create_typedefs function of the IDC script, which
+// creates the type definitions recorded by the parser.
+func idcTypedefs(w *bufio.Writer, p *csym.Parser) {
+	w.WriteString("\nstatic create_typedefs() {\n")
+	for _, def := range p.Typedefs {
+		v, ok := def.(*c.VarDecl)
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(w, "\tparse_decls(%q, 0);\n", fmt.Sprintf("typedef %s;", declString(v.Var)))
+	}
+	w.WriteString("}\n")
+}
+
+// --- [ Declarations ] --------------------------------------------------------
+
+// idcDecls writes the set_names and set_types functions of the IDC script,
+// which name the functions and global variables of the overlay, and apply
+// their function prototypes and types, respectively.
+func idcDecls(w *bufio.Writer, overlay *csym.Overlay) {
+	w.WriteString("\nstatic set_names() {\n")
+	for _, f := range overlay.Funcs {
+		fmt.Fprintf(w, "\tset_name(0x%08X, %q, SN_NOWARN);\n", f.Addr, f.Name)
+	}
+	for _, v := range overlay.Vars {
+		fmt.Fprintf(w, "\tset_name(0x%08X, %q, SN_NOWARN);\n", v.Addr, v.Name)
+	}
+	w.WriteString("}\n")
+	w.WriteString("\nstatic set_types() {\n")
+	for _, f := range overlay.Funcs {
+		fmt.Fprintf(w, "\tSetType(0x%08X, %q);\n", f.Addr, declString(f.Var)+";")
+	}
+	for _, v := range overlay.Vars {
+		fmt.Fprintf(w, "\tdel_items(0x%08X);\n", v.Addr)
+		fmt.Fprintf(w, "\tSetType(0x%08X, %q);\n", v.Addr, declString(v.Var)+";")
+	}
+	w.WriteString("}\n")
+}
+
+// ### [ Helper functions ] ####################################################
+
+// idcDataFlag returns the IDC data flag of scalars of the given size in bytes.
+func idcDataFlag(size uint32) string {
+	switch size {
+	case 2:
+		return "FF_WORD"
+	case 4:
+		return "FF_DWORD"
+	case 8:
+		return "FF_QWORD"
+	}
+	return "FF_BYTE"
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+)
+
+// Label include file names.
+const (
+	// armips include file.
+	armipsName = "labels.asm"
+	// asmpsx include file.
+	asmpsxName = "labels.inc"
+)
+
+// dumpLabels outputs the declarations recorded by the parser to label include
+// files of armips and asmpsx stored in the output directory; one of each for the
+// default binary, and for each overlay (stored in overlay_N).
+func dumpLabels(p *csym.Parser, outputDir string) error {
+	// Create include files of default binary.
+	if err := dumpLabelsOverlay(p.Overlay, outputDir); err != nil {
+		return errors.WithStack(err)
+	}
+	// Create include files of overlays.
+	for _, overlay := range p.Overlays {
+		if err := dumpLabelsOverlay(overlay, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// dumpLabelsOverlay outputs the declarations of the overlay to label include
+// files, sorted by address, so that assembly patches may reference the
+// functions and global variables of the original binary by name. Symbols
+// without address are omitted.
+//
+// The armips include file defines labels using `.definelabel NAME, ADDRESS`,
+// and the asmpsx include file defines symbols using `NAME equ $ADDRESS`.
+func dumpLabelsOverlay(overlay *csym.Overlay, outputDir string) error {
+	dir := outputDir
+	if overlay.ID != 0 {
+		overlayDir := fmt.Sprintf("overlay_%x", overlay.ID)
+		dir = filepath.Join(outputDir, overlayDir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	syms := definedSymbols(sortedSymbols(overlay))
+	// Create armips include file.
+	armipsPath := filepath.Join(dir, armipsName)
+	fmt.Println("creating:", armipsPath)
+	f, err := os.Create(armipsPath)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create armips include file %q", armipsPath)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	w.WriteString("; Labels of PS1 SYM file, as generated by sym_dump.\n\n")
+	for _, sym := range syms {
+		fmt.Fprintf(w, ".definelabel %s, 0x%08X\n", sym.name, sym.addr)
+	}
+	if err := w.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+	// Create asmpsx include file.
+	asmpsxPath := filepath.Join(dir, asmpsxName)
+	fmt.Println("creating:", asmpsxPath)
+	f, err = os.Create(asmpsxPath)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create asmpsx include file %q", asmpsxPath)
+	}
+	defer f.Close()
+	w = bufio.NewWriter(f)
+	w.WriteString("; Labels of PS1 SYM file, as generated by sym_dump.\n\n")
+	for _, sym := range syms {
+		fmt.Fprintf(w, "%s\tequ\t$%08X\n", sym.name, sym.addr)
+	}
+	if err := w.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// m2c context file name.
+const m2cName = "m2c_ctx.c"
+
+// dumpM2C outputs the type information and declarations recorded by the parser
+// to m2c context files stored in the output directory; one for the default
+// binary, and one for each overlay (stored in overlay_N). The context file of
+// an overlay also contains the declarations of the default binary, as
+// referenced by the overlay.
+func dumpM2C(p *csym.Parser, outputDir string) error {
+	// Create context file of default binary.
+	if err := dumpM2COverlay(p, []*csym.Overlay{p.Overlay}, outputDir); err != nil {
+		return errors.WithStack(err)
+	}
+	// Create context files of overlays.
+	for _, overlay := range p.Overlays {
+		if err := dumpM2COverlay(p, []*csym.Overlay{p.Overlay, overlay}, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// dumpM2COverlay outputs the type information recorded by the parser and the
+// declarations of the given overlays to a self-contained C context file, as
+// passed to m2c using `--context m2c_ctx.c`. The context file is stored in the
+// directory of the last overlay.
+//
+// The context file contains, in order, forward declarations of structs and
+// unions, type definitions sorted in dependency order, global variable
+// declarations and function prototypes. System headers are not included, as
+// m2c does not run the C preprocessor; the fixed-width integer types of
+// stdint.h are defined in place.
+func dumpM2COverlay(p *csym.Parser, overlays []*csym.Overlay, outputDir string) error {
+	dir := outputDir
+	if overlay := overlays[len(overlays)-1]; overlay.ID != 0 {
+		overlayDir := fmt.Sprintf("overlay_%x", overlay.ID)
+		dir = filepath.Join(outputDir, overlayDir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if err := createOutputFile(dir, m2cName, func(f io.Writer) error {
+		w := bufio.NewWriter(f)
+		writeM2CTypes(w, p)
+		// Global variable declarations.
+		for _, overlay := range overlays {
+			for _, v := range overlay.Vars {
+				fmt.Fprintf(w, "%s;\n", m2cVarDecl(v))
+			}
+		}
+		w.WriteString("\n")
+		// Function prototypes.
+		for _, overlay := range overlays {
+			for _, f := range overlay.Funcs {
+				fmt.Fprintf(w, "%s;\n", funcProto(f))
+			}
+		}
+		return w.Flush()
+	}); err != nil {
+		return errors.Wrap(err, "unable to create m2c context file")
+	}
+	return nil
+}
+
+// writeM2CTypes writes the type definitions recorded by the parser to w,
+// replacing includes of system headers.
+func writeM2CTypes(w *bufio.Writer, p *csym.Parser) {
+	header, _ := typesHeader(p, c.DefaultStyle, guardIfndef)
+	for _, line := range strings.Split(strings.TrimRight(header, "\n"), "\n") {
+		if strings.HasPrefix(line, "#include ") {
+			include := strings.Trim(strings.TrimPrefix(line, "#include "), `<>"`)
+			if include == "stdint.h" {
+				w.WriteString(m2cStdint)
+			}
+			continue
+		}
+		w.WriteString(line)
+		w.WriteString("\n")
+	}
+	w.WriteString("\n")
+}
+
+// m2cStdint defines the fixed-width integer types of stdint.h.
+const m2cStdint = `typedef signed char int8_t;
+typedef unsigned char uint8_t;
+typedef short int16_t;
+typedef unsigned short uint16_t;
+typedef int int32_t;
+typedef unsigned int uint32_t;
+typedef long long int64_t;
+typedef unsigned long long uint64_t;
+`
+
+// ### [ Helper functions ] ####################################################
+
+// m2cVarDecl returns the C declaration of the given global variable; external
+// unless static.
+func m2cVarDecl(v *c.VarDecl) string {
+	if v.Class == c.Static {
+		return fmt.Sprintf("%s %s", v.Class, v.Var)
+	}
+	return fmt.Sprintf("%s %s", c.Extern, v.Var)
+}
@@ -3,24 +3,57 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
+	"debug/elf"
+	"encoding/binary"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"math"
 	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"text/template"
 
 	"github.com/pkg/errors"
 	"github.com/rickypai/natsort"
 	"github.com/sanctuary/sym"
+	"github.com/sanctuary/sym/cheader"
 	"github.com/sanctuary/sym/csym"
 	"github.com/sanctuary/sym/csym/c"
+	"github.com/sanctuary/sym/dwarfsym"
+	"github.com/sanctuary/sym/ghidra"
+	"github.com/sanctuary/sym/psymap"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
 )
 
 // usage prints usage information.
 func usage() {
 	const use = `
 Convert Playstation 1 SYM files to C headers (*.sym -> *.h) and scripts for importing symbol information into IDA.
+
+Usage:
+
+	sym_dump [OPTION]... FILE...
+	sym_dump convert [OPTION]... -to FORMAT FILE...
+	sym_dump diff [OPTION]... OLD NEW
+	sym_dump filter [OPTION]... FILE...
+	sym_dump rename [OPTION]... -map MAPPING FILE...
+	sym_dump rebase [OPTION]... -delta DELTA FILE...
+	sym_dump extract [OPTION]... -overlay ID FILE...
+	sym_dump query [OPTION]... FILE ADDR...
+	sym_dump stats [OPTION]... FILE...
+	sym_dump validate [OPTION]... FILE...
+
+Symbol files are read from standard input if FILE is "-", and transparently decompressed if gzip compressed (e.g. FILE.sym.gz).
+
+Run "sym_dump convert -h" for the supported input and output formats of the convert command.
 `
 	fmt.Println(use[1:])
 	flag.PrintDefaults()
@@ -30,83 +63,718 @@ Convert Playstation 1 SYM files to C headers (*.sym -> *.h) and scripts for impo
 const dumpDir = "_dump_"
 
 func main() {
+	// Subcommands.
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "convert":
+			// Convert between formats.
+			if err := convert(os.Args[2:]); err != nil {
+				log.Fatalf("%+v", err)
+			}
+			return
+		case "diff":
+			// Compare symbol files.
+			if err := diff(os.Args[2:]); err != nil {
+				log.Fatalf("%+v", err)
+			}
+			return
+		case "filter":
+			// Reduce symbol files.
+			if err := filter(os.Args[2:]); err != nil {
+				log.Fatalf("%+v", err)
+			}
+			return
+		case "rename":
+			// Rename symbols.
+			if err := rename(os.Args[2:]); err != nil {
+				log.Fatalf("%+v", err)
+			}
+			return
+		case "rebase":
+			// Slide addresses of symbols.
+			if err := rebase(os.Args[2:]); err != nil {
+				log.Fatalf("%+v", err)
+			}
+			return
+		case "extract":
+			// Extract symbols of overlay.
+			if err := extract(os.Args[2:]); err != nil {
+				log.Fatalf("%+v", err)
+			}
+			return
+		case "query":
+			// Look up symbols.
+			if err := query(os.Args[2:]); err != nil {
+				log.Fatalf("%+v", err)
+			}
+			return
+		case "stats":
+			// Print statistics of symbol files.
+			if err := stats(os.Args[2:]); err != nil {
+				log.Fatalf("%+v", err)
+			}
+			return
+		case "validate":
+			// Validate symbol files.
+			failed, err := validate(os.Args[2:])
+			if err != nil {
+				log.Fatalf("%+v", err)
+			}
+			if failed {
+				os.Exit(1)
+			}
+			return
+		}
+	}
 	// Command line flags.
 	var (
-		// Output C types and declarations.
-		outputC bool
 		// Output directory.
 		outputDir string
-		// Output IDA scripts.
-		outputIDA bool
+		// Skip symbols of unknown kind.
+		lenient bool
+		// Skip corrupted symbols.
+		recoverSyms bool
+		// Text encoding of symbol names.
+		encodingName string
+		// Byte order of SYM files.
+		endian string
 		// Merge SYM files.
 		merge bool
-		// Split output into source files.
-		splitSrc bool
-		// Output C types.
-		outputTypes bool
+		// Conflict resolution policy of merge mode.
+		mergePolicy string
+		// Include guard style of C headers.
+		guard string
+		// Sort order of declarations and type definitions.
+		sortName string
+		// Insert explicit padding members into structs.
+		pad bool
+		// Map integer types to stdint.h types.
+		stdint bool
+		// Overrides of stdint.h type mapping.
+		stdintMap string
+		// Number of spaces of each indentation level (0 for tabs).
+		indent int
+		// Place opening braces on separate lines.
+		allman bool
+		// Use block comments.
+		blockComments bool
+		// Maximum line width of declarations.
+		width int
+		// Define enums inline in type definitions.
+		typedefEnums bool
+		// Print member values of bit flag enums in hexadecimal.
+		hexEnums bool
+		// Rename types with fake tags.
+		renameFake bool
+		// Emit Doxygen comment blocks.
+		doxygen bool
+		// Output in JSON format.
+		outputJSON bool
+		// Omit file offsets from the Psy-Q DUMPSYM.EXE output.
+		noOffsets bool
+		// Output binary SYM files.
+		outputSYM bool
+		// Go text/template of custom output format.
+		templatePath string
 	)
-	flag.BoolVar(&outputC, "c", false, "output C types and declarations")
+	dumpOpts := &dumpOptions{}
+	flag.BoolVar(&dumpOpts.outputC, "c", false, "output C types and declarations")
 	flag.StringVar(&outputDir, "dir", dumpDir, "output directory")
-	flag.BoolVar(&outputIDA, "ida", false, "output IDA scripts")
+	flag.BoolVar(&dumpOpts.outputIDA, "ida", false, "output IDA scripts")
+	flag.BoolVar(&lenient, "lenient", false, "skip symbols of unknown kind")
+	flag.BoolVar(&recoverSyms, "recover", false, "skip corrupted symbols")
+	flag.StringVar(&encodingName, "encoding", "", "text encoding of symbol names (sjis, or auto to detect)")
+	flag.StringVar(&endian, "endian", "", "byte order of SYM files (big or little); detected if not specified")
 	flag.BoolVar(&merge, "merge", false, "merge SYM files")
-	flag.BoolVar(&splitSrc, "src", false, "split output into source files")
-	flag.BoolVar(&outputTypes, "types", false, "output C types")
+	flag.StringVar(&mergePolicy, "mergepolicy", "", "conflict resolution policy of merge mode (prefer-first, prefer-named or error); duplicates are pruned if not specified")
+	flag.BoolVar(&dumpOpts.splitSrc, "src", false, "split output into source files")
+	flag.BoolVar(&dumpOpts.sourceTree, "tree", false, "reconstruct source tree layout of the original project, with a header and stub of each source file (e.g. C:\\PROJ\\SRC\\FOO.C -> src/foo/foo.h and src/foo/foo.c)")
+	flag.BoolVar(&dumpOpts.units, "units", false, "split C headers by translation unit of the original project, grouping type definitions, global variables and function prototypes by source file (e.g. C:\\PROJ\\SRC\\FOO.C -> src/foo.h)")
+	flag.BoolVar(&dumpOpts.overlays, "overlays", false, "split C headers by overlay, with type definitions specific to an overlay output to the types header of the overlay (e.g. overlay_4_types.h), and overlay headers including the declarations of the base executable (decls.h)")
+	flag.BoolVar(&dumpOpts.categories, "categories", false, "split C headers by category of declaration; type definitions (types.h), function prototypes (functions.h) and global variables (variables.h)")
+	flag.StringVar(&guard, "guard", guardIfndef, "include guard style of C headers (ifndef, pragma or none)")
+	flag.StringVar(&sortName, "sort", "original", "sort order of declarations and type definitions (original, address or name)")
+	flag.BoolVar(&dumpOpts.outputTypes, "types", false, "output C types")
+	flag.BoolVar(&pad, "pad", false, "insert explicit padding members into structs")
+	flag.BoolVar(&stdint, "stdint", false, "map integer types to stdint.h types, in all output formats")
+	flag.StringVar(&stdintMap, "stdintmap", "", "comma-separated list of stdint.h type mapping overrides (e.g. \"char=int8_t,u_long=uint32_t\")")
+	flag.IntVar(&indent, "indent", 0, "number of spaces of each indentation level (0 for tabs)")
+	flag.BoolVar(&allman, "allman", false, "place opening braces on separate lines")
+	flag.BoolVar(&blockComments, "blockcomments", false, "use block comments (/* */) rather than line comments (//)")
+	flag.IntVar(&width, "width", 0, "maximum line width of declarations (0 for unlimited)")
+	flag.BoolVar(&typedefEnums, "typedefenums", false, "define enums inline in type definitions (typedef enum {...} Name;)")
+	flag.BoolVar(&hexEnums, "hexenums", false, "print member values of enums likely to be sets of bit flags in hexadecimal")
+	flag.BoolVar(&renameFake, "renamefake", false, "rename types with fake tags (e.g. _123fake) based on their use")
+	flag.BoolVar(&dumpOpts.asserts, "asserts", false, "output static assertions verifying struct and union layouts (asserts.h)")
+	flag.BoolVar(&doxygen, "doxygen", false, "emit metadata comments as Doxygen comment blocks (/** */)")
+	flag.BoolVar(&dumpOpts.check, "check", false, "validate the syntax of generated C headers using an embedded C frontend")
+	flag.BoolVar(&outputJSON, "json", false, "output symbols in JSON format")
+	flag.BoolVar(&noOffsets, "nooffsets", false, "omit file offsets from the Psy-Q DUMPSYM.EXE output, so that dumps of symbol files differing only in layout compare equal")
+	flag.BoolVar(&outputSYM, "sym", false, "output binary SYM files (e.g. to re-encode JSON input files; *.json -> *.sym)")
+	flag.BoolVar(&dumpOpts.outputYAML, "yaml", false, "output symbols and types in YAML format (symbols.yaml)")
+	flag.BoolVar(&dumpOpts.outputCSV, "csv", false, "output flat symbol table in CSV format (symbols.csv)")
+	flag.BoolVar(&dumpOpts.outputProto, "proto", false, "output symbols and types in Protocol Buffers format (symbols.pb; see proto/sym.proto)")
+	flag.BoolVar(&dumpOpts.outputHTML, "html", false, "output static HTML report of symbols and types (index.html)")
+	flag.BoolVar(&dumpOpts.outputDOT, "dot", false, "output type dependency graph in Graphviz DOT format (types.dot)")
+	flag.StringVar(&dumpOpts.dotRoot, "dotroot", "", "limit type dependency graph to types reachable from the given struct, union or enum tag or type definition name")
+	flag.BoolVar(&dumpOpts.outputTags, "tags", false, "output ctags and etags files (tags and TAGS) of generated C headers")
+	flag.BoolVar(&dumpOpts.outputCscope, "cscope", false, "output cscope cross-reference database (cscope.out) of generated C headers")
+	flag.BoolVar(&dumpOpts.outputGhidra, "ghidra", false, "output Ghidra symbol scripts and data type archive (ghidra_symbols.txt, ghidra_import_symbols.py and ghidra_types.xml)")
+	flag.BoolVar(&dumpOpts.outputIDC, "idc", false, "output IDC scripts (symbols.idc)")
+	flag.BoolVar(&dumpOpts.outputIDAPython, "idapython", false, "output IDAPython script creating overlay segments (ida_import_symbols.py)")
+	flag.BoolVar(&dumpOpts.outputR2, "r2", false, "output radare2 script (symbols.r2)")
+	flag.BoolVar(&dumpOpts.outputBinja, "binja", false, "output Binary Ninja script (binja_import_symbols.py)")
+	flag.BoolVar(&dumpOpts.outputNocash, "nocash", false, "output no$psx symbol files (nocash.sym)")
+	flag.BoolVar(&dumpOpts.outputRedux, "redux", false, "output PCSX-Redux symbol maps and Lua scripts (pcsx_redux.map and pcsx_redux.lua)")
+	flag.BoolVar(&dumpOpts.outputGDB, "gdb", false, "output GDB scripts (symbols.gdb)")
+	flag.BoolVar(&dumpOpts.outputELF, "elf", false, "output ELF files containing symbol tables (symbols.elf)")
+	flag.BoolVar(&dumpOpts.outputDWARF, "dwarf", false, "output ELF files with DWARF debug information (debug.elf)")
+	flag.BoolVar(&dumpOpts.outputStabs, "stabs", false, "output assembly files with stabs debug information (stabs.s)")
+	flag.BoolVar(&dumpOpts.outputSplat, "splat", false, "output splat symbol_addrs.txt and undefined symbol linker scripts")
+	flag.BoolVar(&dumpOpts.outputM2C, "m2c", false, "output m2c context files (m2c_ctx.c)")
+	flag.BoolVar(&dumpOpts.outputScratch, "scratch", false, "output decomp.me scratch context of the function given by -scratchfunc")
+	flag.StringVar(&dumpOpts.scratchFunc, "scratchfunc", "", "function name of decomp.me scratch context")
+	flag.StringVar(&dumpOpts.scratchSyms, "scratchsyms", "", "comma-separated list of global variables and functions referenced by the function of decomp.me scratch context")
+	flag.StringVar(&templatePath, "template", "", "render custom output format using the given Go text/template, with the symbol and type model as data (e.g. symbols.txt.tmpl -> symbols.txt)")
+	flag.BoolVar(&dumpOpts.outputAsmDiffer, "asmdiffer", false, "output asm-differ linker maps and settings scripts")
+	flag.BoolVar(&dumpOpts.outputLabels, "labels", false, "output armips and asmpsx label include files (labels.asm and labels.inc)")
+	flag.BoolVar(&dumpOpts.outputStubs, "stubs", false, "output GNU assembler symbol stub files (symbols.s)")
 	flag.Usage = usage
 	flag.Parse()
-	if merge && outputIDA {
+	if outputDir == stdio {
+		log.Fatalf("output to standard output not supported; use -dir - with the convert command instead.")
+	}
+	if merge && (dumpOpts.outputIDA || dumpOpts.outputIDC) {
 		log.Fatalf("IDA output not supported in merge mode, as the scripts would be unusable.")
 	}
+	enc, err := parseEncoding(encodingName)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	order, err := parseByteOrder(endian)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	if dumpOpts.guard, err = parseGuardStyle(guard); err != nil {
+		log.Fatalf("%+v", err)
+	}
+	sortOrder, err := parseSortOrder(sortName)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	dumpOpts.style = c.Style{
+		Indent:        "\t",
+		BraceNewline:  allman,
+		BlockComments: blockComments,
+		MaxWidth:      width,
+		Doxygen:       doxygen,
+	}
+	if indent > 0 {
+		dumpOpts.style.Indent = strings.Repeat(" ", indent)
+	}
+	if len(templatePath) > 0 {
+		if dumpOpts.tmpl, err = parseTemplate(templatePath, dumpOpts.style); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
+	// Output formats are mutually exclusive, except for binary SYM files of the
+	// merged symbols in merge mode.
+	formats := dumpOpts.formats()
+	if outputSYM && !merge {
+		formats = append(formats, "-sym")
+	}
+	if outputJSON {
+		formats = append(formats, "-json")
+	}
+	if len(formats) > 1 {
+		log.Fatalf("incompatible output formats %s; specify at most one output format.", strings.Join(formats, ", "))
+	}
+	var stdintTypes map[string]string
+	if stdint {
+		stdintTypes, err = parseStdintMap(stdintMap)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
 
 	// Parse SYM files.
 	var ps []*csym.Parser
 	for _, path := range flag.Args() {
 		// Parse SYM file.
-		f, err := sym.ParseFile(path)
+		opts := &sym.ParseOptions{
+			Lenient:        lenient,
+			Recover:        recoverSyms,
+			Encoding:       enc,
+			Order:          order,
+			DetectEncoding: strings.EqualFold(encodingName, "auto"),
+		}
+		f, err := parseFile(path, "", opts)
 		if err != nil {
-			log.Fatalf("%+v", err)
+			if errors.Cause(err) != sym.ErrTruncated {
+				log.Fatalf("%+v", err)
+			}
+			// Use the symbols parsed before the end of the truncated file.
+			log.Printf("%s: %v", path, err)
+		}
+		for _, region := range f.Skipped {
+			log.Printf("%s: %v", path, region)
 		}
 		switch {
-		case outputC, outputIDA:
+		case dumpOpts.outputC, dumpOpts.outputIDA, dumpOpts.outputYAML, dumpOpts.outputCSV, dumpOpts.outputProto, dumpOpts.outputHTML, dumpOpts.outputDOT, dumpOpts.outputGhidra, dumpOpts.outputIDC, dumpOpts.outputIDAPython, dumpOpts.outputR2, dumpOpts.outputBinja, dumpOpts.outputNocash, dumpOpts.outputRedux, dumpOpts.outputGDB, dumpOpts.outputELF, dumpOpts.outputDWARF, dumpOpts.outputStabs, dumpOpts.outputSplat, dumpOpts.outputM2C, dumpOpts.outputScratch, dumpOpts.outputAsmDiffer, dumpOpts.outputLabels, dumpOpts.outputStubs, dumpOpts.tmpl != nil, merge && outputSYM:
 			// Parse C types and declarations.
 			p := csym.NewParser()
+			if f.Dialect != nil {
+				p.Dims = f.Dialect.Dims
+			}
 			if merge {
 				ps = append(ps, p)
 			}
 			p.ParseTypes(f.Syms)
+			p.Canonicalize()
+			if pad {
+				insertPadding(p)
+			}
+			if typedefEnums {
+				p.InlineTypedefEnums()
+			}
+			if hexEnums {
+				p.HexFlagEnums()
+			}
 			p.ParseDecls(f.Syms)
+			if renameFake {
+				p.RenameFakeTags()
+			}
+			if stdintTypes != nil {
+				p.MapStdintTypes(stdintTypes)
+			}
 			// Output once for each files if not in merge mode.
 			if !merge {
-				if err := dump(p, outputDir, outputC, outputTypes, outputIDA, splitSrc, merge); err != nil {
+				p.Sort(sortOrder)
+				if err := dump(p, outputDir, dumpOpts); err != nil {
 					log.Fatalf("%+v", err)
 				}
 			}
-		case outputTypes:
+		case dumpOpts.outputTypes:
 			// Parse C types.
 			p := csym.NewParser()
+			if f.Dialect != nil {
+				p.Dims = f.Dialect.Dims
+			}
 			if merge {
 				ps = append(ps, p)
 			}
 			p.ParseTypes(f.Syms)
+			p.Canonicalize()
+			if pad {
+				insertPadding(p)
+			}
+			if typedefEnums {
+				p.InlineTypedefEnums()
+			}
+			if hexEnums {
+				p.HexFlagEnums()
+			}
+			if renameFake {
+				p.RenameFakeTags()
+			}
+			if stdintTypes != nil {
+				p.MapStdintTypes(stdintTypes)
+			}
 			// Output once for each files if not in merge mode.
 			if !merge {
-				if err := dump(p, outputDir, outputC, outputTypes, outputIDA, splitSrc, merge); err != nil {
+				p.Sort(sortOrder)
+				if err := dump(p, outputDir, dumpOpts); err != nil {
 					log.Fatalf("%+v", err)
 				}
 			}
+		case outputSYM:
+			// Output binary SYM file.
+			if err := dumpSYM(f, inputName(path), outputDir); err != nil {
+				log.Fatalf("%+v", err)
+			}
+		case outputJSON:
+			// Output in JSON format.
+			// Note, we never merge the JSON output.
+			buf, err := json.MarshalIndent(f, "", "\t")
+			if err != nil {
+				log.Fatalf("%+v", errors.WithStack(err))
+			}
+			fmt.Println(string(buf))
 		default:
 			// Output in Psy-Q DUMPSYM.EXE format.
 			// Note, we never merge the Psy-Q output.
-			fmt.Print(f)
+			fmt.Print(f.Dump(&sym.DumpOptions{NoOffsets: noOffsets}))
 		}
 	}
 	// Output the merge of all files if in merge mode.
 	if merge {
-		skipAddrDiff := true
-		skipLineDiff := true
-		p := pruneDuplicates(ps, skipAddrDiff, skipLineDiff)
-		if err := dump(p, outputDir, outputC, outputTypes, outputIDA, splitSrc, merge); err != nil {
+		var p *csym.Parser
+		if len(mergePolicy) > 0 {
+			policy, err := parseMergePolicy(mergePolicy)
+			if err != nil {
+				log.Fatalf("%+v", err)
+			}
+			merged, conflicts, err := csym.Merge(ps, policy)
+			if err != nil {
+				log.Fatalf("%+v", err)
+			}
+			for _, conflict := range conflicts {
+				log.Print(conflict)
+			}
+			p = merged
+		} else {
+			skipAddrDiff := true
+			skipLineDiff := true
+			p = pruneDuplicates(ps, skipAddrDiff, skipLineDiff)
+		}
+		p.Sort(sortOrder)
+		// Encode the merged symbols before output, as some output formats prune
+		// types of the parser.
+		var merged *sym.File
+		if outputSYM {
+			if merged, err = encodeParser(p); err != nil {
+				log.Fatalf("%+v", err)
+			}
+		}
+		if err := dump(p, outputDir, dumpOpts); err != nil {
 			log.Fatalf("%+v", err)
 		}
+		if outputSYM {
+			// Output binary SYM file of the merged symbols, after dump has
+			// initialized the output directory.
+			if err := dumpSYM(merged, "merged.sym", outputDir); err != nil {
+				log.Fatalf("%+v", err)
+			}
+		}
+	}
+}
+
+// Input formats of symbol files.
+const (
+	// Playstation 1 SYM file.
+	formatSYM = "sym"
+	// JSON encoded symbol file (*.json).
+	formatJSON = "json"
+	// Ghidra symbol table (*.csv).
+	formatGhidraCSV = "ghidracsv"
+	// Ghidra program (*.xml).
+	formatGhidraXML = "ghidraxml"
+	// ELF file with DWARF debug information.
+	formatELF = "elf"
+	// C header (*.h).
+	formatC = "c"
+	// Psy-Q linker MAP file.
+	formatPsyqMap = "psyqmap"
+	// no$psx symbol file.
+	formatNocash = "nocash"
+	// IDA MAP file.
+	formatIDAMap = "idamap"
+)
+
+// detectFormat returns the input format of the given symbol file, as detected
+// by its file extension and contents. JSON encoded symbol files (*.json), Ghidra
+// symbol tables (*.csv), Ghidra programs (*.xml) and C headers (*.h) are
+// detected by file extension, ELF files by magic number, and Psy-Q linker MAP
+// files, no$psx symbol files and IDA MAP files by contents. Other files are
+// assumed to be SYM files.
+func detectFormat(path string, buf []byte) string {
+	ext := filepath.Ext(path)
+	switch {
+	case strings.EqualFold(ext, ".json"):
+		return formatJSON
+	case strings.EqualFold(ext, ".csv"):
+		return formatGhidraCSV
+	case strings.EqualFold(ext, ".xml"):
+		return formatGhidraXML
+	case bytes.HasPrefix(buf, []byte(elf.ELFMAG)):
+		return formatELF
+	case strings.EqualFold(ext, ".h"):
+		return formatC
+	case isPsyqMap(buf):
+		return formatPsyqMap
+	case isNocash(buf):
+		return formatNocash
+	case isIDAMap(buf):
+		return formatIDAMap
+	default:
+		return formatSYM
+	}
+}
+
+// stdio is the path denoting standard input (as input file) and standard output
+// (as output directory).
+const stdio = "-"
+
+// readInput returns the contents of the given input file, or of standard input
+// if path is "-". Gzip compressed contents (e.g. foo.sym.gz) are transparently
+// decompressed.
+func readInput(path string) ([]byte, error) {
+	var buf []byte
+	var err error
+	if path == stdio {
+		buf, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		buf, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if !bytes.HasPrefix(buf, gzipMagic) {
+		return buf, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(buf))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to decompress %q", path)
+	}
+	defer zr.Close()
+	buf, err = ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to decompress %q", path)
+	}
+	return buf, nil
+}
+
+// gzipMagic is the magic number of gzip compressed files.
+var gzipMagic = []byte{0x1F, 0x8B}
+
+// inputName returns the name of the given input file used for format detection
+// and naming of output files; i.e. without ".gz" extension, and "stdin" for
+// standard input.
+func inputName(path string) string {
+	if path == stdio {
+		return "stdin"
+	}
+	if ext := filepath.Ext(path); strings.EqualFold(ext, ".gz") {
+		return strings.TrimSuffix(path, ext)
+	}
+	return path
+}
+
+// parseFile parses the given symbol file of the specified input format, using
+// the specified parse options. The input format is detected if not specified.
+// SYM files are parsed, JSON encoded symbol files are decoded from JSON, and
+// symbol files of other formats are converted to equivalent symbols.
+//
+// The symbol file is read from standard input if path is "-", and decompressed
+// if gzip compressed.
+func parseFile(path, format string, opts *sym.ParseOptions) (*sym.File, error) {
+	buf, err := readInput(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(format) == 0 {
+		format = detectFormat(inputName(path), buf)
+	}
+	switch format {
+	case formatSYM:
+		return sym.ParseBytesWithOptions(buf, opts)
+	case formatJSON:
+		f := &sym.File{}
+		if err := json.Unmarshal(buf, f); err != nil {
+			return nil, errors.Wrapf(err, "unable to decode JSON symbol file %q", path)
+		}
+		return f, nil
+	case formatGhidraCSV:
+		syms, err := ghidra.ParseSymbols(bytes.NewReader(buf))
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse Ghidra symbol table %q", path)
+		}
+		return encodeGhidra(nil, syms)
+	case formatGhidraXML:
+		prog, err := ghidra.ParseProgram(bytes.NewReader(buf))
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse Ghidra program %q", path)
+		}
+		return encodeGhidra(prog, nil)
+	case formatELF:
+		f, err := elf.NewFile(bytes.NewReader(buf))
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse ELF file %q", path)
+		}
+		p, err := dwarfsym.ParseELF(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse DWARF debug information of %q", path)
+		}
+		return encodeParser(p)
+	case formatC:
+		p, err := cheader.Parse(&cheader.Header{Path: path, Src: string(buf)})
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse C header %q", path)
+		}
+		return encodeParser(p)
+	case formatPsyqMap:
+		m, err := psymap.Parse(bytes.NewReader(buf))
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse Psy-Q linker MAP file %q", path)
+		}
+		return encodePsyqMap(m)
+	case formatNocash:
+		f, err := sym.ParseNocash(bytes.NewReader(buf))
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse no$psx symbol file %q", path)
+		}
+		return f, nil
+	case formatIDAMap:
+		f, err := sym.ParseIDAMap(bytes.NewReader(buf))
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse IDA MAP file %q", path)
+		}
+		return f, nil
+	default:
+		return nil, errors.Errorf("support for input format %q not yet implemented", format)
+	}
+}
+
+// encodeGhidra returns a symbol file of the SYM symbols equivalent to the data
+// types and declarations of the given Ghidra program and symbols.
+func encodeGhidra(prog *ghidra.Program, syms []*ghidra.Symbol) (*sym.File, error) {
+	p, err := ghidra.NewParser(prog, syms)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return encodeParser(p)
+}
+
+// encodeParser returns a symbol file of the SYM symbols equivalent to the types
+// and declarations recorded by the given parser.
+func encodeParser(p *csym.Parser) (*sym.File, error) {
+	encoded, err := p.Encode()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to encode SYM symbols")
+	}
+	return sym.NewFile(encoded), nil
+}
+
+// encodePsyqMap returns a symbol file of the Name2 symbols equivalent to the
+// global symbols of the given Psy-Q linker MAP file.
+func encodePsyqMap(m *psymap.Map) (*sym.File, error) {
+	f := sym.NewFile(nil)
+	for _, s := range m.Symbols {
+		if len(s.Name) > math.MaxUint8 {
+			return nil, errors.Errorf("length of symbol name %q exceeds %d characters", s.Name, math.MaxUint8)
+		}
+		body := &sym.Name2{NameLen: uint8(len(s.Name)), Name: s.Name}
+		hdr := &sym.SymbolHeader{Value: s.Addr, Kind: sym.KindName2}
+		f.Syms = append(f.Syms, &sym.Symbol{Hdr: hdr, Body: body})
+	}
+	return f, nil
+}
+
+// isPsyqMap reports whether the given symbol file is a Psy-Q linker MAP file;
+// i.e. a text file containing a table of symbol names.
+func isPsyqMap(buf []byte) bool {
+	if bytes.HasPrefix(buf, []byte("MND")) {
+		return false
+	}
+	return bytes.Contains(buf, []byte("Names alphabetically")) || bytes.Contains(buf, []byte("Names in address order"))
+}
+
+// isIDAMap reports whether the given symbol file is an IDA MAP file; i.e. a
+// text file containing a table of public symbols.
+func isIDAMap(buf []byte) bool {
+	if bytes.HasPrefix(buf, []byte("MND")) {
+		return false
+	}
+	return bytes.Contains(buf, []byte("Publics by Value")) || bytes.Contains(buf, []byte("Publics by Name"))
+}
+
+// isNocash reports whether the given symbol file is a no$psx symbol file; i.e.
+// a text file of which the first line that is neither empty nor a comment
+// starts with an address of 8 hexadecimal digits.
+func isNocash(buf []byte) bool {
+	if bytes.HasPrefix(buf, []byte("MND")) {
+		return false
+	}
+	for _, line := range strings.Split(string(buf), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, ";") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || len(fields[0]) != 8 {
+			return false
+		}
+		_, err := strconv.ParseUint(fields[0], 16, 32)
+		return err == nil
+	}
+	return false
+}
+
+// parseEncoding returns the text encoding of the given name, or nil if no
+// encoding was specified or if the encoding is to be detected ("auto").
+func parseEncoding(name string) (encoding.Encoding, error) {
+	switch strings.ToLower(name) {
+	case "", "auto":
+		return nil, nil
+	case "sjis", "shift-jis", "shift_jis":
+		return japanese.ShiftJIS, nil
+	default:
+		return nil, errors.Errorf("support for text encoding %q not yet implemented", name)
+	}
+}
+
+// parseMergePolicy returns the conflict resolution policy of merge mode of the
+// given name.
+func parseMergePolicy(name string) (csym.MergePolicy, error) {
+	for _, policy := range []csym.MergePolicy{csym.PreferFirst, csym.PreferNamed, csym.ConflictError} {
+		if strings.EqualFold(name, policy.String()) {
+			return policy, nil
+		}
+	}
+	return 0, errors.Errorf("invalid merge policy %q; expected prefer-first, prefer-named or error", name)
+}
+
+// parseSortOrder returns the sort order of declarations and type definitions
+// of the given name.
+func parseSortOrder(name string) (csym.SortOrder, error) {
+	for _, order := range []csym.SortOrder{csym.SortOriginal, csym.SortAddress, csym.SortName} {
+		if strings.EqualFold(name, order.String()) {
+			return order, nil
+		}
+	}
+	return 0, errors.Errorf("invalid sort order %q; expected original, address or name", name)
+}
+
+// parseStdintMap returns the stdint.h type mapping of the default mapping,
+// overridden by the given comma-separated list of mappings (e.g.
+// "char=int8_t,u_long=uint32_t"). An empty stdint.h type name removes the
+// mapping of a type.
+func parseStdintMap(s string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for from, to := range csym.DefaultStdintTypes {
+		mapping[from] = to
+	}
+	if len(s) == 0 {
+		return mapping, nil
+	}
+	for _, entry := range strings.Split(s, ",") {
+		pos := strings.Index(entry, "=")
+		if pos == -1 {
+			return nil, errors.Errorf("invalid stdint.h type mapping %q; expected format \"type=stdint_type\"", entry)
+		}
+		from := strings.TrimSpace(entry[:pos])
+		to := strings.TrimSpace(entry[pos+1:])
+		if len(to) == 0 {
+			delete(mapping, from)
+			continue
+		}
+		mapping[from] = to
+	}
+	return mapping, nil
+}
+
+// parseByteOrder returns the byte order of the given name, or nil if no byte
+// order was specified.
+func parseByteOrder(name string) (binary.ByteOrder, error) {
+	switch strings.ToLower(name) {
+	case "":
+		return nil, nil
+	case "big":
+		return binary.BigEndian, nil
+	case "little":
+		return binary.LittleEndian, nil
+	default:
+		return nil, errors.Errorf("invalid byte order %q; expected big or little", name)
 	}
 }
 
@@ -122,6 +790,7 @@ func pruneDuplicates(ps []*csym.Parser, skipAddrDiff, skipLineDiff bool) *csym.P
 	if def, ok := ps[0].Types["bool"]; ok {
 		dst.Types["bool"] = def
 	}
+	dst.Includes = ps[0].Includes
 
 	// placeholder type name to make types match even when typename differ.
 	const placeholder = "placeholder"
@@ -308,36 +977,211 @@ func pruneDuplicates(ps []*csym.Parser, skipAddrDiff, skipLineDiff bool) *csym.P
 	return dst
 }
 
+// insertPadding inserts explicit padding members into the gaps between the
+// fields of the structs recorded by the parser.
+func insertPadding(p *csym.Parser) {
+	for _, tag := range p.StructTags {
+		p.Structs[tag].InsertPadding()
+	}
+}
+
+// dumpOptions specifies the output format of dump, and its options.
+type dumpOptions struct {
+	// Output C types and declarations.
+	outputC bool
+	// Output C types.
+	outputTypes bool
+	// Output IDA scripts.
+	outputIDA bool
+	// Output YAML file.
+	outputYAML bool
+	// Output CSV symbol table.
+	outputCSV bool
+	// Output Protocol Buffers file.
+	outputProto bool
+	// Output HTML report.
+	outputHTML bool
+	// Output Graphviz DOT type dependency graph.
+	outputDOT bool
+	// Output Ghidra symbol scripts and data type archive.
+	outputGhidra bool
+	// Output IDC scripts.
+	outputIDC bool
+	// Output IDAPython script.
+	outputIDAPython bool
+	// Output radare2 script.
+	outputR2 bool
+	// Output Binary Ninja script.
+	outputBinja bool
+	// Output no$psx symbol files.
+	outputNocash bool
+	// Output PCSX-Redux symbol maps and Lua scripts.
+	outputRedux bool
+	// Output GDB scripts.
+	outputGDB bool
+	// Output ELF symbol files.
+	outputELF bool
+	// Output ELF files with DWARF debug information.
+	outputDWARF bool
+	// Output assembly files with stabs debug information.
+	outputStabs bool
+	// Output splat configuration files.
+	outputSplat bool
+	// Output m2c context files.
+	outputM2C bool
+	// Output decomp.me scratch context of function.
+	outputScratch bool
+	// Output asm-differ linker maps and settings.
+	outputAsmDiffer bool
+	// Output armips and asmpsx label include files.
+	outputLabels bool
+	// Output GNU assembler symbol stub files.
+	outputStubs bool
+	// Go text/template of custom output format.
+	tmpl *template.Template
+
+	// Output ctags and etags files.
+	outputTags bool
+	// Output cscope cross-reference database.
+	outputCscope bool
+	// Split output into source files.
+	splitSrc bool
+	// Reconstruct source tree layout of the original project.
+	sourceTree bool
+	// Split C headers by translation unit of the original project.
+	units bool
+	// Split C headers by overlay.
+	overlays bool
+	// Split C headers by category of declaration.
+	categories bool
+	// Output static assertions verifying struct layouts.
+	asserts bool
+	// Validate the syntax of generated C headers.
+	check bool
+	// Root type of DOT type dependency graph.
+	dotRoot string
+	// Function name of decomp.me scratch context.
+	scratchFunc string
+	// Comma-separated list of global variables and functions referenced by
+	// the function of the decomp.me scratch context.
+	scratchSyms string
+	// Formatting style of generated C code.
+	style c.Style
+	// Include guard style of C headers.
+	guard string
+}
+
+// formats returns the command line flags of the output formats specified by the
+// dump options.
+func (opts *dumpOptions) formats() []string {
+	var formats []string
+	add := func(output bool, name string) {
+		if output {
+			formats = append(formats, "-"+name)
+		}
+	}
+	add(opts.outputC, "c")
+	add(opts.outputTypes, "types")
+	add(opts.outputIDA, "ida")
+	add(opts.outputYAML, "yaml")
+	add(opts.outputCSV, "csv")
+	add(opts.outputProto, "proto")
+	add(opts.outputHTML, "html")
+	add(opts.outputDOT, "dot")
+	add(opts.outputGhidra, "ghidra")
+	add(opts.outputIDC, "idc")
+	add(opts.outputIDAPython, "idapython")
+	add(opts.outputR2, "r2")
+	add(opts.outputBinja, "binja")
+	add(opts.outputNocash, "nocash")
+	add(opts.outputRedux, "redux")
+	add(opts.outputGDB, "gdb")
+	add(opts.outputELF, "elf")
+	add(opts.outputDWARF, "dwarf")
+	add(opts.outputStabs, "stabs")
+	add(opts.outputSplat, "splat")
+	add(opts.outputM2C, "m2c")
+	add(opts.outputScratch, "scratch")
+	add(opts.outputAsmDiffer, "asmdiffer")
+	add(opts.outputLabels, "labels")
+	add(opts.outputStubs, "stubs")
+	add(opts.tmpl != nil, "template")
+	return formats
+}
+
 // dump dumps the declarations of the parser to the given output directory, in
-// the format specified.
-func dump(p *csym.Parser, outputDir string, outputC, outputTypes, outputIDA, splitSrc, merge bool) error {
+// the format specified by the dump options; at most one output format may be
+// specified.
+func dump(p *csym.Parser, outputDir string, opts *dumpOptions) error {
+	// Locations of declarations in generated C headers.
+	var tags *tagsFile
+	if opts.outputTags || opts.outputCscope {
+		tags = &tagsFile{}
+	}
 	switch {
-	case outputC:
+	case opts.outputC:
 		// Output C types and declarations.
 		if err := initOutputDir(outputDir); err != nil {
 			return errors.WithStack(err)
 		}
-		if err := dumpTypes(p, outputDir); err != nil {
-			return errors.WithStack(err)
+		switch {
+		case opts.units:
+			// Type definitions are split by translation unit.
+			if err := dumpUnits(p, outputDir, opts.check, tags, opts.style, opts.guard); err != nil {
+				return errors.WithStack(err)
+			}
+		case opts.overlays:
+			// Type definitions are split by overlay.
+			if err := dumpOverlays(p, outputDir, opts.check, tags, opts.style, opts.guard); err != nil {
+				return errors.WithStack(err)
+			}
+		case opts.categories:
+			// Type definitions are output to types.h, and declarations by
+			// category.
+			if err := dumpCategories(p, outputDir, opts.check, tags, opts.style, opts.guard); err != nil {
+				return errors.WithStack(err)
+			}
+		default:
+			if err := dumpTypes(p, outputDir, opts.check, tags, opts.style, opts.guard); err != nil {
+				return errors.WithStack(err)
+			}
 		}
-		if splitSrc {
-			if err := dumpSourceFiles(p, outputDir); err != nil {
+		if opts.asserts {
+			if err := dumpAsserts(p, outputDir, opts.guard); err != nil {
 				return errors.WithStack(err)
 			}
-		} else {
-			if err := dumpDecls(p, outputDir); err != nil {
+		}
+		switch {
+		case opts.units, opts.overlays, opts.categories:
+			// Declarations output by dumpUnits, dumpOverlays and
+			// dumpCategories.
+		case opts.sourceTree:
+			if err := dumpSourceTree(p, outputDir, tags, opts.style, opts.guard); err != nil {
+				return errors.WithStack(err)
+			}
+		case opts.splitSrc:
+			if err := dumpSourceFiles(p, outputDir, tags, opts.style); err != nil {
+				return errors.WithStack(err)
+			}
+		default:
+			if err := dumpDecls(p, outputDir, tags, opts.style, opts.guard); err != nil {
 				return errors.WithStack(err)
 			}
 		}
-	case outputTypes:
+	case opts.outputTypes:
 		// Output C types.
 		if err := initOutputDir(outputDir); err != nil {
 			return errors.WithStack(err)
 		}
-		if err := dumpTypes(p, outputDir); err != nil {
+		if err := dumpTypes(p, outputDir, opts.check, tags, opts.style, opts.guard); err != nil {
 			return errors.WithStack(err)
 		}
-	case outputIDA:
+		if opts.asserts {
+			if err := dumpAsserts(p, outputDir, opts.guard); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	case opts.outputIDA:
 		// Output IDA scripts.
 		if err := initOutputDir(outputDir); err != nil {
 			return errors.WithStack(err)
@@ -345,19 +1189,208 @@ func dump(p *csym.Parser, outputDir string, outputC, outputTypes, outputIDA, spl
 		if err := dumpIDAScripts(p, outputDir); err != nil {
 			return errors.WithStack(err)
 		}
-		// Delete bool and __int64 types as they cause issues with IDA.
-		delete(p.Types, "bool")
-		for i, def := range p.Typedefs {
-			if v, ok := def.(*c.VarDecl); ok {
-				if v.Name == "__int64" {
-					defs := append(p.Typedefs[:i], p.Typedefs[i+1:]...)
-					p.Typedefs = defs
-					break
-				}
-			}
+		pruneIDATypes(p)
+		if err := dumpTypes(p, outputDir, opts.check, tags, opts.style, opts.guard); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputYAML:
+		// Output YAML file.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpYAML(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputCSV:
+		// Output CSV symbol table.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpCSV(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputProto:
+		// Output Protocol Buffers file.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpProto(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputHTML:
+		// Output HTML report.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpHTML(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputDOT:
+		// Output Graphviz DOT type dependency graph.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpDOT(p, outputDir, opts.dotRoot); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputGhidra:
+		// Output Ghidra symbol scripts and data type archive.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpGhidraSymbols(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpGhidraTypes(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputIDC:
+		// Output IDC scripts.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpIDC(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputIDAPython:
+		// Output IDAPython script.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		pruneIDATypes(p)
+		if err := dumpIDAPython(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputR2:
+		// Output radare2 script.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpR2(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputBinja:
+		// Output Binary Ninja script.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpBinja(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputNocash:
+		// Output no$psx symbol files.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpNocash(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputRedux:
+		// Output PCSX-Redux symbol maps and Lua scripts.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpRedux(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputGDB:
+		// Output GDB scripts.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpGDB(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputELF:
+		// Output ELF symbol files.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpELF(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputDWARF:
+		// Output ELF files with DWARF debug information.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpDWARF(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputStabs:
+		// Output assembly files with stabs debug information.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpStabs(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputSplat:
+		// Output splat configuration files.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpSplat(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputM2C:
+		// Output m2c context files.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpM2C(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputScratch:
+		// Output decomp.me scratch context.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpScratch(p, outputDir, opts.scratchFunc, opts.scratchSyms, opts.style); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputAsmDiffer:
+		// Output asm-differ linker maps and settings.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpAsmDiffer(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputLabels:
+		// Output armips and asmpsx label include files.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpLabels(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.outputStubs:
+		// Output GNU assembler symbol stub files.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
 		}
-		delete(p.Types, "__int64")
-		if err := dumpTypes(p, outputDir); err != nil {
+		if err := dumpStubs(p, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	case opts.tmpl != nil:
+		// Output custom format of template.
+		if err := initOutputDir(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpTemplate(p, outputDir, opts.tmpl); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	// Output tags of generated C headers.
+	if opts.outputTags {
+		if err := tags.write(outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	// Output cscope cross-reference database of generated C headers.
+	if opts.outputCscope && len(tags.tags) > 0 {
+		if err := dumpCscope(outputDir, tags); err != nil {
 			return errors.WithStack(err)
 		}
 	}
@@ -366,6 +1399,10 @@ func dump(p *csym.Parser, outputDir string, outputC, outputTypes, outputIDA, spl
 
 // initOutputDir initializes the output directory.
 func initOutputDir(outputDir string) error {
+	if outputDir == stdio {
+		// Output to standard output.
+		return nil
+	}
 	// Only remove output directory if set to default. Otherwise, let user remove
 	// output directory as a safety precaution.
 	if outputDir == dumpDir {
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sanctuary/sym"
+	"github.com/sanctuary/sym/csym"
+)
+
+// update specifies whether to update the golden files of the output format
+// tests.
+var update = flag.Bool("update", false, "update golden files of testdata/golden")
+
+// testFile is the symbol file of the output format tests; a small program with
+// enums, structs (with bitfields and anonymous structs), unions, type
+// definitions, global variables, functions with blocks and local variables,
+// and an overlay.
+const testFile = "testdata/test.json"
+
+// Environment variable of the test binary, specifying that sym_dump is run by
+// runMain.
+const mainEnv = "SYM_DUMP_TEST_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(mainEnv) == "1" {
+		// Run sym_dump with the command line arguments of runMain.
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestOutputFormats(t *testing.T) {
+	// Output files of each test case are written to the "out" directory,
+	// relative to a temporary working directory.
+	golden := []struct {
+		// Name of the test case; and directory of golden files.
+		name string
+		// Command line arguments; "$FILE" is replaced by the path of the test
+		// symbol file, and "$TESTDATA" by the path of the testdata directory.
+		args []string
+	}{
+		// Psy-Q DUMPSYM.EXE output.
+		{name: "psyq", args: []string{"$FILE"}},
+		{name: "json", args: []string{"-json", "$FILE"}},
+		// C headers.
+		{name: "c", args: []string{"-c", "-dir", "out", "$FILE"}},
+		{name: "types", args: []string{"-types", "-dir", "out", "$FILE"}},
+		{name: "src", args: []string{"-c", "-src", "-dir", "out", "$FILE"}},
+		{name: "tree", args: []string{"-c", "-tree", "-dir", "out", "$FILE"}},
+		{name: "units", args: []string{"-c", "-units", "-dir", "out", "$FILE"}},
+		{name: "overlays", args: []string{"-c", "-overlays", "-dir", "out", "$FILE"}},
+		{name: "categories", args: []string{"-c", "-categories", "-dir", "out", "$FILE"}},
+		{name: "guard", args: []string{"-c", "-guard", "pragma", "-dir", "out", "$FILE"}},
+		{name: "asserts", args: []string{"-c", "-asserts", "-dir", "out", "$FILE"}},
+		{name: "style", args: []string{"-c", "-allman", "-indent", "2", "-blockcomments", "-width", "24", "-doxygen", "-hexenums", "-dir", "out", "$FILE"}},
+		{name: "tags", args: []string{"-c", "-tags", "-dir", "out", "$FILE"}},
+		{name: "cscope", args: []string{"-c", "-cscope", "-dir", "out", "$FILE"}},
+		// Symbol and type information.
+		{name: "yaml", args: []string{"-yaml", "-dir", "out", "$FILE"}},
+		{name: "csv", args: []string{"-csv", "-dir", "out", "$FILE"}},
+		{name: "html", args: []string{"-html", "-dir", "out", "$FILE"}},
+		{name: "dot", args: []string{"-dot", "-dir", "out", "$FILE"}},
+		{name: "dotroot", args: []string{"-dot", "-dotroot", "point", "-dir", "out", "$FILE"}},
+		{name: "template", args: []string{"-template", "$TESTDATA/symbols.txt.tmpl", "-dir", "out", "$FILE"}},
+		// Disassemblers and decompilers.
+		{name: "ida", args: []string{"-ida", "-dir", "out", "$FILE"}},
+		{name: "idc", args: []string{"-idc", "-dir", "out", "$FILE"}},
+		{name: "idapython", args: []string{"-idapython", "-dir", "out", "$FILE"}},
+		{name: "ghidra", args: []string{"-ghidra", "-dir", "out", "$FILE"}},
+		{name: "r2", args: []string{"-r2", "-dir", "out", "$FILE"}},
+		// Type definitions of radare2 are independent of the C formatting style.
+		{name: "r2_style", args: []string{"-r2", "-allman", "-blockcomments", "-doxygen", "-dir", "out", "$FILE"}},
+		{name: "binja", args: []string{"-binja", "-dir", "out", "$FILE"}},
+		// Emulators and debuggers.
+		{name: "nocash", args: []string{"-nocash", "-dir", "out", "$FILE"}},
+		{name: "redux", args: []string{"-redux", "-dir", "out", "$FILE"}},
+		{name: "gdb", args: []string{"-gdb", "-dir", "out", "$FILE"}},
+		// Decompilation projects.
+		{name: "stabs", args: []string{"-stabs", "-dir", "out", "$FILE"}},
+		{name: "splat", args: []string{"-splat", "-dir", "out", "$FILE"}},
+		{name: "m2c", args: []string{"-m2c", "-dir", "out", "$FILE"}},
+		{name: "scratch", args: []string{"-scratch", "-scratchfunc", "add", "-dir", "out", "$FILE"}},
+		{name: "asmdiffer", args: []string{"-asmdiffer", "-dir", "out", "$FILE"}},
+		{name: "labels", args: []string{"-labels", "-dir", "out", "$FILE"}},
+		{name: "stubs", args: []string{"-stubs", "-dir", "out", "$FILE"}},
+		// Subcommands.
+		{name: "convert", args: []string{"convert", "-to", "json", "-dir", "out", "$FILE"}},
+		{name: "convert_psyq", args: []string{"convert", "-to", "psyq", "-dir", "out", "$FILE"}},
+		{name: "convert_stdout", args: []string{"convert", "-to", "csv", "-dir", "-", "$FILE"}},
+		{name: "query", args: []string{"query", "$FILE", "80010104", "800B0024"}},
+		{name: "query_json", args: []string{"query", "-json", "-name", "g*", "$FILE"}},
+	}
+	for _, g := range golden {
+		t.Run(g.name, func(t *testing.T) {
+			got := runMain(t, g.args...)
+			goldenDir := filepath.Join("testdata", "golden", g.name)
+			if *update {
+				writeFiles(t, goldenDir, got)
+				return
+			}
+			want := readFiles(t, goldenDir)
+			compareFiles(t, want, got)
+		})
+	}
+}
+
+func TestIncompatibleFormats(t *testing.T) {
+	cmd, _, stderr := mainCommand(t, "-c", "-yaml", "-dir", "out", "$FILE")
+	if err := cmd.Run(); err == nil {
+		t.Fatalf("expected error of incompatible output formats, got nil")
+	}
+	if want := "incompatible output formats -c, -yaml"; !strings.Contains(stderr.String(), want) {
+		t.Errorf("error mismatch; expected %q, got %q", want, stderr.String())
+	}
+}
+
+// parseTestFile returns a parser of the C types and declarations of the test
+// symbol file, as parsed by the convert command.
+func parseTestFile(t *testing.T) *csym.Parser {
+	t.Helper()
+	f, err := parseFile(testFile, "", &sym.ParseOptions{})
+	if err != nil {
+		t.Fatalf("unable to parse %q; %+v", testFile, err)
+	}
+	return parseSyms(f)
+}
+
+// mainCommand returns a command running sym_dump with the given command line
+// arguments in a temporary working directory, and the buffers of its standard
+// output and standard error.
+func mainCommand(t *testing.T, args ...string) (*exec.Cmd, *bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+	testdata, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cmdArgs []string
+	for _, arg := range args {
+		arg = strings.Replace(arg, "$FILE", filepath.Join(testdata, filepath.Base(testFile)), -1)
+		arg = strings.Replace(arg, "$TESTDATA", testdata, -1)
+		cmdArgs = append(cmdArgs, arg)
+	}
+	cmd := exec.Command(os.Args[0], cmdArgs...)
+	cmd.Dir = t.TempDir()
+	cmd.Env = append(os.Environ(), mainEnv+"=1")
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd, stdout, stderr
+}
+
+// runMain runs sym_dump with the given command line arguments, and returns the
+// files of the output directory ("out") and standard output ("stdout"), with
+// the temporary working directory replaced by "$WORK".
+func runMain(t *testing.T, args ...string) map[string][]byte {
+	t.Helper()
+	cmd, stdout, stderr := mainCommand(t, args...)
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("unable to run sym_dump %s; %v\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	files := make(map[string][]byte)
+	if outDir := filepath.Join(cmd.Dir, "out"); exists(outDir) {
+		files = readFiles(t, outDir)
+	}
+	files["stdout"] = stdout.Bytes()
+	for name, buf := range files {
+		if name == "cscope.out" {
+			buf = normalizeCscope(t, buf, cmd.Dir)
+		}
+		files[name] = bytes.Replace(buf, []byte(cmd.Dir), []byte("$WORK"), -1)
+	}
+	return files
+}
+
+// cscopeHeader matches the header of cscope cross-reference files, containing
+// the source directory and the offset of the trailer.
+var cscopeHeader = regexp.MustCompile(`^cscope 15 (\S+) -c ([0-9]{10})\n`)
+
+// normalizeCscope adjusts the trailer offset of the given cscope
+// cross-reference file, as if its source directory within the working
+// directory was stored relative to "$WORK".
+func normalizeCscope(t *testing.T, buf []byte, workDir string) []byte {
+	t.Helper()
+	m := cscopeHeader.FindSubmatch(buf)
+	if m == nil {
+		t.Fatalf("invalid cscope header %q", bytes.SplitN(buf, []byte("\n"), 2)[0])
+	}
+	off, err := strconv.Atoi(string(m[2]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	off += len("$WORK") - len(workDir)
+	return append([]byte(fmt.Sprintf("cscope 15 %s -c %010d\n", m[1], off)), buf[len(m[0]):]...)
+}
+
+// readFiles returns the contents of the files of the given directory and its
+// subdirectories, keyed by slash-separated path relative to the directory.
+func readFiles(t *testing.T, dir string) map[string][]byte {
+	t.Helper()
+	files := make(map[string][]byte)
+	walk := func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(name)] = buf
+		return nil
+	}
+	if err := filepath.Walk(dir, walk); err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// writeFiles writes the given files, keyed by slash-separated path, to the
+// directory, replacing its previous contents.
+func writeFiles(t *testing.T, dir string, files map[string][]byte) {
+	t.Helper()
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	for name, buf := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, buf, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// compareFiles reports differences between the expected and actual files,
+// keyed by slash-separated path.
+func compareFiles(t *testing.T, want, got map[string][]byte) {
+	t.Helper()
+	var names []string
+	for name := range want {
+		names = append(names, name)
+	}
+	for name := range got {
+		if _, ok := want[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		w, ok := want[name]
+		if !ok {
+			t.Errorf("unexpected output file %q", name)
+			continue
+		}
+		g, ok := got[name]
+		if !ok {
+			t.Errorf("missing output file %q", name)
+			continue
+		}
+		if !bytes.Equal(w, g) {
+			t.Errorf("output file %q mismatch; expected:\n%s\ngot:\n%s", name, w, g)
+		}
+	}
+}
+
+// exists reports whether the given file or directory exists.
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
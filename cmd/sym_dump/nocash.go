@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// no$psx symbol file name.
+const nocashName = "nocash.sym"
+
+// dumpNocash outputs the declarations recorded by the parser to no$psx symbol
+// files stored in the output directory; one for the default binary, and one
+// for each overlay (stored in overlay_N).
+func dumpNocash(p *csym.Parser, outputDir string) error {
+	// Create symbol file of default binary.
+	if err := dumpNocashOverlay(p.Overlay, outputDir); err != nil {
+		return errors.WithStack(err)
+	}
+	// Create symbol files of overlays.
+	for _, overlay := range p.Overlays {
+		if err := dumpNocashOverlay(overlay, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// nocashEntry is a line of a no$psx symbol file.
+type nocashEntry struct {
+	// Address.
+	addr uint32
+	// Symbol name or data directive.
+	text string
+}
+
+// dumpNocashOverlay outputs the declarations of the overlay to a no$psx symbol
+// file, sorted by address. Each symbol is output as an "ADDRESS NAME" line.
+// Global variables of scalar type (and arrays thereof) are followed by a data
+// directive of their element size and length in bytes; .byt for bytes, .wrd
+// for 16-bit words and .dbl for 32-bit words. Symbols without address (e.g.
+// external declarations) are omitted.
+func dumpNocashOverlay(overlay *csym.Overlay, outputDir string) error {
+	dir := outputDir
+	if overlay.ID != 0 {
+		overlayDir := fmt.Sprintf("overlay_%x", overlay.ID)
+		dir = filepath.Join(outputDir, overlayDir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	var entries []nocashEntry
+	for _, f := range overlay.Funcs {
+		if f.Addr == 0 {
+			continue
+		}
+		entries = append(entries, nocashEntry{addr: f.Addr, text: f.Name})
+	}
+	for _, v := range overlay.Vars {
+		if v.Addr == 0 {
+			continue
+		}
+		entries = append(entries, nocashEntry{addr: v.Addr, text: v.Name})
+		if directive, ok := nocashDirective(v); ok {
+			entries = append(entries, nocashEntry{addr: v.Addr, text: directive})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].addr < entries[j].addr
+	})
+	if err := createOutputFile(dir, nocashName, func(f io.Writer) error {
+		w := bufio.NewWriter(f)
+		for _, entry := range entries {
+			fmt.Fprintf(w, "%08X %s\n", entry.addr, entry.text)
+		}
+		return w.Flush()
+	}); err != nil {
+		return errors.Wrap(err, "unable to create no$psx symbol file")
+	}
+	return nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// nocashDirective returns the no$psx data directive of the given global
+// variable, and a boolean indicating whether the variable is of scalar type or
+// array thereof.
+func nocashDirective(v *c.VarDecl) (string, bool) {
+	size := v.Size
+	if size == 0 {
+		size = c.Sizeof(v.Type)
+	}
+	if size == 0 {
+		return "", false
+	}
+	// Element type of arrays.
+	elem := underlyingType(v.Type)
+	for {
+		t, ok := elem.(*c.ArrayType)
+		if !ok {
+			break
+		}
+		elem = underlyingType(t.Elem)
+	}
+	switch elem.(type) {
+	case c.BaseType, *c.EnumType, *c.PointerType:
+		// Scalar type.
+	default:
+		return "", false
+	}
+	switch c.Sizeof(elem) {
+	case 1:
+		return fmt.Sprintf(".byt:%04X", size), true
+	case 2:
+		return fmt.Sprintf(".wrd:%04X", size), true
+	case 4:
+		return fmt.Sprintf(".dbl:%04X", size), true
+	}
+	return "", false
+}
@@ -10,59 +10,208 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/rickypai/natsort"
+	"github.com/sanctuary/sym"
 	"github.com/sanctuary/sym/csym"
 	"github.com/sanctuary/sym/csym/c"
 )
 
+// --- [ Output files ] --------------------------------------------------------
+
+// createOutputFile creates the output file of the given slash-separated path
+// relative to the output directory, and writes its contents using dump. If the
+// output directory is "-", the contents are written to standard output instead
+// (see outputFile).
+func createOutputFile(outputDir, relPath string, dump func(w io.Writer) error) error {
+	if outputDir == stdio {
+		return dump(os.Stdout)
+	}
+	path := filepath.Join(outputDir, filepath.FromSlash(relPath))
+	fmt.Println("creating:", path)
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := dump(f); err != nil {
+		f.Close()
+		return errors.WithStack(err)
+	}
+	if err := f.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// --- [ SYM files ] -----------------------------------------------------------
+
+// dumpSYM outputs the binary encoding of the given symbol file to the output
+// directory, named after the base name of the input path (e.g. "foo.json" ->
+// "foo.sym").
+func dumpSYM(f *sym.File, path, outputDir string) error {
+	if outputDir != stdio {
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + ".sym"
+	return createOutputFile(outputDir, name, f.Write)
+}
+
 // --- [ Type definitions ] ----------------------------------------------------
 
 // Type definitions header file name.
 const typesName = "types.h"
 
 // dumpTypes outputs the type information recorded by the parser to a C header
-// stored in the output directory.
-func dumpTypes(p *csym.Parser, outputDir string) error {
-	// Create output file.
-	typesPath := filepath.Join(outputDir, typesName)
-	fmt.Println("creating:", typesPath)
-	f, err := os.Create(typesPath)
-	if err != nil {
+// stored in the output directory. If check is set, the header is re-parsed to
+// validate its syntax. The locations of type definitions are recorded in tags.
+func dumpTypes(p *csym.Parser, outputDir string, check bool, tags *tagsFile, style c.Style, guard string) error {
+	header, spans := defsHeader(p.Includes, nil, typeDefs(p), typesName, style, guard)
+	return dumpHeader(outputDir, typesName, header, spans, check, tags, style)
+}
+
+// dumpHeader outputs the given C header of type definitions to the named file
+// (slash-separated path relative to the output directory). If check is set, the
+// header is re-parsed to validate its syntax. The locations of type definitions
+// are recorded in tags.
+func dumpHeader(outputDir, name, header string, spans []defSpan, check bool, tags *tagsFile, style c.Style) error {
+	for _, span := range spans {
+		tags.addType(name, span.line, span.offset, span.def, style)
+	}
+	if err := createOutputFile(outputDir, name, func(w io.Writer) error {
+		_, err := io.WriteString(w, header)
+		return err
+	}); err != nil {
 		return errors.WithStack(err)
 	}
-	defer f.Close()
-	// Print predeclared identifiers.
-	if def, ok := p.Types["bool"]; ok {
-		if _, err := fmt.Fprintf(f, "%s;\n\n", def.Def()); err != nil {
+	if check {
+		if err := checkHeader(name, header, spans); err != nil {
 			return errors.WithStack(err)
 		}
 	}
-	// Print enums.
+	return nil
+}
+
+// A defSpan records the location of a type definition in a C header.
+type defSpan struct {
+	// Line number of the first line of the type definition (1-based).
+	line int
+	// Offset in bytes of the type definition.
+	offset int
+	// Type definition.
+	def c.Type
+}
+
+// typesHeader returns the C header of the type information recorded by the
+// parser, and the lines of its type definitions. The header is not protected by
+// an include guard, as it is embedded in other output formats.
+func typesHeader(p *csym.Parser, style c.Style, guard string) (string, []defSpan) {
+	return defsHeader(p.Includes, nil, typeDefs(p), "", style, guard)
+}
+
+// defsHeader returns the C header of the given type definitions, including the
+// given system headers and local headers, and the lines of its type
+// definitions. The header is protected by the include guard of the given header
+// path, if non-empty.
+func defsHeader(sysIncludes, includes []string, defs []c.Type, path string, style c.Style, guard string) (string, []defSpan) {
+	buf := &strings.Builder{}
+	if len(path) > 0 {
+		buf.WriteString(guardStart(path, guard))
+	}
+	// Print includes of system headers.
+	for _, include := range sysIncludes {
+		fmt.Fprintf(buf, "#include <%s>\n\n", include)
+	}
+	// Print includes of local headers.
+	for _, include := range includes {
+		fmt.Fprintf(buf, "#include %q\n\n", include)
+	}
+	// Print forward declarations of structs and unions referenced before
+	// defined.
+	if fwds := c.ForwardDecls(defs); len(fwds) > 0 {
+		for _, t := range fwds {
+			fmt.Fprintf(buf, "%s;\n", t)
+		}
+		buf.WriteString("\n")
+	}
+	// Print type definitions.
+	var spans []defSpan
+	line := strings.Count(buf.String(), "\n") + 1
+	for _, def := range defs {
+		s := fmt.Sprintf("%s;\n\n", def.DefStyle(style))
+		spans = append(spans, defSpan{line: line, offset: buf.Len(), def: def})
+		line += strings.Count(s, "\n")
+		buf.WriteString(s)
+	}
+	if len(path) > 0 {
+		buf.WriteString(guardEnd(path, guard))
+	}
+	return buf.String(), spans
+}
+
+// typeDefs returns the type definitions recorded by the parser in order of
+// predeclared identifiers, enums, structs, unions and typedefs; sorted in
+// dependency order.
+func typeDefs(p *csym.Parser) []c.Type {
+	var defs []c.Type
+	if def, ok := p.Types["bool"]; ok {
+		defs = append(defs, def)
+	}
 	for _, tag := range p.EnumTags {
 		t := p.Enums[tag]
-		if _, err := fmt.Fprintf(f, "%s;\n\n", t.Def()); err != nil {
-			return errors.WithStack(err)
+		if t.Typedef != nil {
+			// Enum defined inline in type definition.
+			continue
 		}
+		defs = append(defs, t)
 	}
-	// Print structs.
 	for _, tag := range p.StructTags {
-		t := p.Structs[tag]
-		if _, err := fmt.Fprintf(f, "%s;\n\n", t.Def()); err != nil {
-			return errors.WithStack(err)
-		}
+		defs = append(defs, p.Structs[tag])
 	}
-	// Print unions.
 	for _, tag := range p.UnionTags {
-		t := p.Unions[tag]
-		if _, err := fmt.Fprintf(f, "%s;\n\n", t.Def()); err != nil {
-			return errors.WithStack(err)
-		}
+		defs = append(defs, p.Unions[tag])
 	}
-	// Print typedefs.
-	for _, def := range p.Typedefs {
-		if _, err := fmt.Fprintf(f, "%s;\n\n", def.Def()); err != nil {
+	defs = append(defs, p.Typedefs...)
+	return c.SortDefs(defs)
+}
+
+// --- [ Layout assertions ] --------------------------------------------------
+
+// Layout assertions header file name.
+const assertsName = "asserts.h"
+
+// dumpAsserts outputs static assertions verifying the layout of the structs and
+// unions recorded by the parser to a C header stored in the output directory.
+func dumpAsserts(p *csym.Parser, outputDir string, guard string) error {
+	// Create output file.
+	assertsPath := filepath.Join(outputDir, assertsName)
+	fmt.Println("creating:", assertsPath)
+	f, err := os.Create(assertsPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintf(f, "%s#include <stddef.h>\n\n#include %q\n\n", guardStart(assertsName, guard), typesName); err != nil {
+		return errors.WithStack(err)
+	}
+	var types []c.Type
+	for _, tag := range p.StructTags {
+		types = append(types, p.Structs[tag])
+	}
+	for _, tag := range p.UnionTags {
+		types = append(types, p.Unions[tag])
+	}
+	for _, t := range types {
+		asserts := c.LayoutAsserts(t)
+		if len(asserts) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(f, "%s\n\n", strings.Join(asserts, "\n")); err != nil {
 			return errors.WithStack(err)
 		}
 	}
+	if _, err := io.WriteString(f, guardEnd(assertsName, guard)); err != nil {
+		return errors.WithStack(err)
+	}
 	return nil
 }
 
@@ -76,8 +225,8 @@ const (
 )
 
 // dumpDecls outputs the declarations recorded by the parser to C headers stored
-// in the output directory.
-func dumpDecls(p *csym.Parser, outputDir string) error {
+// in the output directory. The locations of declarations are recorded in tags.
+func dumpDecls(p *csym.Parser, outputDir string, tags *tagsFile, style c.Style, guard string) error {
 	// Create output file.
 	declsPath := filepath.Join(outputDir, declsName)
 	fmt.Println("creating:", declsPath)
@@ -87,7 +236,7 @@ func dumpDecls(p *csym.Parser, outputDir string) error {
 	}
 	defer f.Close()
 	// Store declarations of default binary.
-	if err := dumpOverlay(f, p.Overlay); err != nil {
+	if err := dumpOverlay(f, p.Overlay, declsName, []string{typesName}, tags, style, guard); err != nil {
 		return errors.WithStack(err)
 	}
 	// Store declarations of overlays.
@@ -100,36 +249,54 @@ func dumpDecls(p *csym.Parser, outputDir string) error {
 			return errors.Wrapf(err, "unable to create overlay header %q", overlayPath)
 		}
 		defer f.Close()
-		if err := dumpOverlay(f, overlay); err != nil {
+		if err := dumpOverlay(f, overlay, overlayName, []string{typesName}, tags, style, guard); err != nil {
 			return errors.WithStack(err)
 		}
 	}
 	return nil
 }
 
-// dumpOverlay outputs the declarations of the overlay, writing to w.
-func dumpOverlay(w io.Writer, overlay *csym.Overlay) error {
-	// Add types.h include directory.
-	if _, err := fmt.Fprintf(w, "#include %q\n\n", typesName); err != nil {
+// dumpOverlay outputs the declarations of the overlay, writing to w. The
+// declarations are preceded by the given includes. The locations of
+// declarations are recorded in tags, as output to the given path relative to
+// the output directory.
+func dumpOverlay(w io.Writer, overlay *csym.Overlay, path string, includes []string, tags *tagsFile, style c.Style, guard string) error {
+	lw := newLineWriter(w)
+	w = lw
+	if _, err := io.WriteString(w, guardStart(path, guard)); err != nil {
 		return errors.WithStack(err)
 	}
-	if overlay.Addr != 0 || overlay.ID != 0 || overlay.Length != 0 {
-		if _, err := fmt.Fprintf(w, "// === [ Overlay ID %x ] ===\n\n", overlay.ID); err != nil {
+	// Add includes (e.g. types.h).
+	for _, include := range includes {
+		if _, err := fmt.Fprintf(w, "#include %q\n", include); err != nil {
 			return errors.WithStack(err)
 		}
 	}
+	if _, err := fmt.Fprintln(w); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := dumpOverlayHeading(w, overlay); err != nil {
+		return errors.WithStack(err)
+	}
 	// Print variable declarations.
 	for _, v := range overlay.Vars {
-		if _, err := fmt.Fprintf(w, "%s;\n\n", v.Def()); err != nil {
+		def := v.DefStyle(style)
+		tags.addDef(path, lw.line, lw.offset, def, v.Name, tagKindVar, v.Addr)
+		if _, err := fmt.Fprintf(w, "%s;\n\n", def); err != nil {
 			return errors.WithStack(err)
 		}
 	}
 	// Print function declarations.
 	for _, f := range overlay.Funcs {
-		if _, err := fmt.Fprintf(w, "%s\n\n", f.Def()); err != nil {
+		def := f.DefStyle(style)
+		tags.addDef(path, lw.line, lw.offset, def, f.Name, tagKindFunc, f.Addr)
+		if _, err := fmt.Fprintf(w, "%s\n\n", def); err != nil {
 			return errors.WithStack(err)
 		}
 	}
+	if _, err := io.WriteString(w, guardEnd(path, guard)); err != nil {
+		return errors.WithStack(err)
+	}
 	return nil
 }
 
@@ -145,9 +312,27 @@ type SourceFile struct {
 	funcs []*c.FuncDecl
 }
 
+// uniqueNames renames duplicate identifiers of the declarations of the source
+// file.
+func (src *SourceFile) uniqueNames() {
+	names := make(map[string]bool)
+	for _, v := range src.vars {
+		if names[v.Name] {
+			v.Name = csym.UniqueName(v.Name, v.Addr)
+		}
+		names[v.Name] = true
+	}
+	for _, f := range src.funcs {
+		if names[f.Name] {
+			f.Name = csym.UniqueName(f.Name, f.Addr)
+		}
+		names[f.Name] = true
+	}
+}
+
 // dumpSourceFiles outputs the source files recorded by the parser to the output
-// directory.
-func dumpSourceFiles(p *csym.Parser, outputDir string) error {
+// directory. The locations of declarations are recorded in tags.
+func dumpSourceFiles(p *csym.Parser, outputDir string, tags *tagsFile, style c.Style) error {
 	srcs := getSourceFiles(p)
 	for _, src := range srcs {
 		// Create source file directory.
@@ -156,6 +341,7 @@ func dumpSourceFiles(p *csym.Parser, outputDir string) error {
 		if strings.HasPrefix(path[1:], ":/") {
 			path = path[len("c:/"):]
 		}
+		relPath := path
 		path = filepath.Join(outputDir, path)
 		dir := filepath.Dir(path)
 		if err := os.MkdirAll(dir, 0755); err != nil {
@@ -167,15 +353,19 @@ func dumpSourceFiles(p *csym.Parser, outputDir string) error {
 			return errors.WithStack(err)
 		}
 		defer f.Close()
-		if err := dumpSourceFile(f, src); err != nil {
+		if err := dumpSourceFile(f, src, relPath, tags, style); err != nil {
 			return errors.WithStack(err)
 		}
 	}
 	return nil
 }
 
-// dumpSourceFile outputs the declarations of the source file, writing to w.
-func dumpSourceFile(w io.Writer, src *SourceFile) error {
+// dumpSourceFile outputs the declarations of the source file, writing to w. The
+// locations of declarations are recorded in tags, as output to the given path
+// relative to the output directory.
+func dumpSourceFile(w io.Writer, src *SourceFile, path string, tags *tagsFile, style c.Style) error {
+	lw := newLineWriter(w)
+	w = lw
 	if _, err := fmt.Fprintf(w, "// %s\n\n", src.Path); err != nil {
 		return errors.WithStack(err)
 	}
@@ -183,29 +373,20 @@ func dumpSourceFile(w io.Writer, src *SourceFile) error {
 	if _, err := fmt.Fprintf(w, "#include %q\n\n", typesName); err != nil {
 		return errors.WithStack(err)
 	}
-	// Handle duplicate identifiers.
-	names := make(map[string]bool)
-	for _, v := range src.vars {
-		if names[v.Name] {
-			v.Name = csym.UniqueName(v.Name, v.Addr)
-		}
-		names[v.Name] = true
-	}
-	for _, f := range src.funcs {
-		if names[f.Name] {
-			f.Name = csym.UniqueName(f.Name, f.Addr)
-		}
-		names[f.Name] = true
-	}
+	src.uniqueNames()
 	// Print variable declarations.
 	for _, v := range src.vars {
-		if _, err := fmt.Fprintf(w, "%s;\n\n", v.Def()); err != nil {
+		def := v.DefStyle(style)
+		tags.addDef(path, lw.line, lw.offset, def, v.Name, tagKindVar, v.Addr)
+		if _, err := fmt.Fprintf(w, "%s;\n\n", def); err != nil {
 			return errors.WithStack(err)
 		}
 	}
 	// Print function declarations.
 	for _, f := range src.funcs {
-		if _, err := fmt.Fprintf(w, "%s\n\n", f.Def()); err != nil {
+		def := f.DefStyle(style)
+		tags.addDef(path, lw.line, lw.offset, def, f.Name, tagKindFunc, f.Addr)
+		if _, err := fmt.Fprintf(w, "%s\n\n", def); err != nil {
 			return errors.WithStack(err)
 		}
 	}
@@ -303,8 +484,91 @@ func dumpIDAOverlay(overlay *csym.Overlay, outputDir string) error {
 	return nil
 }
 
+// pruneIDATypes deletes the bool and __int64 types recorded by the parser, as
+// they cause issues with IDA.
+func pruneIDATypes(p *csym.Parser) {
+	delete(p.Types, "bool")
+	for i, def := range p.Typedefs {
+		if v, ok := def.(*c.VarDecl); ok {
+			if v.Name == "__int64" {
+				defs := append(p.Typedefs[:i], p.Typedefs[i+1:]...)
+				p.Typedefs = defs
+				break
+			}
+		}
+	}
+	delete(p.Types, "__int64")
+}
+
 // ### [ Helper functions ] ####################################################
 
+// typeString returns the C syntax of the given type on a single line, without
+// metadata comments; as used by the type strings of symbol exporters.
+func typeString(t c.Type) string {
+	return c.CompactStyle.DeclString(t, "")
+}
+
+// declString returns the C declaration of the given variable on a single line,
+// without metadata comments; as used by the type strings of symbol exporters.
+func declString(v c.Var) string {
+	return c.CompactStyle.DeclString(v.Type, v.Name)
+}
+
+// addrSymbol is a function or global variable symbol.
+type addrSymbol struct {
+	// Address.
+	addr uint32
+	// Size in bytes (optional).
+	size uint32
+	// Symbol name.
+	name string
+	// Function symbol.
+	isFunc bool
+}
+
+// sortedSymbols returns the function and global variable symbols of the
+// overlay, sorted by address.
+func sortedSymbols(overlay *csym.Overlay) []addrSymbol {
+	var syms []addrSymbol
+	for _, f := range overlay.Funcs {
+		syms = append(syms, addrSymbol{addr: f.Addr, size: f.Size, name: f.Name, isFunc: true})
+	}
+	for _, v := range overlay.Vars {
+		syms = append(syms, addrSymbol{addr: v.Addr, size: v.Size, name: v.Name})
+	}
+	sort.SliceStable(syms, func(i, j int) bool {
+		return syms[i].addr < syms[j].addr
+	})
+	return syms
+}
+
+// definedSymbols returns the symbols with address, omitting symbols without
+// address (e.g. external declarations).
+func definedSymbols(syms []addrSymbol) []addrSymbol {
+	var defined []addrSymbol
+	for _, sym := range syms {
+		if sym.addr != 0 {
+			defined = append(defined, sym)
+		}
+	}
+	return defined
+}
+
+// underlyingType returns the underlying type of the given type, resolving type
+// definitions and type qualifiers.
+func underlyingType(t c.Type) c.Type {
+	for {
+		switch u := t.(type) {
+		case *c.VarDecl:
+			t = u.Type
+		case *c.QualType:
+			t = u.Type
+		default:
+			return t
+		}
+	}
+}
+
 // getSourceFiles returns the source files recorded by the parser.
 func getSourceFiles(p *csym.Parser) []*SourceFile {
 	// Record source file information from overlays.
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// Overlay type definitions header file name format string.
+const overlayTypesNameFormat = "overlay_%x_types.h"
+
+// dumpOverlays outputs the type definitions and declarations recorded by the
+// parser to one set of C headers per overlay, stored in the output directory.
+// If check is set, the header of shared types is re-parsed to validate its
+// syntax. The locations of type definitions and declarations are recorded in
+// tags.
+//
+// Type definitions only used by the declarations of a single overlay are output
+// to the type definitions header of the overlay (e.g. overlay_4_types.h), and
+// the remaining type definitions to types.h. The declarations of the base
+// executable are output to decls.h, and the declarations of each overlay to the
+// header of the overlay (e.g. overlay_4.h), which includes decls.h.
+func dumpOverlays(p *csym.Parser, outputDir string, check bool, tags *tagsFile, style c.Style, guard string) error {
+	defs := typeDefs(p)
+	// users maps from type definition to the overlays using it, as located by
+	// the type definitions and function prototypes of their declarations.
+	users := make(map[c.Type]map[*csym.Overlay]bool)
+	for _, overlay := range append([]*csym.Overlay{p.Overlay}, p.Overlays...) {
+		var uses []c.Type
+		for _, v := range overlay.Vars {
+			uses = append(uses, v)
+		}
+		for _, f := range overlay.Funcs {
+			uses = append(uses, &c.VarDecl{Var: f.Var})
+		}
+		seen := make(map[c.Type]bool)
+		for len(uses) > 0 {
+			use := uses[0]
+			uses = uses[1:]
+			complete, incomplete := c.Deps(use)
+			for _, dep := range append(complete, incomplete...) {
+				if seen[dep] {
+					continue
+				}
+				seen[dep] = true
+				if users[dep] == nil {
+					users[dep] = make(map[*csym.Overlay]bool)
+				}
+				users[dep][overlay] = true
+				uses = append(uses, dep)
+			}
+		}
+	}
+	// owners maps from type definition to the header of the overlay using it;
+	// shared type definitions are not present.
+	owners := make(map[c.Type]string)
+	for _, overlay := range p.Overlays {
+		name := fmt.Sprintf(overlayTypesNameFormat, overlay.ID)
+		for _, def := range defs {
+			if len(users[def]) == 1 && users[def][overlay] {
+				owners[def] = name
+			}
+		}
+	}
+	shareTypes(defs, owners)
+	var shared []c.Type
+	overlayDefs := make(map[string][]c.Type)
+	for _, def := range defs {
+		name, ok := owners[def]
+		if !ok {
+			shared = append(shared, def)
+			continue
+		}
+		overlayDefs[name] = append(overlayDefs[name], def)
+	}
+	// Output shared type definitions and declarations of the base executable.
+	header, spans := defsHeader(p.Includes, nil, shared, typesName, style, guard)
+	if err := dumpHeader(outputDir, typesName, header, spans, check, tags, style); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := createOutputFile(outputDir, declsName, func(w io.Writer) error {
+		return dumpOverlay(w, p.Overlay, declsName, []string{typesName}, tags, style, guard)
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	// Output type definitions and declarations of overlays.
+	for _, overlay := range p.Overlays {
+		includes := []string{declsName}
+		overlayTypesName := fmt.Sprintf(overlayTypesNameFormat, overlay.ID)
+		if defs := overlayDefs[overlayTypesName]; len(defs) > 0 {
+			header, spans := defsHeader(nil, []string{typesName}, defs, overlayTypesName, style, guard)
+			// Type definitions of the overlay depend on the shared type
+			// definitions, and are thus not validated on their own.
+			if err := dumpHeader(outputDir, overlayTypesName, header, spans, false, tags, style); err != nil {
+				return errors.WithStack(err)
+			}
+			includes = append(includes, overlayTypesName)
+		}
+		overlayName := fmt.Sprintf(overlayNameFormat, overlay.ID)
+		if err := createOutputFile(outputDir, overlayName, func(w io.Writer) error {
+			return dumpOverlay(w, overlay, overlayName, includes, tags, style, guard)
+		}); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Protocol Buffers file name.
+const protoName = "symbols.pb"
+
+// dumpProto outputs the declarations and type information recorded by the
+// parser to a Protocol Buffers file stored in the output directory. The file
+// contains a single SymbolFile message, as defined by the schema of
+// proto/sym.proto.
+func dumpProto(p *csym.Parser, outputDir string) error {
+	if err := createOutputFile(outputDir, protoName, func(w io.Writer) error {
+		_, err := w.Write(appendSymbolFile(nil, p))
+		return err
+	}); err != nil {
+		return errors.Wrap(err, "unable to create Protocol Buffers file")
+	}
+	return nil
+}
+
+// The encoders below append the wire format encoding of the messages defined by
+// proto/sym.proto; field numbers must be kept in sync with the schema. Fields
+// of zero value are omitted, as per proto3.
+
+// appendSymbolFile appends the SymbolFile message of the parser to b.
+func appendSymbolFile(b []byte, p *csym.Parser) []byte {
+	overlays := append([]*csym.Overlay{p.Overlay}, p.Overlays...)
+	for _, overlay := range overlays {
+		b = appendMessage(b, 1, appendOverlay(nil, overlay))
+	}
+	b = appendMessage(b, 2, appendTypes(nil, p))
+	return b
+}
+
+// --- [ Declarations ] --------------------------------------------------------
+
+// appendOverlay appends the Overlay message of the given overlay to b.
+func appendOverlay(b []byte, overlay *csym.Overlay) []byte {
+	b = appendUint32(b, 1, overlay.ID)
+	b = appendUint32(b, 2, overlay.Addr)
+	b = appendUint32(b, 3, overlay.Length)
+	for _, v := range overlay.Vars {
+		b = appendMessage(b, 4, appendVariable(nil, v))
+	}
+	for _, f := range overlay.Funcs {
+		b = appendMessage(b, 5, appendFunction(nil, f))
+	}
+	return b
+}
+
+// appendVariable appends the Variable message of the given variable
+// declaration or type definition to b.
+func appendVariable(b []byte, v *c.VarDecl) []byte {
+	b = appendString(b, 1, v.Name)
+	b = appendUint32(b, 2, v.Addr)
+	b = appendUint32(b, 3, v.Size)
+	b = appendUint32(b, 4, uint32(v.Class))
+	b = appendString(b, 5, declString(v.Var))
+	return b
+}
+
+// appendFunction appends the Function message of the given function
+// declaration to b.
+func appendFunction(b []byte, f *c.FuncDecl) []byte {
+	b = appendString(b, 1, f.Name)
+	b = appendUint32(b, 2, f.Addr)
+	b = appendUint32(b, 3, f.Size)
+	b = appendUint32(b, 4, uint32(f.Class))
+	b = appendString(b, 5, declString(f.Var))
+	b = appendString(b, 6, f.Path)
+	b = appendUint32(b, 7, f.LineStart)
+	b = appendUint32(b, 8, f.LineEnd)
+	return b
+}
+
+// --- [ Types ] ---------------------------------------------------------------
+
+// appendTypes appends the Types message of the parser to b.
+func appendTypes(b []byte, p *csym.Parser) []byte {
+	for _, tag := range p.StructTags {
+		t := p.Structs[tag]
+		b = appendMessage(b, 1, appendComposite(nil, t.Tag, t.Size, t.Fields))
+	}
+	for _, tag := range p.UnionTags {
+		t := p.Unions[tag]
+		b = appendMessage(b, 2, appendComposite(nil, t.Tag, t.Size, t.Fields))
+	}
+	for _, tag := range p.EnumTags {
+		b = appendMessage(b, 3, appendEnum(nil, p.Enums[tag]))
+	}
+	for _, def := range p.Typedefs {
+		if v, ok := def.(*c.VarDecl); ok {
+			b = appendMessage(b, 4, appendVariable(nil, v))
+		}
+	}
+	return b
+}
+
+// appendComposite appends the Composite message of the struct or union type
+// with the given tag, size and fields to b.
+func appendComposite(b []byte, tag string, size uint32, fields []c.Field) []byte {
+	b = appendString(b, 1, tag)
+	b = appendUint32(b, 2, size)
+	for _, field := range fields {
+		var f []byte
+		f = appendString(f, 1, field.Name)
+		f = appendUint32(f, 2, field.Offset)
+		f = appendUint32(f, 3, field.Size)
+		f = appendUint32(f, 4, field.BitOffset)
+		f = appendUint32(f, 5, field.BitWidth)
+		f = appendString(f, 6, declString(field.Var))
+		b = appendMessage(b, 3, f)
+	}
+	return b
+}
+
+// appendEnum appends the Enum message of the given enum type to b.
+func appendEnum(b []byte, t *c.EnumType) []byte {
+	b = appendString(b, 1, t.Tag)
+	for _, member := range t.Members {
+		value := int64(member.Value)
+		if t.Signed {
+			value = int64(int32(member.Value))
+		}
+		var m []byte
+		m = appendString(m, 1, member.Name)
+		if value != 0 {
+			m = protowire.AppendTag(m, 2, protowire.VarintType)
+			m = protowire.AppendVarint(m, uint64(value))
+		}
+		b = appendMessage(b, 2, m)
+	}
+	return b
+}
+
+// ### [ Helper functions ] ####################################################
+
+// appendMessage appends the field of the given number and encoded message to b.
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+// appendString appends the string field of the given number to b, unless
+// empty.
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if len(s) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+// appendUint32 appends the uint32 (or enum) field of the given number to b,
+// unless zero.
+func appendUint32(b []byte, num protowire.Number, v uint32) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// protoSchema is the schema of the Protocol Buffers output format.
+const protoSchema = "../../proto/sym.proto"
+
+func TestDumpProto(t *testing.T) {
+	p := parseTestFile(t)
+	dir := t.TempDir()
+	if err := dumpProto(p, dir); err != nil {
+		t.Fatalf("unable to output Protocol Buffers file; %+v", err)
+	}
+	buf, err := ioutil.ReadFile(filepath.Join(dir, protoName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Decode the output against the schema. Fields of unknown numbers, and
+	// fields of known numbers with mismatching wire types, are retained as
+	// unknown fields of the decoded messages.
+	fd := parseProtoSchema(t, protoSchema)
+	md := fd.Messages().ByName("SymbolFile")
+	m := dynamicpb.NewMessage(md)
+	if err := proto.Unmarshal(buf, m); err != nil {
+		t.Fatalf("unable to decode SymbolFile message; %v", err)
+	}
+	checkUnknownFields(t, m)
+	// The encoding must round-trip; i.e. fields are encoded in order of field
+	// number with the wire types of the schema, and fields of zero value are
+	// omitted.
+	got, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, buf) {
+		i := 0
+		for i < len(got) && i < len(buf) && got[i] == buf[i] {
+			i++
+		}
+		t.Errorf("re-encoded SymbolFile message mismatch at offset %d; expected %d bytes, got %d bytes", i, len(buf), len(got))
+	}
+	// Spot-check decoded values.
+	overlays := m.Get(md.Fields().ByName("overlays")).List()
+	if overlays.Len() != 2 {
+		t.Fatalf("number of overlays mismatch; expected 2, got %d", overlays.Len())
+	}
+	overlay := overlays.Get(1).Message()
+	if got, want := overlay.Get(field(overlay, "id")).Uint(), uint64(4); got != want {
+		t.Errorf("overlay ID mismatch; expected %d, got %d", want, got)
+	}
+	if got, want := overlay.Get(field(overlay, "address")).Uint(), uint64(0x800B0000); got != want {
+		t.Errorf("overlay address mismatch; expected 0x%08X, got 0x%08X", want, got)
+	}
+	funcs := overlays.Get(0).Message().Get(field(overlays.Get(0).Message(), "funcs")).List()
+	if funcs.Len() == 0 {
+		t.Fatalf("no functions in default binary")
+	}
+	add := funcs.Get(0).Message()
+	golden := []struct {
+		name string
+		want string
+	}{
+		{name: "name", want: "add"},
+		{name: "address", want: "2147549440"},
+		{name: "class", want: "2"},
+		{name: "type", want: "int add(struct point *p, int n)"},
+		{name: "source_file", want: `C:\PROJ\SRC\FOO.C`},
+		{name: "line_start", want: "26"},
+		{name: "line_end", want: "35"},
+	}
+	for _, g := range golden {
+		v := add.Get(field(add, g.name))
+		var got string
+		switch v := v.Interface().(type) {
+		case string:
+			got = v
+		case uint32:
+			got = strconv.FormatUint(uint64(v), 10)
+		case protoreflect.EnumNumber:
+			got = strconv.Itoa(int(v))
+		}
+		if got != g.want {
+			t.Errorf("function field %q mismatch; expected %q, got %q", g.name, g.want, got)
+		}
+	}
+	// Signed enum members are encoded as negative int64 values.
+	types := m.Get(md.Fields().ByName("types")).Message()
+	enums := types.Get(field(types, "enums")).List()
+	values := make(map[string]int64)
+	for i := 0; i < enums.Len(); i++ {
+		enum := enums.Get(i).Message()
+		members := enum.Get(field(enum, "members")).List()
+		for j := 0; j < members.Len(); j++ {
+			member := members.Get(j).Message()
+			values[member.Get(field(member, "name")).String()] = member.Get(field(member, "value")).Int()
+		}
+	}
+	if got, want := values["NONE"], int64(-1); got != want {
+		t.Errorf("value of enum member NONE mismatch; expected %d, got %d", want, got)
+	}
+}
+
+// field returns the field descriptor of the given name of the message.
+func field(m protoreflect.Message, name string) protoreflect.FieldDescriptor {
+	return m.Descriptor().Fields().ByName(protoreflect.Name(name))
+}
+
+// checkUnknownFields reports unknown fields of the given message and its
+// submessages.
+func checkUnknownFields(t *testing.T, m protoreflect.Message) {
+	t.Helper()
+	if unknown := m.GetUnknown(); len(unknown) > 0 {
+		t.Errorf("%s message contains fields not defined by the schema (%d bytes)", m.Descriptor().Name(), len(unknown))
+	}
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Kind() != protoreflect.MessageKind {
+			return true
+		}
+		if fd.IsList() {
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				checkUnknownFields(t, list.Get(i).Message())
+			}
+			return true
+		}
+		checkUnknownFields(t, v.Message())
+		return true
+	})
+}
+
+// Scalar types of the schema.
+var protoScalars = map[string]descriptorpb.FieldDescriptorProto_Type{
+	"uint32": descriptorpb.FieldDescriptorProto_TYPE_UINT32,
+	"int64":  descriptorpb.FieldDescriptorProto_TYPE_INT64,
+	"string": descriptorpb.FieldDescriptorProto_TYPE_STRING,
+}
+
+var (
+	// `message Name {` or `enum Name {`
+	protoDefPattern = regexp.MustCompile(`^(message|enum)\s+(\w+)\s*{$`)
+	// `repeated Type name = 1;`
+	protoFieldPattern = regexp.MustCompile(`^(repeated\s+)?(\w+)\s+(\w+)\s*=\s*(\d+);$`)
+	// `NAME = 1;`
+	protoValuePattern = regexp.MustCompile(`^(\w+)\s*=\s*(\d+);$`)
+)
+
+// parseProtoSchema parses the Protocol Buffers schema of the given path. Only
+// the subset of the proto3 language used by proto/sym.proto is supported;
+// top-level messages of scalar, enum and message fields, and top-level enums.
+func parseProtoSchema(t *testing.T, path string) protoreflect.FileDescriptor {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	file := &descriptorpb.FileDescriptorProto{
+		Name: proto.String(filepath.Base(path)),
+	}
+	enums := make(map[string]bool)
+	var (
+		msg  *descriptorpb.DescriptorProto
+		enum *descriptorpb.EnumDescriptorProto
+	)
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := s.Text()
+		if pos := strings.Index(line, "//"); pos != -1 {
+			line = line[:pos]
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case len(line) == 0:
+			// Skip empty lines and comments.
+		case strings.HasPrefix(line, "syntax "):
+			file.Syntax = proto.String(strings.Trim(strings.TrimSuffix(line[len("syntax "):], ";"), ` ="`))
+		case strings.HasPrefix(line, "package "):
+			file.Package = proto.String(strings.TrimSuffix(line[len("package "):], ";"))
+		case line == "}":
+			msg, enum = nil, nil
+		case msg == nil && enum == nil && protoDefPattern.MatchString(line):
+			m := protoDefPattern.FindStringSubmatch(line)
+			if m[1] == "message" {
+				msg = &descriptorpb.DescriptorProto{Name: proto.String(m[2])}
+				file.MessageType = append(file.MessageType, msg)
+			} else {
+				enum = &descriptorpb.EnumDescriptorProto{Name: proto.String(m[2])}
+				file.EnumType = append(file.EnumType, enum)
+				enums[m[2]] = true
+			}
+		case msg != nil && protoFieldPattern.MatchString(line):
+			m := protoFieldPattern.FindStringSubmatch(line)
+			num, _ := strconv.Atoi(m[4])
+			fd := &descriptorpb.FieldDescriptorProto{
+				Name:   proto.String(m[3]),
+				Number: proto.Int32(int32(num)),
+				Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			}
+			if len(m[1]) > 0 {
+				fd.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+			}
+			if typ, ok := protoScalars[m[2]]; ok {
+				fd.Type = typ.Enum()
+			} else {
+				// Resolved to message or enum type once all types are known.
+				fd.TypeName = proto.String(m[2])
+			}
+			msg.Field = append(msg.Field, fd)
+		case enum != nil && protoValuePattern.MatchString(line):
+			m := protoValuePattern.FindStringSubmatch(line)
+			num, _ := strconv.Atoi(m[2])
+			enum.Value = append(enum.Value, &descriptorpb.EnumValueDescriptorProto{
+				Name:   proto.String(m[1]),
+				Number: proto.Int32(int32(num)),
+			})
+		default:
+			t.Fatalf("%s:%d: unsupported syntax %q", path, n, line)
+		}
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	// Resolve message and enum types of fields.
+	for _, msg := range file.MessageType {
+		for _, fd := range msg.Field {
+			if fd.TypeName == nil {
+				continue
+			}
+			name := fd.GetTypeName()
+			if enums[name] {
+				fd.Type = descriptorpb.FieldDescriptorProto_TYPE_ENUM.Enum()
+			} else {
+				fd.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+			}
+			fd.TypeName = proto.String("." + file.GetPackage() + "." + name)
+		}
+	}
+	fd, err := protodesc.NewFile(file, nil)
+	if err != nil {
+		t.Fatalf("invalid schema %q; %v", path, err)
+	}
+	return fd
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym"
+)
+
+// queryUsage prints usage information of the query command.
+func queryUsage(fs *flag.FlagSet) {
+	const use = `
+Look up the symbols of a symbol file located at (or covering) the given addresses, or of names matching a glob pattern.
+
+Usage:
+
+	sym_dump query [OPTION]... FILE ADDR...
+	sym_dump query [OPTION]... -name PATTERN FILE
+
+Examples:
+
+	sym_dump query FILE.SYM 0x8001a2c4
+	sym_dump query -name 'Sprite*' FILE.SYM
+`
+	fmt.Println(use[1:])
+	fs.PrintDefaults()
+}
+
+// A queryMatch is a symbol matched by the query command.
+type queryMatch struct {
+	// Symbol name.
+	Name string `json:"name"`
+	// Symbol address.
+	Addr uint32 `json:"addr"`
+	// Offset of the queried address from the symbol address.
+	Offset uint32 `json:"offset,omitempty"`
+	// Symbol kind, in DUMPSYM representation (e.g. "8c").
+	Kind string `json:"kind"`
+	// Definition class; or empty if not a definition.
+	Class string `json:"class,omitempty"`
+	// Size in bytes; or 0 if unknown.
+	Size uint32 `json:"size,omitempty"`
+	// Provenance of the size.
+	SizeSource string `json:"size_source"`
+	// ID of the overlay containing the symbol (0 for the default binary).
+	Overlay uint32 `json:"overlay"`
+}
+
+// query looks up the symbols of the symbol file specified by the given command
+// line arguments of the query command, and prints the matched symbols to
+// standard output.
+func query(args []string) error {
+	// Command line flags.
+	var (
+		// Glob pattern of symbol names.
+		name string
+		// Overlay IDs.
+		overlays string
+		// Output in JSON format.
+		outputJSON bool
+		// Input format.
+		from string
+		// Text encoding of symbol names.
+		encodingName string
+		// Byte order of SYM files.
+		endian string
+	)
+	fs := flag.NewFlagSet("query", flag.ExitOnError)
+	fs.StringVar(&name, "name", "", "glob pattern of symbol names to look up (e.g. 'Sprite*')")
+	fs.StringVar(&overlays, "overlay", "", "comma-separated list of overlay IDs to search (0 for the default binary); all if not specified")
+	fs.BoolVar(&outputJSON, "json", false, "output in JSON format")
+	fs.StringVar(&from, "from", "", "input format; detected if not specified")
+	fs.StringVar(&encodingName, "encoding", "", "text encoding of symbol names (sjis, or auto to detect)")
+	fs.StringVar(&endian, "endian", "", "byte order of SYM files (big or little); detected if not specified")
+	fs.Usage = func() { queryUsage(fs) }
+	if err := fs.Parse(args); err != nil {
+		return errors.WithStack(err)
+	}
+	if fs.NArg() == 0 || (len(name) == 0 && fs.NArg() < 2) {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if len(name) > 0 {
+		// Validate glob pattern.
+		if _, err := path.Match(name, ""); err != nil {
+			return errors.Wrapf(err, "invalid glob pattern %q", name)
+		}
+	}
+	if len(from) > 0 && !isInputFormat(from) {
+		return errors.Errorf("invalid input format %q", from)
+	}
+	enc, err := parseEncoding(encodingName)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	order, err := parseByteOrder(endian)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	ids, err := parseOverlayIDs(overlays)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var addrs []uint32
+	for _, arg := range fs.Args()[1:] {
+		addr, err := parseHex(arg)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		addrs = append(addrs, addr)
+	}
+	opts := &sym.ParseOptions{
+		Encoding:       enc,
+		Order:          order,
+		DetectEncoding: strings.EqualFold(encodingName, "auto"),
+	}
+	f, err := parseFile(fs.Arg(0), from, opts)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	idx := sym.NewAddrIndex(f.Syms)
+	// searched reports whether the given overlay is searched.
+	searched := func(overlay uint32) bool {
+		if ids == nil {
+			return true
+		}
+		for _, id := range ids {
+			if id == overlay {
+				return true
+			}
+		}
+		return false
+	}
+	var matches []*queryMatch
+	if len(name) > 0 {
+		for _, e := range idx.Entries {
+			if ok, _ := path.Match(name, e.Name); ok && searched(e.Overlay) {
+				matches = append(matches, newQueryMatch(e, e.Addr))
+			}
+		}
+	}
+	for _, addr := range addrs {
+		for _, e := range idx.Entries {
+			if !searched(e.Overlay) {
+				continue
+			}
+			// Symbols located at the address, or covering it.
+			if e.Addr == addr || (e.Addr < addr && addr-e.Addr < e.Size) {
+				matches = append(matches, newQueryMatch(e, addr))
+			}
+		}
+	}
+	if outputJSON {
+		if matches == nil {
+			matches = []*queryMatch{}
+		}
+		buf, err := json.MarshalIndent(matches, "", "\t")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		fmt.Println(string(buf))
+		return nil
+	}
+	if len(matches) == 0 {
+		// Report failed lookups by exit status, as grep does.
+		fmt.Fprintln(os.Stderr, "no matching symbols")
+		os.Exit(1)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ADDRESS\tNAME\tKIND\tCLASS\tSIZE\tOVERLAY")
+	for _, m := range matches {
+		addr := fmt.Sprintf("0x%08X", m.Addr)
+		name := m.Name
+		if m.Offset != 0 {
+			name = fmt.Sprintf("%s+0x%X", m.Name, m.Offset)
+		}
+		size := "-"
+		if m.Size != 0 {
+			size = fmt.Sprintf("0x%X (%s)", m.Size, m.SizeSource)
+		}
+		class := m.Class
+		if len(class) == 0 {
+			class = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%X\n", addr, name, m.Kind, class, size, m.Overlay)
+	}
+	return w.Flush()
+}
+
+// newQueryMatch returns a new query match of the given address index entry,
+// as queried by the given address.
+func newQueryMatch(e *sym.AddrEntry, addr uint32) *queryMatch {
+	m := &queryMatch{
+		Name:       e.Name,
+		Addr:       e.Addr,
+		Offset:     addr - e.Addr,
+		Kind:       e.Sym.Hdr.Kind.String(),
+		Size:       e.Size,
+		SizeSource: e.SizeSource.String(),
+		Overlay:    e.Overlay,
+	}
+	switch body := e.Sym.Body.(type) {
+	case *sym.Def:
+		m.Class = body.Class.String()
+	case *sym.Def2:
+		m.Class = body.Class.String()
+	}
+	return m
+}
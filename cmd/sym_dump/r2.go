@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// radare2 script name.
+const r2Name = "symbols.r2"
+
+// dumpR2 outputs the declarations and type information recorded by the parser
+// to a radare2 (and rizin) script stored in the output directory, as loaded by
+// `r2 -i symbols.r2 BINARY`. The script:
+//
+//   - defines the C types of the SYM file (td);
+//   - flags functions and global variables (f sym.NAME SIZE @ ADDR), in one flag
+//     space per overlay (symbols for the default binary, and overlay_N for
+//     overlays);
+//   - defines functions and their signatures (af and afs); and
+//   - links the types of global variables of struct, union, enum and type
+//     definition type (tl).
+func dumpR2(p *csym.Parser, outputDir string) error {
+	if err := createOutputFile(outputDir, r2Name, func(f io.Writer) error {
+		w := bufio.NewWriter(f)
+		w.WriteString("# Import symbols of PS1 SYM file, as generated by sym_dump.\n")
+		w.WriteString("#\n")
+		w.WriteString("# Usage: r2 -i symbols.r2 BINARY\n")
+		// Types.
+		w.WriteString("\n# Types.\n")
+		for _, def := range r2Defs(p) {
+			fmt.Fprintf(w, "\"td %s;\"\n", def.DefStyle(c.CompactStyle))
+		}
+		// Declarations.
+		overlays := append([]*csym.Overlay{p.Overlay}, p.Overlays...)
+		for _, overlay := range overlays {
+			space := "symbols"
+			if overlay.ID != 0 {
+				space = fmt.Sprintf("overlay_%x", overlay.ID)
+			}
+			fmt.Fprintf(w, "\n# Declarations of %s.\n", space)
+			fmt.Fprintf(w, "fs %s\n", space)
+			for _, fn := range overlay.Funcs {
+				fmt.Fprintf(w, "f %s @ 0x%08X\n", r2Flag(fn.Name, fn.Size), fn.Addr)
+				fmt.Fprintf(w, "af sym.%s @ 0x%08X\n", fn.Name, fn.Addr)
+				fmt.Fprintf(w, "afs %s @ 0x%08X\n", declString(fn.Var), fn.Addr)
+			}
+			for _, v := range overlay.Vars {
+				fmt.Fprintf(w, "f %s @ 0x%08X\n", r2Flag(v.Name, v.Size), v.Addr)
+				if name, ok := r2TypeName(v.Type); ok {
+					fmt.Fprintf(w, "tl %s = 0x%08X\n", name, v.Addr)
+				}
+			}
+		}
+		w.WriteString("\nfs *\n")
+		return w.Flush()
+	}); err != nil {
+		return errors.Wrap(err, "unable to create radare2 script")
+	}
+	return nil
+}
+
+// r2Defs returns the type definitions recorded by the parser, in dependency
+// order.
+func r2Defs(p *csym.Parser) []c.Type {
+	var defs []c.Type
+	for _, tag := range p.EnumTags {
+		t := p.Enums[tag]
+		if t.Typedef != nil {
+			// Enum defined inline in type definition.
+			continue
+		}
+		defs = append(defs, t)
+	}
+	for _, tag := range p.StructTags {
+		defs = append(defs, p.Structs[tag])
+	}
+	for _, tag := range p.UnionTags {
+		defs = append(defs, p.Unions[tag])
+	}
+	defs = append(defs, p.Typedefs...)
+	return c.SortDefs(defs)
+}
+
+// ### [ Helper functions ] ####################################################
+
+// r2Flag returns the flag name and size (optional) of the symbol of the given
+// name and size.
+func r2Flag(name string, size uint32) string {
+	if size == 0 {
+		return "sym." + name
+	}
+	return fmt.Sprintf("sym.%s 0x%X", name, size)
+}
+
+// r2TypeName returns the radare2 type name of the given type of a global
+// variable, and a boolean indicating whether the type may be linked to the
+// variable; i.e. whether the type is a struct, union, enum or type definition.
+func r2TypeName(t c.Type) (string, bool) {
+	switch t := t.(type) {
+	case *c.StructType:
+		return t.Tag, true
+	case *c.UnionType:
+		return t.Tag, true
+	case *c.EnumType:
+		return t.Tag, true
+	case *c.VarDecl:
+		// Type definition.
+		return t.Name, true
+	}
+	return "", false
+}
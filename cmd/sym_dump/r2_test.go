@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDumpR2Types(t *testing.T) {
+	p := parseTestFile(t)
+	dir := t.TempDir()
+	if err := dumpR2(p, dir); err != nil {
+		t.Fatalf("unable to output radare2 script; %+v", err)
+	}
+	f, err := os.Open(filepath.Join(dir, r2Name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	// Type definitions are given on a single line each; a comment would comment
+	// out the remainder of the definition.
+	defs := make(map[string]bool)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if !strings.HasPrefix(line, `"td `) {
+			continue
+		}
+		if !strings.HasSuffix(line, `;"`) {
+			t.Errorf("type definition %q not terminated on the same line", line)
+		}
+		if strings.Contains(line, "//") || strings.Contains(line, "/*") {
+			t.Errorf("type definition %q contains comment", line)
+		}
+		defs[strings.TrimSuffix(strings.TrimPrefix(line, `"td `), `;"`)] = true
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"enum color { NONE = -1, RED = 0, GREEN = 1 }",
+		"typedef struct { enum color c; void (*cb)(); } entry_t",
+	} {
+		if !defs[want] {
+			t.Errorf("missing type definition %q", want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym"
+)
+
+// rebaseUsage prints usage information of the rebase command.
+func rebaseUsage(fs *flag.FlagSet) {
+	const use = `
+Slide the addresses of symbols by a constant delta, for executables loaded at a different base address than assumed by the symbol files, and output the rebased symbols in the specified output format.
+
+Usage:
+
+	sym_dump rebase [OPTION]... -delta DELTA FILE...
+
+Examples:
+
+	# Slide the symbols of overlay 4 down by 0x800.
+	sym_dump rebase -delta -0x800 -overlay 4 FILE
+`
+	fmt.Println(use[1:])
+	fs.PrintDefaults()
+}
+
+// rebase slides the addresses of the symbol files specified by the given
+// command line arguments of the rebase command, and outputs the rebased symbols
+// in the specified output format.
+func rebase(args []string) error {
+	// Command line flags.
+	var (
+		// Input format.
+		from string
+		// Output format.
+		to string
+		// Output directory.
+		outputDir string
+		// Text encoding of symbol names.
+		encodingName string
+		// Byte order of SYM files.
+		endian string
+		// Rebase criteria.
+		delta    string
+		minAddr  string
+		maxAddr  string
+		overlays string
+	)
+	fs := flag.NewFlagSet("rebase", flag.ExitOnError)
+	fs.StringVar(&from, "from", "", "input format; detected if not specified")
+	fs.StringVar(&to, "to", "sym", "output format")
+	fs.StringVar(&outputDir, "dir", dumpDir, "output directory (- for standard output)")
+	fs.StringVar(&encodingName, "encoding", "", "text encoding of symbol names (sjis, or auto to detect)")
+	fs.StringVar(&endian, "endian", "", "byte order of SYM files (big or little); detected if not specified")
+	fs.StringVar(&delta, "delta", "", "delta in hexadecimal added to addresses (e.g. 0x1000 or -0x1000)")
+	fs.StringVar(&minAddr, "minaddr", "", "start address of address range to rebase (inclusive)")
+	fs.StringVar(&maxAddr, "maxaddr", "", "end address of address range to rebase (exclusive)")
+	fs.StringVar(&overlays, "overlay", "", "comma-separated list of overlay IDs to rebase (0 for the default binary)")
+	fs.Usage = func() { rebaseUsage(fs) }
+	if err := fs.Parse(args); err != nil {
+		return errors.WithStack(err)
+	}
+	if fs.NArg() == 0 || len(delta) == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if len(from) > 0 && !isInputFormat(from) {
+		return errors.Errorf("invalid input format %q", from)
+	}
+	format := lookupOutputFormat(to)
+	if format == nil {
+		return errors.Errorf("invalid output format %q", to)
+	}
+	enc, err := parseEncoding(encodingName)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	order, err := parseByteOrder(endian)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	rebase := &sym.Rebase{}
+	if rebase.Delta, err = parseDelta(delta); err != nil {
+		return errors.WithStack(err)
+	}
+	if rebase.MinAddr, err = parseHex(minAddr); err != nil {
+		return errors.WithStack(err)
+	}
+	if rebase.MaxAddr, err = parseHex(maxAddr); err != nil {
+		return errors.WithStack(err)
+	}
+	if rebase.Overlays, err = parseOverlayIDs(overlays); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := initOutputDir(outputDir); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, path := range fs.Args() {
+		opts := &sym.ParseOptions{
+			Encoding:       enc,
+			Order:          order,
+			DetectEncoding: strings.EqualFold(encodingName, "auto"),
+		}
+		f, err := parseFile(path, from, opts)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		n := rebase.Apply(f.Syms)
+		fmt.Printf("rebased %d symbols of %q\n", n, path)
+		if err := outputFile(format, f, path, outputDir, fs.NArg() > 1); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// parseDelta returns the value of the given signed hexadecimal string, with
+// optional "0x" prefix; e.g. "-0x1000".
+func parseDelta(s string) (int32, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	x, err := parseHex(strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+"))
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if x > math.MaxInt32 {
+		return 0, errors.Errorf("invalid delta %q; exceeds 0x%X", s, math.MaxInt32)
+	}
+	if neg {
+		return -int32(x), nil
+	}
+	return int32(x), nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+)
+
+// PCSX-Redux file names.
+const (
+	// Symbol map.
+	reduxMapName = "pcsx_redux.map"
+	// Lua script.
+	reduxLuaName = "pcsx_redux.lua"
+)
+
+// dumpRedux outputs the declarations recorded by the parser to PCSX-Redux
+// symbol maps and Lua scripts stored in the output directory; one of each for
+// the default binary, and for each overlay (stored in overlay_N).
+func dumpRedux(p *csym.Parser, outputDir string) error {
+	// Create files of default binary.
+	if err := dumpReduxOverlay(p.Overlay, outputDir); err != nil {
+		return errors.WithStack(err)
+	}
+	// Create files of overlays.
+	for _, overlay := range p.Overlays {
+		if err := dumpReduxOverlay(overlay, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// dumpReduxOverlay outputs the declarations of the overlay to a PCSX-Redux
+// symbol map and Lua script, sorted by address. Symbols without address (e.g.
+// external declarations) are omitted.
+//
+// The symbol map contains one "ADDRESS NAME" line per symbol. The Lua script
+// defines a table of symbols with their extents (address and size), and
+// functions to look up the symbol containing an address (e.g. the program
+// counter) and the address of a symbol by name.
+func dumpReduxOverlay(overlay *csym.Overlay, outputDir string) error {
+	dir := outputDir
+	if overlay.ID != 0 {
+		overlayDir := fmt.Sprintf("overlay_%x", overlay.ID)
+		dir = filepath.Join(outputDir, overlayDir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	syms := definedSymbols(sortedSymbols(overlay))
+	// Create symbol map.
+	mapPath := filepath.Join(dir, reduxMapName)
+	fmt.Println("creating:", mapPath)
+	f, err := os.Create(mapPath)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create PCSX-Redux symbol map %q", mapPath)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	for _, sym := range syms {
+		fmt.Fprintf(w, "%08x %s\n", sym.addr, sym.name)
+	}
+	if err := w.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+	// Create Lua script.
+	luaPath := filepath.Join(dir, reduxLuaName)
+	fmt.Println("creating:", luaPath)
+	f, err = os.Create(luaPath)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create PCSX-Redux Lua script %q", luaPath)
+	}
+	defer f.Close()
+	w = bufio.NewWriter(f)
+	w.WriteString(reduxLuaHeader)
+	for _, sym := range syms {
+		fmt.Fprintf(w, "\t{ addr = 0x%08X, size = 0x%X, name = %q, func = %t },\n", sym.addr, sym.size, sym.name, sym.isFunc)
+	}
+	w.WriteString(reduxLuaFooter)
+	if err := w.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// reduxLuaHeader is the header of the PCSX-Redux Lua script.
+const reduxLuaHeader = `-- Symbols of PS1 SYM file, as generated by sym_dump.
+--
+-- Usage from the Lua console of PCSX-Redux:
+--
+--    sym = dofile("pcsx_redux.lua")
+--    print(sym.lookup(0x80010010))  -- "main", 0
+--    print(sym.addr("main"))        -- 0x80010010
+
+local M = {}
+
+-- Symbols sorted by address.
+M.symbols = {
+`
+
+// reduxLuaFooter is the footer of the PCSX-Redux Lua script, defining the
+// lookup functions.
+const reduxLuaFooter = `}
+
+local byName = {}
+for _, s in ipairs(M.symbols) do
+	byName[s.name] = s
+end
+
+-- lookup returns the name of the symbol containing the given address, and the
+-- offset of the address within the symbol; or nil if not located.
+function M.lookup(addr)
+	local found = nil
+	for _, s in ipairs(M.symbols) do
+		if s.addr > addr then
+			break
+		end
+		if addr < s.addr + math.max(s.size, 1) then
+			found = s
+		end
+	end
+	if found == nil then
+		return nil
+	end
+	return found.name, addr - found.addr
+end
+
+-- addr returns the address and size of the symbol of the given name; or nil
+-- if not present.
+function M.addr(name)
+	local s = byName[name]
+	if s == nil then
+		return nil
+	end
+	return s.addr, s.size
+end
+
+return M
+`
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym"
+)
+
+// renameUsage prints usage information of the rename command.
+func renameUsage(fs *flag.FlagSet) {
+	const use = `
+Rename the global symbols of symbol files, as specified by a mapping of old names or addresses to new names, and output the renamed symbols in the specified output format.
+
+Usage:
+
+	sym_dump rename [OPTION]... -map MAPPING FILE...
+
+The name mapping is a CSV file of "old,new" records, or a JSON object of "old": "new" members (*.json); old names may be specified by address in hexadecimal with "0x" prefix.
+`
+	fmt.Println(use[1:])
+	fs.PrintDefaults()
+}
+
+// rename renames the global symbols of the symbol files specified by the given
+// command line arguments of the rename command, and outputs the renamed symbols
+// in the specified output format.
+func rename(args []string) error {
+	// Command line flags.
+	var (
+		// Name mapping file.
+		mapPath string
+		// Input format.
+		from string
+		// Output format.
+		to string
+		// Output directory.
+		outputDir string
+		// Text encoding of symbol names.
+		encodingName string
+		// Byte order of SYM files.
+		endian string
+	)
+	fs := flag.NewFlagSet("rename", flag.ExitOnError)
+	fs.StringVar(&mapPath, "map", "", "name mapping file (*.csv or *.json)")
+	fs.StringVar(&from, "from", "", "input format; detected if not specified")
+	fs.StringVar(&to, "to", "sym", "output format")
+	fs.StringVar(&outputDir, "dir", dumpDir, "output directory (- for standard output)")
+	fs.StringVar(&encodingName, "encoding", "", "text encoding of symbol names (sjis, or auto to detect)")
+	fs.StringVar(&endian, "endian", "", "byte order of SYM files (big or little); detected if not specified")
+	fs.Usage = func() { renameUsage(fs) }
+	if err := fs.Parse(args); err != nil {
+		return errors.WithStack(err)
+	}
+	if fs.NArg() == 0 || len(mapPath) == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if len(from) > 0 && !isInputFormat(from) {
+		return errors.Errorf("invalid input format %q", from)
+	}
+	format := lookupOutputFormat(to)
+	if format == nil {
+		return errors.Errorf("invalid output format %q", to)
+	}
+	enc, err := parseEncoding(encodingName)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	order, err := parseByteOrder(endian)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	m, err := sym.ParseNameMapFile(mapPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := initOutputDir(outputDir); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, path := range fs.Args() {
+		opts := &sym.ParseOptions{
+			Encoding:       enc,
+			Order:          order,
+			DetectEncoding: strings.EqualFold(encodingName, "auto"),
+		}
+		f, err := parseFile(path, from, opts)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		n, err := m.Rename(f.Syms)
+		if err != nil {
+			return errors.Wrapf(err, "unable to rename symbols of %q", path)
+		}
+		fmt.Printf("renamed %d symbols of %q\n", n, path)
+		if err := outputFile(format, f, path, outputDir, fs.NArg() > 1); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
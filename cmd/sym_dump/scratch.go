@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// dumpScratch outputs a self-contained C context of the function of the given
+// name to a file stored in the output directory (named NAME_ctx.c), suitable for
+// pasting into the context of decomp.me scratches.
+//
+// The context contains the type definitions transitively required by the
+// function (its return type, parameters and local variables) and by the
+// referenced global variables and functions, followed by the declarations of
+// the referenced global variables and functions and the prototype of the
+// function. As SYM files do not record references between symbols, referenced
+// symbols are given by syms, a comma-separated list of global variable and
+// function names.
+func dumpScratch(p *csym.Parser, outputDir, funcName, syms string, style c.Style) error {
+	if len(funcName) == 0 {
+		return errors.New("missing function name of scratch context; use -scratchfunc")
+	}
+	overlays := append([]*csym.Overlay{p.Overlay}, p.Overlays...)
+	f, ok := findFunc(overlays, funcName)
+	if !ok {
+		return errors.Errorf("unable to locate function %q of scratch context", funcName)
+	}
+	// Declarations of referenced symbols.
+	var decls []string
+	roots := []c.Type{f.Type}
+	for _, block := range f.Blocks {
+		for _, local := range block.Locals {
+			roots = append(roots, local.Type)
+		}
+	}
+	for _, name := range strings.Split(syms, ",") {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+		if v, ok := findVar(overlays, name); ok {
+			roots = append(roots, v.Type)
+			decls = append(decls, m2cVarDecl(v))
+			continue
+		}
+		if g, ok := findFunc(overlays, name); ok {
+			roots = append(roots, g.Type)
+			decls = append(decls, funcProto(g))
+			continue
+		}
+		return errors.Errorf("unable to locate global variable or function %q referenced by scratch context", name)
+	}
+	decls = append(decls, funcProto(f))
+	// Type definitions transitively required by the declarations.
+	g := newTypeGraph(p)
+	required := make(map[c.Type]bool)
+	for _, root := range roots {
+		for _, ref := range typeRefs(root, false) {
+			required[ref.to] = true
+			for _, t := range g.reachable(ref.to) {
+				required[t] = true
+			}
+		}
+	}
+	var defs []c.Type
+	for _, def := range typeDefs(p) {
+		if t, ok := def.(*c.VarDecl); ok {
+			if e, ok := t.Type.(*c.EnumType); ok && e.Typedef == t && required[e] {
+				// Enum defined inline in type definition.
+				required[t] = true
+			}
+		}
+		if required[def] {
+			defs = append(defs, def)
+		}
+	}
+	// Create output file.
+	ctxPath := filepath.Join(outputDir, fmt.Sprintf("%s_ctx.c", f.Name))
+	fmt.Println("creating:", ctxPath)
+	file, err := os.Create(ctxPath)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create scratch context %q", ctxPath)
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	for _, include := range p.Includes {
+		if include == "stdint.h" {
+			w.WriteString(m2cStdint)
+			w.WriteString("\n")
+		}
+	}
+	if fwds := c.ForwardDecls(defs); len(fwds) > 0 {
+		for _, t := range fwds {
+			fmt.Fprintf(w, "%s;\n", t)
+		}
+		w.WriteString("\n")
+	}
+	for _, def := range defs {
+		fmt.Fprintf(w, "%s;\n\n", def.DefStyle(style))
+	}
+	for _, decl := range decls {
+		fmt.Fprintf(w, "%s;\n", decl)
+	}
+	if err := w.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// findFunc returns the function of the given name in the given overlays, and a
+// boolean indicating if such a function was found.
+func findFunc(overlays []*csym.Overlay, name string) (*c.FuncDecl, bool) {
+	for _, overlay := range overlays {
+		for _, f := range overlay.Funcs {
+			if f.Name == name {
+				return f, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// findVar returns the global variable of the given name in the given overlays,
+// and a boolean indicating if such a variable was found.
+func findVar(overlays []*csym.Overlay, name string) (*c.VarDecl, bool) {
+	for _, overlay := range overlays {
+		for _, v := range overlay.Vars {
+			if v.Name == name {
+				return v, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// funcProto returns the C prototype of the given function.
+func funcProto(f *c.FuncDecl) string {
+	if f.Class == c.Static {
+		return fmt.Sprintf("%s %s", f.Class, f.Var)
+	}
+	return f.Var.String()
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// splat file names.
+const (
+	// Symbol addresses.
+	splatSymbolAddrsName = "symbol_addrs.txt"
+	// Linker script of function symbols.
+	splatUndefinedFuncsName = "undefined_funcs.txt"
+	// Linker script of data symbols.
+	splatUndefinedSymsName = "undefined_syms.txt"
+)
+
+// dumpSplat outputs the declarations recorded by the parser to splat
+// configuration files stored in the output directory, for bootstrapping PS1
+// decompilation projects.
+//
+// The symbol_addrs.txt file contains the symbols of the default binary and all
+// overlays; symbols of overlays are assigned to the splat segment overlay_N.
+// The undefined_funcs.txt and undefined_syms.txt linker scripts are output for
+// the default binary, and for each overlay (stored in overlay_N), to link
+// segments against the symbols of other segments.
+func dumpSplat(p *csym.Parser, outputDir string) error {
+	overlays := append([]*csym.Overlay{p.Overlay}, p.Overlays...)
+	// Create symbol addresses file.
+	symbolAddrsPath := filepath.Join(outputDir, splatSymbolAddrsName)
+	fmt.Println("creating:", symbolAddrsPath)
+	f, err := os.Create(symbolAddrsPath)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create splat symbol addresses file %q", symbolAddrsPath)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	for _, overlay := range overlays {
+		segment := ""
+		if overlay.ID != 0 {
+			segment = fmt.Sprintf("overlay_%x", overlay.ID)
+		}
+		for _, sym := range splatSymbols(overlay) {
+			attrs := sym.attrs
+			if len(segment) > 0 {
+				attrs = append(attrs, "segment:"+segment)
+			}
+			fmt.Fprintf(w, "%s = 0x%08X;", sym.name, sym.addr)
+			if len(attrs) > 0 {
+				fmt.Fprintf(w, " // %s", strings.Join(attrs, " "))
+			}
+			w.WriteString("\n")
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+	// Create linker scripts.
+	for _, overlay := range overlays {
+		if err := dumpSplatOverlay(overlay, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// dumpSplatOverlay outputs the function and data symbols of the overlay to the
+// undefined_funcs.txt and undefined_syms.txt linker scripts, respectively.
+func dumpSplatOverlay(overlay *csym.Overlay, outputDir string) error {
+	dir := outputDir
+	if overlay.ID != 0 {
+		overlayDir := fmt.Sprintf("overlay_%x", overlay.ID)
+		dir = filepath.Join(outputDir, overlayDir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	var funcs, syms []splatSymbol
+	for _, sym := range splatSymbols(overlay) {
+		if sym.isFunc {
+			funcs = append(funcs, sym)
+		} else {
+			syms = append(syms, sym)
+		}
+	}
+	if err := writeSplatLinkerScript(filepath.Join(dir, splatUndefinedFuncsName), funcs); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := writeSplatLinkerScript(filepath.Join(dir, splatUndefinedSymsName), syms); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// writeSplatLinkerScript writes a linker script of "NAME = ADDRESS;" symbol
+// assignments to the given path.
+func writeSplatLinkerScript(path string, syms []splatSymbol) error {
+	fmt.Println("creating:", path)
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create linker script %q", path)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	for _, sym := range syms {
+		fmt.Fprintf(w, "%s = 0x%08X;\n", sym.name, sym.addr)
+	}
+	if err := w.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// splatSymbol is a symbol of the splat symbol addresses file.
+type splatSymbol struct {
+	// Symbol name.
+	name string
+	// Address.
+	addr uint32
+	// Function symbol.
+	isFunc bool
+	// Symbol attributes; e.g. "type:func" and "size:0x10".
+	attrs []string
+}
+
+// splatSymbols returns the function and global variable symbols of the
+// overlay, sorted by address. Symbols without address (e.g. external
+// declarations) are omitted.
+func splatSymbols(overlay *csym.Overlay) []splatSymbol {
+	var syms []splatSymbol
+	for _, f := range overlay.Funcs {
+		if f.Addr == 0 {
+			continue
+		}
+		attrs := []string{"type:func"}
+		if f.Size > 0 {
+			attrs = append(attrs, fmt.Sprintf("size:0x%X", f.Size))
+		}
+		syms = append(syms, splatSymbol{name: f.Name, addr: f.Addr, isFunc: true, attrs: attrs})
+	}
+	for _, v := range overlay.Vars {
+		if v.Addr == 0 {
+			continue
+		}
+		var attrs []string
+		if typ, ok := splatType(v.Type); ok {
+			attrs = append(attrs, "type:"+typ)
+		}
+		size := v.Size
+		if size == 0 {
+			size = c.Sizeof(v.Type)
+		}
+		if size > 0 {
+			attrs = append(attrs, fmt.Sprintf("size:0x%X", size))
+		}
+		syms = append(syms, splatSymbol{name: v.Name, addr: v.Addr, attrs: attrs})
+	}
+	sort.SliceStable(syms, func(i, j int) bool {
+		return syms[i].addr < syms[j].addr
+	})
+	return syms
+}
+
+// ### [ Helper functions ] ####################################################
+
+// splatType returns the splat data type of the given variable type, and a
+// boolean indicating whether the variable is of scalar type or array thereof.
+func splatType(t c.Type) (string, bool) {
+	// Element type of arrays.
+	elem := underlyingType(t)
+	for {
+		t, ok := elem.(*c.ArrayType)
+		if !ok {
+			break
+		}
+		elem = underlyingType(t.Elem)
+	}
+	switch elem := elem.(type) {
+	case *c.PointerType:
+		return "u32", true
+	case *c.EnumType:
+		return "s32", true
+	case c.BaseType:
+		switch elem {
+		case c.Char, c.SChar:
+			return "s8", true
+		case c.UChar, c.Bool:
+			return "u8", true
+		case c.Short:
+			return "s16", true
+		case c.UShort:
+			return "u16", true
+		case c.Int, c.Long:
+			return "s32", true
+		case c.UInt, c.ULong:
+			return "u32", true
+		case c.LongLong:
+			return "s64", true
+		case c.ULongLong:
+			return "u64", true
+		case c.Float:
+			return "f32", true
+		case c.Double, c.LongDouble:
+			return "f64", true
+		}
+	}
+	return "", false
+}
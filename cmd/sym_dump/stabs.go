@@ -0,0 +1,445 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// Assembly file name with stabs debug information.
+const stabsName = "stabs.s"
+
+// dumpStabs outputs the declarations and type information recorded by the
+// parser to assembly files with stabs debug information stored in the output
+// directory; one for the default binary, and one for each overlay (stored in
+// overlay_N).
+func dumpStabs(p *csym.Parser, outputDir string) error {
+	// Create assembly file of default binary.
+	if err := dumpStabsOverlay(p, p.Overlay, outputDir); err != nil {
+		return errors.WithStack(err)
+	}
+	// Create assembly files of overlays.
+	for _, overlay := range p.Overlays {
+		if err := dumpStabsOverlay(p, overlay, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// dumpStabsOverlay outputs the type information recorded by the parser and the
+// declarations of the overlay to an assembly file of .stabs and .stabn
+// directives, for use by old toolchains and debuggers reading stabs.
+//
+// The assembly file defines absolute symbols of functions and global
+// variables, and the stabs of a single source file, comprising:
+//
+//   - the base types, structs, unions, enums and type definitions of the SYM
+//     file;
+//   - global variables;
+//   - functions, with their parameters and the local variables of all their
+//     blocks (block scopes have no address ranges in SYM files); and
+//   - the line numbers of functions, relative to the function start as output
+//     by GCC for ELF targets.
+//
+// Source files of functions and line numbers are recorded as N_SOL stabs.
+func dumpStabsOverlay(p *csym.Parser, overlay *csym.Overlay, outputDir string) error {
+	dir := outputDir
+	name := "symbols"
+	if overlay.ID != 0 {
+		name = fmt.Sprintf("overlay_%x", overlay.ID)
+		dir = filepath.Join(outputDir, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if err := createOutputFile(dir, stabsName, func(f io.Writer) error {
+		w := bufio.NewWriter(f)
+		w.WriteString(stabsHeader)
+		w.WriteString(newStabsBuilder(p, overlay, name).String())
+		return w.Flush()
+	}); err != nil {
+		return errors.Wrap(err, "unable to create assembly file")
+	}
+	return nil
+}
+
+// stabsHeader is the header of the assembly file.
+const stabsHeader = `# Stabs debug information of PS1 SYM file, as generated by sym_dump.
+#
+# Usage:
+#
+#    mipsel-linux-gnu-as -o stabs.o stabs.s
+#    gdb-multiarch
+#    (gdb) add-symbol-file stabs.o
+
+`
+
+// Stab types.
+const (
+	// Global variable.
+	stabGSYM = 0x20
+	// Function name or end of function.
+	stabFUN = 0x24
+	// Static variable.
+	stabSTSYM = 0x26
+	// Register variable or parameter.
+	stabRSYM = 0x40
+	// Line number.
+	stabSLINE = 0x44
+	// Source file name or end of source file.
+	stabSO = 0x64
+	// Local variable or type.
+	stabLSYM = 0x80
+	// Name of sub-source file.
+	stabSOL = 0x84
+	// Stack parameter.
+	stabPSYM = 0xA0
+)
+
+// stabsLangC is the source language (stored in the description field of the
+// N_SO stab) of C source files.
+const stabsLangC = 2
+
+// --- [ Builder ] -------------------------------------------------------------
+
+// stabsBuilder builds the stabs debug information of an overlay.
+type stabsBuilder struct {
+	// Stabs directives.
+	buf *strings.Builder
+	// types maps from type to type number.
+	types map[c.Type]int
+	// Last assigned type number.
+	last int
+	// Source file of the preceding stabs.
+	path string
+}
+
+// newStabsBuilder returns the stabs debug information of the type information
+// recorded by the parser and the declarations of the overlay, as a source file
+// of the given name.
+func newStabsBuilder(p *csym.Parser, overlay *csym.Overlay, name string) *stabsBuilder {
+	b := &stabsBuilder{
+		buf:   &strings.Builder{},
+		types: make(map[c.Type]int),
+		path:  name,
+	}
+	var text elfRange
+	for _, f := range overlay.Funcs {
+		if f.Addr != 0 {
+			text.add(f.Addr, f.Size)
+		}
+	}
+	// Symbols.
+	writeStubs(b.buf, overlay)
+	b.buf.WriteString("\n")
+	b.stabs(name, stabSO, stabsLangC, text.start)
+	// Type numbers of base types and named types are assigned in advance, as
+	// types may be referenced before being defined (e.g. a struct containing a
+	// pointer to a struct defined later on).
+	var named []c.Type
+	for t := c.Void; t <= c.Bool; t++ {
+		b.number(t)
+	}
+	for _, tag := range p.EnumTags {
+		named = append(named, p.Enums[tag])
+	}
+	for _, tag := range p.StructTags {
+		named = append(named, p.Structs[tag])
+	}
+	for _, tag := range p.UnionTags {
+		named = append(named, p.Unions[tag])
+	}
+	for _, def := range p.Typedefs {
+		named = append(named, def)
+	}
+	for _, t := range named {
+		if !isFakeTagged(t) {
+			b.number(t)
+		}
+	}
+	// Types.
+	for t := c.Void; t <= c.Bool; t++ {
+		b.stabs(fmt.Sprintf("%s:t%d=%s", t, b.types[t], b.baseDef(t)), stabLSYM, 0, 0)
+	}
+	for _, t := range named {
+		switch t := t.(type) {
+		case *c.VarDecl:
+			b.stabs(fmt.Sprintf("%s:t%d=%s", t.Name, b.types[t], b.typeRef(t.Type)), stabLSYM, 0, 0)
+		case *c.StructType:
+			if !c.IsFakeTag(t.Tag) {
+				b.stabs(fmt.Sprintf("%s:T%d=%s", t.Tag, b.types[t], b.typeDef(t)), stabLSYM, 0, 0)
+			}
+		case *c.UnionType:
+			if !c.IsFakeTag(t.Tag) {
+				b.stabs(fmt.Sprintf("%s:T%d=%s", t.Tag, b.types[t], b.typeDef(t)), stabLSYM, 0, 0)
+			}
+		case *c.EnumType:
+			if !c.IsFakeTag(t.Tag) {
+				b.stabs(fmt.Sprintf("%s:T%d=%s", t.Tag, b.types[t], b.typeDef(t)), stabLSYM, 0, 0)
+			}
+		}
+	}
+	// Declarations.
+	for _, v := range overlay.Vars {
+		b.varStab(v, false)
+	}
+	lines := make([]*csym.Line, len(overlay.Lines))
+	copy(lines, overlay.Lines)
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Addr < lines[j].Addr
+	})
+	funcs := make([]*c.FuncDecl, len(overlay.Funcs))
+	copy(funcs, overlay.Funcs)
+	sort.SliceStable(funcs, func(i, j int) bool {
+		return funcs[i].Addr < funcs[j].Addr
+	})
+	for i, f := range funcs {
+		// Line numbers of the function; up to the next function if the size of
+		// the function is unknown.
+		limit := f.Addr + f.Size
+		if f.Size == 0 {
+			limit = ^uint32(0)
+			if i+1 < len(funcs) {
+				limit = funcs[i+1].Addr
+			}
+		}
+		start := sort.Search(len(lines), func(i int) bool {
+			return lines[i].Addr >= f.Addr
+		})
+		end := start
+		for end < len(lines) && lines[end].Addr < limit {
+			end++
+		}
+		b.funcStabs(f, lines[start:end])
+	}
+	// End of source file.
+	b.stabs("", stabSO, 0, text.end)
+	return b
+}
+
+// String returns the stabs directives of the builder.
+func (b *stabsBuilder) String() string {
+	return b.buf.String()
+}
+
+// stabs outputs a .stabs directive of the given string, type, description and
+// value.
+func (b *stabsBuilder) stabs(str string, typ, desc int, value uint32) {
+	fmt.Fprintf(b.buf, "\t.stabs\t%q,%d,0,%d,0x%X\n", str, typ, desc, value)
+}
+
+// stabn outputs a .stabn directive of the given type, description and value.
+func (b *stabsBuilder) stabn(typ, desc int, value uint32) {
+	fmt.Fprintf(b.buf, "\t.stabn\t%d,0,%d,0x%X\n", typ, desc, value)
+}
+
+// sourceFile outputs an N_SOL stab of the given source file at the given
+// address, if different from the source file of the preceding stabs.
+func (b *stabsBuilder) sourceFile(path string, addr uint32) {
+	if len(path) == 0 || path == b.path {
+		return
+	}
+	b.stabs(path, stabSOL, 0, addr)
+	b.path = path
+}
+
+// number assigns a type number to the given type.
+func (b *stabsBuilder) number(t c.Type) int {
+	b.last++
+	b.types[t] = b.last
+	return b.last
+}
+
+// typeRef returns the stabs type reference of the given type; defining the
+// type inline if not yet assigned a type number.
+func (b *stabsBuilder) typeRef(t c.Type) string {
+	if n, ok := b.types[t]; ok {
+		return fmt.Sprint(n)
+	}
+	n := b.number(t)
+	return fmt.Sprintf("%d=%s", n, b.typeDef(t))
+}
+
+// baseDef returns the stabs type definition of the given base type, as output
+// by GCC.
+func (b *stabsBuilder) baseDef(t c.BaseType) string {
+	n := b.types[t]
+	switch t {
+	case c.Void:
+		return fmt.Sprint(n)
+	case c.Char, c.SChar:
+		return fmt.Sprintf("r%d;-128;127;", n)
+	case c.UChar:
+		return fmt.Sprintf("r%d;0;255;", n)
+	case c.Bool:
+		return fmt.Sprintf("r%d;0;1;", n)
+	case c.Short:
+		return fmt.Sprintf("r%d;-32768;32767;", n)
+	case c.UShort:
+		return fmt.Sprintf("r%d;0;65535;", n)
+	case c.Int, c.Long:
+		return fmt.Sprintf("r%d;-2147483648;2147483647;", n)
+	case c.UInt, c.ULong:
+		return fmt.Sprintf("r%d;0;4294967295;", n)
+	case c.LongLong:
+		// Bounds of 64-bit types are in octal.
+		return fmt.Sprintf("r%d;01000000000000000000000;0777777777777777777777;", n)
+	case c.ULongLong:
+		return fmt.Sprintf("r%d;0;01777777777777777777777;", n)
+	case c.Float, c.Double, c.LongDouble:
+		// Floating-point types are ranges of int, with the size in bytes as
+		// lower bound.
+		return fmt.Sprintf("r%d;%d;0;", b.types[c.Int], c.Sizeof(t))
+	}
+	panic(fmt.Errorf("support for base type %v not yet implemented", t))
+}
+
+// typeDef returns the stabs type definition of the given type.
+func (b *stabsBuilder) typeDef(t c.Type) string {
+	switch t := t.(type) {
+	case *c.StructType:
+		return b.composite("s", t.Size, t.Fields, false)
+	case *c.UnionType:
+		return b.composite("u", t.Size, t.Fields, true)
+	case *c.EnumType:
+		buf := &strings.Builder{}
+		buf.WriteString("e")
+		for _, member := range t.Members {
+			if t.Signed {
+				fmt.Fprintf(buf, "%s:%d,", member.Name, int32(member.Value))
+			} else {
+				fmt.Fprintf(buf, "%s:%d,", member.Name, member.Value)
+			}
+		}
+		buf.WriteString(";")
+		return buf.String()
+	case *c.PointerType:
+		return "*" + b.typeRef(t.Elem)
+	case *c.ArrayType:
+		return fmt.Sprintf("ar%d;0;%d;%s", b.types[c.Int], t.Len-1, b.typeRef(t.Elem))
+	case *c.QualType:
+		// GNU extensions; B for volatile and k for const.
+		def := b.typeRef(t.Type)
+		if t.Quals&c.Volatile != 0 {
+			def = "B" + def
+		}
+		if t.Quals&c.Const != 0 {
+			def = "k" + def
+		}
+		return def
+	case *c.FuncType:
+		return "f" + b.typeRef(t.RetType)
+	case *c.VarDecl:
+		// Type definition referenced before being numbered; not present in
+		// practice, as type definitions are numbered in advance.
+		return b.typeRef(t.Type)
+	}
+	panic(fmt.Errorf("support for type %T not yet implemented", t))
+}
+
+// composite returns the stabs type definition of the struct or union type with
+// the given type descriptor, size and fields.
+func (b *stabsBuilder) composite(desc string, size uint32, fields []c.Field, union bool) string {
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "%s%d", desc, size)
+	for _, field := range fields {
+		offset := field.Offset
+		if union {
+			offset = 0
+		}
+		bitPos := offset*8 + field.BitOffset
+		bitSize := field.BitWidth
+		if bitSize == 0 {
+			bitSize = c.Sizeof(field.Type) * 8
+		}
+		fmt.Fprintf(buf, "%s:%s,%d,%d;", field.Name, b.typeRef(field.Type), bitPos, bitSize)
+	}
+	buf.WriteString(";")
+	return buf.String()
+}
+
+// varStab outputs the stab of the given global or local variable declaration.
+func (b *stabsBuilder) varStab(v *c.VarDecl, local bool) {
+	switch v.Class {
+	case c.Extern:
+		// Address of global variables is located through the symbol table.
+		b.stabs(fmt.Sprintf("%s:G%s", v.Name, b.typeRef(v.Type)), stabGSYM, 0, 0)
+	case c.Static:
+		if v.Addr == 0 {
+			return
+		}
+		desc := "S"
+		if local {
+			desc = "V"
+		}
+		b.stabs(fmt.Sprintf("%s:%s%s", v.Name, desc, b.typeRef(v.Type)), stabSTSYM, 0, v.Addr)
+	case c.Register:
+		b.stabs(fmt.Sprintf("%s:r%s", v.Name, b.typeRef(v.Type)), stabRSYM, 0, v.Addr)
+	case c.Typedef:
+		// Type definitions local to blocks are omitted.
+	default:
+		// Auto variables; stack pointer relative.
+		b.stabs(fmt.Sprintf("%s:%s", v.Name, b.typeRef(v.Type)), stabLSYM, 0, v.Addr)
+	}
+}
+
+// funcStabs outputs the stabs of the given function declaration and its line
+// numbers.
+func (b *stabsBuilder) funcStabs(f *c.FuncDecl, lines []*csym.Line) {
+	b.sourceFile(f.Path, f.Addr)
+	desc := "F"
+	if f.Class == c.Static {
+		desc = "f"
+	}
+	t, ok := f.Type.(*c.FuncType)
+	ret := c.Type(c.Int)
+	if ok {
+		ret = t.RetType
+	}
+	b.stabs(fmt.Sprintf("%s:%s%s", f.Name, desc, b.typeRef(ret)), stabFUN, 0, f.Addr)
+	if ok {
+		for _, param := range t.Params {
+			if param.Class == c.Register {
+				b.stabs(fmt.Sprintf("%s:P%s", param.Name, b.typeRef(param.Type)), stabRSYM, 0, param.Addr)
+				continue
+			}
+			b.stabs(fmt.Sprintf("%s:p%s", param.Name, b.typeRef(param.Type)), stabPSYM, 0, param.Addr)
+		}
+	}
+	for _, block := range f.Blocks {
+		for _, local := range block.Locals {
+			b.varStab(local, true)
+		}
+	}
+	for _, line := range lines {
+		b.sourceFile(line.Path, line.Addr)
+		b.stabn(stabSLINE, int(line.Line), line.Addr-f.Addr)
+	}
+	// End of function; value is the function size.
+	b.stabs("", stabFUN, 0, f.Size)
+}
+
+// ### [ Helper functions ] ####################################################
+
+// isFakeTagged reports whether the given type is a struct, union or enum with a
+// fake tag (i.e. anonymous).
+func isFakeTagged(t c.Type) bool {
+	switch t := t.(type) {
+	case *c.StructType:
+		return c.IsFakeTag(t.Tag)
+	case *c.UnionType:
+		return c.IsFakeTag(t.Tag)
+	case *c.EnumType:
+		return c.IsFakeTag(t.Tag)
+	}
+	return false
+}
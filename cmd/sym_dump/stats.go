@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym"
+)
+
+// statsUsage prints usage information of the stats command.
+func statsUsage(fs *flag.FlagSet) {
+	const use = `
+Print statistics of symbol files; counts and byte totals grouped by symbol kind, definition class, overlay and source file, and the biggest functions and structs.
+
+Usage:
+
+	sym_dump stats [OPTION]... FILE...
+`
+	fmt.Println(use[1:])
+	fs.PrintDefaults()
+}
+
+// stats prints statistics of the symbol files specified by the given command
+// line arguments of the stats command.
+func stats(args []string) error {
+	// Command line flags.
+	var (
+		// Number of biggest functions and structs to list.
+		top int
+		// Output in JSON format.
+		outputJSON bool
+		// Input format.
+		from string
+		// Text encoding of symbol names.
+		encodingName string
+		// Byte order of SYM files.
+		endian string
+	)
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	fs.IntVar(&top, "top", 10, "number of biggest functions and structs to list")
+	fs.BoolVar(&outputJSON, "json", false, "output in JSON format")
+	fs.StringVar(&from, "from", "", "input format; detected if not specified")
+	fs.StringVar(&encodingName, "encoding", "", "text encoding of symbol names (sjis, or auto to detect)")
+	fs.StringVar(&endian, "endian", "", "byte order of SYM files (big or little); detected if not specified")
+	fs.Usage = func() { statsUsage(fs) }
+	if err := fs.Parse(args); err != nil {
+		return errors.WithStack(err)
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if top < 0 {
+		return errors.Errorf("invalid number of biggest functions and structs %d; expected >= 0", top)
+	}
+	if len(from) > 0 && !isInputFormat(from) {
+		return errors.Errorf("invalid input format %q", from)
+	}
+	enc, err := parseEncoding(encodingName)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	order, err := parseByteOrder(endian)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for i, path := range fs.Args() {
+		opts := &sym.ParseOptions{
+			Encoding:       enc,
+			Order:          order,
+			DetectEncoding: strings.EqualFold(encodingName, "auto"),
+		}
+		f, err := parseFile(path, from, opts)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		s := sym.NewStats(f.Syms, top)
+		if outputJSON {
+			buf, err := json.MarshalIndent(s, "", "\t")
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			fmt.Println(string(buf))
+			continue
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		if fs.NArg() > 1 {
+			fmt.Printf("=== [ %s ] ===\n\n", path)
+		}
+		if err := printStats(os.Stdout, s); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// printStats prints the given statistics to w in text format.
+func printStats(w io.Writer, s *sym.Stats) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	// printGroups prints the given groups under the specified heading.
+	printGroups := func(heading string, groups []*sym.StatGroup) {
+		if len(groups) == 0 {
+			return
+		}
+		fmt.Fprintf(tw, "%s\tCOUNT\tBYTES\tSIZE\n", heading)
+		for _, g := range groups {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t0x%X\n", g.Name, g.Count, g.Bytes, g.Size)
+		}
+		fmt.Fprintln(tw)
+	}
+	printGroups("KIND", append(s.Kinds, s.Total))
+	printGroups("CLASS", s.Classes)
+	printGroups("OVERLAY", s.Overlays)
+	printGroups("FILE", s.Files)
+	if len(s.TopFuncs) > 0 {
+		fmt.Fprintln(tw, "FUNCTION\tADDRESS\tOVERLAY\tSIZE")
+		for _, item := range s.TopFuncs {
+			fmt.Fprintf(tw, "%s\t0x%08X\t%X\t0x%X\n", item.Name, item.Addr, item.Overlay, item.Size)
+		}
+		fmt.Fprintln(tw)
+	}
+	if len(s.TopStructs) > 0 {
+		fmt.Fprintln(tw, "STRUCT\tSIZE")
+		for _, item := range s.TopStructs {
+			fmt.Fprintf(tw, "%s\t0x%X\n", item.Name, item.Size)
+		}
+	}
+	return tw.Flush()
+}
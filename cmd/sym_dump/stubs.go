@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// GNU assembler symbol stub file name.
+const stubsName = "symbols.s"
+
+// dumpStubs outputs the declarations recorded by the parser to GNU assembler
+// symbol stub files stored in the output directory; one for the default binary,
+// and one for each overlay (stored in overlay_N).
+func dumpStubs(p *csym.Parser, outputDir string) error {
+	// Create stub file of default binary.
+	if err := dumpStubsOverlay(p.Overlay, outputDir); err != nil {
+		return errors.WithStack(err)
+	}
+	// Create stub files of overlays.
+	for _, overlay := range p.Overlays {
+		if err := dumpStubsOverlay(overlay, outputDir); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// dumpStubsOverlay outputs the declarations of the overlay to a GNU assembler
+// symbol stub file, for linking hand-written assembly or partially decompiled
+// code against the functions and global variables of the original binary.
+func dumpStubsOverlay(overlay *csym.Overlay, outputDir string) error {
+	dir := outputDir
+	if overlay.ID != 0 {
+		overlayDir := fmt.Sprintf("overlay_%x", overlay.ID)
+		dir = filepath.Join(outputDir, overlayDir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if err := createOutputFile(dir, stubsName, func(f io.Writer) error {
+		w := bufio.NewWriter(f)
+		w.WriteString("# Symbols of PS1 SYM file, as generated by sym_dump.\n\n")
+		writeStubs(w, overlay)
+		return w.Flush()
+	}); err != nil {
+		return errors.Wrap(err, "unable to create symbol stub file")
+	}
+	return nil
+}
+
+// writeStubs writes the absolute symbol definitions of the functions and global
+// variables of the overlay to w, with symbol type and size. Symbols of static
+// storage class have local binding, and symbols without address (e.g. external
+// declarations) are omitted.
+//
+// Symbols are assigned using .equ, which is equivalent to .set, as the MIPS
+// assembler interprets .set as an assembler option directive (e.g. `.set
+// noreorder`).
+func writeStubs(w io.Writer, overlay *csym.Overlay) {
+	for _, f := range overlay.Funcs {
+		if f.Addr == 0 {
+			continue
+		}
+		writeStub(w, f.Name, f.Addr, f.Size, f.Class, "function")
+	}
+	for _, v := range overlay.Vars {
+		if v.Addr == 0 {
+			continue
+		}
+		size := v.Size
+		if size == 0 {
+			size = c.Sizeof(v.Type)
+		}
+		writeStub(w, v.Name, v.Addr, size, v.Class, "object")
+	}
+}
+
+// writeStub writes the definition of an absolute symbol of the given name,
+// address, size (optional), storage class and symbol type to w.
+func writeStub(w io.Writer, name string, addr, size uint32, class c.StorageClass, typ string) {
+	if class != c.Static {
+		fmt.Fprintf(w, "\t.globl\t%s\n", name)
+	}
+	fmt.Fprintf(w, "\t.equ\t%s, 0x%08X\n", name, addr)
+	fmt.Fprintf(w, "\t.type\t%s, @%s\n", name, typ)
+	if size > 0 {
+		fmt.Fprintf(w, "\t.size\t%s, %d\n", name, size)
+	}
+}
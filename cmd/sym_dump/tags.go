@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// Tags file names.
+const (
+	// ctags file name (Vim).
+	ctagsName = "tags"
+	// etags file name (Emacs).
+	etagsName = "TAGS"
+)
+
+// A tagsFile records the locations of declarations in generated files, for
+// output to ctags and etags files. A nil tagsFile records nothing.
+type tagsFile struct {
+	// Tags in order of occurrence.
+	tags []*tagEntry
+}
+
+// A tagEntry is the location of a declaration in a generated file.
+type tagEntry struct {
+	// Identifier name.
+	name string
+	// Path of generated file, relative to the output directory.
+	path string
+	// Line number (1-based).
+	line int
+	// Offset in bytes of the line.
+	offset int
+	// Contents of the line.
+	text string
+	// ctags kind; e.g. 's' for struct.
+	kind byte
+	// Address (optional).
+	addr uint32
+}
+
+// ctags kinds of C declarations.
+const (
+	tagKindStruct  = 's'
+	tagKindUnion   = 'u'
+	tagKindEnum    = 'g'
+	tagKindTypedef = 't'
+	tagKindVar     = 'v'
+	tagKindFunc    = 'f'
+)
+
+// addDef records the tag of the given definition, as output at the given line
+// and offset of the generated file.
+func (t *tagsFile) addDef(path string, line, offset int, def string, name string, kind byte, addr uint32) {
+	if t == nil || len(name) == 0 {
+		return
+	}
+	// Locate declaration line, skipping leading comments.
+	lines := strings.Split(def, "\n")
+	i := declLine(lines)
+	for _, l := range lines[:i] {
+		offset += len(l) + len("\n")
+	}
+	tag := &tagEntry{
+		name:   name,
+		path:   filepath.ToSlash(path),
+		line:   line + i,
+		offset: offset,
+		text:   lines[i],
+		kind:   kind,
+		addr:   addr,
+	}
+	t.tags = append(t.tags, tag)
+}
+
+// addType records the tag of the given type definition, as output at the given
+// line and offset of the generated file.
+func (t *tagsFile) addType(path string, line, offset int, def c.Type, style c.Style) {
+	switch def := def.(type) {
+	case *c.StructType:
+		if !c.IsFakeTag(def.Tag) {
+			t.addDef(path, line, offset, def.DefStyle(style), def.Tag, tagKindStruct, 0)
+		}
+	case *c.UnionType:
+		if !c.IsFakeTag(def.Tag) {
+			t.addDef(path, line, offset, def.DefStyle(style), def.Tag, tagKindUnion, 0)
+		}
+	case *c.EnumType:
+		if !c.IsFakeTag(def.Tag) {
+			t.addDef(path, line, offset, def.DefStyle(style), def.Tag, tagKindEnum, 0)
+		}
+	case *c.VarDecl:
+		t.addDef(path, line, offset, def.DefStyle(style), def.Name, tagKindTypedef, 0)
+	}
+}
+
+// write outputs the recorded tags to a ctags file (tags) and an etags file
+// (TAGS) stored in the output directory, unless no tags were recorded.
+func (t *tagsFile) write(outputDir string) error {
+	if t == nil || len(t.tags) == 0 {
+		return nil
+	}
+	ctagsPath := filepath.Join(outputDir, ctagsName)
+	fmt.Println("creating:", ctagsPath)
+	if err := ioutil.WriteFile(ctagsPath, t.ctags(), 0644); err != nil {
+		return errors.Wrapf(err, "unable to create ctags file %q", ctagsPath)
+	}
+	etagsPath := filepath.Join(outputDir, etagsName)
+	fmt.Println("creating:", etagsPath)
+	if err := ioutil.WriteFile(etagsPath, t.etags(), 0644); err != nil {
+		return errors.Wrapf(err, "unable to create etags file %q", etagsPath)
+	}
+	return nil
+}
+
+// ctags returns the contents of a ctags file (extended format) of the recorded
+// tags, sorted by name. Addresses are stored in the "address" extension field.
+func (t *tagsFile) ctags() []byte {
+	tags := make([]*tagEntry, len(t.tags))
+	copy(tags, t.tags)
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].name < tags[j].name
+	})
+	buf := &bytes.Buffer{}
+	buf.WriteString("!_TAG_FILE_FORMAT\t2\t/extended format/\n")
+	buf.WriteString("!_TAG_FILE_SORTED\t1\t/0=unsorted, 1=sorted, 2=foldcase/\n")
+	buf.WriteString("!_TAG_PROGRAM_NAME\tsym_dump\t//\n")
+	for _, tag := range tags {
+		fmt.Fprintf(buf, "%s\t%s\t%d;\"\t%c", tag.name, tag.path, tag.line, tag.kind)
+		if tag.addr != 0 {
+			fmt.Fprintf(buf, "\taddress:0x%08X", tag.addr)
+		}
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
+
+// etags returns the contents of an etags file of the recorded tags, grouped by
+// generated file.
+func (t *tagsFile) etags() []byte {
+	// Group tags by file, in order of occurrence.
+	var paths []string
+	files := make(map[string][]*tagEntry)
+	for _, tag := range t.tags {
+		if _, ok := files[tag.path]; !ok {
+			paths = append(paths, tag.path)
+		}
+		files[tag.path] = append(files[tag.path], tag)
+	}
+	buf := &bytes.Buffer{}
+	for _, path := range paths {
+		section := &bytes.Buffer{}
+		for _, tag := range files[path] {
+			fmt.Fprintf(section, "%s\x7f%s\x01%d,%d\n", tag.text, tag.name, tag.line, tag.offset)
+		}
+		fmt.Fprintf(buf, "\x0c\n%s,%d\n", path, section.Len())
+		buf.Write(section.Bytes())
+	}
+	return buf.Bytes()
+}
+
+// ### [ Helper functions ] ####################################################
+
+// declLine returns the index of the first line of the given definition which
+// is not a comment; i.e. the line of the declaration.
+func declLine(lines []string) int {
+	for i, line := range lines {
+		l := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(l, "//"), strings.HasPrefix(l, "/*"), strings.HasPrefix(l, "*"):
+			continue
+		}
+		return i
+	}
+	return 0
+}
+
+// A lineWriter tracks the line number and offset of the output written to the
+// underlying writer.
+type lineWriter struct {
+	// Underlying writer.
+	w io.Writer
+	// Line number of the current line (1-based).
+	line int
+	// Offset in bytes of the output written so far.
+	offset int
+}
+
+// newLineWriter returns a new line writer writing to w.
+func newLineWriter(w io.Writer) *lineWriter {
+	return &lineWriter{w: w, line: 1}
+}
+
+// Write writes p to the underlying writer.
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	n, err := lw.w.Write(p)
+	lw.line += bytes.Count(p[:n], []byte("\n"))
+	lw.offset += n
+	return n, err
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// Template file extensions, stripped from the template name to locate the
+// output file name (e.g. "symbols.txt.tmpl" -> "symbols.txt").
+var templateExts = []string{".tmpl", ".tpl", ".gotmpl"}
+
+// parseTemplate parses the Go text/template of the given path, for rendering
+// custom output formats. The template functions are listed in templateFuncs,
+// rendering C definitions in the given formatting style.
+func parseTemplate(path string, style c.Style) (*template.Template, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs(style)).Parse(string(buf))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse template %q", path)
+	}
+	return tmpl, nil
+}
+
+// templateFuncs returns the functions available to custom output templates,
+// rendering C definitions in the given formatting style.
+func templateFuncs(style c.Style) template.FuncMap {
+	return template.FuncMap{
+		// hex returns the hexadecimal representation of the given address (e.g.
+		// "0x80010000").
+		"hex": func(v uint32) string {
+			return fmt.Sprintf("0x%08X", v)
+		},
+		// kind returns the kind of the given type definition; "struct", "union",
+		// "enum" or "typedef".
+		"kind": typeKind,
+		// def returns the C syntax representation of the definition of the given
+		// type.
+		"def": func(t c.Type) string {
+			return t.DefStyle(style)
+		},
+		"lower":     strings.ToLower,
+		"upper":     strings.ToUpper,
+		"join":      strings.Join,
+		"replace":   strings.Replace,
+		"hasPrefix": strings.HasPrefix,
+		"hasSuffix": strings.HasSuffix,
+	}
+}
+
+// templateData is the data of custom output templates.
+type templateData struct {
+	// Type definitions in dependency order; predeclared identifiers, enums,
+	// structs, unions and typedefs.
+	Types []c.Type
+	// Structs, in order of occurrence.
+	Structs []*c.StructType
+	// Unions, in order of occurrence.
+	Unions []*c.UnionType
+	// Enums, in order of occurrence.
+	Enums []*c.EnumType
+	// Type definitions, in order of occurrence.
+	Typedefs []c.Type
+	// Overlays, starting with the default binary; with the variable and
+	// function declarations of each overlay.
+	Overlays []*csym.Overlay
+	// Parser recording the type information and declarations, for access to
+	// the complete model.
+	Parser *csym.Parser
+}
+
+// newTemplateData returns the data of custom output templates of the type
+// information and declarations recorded by the parser.
+func newTemplateData(p *csym.Parser) *templateData {
+	data := &templateData{
+		Types:    typeDefs(p),
+		Typedefs: p.Typedefs,
+		Overlays: append([]*csym.Overlay{p.Overlay}, p.Overlays...),
+		Parser:   p,
+	}
+	for _, tag := range p.StructTags {
+		data.Structs = append(data.Structs, p.Structs[tag])
+	}
+	for _, tag := range p.UnionTags {
+		data.Unions = append(data.Unions, p.Unions[tag])
+	}
+	for _, tag := range p.EnumTags {
+		data.Enums = append(data.Enums, p.Enums[tag])
+	}
+	return data
+}
+
+// dumpTemplate renders the given custom output template with the type
+// information and declarations recorded by the parser, and outputs the result
+// to the output directory. The output file is named after the template, with
+// the template extension removed (e.g. "symbols.txt.tmpl" -> "symbols.txt").
+func dumpTemplate(p *csym.Parser, outputDir string, tmpl *template.Template) error {
+	name := tmpl.Name()
+	for _, ext := range templateExts {
+		if strings.HasSuffix(name, ext) && len(name) > len(ext) {
+			name = strings.TrimSuffix(name, ext)
+			break
+		}
+	}
+	outputPath := filepath.Join(outputDir, name)
+	fmt.Println("creating:", outputPath)
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := tmpl.Execute(f, newTemplateData(p)); err != nil {
+		f.Close()
+		return errors.Wrapf(err, "unable to render template %q", tmpl.Name())
+	}
+	if err := f.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// typeKind returns the kind of the given type definition; "struct", "union",
+// "enum" or "typedef".
+func typeKind(t c.Type) string {
+	switch t.(type) {
+	case *c.StructType:
+		return "struct"
+	case *c.UnionType:
+		return "union"
+	case *c.EnumType:
+		return "enum"
+	case *c.VarDecl:
+		return "typedef"
+	}
+	return ""
+}
@@ -0,0 +1,13 @@
+# asm-differ settings, as generated by sym_dump.
+#
+# Update baseimg and myimg to the paths of the original and rebuilt
+# executables, respectively.
+
+def apply(config, args):
+    config["arch"] = "mipsel"
+    config["baseimg"] = "orig/MAIN.EXE"
+    config["myimg"] = "build/MAIN.EXE"
+    config["mapfile"] = "asm_differ.map"
+    config["map_format"] = "gnu"
+    config["source_directories"] = ["src"]
+    config["objdump_executable"] = "mipsel-linux-gnu-objdump"
@@ -0,0 +1,23 @@
+#ifndef ASSERTS_H
+#define ASSERTS_H
+
+#include <stddef.h>
+
+#include "types.h"
+
+_Static_assert(sizeof(struct point) == 0x10, "size of struct point");
+_Static_assert(offsetof(struct point, x) == 0x0, "offset of struct point.x");
+_Static_assert(offsetof(struct point, pad) == 0x4, "offset of struct point.pad");
+_Static_assert(offsetof(struct point, next) == 0x8, "offset of struct point.next");
+_Static_assert(offsetof(struct point, rg) == 0xD, "offset of struct point.rg");
+
+_Static_assert(sizeof(struct bar) == 0x4, "size of struct bar");
+_Static_assert(offsetof(struct bar, pv) == 0x0, "offset of struct bar.pv");
+
+_Static_assert(sizeof(struct ovl_state) == 0x8, "size of struct ovl_state");
+_Static_assert(offsetof(struct ovl_state, n) == 0x0, "offset of struct ovl_state.n");
+_Static_assert(offsetof(struct ovl_state, pt) == 0x4, "offset of struct ovl_state.pt");
+
+_Static_assert(sizeof(union value) == 0x4, "size of union value");
+
+#endif // ASSERTS_H
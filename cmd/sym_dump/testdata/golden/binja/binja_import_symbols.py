@@ -0,0 +1,135 @@
+# Import symbols of PS1 SYM file, as generated by sym_dump.
+#
+# Run from Binary Ninja (File > Run Script) with the binary view of the PS1
+# executable (or overlay) open.
+
+from binaryninja import Symbol, SymbolType
+
+# ID of the overlay of the binary view; or 0 for the default binary.
+OVERLAY = 0
+
+def parse_types():
+	result = bv.parse_types_from_string(TYPES)
+	for name, t in result.types.items():
+		bv.define_user_type(name, t)
+
+def func(addr, name, decl):
+	bv.define_user_symbol(Symbol(SymbolType.FunctionSymbol, addr, name))
+	f = bv.get_function_at(addr)
+	if f is None:
+		bv.add_function(addr)
+		f = bv.get_function_at(addr)
+	if f is not None:
+		t, _ = bv.parse_type_string(decl)
+		f.type = t
+
+def var(addr, name, decl):
+	t, _ = bv.parse_type_string(decl)
+	bv.define_user_data_var(addr, t)
+	bv.define_user_symbol(Symbol(SymbolType.DataSymbol, addr, name))
+
+TYPES = r"""
+struct point;
+union value;
+
+typedef int bool;
+
+enum color {
+	NONE  = -1,
+	RED   = 0,
+	GREEN = 1,
+};
+
+struct __vtbl_ptr_type {
+};
+
+// size: 0x2
+struct _0fake {
+	// offset: 0000 (1 bytes)
+	unsigned char r;
+	// offset: 0001 (1 bytes)
+	unsigned char g;
+};
+
+// size: 0x10
+struct point {
+	// offset: 0000 (4 bytes)
+	int x;
+	// offset: 0004 (4 bytes)
+	short pad[2];
+	// offset: 0008 (4 bytes)
+	struct point *next;
+	// offset: 000C.0 (3 bits)
+	unsigned int flags : 3;
+	// offset: 000C.3 (5 bits)
+	unsigned int kind : 5;
+	// offset: 000D (2 bytes)
+	struct {
+		// offset: 0000 (1 bytes)
+		unsigned char r;
+		// offset: 0001 (1 bytes)
+		unsigned char g;
+	} rg;
+};
+
+// size: 0x8
+struct _1fake {
+	// offset: 0000 (4 bytes)
+	enum color c;
+	// offset: 0004 (4 bytes)
+	void (*cb)();
+};
+
+// size: 0x4
+struct bar {
+	// offset: 0000 (4 bytes)
+	union value *pv;
+};
+
+// size: 0x8
+struct ovl_state {
+	// offset: 0000 (4 bytes)
+	int n;
+	// offset: 0004 (4 bytes)
+	struct point *pt;
+};
+
+// size: 0x4
+union value {
+	// offset: 0000 (4 bytes)
+	int i;
+	// offset: 0000 (4 bytes)
+	float f;
+};
+
+typedef int s32;
+
+typedef struct point point_t;
+
+typedef struct {
+	// offset: 0000 (4 bytes)
+	enum color c;
+	// offset: 0004 (4 bytes)
+	void (*cb)();
+} entry_t;
+
+
+"""
+
+def overlay_0():
+	func(0x80010100, "add", "int add(struct point *p, int n)")
+	func(0x00000000, "f", "void f()")
+	var(0x80010000, "origin", "struct point")
+	var(0x80010020, "entries", "struct { enum color c; void (*cb)(); } [4]")
+	var(0x00000000, "msg", "char *")
+	var(0x80010204, "gval", "union value")
+	var(0x80010208, "gbar", "struct bar")
+
+def overlay_4():
+	var(0x800B0020, "gState", "struct ovl_state")
+	var(0x800B0024, "gVal", "union value")
+
+parse_types()
+overlay_0()
+if OVERLAY != 0:
+	globals()["overlay_%x" % OVERLAY]()
@@ -0,0 +1,16 @@
+#ifndef FUNCTIONS_H
+#define FUNCTIONS_H
+
+#include "types.h"
+
+// address: 0x80010100
+// size: 0x40
+// line start: 26
+// line end:   35
+extern int add(struct point *p, int n);
+
+// line start: 0
+// line end:   0
+extern void f();
+
+#endif // FUNCTIONS_H
@@ -0,0 +1,40 @@
+#ifndef VARIABLES_H
+#define VARIABLES_H
+
+#include "types.h"
+
+// address: 0x80010000
+// size: 0x14
+extern struct point origin;
+
+// address: 0x80010020
+// size: 0x20
+static struct {
+	// offset: 0000 (4 bytes)
+	enum color c;
+	// offset: 0004 (4 bytes)
+	void (*cb)();
+} entries[4];
+
+// size: 0x4
+extern char *msg;
+
+// address: 0x80010204
+// size: 0x4
+extern union value gval;
+
+// address: 0x80010208
+// size: 0x4
+extern struct bar gbar;
+
+// === [ Overlay ID 4 ] ===
+
+// address: 0x800B0020
+// size: 0x8
+extern struct ovl_state gState;
+
+// address: 0x800B0024
+// size: 0x4
+extern union value gVal;
+
+#endif // VARIABLES_H
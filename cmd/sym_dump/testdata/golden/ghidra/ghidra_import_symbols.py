@@ -0,0 +1,40 @@
+# Import symbols of PS1 SYM file, as generated by sym_dump.
+#
+# Overlays are created as uninitialized overlay blocks, unless a block of the
+# same name is already present (e.g. with the contents of the overlay).
+#
+#@category Symbol
+
+from ghidra.program.model.symbol import SourceType
+
+def overlay(name, addr, length):
+	mem = currentProgram.getMemory()
+	block = mem.getBlock(name)
+	if block is None:
+		block = mem.createUninitializedBlock(name, toAddr(addr), length, True)
+	return block.getStart().getAddressSpace()
+
+def label(space, addr, name, func):
+	if space is None:
+		a = toAddr(addr)
+	else:
+		a = space.getAddress(addr)
+	if func:
+		f = getFunctionAt(a)
+		if f is None:
+			createFunction(a, name)
+		else:
+			f.setName(name, SourceType.IMPORTED)
+	else:
+		createLabel(a, name, True, SourceType.IMPORTED)
+label(None, 0x80010100, "add", True)
+label(None, 0x00000000, "f", True)
+label(None, 0x80010000, "origin", False)
+label(None, 0x80010020, "entries", False)
+label(None, 0x00000000, "msg", False)
+label(None, 0x80010204, "gval", False)
+label(None, 0x80010208, "gbar", False)
+
+space_4 = overlay("overlay_4", 0x800B0000, 0x100)
+label(space_4, 0x800B0020, "gState", False)
+label(space_4, 0x800B0024, "gVal", False)
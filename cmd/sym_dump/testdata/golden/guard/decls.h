@@ -0,0 +1,43 @@
+#pragma once
+
+#include "types.h"
+
+// address: 0x80010000
+// size: 0x14
+extern struct point origin;
+
+// address: 0x80010020
+// size: 0x20
+static struct {
+	// offset: 0000 (4 bytes)
+	enum color c;
+	// offset: 0004 (4 bytes)
+	void (*cb)();
+} entries[4];
+
+// size: 0x4
+extern char *msg;
+
+// address: 0x80010204
+// size: 0x4
+extern union value gval;
+
+// address: 0x80010208
+// size: 0x4
+extern struct bar gbar;
+
+// address: 0x80010100
+// size: 0x40
+// line start: 26
+// line end:   35
+int add(struct point *p, int n) {
+	// address: 0x00000010
+	// size: 0x4
+	int sum;
+}
+
+
+// line start: 0
+// line end:   0
+extern void f();
+
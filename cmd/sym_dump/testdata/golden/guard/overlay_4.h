@@ -0,0 +1,14 @@
+#pragma once
+
+#include "types.h"
+
+// === [ Overlay ID 4 ] ===
+
+// address: 0x800B0020
+// size: 0x8
+extern struct ovl_state gState;
+
+// address: 0x800B0024
+// size: 0x4
+extern union value gVal;
+
@@ -0,0 +1,86 @@
+#pragma once
+
+struct point;
+union value;
+
+typedef int bool;
+
+enum color {
+	NONE  = -1,
+	RED   = 0,
+	GREEN = 1,
+};
+
+struct __vtbl_ptr_type {
+};
+
+// size: 0x2
+struct _0fake {
+	// offset: 0000 (1 bytes)
+	unsigned char r;
+	// offset: 0001 (1 bytes)
+	unsigned char g;
+};
+
+// size: 0x10
+struct point {
+	// offset: 0000 (4 bytes)
+	int x;
+	// offset: 0004 (4 bytes)
+	short pad[2];
+	// offset: 0008 (4 bytes)
+	struct point *next;
+	// offset: 000C.0 (3 bits)
+	unsigned int flags : 3;
+	// offset: 000C.3 (5 bits)
+	unsigned int kind : 5;
+	// offset: 000D (2 bytes)
+	struct {
+		// offset: 0000 (1 bytes)
+		unsigned char r;
+		// offset: 0001 (1 bytes)
+		unsigned char g;
+	} rg;
+};
+
+// size: 0x8
+struct _1fake {
+	// offset: 0000 (4 bytes)
+	enum color c;
+	// offset: 0004 (4 bytes)
+	void (*cb)();
+};
+
+// size: 0x4
+struct bar {
+	// offset: 0000 (4 bytes)
+	union value *pv;
+};
+
+// size: 0x8
+struct ovl_state {
+	// offset: 0000 (4 bytes)
+	int n;
+	// offset: 0004 (4 bytes)
+	struct point *pt;
+};
+
+// size: 0x4
+union value {
+	// offset: 0000 (4 bytes)
+	int i;
+	// offset: 0000 (4 bytes)
+	float f;
+};
+
+typedef int s32;
+
+typedef struct point point_t;
+
+typedef struct {
+	// offset: 0000 (4 bytes)
+	enum color c;
+	// offset: 0004 (4 bytes)
+	void (*cb)();
+} entry_t;
+
@@ -0,0 +1,7 @@
+set_name(0x80010100, "add", SN_NOWARN)
+set_name(0x00000000, "f", SN_NOWARN)
+set_name(0x80010000, "origin", SN_NOWARN)
+set_name(0x80010020, "entries", SN_NOWARN)
+set_name(0x00000000, "msg", SN_NOWARN)
+set_name(0x80010204, "gval", SN_NOWARN)
+set_name(0x80010208, "gbar", SN_NOWARN)
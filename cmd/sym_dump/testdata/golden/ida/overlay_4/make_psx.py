@@ -0,0 +1,2 @@
+set_name(0x800B0020, "gState", SN_NOWARN)
+set_name(0x800B0024, "gVal", SN_NOWARN)
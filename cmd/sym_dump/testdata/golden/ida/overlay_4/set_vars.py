@@ -0,0 +1,4 @@
+del_items(0x800B0020)
+SetType(0x800B0020, "struct ovl_state gState")
+del_items(0x800B0024)
+SetType(0x800B0024, "union value gVal")
@@ -0,0 +1,4 @@
+del_items(0x80010100)
+SetType(0x80010100, "int add(struct point *p, int n)")
+del_items(0x00000000)
+SetType(0x00000000, "void f()")
@@ -0,0 +1,10 @@
+del_items(0x80010000)
+SetType(0x80010000, "struct point origin")
+del_items(0x80010020)
+SetType(0x80010020, "struct {\n\t// offset: 0000 (4 bytes)\n\tenum color c;\n\t// offset: 0004 (4 bytes)\n\tvoid (*cb)();\n} entries[4]")
+del_items(0x00000000)
+SetType(0x00000000, "char *msg")
+del_items(0x80010204)
+SetType(0x80010204, "union value gval")
+del_items(0x80010208)
+SetType(0x80010208, "struct bar gbar")
@@ -0,0 +1,151 @@
+# Import symbols of PS1 SYM file, as generated by sym_dump.
+#
+# Overlay segments are created uninitialized; load the contents of overlays
+# into their segments using File > Load file > Additional binary file.
+
+import ida_segment
+import idaapi
+import idautils
+import idc
+
+def parse_types():
+	n = idc.parse_decls(TYPES, idc.PT_SILENT)
+	if n > 0:
+		print("unable to parse %d type declarations" % n)
+
+def overlay(name, addr, length):
+	"""
+	Creates the segment of the overlay, unless present, and returns the delta
+	of its start address relative to its load address.
+	"""
+	seg = ida_segment.get_segm_by_name(name)
+	if seg is not None:
+		return seg.start_ea - addr
+	start = addr
+	end = addr
+	for ea in idautils.Segments():
+		s = ida_segment.getseg(ea)
+		if s.start_ea < addr + length and addr < s.end_ea:
+			start = None
+		end = max(end, s.end_ea)
+	if start is None:
+		# Load address range occupied; relocate to the next free address.
+		start = (end + 0xFFFF) & ~0xFFFF
+	idc.add_segm_ex(start, start + length, 0, 1, idaapi.saRelPara, idaapi.scPub, idc.ADDSEG_NOSREG)
+	idc.set_segm_name(start, name)
+	idc.set_segm_class(start, "CODE")
+	idc.set_cmt(start, "%s loaded at 0x%08X" % (name, addr), 1)
+	return start - addr
+
+def func(ea, name, decl):
+	idc.set_name(ea, name, idc.SN_NOWARN)
+	idc.add_func(ea)
+	idc.SetType(ea, decl)
+
+def var(ea, name, decl):
+	idc.set_name(ea, name, idc.SN_NOWARN)
+	idc.del_items(ea)
+	idc.SetType(ea, decl)
+
+TYPES = r"""
+struct point;
+union value;
+
+enum color {
+	NONE  = -1,
+	RED   = 0,
+	GREEN = 1,
+};
+
+struct __vtbl_ptr_type {
+};
+
+// size: 0x2
+struct _0fake {
+	// offset: 0000 (1 bytes)
+	unsigned char r;
+	// offset: 0001 (1 bytes)
+	unsigned char g;
+};
+
+// size: 0x10
+struct point {
+	// offset: 0000 (4 bytes)
+	int x;
+	// offset: 0004 (4 bytes)
+	short pad[2];
+	// offset: 0008 (4 bytes)
+	struct point *next;
+	// offset: 000C.0 (3 bits)
+	unsigned int flags : 3;
+	// offset: 000C.3 (5 bits)
+	unsigned int kind : 5;
+	// offset: 000D (2 bytes)
+	struct {
+		// offset: 0000 (1 bytes)
+		unsigned char r;
+		// offset: 0001 (1 bytes)
+		unsigned char g;
+	} rg;
+};
+
+// size: 0x8
+struct _1fake {
+	// offset: 0000 (4 bytes)
+	enum color c;
+	// offset: 0004 (4 bytes)
+	void (*cb)();
+};
+
+// size: 0x4
+struct bar {
+	// offset: 0000 (4 bytes)
+	union value *pv;
+};
+
+// size: 0x8
+struct ovl_state {
+	// offset: 0000 (4 bytes)
+	int n;
+	// offset: 0004 (4 bytes)
+	struct point *pt;
+};
+
+// size: 0x4
+union value {
+	// offset: 0000 (4 bytes)
+	int i;
+	// offset: 0000 (4 bytes)
+	float f;
+};
+
+typedef int bool;
+
+typedef int s32;
+
+typedef struct point point_t;
+
+typedef struct {
+	// offset: 0000 (4 bytes)
+	enum color c;
+	// offset: 0004 (4 bytes)
+	void (*cb)();
+} entry_t;
+
+
+"""
+
+parse_types()
+
+d = 0
+func(d + 0x80010100, "add", "int add(struct point *p, int n);")
+func(d + 0x00000000, "f", "void f();")
+var(d + 0x80010000, "origin", "struct point origin;")
+var(d + 0x80010020, "entries", "struct { enum color c; void (*cb)(); } entries[4];")
+var(d + 0x00000000, "msg", "char *msg;")
+var(d + 0x80010204, "gval", "union value gval;")
+var(d + 0x80010208, "gbar", "struct bar gbar;")
+
+d = overlay("overlay_4", 0x800B0000, 0x100)
+var(d + 0x800B0020, "gState", "struct ovl_state gState;")
+var(d + 0x800B0024, "gVal", "union value gVal;")
@@ -0,0 +1,7 @@
+; Labels of PS1 SYM file, as generated by sym_dump.
+
+.definelabel origin, 0x80010000
+.definelabel entries, 0x80010020
+.definelabel add, 0x80010100
+.definelabel gval, 0x80010204
+.definelabel gbar, 0x80010208
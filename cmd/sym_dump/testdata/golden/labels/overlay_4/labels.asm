@@ -0,0 +1,4 @@
+; Labels of PS1 SYM file, as generated by sym_dump.
+
+.definelabel gState, 0x800B0020
+.definelabel gVal, 0x800B0024
@@ -0,0 +1,16 @@
+#ifndef OVERLAY_4_TYPES_H
+#define OVERLAY_4_TYPES_H
+
+#include "types.h"
+
+struct point;
+
+// size: 0x8
+struct ovl_state {
+	// offset: 0000 (4 bytes)
+	int n;
+	// offset: 0004 (4 bytes)
+	struct point *pt;
+};
+
+#endif // OVERLAY_4_TYPES_H
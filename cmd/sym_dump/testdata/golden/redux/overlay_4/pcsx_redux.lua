@@ -0,0 +1,50 @@
+-- Symbols of PS1 SYM file, as generated by sym_dump.
+--
+-- Usage from the Lua console of PCSX-Redux:
+--
+--    sym = dofile("pcsx_redux.lua")
+--    print(sym.lookup(0x80010010))  -- "main", 0
+--    print(sym.addr("main"))        -- 0x80010010
+
+local M = {}
+
+-- Symbols sorted by address.
+M.symbols = {
+	{ addr = 0x800B0020, size = 0x8, name = "gState", func = false },
+	{ addr = 0x800B0024, size = 0x4, name = "gVal", func = false },
+}
+
+local byName = {}
+for _, s in ipairs(M.symbols) do
+	byName[s.name] = s
+end
+
+-- lookup returns the name of the symbol containing the given address, and the
+-- offset of the address within the symbol; or nil if not located.
+function M.lookup(addr)
+	local found = nil
+	for _, s in ipairs(M.symbols) do
+		if s.addr > addr then
+			break
+		end
+		if addr < s.addr + math.max(s.size, 1) then
+			found = s
+		end
+	end
+	if found == nil then
+		return nil
+	end
+	return found.name, addr - found.addr
+end
+
+-- addr returns the address and size of the symbol of the given name; or nil
+-- if not present.
+function M.addr(name)
+	local s = byName[name]
+	if s == nil then
+		return nil
+	end
+	return s.addr, s.size
+end
+
+return M
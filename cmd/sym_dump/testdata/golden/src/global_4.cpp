@@ -0,0 +1,12 @@
+// global_4.cpp
+
+#include "types.h"
+
+// address: 0x800B0020
+// size: 0x8
+extern struct ovl_state gState;
+
+// address: 0x800B0024
+// size: 0x4
+extern union value gVal;
+
@@ -0,0 +1,15 @@
+// C:\PROJ\SRC\FOO.C
+
+#include "types.h"
+
+// address: 0x80010100
+// size: 0x40
+// line start: 26
+// line end:   35
+int add(struct point *p, int n) {
+	// address: 0x00000010
+	// size: 0x4
+	int sum;
+}
+
+
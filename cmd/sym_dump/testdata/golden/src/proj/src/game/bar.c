@@ -0,0 +1,8 @@
+// C:\PROJ\SRC\GAME\BAR.C
+
+#include "types.h"
+
+// line start: 0
+// line end:   0
+extern void f();
+
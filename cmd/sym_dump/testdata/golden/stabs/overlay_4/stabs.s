@@ -0,0 +1,47 @@
+# Stabs debug information of PS1 SYM file, as generated by sym_dump.
+#
+# Usage:
+#
+#    mipsel-linux-gnu-as -o stabs.o stabs.s
+#    gdb-multiarch
+#    (gdb) add-symbol-file stabs.o
+
+	.globl	gState
+	.equ	gState, 0x800B0020
+	.type	gState, @object
+	.size	gState, 8
+	.globl	gVal
+	.equ	gVal, 0x800B0024
+	.type	gVal, @object
+	.size	gVal, 4
+
+	.stabs	"overlay_4",100,0,2,0x0
+	.stabs	"void:t1=1",128,0,0,0x0
+	.stabs	"char:t2=r2;-128;127;",128,0,0,0x0
+	.stabs	"short:t3=r3;-32768;32767;",128,0,0,0x0
+	.stabs	"int:t4=r4;-2147483648;2147483647;",128,0,0,0x0
+	.stabs	"long:t5=r5;-2147483648;2147483647;",128,0,0,0x0
+	.stabs	"unsigned char:t6=r6;0;255;",128,0,0,0x0
+	.stabs	"unsigned short:t7=r7;0;65535;",128,0,0,0x0
+	.stabs	"unsigned int:t8=r8;0;4294967295;",128,0,0,0x0
+	.stabs	"unsigned long:t9=r9;0;4294967295;",128,0,0,0x0
+	.stabs	"float:t10=r4;4;0;",128,0,0,0x0
+	.stabs	"double:t11=r4;8;0;",128,0,0,0x0
+	.stabs	"long double:t12=r4;8;0;",128,0,0,0x0
+	.stabs	"long long:t13=r13;01000000000000000000000;0777777777777777777777;",128,0,0,0x0
+	.stabs	"unsigned long long:t14=r14;0;01777777777777777777777;",128,0,0,0x0
+	.stabs	"signed char:t15=r15;-128;127;",128,0,0,0x0
+	.stabs	"bool:t16=r16;0;1;",128,0,0,0x0
+	.stabs	"color:T17=eRED:0,GREEN:1,NONE:-1,;",128,0,0,0x0
+	.stabs	"__vtbl_ptr_type:T18=s0;",128,0,0,0x0
+	.stabs	"point:T19=s16x:4,0,32;pad:27=ar4;0;1;3,32,32;next:28=*19,64,32;flags:8,96,3;kind:8,99,5;rg:29=s2r:6,0,8;g:6,8,8;;,104,16;;",128,0,0,0x0
+	.stabs	"bar:T20=s4pv:30=*22,0,32;;",128,0,0,0x0
+	.stabs	"ovl_state:T21=s8n:4,0,32;pt:31=*19,32,32;;",128,0,0,0x0
+	.stabs	"value:T22=u4i:4,0,32;f:10,0,32;;",128,0,0,0x0
+	.stabs	"bool:t23=4",128,0,0,0x0
+	.stabs	"s32:t24=4",128,0,0,0x0
+	.stabs	"point_t:t25=19",128,0,0,0x0
+	.stabs	"entry_t:t26=32=s8c:17,0,32;cb:33=*34=f1,32,32;;",128,0,0,0x0
+	.stabs	"gState:G21",32,0,0,0x0
+	.stabs	"gVal:G22",32,0,0,0x0
+	.stabs	"",100,0,0,0x0
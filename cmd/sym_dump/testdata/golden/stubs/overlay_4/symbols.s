@@ -0,0 +1,10 @@
+# Symbols of PS1 SYM file, as generated by sym_dump.
+
+	.globl	gState
+	.equ	gState, 0x800B0020
+	.type	gState, @object
+	.size	gState, 8
+	.globl	gVal
+	.equ	gVal, 0x800B0024
+	.type	gVal, @object
+	.size	gVal, 4
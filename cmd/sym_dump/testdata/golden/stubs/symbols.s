@@ -0,0 +1,21 @@
+# Symbols of PS1 SYM file, as generated by sym_dump.
+
+	.globl	add
+	.equ	add, 0x80010100
+	.type	add, @function
+	.size	add, 64
+	.globl	origin
+	.equ	origin, 0x80010000
+	.type	origin, @object
+	.size	origin, 20
+	.equ	entries, 0x80010020
+	.type	entries, @object
+	.size	entries, 32
+	.globl	gval
+	.equ	gval, 0x80010204
+	.type	gval, @object
+	.size	gval, 4
+	.globl	gbar
+	.equ	gbar, 0x80010208
+	.type	gbar, @object
+	.size	gbar, 4
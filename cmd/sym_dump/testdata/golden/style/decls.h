@@ -0,0 +1,66 @@
+#ifndef DECLS_H
+#define DECLS_H
+
+#include "types.h"
+
+/**
+ * address: 0x80010000
+ * size: 0x14
+ */
+extern struct point origin;
+
+/**
+ * address: 0x80010020
+ * size: 0x20
+ */
+static struct
+{
+  /** offset: 0000 (4 bytes) */
+  enum color c;
+  /** offset: 0004 (4 bytes) */
+  void (*cb)();
+} entries[4];
+
+/** size: 0x4 */
+extern char *msg;
+
+/**
+ * address: 0x80010204
+ * size: 0x4
+ */
+extern union value gval;
+
+/**
+ * address: 0x80010208
+ * size: 0x4
+ */
+extern struct bar gbar;
+
+/**
+ * address: 0x80010100
+ * size: 0x40
+ * source file: C:\PROJ\SRC\FOO.C
+ * line start: 26
+ * line end:   35
+ */
+int add(
+  struct point *p,
+  int n
+)
+{
+  /**
+   * address: 0x00000010
+   * size: 0x4
+   */
+  int sum;
+}
+
+
+/**
+ * source file: C:\PROJ\SRC\GAME\BAR.C
+ * line start: 0
+ * line end:   0
+ */
+extern void f();
+
+#endif // DECLS_H
@@ -0,0 +1,16 @@
+#ifndef OVERLAY_4_H
+#define OVERLAY_4_H
+
+#include "types.h"
+
+// === [ Overlay ID 4 ] ===
+
+// address: 0x800B0020
+// size: 0x8
+extern struct ovl_state gState;
+
+// address: 0x800B0024
+// size: 0x4
+extern union value gVal;
+
+#endif // OVERLAY_4_H
@@ -0,0 +1,14 @@
+// C:\PROJ\SRC\FOO.C
+
+#ifndef FOO_FOO_H
+#define FOO_FOO_H
+
+#include "types.h"
+
+// address: 0x80010100
+// size: 0x40
+// line start: 26
+// line end:   35
+extern int add(struct point *p, int n);
+
+#endif // FOO_FOO_H
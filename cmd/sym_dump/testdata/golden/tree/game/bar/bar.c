@@ -0,0 +1,9 @@
+// C:\PROJ\SRC\GAME\BAR.C
+
+#include "bar.h"
+
+// line start: 0
+// line end:   0
+void f() {
+}
+
@@ -0,0 +1,12 @@
+// C:\PROJ\SRC\GAME\BAR.C
+
+#ifndef GAME_BAR_BAR_H
+#define GAME_BAR_BAR_H
+
+#include "types.h"
+
+// line start: 0
+// line end:   0
+extern void f();
+
+#endif // GAME_BAR_BAR_H
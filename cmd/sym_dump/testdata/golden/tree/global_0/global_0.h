@@ -0,0 +1,23 @@
+// global_0.cpp
+
+#ifndef GLOBAL_0_GLOBAL_0_H
+#define GLOBAL_0_GLOBAL_0_H
+
+#include "types.h"
+
+// address: 0x80010000
+// size: 0x14
+extern struct point origin;
+
+// size: 0x4
+extern char *msg;
+
+// address: 0x80010204
+// size: 0x4
+extern union value gval;
+
+// address: 0x80010208
+// size: 0x4
+extern struct bar gbar;
+
+#endif // GLOBAL_0_GLOBAL_0_H
@@ -0,0 +1,12 @@
+// global_4.cpp
+
+#include "global_4.h"
+
+// address: 0x800B0020
+// size: 0x8
+struct ovl_state gState;
+
+// address: 0x800B0024
+// size: 0x4
+union value gVal;
+
@@ -0,0 +1,16 @@
+// global_4.cpp
+
+#ifndef GLOBAL_4_GLOBAL_4_H
+#define GLOBAL_4_GLOBAL_4_H
+
+#include "types.h"
+
+// address: 0x800B0020
+// size: 0x8
+extern struct ovl_state gState;
+
+// address: 0x800B0024
+// size: 0x4
+extern union value gVal;
+
+#endif // GLOBAL_4_GLOBAL_4_H
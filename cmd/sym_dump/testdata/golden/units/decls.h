@@ -0,0 +1,16 @@
+#ifndef DECLS_H
+#define DECLS_H
+
+#include "types.h"
+
+#include "foo.h"
+
+// address: 0x800B0020
+// size: 0x8
+extern struct ovl_state gState;
+
+// address: 0x800B0024
+// size: 0x4
+extern union value gVal;
+
+#endif // DECLS_H
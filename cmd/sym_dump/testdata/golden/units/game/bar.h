@@ -0,0 +1,30 @@
+// C:\PROJ\SRC\GAME\BAR.C
+
+#ifndef GAME_BAR_H
+#define GAME_BAR_H
+
+#include "types.h"
+
+union value;
+
+// size: 0x4
+struct bar {
+	// offset: 0000 (4 bytes)
+	union value *pv;
+};
+
+#include "foo.h"
+
+// address: 0x80010204
+// size: 0x4
+extern union value gval;
+
+// address: 0x80010208
+// size: 0x4
+extern struct bar gbar;
+
+// line start: 0
+// line end:   0
+extern void f();
+
+#endif // GAME_BAR_H
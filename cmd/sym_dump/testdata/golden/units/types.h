@@ -0,0 +1,17 @@
+#ifndef TYPES_H
+#define TYPES_H
+
+struct point;
+
+struct __vtbl_ptr_type {
+};
+
+// size: 0x8
+struct ovl_state {
+	// offset: 0000 (4 bytes)
+	int n;
+	// offset: 0004 (4 bytes)
+	struct point *pt;
+};
+
+#endif // TYPES_H
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// dumpSourceTree outputs the source files recorded by the parser to a directory
+// hierarchy mirroring the source tree of the original project, stored in the
+// output directory. The locations of declarations are recorded in tags.
+//
+// Each source file is output to a directory named after the source file,
+// relative to the common root directory of all source files (e.g.
+// C:\PROJ\SRC\FOO.C -> src/foo/), containing a header of its declarations
+// (foo.h) and a stub of its definitions (foo.c).
+func dumpSourceTree(p *csym.Parser, outputDir string, tags *tagsFile, style c.Style, guard string) error {
+	srcs := getSourceFiles(p)
+	dirs := sourceTreeDirs(srcs)
+	for _, src := range srcs {
+		dir := dirs[src.Path]
+		if err := os.MkdirAll(filepath.Join(outputDir, filepath.FromSlash(dir)), 0755); err != nil {
+			return errors.WithStack(err)
+		}
+		src.uniqueNames()
+		base := path.Base(dir)
+		headerPath := path.Join(dir, base+".h")
+		stubPath := path.Join(dir, base+".c")
+		if err := createOutputFile(outputDir, headerPath, func(w io.Writer) error {
+			return dumpSourceHeader(w, src, headerPath, tags, style, guard)
+		}); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := createOutputFile(outputDir, stubPath, func(w io.Writer) error {
+			return dumpSourceStub(w, src, base+".h", style)
+		}); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// dumpSourceHeader outputs the declarations of the source file as a C header,
+// writing to w. The locations of declarations are recorded in tags, as output
+// to the given path relative to the output directory.
+func dumpSourceHeader(w io.Writer, src *SourceFile, path string, tags *tagsFile, style c.Style, guard string) error {
+	lw := newLineWriter(w)
+	w = lw
+	if _, err := fmt.Fprintf(w, "// %s\n\n", src.Path); err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := io.WriteString(w, guardStart(path, guard)); err != nil {
+		return errors.WithStack(err)
+	}
+	// Add types.h include directory.
+	if _, err := fmt.Fprintf(w, "#include %q\n\n", typesName); err != nil {
+		return errors.WithStack(err)
+	}
+	// Print variable declarations; static variables are local to the stub.
+	for _, v := range src.vars {
+		if v.Class == c.Static {
+			continue
+		}
+		def := v.DefStyle(style)
+		tags.addDef(path, lw.line, lw.offset, def, v.Name, tagKindVar, v.Addr)
+		if _, err := fmt.Fprintf(w, "%s;\n\n", def); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	// Print function prototypes.
+	for _, f := range src.funcs {
+		if f.Class == c.Static {
+			continue
+		}
+		proto := *f
+		proto.Blocks = nil
+		def := proto.DefStyle(style)
+		tags.addDef(path, lw.line, lw.offset, def, f.Name, tagKindFunc, f.Addr)
+		if _, err := fmt.Fprintf(w, "%s\n\n", def); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if _, err := io.WriteString(w, guardEnd(path, guard)); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// dumpSourceStub outputs a stub of the definitions of the source file, writing
+// to w. Global variables located at addresses are defined, and functions are
+// defined with bodies declaring their local variables. The stub includes the
+// given header of the source file.
+func dumpSourceStub(w io.Writer, src *SourceFile, header string, style c.Style) error {
+	if _, err := fmt.Fprintf(w, "// %s\n\n", src.Path); err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := fmt.Fprintf(w, "#include %q\n\n", header); err != nil {
+		return errors.WithStack(err)
+	}
+	// Print variable definitions.
+	for _, v := range src.vars {
+		if v.Addr == 0 {
+			// Skip undefined variables.
+			continue
+		}
+		def := *v
+		if def.Class == c.Extern {
+			def.Class = 0
+		}
+		if _, err := fmt.Fprintf(w, "%s;\n\n", def.DefStyle(style)); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	// Print function definitions.
+	for _, f := range src.funcs {
+		def := *f
+		if def.Class == c.Extern {
+			def.Class = 0
+		}
+		if len(def.Blocks) == 0 {
+			// Empty function body.
+			def.Blocks = []*c.Block{{}}
+		}
+		if _, err := fmt.Fprintf(w, "%s\n", def.DefStyle(style)); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// sourceTreeDirs returns a map from source file path to the slash-separated
+// output directory of the source file, relative to the common root directory
+// of all source files (e.g. C:\PROJ\SRC\FOO.C -> src/foo).
+func sourceTreeDirs(srcs []*SourceFile) map[string]string {
+	// Normalize paths; e.g. C:\PROJ\SRC\FOO.C -> proj/src/foo.c
+	paths := make(map[string]string)
+	var root []string
+	first := true
+	for _, src := range srcs {
+		p := strings.ToLower(strings.Replace(src.Path, `\`, "/", -1))
+		if len(p) >= 2 && p[1] == ':' {
+			// Strip drive letter.
+			p = p[len("c:"):]
+		}
+		p = strings.TrimPrefix(path.Clean("/"+p), "/")
+		if len(p) == 0 {
+			// Functions of unknown source file.
+			p = "unknown"
+		}
+		paths[src.Path] = p
+		dir := path.Dir(p)
+		if dir == "." {
+			// Skip files without directory (e.g. global variables of
+			// overlays) when locating the common root directory.
+			continue
+		}
+		parts := strings.Split(dir, "/")
+		if first {
+			root = parts
+			first = false
+			continue
+		}
+		n := 0
+		for n < len(root) && n < len(parts) && root[n] == parts[n] {
+			n++
+		}
+		root = root[:n]
+	}
+	prefix := strings.Join(root, "/")
+	dirs := make(map[string]string)
+	used := make(map[string]bool)
+	for _, src := range srcs {
+		p := paths[src.Path]
+		if len(prefix) > 0 && strings.HasPrefix(p, prefix+"/") {
+			p = p[len(prefix+"/"):]
+		}
+		dir := strings.TrimSuffix(p, path.Ext(p))
+		if used[dir] {
+			// Disambiguate source files of the same name but different
+			// extension; e.g. foo.c and foo.s -> foo and foo_s.
+			dir = strings.Replace(p, ".", "_", -1)
+		}
+		used[dir] = true
+		dirs[src.Path] = dir
+	}
+	return dirs
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/rickypai/natsort"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// A unit is a translation unit of the original project.
+type unit struct {
+	// Global variables and functions of the translation unit.
+	src *SourceFile
+	// Header of the translation unit; slash-separated path relative to the
+	// output directory.
+	header string
+	// Type definitions of the translation unit, in dependency order.
+	types []c.Type
+}
+
+// dumpUnits outputs the type definitions and declarations recorded by the
+// parser to one C header per translation unit of the original project, stored
+// in the output directory. If check is set, the header of shared types is
+// re-parsed to validate its syntax. The locations of type definitions and
+// declarations are recorded in tags.
+//
+// Each header is named after the source file of the translation unit, relative
+// to the common root directory of all source files (e.g. C:\PROJ\SRC\FOO.C ->
+// src/foo.h), and contains the type definitions, extern declarations of global
+// variables and function prototypes of the translation unit (see
+// Parser.Units). Type definitions used by the type definitions of more than one
+// translation unit are output to types.h, and global variables of unknown
+// translation unit to decls.h. Headers include the headers of other translation
+// units defining the types of their declarations.
+func dumpUnits(p *csym.Parser, outputDir string, check bool, tags *tagsFile, style c.Style, guard string) error {
+	defs := typeDefs(p)
+	// owners maps from type definition to the source file of its translation
+	// unit; shared type definitions are not present.
+	owners := make(map[c.Type]string)
+	for _, def := range defs {
+		if srcPath, ok := p.Units[def]; ok {
+			owners[def] = srcPath
+		}
+	}
+	shareTypes(defs, owners)
+	// units maps from source file to translation unit.
+	units := make(map[string]*unit)
+	getUnit := func(srcPath string) *unit {
+		u, ok := units[srcPath]
+		if !ok {
+			u = &unit{src: &SourceFile{Path: srcPath}}
+			units[srcPath] = u
+		}
+		return u
+	}
+	var shared []c.Type
+	for _, def := range defs {
+		srcPath, ok := owners[def]
+		if !ok {
+			shared = append(shared, def)
+			continue
+		}
+		u := getUnit(srcPath)
+		u.types = append(u.types, def)
+	}
+	// Global variables of unknown translation unit.
+	decls := &unit{src: &SourceFile{}, header: declsName}
+	for _, overlay := range append([]*csym.Overlay{p.Overlay}, p.Overlays...) {
+		for _, v := range overlay.Vars {
+			srcPath, ok := p.Units[v]
+			if !ok {
+				decls.src.vars = append(decls.src.vars, v)
+				continue
+			}
+			u := getUnit(srcPath)
+			u.src.vars = append(u.src.vars, v)
+		}
+		for _, f := range overlay.Funcs {
+			u := getUnit(f.Path)
+			u.src.funcs = append(u.src.funcs, f)
+		}
+	}
+	var srcs []*SourceFile
+	for _, u := range units {
+		srcs = append(srcs, u.src)
+	}
+	sort.Slice(srcs, func(i, j int) bool {
+		return natsort.Less(srcs[i].Path, srcs[j].Path)
+	})
+	dirs := sourceTreeDirs(srcs)
+	for _, src := range srcs {
+		units[src.Path].header = dirs[src.Path] + ".h"
+	}
+	// Output shared type definitions.
+	header, spans := defsHeader(p.Includes, nil, shared, typesName, style, guard)
+	if err := dumpHeader(outputDir, typesName, header, spans, check, tags, style); err != nil {
+		return errors.WithStack(err)
+	}
+	// Output headers of translation units.
+	for _, src := range srcs {
+		u := units[src.Path]
+		if err := os.MkdirAll(filepath.Join(outputDir, filepath.FromSlash(path.Dir(u.header))), 0755); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := createOutputFile(outputDir, u.header, func(w io.Writer) error {
+			return dumpUnit(w, u, units, owners, tags, style, guard)
+		}); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if len(decls.src.vars) > 0 {
+		if err := createOutputFile(outputDir, decls.header, func(w io.Writer) error {
+			return dumpUnit(w, decls, units, owners, tags, style, guard)
+		}); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// dumpUnit outputs the header of the translation unit, writing to w. The
+// headers of other translation units are located using units, and the
+// translation units of type definitions using owners. The locations of type
+// definitions and declarations are recorded in tags.
+func dumpUnit(w io.Writer, u *unit, units map[string]*unit, owners map[c.Type]string, tags *tagsFile, style c.Style, guard string) error {
+	lw := newLineWriter(w)
+	w = lw
+	u.src.uniqueNames()
+	// Declarations of the header; static variables and functions are local to
+	// the translation unit.
+	var vars []*c.VarDecl
+	for _, v := range u.src.vars {
+		if v.Class != c.Static {
+			vars = append(vars, v)
+		}
+	}
+	var funcs []*c.FuncDecl
+	for _, f := range u.src.funcs {
+		if f.Class != c.Static {
+			proto := *f
+			proto.Blocks = nil
+			funcs = append(funcs, &proto)
+		}
+	}
+	// Locate headers of other translation units defining types of the
+	// declarations, and structs and unions of other translation units
+	// referenced by the declarations.
+	var deps []c.Type
+	for _, v := range vars {
+		deps = append(deps, v)
+	}
+	for _, f := range funcs {
+		deps = append(deps, &c.VarDecl{Var: f.Var})
+	}
+	var (
+		includes []string
+		included = make(map[string]bool)
+		fwds     []c.Type
+		declared = make(map[c.Type]bool)
+	)
+	// other reports whether the given type is defined by another translation
+	// unit.
+	other := func(t c.Type) bool {
+		srcPath, ok := owners[t]
+		return ok && units[srcPath] != u
+	}
+	for _, t := range c.ForwardDecls(u.types) {
+		if other(t) {
+			fwds = append(fwds, t)
+			declared[t] = true
+		}
+	}
+	for _, dep := range deps {
+		complete, incomplete := c.Deps(dep)
+		for _, t := range complete {
+			if !other(t) {
+				continue
+			}
+			header := units[owners[t]].header
+			if !included[header] {
+				includes = append(includes, header)
+				included[header] = true
+			}
+		}
+		for _, t := range incomplete {
+			switch t.(type) {
+			case *c.StructType, *c.UnionType:
+				if other(t) && !declared[t] {
+					fwds = append(fwds, t)
+					declared[t] = true
+				}
+			}
+		}
+	}
+	sort.Strings(includes)
+	if len(u.src.Path) > 0 {
+		if _, err := fmt.Fprintf(w, "// %s\n\n", u.src.Path); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if _, err := io.WriteString(w, guardStart(u.header, guard)); err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := fmt.Fprintf(w, "#include %q\n\n", typesName); err != nil {
+		return errors.WithStack(err)
+	}
+	// Print forward declarations of structs and unions of other translation
+	// units.
+	if len(fwds) > 0 {
+		for _, t := range fwds {
+			if _, err := fmt.Fprintf(w, "%s;\n", t); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	// Print type definitions.
+	for _, def := range u.types {
+		tags.addType(u.header, lw.line, lw.offset, def, style)
+		if _, err := fmt.Fprintf(w, "%s;\n\n", def.DefStyle(style)); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	// Include headers of other translation units after the type definitions,
+	// so that mutually dependent headers see each others types.
+	if len(includes) > 0 {
+		for _, header := range includes {
+			if _, err := fmt.Fprintf(w, "#include %q\n", header); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	// Print variable declarations.
+	for _, v := range vars {
+		def := v.DefStyle(style)
+		tags.addDef(u.header, lw.line, lw.offset, def, v.Name, tagKindVar, v.Addr)
+		if _, err := fmt.Fprintf(w, "%s;\n\n", def); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	// Print function prototypes.
+	for _, f := range funcs {
+		def := f.DefStyle(style)
+		tags.addDef(u.header, lw.line, lw.offset, def, f.Name, tagKindFunc, f.Addr)
+		if _, err := fmt.Fprintf(w, "%s\n\n", def); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if _, err := io.WriteString(w, guardEnd(u.header, guard)); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// shareTypes removes from owners the type definitions required to be complete
+// by shared type definitions (i.e. not present in owners) or by the type
+// definitions of other owners, so that the type definitions of each owner only
+// depend on shared type definitions and forward declarations.
+func shareTypes(defs []c.Type, owners map[c.Type]string) {
+	for changed := true; changed; {
+		changed = false
+		for _, def := range defs {
+			owner, owned := owners[def]
+			complete, _ := c.Deps(def)
+			for _, dep := range complete {
+				if depOwner, ok := owners[dep]; ok && (!owned || depOwner != owner) {
+					delete(owners, dep)
+					changed = true
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym"
+)
+
+// validateUsage prints usage information of the validate command.
+func validateUsage(fs *flag.FlagSet) {
+	const use = `
+Validate the integrity of symbol files, reporting violated invariants of the SYM format.
+
+The exit status is 0 if no diagnostics of at least the severity specified by -fail were reported, and 1 otherwise (or on failure to read the symbol files).
+
+Usage:
+
+	sym_dump validate [OPTION]... FILE...
+
+Examples:
+
+	# Fail on errors and warnings, reporting diagnostics in JSON format.
+	sym_dump validate -json -fail warning FILE.SYM
+`
+	fmt.Println(use[1:])
+	fs.PrintDefaults()
+}
+
+// A validateDiag is a diagnostic reported by the validate command.
+type validateDiag struct {
+	// Path of the symbol file.
+	File string `json:"file"`
+	*sym.Diagnostic
+}
+
+// validate validates the symbol files specified by the given command line
+// arguments of the validate command, and prints the diagnostics to standard
+// output. The returned boolean reports whether any diagnostic of at least the
+// severity specified by -fail was reported.
+func validate(args []string) (bool, error) {
+	// Command line flags.
+	var (
+		// Minimum severity of failing diagnostics.
+		failName string
+		// Output in JSON format.
+		outputJSON bool
+		// Input format.
+		from string
+		// Text encoding of symbol names.
+		encodingName string
+		// Byte order of SYM files.
+		endian string
+	)
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	fs.StringVar(&failName, "fail", "error", "minimum severity of diagnostics failing validation (info, warning or error)")
+	fs.BoolVar(&outputJSON, "json", false, "output in JSON format")
+	fs.StringVar(&from, "from", "", "input format; detected if not specified")
+	fs.StringVar(&encodingName, "encoding", "", "text encoding of symbol names (sjis, or auto to detect)")
+	fs.StringVar(&endian, "endian", "", "byte order of SYM files (big or little); detected if not specified")
+	fs.Usage = func() { validateUsage(fs) }
+	if err := fs.Parse(args); err != nil {
+		return false, errors.WithStack(err)
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	fail, err := parseSeverity(failName)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	if len(from) > 0 && !isInputFormat(from) {
+		return false, errors.Errorf("invalid input format %q", from)
+	}
+	enc, err := parseEncoding(encodingName)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	order, err := parseByteOrder(endian)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	diags := []*validateDiag{}
+	failed := false
+	for _, path := range fs.Args() {
+		// Capture unknown and corrupted symbols, to report them as diagnostics
+		// rather than aborting the parse.
+		opts := &sym.ParseOptions{
+			Lenient:        true,
+			Recover:        true,
+			Encoding:       enc,
+			Order:          order,
+			DetectEncoding: strings.EqualFold(encodingName, "auto"),
+		}
+		f, err := parseFile(path, from, opts)
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+		for _, d := range f.Validate() {
+			if d.Severity >= fail {
+				failed = true
+			}
+			diags = append(diags, &validateDiag{File: path, Diagnostic: d})
+		}
+	}
+	if outputJSON {
+		buf, err := json.MarshalIndent(diags, "", "\t")
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+		fmt.Println(string(buf))
+		return failed, nil
+	}
+	for _, d := range diags {
+		fmt.Printf("%s: %v\n", d.File, d.Diagnostic)
+	}
+	return failed, nil
+}
+
+// parseSeverity returns the diagnostic severity of the given name.
+func parseSeverity(name string) (sym.Severity, error) {
+	for severity := sym.SeverityInfo; severity <= sym.SeverityError; severity++ {
+		if strings.EqualFold(severity.String(), name) {
+			return severity, nil
+		}
+	}
+	return 0, errors.Errorf("invalid severity %q", name)
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+	"gopkg.in/yaml.v3"
+)
+
+// YAML file name.
+const yamlName = "symbols.yaml"
+
+// dumpYAML outputs the declarations and type information recorded by the
+// parser to a YAML file stored in the output directory. Declarations are
+// grouped by overlay, and types are stored under a separate key.
+func dumpYAML(p *csym.Parser, outputDir string) error {
+	doc := &yamlDoc{
+		Types: newYAMLTypes(p),
+	}
+	doc.Overlays = append(doc.Overlays, newYAMLOverlay(p.Overlay))
+	for _, overlay := range p.Overlays {
+		doc.Overlays = append(doc.Overlays, newYAMLOverlay(overlay))
+	}
+	// Create output file.
+	if err := createOutputFile(outputDir, yamlName, func(w io.Writer) error {
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return errors.WithStack(err)
+		}
+		return enc.Close()
+	}); err != nil {
+		return errors.Wrap(err, "unable to create YAML file")
+	}
+	return nil
+}
+
+// yamlDoc is the YAML representation of the declarations and type information
+// recorded by the parser.
+type yamlDoc struct {
+	// Declarations of the default binary and overlays.
+	Overlays []*yamlOverlay `yaml:"overlays"`
+	// Type information.
+	Types *yamlTypes `yaml:"types"`
+}
+
+// --- [ Declarations ] --------------------------------------------------------
+
+// yamlOverlay is the YAML representation of the declarations of an overlay.
+type yamlOverlay struct {
+	// Overlay ID (0 for the default binary).
+	ID hexUint32 `yaml:"id"`
+	// Base address at which the overlay is loaded.
+	Addr hexUint32 `yaml:"address,omitempty"`
+	// Overlay length in bytes.
+	Length hexUint32 `yaml:"length,omitempty"`
+	// Global variables.
+	Vars []*yamlVar `yaml:"vars,omitempty"`
+	// Functions.
+	Funcs []*yamlFunc `yaml:"funcs,omitempty"`
+}
+
+// newYAMLOverlay returns the YAML representation of the given overlay.
+func newYAMLOverlay(overlay *csym.Overlay) *yamlOverlay {
+	o := &yamlOverlay{
+		ID:     hexUint32(overlay.ID),
+		Addr:   hexUint32(overlay.Addr),
+		Length: hexUint32(overlay.Length),
+	}
+	for _, v := range overlay.Vars {
+		o.Vars = append(o.Vars, newYAMLVar(v))
+	}
+	for _, f := range overlay.Funcs {
+		fn := &yamlFunc{
+			Name:      f.Name,
+			Addr:      hexUint32(f.Addr),
+			Size:      hexUint32(f.Size),
+			Type:      typeString(f.Type),
+			Path:      f.Path,
+			LineStart: f.LineStart,
+			LineEnd:   f.LineEnd,
+		}
+		if f.Class != 0 {
+			fn.Class = f.Class.String()
+		}
+		o.Funcs = append(o.Funcs, fn)
+	}
+	return o
+}
+
+// yamlVar is the YAML representation of a variable declaration.
+type yamlVar struct {
+	// Variable name.
+	Name string `yaml:"name"`
+	// Address.
+	Addr hexUint32 `yaml:"address,omitempty"`
+	// Size in bytes.
+	Size hexUint32 `yaml:"size,omitempty"`
+	// Storage class.
+	Class string `yaml:"class,omitempty"`
+	// C syntax representation of the type (e.g. "int [4]").
+	Type string `yaml:"type"`
+}
+
+// newYAMLVar returns the YAML representation of the given variable
+// declaration.
+func newYAMLVar(v *c.VarDecl) *yamlVar {
+	y := &yamlVar{
+		Name: v.Name,
+		Addr: hexUint32(v.Addr),
+		Size: hexUint32(v.Size),
+		Type: typeString(v.Type),
+	}
+	if v.Class != 0 {
+		y.Class = v.Class.String()
+	}
+	return y
+}
+
+// yamlFunc is the YAML representation of a function declaration.
+type yamlFunc struct {
+	// Function name.
+	Name string `yaml:"name"`
+	// Address.
+	Addr hexUint32 `yaml:"address,omitempty"`
+	// Size in bytes.
+	Size hexUint32 `yaml:"size,omitempty"`
+	// Storage class.
+	Class string `yaml:"class,omitempty"`
+	// C syntax representation of the function type (e.g. "int (int x)").
+	Type string `yaml:"type"`
+	// Source file.
+	Path string `yaml:"source_file,omitempty"`
+	// Start line number.
+	LineStart uint32 `yaml:"line_start,omitempty"`
+	// End line number.
+	LineEnd uint32 `yaml:"line_end,omitempty"`
+}
+
+// --- [ Types ] ---------------------------------------------------------------
+
+// yamlTypes is the YAML representation of the type information recorded by the
+// parser.
+type yamlTypes struct {
+	// Structure types.
+	Structs []*yamlComposite `yaml:"structs,omitempty"`
+	// Union types.
+	Unions []*yamlComposite `yaml:"unions,omitempty"`
+	// Enum types.
+	Enums []*yamlEnum `yaml:"enums,omitempty"`
+	// Type definitions.
+	Typedefs []*yamlVar `yaml:"typedefs,omitempty"`
+}
+
+// newYAMLTypes returns the YAML representation of the type information
+// recorded by the parser.
+func newYAMLTypes(p *csym.Parser) *yamlTypes {
+	types := &yamlTypes{}
+	for _, tag := range p.StructTags {
+		t := p.Structs[tag]
+		types.Structs = append(types.Structs, newYAMLComposite(t.Tag, t.Size, t.Fields))
+	}
+	for _, tag := range p.UnionTags {
+		t := p.Unions[tag]
+		types.Unions = append(types.Unions, newYAMLComposite(t.Tag, t.Size, t.Fields))
+	}
+	for _, tag := range p.EnumTags {
+		t := p.Enums[tag]
+		e := &yamlEnum{
+			Tag: t.Tag,
+		}
+		// Members in order of the C definition.
+		for _, member := range t.SortedMembers() {
+			m := &yamlEnumMember{
+				Name:  member.Name,
+				Value: t.Value(member),
+			}
+			e.Members = append(e.Members, m)
+		}
+		types.Enums = append(types.Enums, e)
+	}
+	for _, def := range p.Typedefs {
+		if v, ok := def.(*c.VarDecl); ok {
+			types.Typedefs = append(types.Typedefs, newYAMLVar(v))
+		}
+	}
+	return types
+}
+
+// yamlComposite is the YAML representation of a struct or union type.
+type yamlComposite struct {
+	// Structure or union tag.
+	Tag string `yaml:"tag"`
+	// Size in bytes.
+	Size hexUint32 `yaml:"size,omitempty"`
+	// Structure or union fields.
+	Fields []*yamlField `yaml:"fields,omitempty"`
+}
+
+// newYAMLComposite returns the YAML representation of the struct or union type
+// with the given tag, size and fields.
+func newYAMLComposite(tag string, size uint32, fields []c.Field) *yamlComposite {
+	t := &yamlComposite{
+		Tag:  tag,
+		Size: hexUint32(size),
+	}
+	for _, field := range fields {
+		f := &yamlField{
+			Name:      field.Name,
+			Offset:    hexUint32(field.Offset),
+			Size:      hexUint32(field.Size),
+			BitOffset: field.BitOffset,
+			BitWidth:  field.BitWidth,
+			Type:      typeString(field.Type),
+		}
+		t.Fields = append(t.Fields, f)
+	}
+	return t
+}
+
+// yamlField is the YAML representation of a struct or union field.
+type yamlField struct {
+	// Field name.
+	Name string `yaml:"name"`
+	// Offset in bytes.
+	Offset hexUint32 `yaml:"offset"`
+	// Size in bytes.
+	Size hexUint32 `yaml:"size,omitempty"`
+	// Bit offset of bitfield, relative to offset.
+	BitOffset uint32 `yaml:"bit_offset,omitempty"`
+	// Width in bits of bitfield.
+	BitWidth uint32 `yaml:"bit_width,omitempty"`
+	// C syntax representation of the field type (e.g. "int [4]").
+	Type string `yaml:"type"`
+}
+
+// yamlEnum is the YAML representation of an enum type.
+type yamlEnum struct {
+	// Enum tag.
+	Tag string `yaml:"tag"`
+	// Enum members.
+	Members []*yamlEnumMember `yaml:"members,omitempty"`
+}
+
+// yamlEnumMember is the YAML representation of an enum member.
+type yamlEnumMember struct {
+	// Enum name.
+	Name string `yaml:"name"`
+	// Enum value.
+	Value int64 `yaml:"value"`
+}
+
+// ### [ Helper functions ] ####################################################
+
+// hexUint32 is a 32-bit unsigned integer encoded in hexadecimal notation in
+// YAML (e.g. 0x80010000).
+type hexUint32 uint32
+
+// IsZero reports whether the integer is zero, as used by omitempty.
+func (v hexUint32) IsZero() bool {
+	return v == 0
+}
+
+// MarshalYAML returns the YAML representation of the integer.
+func (v hexUint32) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{
+		Kind:  yaml.ScalarNode,
+		Tag:   "!!int",
+		Value: fmt.Sprintf("0x%X", uint32(v)),
+	}
+	return node, nil
+}
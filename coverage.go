@@ -0,0 +1,264 @@
+package sym
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+//go:generate stringer -linecomment -type Section
+
+// Section specifies the kind of contents of an address range, as determined
+// heuristically from the symbols covering it.
+type Section uint8
+
+// Sections.
+const (
+	// Unknown contents.
+	SectionUnknown Section = iota // unknown
+	// Code; i.e. covered by functions.
+	SectionText // text
+	// Data; i.e. covered by global variables.
+	SectionData // data
+)
+
+// Coverage records the address ranges covered and not covered by symbols of
+// known extent.
+type Coverage struct {
+	// Regions of covered and uncovered address ranges, sorted by overlay ID and
+	// address.
+	Regions []*Region
+}
+
+// A Region is a contiguous address range, either covered or not covered by
+// symbols of known extent.
+type Region struct {
+	// ID of the overlay containing the address range (0 for the default
+	// binary).
+	Overlay uint32
+	// Start address.
+	Start uint32
+	// End address (exclusive).
+	End uint32
+	// Kind of contents of the address range.
+	Section Section
+	// Specifies whether the address range is covered by symbols of known extent
+	// (i.e. functions and global variable definitions).
+	Covered bool
+	// Number of named symbols located within the address range.
+	Symbols int
+}
+
+// Size returns the size of the region in bytes.
+func (r *Region) Size() uint32 {
+	return r.End - r.Start
+}
+
+// String returns the string representation of the region.
+func (r *Region) String() string {
+	// 0x80010000-0x80010040 (0x40 bytes) text covered
+	// 0x80010040-0x80010100 (0xC0 bytes) text gap (no symbols)
+	status := "covered"
+	if !r.Covered {
+		if r.Symbols == 0 {
+			status = "gap (no symbols)"
+		} else {
+			status = fmt.Sprintf("gap (%d symbols of unknown extent)", r.Symbols)
+		}
+	}
+	return fmt.Sprintf("0x%08X-0x%08X (0x%X bytes) %v %s", r.Start, r.End, r.Size(), r.Section, status)
+}
+
+// NewCoverage returns the coverage of the address space of each overlay (and
+// the default binary) by symbols of the given symbols.
+//
+// Symbols of known extent are functions and global definitions with sizes (see
+// SizeSource); the sizes of name symbols are unknown, and thus do not cover
+// address ranges. The address space of overlays spans the base address and
+// length specified by overlay symbols, and otherwise the address range of the
+// symbols of the overlay.
+func NewCoverage(syms []*Symbol) *Coverage {
+	type bounds struct {
+		start, end uint32
+	}
+	// overlays maps from overlay ID to the address space of the overlay.
+	overlays := make(map[uint32]bounds)
+	for _, s := range syms {
+		if body, ok := s.Body.(*Overlay); ok {
+			overlays[body.ID] = bounds{start: s.Hdr.Value, end: endAddr(s.Hdr.Value, body.Length)}
+		}
+	}
+	// entries maps from overlay ID to the index entries of the overlay.
+	entries := make(map[uint32][]*AddrEntry)
+	var ids []uint32
+	for _, e := range NewAddrIndex(syms).Entries {
+		if _, ok := entries[e.Overlay]; !ok {
+			ids = append(ids, e.Overlay)
+		}
+		entries[e.Overlay] = append(entries[e.Overlay], e)
+	}
+	cov := &Coverage{}
+	for _, id := range ids {
+		es := entries[id]
+		b, ok := overlays[id]
+		if !ok {
+			b = bounds{start: es[0].Addr, end: es[len(es)-1].Addr}
+			for _, e := range es {
+				if end := endAddr(e.Addr, e.Size); end > b.end {
+					b.end = end
+				}
+			}
+		}
+		cov.Regions = append(cov.Regions, overlayRegions(id, b.start, b.end, es)...)
+	}
+	return cov
+}
+
+// Gaps returns the regions not covered by symbols of known extent.
+func (cov *Coverage) Gaps() []*Region {
+	var gaps []*Region
+	for _, r := range cov.Regions {
+		if !r.Covered {
+			gaps = append(gaps, r)
+		}
+	}
+	return gaps
+}
+
+// WriteReport writes a text report of the coverage to w, listing the regions
+// of each overlay followed by a summary of the covered bytes.
+func (cov *Coverage) WriteReport(w io.Writer) error {
+	for i := 0; i < len(cov.Regions); {
+		overlay := cov.Regions[i].Overlay
+		if overlay == 0 {
+			if _, err := fmt.Fprintln(w, "default binary:"); err != nil {
+				return err
+			}
+		} else {
+			if _, err := fmt.Fprintf(w, "overlay %X:\n", overlay); err != nil {
+				return err
+			}
+		}
+		var total, covered uint64
+		for ; i < len(cov.Regions) && cov.Regions[i].Overlay == overlay; i++ {
+			r := cov.Regions[i]
+			if _, err := fmt.Fprintf(w, "   %v\n", r); err != nil {
+				return err
+			}
+			total += uint64(r.Size())
+			if r.Covered {
+				covered += uint64(r.Size())
+			}
+		}
+		percent := 0.0
+		if total > 0 {
+			percent = 100 * float64(covered) / float64(total)
+		}
+		if _, err := fmt.Fprintf(w, "   covered 0x%X of 0x%X bytes (%.1f%%)\n", covered, total, percent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// overlayRegions returns the covered and uncovered regions of the address range
+// [start, end) of the given overlay, based on the given index entries of the
+// overlay sorted by address.
+func overlayRegions(overlay, start, end uint32, entries []*AddrEntry) []*Region {
+	// Covered regions, merged if overlapping or adjacent and of the same
+	// section.
+	var covered []*Region
+	for _, e := range entries {
+		var section Section
+		switch e.SizeSource {
+		case SizeExplicit:
+			section = SectionData
+			if isFuncDef(e.Sym) {
+				section = SectionText
+			}
+		case SizeFunction:
+			section = SectionText
+		default:
+			continue
+		}
+		r := &Region{
+			Overlay: overlay,
+			Start:   e.Addr,
+			End:     endAddr(e.Addr, e.Size),
+			Section: section,
+			Covered: true,
+		}
+		if n := len(covered); n > 0 {
+			prev := covered[n-1]
+			if r.Start < prev.End || (r.Start == prev.End && r.Section == prev.Section) {
+				if r.End > prev.End {
+					prev.End = r.End
+				}
+				continue
+			}
+		}
+		covered = append(covered, r)
+	}
+	// Interleave gaps between covered regions.
+	var regions []*Region
+	addGap := func(gapStart, gapEnd uint32, prev, next *Region) {
+		if gapStart >= gapEnd {
+			return
+		}
+		gap := &Region{
+			Overlay: overlay,
+			Start:   gapStart,
+			End:     gapEnd,
+		}
+		// Gaps surrounded by regions of the same section are assumed to be of the
+		// same section.
+		if prev != nil && next != nil && prev.Section == next.Section {
+			gap.Section = prev.Section
+		}
+		regions = append(regions, gap)
+	}
+	pos := start
+	var prev *Region
+	for _, r := range covered {
+		if r.End <= start || r.Start >= end {
+			continue
+		}
+		// Clip region to address range.
+		if r.Start < start {
+			r.Start = start
+		}
+		if r.End > end {
+			r.End = end
+		}
+		addGap(pos, r.Start, prev, r)
+		regions = append(regions, r)
+		pos = r.End
+		prev = r
+	}
+	addGap(pos, end, prev, nil)
+	// Count named symbols within each region.
+	for _, r := range regions {
+		lo := sort.Search(len(entries), func(i int) bool {
+			return entries[i].Addr >= r.Start
+		})
+		for i := lo; i < len(entries) && entries[i].Addr < r.End; i++ {
+			r.Symbols++
+		}
+	}
+	return regions
+}
+
+// isFuncDef reports whether the given symbol is a definition of a function.
+func isFuncDef(sym *Symbol) bool {
+	var t Type
+	switch body := sym.Body.(type) {
+	case *Def:
+		t = body.Type
+	case *Def2:
+		t = body.Type
+	default:
+		return false
+	}
+	mods := t.Mods()
+	return len(mods) > 0 && mods[0] == ModFunction
+}
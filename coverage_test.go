@@ -0,0 +1,73 @@
+package sym_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sanctuary/sym"
+)
+
+func TestCoverage(t *testing.T) {
+	syms := []*sym.Symbol{
+		// Functions of 0x40 bytes each.
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010000, Kind: sym.KindDef},
+			Body: &sym.Def{Class: sym.ClassEXT, Type: 0x24, Size: 0x40, Name: "foo"},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010100, Kind: sym.KindDef},
+			Body: &sym.Def{Class: sym.ClassEXT, Type: 0x24, Size: 0x40, Name: "bar"},
+		},
+		// Name symbol of unknown extent.
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010080, Kind: sym.KindName2},
+			Body: &sym.Name2{Name: "baz"},
+		},
+		// Global variable of 0x10 bytes.
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80020000, Kind: sym.KindDef},
+			Body: &sym.Def{Class: sym.ClassEXT, Type: 0x04, Size: 0x10, Name: "x"},
+		},
+	}
+	cov := sym.NewCoverage(syms)
+	want := []string{
+		"0x80010000-0x80010040 (0x40 bytes) text covered",
+		"0x80010040-0x80010100 (0xC0 bytes) text gap (1 symbols of unknown extent)",
+		"0x80010100-0x80010140 (0x40 bytes) text covered",
+		"0x80010140-0x80020000 (0xFEC0 bytes) unknown gap (no symbols)",
+		"0x80020000-0x80020010 (0x10 bytes) data covered",
+	}
+	if len(cov.Regions) != len(want) {
+		t.Fatalf("number of regions mismatch; expected %d, got %d (%v)", len(want), len(cov.Regions), cov.Regions)
+	}
+	for i, r := range cov.Regions {
+		if got := r.String(); got != want[i] {
+			t.Errorf("region %d mismatch; expected %q, got %q", i, want[i], got)
+		}
+	}
+	if gaps := cov.Gaps(); len(gaps) != 2 {
+		t.Errorf("number of gaps mismatch; expected 2, got %d", len(gaps))
+	}
+	buf := &strings.Builder{}
+	if err := cov.WriteReport(buf); err != nil {
+		t.Fatalf("unable to write coverage report; %v", err)
+	}
+	if !strings.Contains(buf.String(), "covered 0x90 of 0x10010 bytes (0.2%)") {
+		t.Errorf("coverage summary missing from report:\n%s", buf)
+	}
+}
+
+func TestCoverageSaturate(t *testing.T) {
+	// Global variable extending past the end of the address space.
+	syms := []*sym.Symbol{
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0xFFFFFF00, Kind: sym.KindDef},
+			Body: &sym.Def{Class: sym.ClassEXT, Type: 0x04, Size: 0x200, Name: "x"},
+		},
+	}
+	cov := sym.NewCoverage(syms)
+	want := "0xFFFFFF00-0xFFFFFFFF (0xFF bytes) data covered"
+	if len(cov.Regions) != 1 || cov.Regions[0].String() != want {
+		t.Errorf("regions mismatch; expected [%s], got %v", want, cov.Regions)
+	}
+}
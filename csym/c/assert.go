@@ -0,0 +1,48 @@
+package c
+
+import (
+	"fmt"
+)
+
+// LayoutAsserts returns static assertions verifying the recorded layout of the
+// given struct or union type at compile time; the size of the type and the
+// offset of each struct field (e.g. `_Static_assert(offsetof(struct foo, bar)
+// == 0x4, "offset of struct foo.bar");`). The assertions require stddef.h.
+//
+// Bitfields, anonymous types (with fake tags) and sizes not recorded are
+// skipped, as they may not be verified using sizeof and offsetof.
+func LayoutAsserts(t Type) []string {
+	var (
+		size   uint32
+		fields []Field
+	)
+	switch t := t.(type) {
+	case *StructType:
+		if IsFakeTag(t.Tag) {
+			return nil
+		}
+		size = t.Size
+		fields = t.Fields
+	case *UnionType:
+		if IsFakeTag(t.Tag) {
+			return nil
+		}
+		// Fields of unions are all located at offset 0.
+		size = t.Size
+	default:
+		return nil
+	}
+	var asserts []string
+	if size > 0 {
+		assert := fmt.Sprintf("_Static_assert(sizeof(%s) == 0x%X, \"size of %s\");", t, size, t)
+		asserts = append(asserts, assert)
+	}
+	for _, field := range fields {
+		if field.BitWidth > 0 || len(field.Name) == 0 {
+			continue
+		}
+		assert := fmt.Sprintf("_Static_assert(offsetof(%s, %s) == 0x%X, \"offset of %s.%s\");", t, field.Name, field.Offset, t, field.Name)
+		asserts = append(asserts, assert)
+	}
+	return asserts
+}
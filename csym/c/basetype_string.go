@@ -4,9 +4,9 @@ package c
 
 import "strconv"
 
-const _BaseType_name = "voidcharshortintlongunsigned charunsigned shortunsigned intunsigned long"
+const _BaseType_name = "voidcharshortintlongunsigned charunsigned shortunsigned intunsigned longfloatdoublelong doublelong longunsigned long longsigned charbool"
 
-var _BaseType_index = [...]uint8{0, 4, 8, 13, 16, 20, 33, 47, 59, 72}
+var _BaseType_index = [...]uint8{0, 4, 8, 13, 16, 20, 33, 47, 59, 72, 77, 83, 94, 103, 121, 132, 136}
 
 func (i BaseType) String() string {
 	i -= 1
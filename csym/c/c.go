@@ -15,6 +15,9 @@ type VarDecl struct {
 	Size uint32
 	// Storage class.
 	Class StorageClass
+	// ID of the overlay containing the global variable (0 for the default
+	// binary).
+	Overlay uint32
 	// Underlying variable.
 	Var
 }
@@ -25,24 +28,51 @@ func (v *VarDecl) String() string {
 }
 
 // Def returns the C syntax representation of the definition of the variable
-// declaration.
+// declaration, formatted in the default style.
 func (v *VarDecl) Def() string {
+	return v.DefStyle(DefaultStyle)
+}
+
+// DefStyle returns the C syntax representation of the definition of the variable
+// declaration, formatted in the given style.
+func (v *VarDecl) DefStyle(style Style) string {
 	buf := &strings.Builder{}
+	var meta []string
 	switch v.Class {
 	case Register:
-		fmt.Fprintf(buf, "// register: %d\n", v.Addr)
+		meta = append(meta, fmt.Sprintf("register: %d", v.Addr))
 	default:
 		if v.Addr > 0 {
-			fmt.Fprintf(buf, "// address: 0x%08X\n", v.Addr)
+			meta = append(meta, fmt.Sprintf("address: 0x%08X", v.Addr))
 		}
 	}
 	if v.Size > 0 {
-		fmt.Fprintf(buf, "// size: 0x%X\n", v.Size)
+		meta = append(meta, fmt.Sprintf("size: 0x%X", v.Size))
 	}
-	if v.Class == 0 {
-		fmt.Fprintf(buf, "%s", v.Var)
-	} else {
-		fmt.Fprintf(buf, "%s %s", v.Class, v.Var)
+	if style.Doxygen && v.Overlay != 0 {
+		meta = append(meta, fmt.Sprintf("overlay: %X", v.Overlay))
+	}
+	buf.WriteString(style.comments("", meta))
+	decl := style.declString(v.Type, v.Name)
+	switch t := v.Type.(type) {
+	case *FuncType:
+		decl = style.funcDeclString(t, v.Name)
+	case *EnumType:
+		if t.Typedef == v {
+			// Enum defined inline; e.g. `typedef enum {...} Name`.
+			tag := t.Tag
+			if IsFakeTag(tag) {
+				tag = ""
+			}
+			decl = fmt.Sprintf("%s %s", t.def(style, tag), v.Name)
+		}
+	}
+	switch v.Class {
+	case 0, Auto:
+		// Local variables have automatic storage by default.
+		buf.WriteString(decl)
+	default:
+		fmt.Fprintf(buf, "%s %s", v.Class, decl)
 	}
 	return buf.String()
 }
@@ -69,6 +99,10 @@ type FuncDecl struct {
 	Addr uint32
 	// Size (optional).
 	Size uint32
+	// Storage class (optional).
+	Class StorageClass
+	// ID of the overlay containing the function (0 for the default binary).
+	Overlay uint32
 	// Start line number.
 	LineStart uint32
 	// End line number.
@@ -85,34 +119,66 @@ func (f *FuncDecl) String() string {
 }
 
 // Def returns the C syntax representation of the definition of the function
-// declaration.
+// declaration, formatted in the default style.
 func (f *FuncDecl) Def() string {
-	// TODO: Print storage class.
+	return f.DefStyle(DefaultStyle)
+}
+
+// DefStyle returns the C syntax representation of the definition of the function
+// declaration, formatted in the given style.
+func (f *FuncDecl) DefStyle(style Style) string {
 	buf := &strings.Builder{}
+	var meta []string
 	if f.Addr > 0 {
-		fmt.Fprintf(buf, "// address: 0x%08X\n", f.Addr)
+		meta = append(meta, fmt.Sprintf("address: 0x%08X", f.Addr))
 	}
 	if f.Size > 0 {
-		fmt.Fprintf(buf, "// size: 0x%X\n", f.Size)
+		meta = append(meta, fmt.Sprintf("size: 0x%X", f.Size))
+	}
+	if style.Doxygen {
+		if len(f.Path) > 0 {
+			meta = append(meta, fmt.Sprintf("source file: %s", f.Path))
+		}
+		if f.Overlay != 0 {
+			meta = append(meta, fmt.Sprintf("overlay: %X", f.Overlay))
+		}
+	}
+	meta = append(meta, fmt.Sprintf("line start: %d", f.LineStart))
+	meta = append(meta, fmt.Sprintf("line end:   %d", f.LineEnd))
+	buf.WriteString(style.comments("", meta))
+	decl := style.declString(f.Type, f.Name)
+	if t, ok := f.Type.(*FuncType); ok {
+		decl = style.funcDeclString(t, f.Name)
 	}
-	fmt.Fprintf(buf, "// line start: %d\n", f.LineStart)
-	fmt.Fprintf(buf, "// line end:   %d\n", f.LineEnd)
-	if len(f.Blocks) == 0 {
-		fmt.Fprintf(buf, "%s;", f.Var)
+	if len(f.Blocks) == 0 || style.Compact {
+		// Prototype.
+		if f.Class != 0 {
+			decl = fmt.Sprintf("%s %s", f.Class, decl)
+		}
+		fmt.Fprintf(buf, "%s;", decl)
 		return buf.String()
 	}
-	fmt.Fprintf(buf, "%s ", f.Var)
+	// Function definitions have external linkage unless declared static.
+	if f.Class == Static {
+		decl = fmt.Sprintf("%s %s", f.Class, decl)
+	}
+	// Opening brace of the function body.
+	if style.BraceNewline {
+		fmt.Fprintf(buf, "%s\n", decl)
+	} else {
+		fmt.Fprintf(buf, "%s ", decl)
+	}
 	for i, block := range f.Blocks {
-		indent := strings.Repeat("\t", i)
+		indent := style.indent(i)
 		fmt.Fprintf(buf, "%s{\n", indent)
 		for _, local := range block.Locals {
-			indent := strings.Repeat("\t", i+1)
-			l := strings.Replace(local.Def(), "\n", "\n"+indent, -1)
+			indent := style.indent(i + 1)
+			l := strings.Replace(local.DefStyle(style), "\n", "\n"+indent, -1)
 			fmt.Fprintf(buf, "%s%s;\n", indent, l)
 		}
 	}
 	for i := len(f.Blocks) - 1; i >= 0; i-- {
-		indent := strings.Repeat("\t", i)
+		indent := style.indent(i)
 		fmt.Fprintf(buf, "%s}\n", indent)
 	}
 	return buf.String()
@@ -0,0 +1,188 @@
+package c
+
+// Deps returns the types on which the definition of the given type depends.
+// Complete dependencies must be defined before the type (e.g. struct fields by
+// value and type definitions), while incomplete dependencies need only be
+// declared before the type (e.g. the element type of pointers).
+func Deps(t Type) (complete, incomplete []Type) {
+	d := &deps{
+		seen: make(map[Type]bool),
+	}
+	switch t := t.(type) {
+	case *StructType:
+		for _, field := range t.Fields {
+			d.walk(field.Type, true)
+		}
+	case *UnionType:
+		for _, field := range t.Fields {
+			d.walk(field.Type, true)
+		}
+	case *VarDecl:
+		// Type definition.
+		if e, ok := t.Type.(*EnumType); ok && e.Typedef == t {
+			// Enum defined inline.
+			break
+		}
+		d.walk(t.Type, true)
+	}
+	return d.complete, d.incomplete
+}
+
+// ForwardDecls returns the struct and union types which are referenced by the
+// given type definitions before being defined, in order of first reference.
+// Forward declarations of these types (e.g. "struct node;") are thus required
+// before the type definitions.
+func ForwardDecls(defs []Type) []Type {
+	var (
+		// defined records the types defined so far.
+		defined = make(map[Type]bool)
+		// forward records the types requiring forward declarations.
+		forward = make(map[Type]bool)
+		fwds    []Type
+	)
+	for _, def := range defs {
+		_, incomplete := Deps(def)
+		for _, dep := range incomplete {
+			if defined[dep] || forward[dep] {
+				continue
+			}
+			switch dep.(type) {
+			case *StructType, *UnionType:
+				forward[dep] = true
+				fwds = append(fwds, dep)
+			}
+		}
+		defined[def] = true
+	}
+	return fwds
+}
+
+// SortDefs returns the given type definitions sorted in dependency order, such
+// that each type definition succeeds the definitions of its complete
+// dependencies (see Deps). The order of type definitions is otherwise
+// preserved.
+//
+// Incomplete dependencies do not affect the order, as cycles between type
+// definitions are broken by forward declarations (see ForwardDecls).
+func SortDefs(defs []Type) []Type {
+	// index records the type definitions to sort.
+	index := make(map[Type]bool)
+	for _, def := range defs {
+		index[def] = true
+	}
+	visited := make(map[Type]bool)
+	sorted := make([]Type, 0, len(defs))
+	var visit func(def Type)
+	visit = func(def Type) {
+		if visited[def] {
+			return
+		}
+		visited[def] = true
+		complete, _ := Deps(def)
+		for _, dep := range complete {
+			if index[dep] {
+				visit(dep)
+			}
+		}
+		sorted = append(sorted, def)
+	}
+	for _, def := range defs {
+		visit(def)
+	}
+	return sorted
+}
+
+// deps tracks the dependencies of a type definition.
+type deps struct {
+	// Complete dependencies, in order of occurrence.
+	complete []Type
+	// Incomplete dependencies, in order of occurrence.
+	incomplete []Type
+	// seen records the dependencies added so far.
+	seen map[Type]bool
+}
+
+// walk records the dependencies of the given type reference. The complete
+// parameter specifies whether the referenced type must be complete.
+func (d *deps) walk(t Type, complete bool) {
+	switch t := t.(type) {
+	case *StructType:
+		d.add(t, complete)
+		if complete && IsFakeTag(t.Tag) {
+			// Anonymous structs are defined inline.
+			for _, field := range t.Fields {
+				d.walk(field.Type, true)
+			}
+		}
+	case *UnionType:
+		d.add(t, complete)
+		if complete && IsFakeTag(t.Tag) {
+			// Anonymous unions are defined inline.
+			for _, field := range t.Fields {
+				d.walk(field.Type, true)
+			}
+		}
+	case *EnumType:
+		// Enums may not be forward declared.
+		if t.Typedef != nil {
+			// Enum defined inline in type definition.
+			d.add(t.Typedef, true)
+			break
+		}
+		d.add(t, true)
+	case *VarDecl:
+		// Type definitions may not be forward declared.
+		d.add(t, true)
+	case *PointerType:
+		d.walk(t.Elem, false)
+	case *ArrayType:
+		d.walk(t.Elem, complete)
+	case *QualType:
+		d.walk(t.Type, complete)
+	case *FuncType:
+		// Return and parameter types of function declarators may be incomplete.
+		d.walk(t.RetType, false)
+		for _, param := range t.Params {
+			d.walk(param.Type, false)
+		}
+	}
+}
+
+// add records the given dependency. A dependency recorded as both complete and
+// incomplete is recorded as complete.
+func (d *deps) add(t Type, complete bool) {
+	if complete {
+		if d.seen[t] && !d.isIncomplete(t) {
+			return
+		}
+		d.removeIncomplete(t)
+		d.complete = append(d.complete, t)
+	} else {
+		if d.seen[t] {
+			return
+		}
+		d.incomplete = append(d.incomplete, t)
+	}
+	d.seen[t] = true
+}
+
+// isIncomplete reports whether the given type is recorded as an incomplete
+// dependency.
+func (d *deps) isIncomplete(t Type) bool {
+	for _, dep := range d.incomplete {
+		if dep == t {
+			return true
+		}
+	}
+	return false
+}
+
+// removeIncomplete removes the given type from the incomplete dependencies.
+func (d *deps) removeIncomplete(t Type) {
+	for i, dep := range d.incomplete {
+		if dep == t {
+			d.incomplete = append(d.incomplete[:i], d.incomplete[i+1:]...)
+			return
+		}
+	}
+}
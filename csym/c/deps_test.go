@@ -0,0 +1,53 @@
+package c
+
+import "testing"
+
+func TestForwardDecls(t *testing.T) {
+	// struct node { struct node *next; struct list *list; };
+	node := &StructType{Size: 8, Tag: "node"}
+	// struct list { struct node head; int n; };
+	list := &StructType{Size: 12, Tag: "list"}
+	node.Fields = []Field{
+		{Offset: 0, Var: Var{Type: &PointerType{Elem: node}, Name: "next"}},
+		{Offset: 4, Var: Var{Type: &PointerType{Elem: list}, Name: "list"}},
+	}
+	list.Fields = []Field{
+		{Offset: 0, Var: Var{Type: node, Name: "head"}},
+		{Offset: 8, Var: Var{Type: Int, Name: "n"}},
+	}
+	complete, incomplete := Deps(list)
+	if len(complete) != 1 || complete[0] != node || len(incomplete) != 0 {
+		t.Errorf("dependencies of struct list mismatch; expected complete [struct node], got complete %v, incomplete %v", complete, incomplete)
+	}
+	fwds := ForwardDecls([]Type{node, list})
+	if len(fwds) != 2 || fwds[0] != node || fwds[1] != list {
+		t.Errorf("forward declarations mismatch; expected [struct node struct list], got %v", fwds)
+	}
+	if fwds := ForwardDecls([]Type{list}); len(fwds) != 0 {
+		t.Errorf("unexpected forward declarations; got %v", fwds)
+	}
+}
+
+func TestSortDefs(t *testing.T) {
+	// typedef struct point point_t;
+	// struct point { int x, y; };
+	// struct line { point_t a, b; };
+	point := &StructType{Size: 8, Tag: "point"}
+	point.Fields = []Field{
+		{Offset: 0, Var: Var{Type: Int, Name: "x"}},
+		{Offset: 4, Var: Var{Type: Int, Name: "y"}},
+	}
+	pointT := &VarDecl{Class: Typedef, Var: Var{Type: point, Name: "point_t"}}
+	line := &StructType{Size: 16, Tag: "line"}
+	line.Fields = []Field{
+		{Offset: 0, Var: Var{Type: pointT, Name: "a"}},
+		{Offset: 8, Var: Var{Type: pointT, Name: "b"}},
+	}
+	sorted := SortDefs([]Type{line, point, pointT})
+	want := []Type{point, pointT, line}
+	for i := range want {
+		if sorted[i] != want[i] {
+			t.Errorf("type definition %d mismatch; expected %v, got %v", i, want[i], sorted[i])
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package c
+
+// Equal reports whether the given types are structurally identical.
+//
+// Struct, union and enum types are compared by layout and contents; their tags
+// are not compared, as duplicate definitions of the same type are given unique
+// tags. Recursive types are handled by assuming types already under comparison
+// to be identical.
+func Equal(a, b Type) bool {
+	eq := &equaler{
+		seen: make(map[[2]Type]bool),
+	}
+	return eq.equal(a, b)
+}
+
+// equaler tracks the pairs of types under comparison.
+type equaler struct {
+	// seen records the pairs of struct and union types under comparison.
+	seen map[[2]Type]bool
+}
+
+// equal reports whether the given types are structurally identical.
+func (eq *equaler) equal(a, b Type) bool {
+	if a == b {
+		return true
+	}
+	switch a := a.(type) {
+	case BaseType:
+		b, ok := b.(BaseType)
+		return ok && a == b
+	case *StructType:
+		b, ok := b.(*StructType)
+		if !ok || a.Size != b.Size {
+			return false
+		}
+		key := [2]Type{a, b}
+		if eq.seen[key] {
+			return true
+		}
+		eq.seen[key] = true
+		return eq.equalFields(a.Fields, b.Fields)
+	case *UnionType:
+		b, ok := b.(*UnionType)
+		if !ok || a.Size != b.Size {
+			return false
+		}
+		key := [2]Type{a, b}
+		if eq.seen[key] {
+			return true
+		}
+		eq.seen[key] = true
+		return eq.equalFields(a.Fields, b.Fields)
+	case *EnumType:
+		b, ok := b.(*EnumType)
+		if !ok || len(a.Members) != len(b.Members) {
+			return false
+		}
+		for i, am := range a.Members {
+			bm := b.Members[i]
+			if am.Value != bm.Value || am.Name != bm.Name {
+				return false
+			}
+		}
+		return true
+	case *PointerType:
+		b, ok := b.(*PointerType)
+		return ok && eq.equal(a.Elem, b.Elem)
+	case *ArrayType:
+		b, ok := b.(*ArrayType)
+		return ok && a.Len == b.Len && eq.equal(a.Elem, b.Elem)
+	case *QualType:
+		b, ok := b.(*QualType)
+		return ok && a.Quals == b.Quals && eq.equal(a.Type, b.Type)
+	case *FuncType:
+		b, ok := b.(*FuncType)
+		if !ok || a.Variadic != b.Variadic || len(a.Params) != len(b.Params) {
+			return false
+		}
+		if !eq.equal(a.RetType, b.RetType) {
+			return false
+		}
+		// Parameter names are not part of the function type.
+		for i, ap := range a.Params {
+			if !eq.equal(ap.Type, b.Params[i].Type) {
+				return false
+			}
+		}
+		return true
+	case *VarDecl:
+		// Type definition.
+		b, ok := b.(*VarDecl)
+		return ok && a.Class == b.Class && a.Name == b.Name && eq.equal(a.Type, b.Type)
+	}
+	return false
+}
+
+// equalFields reports whether the given struct or union fields are structurally
+// identical.
+func (eq *equaler) equalFields(a, b []Field) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, af := range a {
+		bf := b[i]
+		if af.Offset != bf.Offset || af.Size != bf.Size || af.BitOffset != bf.BitOffset || af.BitWidth != bf.BitWidth || af.Name != bf.Name {
+			return false
+		}
+		if !eq.equal(af.Type, bf.Type) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,258 @@
+package c
+
+import (
+	"fmt"
+)
+
+// Size of pointers in bytes, as specified by the MIPS ABI of the PS1.
+const ptrSize = 4
+
+// Sizeof returns the size in bytes of the given type, as specified by the MIPS
+// ABI of the PS1. The size of structs and unions is computed from the natural
+// layout of their fields; the recorded size is used for structs and unions
+// without fields (e.g. forward declarations). Void and function types have size
+// 0.
+func Sizeof(t Type) uint32 {
+	switch t := t.(type) {
+	case BaseType:
+		return baseSize(t)
+	case *StructType:
+		if len(t.Fields) == 0 {
+			return t.Size
+		}
+		size, align := structLayout(t)
+		return alignUp(size, align)
+	case *UnionType:
+		if len(t.Fields) == 0 {
+			return t.Size
+		}
+		var size uint32
+		for _, field := range t.Fields {
+			if n := Sizeof(field.Type); n > size {
+				size = n
+			}
+		}
+		return alignUp(size, Alignof(t))
+	case *EnumType:
+		return 4
+	case *PointerType:
+		return ptrSize
+	case *ArrayType:
+		return uint32(t.Len) * Sizeof(t.Elem)
+	case *QualType:
+		return Sizeof(t.Type)
+	case *VarDecl:
+		// Type definition.
+		return Sizeof(t.Type)
+	}
+	return 0
+}
+
+// Alignof returns the natural alignment in bytes of the given type, as
+// specified by the MIPS ABI of the PS1.
+func Alignof(t Type) uint32 {
+	switch t := t.(type) {
+	case BaseType:
+		if size := baseSize(t); size > 0 {
+			return size
+		}
+		return 1
+	case *StructType:
+		_, align := structLayout(t)
+		return align
+	case *UnionType:
+		align := uint32(1)
+		for _, field := range t.Fields {
+			if a := Alignof(field.Type); a > align {
+				align = a
+			}
+		}
+		return align
+	case *EnumType:
+		return 4
+	case *PointerType:
+		return ptrSize
+	case *ArrayType:
+		return Alignof(t.Elem)
+	case *QualType:
+		return Alignof(t.Type)
+	case *VarDecl:
+		// Type definition.
+		return Alignof(t.Type)
+	}
+	return 1
+}
+
+// A SizeMismatch is a struct or union type, the recorded size of which differs
+// from its computed size.
+type SizeMismatch struct {
+	// Struct or union type.
+	Type Type
+	// Recorded size in bytes.
+	Size uint32
+	// Computed size in bytes.
+	Computed uint32
+}
+
+// String returns the string representation of the size mismatch.
+func (m *SizeMismatch) String() string {
+	return fmt.Sprintf("size mismatch of %v; recorded 0x%X, computed 0x%X", m.Type, m.Size, m.Computed)
+}
+
+// CheckSizes cross-checks the recorded sizes of the given struct and union
+// types against their computed sizes, and returns the mismatches found. Other
+// types, and struct and union types without recorded size, are ignored.
+func CheckSizes(types []Type) []*SizeMismatch {
+	var mismatches []*SizeMismatch
+	for _, t := range types {
+		var size uint32
+		switch t := t.(type) {
+		case *StructType:
+			size = t.Size
+		case *UnionType:
+			size = t.Size
+		default:
+			continue
+		}
+		if size == 0 {
+			continue
+		}
+		if computed := Sizeof(t); computed != size {
+			m := &SizeMismatch{
+				Type:     t,
+				Size:     size,
+				Computed: computed,
+			}
+			mismatches = append(mismatches, m)
+		}
+	}
+	return mismatches
+}
+
+// A Padding is a gap between the members of a struct, not accounted for by
+// the sizes of its fields.
+type Padding struct {
+	// Offset in bytes of the gap.
+	Offset uint32
+	// Size in bytes of the gap.
+	Size uint32
+}
+
+// Paddings returns the gaps between the recorded field offsets of the struct,
+// and between the end of the last field and the recorded size of the struct.
+// The extent of each field is given by its recorded size, or its computed size
+// if not recorded.
+func (t *StructType) Paddings() []Padding {
+	var pads []Padding
+	// End offset of the preceding fields.
+	var end uint32
+	for _, field := range t.Fields {
+		if field.Offset > end {
+			pads = append(pads, Padding{Offset: end, Size: field.Offset - end})
+		}
+		if fieldEnd := field.Offset + fieldSize(field); fieldEnd > end {
+			end = fieldEnd
+		}
+	}
+	if len(t.Fields) > 0 && t.Size > end {
+		pads = append(pads, Padding{Offset: end, Size: t.Size - end})
+	}
+	return pads
+}
+
+// InsertPadding inserts explicit "char pad_N[x]" members into the gaps between
+// the fields of the struct (see Paddings), so that the struct definition
+// matches the recorded layout byte-for-byte.
+func (t *StructType) InsertPadding() {
+	pads := t.Paddings()
+	if len(pads) == 0 {
+		return
+	}
+	fields := make([]Field, 0, len(t.Fields)+len(pads))
+	i := 0
+	for n, pad := range pads {
+		for ; i < len(t.Fields) && t.Fields[i].Offset < pad.Offset+pad.Size; i++ {
+			fields = append(fields, t.Fields[i])
+		}
+		field := Field{
+			Offset: pad.Offset,
+			Size:   pad.Size,
+			Var: Var{
+				Type: &ArrayType{Elem: Char, Len: int(pad.Size)},
+				Name: fmt.Sprintf("pad_%d", n),
+			},
+		}
+		fields = append(fields, field)
+	}
+	fields = append(fields, t.Fields[i:]...)
+	t.Fields = fields
+}
+
+// ### [ Helper functions ] ####################################################
+
+// baseSize returns the size in bytes of the given base type.
+func baseSize(t BaseType) uint32 {
+	switch t {
+	case Char, UChar, SChar, Bool:
+		return 1
+	case Short, UShort:
+		return 2
+	case Int, UInt, Long, ULong, Float:
+		return 4
+	case LongLong, ULongLong, Double, LongDouble:
+		// Note, long double is equivalent to double on the PS1.
+		return 8
+	}
+	// void
+	return 0
+}
+
+// structLayout returns the unaligned size in bytes and the alignment of the
+// given struct type, as computed by laying out its fields in order with natural
+// alignment. Adjacent bitfields share storage units of their type where they
+// fit.
+func structLayout(t *StructType) (size, align uint32) {
+	align = 1
+	// Offset in bits of the end of the preceding field.
+	var bits uint32
+	for _, field := range t.Fields {
+		fieldSize := Sizeof(field.Type)
+		fieldAlign := Alignof(field.Type)
+		if fieldAlign > align {
+			align = fieldAlign
+		}
+		if field.BitWidth > 0 {
+			// Place bitfield in the current storage unit if it fits; otherwise
+			// start a new storage unit.
+			unitBits := fieldSize * 8
+			if unitBits > 0 && bits/unitBits != (bits+field.BitWidth-1)/unitBits {
+				bits = alignUp(bits, unitBits)
+			}
+			bits += field.BitWidth
+			continue
+		}
+		offset := alignUp(alignUp(bits, 8)/8, fieldAlign)
+		bits = (offset + fieldSize) * 8
+	}
+	return alignUp(bits, 8) / 8, align
+}
+
+// fieldSize returns the size in bytes of the storage occupied by the given
+// struct field, as recorded or otherwise computed.
+func fieldSize(field Field) uint32 {
+	if field.BitWidth > 0 {
+		return (field.BitOffset + field.BitWidth + 7) / 8
+	}
+	if field.Size > 0 {
+		return field.Size
+	}
+	return Sizeof(field.Type)
+}
+
+// alignUp returns x rounded up to the nearest multiple of align.
+func alignUp(x, align uint32) uint32 {
+	if align == 0 {
+		return x
+	}
+	return (x + align - 1) / align * align
+}
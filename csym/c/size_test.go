@@ -0,0 +1,127 @@
+package c
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSizeof(t *testing.T) {
+	// struct s { char a; int b; short c; };
+	s := &StructType{
+		Size: 12,
+		Tag:  "s",
+		Fields: []Field{
+			{Var: Var{Type: Char, Name: "a"}},
+			{Var: Var{Type: Int, Name: "b"}},
+			{Var: Var{Type: Short, Name: "c"}},
+		},
+	}
+	// struct flags { unsigned int a : 3; unsigned int b : 30; char c; };
+	flags := &StructType{
+		Size: 8,
+		Tag:  "flags",
+		Fields: []Field{
+			{BitWidth: 3, Var: Var{Type: UInt, Name: "a"}},
+			{BitWidth: 30, Var: Var{Type: UInt, Name: "b"}},
+			{Var: Var{Type: Char, Name: "c"}},
+		},
+	}
+	// union u { char a[5]; short b; };
+	u := &UnionType{
+		Size: 8,
+		Tag:  "u",
+		Fields: []Field{
+			{Var: Var{Type: &ArrayType{Elem: Char, Len: 5}, Name: "a"}},
+			{Var: Var{Type: Short, Name: "b"}},
+		},
+	}
+	golden := []struct {
+		typ         Type
+		size, align uint32
+	}{
+		{typ: Char, size: 1, align: 1},
+		{typ: Double, size: 8, align: 8},
+		{typ: &PointerType{Elem: Void}, size: 4, align: 4},
+		{typ: &ArrayType{Elem: Short, Len: 3}, size: 6, align: 2},
+		{typ: s, size: 12, align: 4},
+		{typ: flags, size: 12, align: 4},
+		{typ: u, size: 6, align: 2},
+	}
+	for _, g := range golden {
+		if size := Sizeof(g.typ); size != g.size {
+			t.Errorf("size mismatch of %v; expected %d, got %d", g.typ, g.size, size)
+		}
+		if align := Alignof(g.typ); align != g.align {
+			t.Errorf("alignment mismatch of %v; expected %d, got %d", g.typ, g.align, align)
+		}
+	}
+	mismatches := CheckSizes([]Type{s, flags, u})
+	if len(mismatches) != 2 {
+		t.Fatalf("number of size mismatches; expected 2, got %d (%v)", len(mismatches), mismatches)
+	}
+	if m := mismatches[0]; m.Type != flags || m.Computed != 12 {
+		t.Errorf("size mismatch of struct flags; expected computed size 12, got %v", m)
+	}
+}
+
+func TestInsertPadding(t *testing.T) {
+	// struct s { char a; int b; char c; };
+	s := &StructType{
+		Size: 12,
+		Tag:  "s",
+		Fields: []Field{
+			{Offset: 0, Var: Var{Type: Char, Name: "a"}},
+			{Offset: 4, Var: Var{Type: Int, Name: "b"}},
+			{Offset: 8, Var: Var{Type: Char, Name: "c"}},
+		},
+	}
+	pads := s.Paddings()
+	want := []Padding{{Offset: 1, Size: 3}, {Offset: 9, Size: 3}}
+	if len(pads) != len(want) {
+		t.Fatalf("number of paddings; expected %d, got %d (%v)", len(want), len(pads), pads)
+	}
+	for i := range want {
+		if pads[i] != want[i] {
+			t.Errorf("padding %d mismatch; expected %v, got %v", i, want[i], pads[i])
+		}
+	}
+	s.InsertPadding()
+	var names []string
+	for _, field := range s.Fields {
+		names = append(names, field.String())
+	}
+	got := strings.Join(names, "; ")
+	const expected = "char a; char pad_0[3]; int b; char c; char pad_1[3]"
+	if got != expected {
+		t.Errorf("fields mismatch; expected %q, got %q", expected, got)
+	}
+	if pads := s.Paddings(); len(pads) != 0 {
+		t.Errorf("unexpected paddings after insertion; got %v", pads)
+	}
+}
+
+func TestLayoutAsserts(t *testing.T) {
+	s := &StructType{
+		Size: 8,
+		Tag:  "s",
+		Fields: []Field{
+			{Offset: 0, Var: Var{Type: Char, Name: "a"}},
+			{Offset: 0, BitWidth: 3, Var: Var{Type: UInt, Name: "b"}},
+			{Offset: 4, Var: Var{Type: Int, Name: "c"}},
+		},
+	}
+	want := []string{
+		`_Static_assert(sizeof(struct s) == 0x8, "size of struct s");`,
+		`_Static_assert(offsetof(struct s, a) == 0x0, "offset of struct s.a");`,
+		`_Static_assert(offsetof(struct s, c) == 0x4, "offset of struct s.c");`,
+	}
+	got := LayoutAsserts(s)
+	if len(got) != len(want) {
+		t.Fatalf("number of assertions mismatch; expected %d, got %d (%q)", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("assertion %d mismatch; expected %q, got %q", i, want[i], got[i])
+		}
+	}
+}
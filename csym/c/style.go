@@ -0,0 +1,125 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Style specifies the formatting style of the C syntax produced by the
+// DefStyle methods of types and declarations.
+type Style struct {
+	// Indentation of each nesting level; e.g. "\t" or "    ".
+	Indent string
+	// Place opening braces on a separate line (Allman style), rather than at the
+	// end of the line of the declaration (K&R style).
+	BraceNewline bool
+	// Use block comments ("/* */"), rather than line comments ("//").
+	BlockComments bool
+	// Maximum line width of declarations, beyond which the parameters of
+	// function declarations are placed on separate lines; or 0 for unlimited.
+	MaxWidth int
+	// Emit the metadata comments of types, fields and declarations as Doxygen
+	// comment blocks ("/** */"), including source file and overlay information.
+	Doxygen bool
+	// Emit C syntax on a single line, without metadata comments; e.g. for the
+	// type strings of symbol exporters. Function bodies are omitted.
+	Compact bool
+}
+
+// DefaultStyle is the default formatting style; tab indentation, K&R style
+// braces, line comments and unlimited line width.
+var DefaultStyle = Style{
+	Indent: "\t",
+}
+
+// CompactStyle is the single-line formatting style without metadata comments.
+var CompactStyle = Style{
+	Compact: true,
+}
+
+// DeclString returns the C declaration of a variable of the given type and
+// name; or the abstract declaration of the type if name is empty, formatted in
+// the given style.
+func (s Style) DeclString(t Type, name string) string {
+	return s.declString(t, name)
+}
+
+// indent returns the indentation of the given nesting level.
+func (s Style) indent(level int) string {
+	return strings.Repeat(s.Indent, level)
+}
+
+// comment returns a comment of the given formatted text, without trailing
+// newline.
+func (s Style) comment(format string, a ...interface{}) string {
+	text := fmt.Sprintf(format, a...)
+	if s.BlockComments {
+		return fmt.Sprintf("/* %s */", text)
+	}
+	return fmt.Sprintf("// %s", text)
+}
+
+// comments returns the comment lines of the given metadata, each preceded by
+// the given indentation and succeeded by a newline; or a Doxygen comment block
+// of the metadata if enabled.
+func (s Style) comments(indent string, lines []string) string {
+	if len(lines) == 0 || s.Compact {
+		return ""
+	}
+	buf := &strings.Builder{}
+	switch {
+	case !s.Doxygen:
+		for _, line := range lines {
+			fmt.Fprintf(buf, "%s%s\n", indent, s.comment("%s", line))
+		}
+	case len(lines) == 1:
+		fmt.Fprintf(buf, "%s/** %s */\n", indent, lines[0])
+	default:
+		fmt.Fprintf(buf, "%s/**\n", indent)
+		for _, line := range lines {
+			fmt.Fprintf(buf, "%s * %s\n", indent, line)
+		}
+		fmt.Fprintf(buf, "%s */\n", indent)
+	}
+	return buf.String()
+}
+
+// openBrace returns the given declaration head followed by an opening brace and
+// a newline; or a space if compact.
+func (s Style) openBrace(head string) string {
+	switch {
+	case s.Compact:
+		return fmt.Sprintf("%s { ", head)
+	case s.BraceNewline:
+		return fmt.Sprintf("%s\n{\n", head)
+	default:
+		return fmt.Sprintf("%s {\n", head)
+	}
+}
+
+// funcDeclString returns the C declaration of a function of the given type and
+// name, with parameters placed on separate lines if the declaration exceeds the
+// maximum line width.
+func (s Style) funcDeclString(t *FuncType, name string) string {
+	decl := s.declString(t, name)
+	if s.MaxWidth <= 0 || len(decl) <= s.MaxWidth || len(t.Params) == 0 {
+		return decl
+	}
+	buf := &strings.Builder{}
+	buf.WriteString(name)
+	buf.WriteString("(\n")
+	for i, param := range t.Params {
+		buf.WriteString(s.indent(1))
+		buf.WriteString(s.declString(param.Type, param.Name))
+		if i != len(t.Params)-1 || t.Variadic {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	if t.Variadic {
+		buf.WriteString(s.indent(1))
+		buf.WriteString("...\n")
+	}
+	buf.WriteString(")")
+	return s.declString(t.RetType, buf.String())
+}
@@ -11,8 +11,12 @@ import (
 // Type is a C type.
 type Type interface {
 	fmt.Stringer
-	// Def returns the C syntax representation of the definition of the type.
+	// Def returns the C syntax representation of the definition of the type,
+	// formatted in the default style.
 	Def() string
+	// DefStyle returns the C syntax representation of the definition of the
+	// type, formatted in the given style.
+	DefStyle(s Style) string
 }
 
 // --- [ Base type ] -----------------------------------------------------------
@@ -24,15 +28,22 @@ type BaseType uint8
 
 // Base types.
 const (
-	Void   BaseType = iota + 1 // void
-	Char                       // char
-	Short                      // short
-	Int                        // int
-	Long                       // long
-	UChar                      // unsigned char
-	UShort                     // unsigned short
-	UInt                       // unsigned int
-	ULong                      // unsigned long
+	Void       BaseType = iota + 1 // void
+	Char                           // char
+	Short                          // short
+	Int                            // int
+	Long                           // long
+	UChar                          // unsigned char
+	UShort                         // unsigned short
+	UInt                           // unsigned int
+	ULong                          // unsigned long
+	Float                          // float
+	Double                         // double
+	LongDouble                     // long double
+	LongLong                       // long long
+	ULongLong                      // unsigned long long
+	SChar                          // signed char
+	Bool                           // bool
 )
 
 // Def returns the C syntax representation of the definition of the type.
@@ -40,6 +51,12 @@ func (t BaseType) Def() string {
 	return t.String()
 }
 
+// DefStyle returns the C syntax representation of the definition of the type,
+// formatted in the given style.
+func (t BaseType) DefStyle(s Style) string {
+	return t.String()
+}
+
 // --- [ Struct type ] ---------------------------------------------------------
 
 // StructType is a structure type.
@@ -50,8 +67,6 @@ type StructType struct {
 	Tag string
 	// Structure fields.
 	Fields []Field
-	// Struct methods.
-	Methods []Field
 }
 
 // String returns the string representation of the structure type.
@@ -61,35 +76,13 @@ func (t *StructType) String() string {
 
 // Def returns the C syntax representation of the definition of the type.
 func (t *StructType) Def() string {
-	buf := &strings.Builder{}
-	if t.Size > 0 {
-		fmt.Fprintf(buf, "// size: 0x%X\n", t.Size)
-	}
-	if len(t.Tag) > 0 {
-		fmt.Fprintf(buf, "struct %s {\n", t.Tag)
-	} else {
-		buf.WriteString("struct {\n")
-	}
-	for _, field := range t.Fields {
-		if field.Size > 0 {
-			fmt.Fprintf(buf, "\t// offset: %04X (%d bytes)\n", field.Offset, field.Size)
-		} else if len(t.Fields) > 1 && t.Fields[1].Offset > 0 {
-			fmt.Fprintf(buf, "\t// offset: %04X\n", field.Offset)
-		}
-		fmt.Fprintf(buf, "\t%s;\n", field)
-	}
-	// TODO: Figure out how to print methods in a good way; for now, commented
-	// out.
-	for _, method := range t.Methods {
-		if method.Size > 0 {
-			fmt.Fprintf(buf, "\t// offset: %04X (%d bytes)\n", method.Offset, method.Size)
-		} else if len(t.Fields) > 1 && t.Fields[1].Offset > 0 {
-			fmt.Fprintf(buf, "\t// offset: %04X\n", method.Offset)
-		}
-		fmt.Fprintf(buf, "\t// %s;\n", method)
-	}
-	buf.WriteString("}")
-	return buf.String()
+	return t.DefStyle(DefaultStyle)
+}
+
+// DefStyle returns the C syntax representation of the definition of the type,
+// formatted in the given style.
+func (t *StructType) DefStyle(s Style) string {
+	return s.compositeDef("struct", t.Tag, t.Size, t.Fields)
 }
 
 // --- [ Union type ] ---------------------------------------------------------
@@ -111,25 +104,13 @@ func (t *UnionType) String() string {
 
 // Def returns the C syntax representation of the definition of the type.
 func (t *UnionType) Def() string {
-	buf := &strings.Builder{}
-	if t.Size > 0 {
-		fmt.Fprintf(buf, "// size: 0x%X\n", t.Size)
-	}
-	if len(t.Tag) > 0 {
-		fmt.Fprintf(buf, "union %s {\n", t.Tag)
-	} else {
-		buf.WriteString("union {\n")
-	}
-	for _, field := range t.Fields {
-		if field.Size > 0 {
-			fmt.Fprintf(buf, "\t// offset: %04X (%d bytes)\n", field.Offset, field.Size)
-		} else if len(t.Fields) > 1 && t.Fields[1].Offset > 0 {
-			fmt.Fprintf(buf, "\t// offset: %04X\n", field.Offset)
-		}
-		fmt.Fprintf(buf, "\t%s;\n", field)
-	}
-	buf.WriteString("}")
-	return buf.String()
+	return t.DefStyle(DefaultStyle)
+}
+
+// DefStyle returns the C syntax representation of the definition of the type,
+// formatted in the given style.
+func (t *UnionType) DefStyle(s Style) string {
+	return s.compositeDef("union", t.Tag, t.Size, t.Fields)
 }
 
 // --- [ Enum type ] -----------------------------------------------------------
@@ -140,31 +121,57 @@ type EnumType struct {
 	Tag string
 	// Enum members.
 	Members []*EnumMember
+	// Interpret member values as signed integers.
+	Signed bool
+	// Print member values in hexadecimal, rather than decimal.
+	Hex bool
+	// Type definition in which the enum is defined inline (e.g. "typedef enum
+	// {...} Name;"); or nil if defined separately.
+	Typedef *VarDecl
 }
 
 // String returns the string representation of the enum type.
 func (t *EnumType) String() string {
+	if t.Typedef != nil {
+		return t.Typedef.Name
+	}
 	return fmt.Sprintf("enum %s", t.Tag)
 }
 
 // Def returns the C syntax representation of the definition of the type.
 func (t *EnumType) Def() string {
+	return t.DefStyle(DefaultStyle)
+}
+
+// DefStyle returns the C syntax representation of the definition of the type,
+// formatted in the given style.
+func (t *EnumType) DefStyle(s Style) string {
+	return t.def(s, t.Tag)
+}
+
+// def returns the C syntax representation of the definition of the enum, with
+// the given tag (optional), formatted in the given style.
+func (t *EnumType) def(style Style, tag string) string {
 	buf := &strings.Builder{}
-	if len(t.Tag) > 0 {
-		fmt.Fprintf(buf, "enum %s {\n", t.Tag)
+	if len(tag) > 0 {
+		buf.WriteString(style.openBrace(fmt.Sprintf("enum %s", tag)))
 	} else {
-		buf.WriteString("enum {\n")
+		buf.WriteString(style.openBrace("enum"))
 	}
-	less := func(i, j int) bool {
-		if t.Members[i].Value == t.Members[j].Value {
-			return t.Members[i].Name < t.Members[j].Name
+	members := t.SortedMembers()
+	if style.Compact {
+		for i, member := range members {
+			if i != 0 {
+				buf.WriteString(", ")
+			}
+			fmt.Fprintf(buf, "%s = %s", member.Name, t.valueString(member.Value))
 		}
-		return t.Members[i].Value < t.Members[j].Value
+		buf.WriteString(" }")
+		return buf.String()
 	}
-	sort.Slice(t.Members, less)
 	w := tabwriter.NewWriter(buf, 1, 3, 1, ' ', tabwriter.TabIndent)
-	for _, member := range t.Members {
-		fmt.Fprintf(w, "\t%s\t= %d,\n", member.Name, member.Value)
+	for _, member := range members {
+		fmt.Fprintf(w, "%s%s\t= %s,\n", style.indent(1), member.Name, t.valueString(member.Value))
 	}
 	if err := w.Flush(); err != nil {
 		panic(fmt.Errorf("unable to flush tabwriter; %v", err))
@@ -173,6 +180,48 @@ func (t *EnumType) Def() string {
 	return buf.String()
 }
 
+// SortedMembers returns the members of the enum sorted by value, and by name
+// for members of equal value; the order of the C definition of the enum.
+func (t *EnumType) SortedMembers() []*EnumMember {
+	members := make([]*EnumMember, len(t.Members))
+	copy(members, t.Members)
+	less := func(i, j int) bool {
+		a, b := members[i], members[j]
+		if a.Value == b.Value {
+			return a.Name < b.Name
+		}
+		return t.Value(a) < t.Value(b)
+	}
+	sort.Slice(members, less)
+	return members
+}
+
+// Value returns the value of the given enum member, interpreted as a signed
+// integer if the enum is signed.
+func (t *EnumType) Value(member *EnumMember) int64 {
+	if t.Signed {
+		return int64(int32(member.Value))
+	}
+	return int64(member.Value)
+}
+
+// valueString returns the string representation of the given enum member
+// value, as formatted by the enum.
+func (t *EnumType) valueString(v uint32) string {
+	x := t.Value(&EnumMember{Value: v})
+	switch {
+	case x < 0:
+		if t.Hex {
+			return fmt.Sprintf("-0x%X", -x)
+		}
+		return strconv.FormatInt(x, 10)
+	case t.Hex:
+		return fmt.Sprintf("0x%X", x)
+	default:
+		return strconv.FormatInt(x, 10)
+	}
+}
+
 // ~~~ [ Enum member ] ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 
 // EnumMember is an enum member.
@@ -193,7 +242,7 @@ type PointerType struct {
 
 // String returns the string representation of the pointer type.
 func (t *PointerType) String() string {
-	return fmt.Sprintf("%s*", t.Elem)
+	return DefaultStyle.declString(t, "")
 }
 
 // Def returns the C syntax representation of the definition of the type.
@@ -201,6 +250,12 @@ func (t *PointerType) Def() string {
 	return t.String()
 }
 
+// DefStyle returns the C syntax representation of the definition of the type,
+// formatted in the given style.
+func (t *PointerType) DefStyle(s Style) string {
+	return s.declString(t, "")
+}
+
 // --- [ Array type ] ----------------------------------------------------------
 
 // ArrayType is an array type.
@@ -213,10 +268,7 @@ type ArrayType struct {
 
 // String returns the string representation of the array type.
 func (t *ArrayType) String() string {
-	if t.Len > 0 {
-		return fmt.Sprintf("%s[%d]", t.Elem, t.Len)
-	}
-	return fmt.Sprintf("%s[]", t.Elem)
+	return DefaultStyle.declString(t, "")
 }
 
 // Def returns the C syntax representation of the definition of the type.
@@ -224,6 +276,59 @@ func (t *ArrayType) Def() string {
 	return t.String()
 }
 
+// DefStyle returns the C syntax representation of the definition of the type,
+// formatted in the given style.
+func (t *ArrayType) DefStyle(s Style) string {
+	return s.declString(t, "")
+}
+
+// --- [ Qualified type ] ------------------------------------------------------
+
+// Qualifier is a set of type qualifiers.
+type Qualifier uint8
+
+// Type qualifiers.
+const (
+	Const    Qualifier = 1 << iota // const
+	Volatile                       // volatile
+)
+
+// String returns the string representation of the type qualifiers.
+func (q Qualifier) String() string {
+	var quals []string
+	if q&Const != 0 {
+		quals = append(quals, "const")
+	}
+	if q&Volatile != 0 {
+		quals = append(quals, "volatile")
+	}
+	return strings.Join(quals, " ")
+}
+
+// QualType is a qualified type.
+type QualType struct {
+	// Type qualifiers.
+	Quals Qualifier
+	// Underlying type.
+	Type Type
+}
+
+// String returns the string representation of the qualified type.
+func (t *QualType) String() string {
+	return DefaultStyle.declString(t, "")
+}
+
+// Def returns the C syntax representation of the definition of the type.
+func (t *QualType) Def() string {
+	return t.String()
+}
+
+// DefStyle returns the C syntax representation of the definition of the type,
+// formatted in the given style.
+func (t *QualType) DefStyle(s Style) string {
+	return s.declString(t, "")
+}
+
 // --- [ Function type ] -------------------------------------------------------
 
 // FuncType is a function type.
@@ -238,9 +343,7 @@ type FuncType struct {
 
 // String returns the string representation of the function type.
 func (t *FuncType) String() string {
-	// HACK, but works. The syntax of the C type system is pre-historic.
-	v := Var{Type: t}
-	return v.String()
+	return DefaultStyle.declString(t, "")
 }
 
 // Def returns the C syntax representation of the definition of the type.
@@ -248,6 +351,12 @@ func (t *FuncType) Def() string {
 	return t.String()
 }
 
+// DefStyle returns the C syntax representation of the definition of the type,
+// formatted in the given style.
+func (t *FuncType) DefStyle(s Style) string {
+	return s.declString(t, "")
+}
+
 // ### [ Helper types ] ########################################################
 
 // A Field represents a field in a structure type or union type.
@@ -256,10 +365,19 @@ type Field struct {
 	Offset uint32
 	// Size in bytes (optional).
 	Size uint32
+	// Bit offset of bitfield, relative to Offset (optional).
+	BitOffset uint32
+	// Width in bits of bitfield; or 0 if not a bitfield.
+	BitWidth uint32
 	// Underlying variable.
 	Var
 }
 
+// String returns the string representation of the field.
+func (f Field) String() string {
+	return DefaultStyle.fieldString(f)
+}
+
 // A Var represents a variable declaration or function parameter.
 type Var struct {
 	// Variable type.
@@ -270,80 +388,175 @@ type Var struct {
 
 // String returns the string representation of the variable.
 func (v Var) String() string {
-	switch t := v.Type.(type) {
-	case *PointerType:
-		// HACK, but works. The syntax of the C type system is pre-historic.
-		switch t.Elem.(type) {
-		case *FuncType, *ArrayType:
-			// Add grouping parenthesis.
-			v.Name = fmt.Sprintf("(*%s)", v.Name)
+	return DefaultStyle.declString(v.Type, v.Name)
+}
+
+// fieldString returns the C declaration of the given field, formatted in the
+// given style.
+func (s Style) fieldString(f Field) string {
+	decl := s.declString(f.Type, f.Name)
+	if f.BitWidth > 0 {
+		return fmt.Sprintf("%s : %d", decl, f.BitWidth)
+	}
+	return decl
+}
+
+// declString returns the C declaration of a variable of the given type and
+// name; or the abstract declaration of the type if name is empty.
+//
+// The declarator is constructed inside-out, starting at the variable name and
+// wrapping derived types (pointers, arrays and functions) until reaching the
+// type specifier. Declarators of pointers are enclosed in parenthesis when
+// derived to arrays or functions, as `[]` and `()` bind tighter than `*`.
+// Anonymous structs and unions defined inline are formatted in the given style.
+func (s Style) declString(t Type, name string) string {
+	decl := name
+	// Qualifiers of the type specifier.
+	var quals Qualifier
+	// Specifies whether the type specifier is referenced indirectly (through
+	// pointers or functions).
+	indirect := false
+	for {
+		switch tt := t.(type) {
+		case *PointerType:
+			decl = "*" + decl
+			t = tt.Elem
+			indirect = true
+		case *QualType:
+			elem, ok := tt.Type.(*PointerType)
+			if !ok {
+				// Qualifiers precede the type specifier; e.g. `const int x`, which
+				// also applies to the element type of arrays; e.g. `const int x[2]`.
+				quals |= tt.Quals
+				t = tt.Type
+				continue
+			}
+			// Qualifiers of pointers succeed the asterisk; e.g. `int *const p`.
+			if len(decl) > 0 {
+				decl = " " + decl
+			}
+			decl = fmt.Sprintf("*%s%s", tt.Quals, decl)
+			t = elem.Elem
+			indirect = true
+		case *ArrayType:
+			if strings.HasPrefix(decl, "*") {
+				decl = fmt.Sprintf("(%s)", decl)
+			}
+			if tt.Len > 0 {
+				decl = fmt.Sprintf("%s[%d]", decl, tt.Len)
+			} else {
+				decl = fmt.Sprintf("%s[]", decl)
+			}
+			t = tt.Elem
+		case *FuncType:
+			if strings.HasPrefix(decl, "*") {
+				decl = fmt.Sprintf("(%s)", decl)
+			}
+			decl += s.paramsString(tt)
+			t = tt.RetType
+			indirect = true
 		default:
-			v.Name = fmt.Sprintf("*%s", v.Name)
-		}
-		v.Type = t.Elem
-		return v.String()
-	case *ArrayType:
-		// HACK, but works. The syntax of the C type system is pre-historic.
-		if t.Len > 0 {
-			v.Name = fmt.Sprintf("%s[%d]", v.Name, t.Len)
-		} else {
-			v.Name = fmt.Sprintf("%s[]", v.Name)
-		}
-		v.Type = t.Elem
-		return v.String()
-	case *FuncType:
-		// HACK, but works. The syntax of the C type system is pre-historic.
-		buf := &strings.Builder{}
-		fmt.Fprintf(buf, "%s(", v.Name)
-		for i, param := range t.Params {
-			if i != 0 {
-				buf.WriteString(", ")
+			spec := s.specString(t, !indirect)
+			if quals != 0 {
+				spec = fmt.Sprintf("%s %s", quals, spec)
 			}
-			buf.WriteString(param.Var.String())
-		}
-		if t.Variadic {
-			if len(t.Params) > 0 {
-				buf.WriteString(", ")
+			if len(decl) == 0 {
+				return spec
 			}
-			buf.WriteString("...")
+			return fmt.Sprintf("%s %s", spec, decl)
+		}
+	}
+}
+
+// specString returns the string representation of the given type specifier.
+// Anonymous structs and unions (with fake tags) are defined inline if the inline
+// parameter is set.
+func (s Style) specString(t Type, inline bool) string {
+	if !inline {
+		return fmt.Sprintf("%s", t)
+	}
+	switch t := t.(type) {
+	case *StructType:
+		if IsFakeTag(t.Tag) {
+			// Metadata comments of inline definitions would end up in the middle
+			// of the declaration; omit size.
+			return s.compositeDef("struct", "", 0, t.Fields)
 		}
-		buf.WriteString(")")
-		v.Name = buf.String()
-		v.Type = t.RetType
-		return v.String()
 	case *UnionType:
-		if isFakeTag(t.Tag) {
-			return fmt.Sprintf("%s %s", fakeUnionString(t), v.Name)
+		if IsFakeTag(t.Tag) {
+			return s.compositeDef("union", "", 0, t.Fields)
 		}
-		return fmt.Sprintf("%s %s", t, v.Name)
-	default:
-		return fmt.Sprintf("%s %s", t, v.Name)
 	}
+	return fmt.Sprintf("%s", t)
 }
 
-// fakeUnionString returns the string representation of the given union with a
-// fake name.
-func fakeUnionString(t *UnionType) string {
+// paramsString returns the string representation of the parameter list of the
+// given function type.
+func (s Style) paramsString(t *FuncType) string {
 	buf := &strings.Builder{}
-	if t.Size > 0 {
-		fmt.Fprintf(buf, "// size: 0x%X\n", t.Size)
+	buf.WriteString("(")
+	for i, param := range t.Params {
+		if i != 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(s.declString(param.Type, param.Name))
 	}
-	buf.WriteString("\tunion {\n")
-	for _, field := range t.Fields {
-		if field.Size > 0 {
-			fmt.Fprintf(buf, "\t\t// offset: %04X (%d bytes)\n", field.Offset, field.Size)
-		} else if len(t.Fields) > 1 && t.Fields[1].Offset > 0 {
-			fmt.Fprintf(buf, "\t\t// offset: %04X\n", field.Offset)
+	if t.Variadic {
+		if len(t.Params) > 0 {
+			buf.WriteString(", ")
 		}
-		fmt.Fprintf(buf, "\t\t%s;\n", field)
+		buf.WriteString("...")
 	}
-	buf.WriteString("\t}")
+	buf.WriteString(")")
+	return buf.String()
+}
+
+// compositeDef returns the C syntax representation of the definition of a
+// struct or union with the given keyword ("struct" or "union"), tag (optional),
+// size in bytes (optional; omitted for inline definitions) and fields.
+//
+// Fields of anonymous struct and union types are defined inline, and indented
+// by nesting depth, formatted in the given style.
+func (s Style) compositeDef(keyword, tag string, size uint32, fields []Field) string {
+	buf := &strings.Builder{}
+	if size > 0 {
+		buf.WriteString(s.comments("", []string{fmt.Sprintf("size: 0x%X", size)}))
+	}
+	if len(tag) > 0 {
+		buf.WriteString(s.openBrace(fmt.Sprintf("%s %s", keyword, tag)))
+	} else {
+		buf.WriteString(s.openBrace(keyword))
+	}
+	if s.Compact {
+		for _, field := range fields {
+			fmt.Fprintf(buf, "%s; ", s.fieldString(field))
+		}
+		buf.WriteString("}")
+		return buf.String()
+	}
+	indent := s.indent(1)
+	for _, field := range fields {
+		var meta []string
+		switch {
+		case field.BitWidth > 0:
+			meta = append(meta, fmt.Sprintf("offset: %04X.%d (%d bits)", field.Offset, field.BitOffset, field.BitWidth))
+		case field.Size > 0:
+			meta = append(meta, fmt.Sprintf("offset: %04X (%d bytes)", field.Offset, field.Size))
+		case len(fields) > 1 && fields[1].Offset > 0:
+			meta = append(meta, fmt.Sprintf("offset: %04X", field.Offset))
+		}
+		buf.WriteString(s.comments(indent, meta))
+		// Indent inline definitions of anonymous structs and unions.
+		f := strings.Replace(s.fieldString(field), "\n", "\n"+indent, -1)
+		fmt.Fprintf(buf, "%s%s;\n", indent, f)
+	}
+	buf.WriteString("}")
 	return buf.String()
 }
 
-// isFakeTag reports whether the tag name is fake (generated by the compiler for
+// IsFakeTag reports whether the tag name is fake (generated by the compiler for
 // symbols lacking a tag name).
-func isFakeTag(tag string) bool {
+func IsFakeTag(tag string) bool {
 	if strings.HasPrefix(tag, "_") && strings.HasSuffix(tag, "fake") {
 		s := tag[len("_") : len(tag)-len("fake")]
 		_, err := strconv.Atoi(s)
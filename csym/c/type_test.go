@@ -0,0 +1,291 @@
+package c
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestVarString(t *testing.T) {
+	golden := []struct {
+		typ  Type
+		want string
+	}{
+		// Pointer to const.
+		{typ: &PointerType{Elem: &QualType{Quals: Const, Type: Char}}, want: "const char *x"},
+		// Const pointer.
+		{typ: &QualType{Quals: Const, Type: &PointerType{Elem: Char}}, want: "char *const x"},
+		// Array of volatile elements.
+		{typ: &QualType{Quals: Volatile, Type: &ArrayType{Elem: Int, Len: 2}}, want: "volatile int x[2]"},
+		// Qualified pointer to function.
+		{typ: &QualType{Quals: Const | Volatile, Type: &PointerType{Elem: &FuncType{RetType: Int}}}, want: "int (*const volatile x)()"},
+		// Pointer to array.
+		{typ: &PointerType{Elem: &ArrayType{Elem: Int, Len: 3}}, want: "int (*x)[3]"},
+		// Array of function pointers.
+		{typ: &ArrayType{Elem: &PointerType{Elem: &FuncType{RetType: Int}}, Len: 4}, want: "int (*x[4])()"},
+		// Function returning pointer to array.
+		{typ: &FuncType{RetType: &PointerType{Elem: &ArrayType{Elem: Int, Len: 3}}}, want: "int (*x())[3]"},
+		// Pointer to pointer to function taking a function pointer.
+		{typ: &PointerType{Elem: &PointerType{Elem: &FuncType{RetType: Void, Params: []*VarDecl{{Var: Var{Type: &PointerType{Elem: &FuncType{RetType: Int}}, Name: "f"}}}}}}, want: "void (**x)(int (*f)())"},
+	}
+	for _, g := range golden {
+		v := Var{Type: g.typ, Name: "x"}
+		if got := v.String(); got != g.want {
+			t.Errorf("variable string mismatch; expected %q, got %q", g.want, got)
+		}
+	}
+}
+
+func TestTypeString(t *testing.T) {
+	golden := []struct {
+		typ  Type
+		want string
+	}{
+		{typ: &PointerType{Elem: &FuncType{RetType: Int}}, want: "int (*)()"},
+		{typ: &ArrayType{Elem: &PointerType{Elem: Char}, Len: 2}, want: "char *[2]"},
+		{typ: &QualType{Quals: Const, Type: &PointerType{Elem: Char}}, want: "char *const"},
+	}
+	for _, g := range golden {
+		if got := g.typ.String(); got != g.want {
+			t.Errorf("type string mismatch; expected %q, got %q", g.want, got)
+		}
+	}
+}
+
+func TestStyle(t *testing.T) {
+	style := Style{
+		Indent:        "  ",
+		BraceNewline:  true,
+		BlockComments: true,
+		MaxWidth:      20,
+	}
+	s := &StructType{
+		Size: 4,
+		Tag:  "s",
+		Fields: []Field{
+			{Offset: 0, Size: 4, Var: Var{Type: Int, Name: "x"}},
+		},
+	}
+	const wantStruct = "/* size: 0x4 */\nstruct s\n{\n  /* offset: 0000 (4 bytes) */\n  int x;\n}"
+	if got := s.DefStyle(style); got != wantStruct {
+		t.Errorf("struct definition mismatch; expected %q, got %q", wantStruct, got)
+	}
+	f := &VarDecl{
+		Class: Extern,
+		Var: Var{
+			Type: &FuncType{
+				RetType: Int,
+				Params: []*VarDecl{
+					{Var: Var{Type: Int, Name: "width"}},
+					{Var: Var{Type: Int, Name: "height"}},
+				},
+			},
+			Name: "area",
+		},
+	}
+	const wantFunc = "extern int area(\n  int width,\n  int height\n)"
+	if got := f.DefStyle(style); got != wantFunc {
+		t.Errorf("function declaration mismatch; expected %q, got %q", wantFunc, got)
+	}
+}
+
+func TestEnumDef(t *testing.T) {
+	e := &EnumType{
+		Tag: "_0fake",
+		Members: []*EnumMember{
+			{Value: 1, Name: "B"},
+			{Value: 0xFFFFFFFF, Name: "A"},
+		},
+		Signed: true,
+	}
+	const want = "enum _0fake {\n\tA = -1,\n\tB = 1,\n}"
+	if got := e.Def(); got != want {
+		t.Errorf("enum definition mismatch; expected %q, got %q", want, got)
+	}
+	e.Hex = true
+	def := &VarDecl{Class: Typedef, Var: Var{Type: e, Name: "Name"}}
+	e.Typedef = def
+	const wantTypedef = "typedef enum {\n\tA = -0x1,\n\tB = 0x1,\n} Name"
+	if got := def.Def(); got != wantTypedef {
+		t.Errorf("type definition mismatch; expected %q, got %q", wantTypedef, got)
+	}
+	if got := (Var{Type: e, Name: "x"}).String(); got != "Name x" {
+		t.Errorf("enum reference mismatch; expected %q, got %q", "Name x", got)
+	}
+}
+
+func TestEnumSortedMembers(t *testing.T) {
+	e := &EnumType{
+		Tag:    "e",
+		Signed: true,
+		Members: []*EnumMember{
+			{Name: "C", Value: 1},
+			{Name: "A", Value: 0xFFFFFFFF},
+			{Name: "B", Value: 1},
+		},
+	}
+	var got []string
+	for _, member := range e.SortedMembers() {
+		got = append(got, fmt.Sprintf("%s=%d", member.Name, e.Value(member)))
+	}
+	if want := "A=-1 B=1 C=1"; strings.Join(got, " ") != want {
+		t.Errorf("sorted enum members mismatch; expected %q, got %q", want, strings.Join(got, " "))
+	}
+	// The C definition does not reorder the members of the enum.
+	e.Def()
+	if e.Members[0].Name != "C" {
+		t.Errorf("enum members reordered by definition; got %q first", e.Members[0].Name)
+	}
+}
+
+func TestNestedAnonDef(t *testing.T) {
+	// struct s { union { struct { int a; int b; } ab; int c; } u; struct s *next; };
+	inner := &StructType{
+		Size: 8,
+		Tag:  "_1fake",
+		Fields: []Field{
+			{Offset: 0, Var: Var{Type: Int, Name: "a"}},
+			{Offset: 4, Var: Var{Type: Int, Name: "b"}},
+		},
+	}
+	u := &UnionType{
+		Size: 8,
+		Tag:  "_0fake",
+		Fields: []Field{
+			{Offset: 0, Var: Var{Type: inner, Name: "ab"}},
+			{Offset: 0, Var: Var{Type: Int, Name: "c"}},
+		},
+	}
+	s := &StructType{
+		Tag: "s",
+		Fields: []Field{
+			{Offset: 0, Var: Var{Type: u, Name: "u"}},
+			{Offset: 8, Var: Var{Type: &PointerType{Elem: inner}, Name: "p"}},
+		},
+	}
+	const want = `struct s {
+	// offset: 0000
+	union {
+		struct {
+			// offset: 0000
+			int a;
+			// offset: 0004
+			int b;
+		} ab;
+		int c;
+	} u;
+	// offset: 0008
+	struct _1fake *p;
+}`
+	if got := s.Def(); got != want {
+		t.Errorf("struct definition mismatch; expected %q, got %q", want, got)
+	}
+	// Type definition of anonymous struct.
+	def := &VarDecl{Class: Typedef, Var: Var{Type: inner, Name: "ab_t"}}
+	const wantTypedef = "typedef struct {\n\t// offset: 0000\n\tint a;\n\t// offset: 0004\n\tint b;\n} ab_t"
+	if got := def.Def(); got != wantTypedef {
+		t.Errorf("type definition mismatch; expected %q, got %q", wantTypedef, got)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	inner := &StructType{
+		Size: 8,
+		Tag:  "_1fake",
+		Fields: []Field{
+			{Offset: 0, Size: 4, Var: Var{Type: Int, Name: "a"}},
+			{Offset: 4, Size: 4, Var: Var{Type: Int, Name: "b"}},
+		},
+	}
+	s := &StructType{
+		Size: 12,
+		Tag:  "s",
+		Fields: []Field{
+			{Offset: 0, Size: 8, Var: Var{Type: inner, Name: "ab"}},
+			{Offset: 8, BitOffset: 0, BitWidth: 3, Var: Var{Type: UInt, Name: "flags"}},
+		},
+	}
+	e := &EnumType{
+		Tag:    "e",
+		Signed: true,
+		Members: []*EnumMember{
+			{Name: "B", Value: 1},
+			{Name: "A", Value: 0xFFFFFFFF},
+		},
+	}
+	golden := []struct {
+		got  string
+		want string
+	}{
+		{got: s.DefStyle(CompactStyle), want: "struct s { struct { int a; int b; } ab; unsigned int flags : 3; }"},
+		{got: e.DefStyle(CompactStyle), want: "enum e { A = -1, B = 1 }"},
+		{got: CompactStyle.DeclString(&ArrayType{Elem: inner, Len: 4}, ""), want: "struct { int a; int b; } [4]"},
+		{got: (&VarDecl{Class: Typedef, Addr: 0x80010000, Var: Var{Type: inner, Name: "ab_t"}}).DefStyle(CompactStyle), want: "typedef struct { int a; int b; } ab_t"},
+	}
+	for _, g := range golden {
+		if g.got != g.want {
+			t.Errorf("compact C syntax mismatch; expected %q, got %q", g.want, g.got)
+		}
+	}
+}
+
+func TestFuncDeclClass(t *testing.T) {
+	newFunc := func(class StorageClass, locals ...*VarDecl) *FuncDecl {
+		f := &FuncDecl{
+			Class: class,
+			Var:   Var{Type: &FuncType{RetType: Int}, Name: "f"},
+		}
+		if len(locals) > 0 {
+			f.Blocks = []*Block{{Locals: locals}}
+		}
+		return f
+	}
+	local := func(class StorageClass, name string) *VarDecl {
+		return &VarDecl{Class: class, Var: Var{Type: Int, Name: name}}
+	}
+	golden := []struct {
+		f    *FuncDecl
+		want string
+	}{
+		// Storage class of prototypes.
+		{f: newFunc(Extern), want: "extern int f();"},
+		{f: newFunc(Static), want: "static int f();"},
+		// Function definitions are only qualified by static; automatic local
+		// variables are not qualified.
+		{f: newFunc(Extern, local(Auto, "x"), local(Register, "y")), want: "int f() {\n\tint x;\n\tregister int y;\n}\n"},
+		{f: newFunc(Static, local(Static, "x")), want: "static int f() {\n\tstatic int x;\n}\n"},
+	}
+	for _, g := range golden {
+		// Strip line comments.
+		var lines []string
+		for _, line := range strings.Split(g.f.Def(), "\n") {
+			if !strings.HasPrefix(strings.TrimSpace(line), "//") {
+				lines = append(lines, line)
+			}
+		}
+		if got := strings.Join(lines, "\n"); got != g.want {
+			t.Errorf("function definition mismatch; expected %q, got %q", g.want, got)
+		}
+	}
+}
+
+func TestDoxygen(t *testing.T) {
+	style := DefaultStyle
+	style.Doxygen = true
+	f := &FuncDecl{
+		Path:      "main.c",
+		Addr:      0x80010000,
+		Size:      0x20,
+		Overlay:   2,
+		LineStart: 10,
+		LineEnd:   20,
+		Var: Var{
+			Type: &FuncType{RetType: Void},
+			Name: "f",
+		},
+	}
+	const want = "/**\n * address: 0x80010000\n * size: 0x20\n * source file: main.c\n * overlay: 2\n * line start: 10\n * line end:   20\n */\nvoid f();"
+	if got := f.DefStyle(style); got != want {
+		t.Errorf("function definition mismatch; expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,137 @@
+package c
+
+// Walk traverses the type graph rooted at the given type in depth-first order,
+// calling visit for each type. If visit returns false, the types referenced by
+// the visited type are not traversed.
+//
+// Struct, union and type definitions are traversed at most once, and recursive
+// types are thus handled.
+func Walk(t Type, visit func(t Type) bool) {
+	w := &walker{
+		visited: make(map[Type]bool),
+	}
+	w.walk(t, visit)
+}
+
+// walker tracks the state of a type graph traversal.
+type walker struct {
+	// visited records the struct, union and type definitions traversed so far.
+	visited map[Type]bool
+}
+
+// walk traverses the type graph rooted at the given type.
+func (w *walker) walk(t Type, visit func(t Type) bool) {
+	if t == nil || !visit(t) {
+		return
+	}
+	switch t := t.(type) {
+	case *StructType:
+		if w.visited[t] {
+			return
+		}
+		w.visited[t] = true
+		for _, field := range t.Fields {
+			w.walk(field.Type, visit)
+		}
+	case *UnionType:
+		if w.visited[t] {
+			return
+		}
+		w.visited[t] = true
+		for _, field := range t.Fields {
+			w.walk(field.Type, visit)
+		}
+	case *VarDecl:
+		// Type definition.
+		if w.visited[t] {
+			return
+		}
+		w.visited[t] = true
+		w.walk(t.Type, visit)
+	case *PointerType:
+		w.walk(t.Elem, visit)
+	case *ArrayType:
+		w.walk(t.Elem, visit)
+	case *QualType:
+		w.walk(t.Type, visit)
+	case *FuncType:
+		w.walk(t.RetType, visit)
+		for _, param := range t.Params {
+			w.walk(param.Type, visit)
+		}
+	}
+}
+
+// Rewrite rewrites the type graph rooted at the given type in depth-first
+// order, and returns the rewritten root type. The rewrite function f is called
+// for each type, and returns the replacement of the type (or the type itself)
+// and whether to traverse the types referenced by the replacement.
+//
+// References of derived types (pointers, arrays, qualified types and
+// functions), struct and union fields, and type definitions are updated in
+// place. Struct, union and type definitions are traversed at most once, and
+// recursive types are thus handled.
+func Rewrite(t Type, f func(t Type) (Type, bool)) Type {
+	r := &rewriter{
+		f:       f,
+		visited: make(map[Type]bool),
+	}
+	return r.rewrite(t)
+}
+
+// rewriter tracks the state of a type graph rewrite.
+type rewriter struct {
+	// Rewrite function.
+	f func(t Type) (Type, bool)
+	// visited records the struct, union and type definitions traversed so far.
+	visited map[Type]bool
+}
+
+// rewrite rewrites the type graph rooted at the given type, and returns the
+// rewritten root type.
+func (r *rewriter) rewrite(t Type) Type {
+	if t == nil {
+		return nil
+	}
+	t, ok := r.f(t)
+	if !ok {
+		return t
+	}
+	switch t := t.(type) {
+	case *StructType:
+		if !r.visited[t] {
+			r.visited[t] = true
+			r.rewriteFields(t.Fields)
+		}
+	case *UnionType:
+		if !r.visited[t] {
+			r.visited[t] = true
+			r.rewriteFields(t.Fields)
+		}
+	case *VarDecl:
+		// Type definition.
+		if !r.visited[t] {
+			r.visited[t] = true
+			t.Type = r.rewrite(t.Type)
+		}
+	case *PointerType:
+		t.Elem = r.rewrite(t.Elem)
+	case *ArrayType:
+		t.Elem = r.rewrite(t.Elem)
+	case *QualType:
+		t.Type = r.rewrite(t.Type)
+	case *FuncType:
+		t.RetType = r.rewrite(t.RetType)
+		for _, param := range t.Params {
+			param.Type = r.rewrite(param.Type)
+		}
+	}
+	return t
+}
+
+// rewriteFields rewrites the types of the given struct or union fields.
+func (r *rewriter) rewriteFields(fields []Field) {
+	for i := range fields {
+		fields[i].Type = r.rewrite(fields[i].Type)
+	}
+}
@@ -0,0 +1,33 @@
+package c
+
+import "testing"
+
+func TestWalkRewrite(t *testing.T) {
+	uchar := &VarDecl{Class: Typedef, Var: Var{Type: UChar, Name: "u_char"}}
+	uint8 := &VarDecl{Class: Typedef, Var: Var{Type: UChar, Name: "uint8_t"}}
+	// struct node { struct node *next; u_char data[4]; };
+	node := &StructType{Size: 8, Tag: "node"}
+	node.Fields = []Field{
+		{Offset: 0, Var: Var{Type: &PointerType{Elem: node}, Name: "next"}},
+		{Offset: 4, Var: Var{Type: &ArrayType{Elem: uchar, Len: 4}, Name: "data"}},
+	}
+	var n int
+	Walk(node, func(t Type) bool {
+		n++
+		return true
+	})
+	// node, *node, node (not traversed again), [4]u_char, u_char, unsigned char
+	if n != 6 {
+		t.Errorf("number of visited types mismatch; expected 6, got %d", n)
+	}
+	Rewrite(node, func(t Type) (Type, bool) {
+		if t == uchar {
+			return uint8, false
+		}
+		return t, true
+	})
+	const want = "uint8_t data[4]"
+	if got := node.Fields[1].String(); got != want {
+		t.Errorf("field mismatch; expected %q, got %q", want, got)
+	}
+}
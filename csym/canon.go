@@ -0,0 +1,109 @@
+package csym
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// Canonicalize merges duplicate struct, union and enum definitions which are
+// structurally identical to a preceding definition of the same tag (see
+// c.Equal); as is common for types declared in headers included by several
+// translation units. The tags of merged definitions are removed from the tags
+// of the parser, and map to the canonical definition.
+//
+// Note, type references always resolve to the first definition of a tag, and
+// are thus unaffected by merging.
+func (p *Parser) Canonicalize() {
+	p.StructTags = canonTags(p.StructTags, func(tag string) (c.Type, bool) {
+		t, ok := p.Structs[tag]
+		return t, ok
+	}, c.Equal, func(tag string, canon c.Type) {
+		p.Structs[tag] = canon.(*c.StructType)
+	})
+	p.UnionTags = canonTags(p.UnionTags, func(tag string) (c.Type, bool) {
+		t, ok := p.Unions[tag]
+		return t, ok
+	}, c.Equal, func(tag string, canon c.Type) {
+		p.Unions[tag] = canon.(*c.UnionType)
+	})
+	p.EnumTags = canonTags(p.EnumTags, func(tag string) (c.Type, bool) {
+		t, ok := p.Enums[tag]
+		return t, ok
+	}, enumEqual, func(tag string, canon c.Type) {
+		p.Enums[tag] = canon.(*c.EnumType)
+	})
+}
+
+// canonTags merges the duplicate definitions of the given tags, and returns the
+// tags of the canonical definitions in order of occurrence. The lookup function
+// returns the definition of a tag, and the merge function maps the tag of a
+// duplicate definition to its canonical definition.
+func canonTags(tags []string, lookup func(tag string) (c.Type, bool), equal func(a, b c.Type) bool, merge func(tag string, canon c.Type)) []string {
+	merged := make(map[string]bool)
+	for _, tag := range tags {
+		if merged[tag] {
+			continue
+		}
+		// Canonical definitions of the tag.
+		t, ok := lookup(tag)
+		if !ok {
+			continue
+		}
+		canons := []c.Type{t}
+		for i := 0; ; i++ {
+			dupTag := fmt.Sprintf(duplicateTagFormat, tag, i)
+			dup, ok := lookup(dupTag)
+			if !ok {
+				break
+			}
+			for _, canon := range canons {
+				if equal(canon, dup) {
+					merge(dupTag, canon)
+					merged[dupTag] = true
+					break
+				}
+			}
+			if !merged[dupTag] {
+				canons = append(canons, dup)
+			}
+		}
+	}
+	var dst []string
+	for _, tag := range tags {
+		if !merged[tag] {
+			dst = append(dst, tag)
+		}
+	}
+	return dst
+}
+
+// enumEqual reports whether the given enums are structurally identical, taking
+// into account the unique names given to members of duplicate enums.
+func enumEqual(a, b c.Type) bool {
+	x, y := a.(*c.EnumType), b.(*c.EnumType)
+	if len(x.Members) != len(y.Members) {
+		return false
+	}
+	for i, xm := range x.Members {
+		ym := y.Members[i]
+		if xm.Value != ym.Value || trimDuplicateEnum(xm.Name) != trimDuplicateEnum(ym.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// trimDuplicateEnum returns the given enum member name without the suffix of
+// duplicate enum members (see uniqueEnum).
+func trimDuplicateEnum(name string) string {
+	const suffix = "_DUPLICATE_"
+	if pos := strings.LastIndex(name, suffix); pos != -1 {
+		rest := name[pos+len(suffix):]
+		if len(rest) > 0 && strings.Trim(rest, "0123456789") == "" {
+			return name[:pos]
+		}
+	}
+	return name
+}
@@ -0,0 +1,55 @@
+package csym
+
+import (
+	"testing"
+
+	"github.com/sanctuary/sym/csym/c"
+)
+
+func TestCanonicalize(t *testing.T) {
+	// struct node { struct node *next; int x; };
+	node := &c.StructType{Size: 8, Tag: "node"}
+	node.Fields = []c.Field{
+		{Offset: 0, Var: c.Var{Type: &c.PointerType{Elem: node}, Name: "next"}},
+		{Offset: 4, Var: c.Var{Type: c.Int, Name: "x"}},
+	}
+	// Identical definition of struct node in another translation unit.
+	dup0 := &c.StructType{Size: 8, Tag: "node_duplicate_0"}
+	dup0.Fields = []c.Field{
+		{Offset: 0, Var: c.Var{Type: &c.PointerType{Elem: node}, Name: "next"}},
+		{Offset: 4, Var: c.Var{Type: c.Int, Name: "x"}},
+	}
+	// Conflicting definition of struct node.
+	dup1 := &c.StructType{Size: 8, Tag: "node_duplicate_1"}
+	dup1.Fields = []c.Field{
+		{Offset: 0, Var: c.Var{Type: &c.PointerType{Elem: node}, Name: "next"}},
+		{Offset: 4, Var: c.Var{Type: c.UInt, Name: "x"}},
+	}
+	color := &c.EnumType{Tag: "color", Members: []*c.EnumMember{{Value: 0, Name: "RED"}}}
+	colorDup := &c.EnumType{Tag: "color_duplicate_0", Members: []*c.EnumMember{{Value: 0, Name: "RED_DUPLICATE_0"}}}
+	p := NewParser()
+	for _, t := range []*c.StructType{node, dup0, dup1} {
+		p.Structs[t.Tag] = t
+		p.StructTags = append(p.StructTags, t.Tag)
+	}
+	for _, t := range []*c.EnumType{color, colorDup} {
+		p.Enums[t.Tag] = t
+		p.EnumTags = append(p.EnumTags, t.Tag)
+	}
+	if !c.Equal(node, dup0) {
+		t.Errorf("expected %v and %v to be equal", node, dup0)
+	}
+	if c.Equal(node, dup1) {
+		t.Errorf("expected %v and %v to differ", node, dup1)
+	}
+	p.Canonicalize()
+	if got, want := len(p.StructTags), 2; got != want {
+		t.Errorf("number of struct tags mismatch; expected %d, got %d (%v)", want, got, p.StructTags)
+	}
+	if p.Structs["node_duplicate_0"] != node {
+		t.Errorf("expected node_duplicate_0 to map to canonical struct node")
+	}
+	if got, want := len(p.EnumTags), 1; got != want {
+		t.Errorf("number of enum tags mismatch; expected %d, got %d (%v)", want, got, p.EnumTags)
+	}
+}
@@ -0,0 +1,407 @@
+package csym
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sanctuary/sym/csym/c"
+)
+
+//go:generate stringer -linecomment -type ChangeKind
+
+// ChangeKind specifies the kind of a change between two versions of types and
+// declarations.
+type ChangeKind uint8
+
+// Change kinds.
+const (
+	// Definition present only in the new version.
+	ChangeAdded ChangeKind = iota + 1 // added
+	// Definition present only in the old version.
+	ChangeRemoved // removed
+	// Definition renamed; at the same address for declarations, or with the
+	// same structure for types.
+	ChangeRenamed // renamed
+	// Declaration moved to a different address.
+	ChangeMoved // moved
+	// Type of definition changed.
+	ChangeType // type changed
+	// Size of definition changed.
+	ChangeSize // size changed
+)
+
+// MarshalText returns the textual representation of the change kind, as used
+// by JSON encoded changes.
+func (kind ChangeKind) MarshalText() ([]byte, error) {
+	return []byte(kind.String()), nil
+}
+
+// A Change is a difference between the old and new version of a type or
+// declaration.
+type Change struct {
+	// Kind of change.
+	Kind ChangeKind `json:"kind"`
+	// Kind of the definition; e.g. "struct" or "function".
+	Decl string `json:"decl"`
+	// Overlay ID of declarations (0 for the default binary).
+	Overlay uint32 `json:"overlay,omitempty"`
+	// Name of the old definition; or the new definition if added.
+	Name string `json:"name"`
+	// Name of the new definition, if renamed.
+	NewName string `json:"new_name,omitempty"`
+	// Address of the old declaration; or the new declaration if added.
+	Addr uint32 `json:"addr,omitempty"`
+	// Address of the new declaration, if moved.
+	NewAddr uint32 `json:"new_addr,omitempty"`
+	// Size in bytes of the old definition; or the new definition if added.
+	Size uint32 `json:"size,omitempty"`
+	// Size in bytes of the new definition, if changed.
+	NewSize uint32 `json:"new_size,omitempty"`
+	// C syntax representation of the old definition, if its type changed.
+	Def string `json:"def,omitempty"`
+	// C syntax representation of the new definition, if its type changed.
+	NewDef string `json:"new_def,omitempty"`
+}
+
+// String returns the string representation of the change.
+func (change *Change) String() string {
+	buf := &strings.Builder{}
+	switch change.Kind {
+	case ChangeRenamed:
+		// renamed function "foo" to "bar"
+		fmt.Fprintf(buf, "renamed %s %q to %q", change.Decl, change.Name, change.NewName)
+	case ChangeMoved:
+		// moved function "foo" from 0x80010000 to 0x80010020
+		fmt.Fprintf(buf, "moved %s %q from 0x%08X to 0x%08X", change.Decl, change.Name, change.Addr, change.NewAddr)
+	case ChangeType:
+		// changed type of function "foo"
+		fmt.Fprintf(buf, "changed type of %s %q", change.Decl, change.Name)
+	case ChangeSize:
+		// changed size of struct "foo" from 16 to 20 bytes
+		fmt.Fprintf(buf, "changed size of %s %q from %d to %d bytes", change.Decl, change.Name, change.Size, change.NewSize)
+	default:
+		// added function "foo"
+		fmt.Fprintf(buf, "%v %s %q", change.Kind, change.Decl, change.Name)
+	}
+	if change.Addr != 0 && change.Kind != ChangeMoved {
+		fmt.Fprintf(buf, " at 0x%08X", change.Addr)
+	}
+	if change.Overlay != 0 {
+		fmt.Fprintf(buf, " in overlay %X", change.Overlay)
+	}
+	return buf.String()
+}
+
+// Diff returns the differences between the types and declarations of the old
+// and new parsers; changes of types, followed by changes of declarations in
+// order of overlay.
+//
+// Types are compared by structure, and are matched by tag or name; unmatched
+// types of identical structure are reported as renamed. Types with fake tags
+// are not compared separately, as fake tags are not stable between versions;
+// changes to anonymous types are reported as changes of the enclosing types.
+// Referenced types are compared by tag or name, so that changes of a type are
+// not reported for each declaration using it.
+//
+// Declarations are matched by name and address; then by name, reported as
+// moved; then by address, reported as renamed.
+func Diff(old, new *Parser) []*Change {
+	var changes []*Change
+	changes = append(changes, diffItems("struct", 0, structItems(old), structItems(new), true)...)
+	changes = append(changes, diffItems("union", 0, unionItems(old), unionItems(new), true)...)
+	changes = append(changes, diffItems("enum", 0, enumItems(old), enumItems(new), true)...)
+	changes = append(changes, diffItems("typedef", 0, typedefItems(old), typedefItems(new), false)...)
+	oldOverlays := append([]*Overlay{old.Overlay}, old.Overlays...)
+	newOverlays := append([]*Overlay{new.Overlay}, new.Overlays...)
+	for _, overlay := range oldOverlays {
+		other := findOverlay(newOverlays, overlay.ID)
+		changes = append(changes, diffOverlay(overlay, other)...)
+	}
+	for _, overlay := range newOverlays {
+		if findOverlay(oldOverlays, overlay.ID) == nil {
+			changes = append(changes, diffOverlay(nil, overlay)...)
+		}
+	}
+	return changes
+}
+
+// diffOverlay returns the differences between the declarations of the old and
+// new overlay; either of which may be nil.
+func diffOverlay(old, new *Overlay) []*Change {
+	var id uint32
+	if old != nil {
+		id = old.ID
+	} else {
+		id = new.ID
+	}
+	var changes []*Change
+	changes = append(changes, diffItems("global variable", id, varItems(old), varItems(new), false)...)
+	changes = append(changes, diffItems("function", id, funcItems(old), funcItems(new), false)...)
+	changes = append(changes, diffItems("symbol", id, symbolItems(old), symbolItems(new), false)...)
+	return changes
+}
+
+// A diffItem is a type or declaration under comparison.
+type diffItem struct {
+	// Tag or name.
+	name string
+	// Address; or 0 for types.
+	addr uint32
+	// Size in bytes; or 0 if unknown.
+	size uint32
+	// Type; or nil for symbols.
+	typ c.Type
+	// C syntax representation of the definition.
+	def string
+}
+
+// diffItems returns the differences between the given old and new items of the
+// specified kind of definition. Items of struct, union and enum types are
+// compared by structure, and unmatched items of identical structure are reported
+// as renamed, if byType is set.
+func diffItems(decl string, overlay uint32, olds, news []*diffItem, byType bool) []*Change {
+	// matches maps from index of old item to index of matching new item.
+	matches := make(map[int]int)
+	matched := make(map[int]bool)
+	match := func(same func(old, new *diffItem) bool) {
+		for i, old := range olds {
+			if _, ok := matches[i]; ok {
+				continue
+			}
+			for j, new := range news {
+				if !matched[j] && same(old, new) {
+					matches[i] = j
+					matched[j] = true
+					break
+				}
+			}
+		}
+	}
+	match(func(old, new *diffItem) bool {
+		return old.name == new.name && old.addr == new.addr
+	})
+	match(func(old, new *diffItem) bool {
+		return old.name == new.name
+	})
+	match(func(old, new *diffItem) bool {
+		if byType {
+			return old.typ != nil && new.typ != nil && equalType(old.typ, new.typ)
+		}
+		return old.addr != 0 && old.addr == new.addr
+	})
+	var changes []*Change
+	for i, old := range olds {
+		j, ok := matches[i]
+		if !ok {
+			changes = append(changes, &Change{Kind: ChangeRemoved, Decl: decl, Overlay: overlay, Name: old.name, Addr: old.addr, Size: old.size})
+			continue
+		}
+		new := news[j]
+		if old.name != new.name {
+			changes = append(changes, &Change{Kind: ChangeRenamed, Decl: decl, Overlay: overlay, Name: old.name, NewName: new.name, Addr: old.addr})
+		}
+		if old.addr != new.addr {
+			changes = append(changes, &Change{Kind: ChangeMoved, Decl: decl, Overlay: overlay, Name: new.name, Addr: old.addr, NewAddr: new.addr})
+		}
+		if old.typ != nil && new.typ != nil && !sameType(old.typ, new.typ, byType) {
+			changes = append(changes, &Change{Kind: ChangeType, Decl: decl, Overlay: overlay, Name: new.name, Addr: new.addr, Def: old.def, NewDef: new.def})
+		}
+		if old.size != new.size && old.size != 0 && new.size != 0 {
+			changes = append(changes, &Change{Kind: ChangeSize, Decl: decl, Overlay: overlay, Name: new.name, Addr: new.addr, Size: old.size, NewSize: new.size})
+		}
+	}
+	for j, new := range news {
+		if !matched[j] {
+			changes = append(changes, &Change{Kind: ChangeAdded, Decl: decl, Overlay: overlay, Name: new.name, Addr: new.addr, Size: new.size})
+		}
+	}
+	return changes
+}
+
+// sameType reports whether the given types are identical. The struct, union and
+// enum types and type definitions referenced by the given types are compared by
+// tag or name, rather than by structure, so that changes are reported only for
+// the changed types themselves; except for types with fake tags, which are
+// compared by structure. The given types are compared by structure if def is
+// set.
+func sameType(a, b c.Type, def bool) bool {
+	switch a := a.(type) {
+	case *c.StructType:
+		b, ok := b.(*c.StructType)
+		if !ok {
+			return false
+		}
+		if !def && !c.IsFakeTag(a.Tag) && !c.IsFakeTag(b.Tag) {
+			return a.Tag == b.Tag
+		}
+		return a.Size == b.Size && sameFields(a.Fields, b.Fields)
+	case *c.UnionType:
+		b, ok := b.(*c.UnionType)
+		if !ok {
+			return false
+		}
+		if !def && !c.IsFakeTag(a.Tag) && !c.IsFakeTag(b.Tag) {
+			return a.Tag == b.Tag
+		}
+		return a.Size == b.Size && sameFields(a.Fields, b.Fields)
+	case *c.EnumType:
+		b, ok := b.(*c.EnumType)
+		if !ok {
+			return false
+		}
+		if !def && !c.IsFakeTag(a.Tag) && !c.IsFakeTag(b.Tag) {
+			return a.Tag == b.Tag
+		}
+		return enumEqual(a, b)
+	case *c.VarDecl:
+		// Type definition.
+		b, ok := b.(*c.VarDecl)
+		return ok && a.Name == b.Name
+	case *c.PointerType:
+		b, ok := b.(*c.PointerType)
+		return ok && sameType(a.Elem, b.Elem, false)
+	case *c.ArrayType:
+		b, ok := b.(*c.ArrayType)
+		return ok && a.Len == b.Len && sameType(a.Elem, b.Elem, false)
+	case *c.QualType:
+		b, ok := b.(*c.QualType)
+		return ok && a.Quals == b.Quals && sameType(a.Type, b.Type, false)
+	case *c.FuncType:
+		b, ok := b.(*c.FuncType)
+		if !ok || a.Variadic != b.Variadic || len(a.Params) != len(b.Params) {
+			return false
+		}
+		if !sameType(a.RetType, b.RetType, false) {
+			return false
+		}
+		for i, param := range a.Params {
+			if !sameType(param.Type, b.Params[i].Type, false) {
+				return false
+			}
+		}
+		return true
+	}
+	return c.Equal(a, b)
+}
+
+// sameFields reports whether the given struct or union fields are identical,
+// comparing the types of fields as specified by sameType.
+func sameFields(a, b []c.Field) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, af := range a {
+		bf := b[i]
+		if af.Offset != bf.Offset || af.Size != bf.Size || af.BitOffset != bf.BitOffset || af.BitWidth != bf.BitWidth || af.Name != bf.Name {
+			return false
+		}
+		if !sameType(af.Type, bf.Type, false) {
+			return false
+		}
+	}
+	return true
+}
+
+// structItems returns the struct types of the given parser, except for structs
+// with fake tags.
+func structItems(p *Parser) []*diffItem {
+	var items []*diffItem
+	for _, tag := range p.StructTags {
+		if c.IsFakeTag(tag) {
+			continue
+		}
+		t := p.Structs[tag]
+		items = append(items, &diffItem{name: tag, size: t.Size, typ: t, def: t.Def()})
+	}
+	return items
+}
+
+// unionItems returns the union types of the given parser, except for unions
+// with fake tags.
+func unionItems(p *Parser) []*diffItem {
+	var items []*diffItem
+	for _, tag := range p.UnionTags {
+		if c.IsFakeTag(tag) {
+			continue
+		}
+		t := p.Unions[tag]
+		items = append(items, &diffItem{name: tag, size: t.Size, typ: t, def: t.Def()})
+	}
+	return items
+}
+
+// enumItems returns the enum types of the given parser, except for enums with
+// fake tags.
+func enumItems(p *Parser) []*diffItem {
+	var items []*diffItem
+	for _, tag := range p.EnumTags {
+		if c.IsFakeTag(tag) {
+			continue
+		}
+		t := p.Enums[tag]
+		items = append(items, &diffItem{name: tag, typ: t, def: t.Def()})
+	}
+	return items
+}
+
+// typedefItems returns the type definitions of the given parser.
+func typedefItems(p *Parser) []*diffItem {
+	var items []*diffItem
+	for _, t := range p.Typedefs {
+		def, ok := t.(*c.VarDecl)
+		if !ok {
+			continue
+		}
+		items = append(items, &diffItem{name: def.Name, typ: def.Type, def: def.Def()})
+	}
+	return items
+}
+
+// varItems returns the global variables of the given overlay; or nil if the
+// overlay is nil.
+func varItems(overlay *Overlay) []*diffItem {
+	if overlay == nil {
+		return nil
+	}
+	var items []*diffItem
+	for _, v := range overlay.Vars {
+		items = append(items, &diffItem{name: v.Name, addr: v.Addr, size: v.Size, typ: v.Type, def: v.Var.String()})
+	}
+	return items
+}
+
+// funcItems returns the functions of the given overlay; or nil if the overlay
+// is nil.
+func funcItems(overlay *Overlay) []*diffItem {
+	if overlay == nil {
+		return nil
+	}
+	var items []*diffItem
+	for _, f := range overlay.Funcs {
+		items = append(items, &diffItem{name: f.Name, addr: f.Addr, size: f.Size, typ: f.Type, def: f.Var.String()})
+	}
+	return items
+}
+
+// symbolItems returns the symbols of the given overlay; or nil if the overlay
+// is nil.
+func symbolItems(overlay *Overlay) []*diffItem {
+	if overlay == nil {
+		return nil
+	}
+	var items []*diffItem
+	for _, s := range overlay.Symbols {
+		items = append(items, &diffItem{name: s.Name, addr: s.Addr})
+	}
+	return items
+}
+
+// findOverlay returns the overlay of the given ID; or nil if not present.
+func findOverlay(overlays []*Overlay, id uint32) *Overlay {
+	for _, overlay := range overlays {
+		if overlay.ID == id {
+			return overlay
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package csym
+
+import (
+	"testing"
+
+	"github.com/sanctuary/sym/csym/c"
+)
+
+func TestDiff(t *testing.T) {
+	old := NewParser()
+	// struct point { int x; };
+	point := &c.StructType{Size: 4, Tag: "point", Fields: []c.Field{{Var: c.Var{Type: c.Int, Name: "x"}}}}
+	// struct vec { short x; short y; };
+	vec := &c.StructType{Size: 4, Tag: "vec", Fields: []c.Field{{Var: c.Var{Type: c.Short, Name: "x"}}, {Offset: 2, Var: c.Var{Type: c.Short, Name: "y"}}}}
+	for _, t := range []*c.StructType{point, vec} {
+		old.Structs[t.Tag] = t
+		old.StructTags = append(old.StructTags, t.Tag)
+	}
+	old.Funcs = []*c.FuncDecl{
+		{Addr: 0x80010000, Size: 0x20, Class: c.Extern, Var: c.Var{Type: &c.FuncType{RetType: c.Int}, Name: "main"}},
+		{Addr: 0x80010100, Class: c.Extern, Var: c.Var{Type: &c.FuncType{RetType: c.Void}, Name: "sub_80010100"}},
+		{Addr: 0x80010200, Class: c.Extern, Var: c.Var{Type: &c.FuncType{RetType: c.Void}, Name: "Unused"}},
+	}
+	old.Vars = []*c.VarDecl{
+		{Addr: 0x80020000, Class: c.Extern, Var: c.Var{Type: c.Int, Name: "gVar"}},
+		{Addr: 0x80020010, Class: c.Extern, Var: c.Var{Type: point, Name: "gPoint"}},
+	}
+	new := NewParser()
+	// struct point { int x; int y; };
+	point2 := &c.StructType{Size: 8, Tag: "point", Fields: []c.Field{{Var: c.Var{Type: c.Int, Name: "x"}}, {Offset: 4, Var: c.Var{Type: c.Int, Name: "y"}}}}
+	// struct vec2 { short x; short y; };
+	vec2 := &c.StructType{Size: 4, Tag: "vec2", Fields: []c.Field{{Var: c.Var{Type: c.Short, Name: "x"}}, {Offset: 2, Var: c.Var{Type: c.Short, Name: "y"}}}}
+	for _, t := range []*c.StructType{point2, vec2} {
+		new.Structs[t.Tag] = t
+		new.StructTags = append(new.StructTags, t.Tag)
+	}
+	new.Funcs = []*c.FuncDecl{
+		{Addr: 0x80010000, Size: 0x24, Class: c.Extern, Var: c.Var{Type: &c.FuncType{RetType: c.Int}, Name: "main"}},
+		{Addr: 0x80010100, Class: c.Extern, Var: c.Var{Type: &c.FuncType{RetType: c.Void}, Name: "Player_Update"}},
+		{Addr: 0x80010300, Class: c.Extern, Var: c.Var{Type: &c.FuncType{RetType: c.Void}, Name: "Init"}},
+	}
+	new.Vars = []*c.VarDecl{
+		{Addr: 0x80020004, Class: c.Extern, Var: c.Var{Type: c.Short, Name: "gVar"}},
+		// Changes of struct point are not reported for its variables.
+		{Addr: 0x80020010, Class: c.Extern, Var: c.Var{Type: point2, Name: "gPoint"}},
+	}
+	want := []string{
+		`changed type of struct "point"`,
+		`changed size of struct "point" from 4 to 8 bytes`,
+		`renamed struct "vec" to "vec2"`,
+		`moved global variable "gVar" from 0x80020000 to 0x80020004`,
+		`changed type of global variable "gVar" at 0x80020004`,
+		`changed size of function "main" from 32 to 36 bytes at 0x80010000`,
+		`renamed function "sub_80010100" to "Player_Update" at 0x80010100`,
+		`removed function "Unused" at 0x80010200`,
+		`added function "Init" at 0x80010300`,
+	}
+	changes := Diff(old, new)
+	if len(changes) != len(want) {
+		t.Fatalf("number of changes mismatch; expected %d, got %d: %v", len(want), len(changes), changes)
+	}
+	for i, change := range changes {
+		if got := change.String(); got != want[i] {
+			t.Errorf("change %d mismatch; expected %q, got %q", i, want[i], got)
+		}
+	}
+	if changes := Diff(old, old); len(changes) != 0 {
+		t.Errorf("expected no changes between identical parsers, got %v", changes)
+	}
+}
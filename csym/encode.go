@@ -0,0 +1,522 @@
+package csym
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// Encode encodes the types and declarations recorded by the parser into the
+// equivalent SYM symbols; the inverse of ParseTypes and ParseDecls.
+//
+// Symbols are output in the order expected by ParseDecls; overlays, followed by
+// struct, union and enum tags and type definitions, followed by the symbols,
+// global variables and functions of the default binary and of each overlay.
+//
+// Type definitions are encoded by their underlying types and type qualifiers
+// are omitted, as neither are represented by SYM types. Blocks are encoded
+// sequentially at the address of their function, as their addresses and
+// nesting are not recorded by the parser.
+func (p *Parser) Encode() ([]*sym.Symbol, error) {
+	var syms []*sym.Symbol
+	for _, overlay := range p.Overlays {
+		s := &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: overlay.Addr, Kind: sym.KindOverlay},
+			Body: &sym.Overlay{Length: overlay.Length, ID: overlay.ID},
+		}
+		syms = append(syms, s)
+	}
+	typeSyms, err := p.encodeTypes()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	syms = append(syms, typeSyms...)
+	overlays := append([]*Overlay{p.Overlay}, p.Overlays...)
+	for _, overlay := range overlays {
+		if overlay.ID != 0 {
+			s := &sym.Symbol{
+				Hdr:  &sym.SymbolHeader{Value: overlay.ID, Kind: sym.KindSetOverlay},
+				Body: &sym.SetOverlay{},
+			}
+			syms = append(syms, s)
+		}
+		declSyms, err := p.encodeDecls(overlay)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		syms = append(syms, declSyms...)
+	}
+	return syms, nil
+}
+
+// --- [ Types ] ---------------------------------------------------------------
+
+// encodeTypes encodes the struct, union and enum tags and type definitions
+// recorded by the parser into SYM symbols.
+func (p *Parser) encodeTypes() ([]*sym.Symbol, error) {
+	var syms []*sym.Symbol
+	for _, tag := range p.EnumTags {
+		t := p.Enums[tag]
+		tagSym, err := newDef(0, sym.ClassENTAG, sym.Type(sym.BaseEnum), c.Sizeof(t), tag)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		syms = append(syms, tagSym)
+		for _, member := range t.Members {
+			memberSym, err := newDef(member.Value, sym.ClassMOE, sym.Type(sym.BaseMOE), 0, member.Name)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			syms = append(syms, memberSym)
+		}
+		eosSym, err := newEOS(c.Sizeof(t), tag)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		syms = append(syms, eosSym)
+	}
+	for _, tag := range p.StructTags {
+		t := p.Structs[tag]
+		if tag == "__vtbl_ptr_type" && len(t.Fields) == 0 {
+			// Skip scaffolding type added by ParseTypes.
+			continue
+		}
+		tagSyms, err := p.encodeTag(sym.ClassSTRTAG, sym.ClassMOS, sym.BaseStruct, tag, t.Size, t.Fields)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		syms = append(syms, tagSyms...)
+	}
+	for _, tag := range p.UnionTags {
+		t := p.Unions[tag]
+		tagSyms, err := p.encodeTag(sym.ClassUNTAG, sym.ClassMOU, sym.BaseUnion, tag, t.Size, t.Fields)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		syms = append(syms, tagSyms...)
+	}
+	for _, def := range p.Typedefs {
+		v, ok := def.(*c.VarDecl)
+		if !ok {
+			continue
+		}
+		s, err := p.encodeDef(0, sym.ClassTPDEF, v.Type, c.Sizeof(v.Type), v.Name)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		syms = append(syms, s)
+	}
+	return syms, nil
+}
+
+// encodeTag encodes the struct or union of the given tag, size and fields into
+// a tag sequence of SYM symbols, using the specified tag and member classes.
+func (p *Parser) encodeTag(tagClass, memberClass sym.Class, base sym.Base, tag string, size uint32, fields []c.Field) ([]*sym.Symbol, error) {
+	tagSym, err := newDef(0, tagClass, sym.Type(base), size, tag)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	syms := []*sym.Symbol{tagSym}
+	for _, field := range fields {
+		var s *sym.Symbol
+		if field.BitWidth > 0 {
+			// The value of FIELD symbols specifies the bit offset of the member,
+			// and the size specifies its width in bits.
+			bitOffset := field.Offset*8 + field.BitOffset
+			s, err = p.encodeDef(bitOffset, sym.ClassFIELD, field.Type, field.BitWidth, field.Name)
+		} else {
+			fieldSize := field.Size
+			if fieldSize == 0 {
+				fieldSize = c.Sizeof(field.Type)
+			}
+			s, err = p.encodeDef(field.Offset, memberClass, field.Type, fieldSize, field.Name)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to encode member %q of %q", field.Name, tag)
+		}
+		syms = append(syms, s)
+	}
+	eosSym, err := newEOS(size, tag)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	syms = append(syms, eosSym)
+	return syms, nil
+}
+
+// --- [ Declarations ] --------------------------------------------------------
+
+// encodeDecls encodes the symbols, global variables, functions and line
+// numbers of the overlay into SYM symbols.
+func (p *Parser) encodeDecls(overlay *Overlay) ([]*sym.Symbol, error) {
+	var syms []*sym.Symbol
+	for _, symbol := range overlay.Symbols {
+		body := &sym.Name2{Name: symbol.Name}
+		n, err := nameLen(body.Name)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		body.NameLen = n
+		s := &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: symbol.Addr, Kind: sym.KindName2},
+			Body: body,
+		}
+		syms = append(syms, s)
+	}
+	for _, v := range overlay.Vars {
+		size := v.Size
+		if size == 0 {
+			size = c.Sizeof(v.Type)
+		}
+		s, err := p.encodeDef(v.Addr, globalClass(v.Class), v.Type, size, v.Name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to encode global variable %q", v.Name)
+		}
+		syms = append(syms, s)
+	}
+	for _, f := range overlay.Funcs {
+		s, err := p.encodeDef(f.Addr, globalClass(f.Class), f.Type, f.Size, f.Name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to encode function %q", f.Name)
+		}
+		syms = append(syms, s)
+	}
+	// Function sequences are located after the global declarations of the
+	// functions.
+	funcStarts := make(map[uint32]bool)
+	for _, f := range overlay.Funcs {
+		funcSyms, err := p.encodeFunc(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to encode function %q", f.Name)
+		}
+		syms = append(syms, funcSyms...)
+		funcStarts[f.Addr] = true
+	}
+	lineSyms, err := encodeLines(overlay.Lines, funcStarts)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	syms = append(syms, lineSyms...)
+	return syms, nil
+}
+
+// encodeFunc encodes the parameters and scope blocks of the function into a
+// function sequence of SYM symbols.
+func (p *Parser) encodeFunc(f *c.FuncDecl) ([]*sym.Symbol, error) {
+	funcType, ok := f.Type.(*c.FuncType)
+	if !ok {
+		return nil, errors.Errorf("invalid function type; expected *c.FuncType, got %T", f.Type)
+	}
+	startBody := &sym.FuncStart{
+		FP:     29, // $sp
+		RetReg: 31, // $ra
+		Line:   f.LineStart,
+		Path:   f.Path,
+		Name:   f.Name,
+	}
+	pathLen, err := nameLen(startBody.Path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	startBody.PathLen = pathLen
+	n, err := nameLen(startBody.Name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	startBody.NameLen = n
+	syms := []*sym.Symbol{{
+		Hdr:  &sym.SymbolHeader{Value: f.Addr, Kind: sym.KindFuncStart},
+		Body: startBody,
+	}}
+	for _, param := range funcType.Params {
+		class := sym.ClassARG
+		if param.Class == c.Register {
+			class = sym.ClassREGPARM
+		}
+		s, err := p.encodeDef(param.Addr, class, param.Type, localSize(param), param.Name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to encode parameter %q", param.Name)
+		}
+		syms = append(syms, s)
+	}
+	for _, block := range f.Blocks {
+		start := &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: f.Addr, Kind: sym.KindBlockStart},
+			Body: &sym.BlockStart{Line: block.LineStart},
+		}
+		syms = append(syms, start)
+		for _, local := range block.Locals {
+			s, err := p.encodeDef(local.Addr, localClass(local.Class), local.Type, localSize(local), local.Name)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to encode local variable %q", local.Name)
+			}
+			syms = append(syms, s)
+		}
+		end := &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: f.Addr, Kind: sym.KindBlockEnd},
+			Body: &sym.BlockEnd{Line: block.LineEnd},
+		}
+		syms = append(syms, end)
+	}
+	end := &sym.Symbol{
+		Hdr:  &sym.SymbolHeader{Value: f.Addr + f.Size, Kind: sym.KindFuncEnd},
+		Body: &sym.FuncEnd{Line: f.LineEnd},
+	}
+	syms = append(syms, end)
+	return syms, nil
+}
+
+// encodeLines encodes the line numbers into a line number sequence of SYM
+// symbols. Line numbers at the start address of functions are omitted, as they
+// are recorded by the function sequences.
+func encodeLines(lines []*Line, funcStarts map[uint32]bool) ([]*sym.Symbol, error) {
+	var syms []*sym.Symbol
+	var prev *Line
+	for _, line := range lines {
+		if funcStarts[line.Addr] {
+			continue
+		}
+		var body sym.SymbolBody
+		var kind sym.Kind
+		switch {
+		case prev == nil || line.Path != prev.Path:
+			b := &sym.SetSLD2{Line: line.Line, Path: line.Path}
+			n, err := nameLen(b.Path)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			b.PathLen = n
+			body, kind = b, sym.KindSetSLD2
+		case line.Line == prev.Line+1:
+			body, kind = &sym.IncSLD{}, sym.KindIncSLD
+		case line.Line > prev.Line && line.Line-prev.Line <= 0xFF:
+			body, kind = &sym.IncSLDByte{Inc: uint8(line.Line - prev.Line)}, sym.KindIncSLDByte
+		case line.Line > prev.Line && line.Line-prev.Line <= 0xFFFF:
+			body, kind = &sym.IncSLDWord{Inc: uint16(line.Line - prev.Line)}, sym.KindIncSLDWord
+		default:
+			body, kind = &sym.SetSLD{Line: line.Line}, sym.KindSetSLD
+		}
+		s := &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: line.Addr, Kind: kind},
+			Body: body,
+		}
+		syms = append(syms, s)
+		prev = line
+	}
+	if prev != nil {
+		s := &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: prev.Addr, Kind: sym.KindEndSLD},
+			Body: &sym.EndSLD{},
+		}
+		syms = append(syms, s)
+	}
+	return syms, nil
+}
+
+// --- [ Definitions ] ---------------------------------------------------------
+
+// encodeDef encodes the definition of the given value, class, C type, size and
+// name into a Def symbol; or a Def2 symbol if the type has array dimensions or
+// a struct, union or enum tag.
+func (p *Parser) encodeDef(value uint32, class sym.Class, t c.Type, size uint32, name string) (*sym.Symbol, error) {
+	typ, dims, tag, err := p.EncodeType(t)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(dims) == 0 && len(tag) == 0 {
+		return newDef(value, class, typ, size, name)
+	}
+	body := &sym.Def2{
+		Class:   class,
+		Type:    typ,
+		Size:    size,
+		DimsLen: uint16(len(dims)),
+		Dims:    dims,
+		Tag:     tag,
+		Name:    name,
+	}
+	if body.TagLen, err = nameLen(body.Tag); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if body.NameLen, err = nameLen(body.Name); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	s := &sym.Symbol{
+		Hdr:  &sym.SymbolHeader{Value: value, Kind: sym.KindDef2},
+		Body: body,
+	}
+	return s, nil
+}
+
+// EncodeType encodes the C type into the equivalent SYM type, array dimensions
+// and struct, union or enum tag; the inverse of parseType.
+//
+// Type definitions are encoded by their underlying types, with the exception of
+// the bool type definition of the parser, which is encoded as the NULL base
+// type. Type qualifiers and the parameters of function types are omitted.
+func (p *Parser) EncodeType(t c.Type) (sym.Type, []uint32, string, error) {
+	// Type modifiers from outermost to innermost, and dimensions of array
+	// modifiers.
+	var mods []sym.Mod
+	var dims []uint32
+loop:
+	for {
+		switch tt := t.(type) {
+		case *c.PointerType:
+			mods = append(mods, sym.ModPointer)
+			t = tt.Elem
+		case *c.FuncType:
+			mods = append(mods, sym.ModFunction)
+			t = tt.RetType
+		case *c.ArrayType:
+			mods = append(mods, sym.ModArray)
+			dims = append(dims, uint32(tt.Len))
+			t = tt.Elem
+		case *c.QualType:
+			t = tt.Type
+		case *c.VarDecl:
+			if tt == p.Types["bool"] {
+				break loop
+			}
+			t = tt.Type
+		default:
+			break loop
+		}
+	}
+	base, tag, err := p.encodeBase(t)
+	if err != nil {
+		return 0, nil, "", errors.WithStack(err)
+	}
+	// Six modifiers of two bits each are stored above the four-bit base type.
+	if len(mods) > 6 {
+		return 0, nil, "", errors.Errorf("unable to encode type %v; more than 6 type modifiers", t)
+	}
+	typ := sym.Type(base)
+	for i, mod := range mods {
+		typ |= sym.Type(mod) << uint(4+i*2)
+	}
+	return typ, dims, tag, nil
+}
+
+// encodeBase encodes the C type into the equivalent SYM base type and struct,
+// union or enum tag.
+func (p *Parser) encodeBase(t c.Type) (sym.Base, string, error) {
+	switch t := t.(type) {
+	case c.BaseType:
+		if base, ok := symBases[t]; ok {
+			return base, "", nil
+		}
+	case *c.VarDecl:
+		// bool type definition.
+		return sym.BaseNull, "", nil
+	case *c.StructType:
+		return sym.BaseStruct, t.Tag, nil
+	case *c.UnionType:
+		return sym.BaseUnion, t.Tag, nil
+	case *c.EnumType:
+		return sym.BaseEnum, t.Tag, nil
+	}
+	return 0, "", errors.Errorf("support for encoding C type %v (%T) as SYM base type not yet implemented", t, t)
+}
+
+// symBases maps from C base type to equivalent SYM base type. Signed char and
+// long double are encoded as char and double, respectively.
+var symBases = map[c.BaseType]sym.Base{
+	c.Void:       sym.BaseVoid,
+	c.Char:       sym.BaseChar,
+	c.Short:      sym.BaseShort,
+	c.Int:        sym.BaseInt,
+	c.Long:       sym.BaseLong,
+	c.UChar:      sym.BaseUChar,
+	c.UShort:     sym.BaseUShort,
+	c.UInt:       sym.BaseUInt,
+	c.ULong:      sym.BaseULong,
+	c.Float:      sym.BaseFloat,
+	c.Double:     sym.BaseDouble,
+	c.LongDouble: sym.BaseDouble,
+	c.SChar:      sym.BaseChar,
+	c.Bool:       sym.BaseNull,
+}
+
+// ### [ Helper functions ] ####################################################
+
+// newDef returns a new Def symbol of the given value, class, type, size and
+// name.
+func newDef(value uint32, class sym.Class, typ sym.Type, size uint32, name string) (*sym.Symbol, error) {
+	n, err := nameLen(name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	s := &sym.Symbol{
+		Hdr: &sym.SymbolHeader{Value: value, Kind: sym.KindDef},
+		Body: &sym.Def{
+			Class:   class,
+			Type:    typ,
+			Size:    size,
+			NameLen: n,
+			Name:    name,
+		},
+	}
+	return s, nil
+}
+
+// newEOS returns a new end of struct, union or enum symbol of the given size and
+// tag.
+func newEOS(size uint32, tag string) (*sym.Symbol, error) {
+	body := &sym.Def2{
+		Class: sym.ClassEOS,
+		Size:  size,
+		Tag:   tag,
+		Name:  ".eos",
+	}
+	var err error
+	if body.TagLen, err = nameLen(body.Tag); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if body.NameLen, err = nameLen(body.Name); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	s := &sym.Symbol{
+		Hdr:  &sym.SymbolHeader{Value: size, Kind: sym.KindDef2},
+		Body: body,
+	}
+	return s, nil
+}
+
+// nameLen returns the length of the given name or path of a SYM symbol.
+func nameLen(s string) (uint8, error) {
+	if len(s) > 0xFF {
+		return 0, errors.Errorf("invalid length of %q; expected <= 255 bytes, got %d", s, len(s))
+	}
+	return uint8(len(s)), nil
+}
+
+// globalClass returns the SYM class of a global declaration of the given
+// storage class.
+func globalClass(class c.StorageClass) sym.Class {
+	if class == c.Static {
+		return sym.ClassSTAT
+	}
+	return sym.ClassEXT
+}
+
+// localClass returns the SYM class of a local declaration of the given storage
+// class.
+func localClass(class c.StorageClass) sym.Class {
+	switch class {
+	case c.Static:
+		return sym.ClassSTAT
+	case c.Register:
+		return sym.ClassREG
+	default:
+		return sym.ClassAUTO
+	}
+}
+
+// localSize returns the size of the given parameter or local variable.
+func localSize(v *c.VarDecl) uint32 {
+	if v.Size != 0 {
+		return v.Size
+	}
+	return c.Sizeof(v.Type)
+}
@@ -0,0 +1,191 @@
+package csym
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sanctuary/sym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+func TestEncode(t *testing.T) {
+	def := func(value uint32, class sym.Class, typ sym.Type, size uint32, name string) *sym.Symbol {
+		return &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: value, Kind: sym.KindDef},
+			Body: &sym.Def{Class: class, Type: typ, Size: size, Name: name},
+		}
+	}
+	def2 := func(value uint32, class sym.Class, typ sym.Type, size uint32, dims []uint32, tag, name string) *sym.Symbol {
+		return &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: value, Kind: sym.KindDef2},
+			Body: &sym.Def2{Class: class, Type: typ, Size: size, Dims: dims, Tag: tag, Name: name},
+		}
+	}
+	syms := []*sym.Symbol{
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x800B0000, Kind: sym.KindOverlay},
+			Body: &sym.Overlay{Length: 0x100, ID: 4},
+		},
+		// enum color { RED, GREEN };
+		def(0, sym.ClassENTAG, sym.Type(sym.BaseEnum), 4, "color"),
+		def(0, sym.ClassMOE, sym.Type(sym.BaseMOE), 0, "RED"),
+		def(1, sym.ClassMOE, sym.Type(sym.BaseMOE), 0, "GREEN"),
+		def2(4, sym.ClassEOS, 0, 4, nil, "color", ".eos"),
+		// struct point { int x; unsigned int flag : 1; struct point *next; };
+		def(0, sym.ClassSTRTAG, sym.Type(sym.BaseStruct), 12, "point"),
+		def(0, sym.ClassMOS, sym.Type(sym.BaseInt), 4, "x"),
+		def(32, sym.ClassFIELD, sym.Type(sym.BaseUInt), 1, "flag"),
+		def2(8, sym.ClassMOS, 0x18, 4, nil, "point", "next"),
+		def2(12, sym.ClassEOS, 0, 12, nil, "point", ".eos"),
+		// typedef int s32[2][3];
+		def2(0, sym.ClassTPDEF, 0xF4, 24, []uint32{2, 3}, "", "s32"),
+		// struct point origin;
+		def2(0x80010000, sym.ClassEXT, sym.Type(sym.BaseStruct), 12, nil, "point", "origin"),
+		// static enum color col;
+		def2(0x8001000C, sym.ClassSTAT, sym.Type(sym.BaseEnum), 4, nil, "color", "col"),
+		// int add(int a, int b) { int sum; }
+		def(0x80010100, sym.ClassEXT, 0x24, 0x20, "add"),
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010100, Kind: sym.KindFuncStart},
+			Body: &sym.FuncStart{FP: 29, RetReg: 31, Line: 10, Path: "MAIN.C", Name: "add"},
+		},
+		def(16, sym.ClassARG, sym.Type(sym.BaseInt), 4, "a"),
+		def(5, sym.ClassREGPARM, sym.Type(sym.BaseInt), 4, "b"),
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010108, Kind: sym.KindBlockStart},
+			Body: &sym.BlockStart{Line: 1},
+		},
+		def(8, sym.ClassAUTO, sym.Type(sym.BaseInt), 4, "sum"),
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010118, Kind: sym.KindBlockEnd},
+			Body: &sym.BlockEnd{Line: 3},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010120, Kind: sym.KindFuncEnd},
+			Body: &sym.FuncEnd{Line: 4},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010104, Kind: sym.KindSetSLD2},
+			Body: &sym.SetSLD2{Line: 11, Path: "MAIN.C"},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010108, Kind: sym.KindIncSLDByte},
+			Body: &sym.IncSLDByte{Inc: 2},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x8001011C, Kind: sym.KindEndSLD},
+			Body: &sym.EndSLD{},
+		},
+		// Symbol of overlay.
+		{
+			Hdr:  &sym.SymbolHeader{Value: 4, Kind: sym.KindSetOverlay},
+			Body: &sym.SetOverlay{},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x800B0000, Kind: sym.KindName2},
+			Body: &sym.Name2{Name: "ovl_start"},
+		},
+	}
+	parse := func(syms []*sym.Symbol) *Parser {
+		p := NewParser()
+		p.ParseTypes(syms)
+		p.ParseDecls(syms)
+		return p
+	}
+	want := parse(syms)
+	encoded, err := want.Encode()
+	if err != nil {
+		t.Fatalf("unable to encode symbols; %+v", err)
+	}
+	got := parse(encoded)
+	if !reflect.DeepEqual(got.StructTags, want.StructTags) {
+		t.Errorf("struct tags mismatch; expected %v, got %v", want.StructTags, got.StructTags)
+	}
+	if g, w := got.Structs["point"].Def(), want.Structs["point"].Def(); g != w {
+		t.Errorf("struct mismatch; expected\n%s\ngot\n%s", w, g)
+	}
+	if g, w := got.Enums["color"].Def(), want.Enums["color"].Def(); g != w {
+		t.Errorf("enum mismatch; expected\n%s\ngot\n%s", w, g)
+	}
+	if len(got.Typedefs) != 1 || got.Typedefs[0].(*c.VarDecl).Def() != want.Typedefs[0].(*c.VarDecl).Def() {
+		t.Errorf("type definitions mismatch; expected %v, got %v", want.Typedefs, got.Typedefs)
+	}
+	if len(got.Vars) != len(want.Vars) {
+		t.Fatalf("number of global variables mismatch; expected %d, got %d", len(want.Vars), len(got.Vars))
+	}
+	for i, v := range got.Vars {
+		w := want.Vars[i]
+		if v.Def() != w.Def() || v.Addr != w.Addr || v.Size != w.Size {
+			t.Errorf("global variable %d mismatch; expected %q at 0x%08X, got %q at 0x%08X", i, w.Def(), w.Addr, v.Def(), v.Addr)
+		}
+	}
+	if len(got.Funcs) != 1 {
+		t.Fatalf("number of functions mismatch; expected 1, got %d", len(got.Funcs))
+	}
+	if g, w := got.Funcs[0].Def(), want.Funcs[0].Def(); g != w {
+		t.Errorf("function mismatch; expected\n%s\ngot\n%s", w, g)
+	}
+	if f := got.Funcs[0]; f.Addr != 0x80010100 || f.Size != 0x20 || f.Path != "MAIN.C" || f.LineStart != 10 || f.LineEnd != 4 {
+		t.Errorf("function metadata mismatch; got %s at 0x%08X of size 0x%X (%s:%d-%d)", f.Name, f.Addr, f.Size, f.Path, f.LineStart, f.LineEnd)
+	}
+	if len(got.Overlays) != 1 || got.Overlays[0].Addr != 0x800B0000 || got.Overlays[0].Length != 0x100 {
+		t.Fatalf("overlays mismatch; expected overlay 4 at 0x800B0000, got %v", got.Overlays)
+	}
+	if symbols := got.Overlays[0].Symbols; len(symbols) != 1 || symbols[0].Name != "ovl_start" {
+		t.Errorf("symbols of overlay mismatch; expected [ovl_start], got %v", symbols)
+	}
+	// Line numbers outside of the start of functions, including the line number
+	// of the scope block recorded when parsing the function.
+	var lines []Line
+	for _, line := range got.Lines {
+		if line.Addr == 0x80010104 || line.Addr == 0x80010108 {
+			lines = append(lines, *line)
+		}
+	}
+	wantLines := []Line{
+		{Addr: 0x80010108, Path: "MAIN.C", Line: 10},
+		{Addr: 0x80010104, Path: "MAIN.C", Line: 11},
+		{Addr: 0x80010108, Path: "MAIN.C", Line: 13},
+	}
+	if !reflect.DeepEqual(lines, wantLines) {
+		t.Errorf("line numbers mismatch; expected %v, got %v", wantLines, lines)
+	}
+}
+
+func TestEncodeType(t *testing.T) {
+	p := NewParser()
+	p.Types["bool"] = &c.VarDecl{Class: c.Typedef, Var: c.Var{Type: c.Int, Name: "bool"}}
+	point := &c.StructType{Tag: "point", Size: 8}
+	golden := []struct {
+		in   c.Type
+		typ  sym.Type
+		dims []uint32
+		tag  string
+	}{
+		{in: c.Int, typ: 0x04},
+		{in: c.SChar, typ: 0x02},
+		// int *f()
+		{in: &c.FuncType{RetType: &c.PointerType{Elem: c.Int}}, typ: 0x64},
+		// int (*f)()
+		{in: &c.PointerType{Elem: &c.FuncType{RetType: c.Int}}, typ: 0x94},
+		// struct point x[2][3]
+		{in: &c.ArrayType{Elem: &c.ArrayType{Elem: point, Len: 3}, Len: 2}, typ: 0xF8, dims: []uint32{2, 3}, tag: "point"},
+		// const unsigned char *
+		{in: &c.PointerType{Elem: &c.QualType{Quals: c.Const, Type: c.UChar}}, typ: 0x1C},
+		// bool
+		{in: p.Types["bool"], typ: 0x00},
+	}
+	for _, g := range golden {
+		typ, dims, tag, err := p.EncodeType(g.in)
+		if err != nil {
+			t.Errorf("unable to encode type %v; %v", g.in, err)
+			continue
+		}
+		if typ != g.typ || !reflect.DeepEqual(dims, g.dims) || tag != g.tag {
+			t.Errorf("type mismatch of %v; expected 0x%02X %v %q, got 0x%02X %v %q", g.in, uint16(g.typ), g.dims, g.tag, uint16(typ), dims, tag)
+		}
+	}
+	if _, _, _, err := p.EncodeType(c.LongLong); err == nil {
+		t.Errorf("expected error for type %v", c.LongLong)
+	}
+}
@@ -0,0 +1,571 @@
+package csym
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+//go:generate stringer -linecomment -type MergePolicy
+
+// MergePolicy specifies how conflicting definitions are resolved when merging
+// the types and declarations of several parsers.
+type MergePolicy uint8
+
+// Merge policies.
+const (
+	// Keep the definition of the preceding parser.
+	PreferFirst MergePolicy = iota + 1 // prefer-first
+	// Keep the definition of a meaningful name over the definition of a
+	// generated name (e.g. "sub_80010000" or "FUN_80010000") at the same
+	// address; otherwise keep the definition of the preceding parser.
+	PreferNamed // prefer-named
+	// Report conflicting definitions as errors.
+	ConflictError // error
+)
+
+// A Conflict is a pair of conflicting definitions encountered when merging.
+type Conflict struct {
+	// Kind of the definitions; e.g. "struct" or "function".
+	Kind string
+	// Name of the discarded definition.
+	Name string
+	// Name of the kept definition; the same as Name for definitions of the same
+	// name.
+	Kept string
+	// Address of the definitions; or 0 for types.
+	Addr uint32
+}
+
+// String returns the string representation of the conflict.
+func (conflict *Conflict) String() string {
+	if conflict.Kept != conflict.Name {
+		// conflicting names of function at 0x80010000; kept "foo", discarded "sub_80010000"
+		return fmt.Sprintf("conflicting names of %s at 0x%08X; kept %q, discarded %q", conflict.Kind, conflict.Addr, conflict.Kept, conflict.Name)
+	}
+	if conflict.Addr != 0 {
+		// conflicting definitions of function "foo" at 0x80010000
+		return fmt.Sprintf("conflicting definitions of %s %q at 0x%08X", conflict.Kind, conflict.Name, conflict.Addr)
+	}
+	return fmt.Sprintf("conflicting definitions of %s %q", conflict.Kind, conflict.Name)
+}
+
+// Merge merges the types and declarations of the given parsers into a single
+// parser, resolving conflicting definitions using the specified policy. The
+// resolved conflicts are returned in order of occurrence.
+//
+// Structurally identical definitions are merged, as are incomplete and complete
+// definitions of the same tag. Types with fake tags are merged by structure and
+// renumbered; other types are merged by tag, and type definitions by name.
+// Global variables and functions are merged by overlay and name (except
+// statics, of which the same name may be declared by several source files) and
+// by overlay and address, as are symbols.
+//
+// The types and declarations of the given parsers are shared with (and may be
+// modified by) the merged parser.
+func Merge(ps []*Parser, policy MergePolicy) (*Parser, []*Conflict, error) {
+	m := &merger{
+		dst:      NewParser(),
+		policy:   policy,
+		overlays: make(map[uint32]*mergeOverlay),
+	}
+	if len(ps) > 0 {
+		m.dst.NativeBool = ps[0].NativeBool
+		m.dst.Dims = ps[0].Dims
+	}
+	for _, p := range ps {
+		if err := m.mergeTypes(p); err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+	}
+	for _, p := range ps {
+		if err := m.mergeDecls(p); err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+	}
+	return m.dst, m.conflicts, nil
+}
+
+// merger tracks the merge of several parsers.
+type merger struct {
+	// Merged parser.
+	dst *Parser
+	// Merge policy.
+	policy MergePolicy
+	// Resolved conflicts.
+	conflicts []*Conflict
+	// Types with fake tags, in order of occurrence.
+	fakes []c.Type
+	// overlays maps from overlay ID to merged overlay.
+	overlays map[uint32]*mergeOverlay
+}
+
+// resolve resolves the given conflict, and reports whether to replace the kept
+// definition by the discarded one (in which case the names of the conflict are
+// swapped). The named arguments report whether the names of the kept and
+// discarded definitions are meaningful.
+func (m *merger) resolve(conflict *Conflict, keptNamed, named bool) (bool, error) {
+	if m.policy == ConflictError {
+		return false, errors.New(conflict.String())
+	}
+	replace := m.policy == PreferNamed && named && !keptNamed
+	if replace {
+		conflict.Name, conflict.Kept = conflict.Kept, conflict.Name
+	}
+	m.conflicts = append(m.conflicts, conflict)
+	return replace, nil
+}
+
+// --- [ Types ] ---------------------------------------------------------------
+
+// mergeTypes merges the struct, union and enum types and the type definitions
+// of the given parser.
+func (m *merger) mergeTypes(p *Parser) error {
+	dst := m.dst
+	for _, tag := range p.StructTags {
+		t := p.Structs[tag]
+		if c.IsFakeTag(t.Tag) {
+			if tag, ok := m.mergeFake(t); ok {
+				// Refer to the structurally identical type by its fake tag.
+				t.Tag = tag
+				continue
+			}
+			dst.Structs[t.Tag] = t
+			dst.StructTags = append(dst.StructTags, t.Tag)
+			continue
+		}
+		prev, ok := dst.Structs[tag]
+		if !ok {
+			dst.Structs[tag] = t
+			dst.StructTags = append(dst.StructTags, tag)
+			continue
+		}
+		replace, err := m.mergeType("struct", tag, prev, t, len(prev.Fields) == 0, len(t.Fields) == 0)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if replace {
+			dst.Structs[tag] = t
+		}
+	}
+	for _, tag := range p.UnionTags {
+		t := p.Unions[tag]
+		if c.IsFakeTag(t.Tag) {
+			if tag, ok := m.mergeFake(t); ok {
+				// Refer to the structurally identical type by its fake tag.
+				t.Tag = tag
+				continue
+			}
+			dst.Unions[t.Tag] = t
+			dst.UnionTags = append(dst.UnionTags, t.Tag)
+			continue
+		}
+		prev, ok := dst.Unions[tag]
+		if !ok {
+			dst.Unions[tag] = t
+			dst.UnionTags = append(dst.UnionTags, tag)
+			continue
+		}
+		replace, err := m.mergeType("union", tag, prev, t, len(prev.Fields) == 0, len(t.Fields) == 0)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if replace {
+			dst.Unions[tag] = t
+		}
+	}
+	for _, tag := range p.EnumTags {
+		t := p.Enums[tag]
+		if c.IsFakeTag(t.Tag) {
+			if tag, ok := m.mergeFake(t); ok {
+				// Refer to the structurally identical type by its fake tag.
+				t.Tag = tag
+				continue
+			}
+			dst.Enums[t.Tag] = t
+			dst.EnumTags = append(dst.EnumTags, t.Tag)
+			continue
+		}
+		prev, ok := dst.Enums[tag]
+		if !ok {
+			dst.Enums[tag] = t
+			dst.EnumTags = append(dst.EnumTags, tag)
+			continue
+		}
+		replace, err := m.mergeType("enum", tag, prev, t, len(prev.Members) == 0, len(t.Members) == 0)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if replace {
+			dst.Enums[tag] = t
+		}
+	}
+	for _, def := range p.Typedefs {
+		def := def.(*c.VarDecl)
+		prev, ok := dst.Types[def.Name].(*c.VarDecl)
+		if !ok {
+			dst.Types[def.Name] = def
+			dst.Typedefs = append(dst.Typedefs, def)
+			continue
+		}
+		replace, err := m.mergeType("type definition", def.Name, prev.Type, def.Type, false, false)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if replace {
+			dst.Types[def.Name] = def
+			for i, t := range dst.Typedefs {
+				if t == prev {
+					dst.Typedefs[i] = def
+				}
+			}
+		}
+	}
+	// Predeclared identifiers (e.g. bool).
+	for name, t := range p.Types {
+		if _, ok := dst.Types[name]; !ok {
+			dst.Types[name] = t
+		}
+	}
+	for _, include := range p.Includes {
+		if !contains(dst.Includes, include) {
+			dst.Includes = append(dst.Includes, include)
+		}
+	}
+	return nil
+}
+
+// mergeType merges the given definition of a type with the preceding definition
+// of the same kind and name, and reports whether to replace the preceding
+// definition. Incomplete definitions are replaced by complete ones.
+func (m *merger) mergeType(kind, name string, prev, t c.Type, prevIncomplete, incomplete bool) (bool, error) {
+	switch {
+	case incomplete || equalType(prev, t):
+		return false, nil
+	case prevIncomplete:
+		return true, nil
+	}
+	conflict := &Conflict{Kind: kind, Name: name, Kept: name}
+	return m.resolve(conflict, true, true)
+}
+
+// mergeFake merges the given type with fake tag with a structurally identical
+// type with fake tag of a preceding parser, and returns the fake tag of the
+// preceding type. The boolean return value reports whether a structurally
+// identical type was present; if not, the type is given a unique fake tag.
+func (m *merger) mergeFake(t c.Type) (string, bool) {
+	for _, prev := range m.fakes {
+		if equalType(prev, t) {
+			return tagOf(prev), true
+		}
+	}
+	tag := fmt.Sprintf("_%dfake", len(m.fakes))
+	switch t := t.(type) {
+	case *c.StructType:
+		t.Tag = tag
+	case *c.UnionType:
+		t.Tag = tag
+	case *c.EnumType:
+		t.Tag = tag
+	}
+	m.fakes = append(m.fakes, t)
+	return tag, false
+}
+
+// --- [ Declarations ] --------------------------------------------------------
+
+// mergeOverlay tracks the declarations of a merged overlay.
+type mergeOverlay struct {
+	*Overlay
+	// vars maps from name of non-static global variable to index in Vars.
+	vars map[string]int
+	// varAddrs maps from address of global variable to index in Vars.
+	varAddrs map[uint32]int
+	// funcs maps from name of non-static function to index in Funcs.
+	funcs map[string]int
+	// funcAddrs maps from address of function to index in Funcs.
+	funcAddrs map[uint32]int
+	// symbols maps from address of symbol to index in Symbols.
+	symbols map[uint32]int
+	// lines records the addresses of line numbers.
+	lines map[uint32]bool
+}
+
+// overlay returns the merged overlay of the given overlay.
+func (m *merger) overlay(overlay *Overlay, isDefault bool) (*mergeOverlay, error) {
+	if o, ok := m.overlays[overlay.ID]; ok {
+		if !isDefault && (o.Addr != overlay.Addr || o.Length != overlay.Length) {
+			conflict := &Conflict{Kind: "overlay", Name: fmt.Sprintf("%d", overlay.ID), Kept: fmt.Sprintf("%d", overlay.ID), Addr: overlay.Addr}
+			if _, err := m.resolve(conflict, true, true); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+		return o, nil
+	}
+	dst := m.dst.Overlay
+	if !isDefault {
+		dst = &Overlay{
+			Addr:      overlay.Addr,
+			ID:        overlay.ID,
+			Length:    overlay.Length,
+			varNames:  make(map[string]*c.VarDecl),
+			funcNames: make(map[string]*c.FuncDecl),
+		}
+		m.dst.Overlays = append(m.dst.Overlays, dst)
+		m.dst.overlayIDs[dst.ID] = dst
+	}
+	o := &mergeOverlay{
+		Overlay:   dst,
+		vars:      make(map[string]int),
+		varAddrs:  make(map[uint32]int),
+		funcs:     make(map[string]int),
+		funcAddrs: make(map[uint32]int),
+		symbols:   make(map[uint32]int),
+		lines:     make(map[uint32]bool),
+	}
+	m.overlays[overlay.ID] = o
+	return o, nil
+}
+
+// mergeDecls merges the declarations of the default binary and of the overlays
+// of the given parser.
+func (m *merger) mergeDecls(p *Parser) error {
+	overlays := append([]*Overlay{p.Overlay}, p.Overlays...)
+	for i, overlay := range overlays {
+		o, err := m.overlay(overlay, i == 0)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, v := range overlay.Vars {
+			if err := m.mergeVar(o, v); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		for _, f := range overlay.Funcs {
+			if err := m.mergeFunc(o, f); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		for _, s := range overlay.Symbols {
+			if err := m.mergeSymbol(o, s); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		for _, line := range overlay.Lines {
+			if !o.lines[line.Addr] {
+				o.lines[line.Addr] = true
+				o.Lines = append(o.Lines, line)
+			}
+		}
+	}
+	return nil
+}
+
+// mergeVar merges the given global variable into the merged overlay.
+func (m *merger) mergeVar(o *mergeOverlay, v *c.VarDecl) error {
+	static := v.Class == c.Static
+	i, ok := o.vars[v.Name]
+	if ok && !static {
+		prev := o.Vars[i]
+		if prev.Addr == v.Addr && equalType(prev.Type, v.Type) {
+			return nil
+		}
+		conflict := &Conflict{Kind: "global variable", Name: v.Name, Kept: v.Name, Addr: v.Addr}
+		replace, err := m.resolve(conflict, true, true)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if replace {
+			o.replaceVar(i, v)
+		}
+		return nil
+	}
+	if i, ok := o.varAddrs[v.Addr]; ok && v.Addr != 0 {
+		prev := o.Vars[i]
+		if prev.Name == v.Name {
+			// Static variable declared by several source files.
+			return nil
+		}
+		conflict := &Conflict{Kind: "global variable", Name: v.Name, Kept: prev.Name, Addr: v.Addr}
+		replace, err := m.resolve(conflict, !isGeneratedName(prev.Name, v.Addr), !isGeneratedName(v.Name, v.Addr))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if replace {
+			o.replaceVar(i, v)
+		}
+		return nil
+	}
+	o.addVar(v)
+	return nil
+}
+
+// addVar adds the given global variable to the merged overlay.
+func (o *mergeOverlay) addVar(v *c.VarDecl) {
+	i := len(o.Vars)
+	o.Vars = append(o.Vars, v)
+	o.varNames[v.Name] = v
+	if v.Class != c.Static {
+		o.vars[v.Name] = i
+	}
+	if _, ok := o.varAddrs[v.Addr]; !ok {
+		o.varAddrs[v.Addr] = i
+	}
+}
+
+// replaceVar replaces the global variable at the given index of the merged
+// overlay.
+func (o *mergeOverlay) replaceVar(i int, v *c.VarDecl) {
+	prev := o.Vars[i]
+	if o.vars[prev.Name] == i {
+		delete(o.vars, prev.Name)
+	}
+	if o.varAddrs[prev.Addr] == i {
+		delete(o.varAddrs, prev.Addr)
+	}
+	delete(o.varNames, prev.Name)
+	o.Vars[i] = v
+	o.varNames[v.Name] = v
+	if v.Class != c.Static {
+		o.vars[v.Name] = i
+	}
+	o.varAddrs[v.Addr] = i
+}
+
+// mergeFunc merges the given function into the merged overlay.
+func (m *merger) mergeFunc(o *mergeOverlay, f *c.FuncDecl) error {
+	static := f.Class == c.Static
+	i, ok := o.funcs[f.Name]
+	if ok && !static {
+		prev := o.Funcs[i]
+		if prev.Addr == f.Addr && equalType(prev.Type, f.Type) {
+			return nil
+		}
+		conflict := &Conflict{Kind: "function", Name: f.Name, Kept: f.Name, Addr: f.Addr}
+		replace, err := m.resolve(conflict, true, true)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if replace {
+			o.replaceFunc(i, f)
+		}
+		return nil
+	}
+	if i, ok := o.funcAddrs[f.Addr]; ok && f.Addr != 0 {
+		prev := o.Funcs[i]
+		if prev.Name == f.Name {
+			// Static function declared by several source files.
+			return nil
+		}
+		conflict := &Conflict{Kind: "function", Name: f.Name, Kept: prev.Name, Addr: f.Addr}
+		replace, err := m.resolve(conflict, !isGeneratedName(prev.Name, f.Addr), !isGeneratedName(f.Name, f.Addr))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if replace {
+			o.replaceFunc(i, f)
+		}
+		return nil
+	}
+	o.addFunc(f)
+	return nil
+}
+
+// addFunc adds the given function to the merged overlay.
+func (o *mergeOverlay) addFunc(f *c.FuncDecl) {
+	i := len(o.Funcs)
+	o.Funcs = append(o.Funcs, f)
+	o.funcNames[f.Name] = f
+	if f.Class != c.Static {
+		o.funcs[f.Name] = i
+	}
+	if _, ok := o.funcAddrs[f.Addr]; !ok {
+		o.funcAddrs[f.Addr] = i
+	}
+}
+
+// replaceFunc replaces the function at the given index of the merged overlay.
+func (o *mergeOverlay) replaceFunc(i int, f *c.FuncDecl) {
+	prev := o.Funcs[i]
+	if o.funcs[prev.Name] == i {
+		delete(o.funcs, prev.Name)
+	}
+	if o.funcAddrs[prev.Addr] == i {
+		delete(o.funcAddrs, prev.Addr)
+	}
+	delete(o.funcNames, prev.Name)
+	o.Funcs[i] = f
+	o.funcNames[f.Name] = f
+	if f.Class != c.Static {
+		o.funcs[f.Name] = i
+	}
+	o.funcAddrs[f.Addr] = i
+}
+
+// mergeSymbol merges the given symbol into the merged overlay.
+func (m *merger) mergeSymbol(o *mergeOverlay, s *Symbol) error {
+	i, ok := o.symbols[s.Addr]
+	if !ok {
+		o.symbols[s.Addr] = len(o.Symbols)
+		o.Symbols = append(o.Symbols, s)
+		return nil
+	}
+	prev := o.Symbols[i]
+	if prev.Name == s.Name {
+		return nil
+	}
+	conflict := &Conflict{Kind: "symbol", Name: s.Name, Kept: prev.Name, Addr: s.Addr}
+	replace, err := m.resolve(conflict, !isGeneratedName(prev.Name, s.Addr), !isGeneratedName(s.Name, s.Addr))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if replace {
+		o.Symbols[i] = s
+	}
+	return nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// equalType reports whether the given types are structurally identical, taking
+// into account the unique names given to members of duplicate enums.
+func equalType(a, b c.Type) bool {
+	if _, ok := a.(*c.EnumType); ok {
+		_, ok := b.(*c.EnumType)
+		return ok && enumEqual(a, b)
+	}
+	return c.Equal(a, b)
+}
+
+// tagOf returns the tag of the given struct, union or enum type.
+func tagOf(t c.Type) string {
+	switch t := t.(type) {
+	case *c.StructType:
+		return t.Tag
+	case *c.UnionType:
+		return t.Tag
+	case *c.EnumType:
+		return t.Tag
+	}
+	panic(fmt.Errorf("support for type %T not yet implemented", t))
+}
+
+// isGeneratedName reports whether the given name of a declaration at the given
+// address is generated (e.g. "sub_80010000", "FUN_80010000" or "D_80010000"),
+// rather than meaningful; i.e. whether the name contains the address in
+// hexadecimal.
+func isGeneratedName(name string, addr uint32) bool {
+	return strings.Contains(strings.ToUpper(name), fmt.Sprintf("%08X", addr))
+}
+
+// contains reports whether the given list of strings contains s.
+func contains(ss []string, s string) bool {
+	for _, t := range ss {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package csym
+
+import (
+	"testing"
+
+	"github.com/sanctuary/sym/csym/c"
+)
+
+func TestMerge(t *testing.T) {
+	// newParsers returns the parsers to merge; the base game and a community
+	// symbol file.
+	newParsers := func() []*Parser {
+		base := NewParser()
+		// struct point { int x; };
+		point := &c.StructType{Size: 4, Tag: "point", Fields: []c.Field{{Var: c.Var{Type: c.Int, Name: "x"}}}}
+		// struct _0fake { int a; };
+		anon := &c.StructType{Size: 4, Tag: "_0fake", Fields: []c.Field{{Var: c.Var{Type: c.Int, Name: "a"}}}}
+		for _, t := range []*c.StructType{point, anon} {
+			base.Structs[t.Tag] = t
+			base.StructTags = append(base.StructTags, t.Tag)
+		}
+		base.Funcs = []*c.FuncDecl{
+			{Addr: 0x80010000, Class: c.Extern, Var: c.Var{Type: &c.FuncType{RetType: c.Int}, Name: "main"}},
+			{Addr: 0x80010100, Class: c.Extern, Var: c.Var{Type: &c.FuncType{RetType: c.Void}, Name: "sub_80010100"}},
+		}
+		base.Vars = []*c.VarDecl{
+			{Addr: 0x80020000, Class: c.Extern, Var: c.Var{Type: c.Int, Name: "gVar"}},
+		}
+		community := NewParser()
+		// struct point;
+		fwd := &c.StructType{Tag: "point"}
+		// struct _0fake { short b; };
+		other := &c.StructType{Size: 2, Tag: "_0fake", Fields: []c.Field{{Var: c.Var{Type: c.Short, Name: "b"}}}}
+		// struct _1fake { int a; };
+		dup := &c.StructType{Size: 4, Tag: "_1fake", Fields: []c.Field{{Var: c.Var{Type: c.Int, Name: "a"}}}}
+		for _, t := range []*c.StructType{fwd, other, dup} {
+			community.Structs[t.Tag] = t
+			community.StructTags = append(community.StructTags, t.Tag)
+		}
+		community.Funcs = []*c.FuncDecl{
+			{Addr: 0x80010000, Class: c.Extern, Var: c.Var{Type: &c.FuncType{RetType: c.Int}, Name: "main"}},
+			{Addr: 0x80010100, Class: c.Extern, Var: c.Var{Type: &c.FuncType{RetType: c.Void}, Name: "Player_Update"}},
+		}
+		community.Vars = []*c.VarDecl{
+			{Addr: 0x80020004, Class: c.Extern, Var: c.Var{Type: c.Int, Name: "gVar"}},
+		}
+		return []*Parser{base, community}
+	}
+	golden := []struct {
+		policy    MergePolicy
+		funcs     []string
+		gVarAddr  uint32
+		conflicts []string
+	}{
+		{
+			policy:   PreferFirst,
+			funcs:    []string{"main", "sub_80010100"},
+			gVarAddr: 0x80020000,
+			conflicts: []string{
+				`conflicting definitions of global variable "gVar" at 0x80020004`,
+				`conflicting names of function at 0x80010100; kept "sub_80010100", discarded "Player_Update"`,
+			},
+		},
+		{
+			policy:   PreferNamed,
+			funcs:    []string{"main", "Player_Update"},
+			gVarAddr: 0x80020000,
+			conflicts: []string{
+				`conflicting definitions of global variable "gVar" at 0x80020004`,
+				`conflicting names of function at 0x80010100; kept "Player_Update", discarded "sub_80010100"`,
+			},
+		},
+	}
+	for _, g := range golden {
+		p, conflicts, err := Merge(newParsers(), g.policy)
+		if err != nil {
+			t.Errorf("%v: unable to merge parsers; %v", g.policy, err)
+			continue
+		}
+		wantTags := []string{"point", "_0fake", "_1fake"}
+		if len(p.StructTags) != len(wantTags) {
+			t.Errorf("%v: struct tags mismatch; expected %v, got %v", g.policy, wantTags, p.StructTags)
+		} else {
+			for i, want := range wantTags {
+				if p.StructTags[i] != want {
+					t.Errorf("%v: struct tag %d mismatch; expected %q, got %q", g.policy, i, want, p.StructTags[i])
+				}
+			}
+		}
+		if point := p.Structs["point"]; point.Size != 4 {
+			t.Errorf("%v: expected complete definition of struct point, got %v", g.policy, point.Def())
+		}
+		if len(p.Funcs) != len(g.funcs) {
+			t.Errorf("%v: number of functions mismatch; expected %d, got %d", g.policy, len(g.funcs), len(p.Funcs))
+			continue
+		}
+		for i, want := range g.funcs {
+			if got := p.Funcs[i].Name; got != want {
+				t.Errorf("%v: function %d mismatch; expected %q, got %q", g.policy, i, want, got)
+			}
+		}
+		if len(p.Vars) != 1 || p.Vars[0].Addr != g.gVarAddr {
+			t.Errorf("%v: global variable mismatch; expected gVar at 0x%08X, got %v", g.policy, g.gVarAddr, p.Vars)
+		}
+		if len(conflicts) != len(g.conflicts) {
+			t.Errorf("%v: number of conflicts mismatch; expected %d, got %d (%v)", g.policy, len(g.conflicts), len(conflicts), conflicts)
+			continue
+		}
+		for i, want := range g.conflicts {
+			if got := conflicts[i].String(); got != want {
+				t.Errorf("%v: conflict %d mismatch; expected %q, got %q", g.policy, i, want, got)
+			}
+		}
+	}
+	if _, _, err := Merge(newParsers(), ConflictError); err == nil {
+		t.Errorf("%v: expected error for conflicting definitions", ConflictError)
+	}
+}
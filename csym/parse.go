@@ -2,6 +2,7 @@
 package csym
 
 import (
+	"github.com/sanctuary/sym"
 	"github.com/sanctuary/sym/csym/c"
 )
 
@@ -27,6 +28,27 @@ type Parser struct {
 	Typedefs []c.Type
 	// Tracks unique enum member names.
 	enumMembers map[string]bool
+	// System headers required by the type information (e.g. "stdint.h").
+	Includes []string
+	// Units maps from struct, union and enum types, type definitions and global
+	// variable declarations to the source file of the translation unit defining
+	// them; as located by the function start symbol succeeding their definition
+	// in the SYM file. Definitions not succeeded by a function of known source
+	// file are not mapped.
+	Units map[c.Type]string
+	// NativeBool specifies whether to translate the NULL base type into the bool
+	// base type, rather than into a bool type definition of int.
+	NativeBool bool
+	// Dims specifies the layout of array dimensions of Def2 symbols (see
+	// sym.Dialect).
+	Dims sym.DimsLayout
+	// VariadicFuncs specifies the names of functions known to be variadic.
+	//
+	// SYM files do not record whether a function is variadic, so the variadic
+	// flag of function prototypes is set based on function name. NewParser
+	// initializes the set with the variadic functions of the Psy-Q C library,
+	// which may be extended or replaced before parsing.
+	VariadicFuncs map[string]bool
 
 	// Declarations.
 	*Overlay // default binary
@@ -46,15 +68,35 @@ func NewParser() *Parser {
 		varNames:  make(map[string]*c.VarDecl),
 		funcNames: make(map[string]*c.FuncDecl),
 	}
-	return &Parser{
-		Structs:     make(map[string]*c.StructType),
-		Unions:      make(map[string]*c.UnionType),
-		Enums:       make(map[string]*c.EnumType),
-		Types:       make(map[string]c.Type),
-		enumMembers: make(map[string]bool),
-		Overlay:     overlay,
-		overlayIDs:  make(map[uint32]*Overlay),
-		curOverlay:  overlay,
+	p := &Parser{
+		Structs:       make(map[string]*c.StructType),
+		Unions:        make(map[string]*c.UnionType),
+		Enums:         make(map[string]*c.EnumType),
+		Types:         make(map[string]c.Type),
+		Units:         make(map[c.Type]string),
+		enumMembers:   make(map[string]bool),
+		Overlay:       overlay,
+		overlayIDs:    make(map[uint32]*Overlay),
+		curOverlay:    overlay,
+		VariadicFuncs: make(map[string]bool),
+	}
+	for _, name := range variadicFuncs {
+		p.VariadicFuncs[name] = true
+	}
+	return p
+}
+
+// setUnits maps the given definitions to the translation unit of the given
+// source file, unless already mapped (e.g. types of headers included by several
+// translation units).
+func (p *Parser) setUnits(defs []c.Type, path string) {
+	if len(path) == 0 {
+		return
+	}
+	for _, def := range defs {
+		if _, ok := p.Units[def]; !ok {
+			p.Units[def] = path
+		}
 	}
 }
 
@@ -8,7 +8,12 @@ import (
 )
 
 // ParseDecls parses the symbols into the equivalent C declarations.
+//
+// Global variables are mapped to the translation unit of the succeeding
+// function (see Parser.Units).
 func (p *Parser) ParseDecls(syms []*sym.Symbol) {
+	// Global variables pending translation unit.
+	var pending []c.Type
 	for i := 0; i < len(syms); i++ {
 		s := syms[i]
 		switch body := s.Body.(type) {
@@ -16,17 +21,25 @@ func (p *Parser) ParseDecls(syms []*sym.Symbol) {
 			p.parseSymbol(s.Hdr.Value, body.Name)
 		case *sym.Name2:
 			p.parseSymbol(s.Hdr.Value, body.Name)
+		case *sym.Name5:
+			p.parseSymbol(s.Hdr.Value, body.Name)
+		case *sym.Name6:
+			p.parseSymbol(s.Hdr.Value, body.Name)
 		case *sym.SetSLD2:
 			n := p.parseLineNumbers(s.Hdr.Value, body, syms[i+1:])
 			i += n
 		case *sym.FuncStart:
+			p.setUnits(pending, body.Path)
+			pending = nil
 			n := p.parseFunc(s.Hdr.Value, body, syms[i+1:])
 			i += n
 		case *sym.Def:
 			switch body.Class {
 			case sym.ClassEXT, sym.ClassSTAT:
 				t := p.parseType(body.Type, nil, "")
-				p.parseGlobalDecl(s.Hdr.Value, body.Size, body.Class, t, body.Name)
+				if v := p.parseGlobalDecl(s.Hdr.Value, body.Size, body.Class, t, body.Name); v != nil {
+					pending = append(pending, v)
+				}
 			case sym.ClassMOS, sym.ClassSTRTAG, sym.ClassMOU, sym.ClassUNTAG, sym.ClassTPDEF, sym.ClassENTAG, sym.ClassMOE, sym.ClassFIELD:
 				// nothing to do.
 			default:
@@ -36,7 +49,9 @@ func (p *Parser) ParseDecls(syms []*sym.Symbol) {
 			switch body.Class {
 			case sym.ClassEXT, sym.ClassSTAT:
 				t := p.parseType(body.Type, body.Dims, body.Tag)
-				p.parseGlobalDecl(s.Hdr.Value, body.Size, body.Class, t, body.Name)
+				if v := p.parseGlobalDecl(s.Hdr.Value, body.Size, body.Class, t, body.Name); v != nil {
+					pending = append(pending, v)
+				}
 			case sym.ClassMOS, sym.ClassMOU, sym.ClassTPDEF, sym.ClassMOE, sym.ClassFIELD, sym.ClassEOS:
 				// nothing to do.
 			default:
@@ -50,6 +65,8 @@ func (p *Parser) ParseDecls(syms []*sym.Symbol) {
 				panic(fmt.Errorf("unable to locate overlay with ID %x", s.Hdr.Value))
 			}
 			p.curOverlay = overlay
+		case *sym.UnknownBody:
+			// Skip symbols of unknown kind, as captured in lenient parse mode.
 		default:
 			panic(fmt.Sprintf("support for symbol type %T not yet implemented", body))
 		}
@@ -144,6 +161,7 @@ func (p *Parser) parseFunc(addr uint32, body *sym.FuncStart, syms []*sym.Symbol)
 		}
 	}
 	f.Path = body.Path
+	funcType.Variadic = p.VariadicFuncs[body.Name]
 	// Parse function declaration.
 	f.LineStart = body.Line
 	curLine := Line{
@@ -239,8 +257,9 @@ func (p *Parser) parseLocalDecl(addr, size uint32, class sym.Class, t c.Type, na
 //       Blocks []*Block
 //    }
 
-// parseGlobalDecl parses a global declaration symbol.
-func (p *Parser) parseGlobalDecl(addr, size uint32, class sym.Class, t c.Type, name string) {
+// parseGlobalDecl parses a global declaration symbol, and returns the variable
+// declaration; or nil if a function declaration.
+func (p *Parser) parseGlobalDecl(addr, size uint32, class sym.Class, t c.Type, name string) *c.VarDecl {
 	name = validName(name)
 	if _, ok := t.(*c.FuncType); ok {
 		// Make name unique if already present.
@@ -248,8 +267,10 @@ func (p *Parser) parseGlobalDecl(addr, size uint32, class sym.Class, t c.Type, n
 			name = UniqueName(name, addr)
 		}
 		f := &c.FuncDecl{
-			Addr: addr,
-			Size: size,
+			Addr:    addr,
+			Size:    size,
+			Class:   parseClass(class),
+			Overlay: p.curOverlay.ID,
 			Var: c.Var{
 				Type: t,
 				Name: name,
@@ -257,16 +278,17 @@ func (p *Parser) parseGlobalDecl(addr, size uint32, class sym.Class, t c.Type, n
 		}
 		p.curOverlay.Funcs = append(p.curOverlay.Funcs, f)
 		p.curOverlay.funcNames[name] = f
-		return
+		return nil
 	}
 	// Make name unique if already present.
 	if _, ok := p.curOverlay.varNames[name]; ok {
 		name = UniqueName(name, addr)
 	}
 	v := &c.VarDecl{
-		Addr:  addr,
-		Size:  size,
-		Class: parseClass(class),
+		Addr:    addr,
+		Size:    size,
+		Class:   parseClass(class),
+		Overlay: p.curOverlay.ID,
 		Var: c.Var{
 			Type: t,
 			Name: name,
@@ -274,6 +296,7 @@ func (p *Parser) parseGlobalDecl(addr, size uint32, class sym.Class, t c.Type, n
 	}
 	p.curOverlay.Vars = append(p.curOverlay.Vars, v)
 	p.curOverlay.varNames[name] = v
+	return v
 }
 
 // parseOverlay parses an overlay symbol.
@@ -0,0 +1,84 @@
+package csym
+
+import (
+	"testing"
+
+	"github.com/sanctuary/sym"
+)
+
+func TestUnits(t *testing.T) {
+	def := func(value uint32, class sym.Class, typ sym.Type, size uint32, name string) *sym.Symbol {
+		return &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: value, Kind: sym.KindDef},
+			Body: &sym.Def{Class: class, Type: typ, Size: size, Name: name},
+		}
+	}
+	def2 := func(value uint32, class sym.Class, typ sym.Type, size uint32, tag, name string) *sym.Symbol {
+		return &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: value, Kind: sym.KindDef2},
+			Body: &sym.Def2{Class: class, Type: typ, Size: size, Tag: tag, Name: name},
+		}
+	}
+	funcStart := func(addr uint32, path, name string) *sym.Symbol {
+		return &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: addr, Kind: sym.KindFuncStart},
+			Body: &sym.FuncStart{FP: 29, RetReg: 31, Path: path, Name: name},
+		}
+	}
+	funcEnd := func(addr uint32) *sym.Symbol {
+		return &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: addr, Kind: sym.KindFuncEnd},
+			Body: &sym.FuncEnd{},
+		}
+	}
+	syms := []*sym.Symbol{
+		// foo.c: struct point { int x; }; typedef int s32; int gX;
+		def(0, sym.ClassSTRTAG, sym.Type(sym.BaseStruct), 4, "point"),
+		def(0, sym.ClassMOS, sym.Type(sym.BaseInt), 4, "x"),
+		def2(4, sym.ClassEOS, 0, 4, "point", ".eos"),
+		def(0, sym.ClassTPDEF, sym.Type(sym.BaseInt), 4, "s32"),
+		def(0x80020000, sym.ClassEXT, sym.Type(sym.BaseInt), 4, "gX"),
+		def(0x80010000, sym.ClassEXT, 0x24, 0x10, "foo"),
+		funcStart(0x80010000, `C:\SRC\FOO.C`, "foo"),
+		funcEnd(0x80010010),
+		// bar.c: enum color { RED }; struct point gOrigin;
+		def(0, sym.ClassENTAG, sym.Type(sym.BaseEnum), 4, "color"),
+		def(0, sym.ClassMOE, sym.Type(sym.BaseMOE), 0, "RED"),
+		def2(4, sym.ClassEOS, 0, 4, "color", ".eos"),
+		def2(0x80020004, sym.ClassEXT, sym.Type(sym.BaseStruct), 4, "point", "gOrigin"),
+		def(0x80010010, sym.ClassEXT, 0x24, 0x10, "bar"),
+		funcStart(0x80010010, `C:\SRC\BAR.C`, "bar"),
+		funcEnd(0x80010020),
+		// Not succeeded by function.
+		def(0x80020008, sym.ClassEXT, sym.Type(sym.BaseInt), 4, "gY"),
+	}
+	p := NewParser()
+	p.ParseTypes(syms)
+	p.ParseDecls(syms)
+	// units maps from definition name to source file of translation unit.
+	units := map[string]string{
+		"struct point": p.Units[p.Structs["point"]],
+		"s32":          p.Units[p.Types["s32"]],
+		"enum color":   p.Units[p.Enums["color"]],
+	}
+	for _, v := range p.Vars {
+		units[v.Name] = p.Units[v]
+	}
+	want := map[string]string{
+		"struct point": `C:\SRC\FOO.C`,
+		"s32":          `C:\SRC\FOO.C`,
+		"gX":           `C:\SRC\FOO.C`,
+		"enum color":   `C:\SRC\BAR.C`,
+		"gOrigin":      `C:\SRC\BAR.C`,
+		// Not mapped.
+		"gY": "",
+	}
+	if len(units) != len(want) {
+		t.Fatalf("number of definitions mismatch; expected %d, got %d (%v)", len(want), len(units), units)
+	}
+	for name, srcPath := range want {
+		if got := units[name]; got != srcPath {
+			t.Errorf("translation unit mismatch of %s; expected %q, got %q", name, srcPath, got)
+		}
+	}
+}
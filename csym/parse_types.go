@@ -9,8 +9,13 @@ import (
 )
 
 // ParseTypes parses the SYM types into the equivalent C types.
+//
+// Types are mapped to the translation unit of the succeeding function (see
+// Parser.Units).
 func (p *Parser) ParseTypes(syms []*sym.Symbol) {
 	p.initTaggedTypes(syms)
+	// Types pending translation unit.
+	var pending []c.Type
 	// Parse symbols.
 	for i := 0; i < len(syms); i++ {
 		s := syms[i]
@@ -18,24 +23,32 @@ func (p *Parser) ParseTypes(syms []*sym.Symbol) {
 		case *sym.Def:
 			switch body.Class {
 			case sym.ClassSTRTAG:
+				pending = append(pending, findStruct(p, validName(body.Name), body.Size))
 				n := p.parseStructTag(body, syms[i+1:])
 				i += n
 			case sym.ClassUNTAG:
+				pending = append(pending, findUnion(p, validName(body.Name), body.Size))
 				n := p.parseUnionTag(body, syms[i+1:])
 				i += n
 			case sym.ClassENTAG:
+				pending = append(pending, findEnum(p, validName(body.Name)))
 				n := p.parseEnumTag(body, syms[i+1:])
 				i += n
 			case sym.ClassTPDEF:
 				// TODO: Replace with parseDef?
 				p.parseTypedef(body.Type, nil, "", body.Name)
+				pending = append(pending, p.Typedefs[len(p.Typedefs)-1])
 			}
 		case *sym.Def2:
 			switch body.Class {
 			case sym.ClassTPDEF:
 				// TODO: Replace with parseDef?
 				p.parseTypedef(body.Type, body.Dims, body.Tag, body.Name)
+				pending = append(pending, p.Typedefs[len(p.Typedefs)-1])
 			}
+		case *sym.FuncStart:
+			p.setUnits(pending, body.Path)
+			pending = nil
 		}
 	}
 }
@@ -86,8 +99,10 @@ func (p *Parser) initTaggedTypes(syms []*sym.Symbol) {
 				p.UnionTags = append(p.UnionTags, tag)
 			case sym.ClassENTAG:
 				tag = uniqueTag(tag, enumTags)
+				// Enumerators are of type int.
 				t := &c.EnumType{
-					Tag: tag,
+					Tag:    tag,
+					Signed: true,
 				}
 				p.Enums[tag] = t
 				p.EnumTags = append(p.EnumTags, tag)
@@ -119,16 +134,7 @@ func (p *Parser) parseStructTag(body *sym.Def, syms []*sym.Symbol) (n int) {
 				}
 				t.Fields = append(t.Fields, field)
 			case sym.ClassFIELD:
-				// TODO: Figure out what FIELD represents. Use method for now.
-				method := c.Field{
-					Offset: s.Hdr.Value,
-					Size:   body.Size,
-					Var: c.Var{
-						Type: p.parseType(body.Type, nil, ""),
-						Name: validName(body.Name),
-					},
-				}
-				t.Methods = append(t.Methods, method)
+				t.Fields = append(t.Fields, p.parseBitfield(s.Hdr.Value, body.Size, body.Type, nil, "", body.Name))
 			default:
 				panic(fmt.Errorf("support for class %q not yet implemented", body.Class))
 			}
@@ -144,6 +150,8 @@ func (p *Parser) parseStructTag(body *sym.Def, syms []*sym.Symbol) (n int) {
 					},
 				}
 				t.Fields = append(t.Fields, field)
+			case sym.ClassFIELD:
+				t.Fields = append(t.Fields, p.parseBitfield(s.Hdr.Value, body.Size, body.Type, body.Dims, body.Tag, body.Name))
 			case sym.ClassEOS:
 				return n + 1
 			default:
@@ -154,6 +162,21 @@ func (p *Parser) parseStructTag(body *sym.Def, syms []*sym.Symbol) (n int) {
 	panic("unreachable")
 }
 
+// parseBitfield parses a bitfield member of a struct. The value of FIELD
+// symbols specifies the bit offset of the member, and the size specifies its
+// width in bits.
+func (p *Parser) parseBitfield(bitOffset, bitWidth uint32, t sym.Type, dims []uint32, tag, name string) c.Field {
+	return c.Field{
+		Offset:    bitOffset / 8,
+		BitOffset: bitOffset % 8,
+		BitWidth:  bitWidth,
+		Var: c.Var{
+			Type: p.parseType(t, dims, tag),
+			Name: validName(name),
+		},
+	}
+}
+
 // parseUnionTag parses a union tag sequence of symbols.
 func (p *Parser) parseUnionTag(body *sym.Def, syms []*sym.Symbol) (n int) {
 	if base := body.Type.Base(); base != sym.BaseUnion {
@@ -236,6 +259,30 @@ func (p *Parser) parseEnumTag(body *sym.Def, syms []*sym.Symbol) (n int) {
 	panic("unreachable")
 }
 
+// InlineTypedefEnums defines enums inline in the first type definition of the
+// enum (e.g. "typedef enum {...} Name;"), rather than separately. References to
+// the enum use the name of the type definition.
+func (p *Parser) InlineTypedefEnums() {
+	for _, def := range p.Typedefs {
+		v, ok := def.(*c.VarDecl)
+		if !ok {
+			continue
+		}
+		if t, ok := v.Type.(*c.EnumType); ok && t.Typedef == nil {
+			t.Typedef = v
+		}
+	}
+}
+
+// HexFlagEnums prints the member values of enums likely to be sets of bit
+// flags in hexadecimal, rather than decimal (see isFlagEnum).
+func (p *Parser) HexFlagEnums() {
+	for _, tag := range p.EnumTags {
+		t := p.Enums[tag]
+		t.Hex = isFlagEnum(t)
+	}
+}
+
 // parseTypedef parses a typedef symbol.
 func (p *Parser) parseTypedef(t sym.Type, dims []uint32, tag, name string) {
 	name = validName(name)
@@ -280,6 +327,32 @@ func uniqueEnum(name string, members map[string]bool) string {
 	return newName
 }
 
+// isFlagEnum reports whether the given enum is likely a set of bit flags; i.e.
+// an enum of at least three members, the non-zero values of which are distinct
+// powers of two, at least one of which is above 2. Enums of consecutive values
+// starting at 0 (e.g. {0, 1, 2}) are enumerations rather than bit flags.
+func isFlagEnum(t *c.EnumType) bool {
+	if len(t.Members) < 3 {
+		return false
+	}
+	seen := make(map[uint32]bool)
+	max := uint32(0)
+	for _, member := range t.Members {
+		v := member.Value
+		if v == 0 {
+			continue
+		}
+		if v&(v-1) != 0 || seen[v] {
+			return false
+		}
+		seen[v] = true
+		if v > max {
+			max = v
+		}
+	}
+	return max > 2
+}
+
 // findStruct returns the struct with the given tag and size.
 func findStruct(p *Parser, tag string, size uint32) *c.StructType {
 	newTag := tag
@@ -328,7 +401,11 @@ func findEnum(p *Parser, tag string) *c.EnumType {
 // parseType parses the SYM type into the equivalent C type.
 func (p *Parser) parseType(t sym.Type, dims []uint32, tag string) c.Type {
 	u := p.parseBase(t.Base(), tag)
-	return parseMods(u, t.Mods(), dims)
+	mods := t.Mods()
+	if p.Dims == sym.DimsFlat {
+		mods = flattenArrays(mods, dims)
+	}
+	return parseMods(u, mods, dims)
 }
 
 // parseBase parses the SYM base type into the equivalent C type.
@@ -336,6 +413,9 @@ func (p *Parser) parseBase(base sym.Base, tag string) c.Type {
 	tag = validName(tag)
 	switch base {
 	case sym.BaseNull:
+		if p.NativeBool {
+			return c.Bool
+		}
 		return p.Types["bool"]
 	case sym.BaseVoid:
 		return c.Void
@@ -347,6 +427,10 @@ func (p *Parser) parseBase(base sym.Base, tag string) c.Type {
 		return c.Int
 	case sym.BaseLong:
 		return c.Long
+	case sym.BaseFloat:
+		return c.Float
+	case sym.BaseDouble:
+		return c.Double
 	case sym.BaseStruct:
 		t, ok := p.Structs[tag]
 		if !ok {
@@ -380,8 +464,18 @@ func (p *Parser) parseBase(base sym.Base, tag string) c.Type {
 }
 
 // parseMods parses the SYM type modifiers into the equivalent C type modifiers.
+//
+// Type modifiers are stored from outermost to innermost, as are the dimensions
+// of array modifiers; e.g. the type of `int x[2][3]` is "ARY ARY INT" and its
+// dimensions are [2, 3].
 func parseMods(t c.Type, mods []sym.Mod, dims []uint32) c.Type {
-	j := 0
+	// Index of dimension of the innermost array modifier.
+	j := -1
+	for _, mod := range mods {
+		if mod == sym.ModArray {
+			j++
+		}
+	}
 	for i := len(mods) - 1; i >= 0; i-- {
 		mod := mods[i]
 		switch mod {
@@ -392,16 +486,39 @@ func parseMods(t c.Type, mods []sym.Mod, dims []uint32) c.Type {
 				RetType: t,
 			}
 		case sym.ModArray:
+			// Use unknown array length if dimension is missing.
+			var n int
+			if j < len(dims) {
+				n = int(dims[j])
+			}
 			t = &c.ArrayType{
 				Elem: t,
-				Len:  int(dims[j]),
+				Len:  n,
 			}
-			j++
+			j--
 		}
 	}
 	return t
 }
 
+// flattenArrays returns the type modifiers of a definition with flat array
+// dimensions, merging consecutive array modifiers into a single array modifier
+// of the total number of elements; e.g. the type of `int x[2][3]` with
+// dimensions [6] is translated into `int x[6]`.
+func flattenArrays(mods []sym.Mod, dims []uint32) []sym.Mod {
+	if len(dims) != 1 {
+		return mods
+	}
+	var flat []sym.Mod
+	for i, mod := range mods {
+		if mod == sym.ModArray && i > 0 && mods[i-1] == sym.ModArray {
+			continue
+		}
+		flat = append(flat, mod)
+	}
+	return flat
+}
+
 // validName returns a valid C identifier based on the given name.
 func validName(name string) string {
 	f := func(r rune) rune {
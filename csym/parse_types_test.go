@@ -0,0 +1,85 @@
+package csym
+
+import (
+	"testing"
+
+	"github.com/sanctuary/sym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+func TestParseMods(t *testing.T) {
+	golden := []struct {
+		mods []sym.Mod
+		dims []uint32
+		want string
+	}{
+		// int x[2][3]
+		{mods: []sym.Mod{sym.ModArray, sym.ModArray}, dims: []uint32{2, 3}, want: "int x[2][3]"},
+		// int *x[4][5]
+		{mods: []sym.Mod{sym.ModArray, sym.ModArray, sym.ModPointer}, dims: []uint32{4, 5}, want: "int *x[4][5]"},
+		// int (*x)[6][7]
+		{mods: []sym.Mod{sym.ModPointer, sym.ModArray, sym.ModArray}, dims: []uint32{6, 7}, want: "int (*x)[6][7]"},
+	}
+	for _, g := range golden {
+		v := c.Var{
+			Type: parseMods(c.Int, g.mods, g.dims),
+			Name: "x",
+		}
+		if got := v.String(); got != g.want {
+			t.Errorf("C type mismatch of modifiers %v and dimensions %v; expected %q, got %q", g.mods, g.dims, g.want, got)
+		}
+	}
+}
+
+func TestFlattenArrays(t *testing.T) {
+	golden := []struct {
+		mods []sym.Mod
+		dims []uint32
+		want string
+	}{
+		// int x[2][3]
+		{mods: []sym.Mod{sym.ModArray, sym.ModArray}, dims: []uint32{6}, want: "int x[6]"},
+		// int *x[4][5]
+		{mods: []sym.Mod{sym.ModArray, sym.ModArray, sym.ModPointer}, dims: []uint32{20}, want: "int *x[20]"},
+		// int x[2][3], with one dimension per array modifier.
+		{mods: []sym.Mod{sym.ModArray, sym.ModArray}, dims: []uint32{2, 3}, want: "int x[2][3]"},
+	}
+	for _, g := range golden {
+		v := c.Var{
+			Type: parseMods(c.Int, flattenArrays(g.mods, g.dims), g.dims),
+			Name: "x",
+		}
+		if got := v.String(); got != g.want {
+			t.Errorf("C type mismatch of flat modifiers %v and dimensions %v; expected %q, got %q", g.mods, g.dims, g.want, got)
+		}
+	}
+}
+
+func TestIsFlagEnum(t *testing.T) {
+	golden := []struct {
+		values []uint32
+		want   bool
+	}{
+		// enum {RED, GREEN, BLUE}
+		{values: []uint32{0, 1, 2}, want: false},
+		// enum {A, B}
+		{values: []uint32{1, 2}, want: false},
+		// enum {NONE, READ, WRITE, EXEC}
+		{values: []uint32{0, 1, 2, 4}, want: true},
+		// enum {A = 1, B = 2, C = 4}
+		{values: []uint32{1, 2, 4}, want: true},
+		// enum {A = 1, B = 2, C = 3}
+		{values: []uint32{1, 2, 3}, want: false},
+		// enum {A = 1, B = 4, C = 4}
+		{values: []uint32{1, 4, 4}, want: false},
+	}
+	for _, g := range golden {
+		e := &c.EnumType{}
+		for _, v := range g.values {
+			e.Members = append(e.Members, &c.EnumMember{Value: v})
+		}
+		if got := isFlagEnum(e); got != g.want {
+			t.Errorf("flag enum mismatch of values %v; expected %v, got %v", g.values, g.want, got)
+		}
+	}
+}
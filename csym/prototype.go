@@ -0,0 +1,129 @@
+package csym
+
+import (
+	"github.com/sanctuary/sym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// variadicFuncs specifies the names of functions known to be variadic; the
+// variadic functions of the Psy-Q C library, used as the default of
+// Parser.VariadicFuncs.
+var variadicFuncs = []string{
+	"printf",
+	"sprintf",
+	"scanf",
+	"sscanf",
+}
+
+// ParsePrototypes parses the C function prototypes of the functions of the
+// given symbols. The prototypes are reconstructed from the global definition of
+// each function, specifying its return type, and the parameter definitions
+// (ARG and REGPARM) of its function scope, in order of occurrence.
+//
+// The types of the symbols must have been parsed (using ParseTypes) before
+// parsing prototypes.
+func (p *Parser) ParsePrototypes(syms []*sym.Symbol) []*c.FuncDecl {
+	defs := funcDefs(syms)
+	var funcs []*c.FuncDecl
+	for _, f := range sym.Functions(syms) {
+		funcType := &c.FuncType{
+			// Use implicit return type of C if function definition is missing.
+			RetType:  c.Int,
+			Variadic: p.VariadicFuncs[f.Name],
+		}
+		decl := &c.FuncDecl{
+			Path:      f.Path,
+			Addr:      f.Addr,
+			Overlay:   f.Overlay,
+			LineStart: f.LineStart,
+			LineEnd:   f.LineEnd,
+			Var: c.Var{
+				Type: funcType,
+				Name: validName(f.Name),
+			},
+		}
+		key := funcKey{overlay: f.Overlay, addr: f.Addr}
+		if def, ok := defs[key]; ok {
+			d := p.ParseDef(def)
+			if t, ok := d.Type.(*c.FuncType); ok {
+				funcType.RetType = t.RetType
+			}
+			decl.Size = d.Size
+			decl.Class = d.Class
+		}
+		for _, s := range f.Scope.Defs {
+			switch defClass(s) {
+			case sym.ClassARG, sym.ClassREGPARM:
+				addParam(funcType, p.ParseDef(s))
+			}
+		}
+		funcs = append(funcs, decl)
+	}
+	return funcs
+}
+
+// funcKey uniquely identifies a function by overlay and address.
+type funcKey struct {
+	// Overlay ID.
+	overlay uint32
+	// Function address.
+	addr uint32
+}
+
+// funcDefs returns the global function definitions of the given symbols,
+// mapping from function key to definition symbol.
+func funcDefs(syms []*sym.Symbol) map[funcKey]*sym.Symbol {
+	defs := make(map[funcKey]*sym.Symbol)
+	var overlay uint32
+	for _, s := range syms {
+		var (
+			class sym.Class
+			t     sym.Type
+		)
+		switch body := s.Body.(type) {
+		case *sym.Def:
+			class, t = body.Class, body.Type
+		case *sym.Def2:
+			class, t = body.Class, body.Type
+		case *sym.SetOverlay:
+			overlay = s.Hdr.Value
+			continue
+		default:
+			continue
+		}
+		switch class {
+		case sym.ClassEXT, sym.ClassSTAT:
+			if mods := t.Mods(); len(mods) > 0 && mods[0] == sym.ModFunction {
+				key := funcKey{overlay: overlay, addr: s.Hdr.Value}
+				defs[key] = s
+			}
+		}
+	}
+	return defs
+}
+
+// ParseDef parses the given definition symbol (Def or Def2) into the equivalent
+// C declaration; or returns nil if not a definition symbol.
+func (p *Parser) ParseDef(s *sym.Symbol) *c.VarDecl {
+	switch body := s.Body.(type) {
+	case *sym.Def:
+		t := p.parseType(body.Type, nil, "")
+		return p.parseLocalDecl(s.Hdr.Value, body.Size, body.Class, t, body.Name)
+	case *sym.Def2:
+		t := p.parseType(body.Type, body.Dims, body.Tag)
+		return p.parseLocalDecl(s.Hdr.Value, body.Size, body.Class, t, body.Name)
+	}
+	return nil
+}
+
+// defClass returns the class of the given definition symbol (Def or Def2); or 0
+// if not a definition symbol.
+func defClass(s *sym.Symbol) sym.Class {
+	switch body := s.Body.(type) {
+	case *sym.Def:
+		return body.Class
+	case *sym.Def2:
+		return body.Class
+	}
+	return 0
+}
@@ -0,0 +1,116 @@
+package csym
+
+import (
+	"testing"
+
+	"github.com/sanctuary/sym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+func TestParsePrototypes(t *testing.T) {
+	const (
+		// int
+		typInt = sym.Type(0x04)
+		// char *
+		typCharPtr = sym.Type(0x12)
+		// int ()
+		typFuncInt = sym.Type(0x24)
+	)
+	def := func(value uint32, class sym.Class, typ sym.Type, size uint32, name string) *sym.Symbol {
+		return &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: value, Kind: sym.KindDef},
+			Body: &sym.Def{Class: class, Type: typ, Size: size, Name: name},
+		}
+	}
+	syms := []*sym.Symbol{
+		def(0x80010000, sym.ClassEXT, typFuncInt, 0x40, "printf"),
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010000, Kind: sym.KindFuncStart},
+			Body: &sym.FuncStart{FP: 29, FSize: 24, RetReg: 31, Line: 10, Path: "printf.c", Name: "printf"},
+		},
+		// Register parameter.
+		def(4, sym.ClassREGPARM, typCharPtr, 4, "fmt"),
+		// Stack copy of register parameter.
+		def(24, sym.ClassARG, typCharPtr, 4, "fmt"),
+		// Static local of function scope.
+		def(0x80020000, sym.ClassSTAT, typInt, 4, "count"),
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010040, Kind: sym.KindFuncEnd},
+			Body: &sym.FuncEnd{Line: 20},
+		},
+		// Function without global definition.
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010040, Kind: sym.KindFuncStart},
+			Body: &sym.FuncStart{FP: 29, RetReg: 31, Line: 30, Path: "main.c", Name: "f"},
+		},
+		def(4, sym.ClassREGPARM, typInt, 4, "x"),
+		def(5, sym.ClassREGPARM, typInt, 4, "y"),
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010080, Kind: sym.KindFuncEnd},
+			Body: &sym.FuncEnd{Line: 40},
+		},
+	}
+	p := NewParser()
+	p.ParseTypes(syms)
+	funcs := p.ParsePrototypes(syms)
+	want := []string{
+		"int printf(char *fmt, ...)",
+		"int f(int x, int y)",
+	}
+	if len(funcs) != len(want) {
+		t.Fatalf("number of prototypes mismatch; expected %d, got %d", len(want), len(funcs))
+	}
+	for i, f := range funcs {
+		if got := f.Var.String(); got != want[i] {
+			t.Errorf("prototype mismatch of function %q; expected %q, got %q", f.Name, want[i], got)
+		}
+	}
+	if funcs[0].Size != 0x40 {
+		t.Errorf("size mismatch of function %q; expected 0x40, got 0x%X", funcs[0].Name, funcs[0].Size)
+	}
+	if funcs[0].Class != c.Extern || funcs[1].Class != 0 {
+		t.Errorf("storage class mismatch; expected extern and none, got %v and %v", funcs[0].Class, funcs[1].Class)
+	}
+}
+
+func TestParsePrototypesVariadicFuncs(t *testing.T) {
+	syms := []*sym.Symbol{
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010000, Kind: sym.KindFuncStart},
+			Body: &sym.FuncStart{FP: 29, RetReg: 31, Line: 10, Path: "log.c", Name: "printf"},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010040, Kind: sym.KindFuncEnd},
+			Body: &sym.FuncEnd{Line: 20},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010040, Kind: sym.KindFuncStart},
+			Body: &sym.FuncStart{FP: 29, RetReg: 31, Line: 30, Path: "log.c", Name: "logf"},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010080, Kind: sym.KindFuncEnd},
+			Body: &sym.FuncEnd{Line: 40},
+		},
+	}
+	// Variadic functions are specified per parser, replacing the default set.
+	p := NewParser()
+	p.VariadicFuncs = map[string]bool{"logf": true}
+	p.ParseTypes(syms)
+	funcs := p.ParsePrototypes(syms)
+	want := []string{
+		"int printf()",
+		"int logf(...)",
+	}
+	if len(funcs) != len(want) {
+		t.Fatalf("number of prototypes mismatch; expected %d, got %d", len(want), len(funcs))
+	}
+	for i, f := range funcs {
+		if got := f.Var.String(); got != want[i] {
+			t.Errorf("prototype mismatch of function %q; expected %q, got %q", f.Name, want[i], got)
+		}
+	}
+	// The default set of other parsers is unaffected.
+	if q := NewParser(); !q.VariadicFuncs["printf"] || q.VariadicFuncs["logf"] {
+		t.Errorf("default variadic functions mismatch; got %v", q.VariadicFuncs)
+	}
+}
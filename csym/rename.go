@@ -0,0 +1,181 @@
+package csym
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// RenameFakeTags renames the structs, unions and enums with fake tags
+// (generated by the compiler for types lacking a tag name; e.g. "_123fake").
+//
+// The new tag is based on, in order of precedence:
+//
+//  1. the name of the type definition of the type; e.g. "Name" for `typedef
+//     struct _0fake Name`,
+//  2. the name of the first struct or union field of the type, prefixed by
+//     the tag of the enclosing type; e.g. "foo_bar" for `struct foo { struct
+//     _0fake bar; }`,
+//  3. the name of the first global variable of the type,
+//  4. the number of the fake tag; e.g. "anon_123" for "_123fake".
+//
+// Tags are made unique, as structs, unions and enums share the same tag name
+// space.
+func (p *Parser) RenameFakeTags() {
+	// tags records the tags of structs, unions and enums in use.
+	tags := make(map[string]bool)
+	for _, tag := range p.StructTags {
+		tags[tag] = true
+	}
+	for _, tag := range p.UnionTags {
+		tags[tag] = true
+	}
+	for _, tag := range p.EnumTags {
+		tags[tag] = true
+	}
+	// uses maps from type with fake tag to its first use.
+	type fakeUse struct {
+		// Enclosing struct or union type of field; or nil if not a field.
+		parent c.Type
+		// Name of type definition, field or variable.
+		name string
+	}
+	uses := make(map[c.Type]fakeUse)
+	use := func(t c.Type, parent c.Type, name string) {
+		t = tagType(t)
+		if t == nil || !c.IsFakeTag(typeTag(t)) {
+			return
+		}
+		if _, ok := uses[t]; !ok {
+			uses[t] = fakeUse{parent: parent, name: name}
+		}
+	}
+	// Type definitions.
+	for _, def := range p.Typedefs {
+		if v, ok := def.(*c.VarDecl); ok {
+			switch v.Type.(type) {
+			case *c.StructType, *c.UnionType, *c.EnumType:
+				use(v.Type, nil, v.Name)
+			}
+		}
+	}
+	// Struct and union fields.
+	for _, tag := range p.StructTags {
+		t := p.Structs[tag]
+		for _, field := range t.Fields {
+			use(field.Type, t, field.Name)
+		}
+	}
+	for _, tag := range p.UnionTags {
+		t := p.Unions[tag]
+		for _, field := range t.Fields {
+			use(field.Type, t, field.Name)
+		}
+	}
+	// Global variables.
+	overlays := append([]*Overlay{p.Overlay}, p.Overlays...)
+	for _, overlay := range overlays {
+		for _, v := range overlay.Vars {
+			use(v.Type, nil, v.Name)
+		}
+	}
+	// newTags maps from type with fake tag to new tag.
+	newTags := make(map[c.Type]string)
+	// pending records the types being renamed, to detect cycles of fields.
+	pending := make(map[c.Type]bool)
+	var rename func(t c.Type) string
+	rename = func(t c.Type) string {
+		oldTag := typeTag(t)
+		if !c.IsFakeTag(oldTag) {
+			return oldTag
+		}
+		if newTag, ok := newTags[t]; ok {
+			return newTag
+		}
+		// Fallback name based on the number of the fake tag.
+		name := "anon_" + strings.TrimSuffix(strings.TrimPrefix(oldTag, "_"), "fake")
+		if u, ok := uses[t]; ok && !pending[t] {
+			pending[t] = true
+			if u.parent != nil {
+				name = fmt.Sprintf("%s_%s", rename(u.parent), u.name)
+			} else {
+				name = u.name
+			}
+			delete(pending, t)
+		}
+		if newTag, ok := newTags[t]; ok {
+			// Renamed through cycle of fields.
+			return newTag
+		}
+		delete(tags, oldTag)
+		newTag := uniqueTag(name, tags)
+		newTags[t] = newTag
+		return newTag
+	}
+	// Rename types.
+	for i, tag := range p.StructTags {
+		t := p.Structs[tag]
+		newTag := rename(t)
+		delete(p.Structs, tag)
+		p.Structs[newTag] = t
+		p.StructTags[i] = newTag
+	}
+	for i, tag := range p.UnionTags {
+		t := p.Unions[tag]
+		newTag := rename(t)
+		delete(p.Unions, tag)
+		p.Unions[newTag] = t
+		p.UnionTags[i] = newTag
+	}
+	for i, tag := range p.EnumTags {
+		t := p.Enums[tag]
+		newTag := rename(t)
+		delete(p.Enums, tag)
+		p.Enums[newTag] = t
+		p.EnumTags[i] = newTag
+	}
+	for t, newTag := range newTags {
+		switch t := t.(type) {
+		case *c.StructType:
+			t.Tag = newTag
+		case *c.UnionType:
+			t.Tag = newTag
+		case *c.EnumType:
+			t.Tag = newTag
+		}
+	}
+}
+
+// tagType returns the struct, union or enum type of the given type, as
+// referenced directly or through pointers, arrays and qualifiers; or nil if not
+// present.
+func tagType(t c.Type) c.Type {
+	for {
+		switch tt := t.(type) {
+		case *c.StructType, *c.UnionType, *c.EnumType:
+			return tt
+		case *c.PointerType:
+			t = tt.Elem
+		case *c.ArrayType:
+			t = tt.Elem
+		case *c.QualType:
+			t = tt.Type
+		default:
+			return nil
+		}
+	}
+}
+
+// typeTag returns the tag of the given struct, union or enum type.
+func typeTag(t c.Type) string {
+	switch t := t.(type) {
+	case *c.StructType:
+		return t.Tag
+	case *c.UnionType:
+		return t.Tag
+	case *c.EnumType:
+		return t.Tag
+	}
+	return ""
+}
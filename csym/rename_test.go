@@ -0,0 +1,43 @@
+package csym
+
+import (
+	"testing"
+
+	"github.com/sanctuary/sym/csym/c"
+)
+
+func TestRenameFakeTags(t *testing.T) {
+	p := NewParser()
+	// typedef struct _0fake Point;
+	point := &c.StructType{Size: 4, Tag: "_0fake"}
+	// struct foo { union _1fake u; };
+	u := &c.UnionType{Size: 4, Tag: "_1fake"}
+	foo := &c.StructType{Size: 4, Tag: "foo", Fields: []c.Field{{Var: c.Var{Type: u, Name: "u"}}}}
+	// Unused enum.
+	e := &c.EnumType{Tag: "_2fake"}
+	for _, t := range []*c.StructType{point, foo} {
+		p.Structs[t.Tag] = t
+		p.StructTags = append(p.StructTags, t.Tag)
+	}
+	p.Unions[u.Tag] = u
+	p.UnionTags = append(p.UnionTags, u.Tag)
+	p.Enums[e.Tag] = e
+	p.EnumTags = append(p.EnumTags, e.Tag)
+	p.Typedefs = append(p.Typedefs, &c.VarDecl{Class: c.Typedef, Var: c.Var{Type: point, Name: "Point"}})
+	p.RenameFakeTags()
+	golden := []struct {
+		got, want string
+	}{
+		{got: point.Tag, want: "Point"},
+		{got: u.Tag, want: "foo_u"},
+		{got: e.Tag, want: "anon_2"},
+	}
+	for _, g := range golden {
+		if g.got != g.want {
+			t.Errorf("tag mismatch; expected %q, got %q", g.want, g.got)
+		}
+	}
+	if p.Structs["Point"] != point || p.Unions["foo_u"] != u {
+		t.Errorf("expected renamed types to be present in parser")
+	}
+}
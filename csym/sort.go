@@ -0,0 +1,119 @@
+package csym
+
+import (
+	"sort"
+
+	"github.com/sanctuary/sym/csym/c"
+)
+
+//go:generate stringer -linecomment -type SortOrder
+
+// SortOrder specifies the order of declarations and type definitions in
+// output.
+type SortOrder uint8
+
+// Sort orders.
+const (
+	// Order of occurrence in SYM file.
+	SortOriginal SortOrder = iota + 1 // original
+	// Declarations and overlays in ascending order of address; type definitions
+	// in order of occurrence, as they lack addresses.
+	SortAddress // address
+	// Declarations and type definitions in lexical order of name; overlays in
+	// ascending order of ID.
+	SortName // name
+)
+
+// Sort sorts the declarations and type definitions of the parser in the given
+// order. Ties are broken by address, name and order of occurrence, so that the
+// order is stable across runs.
+//
+// Type definitions are output in dependency order regardless of sort order
+// (see c.SortDefs), with the sort order deciding the order of independent type
+// definitions.
+func (p *Parser) Sort(order SortOrder) {
+	switch order {
+	case SortAddress:
+		sort.SliceStable(p.Overlays, func(i, j int) bool {
+			a, b := p.Overlays[i], p.Overlays[j]
+			if a.Addr != b.Addr {
+				return a.Addr < b.Addr
+			}
+			return a.ID < b.ID
+		})
+		for _, overlay := range append([]*Overlay{p.Overlay}, p.Overlays...) {
+			overlay.sortByAddr()
+		}
+	case SortName:
+		sort.Strings(p.StructTags)
+		sort.Strings(p.UnionTags)
+		sort.Strings(p.EnumTags)
+		sort.SliceStable(p.Typedefs, func(i, j int) bool {
+			return typedefName(p.Typedefs[i]) < typedefName(p.Typedefs[j])
+		})
+		sort.SliceStable(p.Overlays, func(i, j int) bool {
+			return p.Overlays[i].ID < p.Overlays[j].ID
+		})
+		for _, overlay := range append([]*Overlay{p.Overlay}, p.Overlays...) {
+			overlay.sortByName()
+		}
+	}
+}
+
+// sortByAddr sorts the declarations and symbols of the overlay by address.
+func (overlay *Overlay) sortByAddr() {
+	sort.SliceStable(overlay.Vars, func(i, j int) bool {
+		a, b := overlay.Vars[i], overlay.Vars[j]
+		if a.Addr != b.Addr {
+			return a.Addr < b.Addr
+		}
+		return a.Name < b.Name
+	})
+	sort.SliceStable(overlay.Funcs, func(i, j int) bool {
+		a, b := overlay.Funcs[i], overlay.Funcs[j]
+		if a.Addr != b.Addr {
+			return a.Addr < b.Addr
+		}
+		return a.Name < b.Name
+	})
+	sort.SliceStable(overlay.Symbols, func(i, j int) bool {
+		a, b := overlay.Symbols[i], overlay.Symbols[j]
+		if a.Addr != b.Addr {
+			return a.Addr < b.Addr
+		}
+		return a.Name < b.Name
+	})
+}
+
+// sortByName sorts the declarations and symbols of the overlay by name.
+func (overlay *Overlay) sortByName() {
+	sort.SliceStable(overlay.Vars, func(i, j int) bool {
+		a, b := overlay.Vars[i], overlay.Vars[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Addr < b.Addr
+	})
+	sort.SliceStable(overlay.Funcs, func(i, j int) bool {
+		a, b := overlay.Funcs[i], overlay.Funcs[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Addr < b.Addr
+	})
+	sort.SliceStable(overlay.Symbols, func(i, j int) bool {
+		a, b := overlay.Symbols[i], overlay.Symbols[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Addr < b.Addr
+	})
+}
+
+// typedefName returns the name of the given type definition.
+func typedefName(t c.Type) string {
+	if def, ok := t.(*c.VarDecl); ok {
+		return def.Name
+	}
+	return ""
+}
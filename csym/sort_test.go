@@ -0,0 +1,79 @@
+package csym
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/sanctuary/sym/csym/c"
+)
+
+func TestSort(t *testing.T) {
+	newParser := func() *Parser {
+		p := NewParser()
+		p.Funcs = []*c.FuncDecl{
+			{Addr: 0x80010200, Var: c.Var{Type: &c.FuncType{RetType: c.Void}, Name: "main"}},
+			{Addr: 0x80010000, Var: c.Var{Type: &c.FuncType{RetType: c.Void}, Name: "init"}},
+			{Addr: 0x80010100, Var: c.Var{Type: &c.FuncType{RetType: c.Void}, Name: "exit"}},
+		}
+		p.Vars = []*c.VarDecl{
+			{Addr: 0x80020004, Var: c.Var{Type: c.Int, Name: "gA"}},
+			{Addr: 0x80020000, Var: c.Var{Type: c.Int, Name: "gB"}},
+		}
+		p.StructTags = []string{"point", "color"}
+		p.Overlays = []*Overlay{{Addr: 0x800B0000, ID: 5}, {Addr: 0x800A0000, ID: 4}}
+		return p
+	}
+	golden := []struct {
+		order    SortOrder
+		funcs    []string
+		vars     []string
+		tags     []string
+		overlays []uint32
+	}{
+		{
+			order:    SortOriginal,
+			funcs:    []string{"main", "init", "exit"},
+			vars:     []string{"gA", "gB"},
+			tags:     []string{"point", "color"},
+			overlays: []uint32{5, 4},
+		},
+		{
+			order:    SortAddress,
+			funcs:    []string{"init", "exit", "main"},
+			vars:     []string{"gB", "gA"},
+			tags:     []string{"point", "color"},
+			overlays: []uint32{4, 5},
+		},
+		{
+			order:    SortName,
+			funcs:    []string{"exit", "init", "main"},
+			vars:     []string{"gA", "gB"},
+			tags:     []string{"color", "point"},
+			overlays: []uint32{4, 5},
+		},
+	}
+	for _, g := range golden {
+		p := newParser()
+		p.Sort(g.order)
+		var funcs, vars []string
+		for _, f := range p.Funcs {
+			funcs = append(funcs, f.Name)
+		}
+		for _, v := range p.Vars {
+			vars = append(vars, v.Name)
+		}
+		var overlays []uint32
+		for _, overlay := range p.Overlays {
+			overlays = append(overlays, overlay.ID)
+		}
+		check := func(kind string, got, want interface{}) {
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("%v order of %s mismatch; expected %v, got %v", g.order, kind, want, got)
+			}
+		}
+		check("functions", funcs, g.funcs)
+		check("variables", vars, g.vars)
+		check("struct tags", p.StructTags, g.tags)
+		check("overlays", overlays, g.overlays)
+	}
+}
@@ -0,0 +1,136 @@
+package csym
+
+import (
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// DefaultStdintTypes maps from C base types and common Psy-Q type definitions
+// to the equivalent fixed-width integer types of stdint.h.
+//
+// Note, char is not mapped by default, as it is used for strings.
+var DefaultStdintTypes = map[string]string{
+	// Base types.
+	"signed char":        "int8_t",
+	"unsigned char":      "uint8_t",
+	"short":              "int16_t",
+	"unsigned short":     "uint16_t",
+	"int":                "int32_t",
+	"unsigned int":       "uint32_t",
+	"long":               "int32_t",
+	"unsigned long":      "uint32_t",
+	"long long":          "int64_t",
+	"unsigned long long": "uint64_t",
+	// Psy-Q type definitions.
+	"u_char":  "uint8_t",
+	"u_short": "uint16_t",
+	"u_int":   "uint32_t",
+	"u_long":  "uint32_t",
+}
+
+// MapStdintTypes replaces references to the base types and type definitions of
+// the given mapping with the fixed-width integer types of stdint.h. The mapping
+// maps from the C syntax representation of base types (e.g. "unsigned char")
+// and from type definition names (e.g. "u_char") to stdint.h type names (e.g.
+// "uint8_t"); see DefaultStdintTypes.
+//
+// The definitions of mapped type definitions are removed, and stdint.h is added
+// to the includes of the parser.
+func (p *Parser) MapStdintTypes(mapping map[string]string) {
+	m := &stdintMapper{
+		mapping: mapping,
+		types:   make(map[string]*c.VarDecl),
+		stdint:  make(map[c.Type]bool),
+	}
+	// Map types of struct and union fields.
+	for _, tag := range p.StructTags {
+		m.mapType(p.Structs[tag])
+	}
+	for _, tag := range p.UnionTags {
+		m.mapType(p.Unions[tag])
+	}
+	// Map type definitions, and remove mapped type definitions.
+	var typedefs []c.Type
+	for _, def := range p.Typedefs {
+		if v, ok := def.(*c.VarDecl); ok {
+			if _, ok := mapping[v.Name]; ok {
+				delete(p.Types, v.Name)
+				continue
+			}
+		}
+		typedefs = append(typedefs, m.mapType(def))
+	}
+	p.Typedefs = typedefs
+	for _, def := range p.Types {
+		m.mapType(def)
+	}
+	// Map types of declarations.
+	overlays := append([]*Overlay{p.Overlay}, p.Overlays...)
+	for _, overlay := range overlays {
+		for _, v := range overlay.Vars {
+			v.Type = m.mapType(v.Type)
+		}
+		for _, f := range overlay.Funcs {
+			f.Type = m.mapType(f.Type)
+			for _, block := range f.Blocks {
+				for _, local := range block.Locals {
+					local.Type = m.mapType(local.Type)
+				}
+			}
+		}
+	}
+	p.Includes = append(p.Includes, "stdint.h")
+}
+
+// stdintMapper tracks the state of mapping types to stdint.h types.
+type stdintMapper struct {
+	// mapping maps from base type or type definition name to stdint.h type name.
+	mapping map[string]string
+	// types maps from stdint.h type name to type definition.
+	types map[string]*c.VarDecl
+	// stdint records the stdint.h type definitions.
+	stdint map[c.Type]bool
+}
+
+// mapType maps the given type to its stdint.h equivalent. Derived types are
+// updated in place.
+func (m *stdintMapper) mapType(t c.Type) c.Type {
+	return c.Rewrite(t, m.rewrite)
+}
+
+// rewrite returns the stdint.h equivalent of the given type, and whether to
+// traverse the types referenced by the type.
+func (m *stdintMapper) rewrite(t c.Type) (c.Type, bool) {
+	if m.stdint[t] {
+		return t, false
+	}
+	switch t := t.(type) {
+	case c.BaseType:
+		if name, ok := m.mapping[t.String()]; ok {
+			return m.stdintType(name, t), false
+		}
+	case *c.VarDecl:
+		// Type definition.
+		if name, ok := m.mapping[t.Name]; ok {
+			return m.stdintType(name, t.Type), false
+		}
+	}
+	return t, true
+}
+
+// stdintType returns the stdint.h type definition of the given name and
+// underlying type.
+func (m *stdintMapper) stdintType(name string, underlying c.Type) *c.VarDecl {
+	if def, ok := m.types[name]; ok {
+		return def
+	}
+	def := &c.VarDecl{
+		Class: c.Typedef,
+		Var: c.Var{
+			Type: underlying,
+			Name: name,
+		},
+	}
+	m.types[name] = def
+	m.stdint[def] = true
+	return def
+}
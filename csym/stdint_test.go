@@ -0,0 +1,36 @@
+package csym
+
+import (
+	"testing"
+
+	"github.com/sanctuary/sym/csym/c"
+)
+
+func TestMapStdintTypes(t *testing.T) {
+	p := NewParser()
+	uchar := &c.VarDecl{Class: c.Typedef, Var: c.Var{Type: c.UChar, Name: "u_char"}}
+	p.Types["u_char"] = uchar
+	p.Typedefs = append(p.Typedefs, uchar)
+	// struct s { u_char a; short *b; char c; };
+	s := &c.StructType{Size: 12, Tag: "s"}
+	s.Fields = []c.Field{
+		{Offset: 0, Var: c.Var{Type: uchar, Name: "a"}},
+		{Offset: 4, Var: c.Var{Type: &c.PointerType{Elem: c.Short}, Name: "b"}},
+		{Offset: 8, Var: c.Var{Type: c.Char, Name: "c"}},
+	}
+	p.Structs["s"] = s
+	p.StructTags = append(p.StructTags, "s")
+	p.MapStdintTypes(DefaultStdintTypes)
+	want := []string{"uint8_t a", "int16_t *b", "char c"}
+	for i, field := range s.Fields {
+		if got := field.String(); got != want[i] {
+			t.Errorf("field %d mismatch; expected %q, got %q", i, want[i], got)
+		}
+	}
+	if len(p.Typedefs) != 0 {
+		t.Errorf("expected mapped type definitions to be removed; got %v", p.Typedefs)
+	}
+	if len(p.Includes) != 1 || p.Includes[0] != "stdint.h" {
+		t.Errorf("includes mismatch; expected [stdint.h], got %v", p.Includes)
+	}
+}
@@ -0,0 +1,83 @@
+package csym
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// A Translator translates SYM types and definitions into the equivalent C types
+// and declarations, resolving struct, union and enum tags against the tagged
+// types of a symbol file.
+//
+// As opposed to Parser, which panics on unsupported symbols, the methods of
+// Translator report errors.
+type Translator struct {
+	// Parser holding type information.
+	p *Parser
+}
+
+// NewTranslator returns a new translator, resolving tags against the struct,
+// union, enum and type definitions of the given symbols.
+func NewTranslator(syms []*sym.Symbol) (*Translator, error) {
+	p := NewParser()
+	if err := try(func() { p.ParseTypes(syms) }); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &Translator{p: p}, nil
+}
+
+// Parser returns the parser holding the type information of the translator.
+func (t *Translator) Parser() *Parser {
+	return t.p
+}
+
+// Type translates the SYM type, with the given array dimensions and struct,
+// union or enum tag, into the equivalent C type.
+func (t *Translator) Type(typ sym.Type, dims []uint32, tag string) (c.Type, error) {
+	var ct c.Type
+	if err := try(func() { ct = t.p.parseType(typ, dims, tag) }); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return ct, nil
+}
+
+// Def translates the definition symbol (Def or Def2) into the equivalent C
+// declaration.
+func (t *Translator) Def(s *sym.Symbol) (*c.VarDecl, error) {
+	var v *c.VarDecl
+	if err := try(func() { v = t.p.ParseDef(s) }); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if v == nil {
+		return nil, errors.Errorf("invalid symbol body type; expected *sym.Def or *sym.Def2, got %T", s.Body)
+	}
+	return v, nil
+}
+
+// Class translates the SYM definition class into the equivalent C storage
+// class.
+func (t *Translator) Class(class sym.Class) (c.StorageClass, error) {
+	var sc c.StorageClass
+	if err := try(func() { sc = parseClass(class) }); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return sc, nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// try invokes f, converting panics into errors.
+func try(f func()) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			if ee, ok := e.(error); ok {
+				err = ee
+			} else {
+				err = errors.Errorf("%v", e)
+			}
+		}
+	}()
+	f()
+	return nil
+}
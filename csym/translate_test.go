@@ -0,0 +1,76 @@
+package csym
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sanctuary/sym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+func TestTranslator(t *testing.T) {
+	syms := []*sym.Symbol{
+		// struct point { int x; };
+		{
+			Hdr:  &sym.SymbolHeader{Kind: sym.KindDef},
+			Body: &sym.Def{Class: sym.ClassSTRTAG, Type: sym.Type(sym.BaseStruct), Size: 4, Name: "point"},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Kind: sym.KindDef},
+			Body: &sym.Def{Class: sym.ClassMOS, Type: sym.Type(sym.BaseInt), Size: 4, Name: "x"},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 4, Kind: sym.KindDef2},
+			Body: &sym.Def2{Class: sym.ClassEOS, Size: 4, Tag: "point"},
+		},
+	}
+	// struct flags { unsigned int a : 3; unsigned int b : 5; };
+	syms = append(syms,
+		&sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Kind: sym.KindDef},
+			Body: &sym.Def{Class: sym.ClassSTRTAG, Type: sym.Type(sym.BaseStruct), Size: 4, Name: "flags"},
+		},
+		&sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: 0, Kind: sym.KindDef},
+			Body: &sym.Def{Class: sym.ClassFIELD, Type: sym.Type(sym.BaseUInt), Size: 3, Name: "a"},
+		},
+		&sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: 3, Kind: sym.KindDef},
+			Body: &sym.Def{Class: sym.ClassFIELD, Type: sym.Type(sym.BaseUInt), Size: 5, Name: "b"},
+		},
+		&sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: 4, Kind: sym.KindDef2},
+			Body: &sym.Def2{Class: sym.ClassEOS, Size: 4, Tag: "flags"},
+		},
+	)
+	tr, err := NewTranslator(syms)
+	if err != nil {
+		t.Fatalf("unable to create translator; %v", err)
+	}
+	flags := tr.Parser().Structs["flags"]
+	if def := flags.Def(); !strings.Contains(def, "\t// offset: 0000.3 (5 bits)\n\tunsigned int b : 5;\n") {
+		t.Errorf("bitfield missing from struct definition:\n%s", def)
+	}
+	// struct point *
+	typ := sym.Type(0x18)
+	got, err := tr.Type(typ, nil, "point")
+	if err != nil {
+		t.Fatalf("unable to translate type %v; %v", typ, err)
+	}
+	want := &c.PointerType{Elem: tr.Parser().Structs["point"]}
+	if got.String() != want.String() {
+		t.Errorf("C type mismatch; expected %v, got %v", want, got)
+	}
+	// double
+	if got, err := tr.Type(sym.Type(sym.BaseDouble), nil, ""); err != nil || got != c.Double {
+		t.Errorf("C type mismatch of DOUBLE; expected %v, got %v (%v)", c.Double, got, err)
+	}
+	// Translate NULL into the bool base type.
+	tr.Parser().NativeBool = true
+	if got, err := tr.Type(sym.Type(sym.BaseNull), nil, ""); err != nil || got != c.Bool {
+		t.Errorf("C type mismatch of NULL; expected %v, got %v (%v)", c.Bool, got, err)
+	}
+	if _, err := tr.Type(typ, nil, "missing"); err == nil {
+		t.Errorf("expected error when translating type of missing struct tag")
+	}
+}
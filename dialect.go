@@ -0,0 +1,175 @@
+package sym
+
+import (
+	"encoding/binary"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// A Dialect describes the variant of the SYM format used by a symbol file, as
+// detected from its file header and symbols.
+//
+// SYM files produced by different SDKs share the same symbol layout, but vary
+// in the target platform, the encoding of symbol names and the recording of
+// array dimensions.
+type Dialect struct {
+	// File format version, as specified by the file header.
+	Version uint8
+	// Target platform; or 0 if unknown.
+	Target Target
+	// Encoding of symbol names, tags and paths; or nil if ASCII (or UTF-8).
+	//
+	// Symbol files of Japanese SDKs record names in Shift-JIS.
+	Encoding encoding.Encoding
+	// Layout of the array dimensions of Def2 symbols.
+	Dims DimsLayout
+}
+
+//go:generate stringer -linecomment -type Target
+
+// Target specifies the target platform of a symbol file.
+type Target uint8
+
+// Target platforms.
+const (
+	// Playstation 1 (little-endian).
+	TargetPSX Target = iota + 1 // PSX
+	// Sega Saturn (big-endian).
+	TargetSaturn // Saturn
+	// Nintendo 64 (big-endian).
+	TargetN64 // N64
+)
+
+//go:generate stringer -linecomment -type DimsLayout
+
+// DimsLayout specifies the layout of the array dimensions of Def2 symbols.
+type DimsLayout uint8
+
+// Layouts of array dimensions.
+const (
+	// One dimension per array modifier; e.g. dimensions [2, 3] of `int x[2][3]`.
+	DimsPerArray DimsLayout = iota // per array
+	// A single dimension specifying the total number of elements of
+	// multi-dimensional arrays; e.g. dimensions [6] of `int x[2][3]`.
+	DimsFlat // flat
+)
+
+// DetectDialect detects the dialect of the given symbol file, based on its file
+// header, byte order and symbols.
+//
+// The target platform is detected from the byte order and the addresses of
+// symbols; Shift-JIS encoded names are detected by multi-byte sequences which
+// are valid Shift-JIS but not valid UTF-8; and flat array dimensions are
+// detected by Def2 symbols of multi-dimensional arrays specifying a single
+// dimension.
+func DetectDialect(f *File) *Dialect {
+	d := &Dialect{
+		Target:   detectTarget(f),
+		Encoding: detectEncoding(f),
+		Dims:     detectDims(f),
+	}
+	if f.Hdr != nil {
+		d.Version = f.Hdr.Version
+	}
+	return d
+}
+
+// detectTarget returns the target platform of the given symbol file; or 0 if
+// unknown.
+func detectTarget(f *File) Target {
+	if f.Order != binary.BigEndian {
+		return TargetPSX
+	}
+	// Big-endian symbol files share the layout of the Saturn and the N64;
+	// distinguish by the addresses of symbols.
+	saturn, n64 := 0, 0
+	for _, sym := range f.Syms {
+		addr, ok := sym.Address()
+		if !ok {
+			continue
+		}
+		switch {
+		case isSaturnAddr(addr):
+			saturn++
+		case isN64Addr(addr):
+			n64++
+		}
+	}
+	switch {
+	case saturn == 0 && n64 == 0:
+		return 0
+	case saturn >= n64:
+		return TargetSaturn
+	default:
+		return TargetN64
+	}
+}
+
+// detectEncoding returns the encoding of the names, tags and paths of the given
+// symbol file; or nil if ASCII (or UTF-8).
+func detectEncoding(f *File) encoding.Encoding {
+	sjis := false
+	for _, sym := range f.Syms {
+		for _, name := range bodyNames(sym.Body) {
+			if isASCII(*name) || utf8.ValidString(*name) {
+				continue
+			}
+			// Invalid byte sequences are decoded into the replacement character.
+			s, err := japanese.ShiftJIS.NewDecoder().String(*name)
+			if err != nil || strings.ContainsRune(s, utf8.RuneError) {
+				return nil
+			}
+			sjis = true
+		}
+	}
+	if sjis {
+		return japanese.ShiftJIS
+	}
+	return nil
+}
+
+// detectDims returns the layout of the array dimensions of Def2 symbols of the
+// given symbol file.
+func detectDims(f *File) DimsLayout {
+	// Number of Def2 symbols of multi-dimensional arrays specifying one
+	// dimension per array modifier and a single dimension, respectively.
+	perArray, flat := 0, 0
+	for _, sym := range f.Syms {
+		body, ok := sym.Body.(*Def2)
+		if !ok {
+			continue
+		}
+		n := 0
+		for _, mod := range body.Type.Mods() {
+			if mod == ModArray {
+				n++
+			}
+		}
+		if n < 2 {
+			continue
+		}
+		switch len(body.Dims) {
+		case n:
+			perArray++
+		case 1:
+			flat++
+		}
+	}
+	if flat > perArray {
+		return DimsFlat
+	}
+	return DimsPerArray
+}
+
+// isASCII reports whether the given string consists of ASCII characters.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
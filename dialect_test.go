@@ -0,0 +1,69 @@
+package sym_test
+
+import (
+	"testing"
+
+	"github.com/sanctuary/sym"
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestDetectDialect(t *testing.T) {
+	// "メイン" in Shift-JIS.
+	raw := "\x83\x81\x83\x43\x83\x93"
+	b := newSymFile()
+	b = appendName(b, 0x80010000, "main")
+	b = appendName(b, 0x80010010, raw)
+	f, err := sym.ParseBytes(b)
+	if err != nil {
+		t.Fatalf("unable to parse symbol file; %v", err)
+	}
+	d := f.Dialect
+	if d == nil {
+		t.Fatalf("dialect not detected")
+	}
+	if d.Version != 1 || d.Target != sym.TargetPSX || d.Encoding != japanese.ShiftJIS || d.Dims != sym.DimsPerArray {
+		t.Errorf("dialect mismatch; expected version 1, target PSX, Shift-JIS names and per array dimensions, got version %d, target %v, encoding %v and %v dimensions", d.Version, d.Target, d.Encoding, d.Dims)
+	}
+	// Names are left undecoded unless requested.
+	if got := f.Syms[1].Body.(*sym.Name2).Name; got != raw {
+		t.Errorf("name mismatch; expected undecoded name %q, got %q", raw, got)
+	}
+	f, err = sym.ParseBytesWithOptions(b, &sym.ParseOptions{DetectEncoding: true})
+	if err != nil {
+		t.Fatalf("unable to parse symbol file; %v", err)
+	}
+	if got, want := f.Syms[1].Body.(*sym.Name2).Name, "メイン"; got != want {
+		t.Errorf("decoded name mismatch; expected %q, got %q", want, got)
+	}
+}
+
+func TestDetectDialectDims(t *testing.T) {
+	// int x[2][3]
+	arrays := sym.Type(sym.ModArray)<<4 | sym.Type(sym.ModArray)<<6 | sym.Type(sym.BaseInt)
+	golden := []struct {
+		dims []uint32
+		want sym.DimsLayout
+	}{
+		{dims: []uint32{2, 3}, want: sym.DimsPerArray},
+		{dims: []uint32{6}, want: sym.DimsFlat},
+	}
+	for _, g := range golden {
+		def := &sym.Def2{
+			Class:   sym.ClassEXT,
+			Type:    arrays,
+			Size:    24,
+			DimsLen: uint16(len(g.dims)),
+			Dims:    g.dims,
+			NameLen: 1,
+			Name:    "x",
+		}
+		s := &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010000, Kind: sym.KindDef2},
+			Body: def,
+		}
+		d := sym.DetectDialect(sym.NewFile([]*sym.Symbol{s}))
+		if d.Dims != g.want {
+			t.Errorf("dimensions layout mismatch of dimensions %v; expected %v, got %v", g.dims, g.want, d.Dims)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package sym
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+//go:generate stringer -linecomment -type DuplicateKind
+
+// DuplicateKind specifies the kind of a duplicate symbol.
+type DuplicateKind uint8
+
+// Duplicate kinds.
+const (
+	// Symbols of the same kind with identical name and address.
+	DuplicateExact DuplicateKind = iota + 1 // identical name and address
+	// Symbols with identical name located at different addresses.
+	DuplicateName // identical name at different addresses
+	// Symbols with conflicting names located at the same address.
+	DuplicateAddr // conflicting names at same address
+)
+
+// A Duplicate is a set of duplicate symbols.
+type Duplicate struct {
+	// Kind of duplicate.
+	Kind DuplicateKind
+	// Symbols of the duplicate set, in order of occurrence.
+	Entries []*NameEntry
+}
+
+// String returns the string representation of the duplicate set.
+func (dup *Duplicate) String() string {
+	// identical name at different addresses: foo (Name2 0x80010000), foo (Name2 0x80020000)
+	return fmt.Sprintf("%v: %v", dup.Kind, dup.Entries)
+}
+
+// FindDuplicates returns the duplicate sets of the named symbols with addresses
+// of the given symbols; exact duplicates, followed by names located at
+// different addresses, followed by addresses with conflicting names.
+//
+// Addresses of different overlays are considered distinct.
+func FindDuplicates(syms []*Symbol) []*Duplicate {
+	type addrKey struct {
+		overlay uint32
+		addr    uint32
+	}
+	type exactKey struct {
+		addrKey
+		name string
+		kind Kind
+	}
+	var (
+		exact     = make(map[exactKey][]*NameEntry)
+		exactKeys []exactKey
+		// byName maps from name to the entries of the name at distinct
+		// addresses.
+		byName   = make(map[string][]*NameEntry)
+		names    []string
+		nameAddr = make(map[string]map[addrKey]bool)
+		// byAddr maps from address to the entries of distinct names at the
+		// address.
+		byAddr    = make(map[addrKey][]*NameEntry)
+		addrs     []addrKey
+		addrNames = make(map[addrKey]map[string]bool)
+	)
+	var overlay uint32
+	for _, s := range syms {
+		if _, ok := s.Body.(*SetOverlay); ok {
+			overlay = s.Hdr.Value
+			continue
+		}
+		info := s.Info()
+		if len(info.Name) == 0 || !info.HasAddr {
+			continue
+		}
+		e := &NameEntry{
+			SymbolInfo: info,
+			Overlay:    overlay,
+			Sym:        s,
+		}
+		ak := addrKey{overlay: overlay, addr: info.Addr}
+		ek := exactKey{addrKey: ak, name: info.Name, kind: info.Kind}
+		if _, ok := exact[ek]; !ok {
+			exactKeys = append(exactKeys, ek)
+		}
+		exact[ek] = append(exact[ek], e)
+		if _, ok := nameAddr[info.Name]; !ok {
+			names = append(names, info.Name)
+			nameAddr[info.Name] = make(map[addrKey]bool)
+		}
+		if !nameAddr[info.Name][ak] {
+			nameAddr[info.Name][ak] = true
+			byName[info.Name] = append(byName[info.Name], e)
+		}
+		if _, ok := addrNames[ak]; !ok {
+			addrs = append(addrs, ak)
+			addrNames[ak] = make(map[string]bool)
+		}
+		if !addrNames[ak][info.Name] {
+			addrNames[ak][info.Name] = true
+			byAddr[ak] = append(byAddr[ak], e)
+		}
+	}
+	var dups []*Duplicate
+	for _, k := range exactKeys {
+		if entries := exact[k]; len(entries) > 1 {
+			dups = append(dups, &Duplicate{Kind: DuplicateExact, Entries: entries})
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if entries := byName[name]; len(entries) > 1 {
+			dups = append(dups, &Duplicate{Kind: DuplicateName, Entries: entries})
+		}
+	}
+	for _, k := range addrs {
+		if entries := byAddr[k]; len(entries) > 1 {
+			dups = append(dups, &Duplicate{Kind: DuplicateAddr, Entries: entries})
+		}
+	}
+	return dups
+}
+
+// WriteDuplicateReport writes a text report of the given duplicate sets to w,
+// with one line per duplicate set.
+func WriteDuplicateReport(w io.Writer, dups []*Duplicate) error {
+	for _, dup := range dups {
+		if _, err := fmt.Fprintln(w, dup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Dedup returns the given symbols with exact duplicates of name symbols and
+// global definitions (outside of functions) removed, keeping the first
+// occurrence. Dedup is intended for merging the symbols of several symbol
+// files.
+func Dedup(syms []*Symbol) []*Symbol {
+	type key struct {
+		overlay uint32
+		addr    uint32
+		name    string
+		kind    Kind
+	}
+	seen := make(map[key]bool)
+	var (
+		// Current overlay ID.
+		overlay uint32
+		// Function nesting depth.
+		funcs int
+	)
+	var dst []*Symbol
+	for _, s := range syms {
+		switch s.Body.(type) {
+		case *SetOverlay:
+			overlay = s.Hdr.Value
+		case *FuncStart:
+			funcs++
+		case *FuncEnd:
+			if funcs > 0 {
+				funcs--
+			}
+		case *Name1, *Name2, *Name5, *Name6:
+			k := key{overlay: overlay, addr: s.Hdr.Value, name: s.Name(), kind: s.Hdr.Kind}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+		case *Def, *Def2:
+			if addr, ok := s.Address(); ok && funcs == 0 {
+				k := key{overlay: overlay, addr: addr, name: s.Name(), kind: s.Hdr.Kind}
+				if seen[k] {
+					continue
+				}
+				seen[k] = true
+			}
+		}
+		dst = append(dst, s)
+	}
+	return dst
+}
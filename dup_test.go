@@ -0,0 +1,38 @@
+package sym_test
+
+import (
+	"testing"
+
+	"github.com/sanctuary/sym"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	name := func(addr uint32, name string) *sym.Symbol {
+		return &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: addr, Kind: sym.KindName2},
+			Body: &sym.Name2{Name: name},
+		}
+	}
+	syms := []*sym.Symbol{
+		name(0x80010000, "foo"),
+		// Exact duplicate.
+		name(0x80010000, "foo"),
+		// Name at different address.
+		name(0x80020000, "foo"),
+		// Conflicting name at same address.
+		name(0x80020000, "bar"),
+	}
+	dups := sym.FindDuplicates(syms)
+	want := []sym.DuplicateKind{sym.DuplicateExact, sym.DuplicateName, sym.DuplicateAddr}
+	if len(dups) != len(want) {
+		t.Fatalf("number of duplicate sets mismatch; expected %d, got %d (%v)", len(want), len(dups), dups)
+	}
+	for i, dup := range dups {
+		if dup.Kind != want[i] || len(dup.Entries) != 2 {
+			t.Errorf("duplicate set %d mismatch; expected 2 symbols of %v, got %v", i, want[i], dup)
+		}
+	}
+	if got := sym.Dedup(syms); len(got) != 3 {
+		t.Errorf("number of deduplicated symbols mismatch; expected 3, got %d", len(got))
+	}
+}
@@ -0,0 +1,537 @@
+// Package dwarfsym translates the DWARF debug information and symbol tables of
+// ELF files into the C declarations of PS1 symbol files; e.g. to build SYM files
+// of modern rebuilds compiled by mipsel GCC, for use by classic debuggers and
+// emulators.
+package dwarfsym
+
+import (
+	"debug/dwarf"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// ParseFile parses the DWARF debug information and symbol table of the given
+// ELF file.
+func ParseFile(path string) (*csym.Parser, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	return ParseELF(f)
+}
+
+// ParseELF parses the DWARF debug information and symbol table of the given ELF
+// file. ELF files without DWARF debug information are translated using their
+// symbol table only.
+func ParseELF(f *elf.File) (*csym.Parser, error) {
+	var d *dwarf.Data
+	if f.Section(".debug_info") != nil || f.Section(".zdebug_info") != nil {
+		var err error
+		if d, err = f.DWARF(); err != nil {
+			return nil, errors.Wrap(err, "unable to parse DWARF debug information")
+		}
+	}
+	syms, err := f.Symbols()
+	if err != nil && err != elf.ErrNoSymbols {
+		return nil, errors.Wrap(err, "unable to parse ELF symbol table")
+	}
+	return NewParser(d, syms)
+}
+
+// NewParser translates the DWARF debug information (optional) and the ELF
+// symbols (optional) into the equivalent C types and declarations of a parser;
+// e.g. to be encoded as SYM symbols using csym.Parser.Encode.
+//
+// Types are translated from the type entries of all compile units; struct,
+// union and enum types of the same tag are only translated once, and anonymous
+// struct, union and enum types are given fake tags (e.g. "_0fake"). 64-bit
+// integer types are translated as arrays of two 32-bit integers, as SYM files
+// have no 64-bit base types.
+//
+// Functions are translated with their parameters and local variables, and
+// global variables with their addresses. Parameters and local variables are
+// located in registers, relative to the frame base, or at static addresses, as
+// specified by simple location expressions; parameters of other locations are
+// located in the argument registers ($a0-$a3) and on the stack as specified by
+// the MIPS calling convention, and local variables of other locations are
+// omitted. Addresses of location expressions are decoded as little-endian, as
+// used by the PS1.
+//
+// Named function and object symbols of the ELF symbol table which are neither
+// declared as functions nor as global variables are recorded as symbols.
+func NewParser(d *dwarf.Data, syms []elf.Symbol) (*csym.Parser, error) {
+	tr := newTranslator(d)
+	if d != nil {
+		if err := tr.translateUnits(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	tr.translateSymbols(syms)
+	return tr.p, nil
+}
+
+// translator tracks the state of the translation of DWARF debug information.
+type translator struct {
+	// Parser holding the translated types and declarations.
+	p *csym.Parser
+	// DWARF debug information.
+	d *dwarf.Data
+	// types maps from DWARF type to translated C type.
+	types map[dwarf.Type]c.Type
+	// declared tracks the addresses of functions and variables.
+	declared map[uint32]bool
+	// Number of fake tags of anonymous struct, union and enum types.
+	nfakes int
+
+	// Compile unit being translated.
+
+	// Source file of the compile unit.
+	path string
+	// Files of the line table of the compile unit.
+	files []*dwarf.LineFile
+	// Line numbers of the compile unit, sorted by address.
+	lines []*csym.Line
+}
+
+// newTranslator returns a new translator of the given DWARF debug information.
+func newTranslator(d *dwarf.Data) *translator {
+	return &translator{
+		p:        csym.NewParser(),
+		d:        d,
+		types:    make(map[dwarf.Type]c.Type),
+		declared: make(map[uint32]bool),
+	}
+}
+
+// --- [ Compile units ] -------------------------------------------------------
+
+// translateUnits translates the compile units of the DWARF debug information.
+func (tr *translator) translateUnits() error {
+	r := tr.d.Reader()
+	for {
+		e, err := r.Next()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if e == nil {
+			return nil
+		}
+		if e.Tag != dwarf.TagCompileUnit {
+			r.SkipChildren()
+			continue
+		}
+		if err := tr.translateUnit(r, e); err != nil {
+			name, _ := e.Val(dwarf.AttrName).(string)
+			return errors.Wrapf(err, "unable to translate compile unit %q", name)
+		}
+	}
+}
+
+// translateUnit translates the line numbers, types, global variables and
+// functions of the given compile unit.
+func (tr *translator) translateUnit(r *dwarf.Reader, cu *dwarf.Entry) error {
+	tr.path, _ = cu.Val(dwarf.AttrName).(string)
+	if err := tr.translateLines(cu); err != nil {
+		return errors.WithStack(err)
+	}
+	if !cu.Children {
+		return nil
+	}
+	for {
+		e, err := r.Next()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if e == nil || e.Tag == 0 {
+			return nil
+		}
+		switch e.Tag {
+		case dwarf.TagSubprogram:
+			if err := tr.translateFunc(r, e); err != nil {
+				return errors.WithStack(err)
+			}
+			continue
+		case dwarf.TagVariable:
+			if err := tr.translateGlobal(e); err != nil {
+				return errors.WithStack(err)
+			}
+		case dwarf.TagTypedef, dwarf.TagStructType, dwarf.TagUnionType, dwarf.TagEnumerationType:
+			if _, err := tr.typeOf(e.Offset); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		r.SkipChildren()
+	}
+}
+
+// translateLines translates the line table of the given compile unit.
+func (tr *translator) translateLines(cu *dwarf.Entry) error {
+	tr.files = nil
+	tr.lines = nil
+	lr, err := tr.d.LineReader(cu)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if lr == nil {
+		// Compile unit without line table.
+		return nil
+	}
+	tr.files = lr.Files()
+	var prev *csym.Line
+	for {
+		var entry dwarf.LineEntry
+		if err := lr.Next(&entry); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return errors.WithStack(err)
+		}
+		if entry.EndSequence {
+			prev = nil
+			continue
+		}
+		if entry.File == nil || entry.Line == 0 {
+			continue
+		}
+		line := &csym.Line{
+			Addr: uint32(entry.Address),
+			Path: entry.File.Name,
+			Line: uint32(entry.Line),
+		}
+		// Keep the last line number of each address.
+		if prev != nil && prev.Addr == line.Addr {
+			*prev = *line
+			continue
+		}
+		tr.lines = append(tr.lines, line)
+		prev = line
+	}
+	sort.SliceStable(tr.lines, func(i, j int) bool {
+		return tr.lines[i].Addr < tr.lines[j].Addr
+	})
+	tr.p.Lines = append(tr.p.Lines, tr.lines...)
+	return nil
+}
+
+// --- [ Declarations ] --------------------------------------------------------
+
+// translateGlobal translates the given global variable entry. Declarations of
+// external variables and variables not located at static addresses are
+// ignored.
+func (tr *translator) translateGlobal(e *dwarf.Entry) error {
+	if declaration, _ := e.Val(dwarf.AttrDeclaration).(bool); declaration {
+		return nil
+	}
+	class, addr, ok := location(e)
+	if !ok || class != c.Static {
+		return nil
+	}
+	v, err := tr.varDecl(e)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	v.Class = c.Static
+	if external, _ := tr.val(e, dwarf.AttrExternal).(bool); external {
+		v.Class = c.Extern
+	}
+	v.Addr = addr
+	tr.p.Vars = append(tr.p.Vars, v)
+	tr.declared[addr] = true
+	return nil
+}
+
+// translateFunc translates the given function entry and its children.
+// Declarations and abstract instances of inline functions (i.e. functions
+// without addresses) are ignored.
+func (tr *translator) translateFunc(r *dwarf.Reader, e *dwarf.Entry) error {
+	lowpc, ok := e.Val(dwarf.AttrLowpc).(uint64)
+	if !ok {
+		r.SkipChildren()
+		return nil
+	}
+	highpc := lowpc
+	switch v := e.Val(dwarf.AttrHighpc).(type) {
+	case uint64:
+		highpc = v
+	case int64:
+		// Offset from low PC as of DWARF version 4.
+		highpc = lowpc + uint64(v)
+	}
+	name, _ := tr.val(e, dwarf.AttrName).(string)
+	retType, err := tr.typeAttr(e)
+	if err != nil {
+		return errors.Wrapf(err, "unable to translate return type of function %q", name)
+	}
+	funcType := &c.FuncType{RetType: retType}
+	f := &c.FuncDecl{
+		Path:  tr.path,
+		Addr:  uint32(lowpc),
+		Size:  uint32(highpc - lowpc),
+		Class: c.Static,
+		Var: c.Var{
+			Type: funcType,
+			Name: name,
+		},
+	}
+	if external, _ := tr.val(e, dwarf.AttrExternal).(bool); external {
+		f.Class = c.Extern
+	}
+	if i, ok := tr.val(e, dwarf.AttrDeclFile).(int64); ok && i >= 0 && int(i) < len(tr.files) && tr.files[i] != nil {
+		f.Path = tr.files[i].Name
+	}
+	if line, ok := tr.val(e, dwarf.AttrDeclLine).(int64); ok {
+		f.LineStart = uint32(line)
+	} else {
+		f.LineStart = tr.lineAt(f.Addr)
+	}
+	f.LineEnd = f.LineStart
+	if f.Size > 0 {
+		if line := tr.lineAt(f.Addr + f.Size - 1); line > f.LineEnd {
+			f.LineEnd = line
+		}
+	}
+	body := &c.Block{
+		LineStart: 1,
+		LineEnd:   f.LineEnd - f.LineStart + 1,
+	}
+	f.Blocks = append(f.Blocks, body)
+	if e.Children {
+		if err := tr.translateScope(r, f, body); err != nil {
+			return errors.Wrapf(err, "unable to translate function %q", name)
+		}
+	}
+	if len(body.Locals) == 0 && len(f.Blocks) == 1 {
+		f.Blocks = nil
+	}
+	tr.p.Funcs = append(tr.p.Funcs, f)
+	tr.declared[f.Addr] = true
+	return nil
+}
+
+// translateScope translates the parameters, local variables and lexical blocks
+// of the given function or block scope, reading child entries from r.
+func (tr *translator) translateScope(r *dwarf.Reader, f *c.FuncDecl, block *c.Block) error {
+	funcType := f.Type.(*c.FuncType)
+	for {
+		e, err := r.Next()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if e == nil || e.Tag == 0 {
+			return nil
+		}
+		switch e.Tag {
+		case dwarf.TagFormalParameter:
+			param, err := tr.varDecl(e)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			i := len(funcType.Params)
+			if len(param.Name) == 0 {
+				param.Name = fmt.Sprintf("param_%d", i+1)
+			}
+			if class, addr, ok := location(e); ok {
+				param.Class, param.Addr = class, addr
+			} else if i < 4 {
+				// The first four parameters are passed in $a0-$a3 (registers
+				// 4-7), and the remaining parameters on the stack.
+				param.Class, param.Addr = c.Register, uint32(4+i)
+			} else {
+				param.Addr = uint32(4 * i)
+			}
+			funcType.Params = append(funcType.Params, param)
+		case dwarf.TagUnspecifiedParameters:
+			funcType.Variadic = true
+		case dwarf.TagVariable:
+			class, addr, ok := location(e)
+			if !ok {
+				break
+			}
+			local, err := tr.varDecl(e)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			local.Class, local.Addr = class, addr
+			block.Locals = append(block.Locals, local)
+			if class == c.Static {
+				tr.declared[addr] = true
+			}
+		case dwarf.TagLexDwarfBlock:
+			b := &c.Block{
+				LineStart: block.LineStart,
+				LineEnd:   block.LineEnd,
+			}
+			if lowpc, ok := e.Val(dwarf.AttrLowpc).(uint64); ok {
+				b.LineStart = tr.relLine(f, tr.lineAt(uint32(lowpc)), block.LineStart)
+				switch v := e.Val(dwarf.AttrHighpc).(type) {
+				case uint64:
+					b.LineEnd = tr.relLine(f, tr.lineAt(uint32(v)-1), block.LineEnd)
+				case int64:
+					b.LineEnd = tr.relLine(f, tr.lineAt(uint32(lowpc+uint64(v))-1), block.LineEnd)
+				}
+			}
+			f.Blocks = append(f.Blocks, b)
+			if e.Children {
+				if err := tr.translateScope(r, f, b); err != nil {
+					return errors.WithStack(err)
+				}
+			}
+			continue
+		}
+		r.SkipChildren()
+	}
+}
+
+// translateSymbols records the named function and object symbols of the ELF
+// symbol table not located at the address of a function or variable.
+func (tr *translator) translateSymbols(syms []elf.Symbol) {
+	for _, s := range syms {
+		switch elf.ST_TYPE(s.Info) {
+		case elf.STT_NOTYPE, elf.STT_OBJECT, elf.STT_FUNC:
+		default:
+			continue
+		}
+		if len(s.Name) == 0 || s.Section == elf.SHN_UNDEF || s.Section == elf.SHN_ABS {
+			continue
+		}
+		addr := uint32(s.Value)
+		if tr.declared[addr] {
+			continue
+		}
+		tr.p.Symbols = append(tr.p.Symbols, &csym.Symbol{Addr: addr, Name: s.Name})
+	}
+}
+
+// varDecl returns the variable declaration of the given variable or parameter
+// entry, with its name and type.
+func (tr *translator) varDecl(e *dwarf.Entry) (*c.VarDecl, error) {
+	name, _ := tr.val(e, dwarf.AttrName).(string)
+	t, err := tr.typeAttr(e)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to translate type of variable %q", name)
+	}
+	v := &c.VarDecl{
+		Size: c.Sizeof(t),
+		Var: c.Var{
+			Type: t,
+			Name: name,
+		},
+	}
+	return v, nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// val returns the value of the given attribute of the entry; or of the entry it
+// is a specification or an instance of, if not present.
+func (tr *translator) val(e *dwarf.Entry, attr dwarf.Attr) interface{} {
+	// Limit the number of indirections, to guard against cycles.
+	for i := 0; i < 4 && e != nil; i++ {
+		if v := e.Val(attr); v != nil {
+			return v
+		}
+		off, ok := e.Val(dwarf.AttrSpecification).(dwarf.Offset)
+		if !ok {
+			if off, ok = e.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset); !ok {
+				return nil
+			}
+		}
+		r := tr.d.Reader()
+		r.Seek(off)
+		var err error
+		if e, err = r.Next(); err != nil {
+			return nil
+		}
+	}
+	return nil
+}
+
+// lineAt returns the line number of the given address in the compile unit being
+// translated; or 0 if not present.
+func (tr *translator) lineAt(addr uint32) uint32 {
+	i := sort.Search(len(tr.lines), func(i int) bool {
+		return tr.lines[i].Addr > addr
+	})
+	if i == 0 {
+		return 0
+	}
+	return tr.lines[i-1].Line
+}
+
+// relLine returns the given line number relative to the start line of the
+// function; or def if the line number is not present or located before the
+// function.
+func (tr *translator) relLine(f *c.FuncDecl, line, def uint32) uint32 {
+	if line == 0 || line < f.LineStart {
+		return def
+	}
+	return line - f.LineStart + 1
+}
+
+// DWARF location operations.
+const (
+	opAddr  = 0x03
+	opReg0  = 0x50
+	opReg31 = 0x6F
+	opBreg0 = 0x70
+	opRegx  = 0x90
+	opFbreg = 0x91
+)
+
+// Stack pointer register of MIPS.
+const mipsSP = 29
+
+// location returns the storage class and address, register or frame pointer
+// delta of the location expression of the given variable entry. The boolean
+// return value reports whether the location is a single register, stack
+// location (relative to the frame base or the stack pointer) or static address.
+func location(e *dwarf.Entry) (class c.StorageClass, addr uint32, ok bool) {
+	loc, ok := e.Val(dwarf.AttrLocation).([]byte)
+	if !ok || len(loc) == 0 {
+		return 0, 0, false
+	}
+	op, arg := loc[0], loc[1:]
+	switch {
+	case op == opAddr && len(arg) == 4:
+		return c.Static, binary.LittleEndian.Uint32(arg), true
+	case op == opAddr && len(arg) == 8:
+		return c.Static, uint32(binary.LittleEndian.Uint64(arg)), true
+	case op >= opReg0 && op <= opReg31 && len(arg) == 0:
+		return c.Register, uint32(op - opReg0), true
+	case op == opRegx:
+		if reg, n := binary.Uvarint(arg); n == len(arg) {
+			return c.Register, uint32(reg), true
+		}
+	case op == opFbreg, op == opBreg0+mipsSP:
+		if delta, n := sleb(arg); n == len(arg) {
+			return c.Auto, uint32(int32(delta)), true
+		}
+	}
+	return 0, 0, false
+}
+
+// sleb decodes the signed LEB128 encoded integer of b, and returns the number
+// of bytes read; or 0 if b is too short.
+func sleb(b []byte) (int64, int) {
+	var x int64
+	var shift uint
+	for i, v := range b {
+		x |= int64(v&0x7F) << shift
+		shift += 7
+		if v&0x80 == 0 {
+			if shift < 64 && v&0x40 != 0 {
+				x |= -1 << shift
+			}
+			return x, i + 1
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,104 @@
+package dwarfsym_test
+
+import (
+	"testing"
+
+	"github.com/sanctuary/sym/csym/c"
+	"github.com/sanctuary/sym/dwarfsym"
+)
+
+// testdata/test.elf is compiled from testdata/test.c using:
+//
+//	gcc -m32 -g -gdwarf-2 -O0 -nostdlib -static -fno-pie -no-pie \
+//		-fno-asynchronous-unwind-tables -fdebug-prefix-map=$(pwd)=/src \
+//		-o test.elf test.c
+
+func TestParseFile(t *testing.T) {
+	p, err := dwarfsym.ParseFile("testdata/test.elf")
+	if err != nil {
+		t.Fatalf("unable to parse ELF file; %+v", err)
+	}
+	point, ok := p.Structs["point"]
+	if !ok {
+		t.Fatalf("unable to locate struct point")
+	}
+	wantFields := []c.Field{
+		{Offset: 0, Var: c.Var{Name: "x"}},
+		{Offset: 4, Var: c.Var{Name: "pad"}},
+		{Offset: 8, Var: c.Var{Name: "next"}},
+		{Offset: 12, BitOffset: 0, BitWidth: 3, Var: c.Var{Name: "flags"}},
+		{Offset: 12, BitOffset: 3, BitWidth: 5, Var: c.Var{Name: "kind"}},
+	}
+	if point.Size != 16 || len(point.Fields) != len(wantFields) {
+		t.Fatalf("struct point mismatch; expected size 16 with %d fields, got %v", len(wantFields), point.Def())
+	}
+	for i, want := range wantFields {
+		got := point.Fields[i]
+		if got.Name != want.Name || got.Offset != want.Offset || got.BitOffset != want.BitOffset || got.BitWidth != want.BitWidth {
+			t.Errorf("field %d mismatch; expected %s at %d.%d, got %s at %d.%d", i, want.Name, want.Offset, want.BitOffset, got.Name, got.Offset, got.BitOffset)
+		}
+	}
+	if got, want := point.Fields[0].String(), "s32 x"; got != want {
+		t.Errorf("field type mismatch; expected %q, got %q", want, got)
+	}
+	if color := p.Enums["color"]; color == nil || !color.Signed || len(color.Members) != 3 {
+		t.Errorf("enum mismatch; expected signed enum color with 3 members, got %v", color)
+	}
+	if _, ok := p.Structs["_0fake"]; !ok {
+		t.Errorf("unable to locate anonymous struct of entry_t")
+	}
+	wantVars := []struct {
+		def   string
+		class c.StorageClass
+		addr  uint32
+	}{
+		{def: "struct point origin", class: c.Extern, addr: 0x0804B020},
+		{def: "entry_t entries[4]", class: c.Static, addr: 0x0804B040},
+		{def: "const char *msg", class: c.Extern, addr: 0x0804B008},
+	}
+	if len(p.Vars) != len(wantVars) {
+		t.Fatalf("number of global variables mismatch; expected %d, got %d", len(wantVars), len(p.Vars))
+	}
+	for i, want := range wantVars {
+		got := p.Vars[i]
+		if got.Var.String() != want.def || got.Class != want.class || got.Addr != want.addr {
+			t.Errorf("global variable %d mismatch; expected %s %q at 0x%08X, got %s %q at 0x%08X", i, want.class, want.def, want.addr, got.Class, got.Var.String(), got.Addr)
+		}
+	}
+	var add *c.FuncDecl
+	for _, f := range p.Funcs {
+		if f.Name == "add" {
+			add = f
+		}
+	}
+	if add == nil {
+		t.Fatalf("unable to locate function add")
+	}
+	if add.Addr != 0x08049000 || add.Size != 0x35 || add.Path != "/src/test.c" || add.LineStart != 26 || add.LineEnd != 35 {
+		t.Errorf("function mismatch; expected add of /src/test.c:26-35 at 0x08049000 of size 0x35, got %s of %s:%d-%d at 0x%08X of size 0x%X", add.Name, add.Path, add.LineStart, add.LineEnd, add.Addr, add.Size)
+	}
+	if got, want := add.Type.(*c.FuncType).String(), "int (struct point *p, int n)"; got != want {
+		t.Errorf("function type mismatch; expected %q, got %q", want, got)
+	}
+	if len(add.Blocks) != 2 {
+		t.Fatalf("number of blocks mismatch; expected 2, got %d", len(add.Blocks))
+	}
+	if sum := add.Blocks[0].Locals[0]; sum.Name != "sum" || sum.Class != c.Auto {
+		t.Errorf("local variable mismatch; expected auto sum, got %s %s", sum.Class, sum.Name)
+	}
+	if calls := add.Blocks[1].Locals[0]; calls.Name != "calls" || calls.Class != c.Static || calls.Addr != 0x0804B060 {
+		t.Errorf("local variable mismatch; expected static calls at 0x0804B060, got %s %s at 0x%08X", calls.Class, calls.Name, calls.Addr)
+	}
+	// ELF symbols not declared by the debug information.
+	for _, s := range p.Symbols {
+		if s.Name == "add" || s.Name == "origin" || s.Name == "calls.0" {
+			t.Errorf("unexpected symbol %q of declared address 0x%08X", s.Name, s.Addr)
+		}
+	}
+	if len(p.Lines) == 0 || p.Lines[0].Addr != 0x08049000 || p.Lines[0].Line != 27 {
+		t.Errorf("line numbers mismatch; expected line 27 at 0x08049000, got %v", p.Lines)
+	}
+	if _, err := p.Encode(); err != nil {
+		t.Errorf("unable to encode translated debug information; %+v", err)
+	}
+}
@@ -0,0 +1,42 @@
+typedef int s32;
+
+enum color {
+	RED,
+	GREEN,
+	NONE = -1,
+};
+
+struct point {
+	s32 x;
+	short pad[2];
+	struct point *next;
+	unsigned int flags : 3;
+	unsigned int kind : 5;
+};
+
+typedef struct {
+	enum color c;
+	void (*cb)(int, ...);
+} entry_t;
+
+struct point origin;
+static entry_t entries[4];
+const char *msg = "hello";
+
+int add(struct point *p, int n)
+{
+	int sum = p->x + n;
+	{
+		static int calls;
+		calls++;
+		sum += calls;
+	}
+	return sum + entries[0].c;
+}
+
+void _start(void)
+{
+	add(&origin, 1);
+	for (;;) {
+	}
+}
@@ -0,0 +1,316 @@
+package dwarfsym
+
+import (
+	"debug/dwarf"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// typeAttr returns the C type of the type attribute of the given entry; or void
+// if not present.
+func (tr *translator) typeAttr(e *dwarf.Entry) (c.Type, error) {
+	off, ok := tr.val(e, dwarf.AttrType).(dwarf.Offset)
+	if !ok {
+		return c.Void, nil
+	}
+	return tr.typeOf(off)
+}
+
+// typeOf returns the C type of the type entry at the given offset.
+func (tr *translator) typeOf(off dwarf.Offset) (c.Type, error) {
+	t, err := tr.d.Type(off)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return tr.translateType(t)
+}
+
+// translateType returns the C type of the given DWARF type, translating it if
+// not yet translated.
+func (tr *translator) translateType(t dwarf.Type) (c.Type, error) {
+	if t == nil {
+		return c.Void, nil
+	}
+	if ct, ok := tr.types[t]; ok {
+		return ct, nil
+	}
+	switch t := t.(type) {
+	case *dwarf.VoidType, *dwarf.UnspecifiedType:
+		return c.Void, nil
+	case *dwarf.IntType, *dwarf.UintType, *dwarf.CharType, *dwarf.UcharType, *dwarf.FloatType, *dwarf.BoolType:
+		return translateBase(t)
+	case *dwarf.StructType:
+		if t.Kind == "union" {
+			return tr.unionType(t)
+		}
+		return tr.structType(t)
+	case *dwarf.EnumType:
+		return tr.enumType(t), nil
+	case *dwarf.TypedefType:
+		return tr.typedef(t)
+	case *dwarf.PtrType:
+		elem, err := tr.translateType(t.Type)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return &c.PointerType{Elem: elem}, nil
+	case *dwarf.ArrayType:
+		elem, err := tr.translateType(t.Type)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		n := 0
+		if t.Count > 0 {
+			n = int(t.Count)
+		}
+		return &c.ArrayType{Elem: elem, Len: n}, nil
+	case *dwarf.QualType:
+		elem, err := tr.translateType(t.Type)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		switch t.Qual {
+		case "const":
+			return &c.QualType{Quals: c.Const, Type: elem}, nil
+		case "volatile":
+			return &c.QualType{Quals: c.Volatile, Type: elem}, nil
+		default:
+			// Qualifiers not present in SYM files (e.g. restrict).
+			return elem, nil
+		}
+	case *dwarf.FuncType:
+		retType, err := tr.translateType(t.ReturnType)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		funcType := &c.FuncType{RetType: retType}
+		for _, param := range t.ParamType {
+			if _, ok := param.(*dwarf.DotDotDotType); ok {
+				funcType.Variadic = true
+				continue
+			}
+			paramType, err := tr.translateType(param)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			funcType.Params = append(funcType.Params, &c.VarDecl{Var: c.Var{Type: paramType}})
+		}
+		return funcType, nil
+	}
+	return nil, errors.Errorf("support for DWARF type %v (%T) not yet implemented", t, t)
+}
+
+// structType returns the C struct type of the given DWARF struct type. Structs
+// of the same tag are translated once; the first complete definition is used.
+func (tr *translator) structType(t *dwarf.StructType) (c.Type, error) {
+	tag := t.StructName
+	if len(tag) == 0 {
+		tag = tr.fakeTag()
+	}
+	st, ok := tr.p.Structs[tag]
+	if !ok {
+		st = &c.StructType{Tag: tag}
+		tr.p.Structs[tag] = st
+		tr.p.StructTags = append(tr.p.StructTags, tag)
+	}
+	tr.types[t] = st
+	if t.Incomplete || st.Size != 0 || len(st.Fields) != 0 {
+		return st, nil
+	}
+	st.Size = uint32(t.ByteSize)
+	fields, err := tr.fields(t)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	st.Fields = fields
+	return st, nil
+}
+
+// unionType returns the C union type of the given DWARF union type. Unions of
+// the same tag are translated once; the first complete definition is used.
+func (tr *translator) unionType(t *dwarf.StructType) (c.Type, error) {
+	tag := t.StructName
+	if len(tag) == 0 {
+		tag = tr.fakeTag()
+	}
+	ut, ok := tr.p.Unions[tag]
+	if !ok {
+		ut = &c.UnionType{Tag: tag}
+		tr.p.Unions[tag] = ut
+		tr.p.UnionTags = append(tr.p.UnionTags, tag)
+	}
+	tr.types[t] = ut
+	if t.Incomplete || ut.Size != 0 || len(ut.Fields) != 0 {
+		return ut, nil
+	}
+	ut.Size = uint32(t.ByteSize)
+	fields, err := tr.fields(t)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	ut.Fields = fields
+	return ut, nil
+}
+
+// fields returns the C fields of the given DWARF struct or union type.
+func (tr *translator) fields(t *dwarf.StructType) ([]c.Field, error) {
+	var fields []c.Field
+	for _, f := range t.Field {
+		ft, err := tr.translateType(f.Type)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to translate type of field %q of %q", f.Name, t.StructName)
+		}
+		field := c.Field{
+			Offset: uint32(f.ByteOffset),
+			Size:   c.Sizeof(ft),
+			Var: c.Var{
+				Type: ft,
+				Name: f.Name,
+			},
+		}
+		if f.BitSize > 0 {
+			// Bit offset of the bitfield from the start of the struct.
+			bitPos := f.DataBitOffset
+			if f.DataBitOffset == 0 && (f.BitOffset != 0 || f.ByteSize != 0) {
+				// Bit offsets of DWARF version 2 and 3 are counted from the most
+				// significant bit of the storage unit of the bitfield.
+				unit := f.ByteSize
+				if unit == 0 {
+					unit = int64(field.Size)
+				}
+				bitPos = f.ByteOffset*8 + unit*8 - f.BitOffset - f.BitSize
+			}
+			field.Offset = uint32(bitPos / 8)
+			field.Size = 0
+			field.BitOffset = uint32(bitPos % 8)
+			field.BitWidth = uint32(f.BitSize)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// enumType returns the C enum type of the given DWARF enum type. Enums of the
+// same tag are translated once.
+func (tr *translator) enumType(t *dwarf.EnumType) c.Type {
+	tag := t.EnumName
+	if len(tag) == 0 {
+		tag = tr.fakeTag()
+	}
+	if et, ok := tr.p.Enums[tag]; ok {
+		tr.types[t] = et
+		return et
+	}
+	et := &c.EnumType{Tag: tag}
+	for _, v := range t.Val {
+		if v.Val < 0 {
+			et.Signed = true
+		}
+		et.Members = append(et.Members, &c.EnumMember{Name: v.Name, Value: uint32(v.Val)})
+	}
+	tr.p.Enums[tag] = et
+	tr.p.EnumTags = append(tr.p.EnumTags, tag)
+	tr.types[t] = et
+	return et
+}
+
+// typedef returns the C type definition of the given DWARF type definition.
+// Type definitions of the same name are translated once.
+func (tr *translator) typedef(t *dwarf.TypedefType) (c.Type, error) {
+	if def, ok := tr.p.Types[t.Name].(*c.VarDecl); ok {
+		tr.types[t] = def
+		return def, nil
+	}
+	def := &c.VarDecl{
+		Class: c.Typedef,
+		Var: c.Var{
+			Name: t.Name,
+		},
+	}
+	tr.types[t] = def
+	tr.p.Types[t.Name] = def
+	typ, err := tr.translateType(t.Type)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to translate type of type definition %q", t.Name)
+	}
+	def.Type = typ
+	tr.p.Typedefs = append(tr.p.Typedefs, def)
+	return def, nil
+}
+
+// fakeTag returns a new fake tag for an anonymous struct, union or enum type.
+func (tr *translator) fakeTag() string {
+	tag := fmt.Sprintf("_%dfake", tr.nfakes)
+	tr.nfakes++
+	return tag
+}
+
+// translateBase returns the C type of the given DWARF base type. Base types are
+// identified by name and size, or by encoding and size if not of a known name
+// or of a different size than expected (e.g. "long int" of 64-bit targets).
+func translateBase(t dwarf.Type) (c.Type, error) {
+	name, size := t.Common().Name, t.Size()
+	if bt, ok := baseTypes[name]; ok && int64(c.Sizeof(bt)) == size {
+		return bt, nil
+	}
+	switch t.(type) {
+	case *dwarf.BoolType:
+		return c.Bool, nil
+	case *dwarf.CharType:
+		return c.Char, nil
+	case *dwarf.UcharType:
+		return c.UChar, nil
+	case *dwarf.FloatType:
+		if size == 4 {
+			return c.Float, nil
+		}
+		// Double and extended precision floating-point types.
+		return c.Double, nil
+	case *dwarf.IntType:
+		switch size {
+		case 1:
+			return c.SChar, nil
+		case 2:
+			return c.Short, nil
+		case 4:
+			return c.Int, nil
+		case 8:
+			return &c.ArrayType{Elem: c.Int, Len: 2}, nil
+		}
+	case *dwarf.UintType:
+		switch size {
+		case 1:
+			return c.UChar, nil
+		case 2:
+			return c.UShort, nil
+		case 4:
+			return c.UInt, nil
+		case 8:
+			return &c.ArrayType{Elem: c.UInt, Len: 2}, nil
+		}
+	}
+	return nil, errors.Errorf("support for DWARF base type %q of size %d not yet implemented", name, size)
+}
+
+// baseTypes maps from DWARF base type name (as output by GCC) to C type.
+var baseTypes = map[string]c.Type{
+	"char":               c.Char,
+	"signed char":        c.SChar,
+	"unsigned char":      c.UChar,
+	"short int":          c.Short,
+	"short":              c.Short,
+	"short unsigned int": c.UShort,
+	"unsigned short":     c.UShort,
+	"int":                c.Int,
+	"unsigned int":       c.UInt,
+	"long int":           c.Long,
+	"long":               c.Long,
+	"long unsigned int":  c.ULong,
+	"unsigned long":      c.ULong,
+	"float":              c.Float,
+	"double":             c.Double,
+	"_Bool":              c.Bool,
+	"bool":               c.Bool,
+}
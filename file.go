@@ -2,7 +2,6 @@
 package sym
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/binary"
 	"fmt"
@@ -12,24 +11,80 @@ import (
 
 	"github.com/lunixbochs/struc"
 	"github.com/pkg/errors"
+	"golang.org/x/text/encoding"
 )
 
 // A File is PS1 symbol file.
 type File struct {
 	// File header.
 	Hdr *FileHeader
+	// Byte order of multi-byte fields; little-endian for PS1 symbol files.
+	Order binary.ByteOrder
 	// Symbols.
 	Syms []*Symbol
+	// Regions of the file skipped while recovering from corrupted symbols.
+	Skipped []*SkippedRegion
+	// Dialect of the SYM format, as detected when parsing; or nil if not parsed
+	// from a SYM file.
+	Dialect *Dialect
 }
 
-// String returns the string representation of the symbol file.
+// A SkippedRegion is a region of the symbol file skipped while recovering from
+// a corrupted symbol.
+type SkippedRegion struct {
+	// Offset in bytes from the start of the file.
+	Offset int
+	// Size in bytes.
+	Size int
+	// Error encountered when decoding the symbol at the start of the region.
+	Err error
+}
+
+// String returns the string representation of the skipped region.
+func (r *SkippedRegion) String() string {
+	return fmt.Sprintf("skipped %d bytes at offset 0x%06x; %v", r.Size, r.Offset, r.Err)
+}
+
+// NewFile returns a new little-endian PS1 symbol file (version 1) of the given
+// symbols.
+func NewFile(syms []*Symbol) *File {
+	return &File{
+		Hdr: &FileHeader{
+			Signature: [3]byte{'M', 'N', 'D'},
+			Version:   1,
+		},
+		Order: binary.LittleEndian,
+		Syms:  syms,
+	}
+}
+
+// DumpOptions specifies the options used when formatting symbol files in Psy-Q
+// DUMPSYM.EXE format.
+type DumpOptions struct {
+	// NoOffsets specifies whether to omit the leading file offset of each
+	// symbol, so that the dumps of symbol files differing only in layout (e.g.
+	// padding or regions skipped while recovering) compare equal.
+	NoOffsets bool
+}
+
+// String returns the string representation of the symbol file, in Psy-Q
+// DUMPSYM.EXE format.
 func (f *File) String() string {
+	return f.Dump(nil)
+}
+
+// Dump returns the string representation of the symbol file in Psy-Q
+// DUMPSYM.EXE format, using the specified dump options.
+func (f *File) Dump(opts *DumpOptions) string {
+	if opts == nil {
+		opts = &DumpOptions{}
+	}
 	buf := &strings.Builder{}
-	offset := 0
 	fmt.Fprintln(buf, f.Hdr)
-	offset += binary.Size(*f.Hdr)
+	offsets := f.symOffsets()
 	var line int
-	for _, sym := range f.Syms {
+	for i, sym := range f.Syms {
+		offset := offsets[i]
 		bodyStr := sym.Body.String()
 		switch body := sym.Body.(type) {
 		case *IncSLD:
@@ -55,18 +110,37 @@ func (f *File) String() string {
 		case *SetSLD2:
 			line = int(body.Line)
 		}
+		if !opts.NoOffsets {
+			fmt.Fprintf(buf, "%06x: ", offset)
+		}
 		if len(bodyStr) == 0 {
 			// Symbol without body.
-			fmt.Fprintf(buf, "%06x: %s\n", offset, sym.Hdr)
-
+			fmt.Fprintf(buf, "%s\n", sym.Hdr)
 		} else {
-			fmt.Fprintf(buf, "%06x: %s %s\n", offset, sym.Hdr, bodyStr)
+			fmt.Fprintf(buf, "%s %s\n", sym.Hdr, bodyStr)
 		}
-		offset += sym.Size()
 	}
 	return buf.String()
 }
 
+// symOffsets returns the offset in bytes from the start of the file of each
+// symbol.
+func (f *File) symOffsets() []int {
+	offsets := make([]int, len(f.Syms))
+	offset := binary.Size(*f.Hdr)
+	skipped := f.Skipped
+	for i, sym := range f.Syms {
+		// Account for regions skipped while recovering from corrupted symbols.
+		for len(skipped) > 0 && skipped[0].Offset == offset {
+			offset += skipped[0].Size
+			skipped = skipped[1:]
+		}
+		offsets[i] = offset
+		offset += sym.Size()
+	}
+	return offsets
+}
+
 // A FileHeader is a PS1 symbol file header.
 type FileHeader struct {
 	// File signature; MND.
@@ -85,50 +159,193 @@ Target unit %d`
 	return fmt.Sprintf(format, hdr.Signature, hdr.Version, hdr.TargetUnit)
 }
 
+// ErrTruncated is returned (as the cause of the error) when the symbol file
+// ends mid-symbol. The successfully parsed symbols are returned alongside the
+// error.
+var ErrTruncated = errors.New("truncated symbol file")
+
+// ParseOptions specifies the options used when parsing PS1 symbol files.
+type ParseOptions struct {
+	// Lenient specifies whether to capture symbols of unknown kind as opaque
+	// UnknownBody symbols and continue parsing, rather than aborting the parse.
+	Lenient bool
+	// Recover specifies whether to skip corrupted symbols that fail to decode,
+	// resuming the parse at the next plausible symbol. Skipped regions are
+	// recorded in File.Skipped.
+	Recover bool
+	// Encoding of symbol names, tags and paths (optional). If set, names are
+	// decoded from the given encoding (e.g. japanese.ShiftJIS) to UTF-8; the
+	// raw encoded contents of each symbol are preserved in Symbol.Raw.
+	Encoding encoding.Encoding
+	// Byte order of multi-byte fields (optional). Symbol files of the PS1 are
+	// little-endian, while the SN Systems symbol files of big-endian targets
+	// (e.g. Saturn and N64) share the same layout in big-endian. If nil, the
+	// byte order is detected from the contents of the file.
+	Order binary.ByteOrder
+	// DetectEncoding specifies whether to decode names, tags and paths using
+	// the encoding of the detected dialect (see File.Dialect), if Encoding is
+	// nil. Names are otherwise left undecoded, as output by DUMPSYM.EXE.
+	DetectEncoding bool
+}
+
 // ParseFile parses the given PS1 symbol file.
 func ParseFile(path string) (*File, error) {
+	return ParseFileWithOptions(path, nil)
+}
+
+// ParseFileWithOptions parses the given PS1 symbol file, using the specified
+// parse options.
+func ParseFileWithOptions(path string, opts *ParseOptions) (*File, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 	defer f.Close()
-	return Parse(f)
+	return ParseWithOptions(f, opts)
 }
 
 // ParseBytes parses the given PS1 symbol file, reading from b.
 func ParseBytes(b []byte) (*File, error) {
-	return Parse(bytes.NewReader(b))
+	return ParseBytesWithOptions(b, nil)
+}
+
+// ParseBytesWithOptions parses the given PS1 symbol file, reading from b and
+// using the specified parse options.
+//
+// The dialect of the symbol file is detected and recorded in File.Dialect.
+func ParseBytesWithOptions(b []byte, opts *ParseOptions) (*File, error) {
+	return ParseWithOptions(bytes.NewReader(b), opts)
 }
 
 // Parse parses the given PS1 symbol file, reading from r.
 func Parse(r io.Reader) (*File, error) {
+	return ParseWithOptions(r, nil)
+}
+
+// ParseWithOptions parses the given PS1 symbol file, reading from r and using
+// the specified parse options.
+//
+// The dialect of the symbol file is detected and recorded in File.Dialect.
+func ParseWithOptions(r io.Reader, opts *ParseOptions) (*File, error) {
+	if opts == nil {
+		opts = &ParseOptions{}
+	}
+	f, err := parse(r, opts)
+	if f == nil {
+		return nil, err
+	}
+	f.Dialect = DetectDialect(f)
+	switch {
+	case opts.Encoding != nil:
+		f.Dialect.Encoding = opts.Encoding
+	case opts.DetectEncoding && f.Dialect.Encoding != nil:
+		for _, sym := range f.Syms {
+			if err := decodeNames(sym.Body, f.Dialect.Encoding.NewDecoder()); err != nil {
+				return f, errors.WithStack(err)
+			}
+		}
+	}
+	return f, err
+}
+
+// parse parses the given PS1 symbol file, reading from r and using the
+// specified parse options. The symbols parsed before an error are returned
+// alongside the error, unless the file header is invalid.
+//
+// The contents of r are read incrementally; besides the raw contents of parsed
+// symbols, only the lookahead required to detect the byte order and to
+// resynchronize after corrupted symbols is buffered.
+func parse(r io.Reader, opts *ParseOptions) (*File, error) {
+	s := &scanner{r: r}
+	order := opts.Order
+	if order == nil {
+		s.fill(orderLookahead)
+		if err := s.readErr(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		order = detectOrder(s.buf)
+	}
 	// Parse file header.
-	f := &File{}
-	br := bufio.NewReader(r)
-	hdr, err := parseFileHeader(br)
+	f := &File{
+		Order: order,
+	}
+	sr := s.reader()
+	hdr, err := parseFileHeader(sr, order)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 	f.Hdr = hdr
+	s.discard(sr.pos)
 
 	// Parse symbols.
 	for {
-		sym, err := parseSymbol(br)
+		s.fill(1)
+		if err := s.readErr(); err != nil {
+			return f, errors.WithStack(err)
+		}
+		if len(s.buf) == 0 {
+			break
+		}
+		offset := s.off
+		sr := s.reader()
+		sym, err := parseSymbol(sr, order)
 		if err != nil {
-			if errors.Cause(err) == io.EOF {
-				break
+			if err := s.readErr(); err != nil {
+				return f, errors.WithStack(err)
+			}
+			if opts.Lenient && sym != nil && !isKnownKind(sym.Hdr.Kind) {
+				// Capture body of unknown symbol kind, extending up until the
+				// next valid symbol.
+				start := binary.Size(*sym.Hdr)
+				end := s.nextSymbol(start, order)
+				sym.Body = &UnknownBody{
+					Kind: sym.Hdr.Kind,
+					Raw:  s.buf[start:end:end],
+				}
+				sym.Raw = s.buf[:end:end]
+				f.Syms = append(f.Syms, sym)
+				s.discard(end)
+				continue
+			}
+			cause := errors.Cause(err)
+			truncated := cause == io.EOF || cause == io.ErrUnexpectedEOF
+			if opts.Recover {
+				// Resynchronize at the next plausible symbol, unless the file
+				// ends mid-symbol.
+				end := s.nextSymbol(1, order)
+				if !truncated || end != len(s.buf) {
+					region := &SkippedRegion{
+						Offset: offset,
+						Size:   end,
+						Err:    err,
+					}
+					f.Skipped = append(f.Skipped, region)
+					s.discard(end)
+					continue
+				}
+			}
+			if truncated {
+				return f, errors.WithStack(ErrTruncated)
 			}
 			return f, errors.WithStack(err)
 		}
+		sym.Raw = s.buf[:sr.pos:sr.pos]
+		s.discard(sr.pos)
+		if opts.Encoding != nil {
+			if err := decodeNames(sym.Body, opts.Encoding.NewDecoder()); err != nil {
+				return f, errors.WithStack(err)
+			}
+		}
 		f.Syms = append(f.Syms, sym)
 	}
 	return f, nil
 }
 
-// parseFileHeader parses and returns a PS1 symbol file header.
-func parseFileHeader(r io.Reader) (*FileHeader, error) {
+// parseFileHeader parses and returns a PS1 symbol file header, using the given
+// byte order.
+func parseFileHeader(r io.Reader, order binary.ByteOrder) (*FileHeader, error) {
 	hdr := &FileHeader{}
-	if err := struc.Unpack(r, hdr); err != nil {
+	if err := struc.UnpackWithOrder(r, hdr, order); err != nil {
 		return nil, errors.WithStack(err)
 	}
 	// Verify Smacker signature.
@@ -140,3 +357,72 @@ func parseFileHeader(r io.Reader) (*FileHeader, error) {
 	}
 	return hdr, nil
 }
+
+// detectOrder detects the byte order of the given symbol file, based on the
+// number of leading symbols which are valid in either byte order.
+func detectOrder(b []byte) binary.ByteOrder {
+	// Maximum number of symbols to inspect.
+	const maxSyms = 64
+	// count returns the number of leading valid symbols in the given byte order.
+	count := func(order binary.ByteOrder) int {
+		offset := binary.Size(FileHeader{})
+		n := 0
+		for ; n < maxSyms && offset < len(b); n++ {
+			if !isValidSymbol(b, offset, order) {
+				break
+			}
+			sym, err := parseSymbol(bytes.NewReader(b[offset:]), order)
+			if err != nil {
+				break
+			}
+			offset += sym.Size()
+		}
+		return n
+	}
+	if count(binary.BigEndian) > count(binary.LittleEndian) {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// decodeNames decodes the names, tags and paths of the given symbol body to
+// UTF-8, using the given decoder.
+func decodeNames(body SymbolBody, dec *encoding.Decoder) error {
+	decode := func(s *string) error {
+		t, err := dec.String(*s)
+		if err != nil {
+			return errors.Wrapf(err, "unable to decode %q", *s)
+		}
+		*s = t
+		return nil
+	}
+	for _, name := range bodyNames(body) {
+		if err := decode(name); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// bodyNames returns the names, tags and paths of the given symbol body.
+func bodyNames(body SymbolBody) []*string {
+	switch body := body.(type) {
+	case *Name1:
+		return []*string{&body.Name}
+	case *Name2:
+		return []*string{&body.Name}
+	case *Name5:
+		return []*string{&body.Name}
+	case *Name6:
+		return []*string{&body.Name}
+	case *SetSLD2:
+		return []*string{&body.Path}
+	case *FuncStart:
+		return []*string{&body.Path, &body.Name}
+	case *Def:
+		return []*string{&body.Name}
+	case *Def2:
+		return []*string{&body.Tag, &body.Name}
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package sym
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// A Filter specifies the criteria of symbols to keep when reducing a symbol
+// file. A symbol matches the filter if it satisfies every specified criterion;
+// unspecified (zero-valued) criteria match every symbol.
+type Filter struct {
+	// Symbol kinds.
+	Kinds []Kind
+	// Definition classes; only definition symbols (Def and Def2) match.
+	Classes []Class
+	// Start address of the address range (inclusive); only symbols associated
+	// with an address match if either end of the address range is specified.
+	MinAddr uint32
+	// End address of the address range (exclusive); or 0 if unbounded.
+	MaxAddr uint32
+	// Overlay IDs (0 for the default binary); or nil to match every overlay.
+	Overlays []uint32
+	// Regular expression of symbol names; only named symbols match.
+	Name *regexp.Regexp
+	// Strip matching symbols, keeping the remaining symbols.
+	Strip bool
+}
+
+// Apply returns the symbols of the given symbols kept by the filter.
+//
+// Symbols are kept or removed in units of related symbols, as matched by the
+// first symbol of each unit; functions (from function start to function end),
+// definitions of struct, union and enum types (from tag to end of symbol) and
+// line number sequences. Definitions of struct, union and enum types referenced
+// by kept symbols are kept, as are the global definitions of kept functions, so
+// that the kept symbols remain self-contained.
+// Overlay definitions are kept for overlays of kept symbols, and set overlay
+// symbols are inserted as required by the kept symbols.
+func (filter *Filter) Apply(syms []*Symbol) []*Symbol {
+	// A unit of related symbols, or an overlay definition.
+	type unit struct {
+		// ID of the overlay active at the unit.
+		overlay uint32
+		// Symbols of the unit.
+		syms []*Symbol
+		// Specifies whether the unit is kept.
+		kept bool
+	}
+	var (
+		// Units, in order of occurrence.
+		units []*unit
+		// tagUnits maps from tag to the units of type definitions of the tag.
+		tagUnits = make(map[string][]*unit)
+		// globalUnits maps from name to the units of global definitions of the
+		// name.
+		globalUnits = make(map[string][]*unit)
+		// Kept units of which the referenced tags remain to be kept.
+		queue []*unit
+	)
+	var overlay uint32
+	for i := 0; i < len(syms); {
+		s := syms[i]
+		switch s.Body.(type) {
+		case *Overlay:
+			// Overlay definitions are kept based on the kept symbols.
+			units = append(units, &unit{syms: syms[i : i+1]})
+			i++
+			continue
+		case *SetOverlay:
+			overlay = s.Hdr.Value
+			i++
+			continue
+		}
+		n := unitLen(syms[i:])
+		u := &unit{overlay: overlay, syms: syms[i : i+n]}
+		units = append(units, u)
+		if isTagDef(s) {
+			tag := s.Name()
+			tagUnits[tag] = append(tagUnits[tag], u)
+		}
+		if class, ok := defClass(s); ok && (class == ClassEXT || class == ClassSTAT) {
+			name := s.Name()
+			globalUnits[name] = append(globalUnits[name], u)
+		}
+		if filter.match(s, overlay) != filter.Strip {
+			u.kept = true
+			queue = append(queue, u)
+		}
+		i += n
+	}
+	// Keep type definitions referenced by kept symbols, and global definitions
+	// of kept functions.
+	keep := func(v *unit) {
+		if !v.kept {
+			v.kept = true
+			queue = append(queue, v)
+		}
+	}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		if body, ok := u.syms[0].Body.(*FuncStart); ok {
+			for _, v := range globalUnits[body.Name] {
+				if v.overlay == u.overlay && v.syms[0].Hdr.Value == u.syms[0].Hdr.Value {
+					keep(v)
+				}
+			}
+		}
+		for _, s := range u.syms {
+			if body, ok := s.Body.(*Def2); ok && len(body.Tag) > 0 {
+				for _, v := range tagUnits[body.Tag] {
+					keep(v)
+				}
+			}
+		}
+	}
+	// overlays maps from ID of overlay with kept symbols to true.
+	overlays := make(map[uint32]bool)
+	for _, u := range units {
+		if u.kept {
+			overlays[u.overlay] = true
+		}
+	}
+	var kept []*Symbol
+	overlay = 0
+	for _, u := range units {
+		if body, ok := u.syms[0].Body.(*Overlay); ok {
+			if overlays[body.ID] {
+				kept = append(kept, u.syms...)
+			}
+			continue
+		}
+		if !u.kept {
+			continue
+		}
+		if u.overlay != overlay {
+			hdr := &SymbolHeader{Value: u.overlay, Kind: KindSetOverlay}
+			kept = append(kept, &Symbol{Hdr: hdr, Body: &SetOverlay{}})
+			overlay = u.overlay
+		}
+		kept = append(kept, u.syms...)
+	}
+	return kept
+}
+
+// ExtractOverlay returns the symbols of the overlay with the given ID, along
+// with the overlay definition specifying its base address and the definitions
+// of types referenced by the symbols of the overlay.
+func ExtractOverlay(syms []*Symbol, id uint32) ([]*Symbol, error) {
+	found := false
+	for _, s := range syms {
+		if body, ok := s.Body.(*Overlay); ok && body.ID == id {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, errors.Errorf("unable to locate overlay with ID %x", id)
+	}
+	filter := &Filter{Overlays: []uint32{id}}
+	return filter.Apply(syms), nil
+}
+
+// match reports whether the given symbol, located in the specified overlay,
+// matches the filter criteria.
+func (filter *Filter) match(s *Symbol, overlay uint32) bool {
+	info := s.Info()
+	if len(filter.Kinds) > 0 && !containsKind(filter.Kinds, info.Kind) {
+		return false
+	}
+	if len(filter.Classes) > 0 {
+		class, ok := defClass(s)
+		if !ok || !containsClass(filter.Classes, class) {
+			return false
+		}
+	}
+	if filter.MinAddr != 0 || filter.MaxAddr != 0 {
+		if !info.HasAddr || info.Addr < filter.MinAddr {
+			return false
+		}
+		if filter.MaxAddr != 0 && info.Addr >= filter.MaxAddr {
+			return false
+		}
+	}
+	if filter.Overlays != nil && !containsOverlay(filter.Overlays, overlay) {
+		return false
+	}
+	if filter.Name != nil && (len(info.Name) == 0 || !filter.Name.MatchString(info.Name)) {
+		return false
+	}
+	return true
+}
+
+// unitLen returns the number of symbols of the unit of related symbols starting
+// at the first of the given symbols.
+func unitLen(syms []*Symbol) int {
+	switch syms[0].Body.(type) {
+	case *FuncStart:
+		// Function start to function end.
+		for n, s := range syms {
+			if _, ok := s.Body.(*FuncEnd); ok {
+				return n + 1
+			}
+		}
+		return len(syms)
+	case *SetSLD2:
+		// Line number sequence.
+		for n, s := range syms[1:] {
+			switch s.Body.(type) {
+			case *IncSLD, *IncSLDByte, *IncSLDWord, *SetSLD:
+				// part of line number sequence.
+			case *EndSLD:
+				return n + 2
+			default:
+				return n + 1
+			}
+		}
+		return len(syms)
+	}
+	if isTagDef(syms[0]) {
+		// Tag to end of symbol.
+		for n, s := range syms {
+			if class, ok := defClass(s); ok && class == ClassEOS {
+				return n + 1
+			}
+		}
+		return len(syms)
+	}
+	return 1
+}
+
+// isTagDef reports whether the given symbol is the tag of a definition of a
+// struct, union or enum type.
+func isTagDef(s *Symbol) bool {
+	class, ok := defClass(s)
+	return ok && (class == ClassSTRTAG || class == ClassUNTAG || class == ClassENTAG)
+}
+
+// defClass returns the class of the given definition symbol. The boolean
+// return value reports whether the symbol is a definition (Def or Def2).
+func defClass(s *Symbol) (Class, bool) {
+	switch body := s.Body.(type) {
+	case *Def:
+		return body.Class, true
+	case *Def2:
+		return body.Class, true
+	}
+	return 0, false
+}
+
+// containsKind reports whether the given list of symbol kinds contains kind.
+func containsKind(kinds []Kind, kind Kind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// containsClass reports whether the given list of definition classes contains
+// class.
+func containsClass(classes []Class, class Class) bool {
+	for _, c := range classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// containsOverlay reports whether the given list of overlay IDs contains id.
+func containsOverlay(ids []uint32, id uint32) bool {
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package sym_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/sanctuary/sym"
+)
+
+func TestFilter(t *testing.T) {
+	name := func(addr uint32, name string) *sym.Symbol {
+		return &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: addr, Kind: sym.KindName2},
+			Body: &sym.Name2{NameLen: uint8(len(name)), Name: name},
+		}
+	}
+	syms := []*sym.Symbol{
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x800B0000, Kind: sym.KindOverlay},
+			Body: &sym.Overlay{Length: 0x100, ID: 4},
+		},
+		name(0x80010000, "main"),
+		// struct point { int x; };
+		newDef(0, sym.ClassSTRTAG, "point"),
+		newDef(0, sym.ClassMOS, "x"),
+		newDef(4, sym.ClassEOS, ".eos"),
+		newDef(0x80010100, sym.ClassEXT, "DoEpi"),
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010100, Kind: sym.KindFuncStart},
+			Body: &sym.FuncStart{Name: "DoEpi"},
+		},
+		// struct point *p;
+		{
+			Hdr:  &sym.SymbolHeader{Value: 4, Kind: sym.KindDef2},
+			Body: &sym.Def2{Class: sym.ClassREGPARM, Type: sym.Type(sym.BaseStruct) | sym.Type(sym.ModPointer)<<4, Size: 4, Tag: "point", Name: "p"},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010140, Kind: sym.KindFuncEnd},
+			Body: &sym.FuncEnd{Line: 4},
+		},
+		newDef(0x80020000, sym.ClassEXT, "gVar"),
+		{
+			Hdr:  &sym.SymbolHeader{Value: 4, Kind: sym.KindSetOverlay},
+			Body: &sym.SetOverlay{},
+		},
+		name(0x800B0000, "ovl_main"),
+	}
+	golden := []struct {
+		filter *sym.Filter
+		want   []string
+	}{
+		{
+			filter: &sym.Filter{Kinds: []sym.Kind{sym.KindName2}},
+			want:   []string{"overlay", "main", "set overlay", "ovl_main"},
+		},
+		{
+			filter: &sym.Filter{Name: regexp.MustCompile(`^Do`)},
+			// Types referenced by kept symbols are kept.
+			want: []string{"point", "x", ".eos", "DoEpi", "DoEpi", "p", "8e"},
+		},
+		{
+			filter: &sym.Filter{Name: regexp.MustCompile(`^Do`), Strip: true},
+			want:   []string{"overlay", "main", "point", "x", ".eos", "gVar", "set overlay", "ovl_main"},
+		},
+		{
+			filter: &sym.Filter{Classes: []sym.Class{sym.ClassEXT}},
+			want:   []string{"DoEpi", "gVar"},
+		},
+		{
+			filter: &sym.Filter{MinAddr: 0x80010080, MaxAddr: 0x800B0000},
+			want:   []string{"point", "x", ".eos", "DoEpi", "DoEpi", "p", "8e", "gVar"},
+		},
+		{
+			// Global definitions of kept functions are kept.
+			filter: &sym.Filter{Kinds: []sym.Kind{sym.KindFuncStart}},
+			want:   []string{"point", "x", ".eos", "DoEpi", "DoEpi", "p", "8e"},
+		},
+		{
+			filter: &sym.Filter{Overlays: []uint32{4}},
+			want:   []string{"overlay", "set overlay", "ovl_main"},
+		},
+	}
+	for i, g := range golden {
+		got := symNames(g.filter.Apply(syms))
+		if strings.Join(got, ", ") != strings.Join(g.want, ", ") {
+			t.Errorf("filter %d mismatch; expected %v, got %v", i, g.want, got)
+		}
+	}
+	overlay, err := sym.ExtractOverlay(syms, 4)
+	if err != nil {
+		t.Fatalf("unable to extract overlay; %+v", err)
+	}
+	want := []string{"overlay", "set overlay", "ovl_main"}
+	if got := symNames(overlay); strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("overlay symbols mismatch; expected %v, got %v", want, got)
+	}
+	if _, err := sym.ExtractOverlay(syms, 5); err == nil {
+		t.Errorf("expected error for extraction of undefined overlay")
+	}
+}
+
+// symNames returns the names of the given symbols; or the kind of unnamed
+// symbols.
+func symNames(syms []*sym.Symbol) []string {
+	var names []string
+	for _, s := range syms {
+		name := s.Name()
+		if len(name) == 0 {
+			name = s.Hdr.Kind.String()
+		}
+		names = append(names, name)
+	}
+	return names
+}
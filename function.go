@@ -0,0 +1,269 @@
+package sym
+
+import (
+	"fmt"
+)
+
+// A Function is a function reconstructed from a function start symbol and its
+// matching function end symbol, exposing the stack frame information of the
+// function.
+type Function struct {
+	// Start address.
+	Addr uint32
+	// End address; i.e. the address of the function end symbol.
+	EndAddr uint32
+	// ID of the overlay containing the function (0 for the default binary).
+	Overlay uint32
+	// Source file.
+	Path string
+	// Function name.
+	Name string
+	// Frame pointer register; RegInvalid if out of range.
+	FP Reg
+	// Frame size in bytes.
+	FrameSize uint32
+	// Return address register; RegInvalid if out of range.
+	RetReg Reg
+	// Mask of callee-saved registers; bit n is set if register n is saved.
+	Mask uint32
+	// Offset of the save area of callee-saved registers, relative to the
+	// virtual frame pointer (i.e. the stack pointer on function entry).
+	MaskOffset int32
+	// Start line number.
+	LineStart uint32
+	// End line number.
+	LineEnd uint32
+	// Function scope, holding the parameters of the function and the nested
+	// block scopes of its body.
+	Scope *Scope
+}
+
+// String returns the string representation of the function.
+func (f *Function) String() string {
+	return fmt.Sprintf("%s (0x%08X)", f.Name, f.Addr)
+}
+
+// A SavedReg is a callee-saved register and its location on the stack.
+type SavedReg struct {
+	// Saved register.
+	Reg Reg
+	// Offset of save slot, relative to the virtual frame pointer (i.e. the stack
+	// pointer on function entry).
+	Offset int32
+}
+
+// SavedRegs returns the callee-saved registers of the function and their
+// locations on the stack.
+//
+// Following the MIPS calling convention, registers are saved in descending
+// order of register number, starting at MaskOffset and proceeding downwards in
+// steps of 4 bytes.
+func (f *Function) SavedRegs() []SavedReg {
+	var regs []SavedReg
+	offset := f.MaskOffset
+	for reg := 31; reg >= 0; reg-- {
+		if f.Mask&(1<<uint(reg)) == 0 {
+			continue
+		}
+		saved := SavedReg{
+			Reg:    Reg(reg),
+			Offset: offset,
+		}
+		regs = append(regs, saved)
+		offset -= 4
+	}
+	return regs
+}
+
+// A Scope is a lexical scope of a function; either the function scope or a
+// nested block scope.
+type Scope struct {
+	// Start address.
+	Addr uint32
+	// End address.
+	EndAddr uint32
+	// Start line number; relative to the start of the function for block
+	// scopes.
+	LineStart uint32
+	// End line number; relative to the start of the function for block scopes.
+	LineEnd uint32
+	// Definition symbols (Def or Def2) declared in the scope; parameters in the
+	// function scope and local variables in block scopes.
+	Defs []*Symbol
+	// Nested block scopes.
+	Scopes []*Scope
+	// Parent scope; nil for the function scope.
+	Parent *Scope
+}
+
+// Functions returns the functions of the given symbols, as reconstructed from
+// function start and end symbols, and the block and definition symbols
+// enclosed by them.
+func Functions(syms []*Symbol) []*Function {
+	var funcs []*Function
+	var (
+		// Current overlay ID.
+		overlay uint32
+		// Current function.
+		cur *Function
+		// Current scope.
+		scope *Scope
+	)
+	for _, s := range syms {
+		switch body := s.Body.(type) {
+		case *FuncStart:
+			cur = &Function{
+				Addr:       s.Hdr.Value,
+				Overlay:    overlay,
+				Path:       body.Path,
+				Name:       body.Name,
+				FP:         newReg(uint32(body.FP)),
+				FrameSize:  body.FSize,
+				RetReg:     newReg(uint32(body.RetReg)),
+				Mask:       body.Mask,
+				MaskOffset: body.MaskOffset,
+				LineStart:  body.Line,
+			}
+			cur.Scope = &Scope{
+				Addr:      cur.Addr,
+				LineStart: cur.LineStart,
+			}
+			scope = cur.Scope
+			funcs = append(funcs, cur)
+		case *FuncEnd:
+			if cur == nil {
+				// Ignore function end without matching function start.
+				continue
+			}
+			cur.EndAddr = s.Hdr.Value
+			cur.LineEnd = body.Line
+			cur.Scope.EndAddr = cur.EndAddr
+			cur.Scope.LineEnd = cur.LineEnd
+			cur = nil
+			scope = nil
+		case *BlockStart:
+			if scope == nil {
+				// Ignore block outside of function.
+				continue
+			}
+			block := &Scope{
+				Addr:      s.Hdr.Value,
+				LineStart: body.Line,
+				Parent:    scope,
+			}
+			scope.Scopes = append(scope.Scopes, block)
+			scope = block
+		case *BlockEnd:
+			if scope == nil || scope.Parent == nil {
+				// Ignore block end without matching block start.
+				continue
+			}
+			scope.EndAddr = s.Hdr.Value
+			scope.LineEnd = body.Line
+			scope = scope.Parent
+		case *Def, *Def2:
+			if scope != nil {
+				scope.Defs = append(scope.Defs, s)
+			}
+		case *SetOverlay:
+			overlay = s.Hdr.Value
+		}
+	}
+	return funcs
+}
+
+// A Local is a local variable or parameter of a function.
+type Local struct {
+	// Variable name.
+	Name string
+	// Definition class.
+	Class Class
+	// Definition type.
+	Type Type
+	// Size in bytes.
+	Size uint32
+	// Array dimensions (optional).
+	Dims []uint32
+	// Struct, union or enum tag (optional).
+	Tag string
+	// Stack offset relative to the frame pointer; for stack locals (AUTO) and
+	// stack parameters (ARG).
+	Offset int32
+	// Register holding the local; for register locals (REG) and register
+	// parameters (REGPARM). RegInvalid if the register number is out of range.
+	Reg Reg
+	// Scope declaring the local.
+	Scope *Scope
+}
+
+// String returns the string representation of the local.
+func (l *Local) String() string {
+	if l.InReg() {
+		if !l.Reg.Valid() {
+			return fmt.Sprintf("%s (%v %v)", l.Name, l.Class, l.Reg)
+		}
+		return fmt.Sprintf("%s (%v %s)", l.Name, l.Class, l.Register())
+	}
+	return fmt.Sprintf("%s (%v %d)", l.Name, l.Class, l.Offset)
+}
+
+// InReg reports whether the local is held in a register.
+func (l *Local) InReg() bool {
+	return l.Class == ClassREG || l.Class == ClassREGPARM
+}
+
+// Register returns the assembly name of the register holding the local (e.g.
+// "$s0"), or an empty string if the local is stored on the stack or the
+// register is invalid.
+func (l *Local) Register() string {
+	if !l.InReg() || !l.Reg.Valid() {
+		return ""
+	}
+	return "$" + l.Reg.String()
+}
+
+// Locals returns the local variables and parameters of the function, stored
+// either on the stack frame or in registers, in depth-first order of the scope
+// tree; parameters precede the local variables of block scopes.
+func (f *Function) Locals() []*Local {
+	var locals []*Local
+	var visit func(scope *Scope)
+	visit = func(scope *Scope) {
+		for _, s := range scope.Defs {
+			local := &Local{
+				Offset: int32(s.Hdr.Value),
+				Scope:  scope,
+			}
+			switch body := s.Body.(type) {
+			case *Def:
+				local.Name = body.Name
+				local.Class = body.Class
+				local.Type = body.Type
+				local.Size = body.Size
+			case *Def2:
+				local.Name = body.Name
+				local.Class = body.Class
+				local.Type = body.Type
+				local.Size = body.Size
+				local.Dims = body.Dims
+				local.Tag = body.Tag
+			}
+			switch local.Class {
+			case ClassAUTO, ClassARG:
+				locals = append(locals, local)
+			case ClassREG, ClassREGPARM:
+				// Value of register locals specifies the register number.
+				local.Reg = newReg(s.Hdr.Value)
+				local.Offset = 0
+				locals = append(locals, local)
+			}
+		}
+		for _, block := range scope.Scopes {
+			visit(block)
+		}
+	}
+	if f.Scope != nil {
+		visit(f.Scope)
+	}
+	return locals
+}
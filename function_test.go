@@ -0,0 +1,170 @@
+package sym_test
+
+import (
+	"testing"
+
+	"github.com/sanctuary/sym"
+)
+
+func TestFunctions(t *testing.T) {
+	syms := []*sym.Symbol{
+		{
+			Hdr: &sym.SymbolHeader{Value: 0x8001FEFC, Kind: sym.KindFuncStart},
+			Body: &sym.FuncStart{
+				FP:         29,
+				FSize:      24,
+				RetReg:     31,
+				Mask:       0x80030000,
+				MaskOffset: -8,
+				Line:       88,
+				Path:       `C:\DIABPSX\GLIBDEV\SOURCE\TASKER.C`,
+				Name:       "DoEpi",
+			},
+		},
+		newDef(0, sym.ClassARG, "x"),
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x8001FF00, Kind: sym.KindBlockStart},
+			Body: &sym.BlockStart{Line: 1},
+		},
+		newDef(16, sym.ClassAUTO, "y"),
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x8001FF10, Kind: sym.KindBlockStart},
+			Body: &sym.BlockStart{Line: 2},
+		},
+		newDef(20, sym.ClassAUTO, "z"),
+		newDef(16, sym.ClassREG, "i"),
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x8001FF20, Kind: sym.KindBlockEnd},
+			Body: &sym.BlockEnd{Line: 3},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x8001FF40, Kind: sym.KindBlockEnd},
+			Body: &sym.BlockEnd{Line: 4},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x8001FF4C, Kind: sym.KindFuncEnd},
+			Body: &sym.FuncEnd{Line: 91},
+		},
+	}
+	funcs := sym.Functions(syms)
+	if len(funcs) != 1 {
+		t.Fatalf("number of functions mismatch; expected 1, got %d", len(funcs))
+	}
+	f := funcs[0]
+	if f.Name != "DoEpi" || f.FP != sym.RegSP || f.RetReg != sym.RegRA || f.FrameSize != 24 {
+		t.Errorf("function frame mismatch; got %+v", f)
+	}
+	if f.EndAddr != 0x8001FF4C || f.LineStart != 88 || f.LineEnd != 91 {
+		t.Errorf("function extent mismatch; got %+v", f)
+	}
+	want := []sym.SavedReg{
+		{Reg: sym.RegRA, Offset: -8},
+		{Reg: sym.RegS1, Offset: -12},
+		{Reg: sym.RegS0, Offset: -16},
+	}
+	got := f.SavedRegs()
+	if len(got) != len(want) {
+		t.Fatalf("number of saved registers mismatch; expected %d, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("saved register %d mismatch; expected %v, got %v", i, want[i], got[i])
+		}
+	}
+	// Verify scope tree.
+	scope := f.Scope
+	if len(scope.Defs) != 1 || len(scope.Scopes) != 1 {
+		t.Fatalf("function scope mismatch; expected 1 parameter and 1 block, got %d and %d", len(scope.Defs), len(scope.Scopes))
+	}
+	block := scope.Scopes[0]
+	if len(block.Defs) != 1 || len(block.Scopes) != 1 || block.LineEnd != 4 {
+		t.Fatalf("outer block mismatch; got %+v", block)
+	}
+	inner := block.Scopes[0]
+	if len(inner.Defs) != 2 || inner.Parent != block || inner.Addr != 0x8001FF10 || inner.EndAddr != 0x8001FF20 {
+		t.Fatalf("inner block mismatch; got %+v", inner)
+	}
+	if name := inner.Defs[0].Body.(*sym.Def).Name; name != "z" {
+		t.Errorf("local variable of inner block mismatch; expected z, got %s", name)
+	}
+	// Verify locals.
+	locals := f.Locals()
+	wantLocals := []struct {
+		name   string
+		offset int32
+		scope  *sym.Scope
+	}{
+		{name: "x", offset: 0, scope: scope},
+		{name: "y", offset: 16, scope: block},
+		{name: "z", offset: 20, scope: inner},
+		{name: "i", offset: 0, scope: inner},
+	}
+	if len(locals) != len(wantLocals) {
+		t.Fatalf("number of locals mismatch; expected %d, got %d", len(wantLocals), len(locals))
+	}
+	for i, want := range wantLocals {
+		got := locals[i]
+		if got.Name != want.name || got.Offset != want.offset || got.Scope != want.scope {
+			t.Errorf("local %d mismatch; expected %s at offset %d, got %v", i, want.name, want.offset, got)
+		}
+	}
+	if reg := locals[3].Register(); reg != "$s0" {
+		t.Errorf("register of local %q mismatch; expected $s0, got %q", locals[3].Name, reg)
+	}
+}
+
+func TestFunctionsInvalidRegs(t *testing.T) {
+	// Register numbers out of range, aliasing $sp and $ra if truncated.
+	syms := []*sym.Symbol{
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010000, Kind: sym.KindFuncStart},
+			Body: &sym.FuncStart{FP: 0x11D, RetReg: 0x11F, Name: "f"},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010010, Kind: sym.KindFuncEnd},
+			Body: &sym.FuncEnd{},
+		},
+	}
+	funcs := sym.Functions(syms)
+	if len(funcs) != 1 {
+		t.Fatalf("number of functions mismatch; expected 1, got %d", len(funcs))
+	}
+	f := funcs[0]
+	if f.FP != sym.RegInvalid || f.RetReg != sym.RegInvalid {
+		t.Errorf("registers mismatch; expected invalid frame pointer and return address registers, got %v and %v", f.FP, f.RetReg)
+	}
+	if f.FP.Valid() || !sym.RegRA.Valid() {
+		t.Errorf("register validity mismatch")
+	}
+}
+
+func TestLocalsInvalidReg(t *testing.T) {
+	// Register local of register number out of range, aliasing $s0 if
+	// truncated.
+	syms := []*sym.Symbol{
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010000, Kind: sym.KindFuncStart},
+			Body: &sym.FuncStart{FP: 29, RetReg: 31, Name: "f"},
+		},
+		newDef(0x110, sym.ClassREG, "i"),
+		newDef(17, sym.ClassREGPARM, "n"),
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010010, Kind: sym.KindFuncEnd},
+			Body: &sym.FuncEnd{},
+		},
+	}
+	funcs := sym.Functions(syms)
+	if len(funcs) != 1 {
+		t.Fatalf("number of functions mismatch; expected 1, got %d", len(funcs))
+	}
+	locals := funcs[0].Locals()
+	if len(locals) != 2 {
+		t.Fatalf("number of locals mismatch; expected 2, got %d", len(locals))
+	}
+	if l := locals[0]; l.Reg != sym.RegInvalid || l.Register() != "" {
+		t.Errorf("register of local %q mismatch; expected invalid register, got %v (%q)", l.Name, l.Reg, l.Register())
+	}
+	if l := locals[1]; l.Reg != sym.RegS1 || l.Register() != "$s1" {
+		t.Errorf("register of local %q mismatch; expected $s1, got %v (%q)", l.Name, l.Reg, l.Register())
+	}
+}
@@ -0,0 +1,146 @@
+// Package ghidra implements parsers for the symbol tables and programs exported
+// by Ghidra, and translation of their symbols and data types into the C
+// declarations of PS1 symbol files.
+//
+// Symbol tables are exported in CSV format from the Symbol Table window (Export
+// > Export to CSV), and programs in the XML program format (File > Export
+// Program > XML).
+package ghidra
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//go:generate stringer -linecomment -type SymbolKind
+
+// SymbolKind specifies the kind of a Ghidra symbol.
+type SymbolKind uint8
+
+// Symbol kinds.
+const (
+	// Label (or global variable).
+	SymbolLabel SymbolKind = iota + 1 // label
+	// Function.
+	SymbolFunction // function
+)
+
+// A Symbol is a symbol of a Ghidra symbol table.
+type Symbol struct {
+	// Symbol name.
+	Name string
+	// Address.
+	Addr uint32
+	// ID of the overlay containing the symbol (0 for the default binary).
+	Overlay uint32
+	// Symbol kind.
+	Kind SymbolKind
+}
+
+// ParseSymbolsFile parses the given Ghidra symbol table CSV file.
+func ParseSymbolsFile(path string) ([]*Symbol, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	return ParseSymbols(f)
+}
+
+// ParseSymbols parses the given Ghidra symbol table CSV file, reading from r.
+//
+// The columns of the table are located by the names of the header row ("Name",
+// "Location" and "Type"). Symbols of type "Function" are parsed as functions,
+// and symbols of type "Label", "Data" or "Global Var" as labels. Other symbols
+// (e.g. parameters, local variables and namespaces) and symbols not located in
+// memory (e.g. external symbols) are ignored.
+func ParseSymbols(r io.Reader) ([]*Symbol, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read header of Ghidra symbol table")
+	}
+	cols := make(map[string]int)
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	nameCol, ok1 := cols["name"]
+	locCol, ok2 := cols["location"]
+	typeCol, ok3 := cols["type"]
+	if !ok1 || !ok2 || !ok3 {
+		return nil, errors.Errorf(`invalid header of Ghidra symbol table; expected "Name", "Location" and "Type" columns, got %q`, header)
+	}
+	var syms []*Symbol
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if nameCol >= len(record) || locCol >= len(record) || typeCol >= len(record) {
+			line, _ := cr.FieldPos(0)
+			return nil, errors.Errorf("invalid Ghidra symbol on line %d; expected %d columns, got %d", line, len(header), len(record))
+		}
+		var kind SymbolKind
+		switch strings.TrimSpace(record[typeCol]) {
+		case "Function":
+			kind = SymbolFunction
+		case "Label", "Data", "Global Var":
+			kind = SymbolLabel
+		default:
+			continue
+		}
+		overlay, addr, err := ParseAddr(record[locCol])
+		if err != nil {
+			// Skip symbols not located in memory.
+			continue
+		}
+		s := &Symbol{
+			Name:    record[nameCol],
+			Addr:    addr,
+			Overlay: overlay,
+			Kind:    kind,
+		}
+		syms = append(syms, s)
+	}
+	return syms, nil
+}
+
+// ParseAddr parses the given Ghidra address, optionally prefixed by the name of
+// its address space (e.g. "80010000", "ram:80010000" or
+// "overlay_4::800b0400"). Addresses in the address space of overlay blocks
+// named overlay_N (as created by sym_dump) are located in overlay N. Addresses
+// of other address spaces (e.g. "EXTERNAL" and "Stack") are not supported.
+func ParseAddr(s string) (overlay, addr uint32, err error) {
+	s = strings.TrimSpace(s)
+	if pos := strings.LastIndex(s, ":"); pos != -1 {
+		space := strings.TrimRight(s[:pos], ":")
+		s = s[pos+1:]
+		switch {
+		case space == "ram":
+			// Default address space.
+		case strings.HasPrefix(space, "overlay_"):
+			id, err := strconv.ParseUint(space[len("overlay_"):], 16, 32)
+			if err != nil {
+				return 0, 0, errors.Wrapf(err, "invalid overlay address space %q", space)
+			}
+			overlay = uint32(id)
+		default:
+			return 0, 0, errors.Errorf("support for address space %q not yet implemented", space)
+		}
+	}
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	x, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return 0, 0, errors.WithStack(err)
+	}
+	return overlay, uint32(x), nil
+}
@@ -0,0 +1,149 @@
+package ghidra_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sanctuary/sym/csym/c"
+	"github.com/sanctuary/sym/ghidra"
+)
+
+const testSymbols = `"Name","Location","Type","Namespace","Source","Reference Count","Offcut Ref Count"
+"main","80010000","Function","Global","User Defined","1","0"
+"g_state","ram:80020000","Label","Global","User Defined","2","0"
+"ovl_init","overlay_4::800b0400","Function","Global","Imported","0","0"
+"param_1","Stack[0x4]","Parameter","main","User Defined","0","0"
+"printf","EXTERNAL:00000001","Function","<EXTERNAL>","Imported","0","0"
+`
+
+func TestParseSymbols(t *testing.T) {
+	syms, err := ghidra.ParseSymbols(strings.NewReader(testSymbols))
+	if err != nil {
+		t.Fatalf("unable to parse symbol table; %+v", err)
+	}
+	golden := []ghidra.Symbol{
+		{Name: "main", Addr: 0x80010000, Kind: ghidra.SymbolFunction},
+		{Name: "g_state", Addr: 0x80020000, Kind: ghidra.SymbolLabel},
+		{Name: "ovl_init", Addr: 0x800B0400, Overlay: 4, Kind: ghidra.SymbolFunction},
+	}
+	if len(syms) != len(golden) {
+		t.Fatalf("number of symbols mismatch; expected %d, got %d", len(golden), len(syms))
+	}
+	for i, g := range golden {
+		if *syms[i] != g {
+			t.Errorf("symbol %d mismatch; expected %v, got %v", i, g, *syms[i])
+		}
+	}
+}
+
+const testProgram = `<?xml version="1.0" standalone="yes"?>
+<?program_dtd version="1"?>
+<PROGRAM NAME="SLUS_000.01">
+	<MEMORY_MAP>
+		<MEMORY_SECTION NAME="overlay_4" START_ADDR="overlay_4::800b0000" LENGTH="0x1000" PERMISSIONS="rwx"/>
+	</MEMORY_MAP>
+	<DATATYPES>
+		<ENUM NAME="color" NAMESPACE="/" SIZE="0x4">
+			<ENUM_ENTRY NAME="RED" VALUE="0x0"/>
+			<ENUM_ENTRY NAME="NONE" VALUE="-0x1"/>
+		</ENUM>
+		<STRUCTURE NAME="point" NAMESPACE="/" SIZE="0xC">
+			<MEMBER OFFSET="0x0" DATATYPE="s32" DATATYPE_NAMESPACE="/" NAME="x" SIZE="0x4"/>
+			<MEMBER OFFSET="0x4" DATATYPE="short[2]" DATATYPE_NAMESPACE="/" NAME="pad" SIZE="0x4"/>
+			<MEMBER OFFSET="0x8" DATATYPE="point *" DATATYPE_NAMESPACE="/" NAME="next" SIZE="0x4"/>
+		</STRUCTURE>
+		<TYPE_DEF NAME="s32" NAMESPACE="/" DATATYPE="int" DATATYPE_NAMESPACE="/"/>
+		<FUNCTION_DEF NAME="add" NAMESPACE="/">
+			<RETURN_TYPE DATATYPE="s32" DATATYPE_NAMESPACE="/" SIZE="0x4"/>
+			<PARAMETER ORDINAL="0x0" DATATYPE="point *" DATATYPE_NAMESPACE="/" NAME="p" SIZE="0x4"/>
+			<PARAMETER ORDINAL="0x1" DATATYPE="_func_0 *" DATATYPE_NAMESPACE="/" NAME="cb" SIZE="0x4"/>
+		</FUNCTION_DEF>
+		<FUNCTION_DEF NAME="_func_0" NAMESPACE="/">
+			<RETURN_TYPE DATATYPE="void" DATATYPE_NAMESPACE="/" SIZE="0x1"/>
+		</FUNCTION_DEF>
+	</DATATYPES>
+	<SYMBOL_TABLE>
+		<SYMBOL ADDRESS="80010100" NAME="add" NAMESPACE="" TYPE="global" SOURCE_TYPE="USER_DEFINED" PRIMARY="y"/>
+		<SYMBOL ADDRESS="80020100" NAME="origin" NAMESPACE="" TYPE="global" SOURCE_TYPE="USER_DEFINED" PRIMARY="y"/>
+		<SYMBOL ADDRESS="80020110" NAME="msg" NAMESPACE="" TYPE="global" SOURCE_TYPE="USER_DEFINED" PRIMARY="y"/>
+		<SYMBOL ADDRESS="overlay_4::800b0010" NAME="ovl_label" NAMESPACE="" TYPE="global" SOURCE_TYPE="USER_DEFINED" PRIMARY="y"/>
+	</SYMBOL_TABLE>
+	<FUNCTIONS>
+		<FUNCTION ENTRY_POINT="80010100" NAME="add" LIBRARY_FUNCTION="n">
+			<RETURN_TYPE DATATYPE="undefined" DATATYPE_NAMESPACE="/" SIZE="0x1"/>
+			<ADDRESS_RANGE START="80010100" END="8001013f"/>
+		</FUNCTION>
+	</FUNCTIONS>
+	<DATA>
+		<DEFINED_DATA ADDRESS="80020100" DATATYPE="point" DATATYPE_NAMESPACE="/" SIZE="0xc"/>
+		<DEFINED_DATA ADDRESS="80020110" DATATYPE="string" DATATYPE_NAMESPACE="/" SIZE="0x6"/>
+	</DATA>
+</PROGRAM>
+`
+
+func TestNewParser(t *testing.T) {
+	prog, err := ghidra.ParseProgram(strings.NewReader(testProgram))
+	if err != nil {
+		t.Fatalf("unable to parse program; %+v", err)
+	}
+	syms, err := ghidra.ParseSymbols(strings.NewReader(testSymbols))
+	if err != nil {
+		t.Fatalf("unable to parse symbol table; %+v", err)
+	}
+	p, err := ghidra.NewParser(prog, syms)
+	if err != nil {
+		t.Fatalf("unable to translate program; %+v", err)
+	}
+	point, ok := p.Structs["point"]
+	if !ok {
+		t.Fatalf("unable to locate struct point")
+	}
+	wantFields := []string{"s32 x", "short pad[2]", "struct point *next"}
+	if len(point.Fields) != len(wantFields) {
+		t.Fatalf("number of fields mismatch; expected %d, got %d", len(wantFields), len(point.Fields))
+	}
+	for i, want := range wantFields {
+		if got := point.Fields[i].String(); got != want {
+			t.Errorf("field %d mismatch; expected %q, got %q", i, want, got)
+		}
+	}
+	if color := p.Enums["color"]; !color.Signed || color.Members[1].Value != 0xFFFFFFFF {
+		t.Errorf("enum mismatch; expected signed member NONE = -1, got %v", color.Def())
+	}
+	if len(p.Funcs) != 1 {
+		t.Fatalf("number of functions mismatch; expected 1, got %d", len(p.Funcs))
+	}
+	add := p.Funcs[0]
+	if add.Addr != 0x80010100 || add.Size != 0x40 {
+		t.Errorf("function mismatch; expected add at 0x80010100 of size 0x40, got %s at 0x%08X of size 0x%X", add.Name, add.Addr, add.Size)
+	}
+	if got, want := add.Type.(*c.FuncType).String(), "s32 (struct point *p, void (*cb)())"; got != want {
+		t.Errorf("function type mismatch; expected %q, got %q", want, got)
+	}
+	if param := add.Type.(*c.FuncType).Params[1]; param.Class != c.Register || param.Addr != 5 {
+		t.Errorf("parameter location mismatch; expected register 5, got %v %d", param.Class, param.Addr)
+	}
+	wantVars := []string{"struct point origin", "unsigned char msg[6]"}
+	if len(p.Vars) != len(wantVars) {
+		t.Fatalf("number of global variables mismatch; expected %d, got %d", len(wantVars), len(p.Vars))
+	}
+	for i, want := range wantVars {
+		if got := p.Vars[i].Var.String(); got != want {
+			t.Errorf("global variable %d mismatch; expected %q, got %q", i, want, got)
+		}
+	}
+	// Symbols of the symbol table not declared by the program.
+	if len(p.Symbols) != 2 || p.Symbols[0].Name != "main" || p.Symbols[1].Name != "g_state" {
+		t.Errorf("symbols mismatch; expected [main g_state], got %v", p.Symbols)
+	}
+	if len(p.Overlays) != 1 {
+		t.Fatalf("number of overlays mismatch; expected 1, got %d", len(p.Overlays))
+	}
+	overlay := p.Overlays[0]
+	if overlay.ID != 4 || overlay.Addr != 0x800B0000 || overlay.Length != 0x1000 || len(overlay.Symbols) != 2 {
+		t.Errorf("overlay mismatch; expected overlay 4 at 0x800B0000 with 2 symbols, got overlay %x at 0x%08X with %v", overlay.ID, overlay.Addr, overlay.Symbols)
+	}
+	if _, err := p.Encode(); err != nil {
+		t.Errorf("unable to encode translated program; %+v", err)
+	}
+}
@@ -0,0 +1,179 @@
+package ghidra
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// A Program is a program in the XML program format of Ghidra. Only the memory
+// blocks, data types, symbols, functions and defined data of the program are
+// parsed.
+type Program struct {
+	XMLName xml.Name `xml:"PROGRAM"`
+	// Program name.
+	Name string `xml:"NAME,attr"`
+	// Memory blocks.
+	Memory []*MemorySection `xml:"MEMORY_MAP>MEMORY_SECTION"`
+	// Data types.
+	DataTypes DataTypes `xml:"DATATYPES"`
+	// Symbols.
+	Symbols []*ProgramSymbol `xml:"SYMBOL_TABLE>SYMBOL"`
+	// Functions.
+	Funcs []*Function `xml:"FUNCTIONS>FUNCTION"`
+	// Defined data.
+	Data []*DefinedData `xml:"DATA>DEFINED_DATA"`
+}
+
+// A MemorySection is a memory block of a Ghidra program.
+type MemorySection struct {
+	// Block name.
+	Name string `xml:"NAME,attr"`
+	// Start address.
+	Start string `xml:"START_ADDR,attr"`
+	// Length in bytes (hexadecimal).
+	Length string `xml:"LENGTH,attr"`
+}
+
+// DataTypes is the data type section of a Ghidra program.
+type DataTypes struct {
+	// Enums.
+	Enums []*Enum `xml:"ENUM"`
+	// Structures.
+	Structs []*Composite `xml:"STRUCTURE"`
+	// Unions.
+	Unions []*Composite `xml:"UNION"`
+	// Type definitions.
+	Typedefs []*Typedef `xml:"TYPE_DEF"`
+	// Function signatures.
+	FuncDefs []*FuncDef `xml:"FUNCTION_DEF"`
+}
+
+// An Enum is a Ghidra enum data type.
+type Enum struct {
+	// Enum name.
+	Name string `xml:"NAME,attr"`
+	// Size in bytes (hexadecimal).
+	Size string `xml:"SIZE,attr"`
+	// Enum members.
+	Entries []*EnumEntry `xml:"ENUM_ENTRY"`
+}
+
+// An EnumEntry is a member of a Ghidra enum data type.
+type EnumEntry struct {
+	// Member name.
+	Name string `xml:"NAME,attr"`
+	// Member value (decimal or hexadecimal).
+	Value string `xml:"VALUE,attr"`
+}
+
+// A Composite is a Ghidra structure or union data type.
+type Composite struct {
+	// Structure or union name.
+	Name string `xml:"NAME,attr"`
+	// Size in bytes (hexadecimal).
+	Size string `xml:"SIZE,attr"`
+	// Members.
+	Members []*Member `xml:"MEMBER"`
+}
+
+// A Member is a member of a Ghidra structure or union data type.
+type Member struct {
+	// Offset in bytes (hexadecimal).
+	Offset string `xml:"OFFSET,attr"`
+	// Data type name.
+	DataType string `xml:"DATATYPE,attr"`
+	// Member name (optional).
+	Name string `xml:"NAME,attr"`
+	// Size in bytes (hexadecimal).
+	Size string `xml:"SIZE,attr"`
+}
+
+// A Typedef is a Ghidra type definition.
+type Typedef struct {
+	// Type definition name.
+	Name string `xml:"NAME,attr"`
+	// Data type name.
+	DataType string `xml:"DATATYPE,attr"`
+}
+
+// A FuncDef is a Ghidra function signature data type.
+type FuncDef struct {
+	// Function signature name.
+	Name string `xml:"NAME,attr"`
+	// Return type.
+	RetType Param `xml:"RETURN_TYPE"`
+	// Parameters.
+	Params []*Param `xml:"PARAMETER"`
+}
+
+// A Param is the return type or a parameter of a Ghidra function signature.
+type Param struct {
+	// Data type name.
+	DataType string `xml:"DATATYPE,attr"`
+	// Parameter name (optional).
+	Name string `xml:"NAME,attr"`
+	// Size in bytes (hexadecimal).
+	Size string `xml:"SIZE,attr"`
+}
+
+// A ProgramSymbol is a symbol of a Ghidra program.
+type ProgramSymbol struct {
+	// Address.
+	Addr string `xml:"ADDRESS,attr"`
+	// Symbol name.
+	Name string `xml:"NAME,attr"`
+	// Symbol type (e.g. "global").
+	Type string `xml:"TYPE,attr"`
+}
+
+// A Function is a function of a Ghidra program.
+type Function struct {
+	// Entry point address.
+	Entry string `xml:"ENTRY_POINT,attr"`
+	// Function name.
+	Name string `xml:"NAME,attr"`
+	// Return type (optional).
+	RetType *Param `xml:"RETURN_TYPE"`
+	// Address ranges of the function body.
+	Ranges []*AddrRange `xml:"ADDRESS_RANGE"`
+}
+
+// An AddrRange is an address range of a Ghidra function body.
+type AddrRange struct {
+	// Start address.
+	Start string `xml:"START,attr"`
+	// End address (inclusive).
+	End string `xml:"END,attr"`
+}
+
+// DefinedData is a data item of a Ghidra program.
+type DefinedData struct {
+	// Address.
+	Addr string `xml:"ADDRESS,attr"`
+	// Data type name.
+	DataType string `xml:"DATATYPE,attr"`
+	// Size in bytes (hexadecimal).
+	Size string `xml:"SIZE,attr"`
+}
+
+// ParseProgramFile parses the given Ghidra program XML file.
+func ParseProgramFile(path string) (*Program, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	return ParseProgram(f)
+}
+
+// ParseProgram parses the given Ghidra program XML file, reading from r.
+func ParseProgram(r io.Reader) (*Program, error) {
+	prog := &Program{}
+	if err := xml.NewDecoder(r).Decode(prog); err != nil {
+		return nil, errors.Wrap(err, "unable to decode Ghidra program XML file")
+	}
+	return prog, nil
+}
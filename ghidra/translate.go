@@ -0,0 +1,543 @@
+package ghidra
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// NewParser translates the data types, functions and defined data of the Ghidra
+// program (optional) and the symbols of the Ghidra symbol table (optional) into
+// the equivalent C types and declarations of a parser; e.g. to be encoded as
+// SYM symbols using csym.Parser.Encode.
+//
+// Functions are declared with the signature of the function signature data type
+// of the same name, if present. The parameters of function signatures are
+// located in the argument registers ($a0-$a3) and on the stack, as specified by
+// the MIPS calling convention. Defined data of named addresses are declared as
+// global variables; and as byte arrays, if of unknown data type. Named addresses
+// neither declared as functions nor as global variables are recorded as
+// symbols.
+//
+// Overlays are located in the address space of overlay blocks named overlay_N,
+// and span their memory block; or the range of their declarations if no memory
+// block is present.
+func NewParser(prog *Program, syms []*Symbol) (*csym.Parser, error) {
+	if prog == nil {
+		prog = &Program{}
+	}
+	tr := newTranslator(prog)
+	if err := tr.translateTypes(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := tr.translateDecls(syms); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	tr.initOverlays()
+	return tr.p, nil
+}
+
+// translator tracks the state of the translation of a Ghidra program.
+type translator struct {
+	// Parser holding the translated types and declarations.
+	p *csym.Parser
+	// Ghidra program.
+	prog *Program
+	// typedefs maps from name to Ghidra type definition.
+	typedefs map[string]*Typedef
+	// funcDefs maps from name to Ghidra function signature.
+	funcDefs map[string]*FuncDef
+	// overlays maps from overlay ID to overlay.
+	overlays map[uint32]*csym.Overlay
+	// declared tracks the addresses of declarations and symbols.
+	declared map[addrKey]bool
+	// resolving tracks the type definitions being resolved, to detect cycles.
+	resolving map[string]bool
+}
+
+// addrKey is an address of an overlay.
+type addrKey struct {
+	// Overlay ID.
+	overlay uint32
+	// Address.
+	addr uint32
+}
+
+// newTranslator returns a new translator of the given Ghidra program.
+func newTranslator(prog *Program) *translator {
+	p := csym.NewParser()
+	return &translator{
+		p:         p,
+		prog:      prog,
+		typedefs:  make(map[string]*Typedef),
+		funcDefs:  make(map[string]*FuncDef),
+		overlays:  map[uint32]*csym.Overlay{0: p.Overlay},
+		declared:  make(map[addrKey]bool),
+		resolving: make(map[string]bool),
+	}
+}
+
+// --- [ Types ] ---------------------------------------------------------------
+
+// translateTypes translates the data types of the Ghidra program into C types.
+func (tr *translator) translateTypes() error {
+	dts := tr.prog.DataTypes
+	// Add struct, union and enum types, so they may be referenced before
+	// defined.
+	for _, enum := range dts.Enums {
+		t := &c.EnumType{Tag: enum.Name}
+		for _, entry := range enum.Entries {
+			v, err := strconv.ParseInt(entry.Value, 0, 64)
+			if err != nil {
+				return errors.Wrapf(err, "invalid value of enum member %q", entry.Name)
+			}
+			if v < 0 {
+				t.Signed = true
+			}
+			t.Members = append(t.Members, &c.EnumMember{Name: entry.Name, Value: uint32(v)})
+		}
+		tr.p.Enums[t.Tag] = t
+		tr.p.EnumTags = append(tr.p.EnumTags, t.Tag)
+	}
+	for _, composite := range dts.Structs {
+		size, err := parseSize(composite.Size)
+		if err != nil {
+			return errors.Wrapf(err, "invalid size of structure %q", composite.Name)
+		}
+		t := &c.StructType{Tag: composite.Name, Size: size}
+		tr.p.Structs[t.Tag] = t
+		tr.p.StructTags = append(tr.p.StructTags, t.Tag)
+	}
+	for _, composite := range dts.Unions {
+		size, err := parseSize(composite.Size)
+		if err != nil {
+			return errors.Wrapf(err, "invalid size of union %q", composite.Name)
+		}
+		t := &c.UnionType{Tag: composite.Name, Size: size}
+		tr.p.Unions[t.Tag] = t
+		tr.p.UnionTags = append(tr.p.UnionTags, t.Tag)
+	}
+	for _, def := range dts.Typedefs {
+		tr.typedefs[def.Name] = def
+	}
+	for _, def := range dts.FuncDefs {
+		tr.funcDefs[def.Name] = def
+	}
+	// Translate members.
+	for _, composite := range dts.Structs {
+		fields, err := tr.fields(composite)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		tr.p.Structs[composite.Name].Fields = fields
+	}
+	for _, composite := range dts.Unions {
+		fields, err := tr.fields(composite)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		tr.p.Unions[composite.Name].Fields = fields
+	}
+	// Translate type definitions in order of occurrence.
+	for _, def := range dts.Typedefs {
+		if _, err := tr.typedef(def.Name); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// fields translates the members of the Ghidra structure or union into C fields.
+func (tr *translator) fields(composite *Composite) ([]c.Field, error) {
+	var fields []c.Field
+	for i, member := range composite.Members {
+		t, err := tr.resolve(member.DataType)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to resolve type of member %d of %q", i, composite.Name)
+		}
+		offset, err := parseSize(member.Offset)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid offset of member %d of %q", i, composite.Name)
+		}
+		size, err := parseSize(member.Size)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid size of member %d of %q", i, composite.Name)
+		}
+		name := member.Name
+		if len(name) == 0 {
+			name = fmt.Sprintf("field_0x%x", offset)
+		}
+		field := c.Field{
+			Offset: offset,
+			Size:   size,
+			Var: c.Var{
+				Type: t,
+				Name: name,
+			},
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// typedef returns the C type definition of the given Ghidra type definition,
+// translating it if not yet translated.
+func (tr *translator) typedef(name string) (*c.VarDecl, error) {
+	if t, ok := tr.p.Types[name]; ok {
+		if def, ok := t.(*c.VarDecl); ok {
+			return def, nil
+		}
+	}
+	if tr.resolving[name] {
+		return nil, errors.Errorf("invalid cyclic type definition %q", name)
+	}
+	tr.resolving[name] = true
+	defer delete(tr.resolving, name)
+	t, err := tr.resolve(tr.typedefs[name].DataType)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to resolve type of type definition %q", name)
+	}
+	def := &c.VarDecl{
+		Class: c.Typedef,
+		Var: c.Var{
+			Type: t,
+			Name: name,
+		},
+	}
+	tr.p.Typedefs = append(tr.p.Typedefs, def)
+	tr.p.Types[name] = def
+	return def, nil
+}
+
+// funcType returns the C function type of the given Ghidra function signature.
+func (tr *translator) funcType(def *FuncDef) (*c.FuncType, error) {
+	retType, err := tr.resolve(def.RetType.DataType)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to resolve return type of %q", def.Name)
+	}
+	t := &c.FuncType{RetType: retType}
+	for i, param := range def.Params {
+		paramType, err := tr.resolve(param.DataType)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to resolve type of parameter %d of %q", i, def.Name)
+		}
+		name := param.Name
+		if len(name) == 0 {
+			name = fmt.Sprintf("param_%d", i+1)
+		}
+		v := &c.VarDecl{
+			Var: c.Var{
+				Type: paramType,
+				Name: name,
+			},
+		}
+		// The first four parameters are passed in $a0-$a3 (registers 4-7), and
+		// the remaining parameters on the stack.
+		if i < 4 {
+			v.Class = c.Register
+			v.Addr = uint32(4 + i)
+		} else {
+			v.Addr = uint32(4 * i)
+		}
+		t.Params = append(t.Params, v)
+	}
+	return t, nil
+}
+
+// resolve returns the C type of the given Ghidra data type name. Names of
+// pointers and arrays are derived from their element types (e.g. "int *" and
+// "char[16]").
+func (tr *translator) resolve(name string) (c.Type, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case strings.HasSuffix(name, "*"):
+		elem, err := tr.resolve(strings.TrimSuffix(name, "*"))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return &c.PointerType{Elem: elem}, nil
+	case strings.HasSuffix(name, "]"):
+		// Dimensions of arrays of arrays are named outermost first; e.g.
+		// "int[2][3]".
+		pos := strings.LastIndex(name, "[")
+		if pos == -1 {
+			return nil, errors.Errorf("invalid array type name %q", name)
+		}
+		n, err := strconv.Atoi(name[pos+1 : len(name)-1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid array length of %q", name)
+		}
+		elem, err := tr.resolve(name[:pos])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return arrayOf(elem, n), nil
+	}
+	if t, ok := baseTypes[name]; ok {
+		return t, nil
+	}
+	if t, ok := tr.p.Structs[name]; ok {
+		return t, nil
+	}
+	if t, ok := tr.p.Unions[name]; ok {
+		return t, nil
+	}
+	if t, ok := tr.p.Enums[name]; ok {
+		return t, nil
+	}
+	if _, ok := tr.typedefs[name]; ok {
+		return tr.typedef(name)
+	}
+	if def, ok := tr.funcDefs[name]; ok {
+		return tr.funcType(def)
+	}
+	return nil, errors.Errorf("unable to locate data type %q", name)
+}
+
+// baseTypes maps from name of built-in Ghidra data type to equivalent C type.
+// Undefined data types are translated to unsigned integers of the same size.
+var baseTypes = map[string]c.Type{
+	"void":       c.Void,
+	"char":       c.Char,
+	"schar":      c.SChar,
+	"uchar":      c.UChar,
+	"short":      c.Short,
+	"ushort":     c.UShort,
+	"int":        c.Int,
+	"uint":       c.UInt,
+	"long":       c.Long,
+	"ulong":      c.ULong,
+	"longlong":   c.LongLong,
+	"ulonglong":  c.ULongLong,
+	"float":      c.Float,
+	"double":     c.Double,
+	"longdouble": c.LongDouble,
+	"bool":       c.Bool,
+	"byte":       c.UChar,
+	"sbyte":      c.Char,
+	"word":       c.UShort,
+	"sword":      c.Short,
+	"dword":      c.UInt,
+	"sdword":     c.Int,
+	"qword":      c.ULongLong,
+	"sqword":     c.LongLong,
+	"undefined":  c.UChar,
+	"undefined1": c.UChar,
+	"undefined2": c.UShort,
+	"undefined4": c.UInt,
+	"undefined8": c.ULongLong,
+	"pointer":    &c.PointerType{Elem: c.Void},
+}
+
+// --- [ Declarations ] --------------------------------------------------------
+
+// translateDecls translates the functions, defined data and symbols of the
+// Ghidra program, and the symbols of the Ghidra symbol table, into C
+// declarations and symbols.
+func (tr *translator) translateDecls(syms []*Symbol) error {
+	for _, fn := range tr.prog.Funcs {
+		if err := tr.translateFunc(fn); err != nil {
+			return errors.Wrapf(err, "unable to translate function %q", fn.Name)
+		}
+	}
+	// Named addresses of the program.
+	names := make(map[addrKey]string)
+	for _, s := range tr.prog.Symbols {
+		overlay, addr, err := ParseAddr(s.Addr)
+		if err != nil {
+			// Skip symbols not located in memory.
+			continue
+		}
+		key := addrKey{overlay: overlay, addr: addr}
+		if _, ok := names[key]; !ok {
+			names[key] = s.Name
+		}
+	}
+	for _, data := range tr.prog.Data {
+		overlay, addr, err := ParseAddr(data.Addr)
+		if err != nil {
+			return errors.Wrapf(err, "invalid address of defined data %q", data.Addr)
+		}
+		key := addrKey{overlay: overlay, addr: addr}
+		name, ok := names[key]
+		if !ok || tr.declared[key] {
+			continue
+		}
+		size, err := parseSize(data.Size)
+		if err != nil {
+			return errors.Wrapf(err, "invalid size of %q", name)
+		}
+		t, err := tr.resolve(data.DataType)
+		if err != nil {
+			// Unknown data type (e.g. string); declare as byte array.
+			t = arrayOf(c.UChar, int(size))
+		}
+		v := &c.VarDecl{
+			Addr:    addr,
+			Size:    size,
+			Class:   c.Extern,
+			Overlay: overlay,
+			Var: c.Var{
+				Type: t,
+				Name: name,
+			},
+		}
+		o := tr.overlay(overlay)
+		o.Vars = append(o.Vars, v)
+		tr.declared[key] = true
+	}
+	for _, s := range tr.prog.Symbols {
+		overlay, addr, err := ParseAddr(s.Addr)
+		if err != nil {
+			continue
+		}
+		tr.addSymbol(overlay, addr, s.Name)
+	}
+	for _, s := range syms {
+		tr.addSymbol(s.Overlay, s.Addr, s.Name)
+	}
+	return nil
+}
+
+// translateFunc translates the Ghidra function into a C function declaration.
+func (tr *translator) translateFunc(fn *Function) error {
+	overlay, addr, err := ParseAddr(fn.Entry)
+	if err != nil {
+		return errors.Wrapf(err, "invalid entry point %q", fn.Entry)
+	}
+	// Size of function body.
+	var size uint32
+	for _, r := range fn.Ranges {
+		_, end, err := ParseAddr(r.End)
+		if err != nil {
+			return errors.Wrapf(err, "invalid end address %q", r.End)
+		}
+		if end >= addr && end+1-addr > size {
+			size = end + 1 - addr
+		}
+	}
+	var t *c.FuncType
+	if def, ok := tr.funcDefs[fn.Name]; ok {
+		if t, err = tr.funcType(def); err != nil {
+			return errors.WithStack(err)
+		}
+	} else {
+		t = &c.FuncType{RetType: c.Int}
+		if fn.RetType != nil {
+			if t.RetType, err = tr.resolve(fn.RetType.DataType); err != nil {
+				return errors.Wrap(err, "unable to resolve return type")
+			}
+		}
+	}
+	f := &c.FuncDecl{
+		Addr:    addr,
+		Size:    size,
+		Class:   c.Extern,
+		Overlay: overlay,
+		Var: c.Var{
+			Type: t,
+			Name: fn.Name,
+		},
+	}
+	o := tr.overlay(overlay)
+	o.Funcs = append(o.Funcs, f)
+	tr.declared[addrKey{overlay: overlay, addr: addr}] = true
+	return nil
+}
+
+// addSymbol records a symbol of the given name at the address of the overlay,
+// unless a declaration or symbol is already present at the address.
+func (tr *translator) addSymbol(overlay, addr uint32, name string) {
+	key := addrKey{overlay: overlay, addr: addr}
+	if tr.declared[key] {
+		return
+	}
+	o := tr.overlay(overlay)
+	o.Symbols = append(o.Symbols, &csym.Symbol{Addr: addr, Name: name})
+	tr.declared[key] = true
+}
+
+// overlay returns the overlay of the given ID, creating it if not yet present.
+func (tr *translator) overlay(id uint32) *csym.Overlay {
+	if o, ok := tr.overlays[id]; ok {
+		return o
+	}
+	o := &csym.Overlay{ID: id}
+	tr.overlays[id] = o
+	tr.p.Overlays = append(tr.p.Overlays, o)
+	return o
+}
+
+// initOverlays sorts the overlays by ID, and sets the load address and length
+// of each overlay based on its memory block; or based on the range of its
+// declarations and symbols if no memory block is present.
+func (tr *translator) initOverlays() {
+	sort.Slice(tr.p.Overlays, func(i, j int) bool {
+		return tr.p.Overlays[i].ID < tr.p.Overlays[j].ID
+	})
+	blocks := make(map[string]*MemorySection)
+	for _, block := range tr.prog.Memory {
+		blocks[block.Name] = block
+	}
+	for _, o := range tr.p.Overlays {
+		if block, ok := blocks[fmt.Sprintf("overlay_%x", o.ID)]; ok {
+			_, start, err1 := ParseAddr(block.Start)
+			length, err2 := parseSize(block.Length)
+			if err1 == nil && err2 == nil {
+				o.Addr, o.Length = start, length
+				continue
+			}
+		}
+		var min, max uint32
+		first := true
+		update := func(start, end uint32) {
+			if first || start < min {
+				min = start
+			}
+			if first || end > max {
+				max = end
+			}
+			first = false
+		}
+		for _, f := range o.Funcs {
+			update(f.Addr, f.Addr+f.Size)
+		}
+		for _, v := range o.Vars {
+			update(v.Addr, v.Addr+v.Size)
+		}
+		for _, s := range o.Symbols {
+			update(s.Addr, s.Addr)
+		}
+		o.Addr, o.Length = min, max-min
+	}
+}
+
+// ### [ Helper functions ] ####################################################
+
+// parseSize parses the given hexadecimal size or offset (e.g. "0x10"); or
+// returns 0 if empty.
+func parseSize(s string) (uint32, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+	x, err := strconv.ParseUint(s, 0, 32)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return uint32(x), nil
+}
+
+// arrayOf returns an array of n elements of the given type, where elem may
+// itself be an array type named outermost first.
+func arrayOf(elem c.Type, n int) c.Type {
+	if a, ok := elem.(*c.ArrayType); ok {
+		// "int[2][3]" is an array of 2 arrays of 3 ints.
+		return &c.ArrayType{Elem: arrayOf(a.Elem, n), Len: a.Len}
+	}
+	return &c.ArrayType{Elem: elem, Len: n}
+}
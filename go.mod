@@ -1,7 +1,22 @@
 module github.com/sanctuary/sym
 
+go 1.25
+
 require (
 	github.com/lunixbochs/struc v0.0.0-20180408203800-02e4c2afbb2a
 	github.com/pkg/errors v0.8.1
 	github.com/rickypai/natsort v0.0.0-20180124032556-f194e6bd5b0c
+	golang.org/x/text v0.14.0
+	google.golang.org/protobuf v1.36.9
+	gopkg.in/yaml.v3 v3.0.1
+	modernc.org/cc/v4 v4.28.4
+)
+
+require (
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	modernc.org/mathutil v1.7.1 // indirect
+	modernc.org/opt v0.2.0 // indirect
+	modernc.org/sortutil v1.2.1 // indirect
+	modernc.org/strutil v1.2.1 // indirect
+	modernc.org/token v1.1.0 // indirect
 )
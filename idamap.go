@@ -0,0 +1,241 @@
+package sym
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ParseIDAMapFile parses the given IDA MAP file.
+func ParseIDAMapFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	return ParseIDAMap(f)
+}
+
+// ParseIDAMap parses the given IDA MAP file (as produced by File -> Produce file
+// -> Create MAP file), reading from r, into a symbol file of equivalent Name2
+// symbols.
+//
+// The public symbols are located by the header line of the symbol table
+// ("Publics by Value"), and each row of the table specifies the segment and
+// offset of the symbol in hexadecimal ("0001:80010000"), followed by the symbol
+// name. Segments of PS1 executables are flat (i.e. have a base address of 0),
+// thus the offset of a symbol is its address. IDA MAP files list the public
+// symbols twice, sorted by name ("Publics by Name") and by value; only the
+// symbols sorted by value are used, or if not present, the symbols sorted by
+// name.
+//
+// Segments of the segment table named after overlays, as created by the
+// IDAPython script of sym_dump (e.g. "overlay_4"), are mapped to overlays
+// loaded at the start address of the segment; symbols of other segments belong
+// to the default binary. Other lines are ignored.
+func ParseIDAMap(r io.Reader) (*File, error) {
+	// Section of the IDA MAP file.
+	const (
+		sectionNone = iota
+		sectionSegments
+		sectionPublicsByName
+		sectionPublicsByValue
+	)
+	section := sectionNone
+	// segs maps from segment number to segment of the segment table.
+	segs := make(map[uint64]*idaSegment)
+	// publics maps from section to the public symbols of the section.
+	publics := make(map[int][]*idaPublic)
+	s := bufio.NewScanner(r)
+	for lineNr := 1; s.Scan(); lineNr++ {
+		line := strings.TrimSpace(s.Text())
+		switch {
+		case len(line) == 0:
+			continue
+		case strings.HasPrefix(line, "Start") && strings.Contains(line, "Length"):
+			section = sectionSegments
+			continue
+		case strings.Contains(line, "Publics by Name"):
+			section = sectionPublicsByName
+			continue
+		case strings.Contains(line, "Publics by Value"):
+			section = sectionPublicsByValue
+			continue
+		case strings.HasPrefix(line, "Program entry point"):
+			section = sectionNone
+			continue
+		}
+		switch section {
+		case sectionSegments:
+			// 0001:80010000 00001000H .text                   CODE
+			seg, ok := parseIDASegment(line)
+			if ok {
+				segs[seg.num] = seg
+			}
+		case sectionPublicsByName, sectionPublicsByValue:
+			// 0001:80010000       main
+			pub, err := parseIDAPublic(line, lineNr)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			publics[section] = append(publics[section], pub)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	pubs, ok := publics[sectionPublicsByValue]
+	if !ok {
+		pubs = publics[sectionPublicsByName]
+	}
+	// Map segments to overlays.
+	type key struct {
+		overlay uint32
+		addr    uint32
+		name    string
+	}
+	seen := make(map[key]bool)
+	var ids []uint32
+	overlaySyms := make(map[uint32][]*Symbol)
+	// Overlay symbols and symbols of the default binary.
+	var overlays, syms []*Symbol
+	for _, pub := range pubs {
+		var overlay uint32
+		if seg, ok := segs[pub.seg]; ok && seg.overlay != 0 {
+			overlay = seg.overlay
+			if _, ok := overlaySyms[overlay]; !ok {
+				ids = append(ids, overlay)
+				s := &Symbol{
+					Hdr:  &SymbolHeader{Value: seg.start, Kind: KindOverlay},
+					Body: &Overlay{Length: seg.length, ID: overlay},
+				}
+				overlays = append(overlays, s)
+				overlaySyms[overlay] = nil
+			}
+		}
+		k := key{overlay: overlay, addr: pub.addr, name: pub.name}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		body := &Name2{Name: pub.name}
+		if err := setLen(&body.NameLen, body.Name); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		sym := &Symbol{
+			Hdr:  &SymbolHeader{Value: pub.addr, Kind: KindName2},
+			Body: body,
+		}
+		if overlay != 0 {
+			overlaySyms[overlay] = append(overlaySyms[overlay], sym)
+			continue
+		}
+		syms = append(syms, sym)
+	}
+	// Output overlays, followed by the symbols of the default binary and of
+	// each overlay.
+	f := NewFile(append(overlays, syms...))
+	for _, id := range ids {
+		s := &Symbol{
+			Hdr:  &SymbolHeader{Value: id, Kind: KindSetOverlay},
+			Body: &SetOverlay{},
+		}
+		f.Syms = append(f.Syms, s)
+		f.Syms = append(f.Syms, overlaySyms[id]...)
+	}
+	return f, nil
+}
+
+// An idaSegment is a segment of the segment table of an IDA MAP file.
+type idaSegment struct {
+	// Segment number.
+	num uint64
+	// Start address.
+	start uint32
+	// Length in bytes.
+	length uint32
+	// ID of the overlay of the segment; or 0 if not an overlay segment.
+	overlay uint32
+}
+
+// parseIDASegment parses the given row of the segment table of an IDA MAP file.
+// The boolean return value indicates success.
+func parseIDASegment(line string) (*idaSegment, bool) {
+	// 0001:80010000 00001000H .text                   CODE
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return nil, false
+	}
+	parts := strings.SplitN(fields[0], ":", 2)
+	if len(parts) != 2 || !strings.HasSuffix(fields[1], "H") {
+		return nil, false
+	}
+	num, err := strconv.ParseUint(parts[0], 16, 16)
+	if err != nil {
+		return nil, false
+	}
+	start, err := strconv.ParseUint(parts[1], 16, 32)
+	if err != nil {
+		return nil, false
+	}
+	length, err := strconv.ParseUint(strings.TrimSuffix(fields[1], "H"), 16, 32)
+	if err != nil {
+		return nil, false
+	}
+	seg := &idaSegment{
+		num:    num,
+		start:  uint32(start),
+		length: uint32(length),
+	}
+	// Overlay segments are named after the overlay ID in hexadecimal (e.g.
+	// "overlay_4").
+	if id := strings.TrimPrefix(fields[2], "overlay_"); id != fields[2] {
+		if x, err := strconv.ParseUint(id, 16, 32); err == nil {
+			seg.overlay = uint32(x)
+		}
+	}
+	return seg, true
+}
+
+// An idaPublic is a public symbol of an IDA MAP file.
+type idaPublic struct {
+	// Segment number.
+	seg uint64
+	// Address.
+	addr uint32
+	// Symbol name.
+	name string
+}
+
+// parseIDAPublic parses the given row of the symbol table of an IDA MAP file,
+// located at the given line number.
+func parseIDAPublic(line string, lineNr int) (*idaPublic, error) {
+	// 0001:80010000       main
+	pos := strings.IndexAny(line, " \t")
+	if pos == -1 {
+		return nil, errors.Errorf("invalid IDA MAP symbol on line %d; expected address and name, got %q", lineNr, line)
+	}
+	addr, name := line[:pos], strings.TrimSpace(line[pos:])
+	parts := strings.SplitN(addr, ":", 2)
+	if len(parts) != 2 {
+		return nil, errors.Errorf("invalid address of IDA MAP symbol on line %d; expected segment:offset, got %q", lineNr, addr)
+	}
+	seg, err := strconv.ParseUint(parts[0], 16, 16)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid segment of IDA MAP symbol on line %d", lineNr)
+	}
+	offset, err := strconv.ParseUint(parts[1], 16, 32)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid offset of IDA MAP symbol on line %d", lineNr)
+	}
+	pub := &idaPublic{
+		seg:  seg,
+		addr: uint32(offset),
+		name: name,
+	}
+	return pub, nil
+}
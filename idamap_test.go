@@ -0,0 +1,104 @@
+package sym_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sanctuary/sym"
+)
+
+func TestParseIDAMap(t *testing.T) {
+	const input = `
+ Start         Length     Name                   Class
+ 0001:80010000 00001000H .text                   CODE
+ 0002:80011000 00000100H .data                   DATA
+
+
+  Address         Publics by Value
+
+ 0001:80010000       main
+ 0001:80010040       InitGame
+ 0002:80011000       g_state
+
+Program entry point at 0001:80010000
+`
+	f, err := sym.ParseIDAMap(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unable to parse IDA MAP file; %+v", err)
+	}
+	golden := []struct {
+		addr uint32
+		name string
+	}{
+		{addr: 0x80010000, name: "main"},
+		{addr: 0x80010040, name: "InitGame"},
+		{addr: 0x80011000, name: "g_state"},
+	}
+	if len(f.Syms) != len(golden) {
+		t.Fatalf("number of symbols mismatch; expected %d, got %d (%v)", len(golden), len(f.Syms), f.Syms)
+	}
+	for i, g := range golden {
+		s := f.Syms[i]
+		body, ok := s.Body.(*sym.Name2)
+		if !ok || s.Hdr.Value != g.addr || s.Hdr.Kind != sym.KindName2 || body.Name != g.name || int(body.NameLen) != len(g.name) {
+			t.Errorf("symbol %d mismatch; expected %s at 0x%08X, got %v", i, g.name, g.addr, s)
+		}
+	}
+}
+
+func TestParseIDAMapOverlays(t *testing.T) {
+	// Symbols listed by name and by value, and overlay segment.
+	const input = `
+ Start         Length     Name                   Class
+ 0001:80010000 00001000H .text                   CODE
+ 0002:80100000 00000800H overlay_4               CODE
+
+
+  Address         Publics by Name
+
+ 0001:80010040       InitGame
+ 0001:80010000       main
+ 0002:80100000       ovl_main
+
+
+  Address         Publics by Value
+
+ 0001:80010000       main
+ 0001:80010040       InitGame
+ 0002:80100000       ovl_main
+
+Program entry point at 0001:80010000
+`
+	f, err := sym.ParseIDAMap(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unable to parse IDA MAP file; %+v", err)
+	}
+	want := []string{
+		"$80100000 overlay length $00000800 id $4",
+		"$80010000 2 main",
+		"$80010040 2 InitGame",
+		"$00000004 set overlay ",
+		"$80100000 2 ovl_main",
+	}
+	if len(f.Syms) != len(want) {
+		t.Fatalf("number of symbols mismatch; expected %d, got %d (%v)", len(want), len(f.Syms), f.Syms)
+	}
+	for i, s := range f.Syms {
+		if got := s.String(); got != want[i] {
+			t.Errorf("symbol %d mismatch; expected %q, got %q", i, want[i], got)
+		}
+	}
+}
+
+func TestParseIDAMapInvalid(t *testing.T) {
+	inputs := []string{
+		"Publics by Value\nmain\n",
+		"Publics by Value\n80010000 main\n",
+		"Publics by Value\n0001:8001000G main\n",
+	}
+	for _, input := range inputs {
+		if _, err := sym.ParseIDAMap(strings.NewReader(input)); err == nil {
+			t.Errorf("expected error for IDA MAP file %q", input)
+		}
+	}
+}
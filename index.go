@@ -0,0 +1,303 @@
+package sym
+
+import (
+	"fmt"
+	"sort"
+)
+
+//go:generate stringer -linecomment -type SizeSource
+
+// SizeSource specifies the provenance of the size of a symbol.
+type SizeSource uint8
+
+// Size sources.
+const (
+	// Unknown size.
+	SizeUnknown SizeSource = iota // unknown
+	// Size specified explicitly by a definition symbol (Def or Def2).
+	SizeExplicit // explicit
+	// Size determined by the address range of a function.
+	SizeFunction // function
+	// Size inferred from the gap up until the succeeding symbol of the same
+	// section and overlay.
+	SizeInferred // inferred
+	// Size determined by the gap up until the succeeding symbol of the same
+	// section of a linker map file.
+	SizeMap // map
+)
+
+// An AddrIndex maps addresses to the named symbols located at them, supporting
+// exact and nearest preceding symbol lookups.
+type AddrIndex struct {
+	// Index entries, sorted by overlay ID and address.
+	Entries []*AddrEntry
+}
+
+// An AddrEntry associates an address with a named symbol.
+type AddrEntry struct {
+	// Address.
+	Addr uint32
+	// Symbol name.
+	Name string
+	// ID of the overlay containing the address (0 for the default binary).
+	Overlay uint32
+	// Symbol associated with the address.
+	Sym *Symbol
+	// Size in bytes of the symbol; valid unless SizeSource is SizeUnknown.
+	Size uint32
+	// Provenance of the size.
+	SizeSource SizeSource
+}
+
+// String returns the string representation of the index entry.
+func (e *AddrEntry) String() string {
+	return fmt.Sprintf("%s (0x%08X)", e.Name, e.Addr)
+}
+
+// NewAddrIndex returns a new address index of the named symbols with addresses
+// (name symbols, function start symbols and global definitions) of the given
+// symbols. Symbols of the same name and address are only indexed once, keeping
+// the first occurrence.
+//
+// The size of each symbol is determined by the size of its definition, the
+// address range of its function, or, if neither is known, inferred from the gap
+// up until the succeeding symbol of the same section and overlay.
+func NewAddrIndex(syms []*Symbol) *AddrIndex {
+	idx := &AddrIndex{}
+	type key struct {
+		overlay uint32
+		addr    uint32
+		name    string
+	}
+	seen := make(map[key]bool)
+	// Current overlay ID.
+	var overlay uint32
+	for _, s := range syms {
+		if _, ok := s.Body.(*SetOverlay); ok {
+			overlay = s.Hdr.Value
+			continue
+		}
+		name := s.Name()
+		if len(name) == 0 {
+			continue
+		}
+		addr, ok := s.Address()
+		if !ok {
+			continue
+		}
+		k := key{overlay: overlay, addr: addr, name: name}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		entry := &AddrEntry{
+			Addr:    addr,
+			Name:    name,
+			Overlay: overlay,
+			Sym:     s,
+		}
+		idx.Entries = append(idx.Entries, entry)
+	}
+	less := func(i, j int) bool {
+		if idx.Entries[i].Overlay != idx.Entries[j].Overlay {
+			return idx.Entries[i].Overlay < idx.Entries[j].Overlay
+		}
+		return idx.Entries[i].Addr < idx.Entries[j].Addr
+	}
+	sort.SliceStable(idx.Entries, less)
+	idx.initSizes(syms)
+	return idx
+}
+
+// initSizes determines the sizes of the index entries, based on the given
+// symbols.
+//
+// Sizes are only inferred within the bounds of a section and overlay; i.e. the
+// gap up until a succeeding symbol of another section (text or data) is not
+// attributed to a symbol, and inferred sizes end at the end of the address
+// space of the overlay (or for the default binary, at the base address of the
+// succeeding overlay).
+func (idx *AddrIndex) initSizes(syms []*Symbol) {
+	type key struct {
+		overlay uint32
+		addr    uint32
+	}
+	// sizes maps from overlay and address to the known size in bytes of the
+	// symbol at the address.
+	sizes := make(map[key]uint32)
+	// sources maps from overlay and address to the source of the known size.
+	sources := make(map[key]SizeSource)
+	// sections maps from overlay and address to the section of the symbol at
+	// the address, if known.
+	sections := make(map[key]Section)
+	// bounds maps from overlay ID to the end address of the address space of
+	// the overlay, and starts records the base address of each overlay.
+	bounds := make(map[uint32]uint32)
+	var starts []uint32
+	var overlay uint32
+	for _, s := range syms {
+		switch body := s.Body.(type) {
+		case *Def, *Def2:
+			if addr, ok := s.Address(); ok {
+				k := key{overlay: overlay, addr: addr}
+				if s.SizeOf() > 0 {
+					sizes[k] = s.SizeOf()
+					sources[k] = SizeExplicit
+				}
+				if isFuncDef(s) {
+					sections[k] = SectionText
+				} else {
+					sections[k] = SectionData
+				}
+			}
+		case *FuncStart:
+			sections[key{overlay: overlay, addr: s.Hdr.Value}] = SectionText
+		case *Overlay:
+			bounds[body.ID] = endAddr(s.Hdr.Value, body.Length)
+			starts = append(starts, s.Hdr.Value)
+		case *SetOverlay:
+			overlay = s.Hdr.Value
+		}
+	}
+	for _, f := range Functions(syms) {
+		k := key{overlay: f.Overlay, addr: f.Addr}
+		if _, ok := sizes[k]; !ok && f.EndAddr > f.Addr {
+			sizes[k] = f.EndAddr - f.Addr
+			sources[k] = SizeFunction
+		}
+	}
+	// limit returns the end address of the address space containing the given
+	// address of the specified overlay; the end of the overlay, or for the
+	// default binary the base address of the succeeding overlay. The boolean
+	// return value indicates whether the address space is bounded.
+	limit := func(overlay, addr uint32) (uint32, bool) {
+		if overlay != 0 {
+			end, ok := bounds[overlay]
+			return end, ok && addr < end
+		}
+		var end uint32
+		found := false
+		for _, start := range starts {
+			if start > addr && (!found || start < end) {
+				end = start
+				found = true
+			}
+		}
+		return end, found
+	}
+	for i, e := range idx.Entries {
+		k := key{overlay: e.Overlay, addr: e.Addr}
+		if size, ok := sizes[k]; ok {
+			e.Size = size
+			e.SizeSource = sources[k]
+			continue
+		}
+		// Infer size from gap up until the succeeding symbol of the same section
+		// and overlay.
+		section := sections[k]
+		end, bounded := limit(e.Overlay, e.Addr)
+		// Last symbol of the address space.
+		last := true
+		for j := i + 1; j < len(idx.Entries); j++ {
+			next := idx.Entries[j]
+			if next.Overlay != e.Overlay {
+				break
+			}
+			if bounded && next.Addr > end {
+				break
+			}
+			if next.Addr > e.Addr {
+				last = false
+				nextSection := sections[key{overlay: next.Overlay, addr: next.Addr}]
+				if section != SectionUnknown && nextSection != SectionUnknown && section != nextSection {
+					break
+				}
+				e.Size = next.Addr - e.Addr
+				e.SizeSource = SizeInferred
+				break
+			}
+		}
+		if last && bounded {
+			// Infer size up until the end of the address space.
+			e.Size = end - e.Addr
+			e.SizeSource = SizeInferred
+		}
+	}
+}
+
+// Lookup returns the index entries located at the given address of the default
+// binary.
+func (idx *AddrIndex) Lookup(addr uint32) []*AddrEntry {
+	return idx.LookupOverlay(0, addr)
+}
+
+// LookupOverlay returns the index entries located at the given address of the
+// specified overlay.
+func (idx *AddrIndex) LookupOverlay(overlay, addr uint32) []*AddrEntry {
+	i := idx.search(overlay, addr)
+	var entries []*AddrEntry
+	for ; i < len(idx.Entries); i++ {
+		e := idx.Entries[i]
+		if e.Overlay != overlay || e.Addr != addr {
+			break
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// Nearest returns the index entry nearest preceding (or located at) the given
+// address of the default binary, and the offset of the address from the entry.
+// The boolean return value indicates success.
+func (idx *AddrIndex) Nearest(addr uint32) (entry *AddrEntry, offset uint32, ok bool) {
+	return idx.NearestOverlay(0, addr)
+}
+
+// NearestOverlay returns the index entry nearest preceding (or located at) the
+// given address of the specified overlay, and the offset of the address from
+// the entry. The boolean return value indicates success.
+func (idx *AddrIndex) NearestOverlay(overlay, addr uint32) (entry *AddrEntry, offset uint32, ok bool) {
+	// Locate the first entry succeeding the address.
+	i := sort.Search(len(idx.Entries), func(i int) bool {
+		e := idx.Entries[i]
+		if e.Overlay != overlay {
+			return e.Overlay > overlay
+		}
+		return e.Addr > addr
+	})
+	if i == 0 {
+		return nil, 0, false
+	}
+	e := idx.Entries[i-1]
+	if e.Overlay != overlay {
+		return nil, 0, false
+	}
+	return e, addr - e.Addr, true
+}
+
+// Symbolize returns a symbolic representation of the given address of the
+// default binary, based on the nearest preceding symbol (e.g. "main+0x1C"); or
+// the hexadecimal address if no symbol precedes it.
+func (idx *AddrIndex) Symbolize(addr uint32) string {
+	e, offset, ok := idx.Nearest(addr)
+	switch {
+	case !ok:
+		return fmt.Sprintf("0x%08X", addr)
+	case offset == 0:
+		return e.Name
+	default:
+		return fmt.Sprintf("%s+0x%X", e.Name, offset)
+	}
+}
+
+// search returns the index of the first entry located at or after the given
+// address of the specified overlay.
+func (idx *AddrIndex) search(overlay, addr uint32) int {
+	return sort.Search(len(idx.Entries), func(i int) bool {
+		e := idx.Entries[i]
+		if e.Overlay != overlay {
+			return e.Overlay > overlay
+		}
+		return e.Addr >= addr
+	})
+}
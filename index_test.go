@@ -0,0 +1,232 @@
+package sym_test
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/sanctuary/sym"
+)
+
+func TestAddrIndex(t *testing.T) {
+	name := func(addr uint32, name string) *sym.Symbol {
+		return &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: addr, Kind: sym.KindName2},
+			Body: &sym.Name2{Name: name},
+		}
+	}
+	syms := []*sym.Symbol{
+		name(0x80010100, "bar"),
+		name(0x80010000, "foo"),
+		// Duplicate of name symbol.
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010000, Kind: sym.KindFuncStart},
+			Body: &sym.FuncStart{Name: "foo"},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 4, Kind: sym.KindSetOverlay},
+			Body: &sym.SetOverlay{},
+		},
+		name(0x80010080, "ovl_foo"),
+	}
+	idx := sym.NewAddrIndex(syms)
+	if len(idx.Entries) != 3 {
+		t.Fatalf("number of index entries mismatch; expected 3, got %d", len(idx.Entries))
+	}
+	if entries := idx.Lookup(0x80010000); len(entries) != 1 || entries[0].Name != "foo" {
+		t.Errorf("exact lookup mismatch; expected [foo], got %v", entries)
+	}
+	golden := []struct {
+		addr uint32
+		want string
+	}{
+		{addr: 0x80000000, want: "0x80000000"},
+		{addr: 0x80010000, want: "foo"},
+		{addr: 0x80010084, want: "foo+0x84"},
+		{addr: 0x80010104, want: "bar+0x4"},
+	}
+	for _, g := range golden {
+		if got := idx.Symbolize(g.addr); got != g.want {
+			t.Errorf("symbolization mismatch of 0x%08X; expected %q, got %q", g.addr, g.want, got)
+		}
+	}
+	// Size of foo inferred from gap up until bar; size of bar unknown.
+	foo, bar := idx.Entries[0], idx.Entries[1]
+	if foo.Size != 0x100 || foo.SizeSource != sym.SizeInferred {
+		t.Errorf("size of foo mismatch; expected inferred size 0x100, got %v size 0x%X", foo.SizeSource, foo.Size)
+	}
+	if bar.SizeSource != sym.SizeUnknown {
+		t.Errorf("size source of bar mismatch; expected unknown, got %v", bar.SizeSource)
+	}
+	e, offset, ok := idx.NearestOverlay(4, 0x80010090)
+	if !ok || e.Name != "ovl_foo" || offset != 0x10 {
+		t.Errorf("nearest symbol mismatch in overlay 4; expected ovl_foo+0x10, got %v+0x%X", e, offset)
+	}
+}
+
+func TestAddrIndexSizeBounds(t *testing.T) {
+	syms := []*sym.Symbol{
+		// Overlay 4 loaded at 0x80100000 (0x100 bytes).
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80100000, Kind: sym.KindOverlay},
+			Body: &sym.Overlay{Length: 0x100, ID: 4},
+		},
+		// Overlay 5 loaded at 0x80100000 (0x100 bytes).
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80100000, Kind: sym.KindOverlay},
+			Body: &sym.Overlay{Length: 0x100, ID: 5},
+		},
+		// Function of unknown size, followed by a global variable.
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010000, Kind: sym.KindFuncStart},
+			Body: &sym.FuncStart{Name: "main"},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80020000, Kind: sym.KindDef},
+			Body: &sym.Def{Class: sym.ClassSTAT, Type: 0x04, Name: "buf"},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80200000, Kind: sym.KindName2},
+			Body: &sym.Name2{Name: "end"},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 4, Kind: sym.KindSetOverlay},
+			Body: &sym.SetOverlay{},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80100080, Kind: sym.KindName2},
+			Body: &sym.Name2{Name: "ovl_foo"},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80180000, Kind: sym.KindName2},
+			Body: &sym.Name2{Name: "ovl_bar"},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 5, Kind: sym.KindSetOverlay},
+			Body: &sym.SetOverlay{},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80100040, Kind: sym.KindName2},
+			Body: &sym.Name2{Name: "ovl_baz"},
+		},
+	}
+	idx := sym.NewAddrIndex(syms)
+	golden := []struct {
+		name   string
+		size   uint32
+		source sym.SizeSource
+	}{
+		// Not inferred across text and data sections.
+		{name: "main", source: sym.SizeUnknown},
+		// Inferred up until the base address of overlay 4.
+		{name: "buf", size: 0xE0000, source: sym.SizeInferred},
+		{name: "end", source: sym.SizeUnknown},
+		// Inferred up until the end of overlay 4.
+		{name: "ovl_foo", size: 0x80, source: sym.SizeInferred},
+		{name: "ovl_bar", source: sym.SizeUnknown},
+		// Last symbol inferred up until the end of overlay 5.
+		{name: "ovl_baz", size: 0xC0, source: sym.SizeInferred},
+	}
+	if len(idx.Entries) != len(golden) {
+		t.Fatalf("number of index entries mismatch; expected %d, got %d", len(golden), len(idx.Entries))
+	}
+	for i, g := range golden {
+		e := idx.Entries[i]
+		if e.Name != g.name || e.Size != g.size || e.SizeSource != g.source {
+			t.Errorf("index entry %d mismatch; expected %s of %v size 0x%X, got %s of %v size 0x%X", i, g.name, g.source, g.size, e.Name, e.SizeSource, e.Size)
+		}
+	}
+}
+
+func TestSymbolTable(t *testing.T) {
+	name := func(addr uint32, name string) *sym.Symbol {
+		return &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: addr, Kind: sym.KindName2},
+			Body: &sym.Name2{Name: name},
+		}
+	}
+	syms := []*sym.Symbol{
+		name(0x80010000, "DrawSync"),
+		name(0x80010100, "DrawPrim"),
+		{
+			Hdr:  &sym.SymbolHeader{Value: 4, Kind: sym.KindSetOverlay},
+			Body: &sym.SetOverlay{},
+		},
+		// Name repeated in overlay.
+		name(0x80100000, "DrawSync"),
+		name(0x80100100, "InitGame"),
+	}
+	st := sym.NewSymbolTable(syms)
+	entries := st.Lookup("DrawSync")
+	if len(entries) != 2 {
+		t.Fatalf("number of DrawSync symbols mismatch; expected 2, got %d", len(entries))
+	}
+	if entries[1].Overlay != 4 || entries[1].Addr != 0x80100000 {
+		t.Errorf("overlay context mismatch; expected 0x80100000 of overlay 4, got %v", entries[1])
+	}
+	if got := st.Prefix("Draw"); len(got) != 3 || got[0].Name != "DrawPrim" {
+		t.Errorf("prefix query mismatch; expected DrawPrim and 2 DrawSync symbols, got %v", got)
+	}
+	if got := st.Match(regexp.MustCompile(`^Init|Prim$`)); len(got) != 2 {
+		t.Errorf("regexp query mismatch; expected DrawPrim and InitGame, got %v", got)
+	}
+}
+
+func TestIntervalTree(t *testing.T) {
+	syms := []*sym.Symbol{
+		// Function of 0x40 bytes.
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010000, Kind: sym.KindDef},
+			Body: &sym.Def{Class: sym.ClassEXT, Type: 0x24, Size: 0x40, Name: "main"},
+		},
+		// Name symbol of unknown extent, up until buf.
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010040, Kind: sym.KindName2},
+			Body: &sym.Name2{Name: "helper"},
+		},
+		// Global variable of 0x100 bytes.
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80020000, Kind: sym.KindDef},
+			Body: &sym.Def{Class: sym.ClassSTAT, Type: 0x04, Size: 0x100, Name: "buf"},
+		},
+	}
+	tree := sym.NewIntervalTree(syms)
+	golden := []struct {
+		lo, hi uint32
+		want   []string
+	}{
+		{lo: 0x8000FFFC, hi: 0x80010000, want: nil},
+		{lo: 0x8001003C, hi: 0x8001003D, want: []string{"main"}},
+		{lo: 0x80010040, hi: 0x80010041, want: []string{"helper"}},
+		{lo: 0x8001FFFF, hi: 0x80020000, want: []string{"helper"}},
+		{lo: 0x80020100, hi: 0x80020104, want: nil},
+		{lo: 0x80010000, hi: 0x80030000, want: []string{"main", "helper", "buf"}},
+	}
+	for _, g := range golden {
+		var got []string
+		for _, iv := range tree.Range(g.lo, g.hi) {
+			got = append(got, iv.Name)
+		}
+		if !reflect.DeepEqual(got, g.want) {
+			t.Errorf("symbols of range [0x%08X, 0x%08X) mismatch; expected %v, got %v", g.lo, g.hi, g.want, got)
+		}
+	}
+	if ivs := tree.Lookup(0x800200FF); len(ivs) != 1 || ivs[0].Name != "buf" {
+		t.Errorf("symbol covering 0x800200FF mismatch; expected [buf], got %v", ivs)
+	}
+	// Symbol extending past the end of the address space.
+	tree = sym.NewIntervalTree([]*sym.Symbol{
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0xFFFFFFF0, Kind: sym.KindDef},
+			Body: &sym.Def{Class: sym.ClassSTAT, Type: 0x04, Size: 0x100, Name: "top"},
+		},
+	})
+	for _, addr := range []uint32{0xFFFFFFF0, 0xFFFFFFFF} {
+		if ivs := tree.Lookup(addr); len(ivs) != 1 || ivs[0].Name != "top" {
+			t.Errorf("symbol covering 0x%08X mismatch; expected [top], got %v", addr, ivs)
+		}
+	}
+	if ivs := tree.Range(0xFFFFFFF8, 0xFFFFFFF8); len(ivs) != 0 {
+		t.Errorf("symbols of empty range mismatch; expected [], got %v", ivs)
+	}
+}
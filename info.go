@@ -0,0 +1,110 @@
+package sym
+
+// A SymbolInfo is a flattened view of a symbol, normalizing the concepts shared
+// by symbols of different kinds.
+type SymbolInfo struct {
+	// Symbol kind.
+	Kind Kind
+	// Symbol name; or empty if the symbol is unnamed.
+	Name string
+	// Address associated with the symbol; valid if HasAddr is set.
+	Addr uint32
+	// Specifies whether the symbol is associated with an address.
+	HasAddr bool
+	// Size in bytes of the entity described by the symbol (e.g. the size of a
+	// variable or the length of an overlay); or 0 if unknown.
+	Size uint32
+}
+
+// Info returns a flattened view of the symbol.
+func (sym *Symbol) Info() SymbolInfo {
+	addr, ok := sym.Address()
+	return SymbolInfo{
+		Kind:    sym.Hdr.Kind,
+		Name:    sym.Name(),
+		Addr:    addr,
+		HasAddr: ok,
+		Size:    sym.SizeOf(),
+	}
+}
+
+// Name returns the name of the symbol; or an empty string if the symbol is
+// unnamed.
+//
+// Names are specified by name symbols (Name1, Name2, Name5 and Name6), function
+// start symbols and definition symbols (Def and Def2).
+func (sym *Symbol) Name() string {
+	switch body := sym.Body.(type) {
+	case *Name1:
+		return body.Name
+	case *Name2:
+		return body.Name
+	case *Name5:
+		return body.Name
+	case *Name6:
+		return body.Name
+	case *FuncStart:
+		return body.Name
+	case *Def:
+		return body.Name
+	case *Def2:
+		return body.Name
+	}
+	return ""
+}
+
+// Address returns the address associated with the symbol. The boolean return
+// value reports whether the symbol is associated with an address.
+//
+// The value of the symbol header specifies an address for name symbols, line
+// number, function and block symbols, overlay symbols and definitions of global
+// variables and functions (EXT and STAT). For other symbols, the value
+// specifies e.g. a stack offset, a register number or an overlay ID.
+func (sym *Symbol) Address() (uint32, bool) {
+	switch body := sym.Body.(type) {
+	case *Name1, *Name2, *Name5, *Name6:
+		return sym.Hdr.Value, true
+	case *IncSLD, *IncSLDByte, *IncSLDWord, *SetSLD, *SetSLD2, *EndSLD:
+		return sym.Hdr.Value, true
+	case *FuncStart, *FuncEnd, *BlockStart, *BlockEnd:
+		return sym.Hdr.Value, true
+	case *Overlay:
+		return sym.Hdr.Value, true
+	case *Def:
+		if isGlobalClass(body.Class) {
+			return sym.Hdr.Value, true
+		}
+	case *Def2:
+		if isGlobalClass(body.Class) {
+			return sym.Hdr.Value, true
+		}
+	}
+	return 0, false
+}
+
+// SizeOf returns the size in bytes of the entity described by the symbol;
+// i.e. the size of definitions (Def and Def2) and the length of overlays. For
+// other symbols, 0 is returned.
+//
+// Note, SizeOf differs from Size, which returns the size of the symbol itself.
+func (sym *Symbol) SizeOf() uint32 {
+	switch body := sym.Body.(type) {
+	case *Def:
+		return body.Size
+	case *Def2:
+		return body.Size
+	case *Overlay:
+		return body.Length
+	}
+	return 0
+}
+
+// isGlobalClass reports whether the given definition class specifies a global
+// declaration, the value of which is an address.
+func isGlobalClass(class Class) bool {
+	switch class {
+	case ClassEXT, ClassSTAT:
+		return true
+	}
+	return false
+}
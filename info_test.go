@@ -0,0 +1,49 @@
+package sym_test
+
+import (
+	"testing"
+
+	"github.com/sanctuary/sym"
+)
+
+func TestSymbolInfo(t *testing.T) {
+	golden := []struct {
+		sym  *sym.Symbol
+		want sym.SymbolInfo
+	}{
+		{
+			sym: &sym.Symbol{
+				Hdr:  &sym.SymbolHeader{Value: 0x80010000, Kind: sym.KindName2},
+				Body: &sym.Name2{Name: "main"},
+			},
+			want: sym.SymbolInfo{Kind: sym.KindName2, Name: "main", Addr: 0x80010000, HasAddr: true},
+		},
+		{
+			sym: &sym.Symbol{
+				Hdr:  &sym.SymbolHeader{Value: 0x80020000, Kind: sym.KindDef},
+				Body: &sym.Def{Class: sym.ClassEXT, Size: 4, Name: "x"},
+			},
+			want: sym.SymbolInfo{Kind: sym.KindDef, Name: "x", Addr: 0x80020000, HasAddr: true, Size: 4},
+		},
+		{
+			// Stack offset of parameter.
+			sym: &sym.Symbol{
+				Hdr:  &sym.SymbolHeader{Value: 16, Kind: sym.KindDef},
+				Body: &sym.Def{Class: sym.ClassARG, Size: 4, Name: "y"},
+			},
+			want: sym.SymbolInfo{Kind: sym.KindDef, Name: "y", Size: 4},
+		},
+		{
+			sym: &sym.Symbol{
+				Hdr:  &sym.SymbolHeader{Value: 0x800B031C, Kind: sym.KindOverlay},
+				Body: &sym.Overlay{Length: 0x9E4, ID: 4},
+			},
+			want: sym.SymbolInfo{Kind: sym.KindOverlay, Addr: 0x800B031C, HasAddr: true, Size: 0x9E4},
+		},
+	}
+	for _, g := range golden {
+		if got := g.sym.Info(); got != g.want {
+			t.Errorf("symbol info mismatch of %v; expected %+v, got %+v", g.sym, g.want, got)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package sym
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// An IntervalTree maps address ranges to the named symbols covering them,
+// supporting point and range queries.
+//
+// The extent of each symbol is determined by its size, as recorded by the
+// address index (see NewAddrIndex).
+type IntervalTree struct {
+	// trees maps from overlay ID to the interval tree of the overlay.
+	trees map[uint32]*intervalTree
+}
+
+// An Interval is the address range covered by a named symbol.
+type Interval struct {
+	// Start address.
+	Start uint32
+	// End address (exclusive); an end address of 0xFFFFFFFF extends to the end
+	// of the address space, inclusive, as used by symbols extending past the
+	// end of the address space.
+	End uint32
+	// Symbol name.
+	Name string
+	// ID of the overlay containing the address range (0 for the default
+	// binary).
+	Overlay uint32
+	// Symbol covering the address range.
+	Sym *Symbol
+}
+
+// String returns the string representation of the interval.
+func (iv *Interval) String() string {
+	return fmt.Sprintf("%s [0x%08X, 0x%08X)", iv.Name, iv.Start, iv.End)
+}
+
+// NewIntervalTree returns a new interval tree of the extents of the named
+// symbols with addresses of the given symbols.
+func NewIntervalTree(syms []*Symbol) *IntervalTree {
+	t := &IntervalTree{
+		trees: make(map[uint32]*intervalTree),
+	}
+	for _, e := range NewAddrIndex(syms).Entries {
+		if e.Size == 0 {
+			// Skip symbols of unknown extent.
+			continue
+		}
+		iv := &Interval{
+			Start:   e.Addr,
+			End:     endAddr(e.Addr, e.Size),
+			Name:    e.Name,
+			Overlay: e.Overlay,
+			Sym:     e.Sym,
+		}
+		tree, ok := t.trees[e.Overlay]
+		if !ok {
+			tree = &intervalTree{}
+			t.trees[e.Overlay] = tree
+		}
+		tree.ivs = append(tree.ivs, iv)
+	}
+	for _, tree := range t.trees {
+		tree.init()
+	}
+	return t
+}
+
+// Lookup returns the intervals covering the given address of the default
+// binary, sorted by start address.
+func (t *IntervalTree) Lookup(addr uint32) []*Interval {
+	return t.LookupOverlay(0, addr)
+}
+
+// LookupOverlay returns the intervals covering the given address of the
+// specified overlay, sorted by start address.
+func (t *IntervalTree) LookupOverlay(overlay, addr uint32) []*Interval {
+	return t.query(overlay, addr, addr)
+}
+
+// Range returns the intervals overlapping the address range [lo, hi) of the
+// default binary, sorted by start address.
+func (t *IntervalTree) Range(lo, hi uint32) []*Interval {
+	return t.RangeOverlay(0, lo, hi)
+}
+
+// RangeOverlay returns the intervals overlapping the address range [lo, hi) of
+// the specified overlay, sorted by start address.
+func (t *IntervalTree) RangeOverlay(overlay, lo, hi uint32) []*Interval {
+	if hi <= lo {
+		return nil
+	}
+	return t.query(overlay, lo, hi-1)
+}
+
+// query returns the intervals overlapping the address range [lo, last] of the
+// specified overlay, sorted by start address. The range is inclusive of its
+// last address, so that queries may extend up until 0xFFFFFFFF.
+func (t *IntervalTree) query(overlay, lo, last uint32) []*Interval {
+	tree, ok := t.trees[overlay]
+	if !ok {
+		return nil
+	}
+	var ivs []*Interval
+	tree.query(0, len(tree.ivs), lo, last, &ivs)
+	return ivs
+}
+
+// intervalTree is an implicit augmented binary search tree of intervals, stored
+// in an array sorted by start address. The root of the subtree spanning
+// ivs[lo:hi] is located at the midpoint of lo and hi.
+type intervalTree struct {
+	// Intervals sorted by start address.
+	ivs []*Interval
+	// maxEnd[i] is the maximum end address of the subtree rooted at i.
+	maxEnd []uint32
+}
+
+// init sorts the intervals and computes the maximum end address of each
+// subtree.
+func (tree *intervalTree) init() {
+	less := func(i, j int) bool {
+		return tree.ivs[i].Start < tree.ivs[j].Start
+	}
+	sort.SliceStable(tree.ivs, less)
+	tree.maxEnd = make([]uint32, len(tree.ivs))
+	tree.build(0, len(tree.ivs))
+}
+
+// build computes the maximum end address of the subtree spanning ivs[lo:hi],
+// and returns it.
+func (tree *intervalTree) build(lo, hi int) uint32 {
+	if lo >= hi {
+		return 0
+	}
+	mid := lo + (hi-lo)/2
+	max := tree.ivs[mid].End
+	if end := tree.build(lo, mid); end > max {
+		max = end
+	}
+	if end := tree.build(mid+1, hi); end > max {
+		max = end
+	}
+	tree.maxEnd[mid] = max
+	return max
+}
+
+// query appends the intervals of the subtree spanning ivs[lo:hi] overlapping the
+// address range [qlo, qlast] to ivs, in order of start address.
+func (tree *intervalTree) query(lo, hi int, qlo, qlast uint32, ivs *[]*Interval) {
+	if lo >= hi {
+		return
+	}
+	mid := lo + (hi-lo)/2
+	if !endsAfter(tree.maxEnd[mid], qlo) {
+		// No interval of the subtree ends after the start of the range.
+		return
+	}
+	tree.query(lo, mid, qlo, qlast, ivs)
+	iv := tree.ivs[mid]
+	if iv.Start > qlast {
+		// Intervals of the right subtree start after the end of the range.
+		return
+	}
+	if endsAfter(iv.End, qlo) {
+		*ivs = append(*ivs, iv)
+	}
+	tree.query(mid+1, hi, qlo, qlast, ivs)
+}
+
+// endsAfter reports whether an interval of the given end address covers
+// addresses at or after addr. An end address of 0xFFFFFFFF extends to the end
+// of the address space.
+func endsAfter(end, addr uint32) bool {
+	return end > addr || end == math.MaxUint32
+}
+
+// endAddr returns the end address of the address range of the given start
+// address and size, saturated to 0xFFFFFFFF for ranges extending past the end
+// of the address space.
+func endAddr(addr, size uint32) uint32 {
+	end := addr + size
+	if end < addr {
+		return math.MaxUint32
+	}
+	return end
+}
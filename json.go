@@ -0,0 +1,515 @@
+package sym
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// JSON names of the built-in symbol kinds, as used by the kind discriminator of
+// JSON encoded symbols.
+var kindNames = map[Kind]string{
+	KindName1:      "name1",
+	KindName2:      "name2",
+	KindName5:      "name5",
+	KindName6:      "name6",
+	KindIncSLD:     "inc_sld",
+	KindIncSLDByte: "inc_sld_byte",
+	KindIncSLDWord: "inc_sld_word",
+	KindSetSLD:     "set_sld",
+	KindSetSLD2:    "set_sld2",
+	KindEndSLD:     "end_sld",
+	KindFuncStart:  "func_start",
+	KindFuncEnd:    "func_end",
+	KindBlockStart: "block_start",
+	KindBlockEnd:   "block_end",
+	KindDef:        "def",
+	KindDef2:       "def2",
+	KindOverlay:    "overlay",
+	KindSetOverlay: "set_overlay",
+}
+
+// kindUnknown is the JSON name of the kind of UnknownBody symbols.
+const kindUnknown = "unknown"
+
+// --- [ File ] ----------------------------------------------------------------
+
+// jsonFile is the JSON representation of a symbol file.
+type jsonFile struct {
+	// File signature; MND.
+	Signature string `json:"signature"`
+	// File format version.
+	Version uint8 `json:"version"`
+	// Target unit.
+	TargetUnit uint32 `json:"target_unit"`
+	// Byte order of multi-byte fields; "little" or "big".
+	Order string `json:"order"`
+	// Symbols.
+	Syms []*Symbol `json:"symbols"`
+	// Regions of the file skipped while recovering from corrupted symbols.
+	Skipped []*jsonSkippedRegion `json:"skipped,omitempty"`
+}
+
+// jsonSkippedRegion is the JSON representation of a skipped region.
+type jsonSkippedRegion struct {
+	// Offset in bytes from the start of the file.
+	Offset int `json:"offset"`
+	// Size in bytes.
+	Size int `json:"size"`
+	// Error encountered when decoding the symbol at the start of the region.
+	Err string `json:"error"`
+}
+
+// MarshalJSON returns the JSON encoding of the symbol file; the file header,
+// byte order, symbols and skipped regions of the file.
+func (f *File) MarshalJSON() ([]byte, error) {
+	v := &jsonFile{
+		Signature:  string(f.Hdr.Signature[:]),
+		Version:    f.Hdr.Version,
+		TargetUnit: f.Hdr.TargetUnit,
+		Order:      "little",
+		Syms:       f.Syms,
+	}
+	if f.Order == binary.BigEndian {
+		v.Order = "big"
+	}
+	for _, region := range f.Skipped {
+		r := &jsonSkippedRegion{
+			Offset: region.Offset,
+			Size:   region.Size,
+			Err:    region.Err.Error(),
+		}
+		v.Skipped = append(v.Skipped, r)
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes the JSON encoding of a symbol file. Skipped regions are
+// not retained, as they are not present when re-encoding the file.
+func (f *File) UnmarshalJSON(data []byte) error {
+	v := &jsonFile{}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.WithStack(err)
+	}
+	hdr := &FileHeader{
+		Version:    v.Version,
+		TargetUnit: v.TargetUnit,
+	}
+	if len(v.Signature) != len(hdr.Signature) {
+		return errors.Errorf("invalid SYM signature %q; expected %d bytes", v.Signature, len(hdr.Signature))
+	}
+	copy(hdr.Signature[:], v.Signature)
+	switch v.Order {
+	case "little", "":
+		f.Order = binary.LittleEndian
+	case "big":
+		f.Order = binary.BigEndian
+	default:
+		return errors.Errorf(`invalid byte order %q; expected "little" or "big"`, v.Order)
+	}
+	f.Hdr = hdr
+	f.Syms = v.Syms
+	f.Skipped = nil
+	return nil
+}
+
+// --- [ Symbol ] --------------------------------------------------------------
+
+// MarshalJSON returns the JSON encoding of the symbol; a JSON object of the
+// value of the symbol header, and the kind discriminator and fields of the
+// symbol body (e.g. `{"value":2147549184,"kind":"name2","name":"main"}`).
+//
+// Bodies of symbol kinds registered using RegisterKind are required to be
+// encoded as JSON objects, and should include a kind discriminator.
+func (sym *Symbol) MarshalJSON() ([]byte, error) {
+	body, err := json.Marshal(sym.Body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(body) < 2 || body[0] != '{' {
+		return nil, errors.Errorf("invalid JSON encoding of %T symbol body; expected object, got %s", sym.Body, body)
+	}
+	buf := &bytes.Buffer{}
+	buf.WriteString(`{"value":`)
+	value, err := json.Marshal(sym.Hdr.Value)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	buf.Write(value)
+	// Inline fields of symbol body.
+	if !bytes.Equal(body, []byte("{}")) {
+		buf.WriteString(",")
+	}
+	buf.Write(body[1:])
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes the JSON encoding of a symbol. The kind of the symbol
+// header is derived from the kind discriminator of the symbol body.
+func (sym *Symbol) UnmarshalJSON(data []byte) error {
+	var v struct {
+		// Address or value of symbol.
+		Value uint32 `json:"value"`
+		// Kind discriminator of symbol body.
+		Kind string `json:"kind"`
+		// Symbol kind of unknown symbol body.
+		Code Kind `json:"code"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.WithStack(err)
+	}
+	var body SymbolBody
+	kind, ok := lookupKindName(v.Kind)
+	switch {
+	case v.Kind == kindUnknown:
+		kind = v.Code
+		body = &UnknownBody{}
+	case ok:
+		body = newBody(kind)
+	default:
+		return errors.Errorf("invalid kind discriminator %q of symbol", v.Kind)
+	}
+	if err := json.Unmarshal(data, body); err != nil {
+		return errors.Wrapf(err, "unable to decode %v symbol body", kind)
+	}
+	sym.Hdr = &SymbolHeader{
+		Value: v.Value,
+		Kind:  kind,
+	}
+	sym.Body = body
+	sym.Raw = nil
+	return nil
+}
+
+// --- [ Symbol bodies ] -------------------------------------------------------
+
+// MarshalJSON returns the JSON encoding of the name symbol.
+func (body *Name1) MarshalJSON() ([]byte, error) {
+	type name1 Name1
+	return marshalBody(KindName1, (*name1)(body))
+}
+
+// UnmarshalJSON decodes the JSON encoding of the name symbol.
+func (body *Name1) UnmarshalJSON(data []byte) error {
+	type name1 Name1
+	if err := json.Unmarshal(data, (*name1)(body)); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := setLen(&body.NameLen, body.Name); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// MarshalJSON returns the JSON encoding of the name symbol.
+func (body *Name2) MarshalJSON() ([]byte, error) {
+	type name2 Name2
+	return marshalBody(KindName2, (*name2)(body))
+}
+
+// UnmarshalJSON decodes the JSON encoding of the name symbol.
+func (body *Name2) UnmarshalJSON(data []byte) error {
+	type name2 Name2
+	if err := json.Unmarshal(data, (*name2)(body)); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := setLen(&body.NameLen, body.Name); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// MarshalJSON returns the JSON encoding of the name symbol.
+func (body *Name5) MarshalJSON() ([]byte, error) {
+	type name5 Name5
+	return marshalBody(KindName5, (*name5)(body))
+}
+
+// UnmarshalJSON decodes the JSON encoding of the name symbol.
+func (body *Name5) UnmarshalJSON(data []byte) error {
+	type name5 Name5
+	if err := json.Unmarshal(data, (*name5)(body)); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := setLen(&body.NameLen, body.Name); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// MarshalJSON returns the JSON encoding of the name symbol.
+func (body *Name6) MarshalJSON() ([]byte, error) {
+	type name6 Name6
+	return marshalBody(KindName6, (*name6)(body))
+}
+
+// UnmarshalJSON decodes the JSON encoding of the name symbol.
+func (body *Name6) UnmarshalJSON(data []byte) error {
+	type name6 Name6
+	if err := json.Unmarshal(data, (*name6)(body)); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := setLen(&body.NameLen, body.Name); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// MarshalJSON returns the JSON encoding of the line number increment symbol.
+func (body *IncSLD) MarshalJSON() ([]byte, error) {
+	type incSLD IncSLD
+	return marshalBody(KindIncSLD, (*incSLD)(body))
+}
+
+// MarshalJSON returns the JSON encoding of the line number increment symbol.
+func (body *IncSLDByte) MarshalJSON() ([]byte, error) {
+	type incSLDByte IncSLDByte
+	return marshalBody(KindIncSLDByte, (*incSLDByte)(body))
+}
+
+// MarshalJSON returns the JSON encoding of the line number increment symbol.
+func (body *IncSLDWord) MarshalJSON() ([]byte, error) {
+	type incSLDWord IncSLDWord
+	return marshalBody(KindIncSLDWord, (*incSLDWord)(body))
+}
+
+// MarshalJSON returns the JSON encoding of the set line number symbol.
+func (body *SetSLD) MarshalJSON() ([]byte, error) {
+	type setSLD SetSLD
+	return marshalBody(KindSetSLD, (*setSLD)(body))
+}
+
+// MarshalJSON returns the JSON encoding of the set line number symbol.
+func (body *SetSLD2) MarshalJSON() ([]byte, error) {
+	type setSLD2 SetSLD2
+	return marshalBody(KindSetSLD2, (*setSLD2)(body))
+}
+
+// UnmarshalJSON decodes the JSON encoding of the set line number symbol.
+func (body *SetSLD2) UnmarshalJSON(data []byte) error {
+	type setSLD2 SetSLD2
+	if err := json.Unmarshal(data, (*setSLD2)(body)); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := setLen(&body.PathLen, body.Path); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// MarshalJSON returns the JSON encoding of the end of line number symbol.
+func (body *EndSLD) MarshalJSON() ([]byte, error) {
+	type endSLD EndSLD
+	return marshalBody(KindEndSLD, (*endSLD)(body))
+}
+
+// MarshalJSON returns the JSON encoding of the function start symbol.
+func (body *FuncStart) MarshalJSON() ([]byte, error) {
+	type funcStart FuncStart
+	return marshalBody(KindFuncStart, (*funcStart)(body))
+}
+
+// UnmarshalJSON decodes the JSON encoding of the function start symbol.
+func (body *FuncStart) UnmarshalJSON(data []byte) error {
+	type funcStart FuncStart
+	if err := json.Unmarshal(data, (*funcStart)(body)); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := setLen(&body.PathLen, body.Path); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := setLen(&body.NameLen, body.Name); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// MarshalJSON returns the JSON encoding of the function end symbol.
+func (body *FuncEnd) MarshalJSON() ([]byte, error) {
+	type funcEnd FuncEnd
+	return marshalBody(KindFuncEnd, (*funcEnd)(body))
+}
+
+// MarshalJSON returns the JSON encoding of the block start symbol.
+func (body *BlockStart) MarshalJSON() ([]byte, error) {
+	type blockStart BlockStart
+	return marshalBody(KindBlockStart, (*blockStart)(body))
+}
+
+// MarshalJSON returns the JSON encoding of the block end symbol.
+func (body *BlockEnd) MarshalJSON() ([]byte, error) {
+	type blockEnd BlockEnd
+	return marshalBody(KindBlockEnd, (*blockEnd)(body))
+}
+
+// MarshalJSON returns the JSON encoding of the definition symbol.
+func (body *Def) MarshalJSON() ([]byte, error) {
+	type def Def
+	return marshalBody(KindDef, (*def)(body))
+}
+
+// UnmarshalJSON decodes the JSON encoding of the definition symbol.
+func (body *Def) UnmarshalJSON(data []byte) error {
+	type def Def
+	if err := json.Unmarshal(data, (*def)(body)); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := setLen(&body.NameLen, body.Name); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// MarshalJSON returns the JSON encoding of the definition symbol.
+func (body *Def2) MarshalJSON() ([]byte, error) {
+	type def2 Def2
+	return marshalBody(KindDef2, (*def2)(body))
+}
+
+// UnmarshalJSON decodes the JSON encoding of the definition symbol.
+func (body *Def2) UnmarshalJSON(data []byte) error {
+	type def2 Def2
+	if err := json.Unmarshal(data, (*def2)(body)); err != nil {
+		return errors.WithStack(err)
+	}
+	if len(body.Dims) > maxDims {
+		return errors.Errorf("invalid dimensions length of Def2 symbol; expected <= %d, got %d", maxDims, len(body.Dims))
+	}
+	body.DimsLen = uint16(len(body.Dims))
+	if err := setLen(&body.TagLen, body.Tag); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := setLen(&body.NameLen, body.Name); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// MarshalJSON returns the JSON encoding of the overlay symbol.
+func (body *Overlay) MarshalJSON() ([]byte, error) {
+	type overlay Overlay
+	return marshalBody(KindOverlay, (*overlay)(body))
+}
+
+// MarshalJSON returns the JSON encoding of the set overlay symbol.
+func (body *SetOverlay) MarshalJSON() ([]byte, error) {
+	type setOverlay SetOverlay
+	return marshalBody(KindSetOverlay, (*setOverlay)(body))
+}
+
+// MarshalJSON returns the JSON encoding of the unknown symbol; the kind is
+// recorded as a numeric code and the raw contents are base64 encoded.
+func (body *UnknownBody) MarshalJSON() ([]byte, error) {
+	v := struct {
+		Kind string `json:"kind"`
+		Code Kind   `json:"code"`
+		Raw  []byte `json:"raw"`
+	}{
+		Kind: kindUnknown,
+		Code: body.Kind,
+		Raw:  body.Raw,
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes the JSON encoding of the unknown symbol.
+func (body *UnknownBody) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Code Kind   `json:"code"`
+		Raw  []byte `json:"raw"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.WithStack(err)
+	}
+	body.Kind = v.Code
+	body.Raw = v.Raw
+	return nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// marshalBody returns the JSON encoding of the given symbol body fields,
+// prefixed by the kind discriminator of the given symbol kind.
+func marshalBody(kind Kind, fields interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	name, err := json.Marshal(kindNames[kind])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	buf.WriteString(`{"kind":`)
+	buf.Write(name)
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if !bytes.Equal(b, []byte("{}")) {
+		buf.WriteString(",")
+	}
+	buf.Write(b[1:])
+	return buf.Bytes(), nil
+}
+
+// lookupKindName returns the built-in symbol kind of the given JSON name.
+func lookupKindName(name string) (Kind, bool) {
+	for kind, kindName := range kindNames {
+		if kindName == name {
+			return kind, true
+		}
+	}
+	return 0, false
+}
+
+// newBody returns a new symbol body of the given built-in symbol kind.
+func newBody(kind Kind) SymbolBody {
+	switch kind {
+	case KindName1:
+		return &Name1{}
+	case KindName2:
+		return &Name2{}
+	case KindName5:
+		return &Name5{}
+	case KindName6:
+		return &Name6{}
+	case KindIncSLD:
+		return &IncSLD{}
+	case KindIncSLDByte:
+		return &IncSLDByte{}
+	case KindIncSLDWord:
+		return &IncSLDWord{}
+	case KindSetSLD:
+		return &SetSLD{}
+	case KindSetSLD2:
+		return &SetSLD2{}
+	case KindEndSLD:
+		return &EndSLD{}
+	case KindFuncStart:
+		return &FuncStart{}
+	case KindFuncEnd:
+		return &FuncEnd{}
+	case KindBlockStart:
+		return &BlockStart{}
+	case KindBlockEnd:
+		return &BlockEnd{}
+	case KindDef:
+		return &Def{}
+	case KindDef2:
+		return &Def2{}
+	case KindOverlay:
+		return &Overlay{}
+	case KindSetOverlay:
+		return &SetOverlay{}
+	}
+	panic(errors.Errorf("support for symbol kind %v not yet implemented", kind))
+}
+
+// setLen sets the given length field to the length in bytes of the given name,
+// tag or path.
+func setLen(n *uint8, s string) error {
+	if len(s) > 0xFF {
+		return errors.Errorf("invalid length of %q; expected <= 255 bytes, got %d", s, len(s))
+	}
+	*n = uint8(len(s))
+	return nil
+}
@@ -0,0 +1,90 @@
+package sym_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/sanctuary/sym"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	f := &sym.File{
+		Hdr: &sym.FileHeader{
+			Signature: [3]byte{'M', 'N', 'D'},
+			Version:   1,
+		},
+		Order: binary.LittleEndian,
+		Syms: []*sym.Symbol{
+			{
+				Hdr:  &sym.SymbolHeader{Value: 0x80010000, Kind: sym.KindName2},
+				Body: &sym.Name2{NameLen: 4, Name: "main"},
+			},
+			{
+				Hdr:  &sym.SymbolHeader{Value: 0x80010004, Kind: sym.KindIncSLD},
+				Body: &sym.IncSLD{},
+			},
+			{
+				Hdr:  &sym.SymbolHeader{Value: 0, Kind: sym.KindDef2},
+				Body: &sym.Def2{Class: sym.ClassMOS, Type: 0x34, Size: 8, DimsLen: 1, Dims: []uint32{2}, NameLen: 1, Name: "x"},
+			},
+			{
+				Hdr:  &sym.SymbolHeader{Value: 0x80010008, Kind: 0xA0},
+				Body: &sym.UnknownBody{Kind: 0xA0, Raw: []byte{1, 2, 3}},
+			},
+		},
+	}
+	got, err := json.Marshal(f)
+	if err != nil {
+		t.Fatalf("unable to marshal file; %+v", err)
+	}
+	want := `{"signature":"MND","version":1,"target_unit":0,"order":"little","symbols":[` +
+		`{"value":2147549184,"kind":"name2","name":"main"},` +
+		`{"value":2147549188,"kind":"inc_sld"},` +
+		`{"value":0,"kind":"def2","class":8,"type":52,"size":8,"dims":[2],"tag":"","name":"x"},` +
+		`{"value":2147549192,"kind":"unknown","code":160,"raw":"AQID"}]}`
+	if string(got) != want {
+		t.Errorf("JSON mismatch; expected %s, got %s", want, got)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	// Hand-edited JSON encoding; length fields are derived from the names.
+	const input = `{"signature":"MND","version":1,"target_unit":0,"order":"little","symbols":[
+		{"value":2147549184,"kind":"name2","name":"main_renamed"},
+		{"value":2147549184,"kind":"func_start","fp":29,"fsize":24,"retreg":31,"mask":2147483648,"mask_offset":-8,"line":88,"path":"MAIN.C","name":"main_renamed"},
+		{"value":2147549188,"kind":"set_sld","line":88},
+		{"value":2147549188,"kind":"inc_sld"},
+		{"value":0,"kind":"def2","class":8,"type":52,"size":8,"dims":[2],"tag":"","name":"x"},
+		{"value":2147549192,"kind":"func_end","line":91},
+		{"value":2147549192,"kind":"unknown","code":160,"raw":"AQID"}]}`
+	f := &sym.File{}
+	if err := json.Unmarshal([]byte(input), f); err != nil {
+		t.Fatalf("unable to unmarshal file; %+v", err)
+	}
+	// Re-encode to binary SYM file and parse.
+	buf := &bytes.Buffer{}
+	if err := f.Write(buf); err != nil {
+		t.Fatalf("unable to write file; %+v", err)
+	}
+	g, err := sym.ParseBytesWithOptions(buf.Bytes(), &sym.ParseOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("unable to parse file; %+v", err)
+	}
+	if want, got := f.String(), g.String(); want != got {
+		t.Errorf("output mismatch; expected %q, got %q", want, got)
+	}
+	// Round-trip through JSON.
+	output, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("unable to marshal file; %+v", err)
+	}
+	h := &sym.File{}
+	if err := json.Unmarshal(output, h); err != nil {
+		t.Fatalf("unable to unmarshal file; %+v", err)
+	}
+	if want, got := f.String(), h.String(); want != got {
+		t.Errorf("output mismatch; expected %q, got %q", want, got)
+	}
+}
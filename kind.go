@@ -1,28 +1,163 @@
 package sym
 
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+
+	"github.com/lunixbochs/struc"
+	"github.com/pkg/errors"
+)
+
 //go:generate stringer -linecomment -type Kind
 
 // Kind specifies the kind of a symbol.
 type Kind uint8
 
 // Symbol kinds.
+//
+// The string representation of each kind matches the output of the DUMPSYM.EXE
+// tool of the Psy-Q SDK, as does the DUMPSYM terminology used in the comments.
 const (
-	KindName1      Kind = 0x01 // 1
-	KindName2      Kind = 0x02 // 2
-	KindName5      Kind = 0x05 // 5
-	KindName6      Kind = 0x06 // 6
-	KindIncSLD     Kind = 0x80 // 80
+	// Symbol name; e.g. "$00000000 1 __RHS2_data_size".
+	KindName1 Kind = 0x01 // 1
+	// Symbol name; e.g. "$80010000 2 printattribute".
+	KindName2 Kind = 0x02 // 2
+	// Symbol name; e.g. "$00000000 5 m".
+	KindName5 Kind = 0x05 // 5
+	// Symbol name; e.g. "$00010604 6 DoTitle".
+	KindName6 Kind = 0x06 // 6
+	// Inc SLD linenum.
+	KindIncSLD Kind = 0x80 // 80
+	// Inc SLD linenum by byte.
 	KindIncSLDByte Kind = 0x82 // 82
+	// Inc SLD linenum by word.
 	KindIncSLDWord Kind = 0x84 // 84
-	KindSetSLD     Kind = 0x86 // 86
-	KindSetSLD2    Kind = 0x88 // 88
-	KindEndSLD     Kind = 0x8A // 8a
-	KindFuncStart  Kind = 0x8C // 8c
-	KindFuncEnd    Kind = 0x8E // 8e
+	// Set SLD linenum.
+	KindSetSLD Kind = 0x86 // 86
+	// Set SLD to line of file.
+	KindSetSLD2 Kind = 0x88 // 88
+	// End SLD info.
+	KindEndSLD Kind = 0x8A // 8a
+	// Function_start.
+	KindFuncStart Kind = 0x8C // 8c
+	// Function_end.
+	KindFuncEnd Kind = 0x8E // 8e
+	// Block_start.
 	KindBlockStart Kind = 0x90 // 90
-	KindBlockEnd   Kind = 0x92 // 92
-	KindDef        Kind = 0x94 // 94
-	KindDef2       Kind = 0x96 // 96
-	KindOverlay    Kind = 0x98 // overlay
+	// Block_end.
+	KindBlockEnd Kind = 0x92 // 92
+	// Def.
+	KindDef Kind = 0x94 // 94
+	// Def2.
+	KindDef2 Kind = 0x96 // 96
+	// Overlay definition.
+	KindOverlay Kind = 0x98 // overlay
+	// Set overlay.
 	KindSetOverlay Kind = 0x9A // set overlay
 )
+
+// Kinds lists the built-in symbol kinds, in ascending order.
+var Kinds = []Kind{
+	KindName1,
+	KindName2,
+	KindName5,
+	KindName6,
+	KindIncSLD,
+	KindIncSLDByte,
+	KindIncSLDWord,
+	KindSetSLD,
+	KindSetSLD2,
+	KindEndSLD,
+	KindFuncStart,
+	KindFuncEnd,
+	KindBlockStart,
+	KindBlockEnd,
+	KindDef,
+	KindDef2,
+	KindOverlay,
+	KindSetOverlay,
+}
+
+// isKnownKind reports whether the given symbol kind is known; i.e. whether a
+// parse function has been registered for the kind.
+func isKnownKind(kind Kind) bool {
+	_, ok := lookupKind(kind)
+	return ok
+}
+
+// A ParseFunc parses and returns the body of a symbol, reading from r and
+// using the given byte order for multi-byte fields.
+type ParseFunc func(r io.Reader, order binary.ByteOrder) (SymbolBody, error)
+
+var (
+	// parsersMu protects parsers.
+	parsersMu sync.RWMutex
+	// parsers maps from symbol kind to parse function of symbol body.
+	parsers = make(map[Kind]ParseFunc)
+)
+
+// RegisterKind registers the parse function of symbols of the given kind,
+// replacing any previously registered parse function of the kind.
+//
+// RegisterKind may be used to decode vendor- or game-specific symbol kinds.
+func RegisterKind(kind Kind, parse ParseFunc) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	parsers[kind] = parse
+}
+
+// UnregisterKind removes the parse function registered for symbols of the given
+// kind, as registered by RegisterKind.
+func UnregisterKind(kind Kind) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	delete(parsers, kind)
+}
+
+// lookupKind returns the parse function registered for the given symbol kind.
+func lookupKind(kind Kind) (ParseFunc, bool) {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+	parse, ok := parsers[kind]
+	return parse, ok
+}
+
+// Register parse functions of built-in symbol kinds.
+func init() {
+	// unpack returns a parse function which unpacks symbol bodies allocated by
+	// newBody.
+	unpack := func(newBody func() SymbolBody) ParseFunc {
+		return func(r io.Reader, order binary.ByteOrder) (SymbolBody, error) {
+			body := newBody()
+			if err := struc.UnpackWithOrder(r, body, order); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			return body, nil
+		}
+	}
+	// empty returns a parse function of symbol bodies without contents.
+	empty := func(newBody func() SymbolBody) ParseFunc {
+		return func(r io.Reader, order binary.ByteOrder) (SymbolBody, error) {
+			return newBody(), nil
+		}
+	}
+	RegisterKind(KindName1, unpack(func() SymbolBody { return &Name1{} }))
+	RegisterKind(KindName2, unpack(func() SymbolBody { return &Name2{} }))
+	RegisterKind(KindName5, unpack(func() SymbolBody { return &Name5{} }))
+	RegisterKind(KindName6, unpack(func() SymbolBody { return &Name6{} }))
+	RegisterKind(KindIncSLD, empty(func() SymbolBody { return &IncSLD{} }))
+	RegisterKind(KindIncSLDByte, unpack(func() SymbolBody { return &IncSLDByte{} }))
+	RegisterKind(KindIncSLDWord, unpack(func() SymbolBody { return &IncSLDWord{} }))
+	RegisterKind(KindSetSLD, unpack(func() SymbolBody { return &SetSLD{} }))
+	RegisterKind(KindSetSLD2, unpack(func() SymbolBody { return &SetSLD2{} }))
+	RegisterKind(KindEndSLD, empty(func() SymbolBody { return &EndSLD{} }))
+	RegisterKind(KindFuncStart, unpack(func() SymbolBody { return &FuncStart{} }))
+	RegisterKind(KindFuncEnd, unpack(func() SymbolBody { return &FuncEnd{} }))
+	RegisterKind(KindBlockStart, unpack(func() SymbolBody { return &BlockStart{} }))
+	RegisterKind(KindBlockEnd, unpack(func() SymbolBody { return &BlockEnd{} }))
+	RegisterKind(KindDef, unpack(func() SymbolBody { return &Def{} }))
+	RegisterKind(KindDef2, parseDef2)
+	RegisterKind(KindOverlay, unpack(func() SymbolBody { return &Overlay{} }))
+	RegisterKind(KindSetOverlay, empty(func() SymbolBody { return &SetOverlay{} }))
+}
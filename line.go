@@ -0,0 +1,223 @@
+package sym
+
+import (
+	"sort"
+)
+
+// A LineTable maps between addresses and line numbers of source files, as
+// reconstructed by replaying the line number symbols of a symbol file.
+type LineTable struct {
+	// Line table entries, sorted by overlay ID and address.
+	Entries []*LineEntry
+}
+
+// A LineEntry associates an address with a line number of a source file.
+type LineEntry struct {
+	// Address.
+	Addr uint32
+	// Source file.
+	Path string
+	// Line number.
+	Line uint32
+	// ID of the overlay containing the address (0 for the default binary).
+	Overlay uint32
+	// End specifies whether the entry marks the end of a line number sequence,
+	// in which case addresses from Addr onwards are not associated with any
+	// line number.
+	End bool
+}
+
+// NewLineTable returns a new line table, reconstructed by replaying the line
+// number, function and block symbols of the given symbols.
+func NewLineTable(syms []*Symbol) *LineTable {
+	t := &LineTable{}
+	var (
+		// Current overlay ID.
+		overlay uint32
+		// Current source file.
+		path string
+		// Current line number.
+		line uint32
+		// Start line number of the current function.
+		funcLine uint32
+	)
+	add := func(addr uint32, end bool) {
+		entry := &LineEntry{
+			Addr:    addr,
+			Path:    path,
+			Line:    line,
+			Overlay: overlay,
+			End:     end,
+		}
+		t.Entries = append(t.Entries, entry)
+	}
+	for _, s := range syms {
+		addr := s.Hdr.Value
+		switch body := s.Body.(type) {
+		case *IncSLD:
+			line++
+			add(addr, false)
+		case *IncSLDByte:
+			line += uint32(body.Inc)
+			add(addr, false)
+		case *IncSLDWord:
+			line += uint32(body.Inc)
+			add(addr, false)
+		case *SetSLD:
+			line = body.Line
+			add(addr, false)
+		case *SetSLD2:
+			path = body.Path
+			line = body.Line
+			add(addr, false)
+		case *EndSLD:
+			add(addr, true)
+		case *FuncStart:
+			path = body.Path
+			line = body.Line
+			funcLine = body.Line
+			add(addr, false)
+		case *FuncEnd:
+			line = body.Line
+			add(addr, false)
+		case *BlockStart:
+			// Block line numbers are relative to the start of the function.
+			line = blockLine(funcLine, body.Line)
+			add(addr, false)
+		case *BlockEnd:
+			line = blockLine(funcLine, body.Line)
+			add(addr, false)
+		case *SetOverlay:
+			overlay = addr
+		}
+	}
+	less := func(i, j int) bool {
+		if t.Entries[i].Overlay != t.Entries[j].Overlay {
+			return t.Entries[i].Overlay < t.Entries[j].Overlay
+		}
+		return t.Entries[i].Addr < t.Entries[j].Addr
+	}
+	sort.SliceStable(t.Entries, less)
+	return t
+}
+
+// Lookup returns the source file and line number associated with the given
+// address of the default binary. The boolean return value indicates success.
+func (t *LineTable) Lookup(addr uint32) (path string, line uint32, ok bool) {
+	return t.LookupOverlay(0, addr)
+}
+
+// LookupOverlay returns the source file and line number associated with the
+// given address of the specified overlay. The boolean return value indicates
+// success.
+func (t *LineTable) LookupOverlay(overlay, addr uint32) (path string, line uint32, ok bool) {
+	// Locate the first entry succeeding the address.
+	i := sort.Search(len(t.Entries), func(i int) bool {
+		e := t.Entries[i]
+		if e.Overlay != overlay {
+			return e.Overlay > overlay
+		}
+		return e.Addr > addr
+	})
+	if i == 0 {
+		return "", 0, false
+	}
+	e := t.Entries[i-1]
+	if e.Overlay != overlay || e.End {
+		return "", 0, false
+	}
+	return e.Path, e.Line, true
+}
+
+// Lines returns the line table entries of the given source file, sorted by
+// overlay ID and address.
+func (t *LineTable) Lines(path string) []*LineEntry {
+	var entries []*LineEntry
+	for _, e := range t.Entries {
+		if e.Path == path && !e.End {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// A SourceFile is a source file referenced by the line number symbols of a
+// symbol file.
+type SourceFile struct {
+	// Source file path.
+	Path string
+	// Address ranges of code generated from the source file, sorted by overlay
+	// ID and address.
+	Ranges []AddrRange
+}
+
+// An AddrRange is a range of addresses.
+type AddrRange struct {
+	// ID of the overlay containing the address range (0 for the default
+	// binary).
+	Overlay uint32
+	// Start address.
+	Start uint32
+	// End address (exclusive).
+	End uint32
+}
+
+// blockLine returns the absolute line number of the given block line number,
+// relative to the start line of the function. Block line numbers are 1-based;
+// the invalid block line number 0 maps to the start line of the function.
+func blockLine(funcLine, line uint32) uint32 {
+	if line == 0 {
+		return funcLine
+	}
+	return funcLine + line - 1
+}
+
+// SourceFiles returns the distinct source files referenced by the line number
+// symbols of the file, in order of address.
+//
+// The address range of each line number entry extends up until the succeeding
+// entry; the last entry of an overlay is assumed to cover a single instruction.
+func (f *File) SourceFiles() []*SourceFile {
+	// Size of a MIPS instruction in bytes.
+	const instSize = 4
+	t := NewLineTable(f.Syms)
+	var srcs []*SourceFile
+	// sources maps from source path to source file.
+	sources := make(map[string]*SourceFile)
+	for i, e := range t.Entries {
+		if e.End {
+			continue
+		}
+		end := e.Addr + instSize
+		if i+1 < len(t.Entries) && t.Entries[i+1].Overlay == e.Overlay {
+			end = t.Entries[i+1].Addr
+		}
+		if end == e.Addr {
+			// Skip empty address range.
+			continue
+		}
+		src, ok := sources[e.Path]
+		if !ok {
+			src = &SourceFile{
+				Path: e.Path,
+			}
+			sources[e.Path] = src
+			srcs = append(srcs, src)
+		}
+		// Extend preceding address range if contiguous.
+		if n := len(src.Ranges); n > 0 {
+			prev := &src.Ranges[n-1]
+			if prev.Overlay == e.Overlay && prev.End == e.Addr {
+				prev.End = end
+				continue
+			}
+		}
+		r := AddrRange{
+			Overlay: e.Overlay,
+			Start:   e.Addr,
+			End:     end,
+		}
+		src.Ranges = append(src.Ranges, r)
+	}
+	return srcs
+}
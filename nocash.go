@@ -0,0 +1,147 @@
+package sym
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ParseNocashFile parses the given no$psx symbol file.
+func ParseNocashFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	return ParseNocash(f)
+}
+
+// ParseNocash parses the given no$psx symbol file, reading from r, into a
+// symbol file of equivalent symbols.
+//
+// no$psx symbol files are text files, where each line specifies an address in
+// hexadecimal followed by a symbol name or data directive. Named addresses are
+// converted to Name2 symbols. Named addresses with a data directive of their
+// element size and length in bytes (.byt for bytes, .wrd for 16-bit words and
+// .dbl for 32-bit words) are converted to Def2 symbols of global variables of
+// unsigned integer type, or array thereof if of more than one element. Other
+// data directives, empty lines and comments (starting with ';') are ignored.
+func ParseNocash(r io.Reader) (*File, error) {
+	// named is a named address of the symbol file.
+	type named struct {
+		addr uint32
+		name string
+	}
+	// directive is a data directive of the symbol file.
+	type directive struct {
+		base Base
+		size uint32
+	}
+	var names []named
+	directives := make(map[uint32]directive)
+	s := bufio.NewScanner(r)
+	for lineNr := 1; s.Scan(); lineNr++ {
+		line := s.Text()
+		if pos := strings.IndexByte(line, ';'); pos != -1 {
+			line = line[:pos]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, errors.Errorf("invalid no$psx symbol on line %d; expected address and name, got %q", lineNr, line)
+		}
+		addr, err := strconv.ParseUint(fields[0], 16, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid address of no$psx symbol on line %d", lineNr)
+		}
+		text := fields[1]
+		if !strings.HasPrefix(text, ".") {
+			names = append(names, named{addr: uint32(addr), name: text})
+			continue
+		}
+		// Data directive.
+		parts := strings.SplitN(text, ":", 2)
+		var base Base
+		switch parts[0] {
+		case ".byt":
+			base = BaseUChar
+		case ".wrd":
+			base = BaseUShort
+		case ".dbl":
+			base = BaseULong
+		default:
+			// Ignore other data directives (e.g. .asc).
+			continue
+		}
+		if len(parts) != 2 {
+			return nil, errors.Errorf("invalid no$psx data directive on line %d; missing length in %q", lineNr, text)
+		}
+		size, err := strconv.ParseUint(parts[1], 16, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid length of no$psx data directive on line %d", lineNr)
+		}
+		directives[uint32(addr)] = directive{base: base, size: uint32(size)}
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	f := NewFile(nil)
+	for _, n := range names {
+		d, ok := directives[n.addr]
+		if !ok {
+			body := &Name2{Name: n.name}
+			if err := setLen(&body.NameLen, body.Name); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			s := &Symbol{
+				Hdr:  &SymbolHeader{Value: n.addr, Kind: KindName2},
+				Body: body,
+			}
+			f.Syms = append(f.Syms, s)
+			continue
+		}
+		// Only the first name of an address is given the data directive.
+		delete(directives, n.addr)
+		body := &Def2{
+			Class: ClassEXT,
+			Type:  Type(d.base),
+			Size:  d.size,
+			Name:  n.name,
+		}
+		if elemSize := nocashElemSize(d.base); d.size != elemSize {
+			body.Type = Type(ModArray)<<4 | Type(d.base)
+			body.Dims = []uint32{d.size / elemSize}
+		}
+		body.DimsLen = uint16(len(body.Dims))
+		if err := setLen(&body.NameLen, body.Name); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		s := &Symbol{
+			Hdr:  &SymbolHeader{Value: n.addr, Kind: KindDef2},
+			Body: body,
+		}
+		f.Syms = append(f.Syms, s)
+	}
+	return f, nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// nocashElemSize returns the size in bytes of the element type of the given
+// no$psx data directive.
+func nocashElemSize(base Base) uint32 {
+	switch base {
+	case BaseUShort:
+		return 2
+	case BaseULong:
+		return 4
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,84 @@
+package sym_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sanctuary/sym"
+)
+
+func TestParseNocash(t *testing.T) {
+	const input = `
+; Labels of main binary.
+80010000 main
+80020000 .byt:0010
+80020000 buf
+80020010 counter
+80020010 .dbl:0004
+80020014 msg
+80020014 .asc:000C
+`
+	f, err := sym.ParseNocash(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unable to parse no$psx symbol file; %+v", err)
+	}
+	golden := []struct {
+		addr uint32
+		kind sym.Kind
+		name string
+		typ  sym.Type
+		size uint32
+		dims []uint32
+	}{
+		{addr: 0x80010000, kind: sym.KindName2, name: "main"},
+		{addr: 0x80020000, kind: sym.KindDef2, name: "buf", typ: 0x3C, size: 0x10, dims: []uint32{0x10}},
+		{addr: 0x80020010, kind: sym.KindDef2, name: "counter", typ: 0x0F, size: 4},
+		{addr: 0x80020014, kind: sym.KindName2, name: "msg"},
+	}
+	if len(f.Syms) != len(golden) {
+		t.Fatalf("number of symbols mismatch; expected %d, got %d (%v)", len(golden), len(f.Syms), f.Syms)
+	}
+	for i, g := range golden {
+		s := f.Syms[i]
+		if s.Hdr.Value != g.addr || s.Hdr.Kind != g.kind {
+			t.Errorf("symbol %d mismatch; expected %v at 0x%08X, got %v", i, g.kind, g.addr, s)
+			continue
+		}
+		switch body := s.Body.(type) {
+		case *sym.Name2:
+			if body.Name != g.name || int(body.NameLen) != len(g.name) {
+				t.Errorf("symbol %d name mismatch; expected %q, got %q", i, g.name, body.Name)
+			}
+		case *sym.Def2:
+			if body.Name != g.name || body.Class != sym.ClassEXT || body.Type != g.typ || body.Size != g.size || !equalDims(body.Dims, g.dims) {
+				t.Errorf("symbol %d mismatch; expected %s of type %v, size %d and dims %v, got %v", i, g.name, g.typ, g.size, g.dims, body)
+			}
+		}
+	}
+}
+
+func TestParseNocashInvalid(t *testing.T) {
+	inputs := []string{
+		"main\n",
+		"8001000G main\n",
+		"80020000 .byt\n",
+	}
+	for _, input := range inputs {
+		if _, err := sym.ParseNocash(strings.NewReader(input)); err == nil {
+			t.Errorf("expected error for no$psx symbol file %q", input)
+		}
+	}
+}
+
+// equalDims reports whether the given array dimensions are equal.
+func equalDims(a, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,434 @@
+package sym_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/rand"
+	"runtime"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym"
+	"github.com/sanctuary/sym/csym"
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestParseLenient(t *testing.T) {
+	// Symbol of unknown kind 0xA0 followed by a name symbol.
+	b := newSymFile()
+	unknown := appendHeader(nil, 0x80010000, 0xA0)
+	unknown = append(unknown, 0xDE, 0xAD, 0xBE)
+	b = append(b, unknown...)
+	b = appendName(b, 0x80010004, "main")
+	if _, err := sym.ParseBytes(b); err == nil {
+		t.Errorf("expected error when parsing unknown symbol kind in strict mode")
+	}
+	opts := &sym.ParseOptions{
+		Lenient: true,
+	}
+	f, err := sym.ParseBytesWithOptions(b, opts)
+	if err != nil {
+		t.Fatalf("unable to parse symbol file in lenient mode; %v", err)
+	}
+	if len(f.Syms) != 2 {
+		t.Fatalf("number of symbols mismatch; expected 2, got %d", len(f.Syms))
+	}
+	body, ok := f.Syms[0].Body.(*sym.UnknownBody)
+	if !ok {
+		t.Fatalf("invalid body type of first symbol; expected *sym.UnknownBody, got %T", f.Syms[0].Body)
+	}
+	if want := []byte{0xDE, 0xAD, 0xBE}; !bytes.Equal(body.Raw, want) {
+		t.Errorf("raw body mismatch; expected % x, got % x", want, body.Raw)
+	}
+	name, ok := f.Syms[1].Body.(*sym.Name2)
+	if !ok || name.Name != "main" {
+		t.Errorf("invalid second symbol; expected name symbol of main, got %v", f.Syms[1])
+	}
+}
+
+func TestParseRecover(t *testing.T) {
+	// Corrupted symbol followed by a function end symbol.
+	b := newSymFile()
+	b = appendName(b, 0x80010000, "main")
+	corrupt := appendHeader(nil, 0x12345678, 0xFF)
+	corrupt = append(corrupt, 0x01, 0x02, 0x03, 0x04)
+	b = append(b, corrupt...)
+	b = appendHeader(b, 0x80010010, sym.KindFuncEnd)
+	b = append(b, 42, 0, 0, 0)
+	opts := &sym.ParseOptions{
+		Recover: true,
+	}
+	f, err := sym.ParseBytesWithOptions(b, opts)
+	if err != nil {
+		t.Fatalf("unable to parse symbol file in recovery mode; %v", err)
+	}
+	if len(f.Syms) != 2 {
+		t.Fatalf("number of symbols mismatch; expected 2, got %d", len(f.Syms))
+	}
+	if len(f.Skipped) != 1 {
+		t.Fatalf("number of skipped regions mismatch; expected 1, got %d", len(f.Skipped))
+	}
+	region := f.Skipped[0]
+	if want := len(newSymFile()) + 5 + 1 + len("main"); region.Offset != want {
+		t.Errorf("offset of skipped region mismatch; expected %d, got %d", want, region.Offset)
+	}
+	if want := len(corrupt); region.Size != want {
+		t.Errorf("size of skipped region mismatch; expected %d, got %d", want, region.Size)
+	}
+	body, ok := f.Syms[1].Body.(*sym.FuncEnd)
+	if !ok || body.Line != 42 {
+		t.Errorf("invalid second symbol; expected function end at line 42, got %v", f.Syms[1])
+	}
+}
+
+func TestParseTruncated(t *testing.T) {
+	// Function end symbol cut short.
+	b := newSymFile()
+	b = appendName(b, 0x80010000, "main")
+	b = appendHeader(b, 0x80010010, sym.KindFuncEnd)
+	b = append(b, 42, 0)
+	f, err := sym.ParseBytes(b)
+	if errors.Cause(err) != sym.ErrTruncated {
+		t.Fatalf("error mismatch; expected %v, got %v", sym.ErrTruncated, err)
+	}
+	if f == nil || len(f.Syms) != 1 {
+		t.Fatalf("expected partial result of 1 symbol, got %v", f)
+	}
+}
+
+// A customBody is the body of a custom symbol kind, holding a 16-bit value.
+type customBody struct {
+	x uint16
+}
+
+func (body *customBody) String() string { return "custom" }
+func (body *customBody) BodySize() int  { return 2 }
+
+func TestRegisterKind(t *testing.T) {
+	const kindCustom sym.Kind = 0xB0
+	parse := func(r io.Reader, order binary.ByteOrder) (sym.SymbolBody, error) {
+		var buf [2]byte
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return nil, err
+		}
+		return &customBody{x: order.Uint16(buf[:])}, nil
+	}
+	sym.RegisterKind(kindCustom, parse)
+	t.Cleanup(func() { sym.UnregisterKind(kindCustom) })
+	b := newSymFile()
+	b = appendHeader(b, 0, kindCustom)
+	b = append(b, 0x34, 0x12)
+	b = appendName(b, 0x80010000, "main")
+	f, err := sym.ParseBytes(b)
+	if err != nil {
+		t.Fatalf("unable to parse symbol file with custom symbol kind; %v", err)
+	}
+	if len(f.Syms) != 2 {
+		t.Fatalf("number of symbols mismatch; expected 2, got %d", len(f.Syms))
+	}
+	body, ok := f.Syms[0].Body.(*customBody)
+	if !ok || body.x != 0x1234 {
+		t.Errorf("invalid first symbol; expected custom symbol with value 0x1234, got %v", f.Syms[0])
+	}
+}
+
+func TestParseEncoding(t *testing.T) {
+	// "メイン" in Shift-JIS.
+	raw := "\x83\x81\x83\x43\x83\x93"
+	b := newSymFile()
+	b = appendName(b, 0x80010000, raw)
+	opts := &sym.ParseOptions{
+		Encoding: japanese.ShiftJIS,
+	}
+	f, err := sym.ParseBytesWithOptions(b, opts)
+	if err != nil {
+		t.Fatalf("unable to parse symbol file; %v", err)
+	}
+	if len(f.Syms) != 1 {
+		t.Fatalf("number of symbols mismatch; expected 1, got %d", len(f.Syms))
+	}
+	s := f.Syms[0]
+	if got, want := s.Body.(*sym.Name2).Name, "メイン"; got != want {
+		t.Errorf("decoded name mismatch; expected %q, got %q", want, got)
+	}
+	if want := b[len(newSymFile()):]; !bytes.Equal(s.Raw, want) {
+		t.Errorf("raw contents mismatch; expected % x, got % x", want, s.Raw)
+	}
+}
+
+func TestParseBigEndian(t *testing.T) {
+	// Function end symbol of N64 symbol file.
+	b := newSymFile()
+	b = append(b, 0x80, 0x01, 0x00, 0x10, byte(sym.KindFuncEnd))
+	b = append(b, 0, 0, 0, 42)
+	f, err := sym.ParseBytes(b)
+	if err != nil {
+		t.Fatalf("unable to parse big-endian symbol file; %v", err)
+	}
+	if f.Order != binary.BigEndian {
+		t.Errorf("byte order mismatch; expected %v, got %v", binary.BigEndian, f.Order)
+	}
+	if len(f.Syms) != 1 {
+		t.Fatalf("number of symbols mismatch; expected 1, got %d", len(f.Syms))
+	}
+	s := f.Syms[0]
+	if s.Hdr.Value != 0x80010010 {
+		t.Errorf("address mismatch; expected 0x80010010, got 0x%08X", s.Hdr.Value)
+	}
+	if body, ok := s.Body.(*sym.FuncEnd); !ok || body.Line != 42 {
+		t.Errorf("invalid symbol; expected function end at line 42, got %v", s)
+	}
+}
+
+func TestParseSLD(t *testing.T) {
+	// Complete run of line number symbols.
+	b := newSymFile()
+	b = appendHeader(b, 0x80010000, sym.KindSetSLD2)
+	b = append(b, 115, 0, 0, 0)
+	b = append(b, byte(len("NULLFUNC.ASM")))
+	b = append(b, "NULLFUNC.ASM"...)
+	b = appendHeader(b, 0x80010004, sym.KindIncSLD)
+	b = appendHeader(b, 0x80010008, sym.KindIncSLDByte)
+	b = append(b, 2)
+	b = appendHeader(b, 0x8001000C, sym.KindIncSLDWord)
+	b = append(b, 0x14, 0x01)
+	b = appendHeader(b, 0x80010010, sym.KindSetSLD)
+	b = append(b, 88, 0, 0, 0)
+	b = appendHeader(b, 0x80010014, sym.KindEndSLD)
+	f, err := sym.ParseBytes(b)
+	if err != nil {
+		t.Fatalf("unable to parse line number symbols; %v", err)
+	}
+	const want = `
+Header : MND version 1
+Target unit 0
+000008: $80010000 88 Set SLD to line 115 of file NULLFUNC.ASM
+00001e: $80010004 80 Inc SLD linenum (to 116)
+000023: $80010008 82 Inc SLD linenum by byte 2 (to 118)
+000029: $8001000c 84 Inc SLD linenum by word 276 (to 394)
+000030: $80010010 86 Set SLD linenum to 88
+000039: $80010014 8a End SLD info
+`
+	if got := f.String(); got != want {
+		t.Errorf("output mismatch; expected %q, got %q", want, got)
+	}
+	const wantNoOffsets = `
+Header : MND version 1
+Target unit 0
+$80010000 88 Set SLD to line 115 of file NULLFUNC.ASM
+$80010004 80 Inc SLD linenum (to 116)
+$80010008 82 Inc SLD linenum by byte 2 (to 118)
+$8001000c 84 Inc SLD linenum by word 276 (to 394)
+$80010010 86 Set SLD linenum to 88
+$80010014 8a End SLD info
+`
+	if got := f.Dump(&sym.DumpOptions{NoOffsets: true}); got != wantNoOffsets {
+		t.Errorf("output mismatch without offsets; expected %q, got %q", wantNoOffsets, got)
+	}
+	// Reconstruct line table.
+	table := sym.NewLineTable(f.Syms)
+	lookups := []struct {
+		addr uint32
+		line uint32
+		ok   bool
+	}{
+		{addr: 0x80000000, ok: false},
+		{addr: 0x80010000, line: 115, ok: true},
+		{addr: 0x8001000A, line: 118, ok: true},
+		{addr: 0x80010010, line: 88, ok: true},
+		{addr: 0x80010014, ok: false},
+	}
+	for _, l := range lookups {
+		path, line, ok := table.Lookup(l.addr)
+		if ok != l.ok || line != l.line || (ok && path != "NULLFUNC.ASM") {
+			t.Errorf("line table lookup of 0x%08X mismatch; expected line %d (%v), got %s:%d (%v)", l.addr, l.line, l.ok, path, line, ok)
+		}
+	}
+	if got := len(table.Lines("NULLFUNC.ASM")); got != 5 {
+		t.Errorf("number of lines mismatch; expected 5, got %d", got)
+	}
+	// Collect source files.
+	srcs := f.SourceFiles()
+	if len(srcs) != 1 || srcs[0].Path != "NULLFUNC.ASM" {
+		t.Fatalf("source files mismatch; expected NULLFUNC.ASM, got %v", srcs)
+	}
+	wantRanges := []sym.AddrRange{{Start: 0x80010000, End: 0x80010014}}
+	if got := srcs[0].Ranges; len(got) != 1 || got[0] != wantRanges[0] {
+		t.Errorf("address ranges mismatch; expected %v, got %v", wantRanges, got)
+	}
+}
+
+func TestLineTableBlockLine(t *testing.T) {
+	// Block start symbol with invalid line number 0.
+	syms := []*sym.Symbol{
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010000, Kind: sym.KindFuncStart},
+			Body: &sym.FuncStart{Line: 10, PathLen: 6, Path: "MAIN.C", NameLen: 4, Name: "main"},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010008, Kind: sym.KindBlockStart},
+			Body: &sym.BlockStart{Line: 0},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80010010, Kind: sym.KindBlockEnd},
+			Body: &sym.BlockEnd{Line: 3},
+		},
+	}
+	table := sym.NewLineTable(syms)
+	for _, l := range []struct{ addr, line uint32 }{{0x80010008, 10}, {0x80010010, 12}} {
+		if _, line, ok := table.Lookup(l.addr); !ok || line != l.line {
+			t.Errorf("line table lookup of 0x%08X mismatch; expected line %d, got %d (%v)", l.addr, l.line, line, ok)
+		}
+	}
+}
+
+func TestParseReader(t *testing.T) {
+	// Valid symbols interleaved with pseudo-random corrupted regions, read one
+	// byte at a time.
+	rnd := rand.New(rand.NewSource(1))
+	b := newSymFile()
+	for i := 0; i < 16; i++ {
+		b = appendName(b, 0x80010000+uint32(i), "main")
+		garbage := make([]byte, 4096)
+		rnd.Read(garbage)
+		b = append(b, garbage...)
+	}
+	opts := &sym.ParseOptions{
+		Lenient: true,
+		Recover: true,
+	}
+	want, err := sym.ParseBytesWithOptions(b, opts)
+	if err != nil {
+		t.Fatalf("unable to parse symbol file; %v", err)
+	}
+	got, err := sym.ParseWithOptions(iotest.OneByteReader(bytes.NewReader(b)), opts)
+	if err != nil {
+		t.Fatalf("unable to parse symbol file from reader; %v", err)
+	}
+	if len(got.Syms) != len(want.Syms) {
+		t.Fatalf("number of symbols mismatch; expected %d, got %d", len(want.Syms), len(got.Syms))
+	}
+	for i := range got.Syms {
+		if g, w := got.Syms[i], want.Syms[i]; g.String() != w.String() || !bytes.Equal(g.Raw, w.Raw) {
+			t.Errorf("symbol %d mismatch; expected %v (% x), got %v (% x)", i, w, w.Raw, g, g.Raw)
+		}
+	}
+	if len(got.Skipped) != len(want.Skipped) {
+		t.Fatalf("number of skipped regions mismatch; expected %d, got %d", len(want.Skipped), len(got.Skipped))
+	}
+	for i := range got.Skipped {
+		if g, w := got.Skipped[i], want.Skipped[i]; g.Offset != w.Offset || g.Size != w.Size {
+			t.Errorf("skipped region %d mismatch; expected %v, got %v", i, w, g)
+		}
+	}
+	// Read errors are reported.
+	readErr := errors.New("read error")
+	r := io.MultiReader(bytes.NewReader(b[:len(newSymFile())+8]), iotest.ErrReader(readErr))
+	if _, err := sym.ParseWithOptions(r, opts); errors.Cause(err) != readErr {
+		t.Errorf("error mismatch; expected %v, got %v", readErr, err)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	b := newSymFile()
+	b = appendName(b, 0x80010000, "main")
+	b = appendHeader(b, 0x80010010, sym.KindFuncEnd)
+	b = append(b, 42, 0, 0, 0)
+	f.Add(b)
+	// Def2 symbol with excessive dimensions length.
+	b = newSymFile()
+	b = appendHeader(b, 0, sym.KindDef2)
+	b = append(b, 0x08, 0x00, 0x34, 0x00, 0x04, 0x00, 0x00, 0x00, 0xFF, 0xFF)
+	f.Add(b)
+	f.Fuzz(func(t *testing.T, b []byte) {
+		// Maximum duration of each input; the parser must not stall on inputs
+		// requiring resynchronization at every offset.
+		const timeout = 2 * time.Second
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			fuzzFile(b)
+		}()
+		select {
+		case <-done:
+		case <-time.After(timeout):
+			t.Fatalf("processing of %d byte input exceeded %v", len(b), timeout)
+		}
+	})
+}
+
+// ### [ Helper functions ] ####################################################
+
+// fuzzFile parses, validates, dumps and converts to C the given symbol file, as
+// exercised by FuzzParse.
+func fuzzFile(b []byte) {
+	opts := &sym.ParseOptions{
+		Lenient: true,
+		Recover: true,
+	}
+	f, err := sym.ParseBytesWithOptions(b, opts)
+	if err != nil {
+		return
+	}
+	diags := f.Validate()
+	// Line number increments before line number assignments are invalid in
+	// Psy-Q DUMPSYM.EXE format.
+	dump := true
+	for _, diag := range diags {
+		if diag.Code == sym.CodeLineIncrement {
+			dump = false
+		}
+	}
+	if dump {
+		f.Dump(nil)
+		f.Dump(&sym.DumpOptions{NoOffsets: true})
+	}
+	// Translate definitions to C.
+	if t, err := csym.NewTranslator(f.Syms); err == nil {
+		for _, s := range f.Syms {
+			switch s.Body.(type) {
+			case *sym.Def, *sym.Def2:
+				t.Def(s)
+			}
+		}
+	}
+	// Parse C types and declarations. The parser panics on unsupported symbols,
+	// but runtime errors (e.g. index out of range) are bugs.
+	defer func() {
+		if e := recover(); e != nil {
+			if _, ok := e.(runtime.Error); ok {
+				panic(e)
+			}
+		}
+	}()
+	p := csym.NewParser()
+	p.Dims = f.Dialect.Dims
+	p.ParseTypes(f.Syms)
+	p.Canonicalize()
+	p.ParseDecls(f.Syms)
+	p.ParsePrototypes(f.Syms)
+}
+
+// newSymFile returns the contents of a new SYM file containing only the file
+// header.
+func newSymFile() []byte {
+	return []byte{'M', 'N', 'D', 1, 0, 0, 0, 0}
+}
+
+// appendHeader appends a symbol header to b.
+func appendHeader(b []byte, value uint32, kind sym.Kind) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], value)
+	b = append(b, buf[:]...)
+	return append(b, byte(kind))
+}
+
+// appendName appends a Name2 symbol to b.
+func appendName(b []byte, addr uint32, name string) []byte {
+	b = appendHeader(b, addr, sym.KindName2)
+	b = append(b, byte(len(name)))
+	return append(b, name...)
+}
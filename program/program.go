@@ -0,0 +1,244 @@
+// Package program provides a high-level semantic model of Playstation 1 symbol
+// files.
+//
+// The model exposes the functions, global variables, types and overlays of a
+// program, as reconstructed from the raw symbol stream, with cross-references
+// between them resolved.
+package program
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/sanctuary/sym"
+	"github.com/sanctuary/sym/csym"
+	"github.com/sanctuary/sym/csym/c"
+)
+
+// A Program is a high-level semantic model of a symbol file.
+type Program struct {
+	// Functions of the program (including functions of overlays), in order of
+	// occurrence.
+	Functions []*Function
+	// Global variables of the program (including global variables of overlays),
+	// in order of occurrence.
+	Globals []*Global
+	// Type definitions of the program; structs, unions and enums followed by
+	// typedefs, in order of occurrence.
+	Types []c.Type
+	// Overlays of the program, in order of occurrence.
+	Overlays []*Overlay
+
+	// Parser holding type information.
+	p *csym.Parser
+	// funcNames maps from function name to the first function of the name.
+	funcNames map[string]*Function
+	// globalNames maps from variable name to the first global variable of the
+	// name.
+	globalNames map[string]*Global
+}
+
+// A Function is a function of a program.
+type Function struct {
+	// Function declaration, specifying the prototype of the function.
+	*c.FuncDecl
+	// Stack frame and scope information of the function.
+	Frame *sym.Function
+	// Overlay containing the function; nil for the default binary.
+	Overlay *Overlay
+}
+
+// A Global is a global variable of a program.
+type Global struct {
+	// Variable declaration, specifying the type of the global variable.
+	*c.VarDecl
+	// Overlay containing the global variable; nil for the default binary.
+	Overlay *Overlay
+}
+
+// An Overlay is an overlay of a program.
+type Overlay struct {
+	// Base address at which the overlay is loaded.
+	Addr uint32
+	// Overlay ID.
+	ID uint32
+	// Overlay length in bytes.
+	Length uint32
+	// Functions of the overlay.
+	Functions []*Function
+	// Global variables of the overlay.
+	Globals []*Global
+}
+
+// String returns the string representation of the overlay.
+func (overlay *Overlay) String() string {
+	return fmt.Sprintf("overlay %X (0x%08X)", overlay.ID, overlay.Addr)
+}
+
+// New returns the high-level semantic model of the given symbols.
+func New(syms []*sym.Symbol) (prog *Program, err error) {
+	// The C type parser panics on malformed symbol sequences; report as error.
+	defer func() {
+		if e := recover(); e != nil {
+			err = errors.Errorf("unable to reconstruct program; %v", e)
+		}
+	}()
+	prog = &Program{
+		p:           csym.NewParser(),
+		funcNames:   make(map[string]*Function),
+		globalNames: make(map[string]*Global),
+	}
+	prog.p.ParseTypes(syms)
+	prog.initTypes()
+	prog.initGlobals(syms)
+	prog.initFunctions(syms)
+	return prog, nil
+}
+
+// Func returns the function of the given name, or nil if not present. The
+// first function is returned if several functions share the same name (e.g.
+// static functions or functions of different overlays).
+func (prog *Program) Func(name string) *Function {
+	return prog.funcNames[name]
+}
+
+// Global returns the global variable of the given name, or nil if not present.
+// The first global variable is returned if several global variables share the
+// same name.
+func (prog *Program) Global(name string) *Global {
+	return prog.globalNames[name]
+}
+
+// Struct returns the struct type of the given tag, or nil if not present.
+func (prog *Program) Struct(tag string) *c.StructType {
+	return prog.p.Structs[tag]
+}
+
+// Union returns the union type of the given tag, or nil if not present.
+func (prog *Program) Union(tag string) *c.UnionType {
+	return prog.p.Unions[tag]
+}
+
+// Enum returns the enum type of the given tag, or nil if not present.
+func (prog *Program) Enum(tag string) *c.EnumType {
+	return prog.p.Enums[tag]
+}
+
+// Typedef returns the type definition of the given name, or nil if not
+// present.
+func (prog *Program) Typedef(name string) c.Type {
+	return prog.p.Types[name]
+}
+
+// initTypes records the type definitions of the program.
+func (prog *Program) initTypes() {
+	p := prog.p
+	for _, tag := range p.StructTags {
+		prog.Types = append(prog.Types, p.Structs[tag])
+	}
+	for _, tag := range p.UnionTags {
+		prog.Types = append(prog.Types, p.Unions[tag])
+	}
+	for _, tag := range p.EnumTags {
+		prog.Types = append(prog.Types, p.Enums[tag])
+	}
+	prog.Types = append(prog.Types, p.Typedefs...)
+}
+
+// initGlobals records the overlays and global variables of the given symbols.
+func (prog *Program) initGlobals(syms []*sym.Symbol) {
+	var (
+		// Current overlay; nil for the default binary.
+		overlay *Overlay
+		// Function nesting depth.
+		funcs int
+	)
+	for _, s := range syms {
+		switch body := s.Body.(type) {
+		case *sym.Overlay:
+			o := prog.overlay(body.ID)
+			o.Addr = s.Hdr.Value
+			o.Length = body.Length
+		case *sym.SetOverlay:
+			overlay = nil
+			if s.Hdr.Value != 0 {
+				overlay = prog.overlay(s.Hdr.Value)
+			}
+		case *sym.FuncStart:
+			funcs++
+		case *sym.FuncEnd:
+			if funcs > 0 {
+				funcs--
+			}
+		case *sym.Def:
+			if funcs == 0 {
+				prog.addGlobal(s, body.Class, overlay)
+			}
+		case *sym.Def2:
+			if funcs == 0 {
+				prog.addGlobal(s, body.Class, overlay)
+			}
+		}
+	}
+}
+
+// addGlobal records the global variable of the given definition symbol and
+// class, located in the specified overlay.
+func (prog *Program) addGlobal(s *sym.Symbol, class sym.Class, overlay *Overlay) {
+	switch class {
+	case sym.ClassEXT, sym.ClassSTAT:
+		// global variable or function.
+	default:
+		return
+	}
+	v := prog.p.ParseDef(s)
+	if _, ok := v.Type.(*c.FuncType); ok {
+		// Skip function definitions, as recorded by initFunctions.
+		return
+	}
+	g := &Global{
+		VarDecl: v,
+		Overlay: overlay,
+	}
+	prog.Globals = append(prog.Globals, g)
+	if overlay != nil {
+		overlay.Globals = append(overlay.Globals, g)
+	}
+	if _, ok := prog.globalNames[g.Name]; !ok {
+		prog.globalNames[g.Name] = g
+	}
+}
+
+// initFunctions records the functions of the given symbols.
+func (prog *Program) initFunctions(syms []*sym.Symbol) {
+	decls := prog.p.ParsePrototypes(syms)
+	// The function prototypes are parsed in order of sym.Functions.
+	for i, frame := range sym.Functions(syms) {
+		f := &Function{
+			FuncDecl: decls[i],
+			Frame:    frame,
+		}
+		if frame.Overlay != 0 {
+			f.Overlay = prog.overlay(frame.Overlay)
+			f.Overlay.Functions = append(f.Overlay.Functions, f)
+		}
+		prog.Functions = append(prog.Functions, f)
+		if _, ok := prog.funcNames[f.Name]; !ok {
+			prog.funcNames[f.Name] = f
+		}
+	}
+}
+
+// overlay returns the overlay of the given ID, creating it if not yet present.
+func (prog *Program) overlay(id uint32) *Overlay {
+	for _, overlay := range prog.Overlays {
+		if overlay.ID == id {
+			return overlay
+		}
+	}
+	overlay := &Overlay{
+		ID: id,
+	}
+	prog.Overlays = append(prog.Overlays, overlay)
+	return overlay
+}
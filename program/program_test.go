@@ -0,0 +1,89 @@
+package program_test
+
+import (
+	"testing"
+
+	"github.com/sanctuary/sym"
+	"github.com/sanctuary/sym/program"
+)
+
+func TestNew(t *testing.T) {
+	const (
+		// int
+		typInt = sym.Type(0x04)
+		// struct
+		typStruct = sym.Type(0x08)
+		// int ()
+		typFuncInt = sym.Type(0x24)
+	)
+	def := func(value uint32, class sym.Class, typ sym.Type, size uint32, name string) *sym.Symbol {
+		return &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: value, Kind: sym.KindDef},
+			Body: &sym.Def{Class: class, Type: typ, Size: size, Name: name},
+		}
+	}
+	def2 := func(value uint32, class sym.Class, typ sym.Type, size uint32, tag, name string) *sym.Symbol {
+		return &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: value, Kind: sym.KindDef2},
+			Body: &sym.Def2{Class: class, Type: typ, Size: size, Tag: tag, Name: name},
+		}
+	}
+	syms := []*sym.Symbol{
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80100000, Kind: sym.KindOverlay},
+			Body: &sym.Overlay{Length: 0x100, ID: 4},
+		},
+		// struct point { int x; int y; };
+		def(0, sym.ClassSTRTAG, typStruct, 8, "point"),
+		def(0, sym.ClassMOS, typInt, 4, "x"),
+		def(4, sym.ClassMOS, typInt, 4, "y"),
+		def2(8, sym.ClassEOS, 0, 8, "point", ""),
+		// struct point origin;
+		def2(0x80020000, sym.ClassEXT, typStruct, 8, "point", "origin"),
+		def(0x80010000, sym.ClassEXT, typFuncInt, 0x10, "main"),
+		{
+			Hdr:  &sym.SymbolHeader{Value: 4, Kind: sym.KindSetOverlay},
+			Body: &sym.SetOverlay{},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80100000, Kind: sym.KindFuncStart},
+			Body: &sym.FuncStart{FP: 29, RetReg: 31, Line: 1, Path: "ovl.c", Name: "ovl_main"},
+		},
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x80100010, Kind: sym.KindFuncEnd},
+			Body: &sym.FuncEnd{Line: 3},
+		},
+	}
+	prog, err := program.New(syms)
+	if err != nil {
+		t.Fatalf("unable to reconstruct program; %v", err)
+	}
+	point := prog.Struct("point")
+	if point == nil || len(point.Fields) != 2 {
+		t.Fatalf("invalid struct point; expected 2 fields, got %v", point)
+	}
+	if len(prog.Globals) != 1 {
+		t.Fatalf("number of globals mismatch; expected 1, got %d", len(prog.Globals))
+	}
+	origin := prog.Global("origin")
+	if origin == nil || origin.Type != point {
+		t.Errorf("type of global origin not resolved to struct point; got %v", origin)
+	}
+	if len(prog.Overlays) != 1 {
+		t.Fatalf("number of overlays mismatch; expected 1, got %d", len(prog.Overlays))
+	}
+	overlay := prog.Overlays[0]
+	if overlay.Addr != 0x80100000 || overlay.Length != 0x100 {
+		t.Errorf("invalid overlay; expected address 0x80100000 and length 0x100, got %v with length 0x%X", overlay, overlay.Length)
+	}
+	f := prog.Func("ovl_main")
+	if f == nil || f.Overlay != overlay {
+		t.Fatalf("function ovl_main not resolved to overlay %v; got %v", overlay, f)
+	}
+	if len(overlay.Functions) != 1 || overlay.Functions[0] != f {
+		t.Errorf("functions of overlay mismatch; expected [ovl_main], got %v", overlay.Functions)
+	}
+	if got, want := f.Var.String(), "int ovl_main()"; got != want {
+		t.Errorf("prototype mismatch; expected %q, got %q", want, got)
+	}
+}
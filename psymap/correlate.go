@@ -0,0 +1,126 @@
+package psymap
+
+import (
+	"fmt"
+
+	"github.com/sanctuary/sym"
+)
+
+//go:generate stringer -linecomment -type DiscrepancyKind
+
+// DiscrepancyKind specifies the kind of a discrepancy between a MAP file and a
+// symbol file.
+type DiscrepancyKind uint8
+
+// Discrepancy kinds.
+const (
+	// Symbols of identical name located at different addresses.
+	DiscrepancyAddr DiscrepancyKind = iota + 1 // identical name at different addresses
+	// Symbols of conflicting names located at the same address.
+	DiscrepancyName // conflicting names at same address
+)
+
+// A Correlation is the correlation of the symbols of a MAP file with the named
+// symbols of a symbol file.
+type Correlation struct {
+	// Symbols of the MAP file matching symbols of the symbol file by name and
+	// address, sorted by address.
+	Matches []*Match
+	// Discrepancies between symbols of the MAP file and the symbol file, sorted
+	// by address of the MAP file symbol.
+	Discrepancies []*Discrepancy
+	// Symbols of the MAP file not present in the symbol file, sorted by
+	// address.
+	Unmatched []*Symbol
+}
+
+// A Match is a symbol of a MAP file matching a symbol of a symbol file.
+type Match struct {
+	// Symbol of the MAP file.
+	Sym *Symbol
+	// Index entry of the symbol file.
+	Entry *sym.AddrEntry
+}
+
+// Section returns the section of the matched symbol; or nil if not located
+// within a section.
+func (match *Match) Section() *Section {
+	return match.Sym.Section
+}
+
+// A Discrepancy is a symbol of a MAP file conflicting with a symbol of a symbol
+// file.
+type Discrepancy struct {
+	// Kind of discrepancy.
+	Kind DiscrepancyKind
+	// Symbol of the MAP file.
+	Sym *Symbol
+	// Conflicting index entry of the symbol file.
+	Entry *sym.AddrEntry
+}
+
+// String returns the string representation of the discrepancy.
+func (d *Discrepancy) String() string {
+	// identical name at different addresses: foo (0x80010000) in MAP, foo (0x80010010) in SYM
+	return fmt.Sprintf("%v: %v in MAP, %v in SYM", d.Kind, d.Sym, d.Entry)
+}
+
+// Correlate correlates the symbols of the MAP file with the index entries of a
+// symbol file (in any overlay).
+//
+// Symbols are matched by name and address. Symbols of the MAP file with the
+// name of a symbol file entry located at a different address, or located at
+// the address of a symbol file entry of a different name, are reported as
+// discrepancies.
+func Correlate(m *Map, idx *sym.AddrIndex) *Correlation {
+	corr := &Correlation{}
+	byName := make(map[string][]*sym.AddrEntry)
+	byAddr := make(map[uint32][]*sym.AddrEntry)
+	for _, e := range idx.Entries {
+		byName[e.Name] = append(byName[e.Name], e)
+		byAddr[e.Addr] = append(byAddr[e.Addr], e)
+	}
+	for _, s := range m.Symbols {
+		if entries, ok := byName[s.Name]; ok {
+			var match *sym.AddrEntry
+			for _, e := range entries {
+				if e.Addr == s.Addr {
+					match = e
+					break
+				}
+			}
+			if match != nil {
+				corr.Matches = append(corr.Matches, &Match{Sym: s, Entry: match})
+			} else {
+				corr.Discrepancies = append(corr.Discrepancies, &Discrepancy{Kind: DiscrepancyAddr, Sym: s, Entry: entries[0]})
+			}
+			continue
+		}
+		if entries, ok := byAddr[s.Addr]; ok {
+			corr.Discrepancies = append(corr.Discrepancies, &Discrepancy{Kind: DiscrepancyName, Sym: s, Entry: entries[0]})
+			continue
+		}
+		corr.Unmatched = append(corr.Unmatched, s)
+	}
+	return corr
+}
+
+// FillSizes sets the sizes of the matched index entries of unknown or inferred
+// size to the sizes of their MAP file symbols, which are bounded by the
+// sections of the MAP file. It returns the number of updated index entries.
+func (corr *Correlation) FillSizes() int {
+	n := 0
+	for _, match := range corr.Matches {
+		e := match.Entry
+		if e.SizeSource != sym.SizeUnknown && e.SizeSource != sym.SizeInferred {
+			continue
+		}
+		if match.Sym.Size == 0 {
+			continue
+		}
+		e.Size = match.Sym.Size
+		e.SizeSource = sym.SizeMap
+		n++
+	}
+	return n
+}
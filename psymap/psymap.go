@@ -0,0 +1,245 @@
+// Package psymap implements a parser for the MAP files of the Psy-Q linker
+// (psylink), and correlation of MAP file symbols with the symbols of PS1 symbol
+// files.
+//
+// MAP files record the groups and sections of the linked executable, which are
+// not present in symbol files, and the addresses of global symbols.
+package psymap
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A Map is a Psy-Q linker MAP file.
+type Map struct {
+	// Groups, in order of occurrence.
+	Groups []*Group
+	// Sections, in order of occurrence.
+	Sections []*Section
+	// Symbols, sorted by address.
+	Symbols []*Symbol
+}
+
+// A Group is a group of sections (e.g. text, data and bss).
+type Group struct {
+	// Group name.
+	Name string
+	// Start address.
+	Start uint32
+	// Size in bytes.
+	Size uint32
+}
+
+// String returns the string representation of the group.
+func (g *Group) String() string {
+	return fmt.Sprintf("%s (0x%08X-0x%08X)", g.Name, g.Start, g.Start+g.Size)
+}
+
+// A Section is a section of a group (e.g. .text and .rdata).
+type Section struct {
+	// Section name.
+	Name string
+	// Name of the group containing the section; or empty if not specified.
+	Group string
+	// Object file number (optional).
+	Obj int
+	// Start address.
+	Start uint32
+	// Size in bytes.
+	Size uint32
+}
+
+// String returns the string representation of the section.
+func (sect *Section) String() string {
+	return fmt.Sprintf("%s (0x%08X-0x%08X)", sect.Name, sect.Start, sect.Start+sect.Size)
+}
+
+// Contains reports whether the given address is located within the section.
+func (sect *Section) Contains(addr uint32) bool {
+	return sect.Start <= addr && addr-sect.Start < sect.Size
+}
+
+// A Symbol is a global symbol of a MAP file.
+type Symbol struct {
+	// Symbol name.
+	Name string
+	// Address.
+	Addr uint32
+	// Section containing the symbol; or nil if not located within a section.
+	Section *Section
+	// Size in bytes of the symbol, as determined by the gap up until the
+	// succeeding symbol of the same section or the end of the section; or 0 if
+	// unknown.
+	Size uint32
+}
+
+// String returns the string representation of the symbol.
+func (s *Symbol) String() string {
+	return fmt.Sprintf("%s (0x%08X)", s.Name, s.Addr)
+}
+
+// ParseFile parses the given Psy-Q linker MAP file.
+func ParseFile(path string) (*Map, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse parses the given Psy-Q linker MAP file, reading from r.
+//
+// The section table is located by its header line (containing "Start", "Stop"
+// and "Length"), and each of its rows specifies the start, stop and length in
+// hexadecimal, optionally followed by the object file number, and the group and
+// section names; rows without a section name specify groups. Symbol tables are
+// located by their header lines (containing "Names alphabetically" or "Names
+// in address order"), and each of their rows specifies the address in
+// hexadecimal followed by the symbol name. Lines not part of tables are
+// ignored.
+func Parse(r io.Reader) (*Map, error) {
+	const (
+		tableNone = iota
+		tableSections
+		tableSymbols
+	)
+	m := &Map{}
+	type key struct {
+		addr uint32
+		name string
+	}
+	seen := make(map[key]bool)
+	table := tableNone
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case strings.Contains(line, "Start") && strings.Contains(line, "Stop") && strings.Contains(line, "Length"):
+			table = tableSections
+			continue
+		case strings.Contains(line, "Names alphabetically") || strings.Contains(line, "Names in address order"):
+			table = tableSymbols
+			continue
+		}
+		switch table {
+		case tableSections:
+			if len(fields) < 4 {
+				continue
+			}
+			start, err1 := parseHex(fields[0])
+			_, err2 := parseHex(fields[1])
+			size, err3 := parseHex(fields[2])
+			if err1 != nil || err2 != nil || err3 != nil {
+				continue
+			}
+			names := fields[3:]
+			obj := 0
+			if n, err := strconv.Atoi(names[0]); err == nil {
+				obj = n
+				names = names[1:]
+			}
+			switch {
+			case len(names) == 1 && !strings.HasPrefix(names[0], "."):
+				g := &Group{Name: names[0], Start: start, Size: size}
+				m.Groups = append(m.Groups, g)
+			case len(names) == 1:
+				sect := &Section{Name: names[0], Obj: obj, Start: start, Size: size}
+				m.Sections = append(m.Sections, sect)
+			case len(names) >= 2:
+				sect := &Section{Name: names[1], Group: names[0], Obj: obj, Start: start, Size: size}
+				m.Sections = append(m.Sections, sect)
+			}
+		case tableSymbols:
+			if len(fields) < 2 {
+				continue
+			}
+			addr, err := parseHex(fields[0])
+			if err != nil {
+				continue
+			}
+			k := key{addr: addr, name: fields[1]}
+			if seen[k] {
+				// Symbols are listed both alphabetically and in address order.
+				continue
+			}
+			seen[k] = true
+			m.Symbols = append(m.Symbols, &Symbol{Name: fields[1], Addr: addr})
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	sort.SliceStable(m.Symbols, func(i, j int) bool {
+		return m.Symbols[i].Addr < m.Symbols[j].Addr
+	})
+	m.initSections()
+	return m, nil
+}
+
+// initSections determines the sections and sizes of the symbols of the MAP
+// file.
+func (m *Map) initSections() {
+	for _, s := range m.Symbols {
+		s.Section = m.SectionOf(s.Addr)
+	}
+	for i, s := range m.Symbols {
+		if s.Section == nil {
+			continue
+		}
+		end := s.Section.Start + s.Section.Size
+		for j := i + 1; j < len(m.Symbols); j++ {
+			next := m.Symbols[j]
+			if next.Addr > s.Addr {
+				if next.Section == s.Section {
+					end = next.Addr
+				}
+				break
+			}
+		}
+		s.Size = end - s.Addr
+	}
+}
+
+// SectionOf returns the first section containing the given address; or nil if
+// not located within a section.
+func (m *Map) SectionOf(addr uint32) *Section {
+	for _, sect := range m.Sections {
+		if sect.Contains(addr) {
+			return sect
+		}
+	}
+	return nil
+}
+
+// Lookup returns the symbol of the given name; or nil if not present.
+func (m *Map) Lookup(name string) *Symbol {
+	for _, s := range m.Symbols {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// ### [ Helper functions ] ####################################################
+
+// parseHex parses the given hexadecimal 32-bit integer.
+func parseHex(s string) (uint32, error) {
+	x, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return uint32(x), nil
+}
@@ -0,0 +1,113 @@
+package psymap_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sanctuary/sym"
+	"github.com/sanctuary/sym/psymap"
+)
+
+const testMap = `
+  Start     Stop      Length    Obj Group            Section name
+ 80010000  8001013F  00000140       text
+ 80010000  8001013F  00000140     1 text             .text
+ 80010140  8001017F  00000040       data
+ 80010140  8001017F  00000040     2 data             .data
+
+  Address  Names alphabetically
+
+ 80010100  bar
+ 80010140  baz
+ 80010000  foo
+ 80010040  qux
+
+  Address  Names in address order
+
+ 80010000  foo
+ 80010040  qux
+ 80010100  bar
+ 80010140  baz
+`
+
+func TestParse(t *testing.T) {
+	m, err := psymap.Parse(strings.NewReader(testMap))
+	if err != nil {
+		t.Fatalf("unable to parse MAP file; %+v", err)
+	}
+	if len(m.Groups) != 2 || m.Groups[0].Name != "text" || m.Groups[1].Name != "data" {
+		t.Errorf("groups mismatch; expected [text data], got %v", m.Groups)
+	}
+	if len(m.Sections) != 2 {
+		t.Fatalf("number of sections mismatch; expected 2, got %d", len(m.Sections))
+	}
+	if text := m.Sections[0]; text.Name != ".text" || text.Group != "text" || text.Obj != 1 || text.Size != 0x140 {
+		t.Errorf("section mismatch; expected .text of group text, got %v of group %q", text, text.Group)
+	}
+	golden := []struct {
+		name    string
+		addr    uint32
+		size    uint32
+		section string
+	}{
+		{name: "foo", addr: 0x80010000, size: 0x40, section: ".text"},
+		{name: "qux", addr: 0x80010040, size: 0xC0, section: ".text"},
+		// Size bounded by end of section.
+		{name: "bar", addr: 0x80010100, size: 0x40, section: ".text"},
+		{name: "baz", addr: 0x80010140, size: 0x40, section: ".data"},
+	}
+	if len(m.Symbols) != len(golden) {
+		t.Fatalf("number of symbols mismatch; expected %d, got %d (%v)", len(golden), len(m.Symbols), m.Symbols)
+	}
+	for i, g := range golden {
+		s := m.Symbols[i]
+		if s.Name != g.name || s.Addr != g.addr || s.Size != g.size || s.Section == nil || s.Section.Name != g.section {
+			t.Errorf("symbol %d mismatch; expected %s (0x%08X) of size 0x%X in %s, got %v of size 0x%X in %v", i, g.name, g.addr, g.size, g.section, s, s.Size, s.Section)
+		}
+	}
+}
+
+func TestCorrelate(t *testing.T) {
+	m, err := psymap.Parse(strings.NewReader(testMap))
+	if err != nil {
+		t.Fatalf("unable to parse MAP file; %+v", err)
+	}
+	name := func(addr uint32, name string) *sym.Symbol {
+		return &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: addr, Kind: sym.KindName2},
+			Body: &sym.Name2{Name: name},
+		}
+	}
+	syms := []*sym.Symbol{
+		name(0x80010000, "foo"),
+		// Conflicting name at address of qux.
+		name(0x80010040, "quux"),
+		// Identical name at different address.
+		name(0x80010110, "bar"),
+	}
+	idx := sym.NewAddrIndex(syms)
+	corr := psymap.Correlate(m, idx)
+	if len(corr.Matches) != 1 || corr.Matches[0].Sym.Name != "foo" || corr.Matches[0].Section().Name != ".text" {
+		t.Errorf("matches mismatch; expected [foo] in .text, got %v", corr.Matches)
+	}
+	want := []psymap.DiscrepancyKind{psymap.DiscrepancyName, psymap.DiscrepancyAddr}
+	if len(corr.Discrepancies) != len(want) {
+		t.Fatalf("number of discrepancies mismatch; expected %d, got %d (%v)", len(want), len(corr.Discrepancies), corr.Discrepancies)
+	}
+	for i, d := range corr.Discrepancies {
+		if d.Kind != want[i] {
+			t.Errorf("discrepancy %d mismatch; expected %v, got %v", i, want[i], d)
+		}
+	}
+	if len(corr.Unmatched) != 1 || corr.Unmatched[0].Name != "baz" {
+		t.Errorf("unmatched symbols mismatch; expected [baz], got %v", corr.Unmatched)
+	}
+	// Size of foo inferred from gap up until quux in the symbol file; replaced
+	// by size of MAP file.
+	if n := corr.FillSizes(); n != 1 {
+		t.Errorf("number of filled sizes mismatch; expected 1, got %d", n)
+	}
+	if e := corr.Matches[0].Entry; e.Size != 0x40 || e.SizeSource != sym.SizeMap {
+		t.Errorf("size of foo mismatch; expected map size 0x40, got %v size 0x%X", e.SizeSource, e.Size)
+	}
+}
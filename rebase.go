@@ -0,0 +1,52 @@
+package sym
+
+// A Rebase specifies a slide of the addresses of symbols, for executables
+// loaded at a different base address than assumed by the symbol file.
+type Rebase struct {
+	// Delta added to addresses; e.g. -0x1000 to slide symbols 4 KB down.
+	Delta int32
+	// Overlay IDs of the symbols to slide (0 for the default binary); or nil to
+	// slide the symbols of every overlay.
+	Overlays []uint32
+	// Start address of the address range of symbols to slide (inclusive).
+	MinAddr uint32
+	// End address of the address range of symbols to slide (exclusive); or 0 if
+	// unbounded.
+	MaxAddr uint32
+}
+
+// Apply slides the addresses of the given symbols, and returns the number of
+// rebased symbols.
+//
+// Only the values of symbols associated with addresses are changed (see
+// Symbol.Address); other values (e.g. stack offsets, registers and struct
+// member offsets) are kept, as are the values of symbols at address 0 (e.g.
+// declarations of undefined global variables). Overlay definitions are rebased
+// as part of the overlay they define.
+func (rebase *Rebase) Apply(syms []*Symbol) int {
+	n := 0
+	var overlay uint32
+	for _, s := range syms {
+		cur := overlay
+		switch body := s.Body.(type) {
+		case *SetOverlay:
+			overlay = s.Hdr.Value
+			continue
+		case *Overlay:
+			cur = body.ID
+		}
+		addr, ok := s.Address()
+		if !ok || addr == 0 {
+			continue
+		}
+		if rebase.Overlays != nil && !containsOverlay(rebase.Overlays, cur) {
+			continue
+		}
+		if addr < rebase.MinAddr || (rebase.MaxAddr != 0 && addr >= rebase.MaxAddr) {
+			continue
+		}
+		s.Hdr.Value = addr + uint32(rebase.Delta)
+		n++
+	}
+	return n
+}
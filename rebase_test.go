@@ -0,0 +1,55 @@
+package sym_test
+
+import (
+	"testing"
+
+	"github.com/sanctuary/sym"
+)
+
+func TestRebase(t *testing.T) {
+	newSyms := func() []*sym.Symbol {
+		return []*sym.Symbol{
+			{
+				Hdr:  &sym.SymbolHeader{Value: 0x800B0000, Kind: sym.KindOverlay},
+				Body: &sym.Overlay{Length: 0x100, ID: 4},
+			},
+			newDef(0x80010000, sym.ClassEXT, "main"),
+			// Stack offsets are not rebased.
+			newDef(16, sym.ClassAUTO, "x"),
+			newDef(0x80020000, sym.ClassEXT, "gVar"),
+			// Undefined global variables are not rebased.
+			newDef(0, sym.ClassEXT, "gExtern"),
+			{
+				Hdr:  &sym.SymbolHeader{Value: 4, Kind: sym.KindSetOverlay},
+				Body: &sym.SetOverlay{},
+			},
+			newDef(0x800B0010, sym.ClassEXT, "ovl_main"),
+		}
+	}
+	golden := []struct {
+		rebase *sym.Rebase
+		want   []uint32
+	}{
+		{
+			rebase: &sym.Rebase{Delta: 0x1000},
+			want:   []uint32{0x800B1000, 0x80011000, 16, 0x80021000, 0, 4, 0x800B1010},
+		},
+		{
+			rebase: &sym.Rebase{Delta: -0x10, Overlays: []uint32{4}},
+			want:   []uint32{0x800AFFF0, 0x80010000, 16, 0x80020000, 0, 4, 0x800B0000},
+		},
+		{
+			rebase: &sym.Rebase{Delta: 0x100, MinAddr: 0x80020000, MaxAddr: 0x800B0000},
+			want:   []uint32{0x800B0000, 0x80010000, 16, 0x80020100, 0, 4, 0x800B0010},
+		},
+	}
+	for i, g := range golden {
+		syms := newSyms()
+		g.rebase.Apply(syms)
+		for j, s := range syms {
+			if s.Hdr.Value != g.want[j] {
+				t.Errorf("rebase %d: value of symbol %d mismatch; expected 0x%08X, got 0x%08X", i, j, g.want[j], s.Hdr.Value)
+			}
+		}
+	}
+}
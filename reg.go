@@ -0,0 +1,59 @@
+package sym
+
+//go:generate stringer -linecomment -type Reg
+
+// Reg is a MIPS general purpose register.
+type Reg uint8
+
+// MIPS general purpose registers.
+const (
+	RegZero Reg = iota // zero
+	RegAT              // at
+	RegV0              // v0
+	RegV1              // v1
+	RegA0              // a0
+	RegA1              // a1
+	RegA2              // a2
+	RegA3              // a3
+	RegT0              // t0
+	RegT1              // t1
+	RegT2              // t2
+	RegT3              // t3
+	RegT4              // t4
+	RegT5              // t5
+	RegT6              // t6
+	RegT7              // t7
+	RegS0              // s0
+	RegS1              // s1
+	RegS2              // s2
+	RegS3              // s3
+	RegS4              // s4
+	RegS5              // s5
+	RegS6              // s6
+	RegS7              // s7
+	RegT8              // t8
+	RegT9              // t9
+	RegK0              // k0
+	RegK1              // k1
+	RegGP              // gp
+	RegSP              // sp
+	RegFP              // fp
+	RegRA              // ra
+)
+
+// RegInvalid is the register of out-of-range register numbers.
+const RegInvalid Reg = 0xFF // invalid
+
+// newReg returns the MIPS general purpose register of the given register
+// number, or RegInvalid if the register number is out of range.
+func newReg(n uint32) Reg {
+	if n > uint32(RegRA) {
+		return RegInvalid
+	}
+	return Reg(n)
+}
+
+// Valid reports whether the register is a valid MIPS general purpose register.
+func (r Reg) Valid() bool {
+	return r <= RegRA
+}
@@ -0,0 +1,172 @@
+package sym
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A NameMap maps the global symbols of symbol files to new names, by old name
+// or by address; e.g. to apply the names of a community renaming effort.
+type NameMap struct {
+	// Names maps from old name to new name.
+	Names map[string]string
+	// Addrs maps from address to new name. Mappings by address take precedence
+	// over mappings by name.
+	Addrs map[uint32]string
+}
+
+// NewNameMap returns a new empty name mapping.
+func NewNameMap() *NameMap {
+	return &NameMap{
+		Names: make(map[string]string),
+		Addrs: make(map[uint32]string),
+	}
+}
+
+// ParseNameMapFile parses the given name mapping file, in JSON format if of the
+// extension ".json" and in CSV format otherwise.
+func ParseNameMapFile(path string) (*NameMap, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return ParseNameMapJSON(f)
+	}
+	return ParseNameMapCSV(f)
+}
+
+// ParseNameMapCSV parses the given name mapping in CSV format, reading from r.
+//
+// Each record maps an old name or an address (in hexadecimal with "0x" prefix)
+// to a new name; e.g. "sub_80010100,Player_Update" or
+// "0x80010100,Player_Update". Lines starting with '#' are ignored.
+func ParseNameMapCSV(r io.Reader) (*NameMap, error) {
+	m := NewNameMap()
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = 2
+	cr.TrimLeadingSpace = true
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to parse name mapping")
+		}
+		if err := m.add(record[0], record[1]); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	return m, nil
+}
+
+// ParseNameMapJSON parses the given name mapping in JSON format, reading from
+// r.
+//
+// The name mapping is a JSON object, which maps old names or addresses (in
+// hexadecimal with "0x" prefix) to new names; e.g.
+//
+//	{"sub_80010100": "Player_Update", "0x80010200": "Player_Draw"}
+func ParseNameMapJSON(r io.Reader) (*NameMap, error) {
+	var names map[string]string
+	if err := json.NewDecoder(r).Decode(&names); err != nil {
+		return nil, errors.Wrap(err, "unable to parse name mapping")
+	}
+	m := NewNameMap()
+	for old, name := range names {
+		if err := m.add(old, name); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	return m, nil
+}
+
+// add adds a mapping from the given old name or address (in hexadecimal with
+// "0x" prefix) to the new name.
+func (m *NameMap) add(old, name string) error {
+	old, name = strings.TrimSpace(old), strings.TrimSpace(name)
+	if len(name) == 0 {
+		return errors.Errorf("invalid mapping of %q; empty name", old)
+	}
+	if strings.HasPrefix(old, "0x") || strings.HasPrefix(old, "0X") {
+		addr, err := strconv.ParseUint(old[len("0x"):], 16, 32)
+		if err != nil {
+			return errors.Wrapf(err, "invalid address %q of mapping to %q", old, name)
+		}
+		m.Addrs[uint32(addr)] = name
+		return nil
+	}
+	m.Names[old] = name
+	return nil
+}
+
+// Rename renames the global symbols of the given symbols, as specified by the
+// name mapping, and returns the number of renamed symbols.
+//
+// Global symbols are name symbols, function start symbols and definitions of
+// global variables and functions (EXT and STAT); local variables, parameters
+// and types are not renamed. Mappings by address apply to the symbols of every
+// overlay.
+func (m *NameMap) Rename(syms []*Symbol) (int, error) {
+	n := 0
+	for _, s := range syms {
+		addr, ok := s.Address()
+		if !ok {
+			continue
+		}
+		oldName := s.Name()
+		if len(oldName) == 0 {
+			continue
+		}
+		name, ok := m.Addrs[addr]
+		if !ok {
+			name, ok = m.Names[oldName]
+		}
+		if !ok || name == oldName {
+			continue
+		}
+		if err := setName(s, name); err != nil {
+			return 0, errors.WithStack(err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// setName sets the name of the given named symbol.
+func setName(s *Symbol, name string) error {
+	switch body := s.Body.(type) {
+	case *Name1:
+		body.Name = name
+		return setLen(&body.NameLen, name)
+	case *Name2:
+		body.Name = name
+		return setLen(&body.NameLen, name)
+	case *Name5:
+		body.Name = name
+		return setLen(&body.NameLen, name)
+	case *Name6:
+		body.Name = name
+		return setLen(&body.NameLen, name)
+	case *FuncStart:
+		body.Name = name
+		return setLen(&body.NameLen, name)
+	case *Def:
+		body.Name = name
+		return setLen(&body.NameLen, name)
+	case *Def2:
+		body.Name = name
+		return setLen(&body.NameLen, name)
+	}
+	return errors.Errorf("support for renaming symbol of type %T not yet implemented", s.Body)
+}
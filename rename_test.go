@@ -0,0 +1,72 @@
+package sym_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sanctuary/sym"
+)
+
+func TestNameMap(t *testing.T) {
+	newSyms := func() []*sym.Symbol {
+		return []*sym.Symbol{
+			newDef(0x80010100, sym.ClassEXT, "sub_80010100"),
+			{
+				Hdr:  &sym.SymbolHeader{Value: 0x80010100, Kind: sym.KindFuncStart},
+				Body: &sym.FuncStart{NameLen: 12, Name: "sub_80010100"},
+			},
+			// Local variables are not renamed.
+			newDef(16, sym.ClassAUTO, "gVar"),
+			{
+				Hdr:  &sym.SymbolHeader{Value: 0x80010140, Kind: sym.KindFuncEnd},
+				Body: &sym.FuncEnd{},
+			},
+			newDef(0x80020000, sym.ClassEXT, "gVar"),
+			{
+				Hdr:  &sym.SymbolHeader{Value: 0x80030000, Kind: sym.KindName2},
+				Body: &sym.Name2{NameLen: 4, Name: "main"},
+			},
+		}
+	}
+	want := []string{"Player_Update", "Player_Update", "gVar", "", "gPlayer", "Main"}
+	golden := []struct {
+		path string
+		src  string
+	}{
+		{path: "names.csv", src: "# old,new\nsub_80010100,Player_Update\ngVar, gPlayer\n0x80030000,Main\n"},
+		{path: "names.json", src: `{"0x80010100": "Player_Update", "gVar": "gPlayer", "main": "Main"}`},
+	}
+	for _, g := range golden {
+		var m *sym.NameMap
+		var err error
+		if strings.HasSuffix(g.path, ".json") {
+			m, err = sym.ParseNameMapJSON(strings.NewReader(g.src))
+		} else {
+			m, err = sym.ParseNameMapCSV(strings.NewReader(g.src))
+		}
+		if err != nil {
+			t.Errorf("%q: unable to parse name mapping; %+v", g.path, err)
+			continue
+		}
+		syms := newSyms()
+		n, err := m.Rename(syms)
+		if err != nil {
+			t.Errorf("%q: unable to rename symbols; %+v", g.path, err)
+			continue
+		}
+		if n != 4 {
+			t.Errorf("%q: number of renamed symbols mismatch; expected 4, got %d", g.path, n)
+		}
+		for i, s := range syms {
+			if got := s.Name(); got != want[i] {
+				t.Errorf("%q: name of symbol %d mismatch; expected %q, got %q", g.path, i, want[i], got)
+			}
+		}
+		if body := syms[1].Body.(*sym.FuncStart); int(body.NameLen) != len(body.Name) {
+			t.Errorf("%q: name length mismatch; expected %d, got %d", g.path, len(body.Name), body.NameLen)
+		}
+	}
+	if _, err := sym.ParseNameMapCSV(strings.NewReader("0xZZ,foo\n")); err == nil {
+		t.Errorf("expected error for invalid address of name mapping")
+	}
+}
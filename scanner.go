@@ -0,0 +1,116 @@
+package sym
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Number of bytes buffered at the start of symbol files to detect their byte
+// order.
+const orderLookahead = 64 * 1024
+
+// Number of bytes buffered past the start of candidate symbols when
+// resynchronizing after corrupted symbols; the maximum size of built-in symbols
+// (Def2 symbols of 0xFFFF array dimensions and tag and name of 255 bytes),
+// followed by the symbol header of the succeeding symbol.
+const symbolLookahead = 5 + 10 + 0xFFFF*4 + 2*(1+255) + 5
+
+// Minimum number of bytes read at once from the underlying reader.
+const minRead = 64 * 1024
+
+// A scanner buffers the contents of a symbol file read incrementally from an
+// underlying reader, providing the lookahead required to resynchronize after
+// corrupted symbols.
+type scanner struct {
+	// Underlying reader.
+	r io.Reader
+	// Buffered unparsed contents, starting at the current symbol.
+	buf []byte
+	// Offset in bytes from the start of the file of buf[0].
+	off int
+	// Error encountered when reading from r; io.EOF at the end of r.
+	err error
+}
+
+// fill buffers at least n bytes of unparsed contents, unless the underlying
+// reader ends or fails first.
+func (s *scanner) fill(n int) {
+	for len(s.buf) < n && s.err == nil {
+		if len(s.buf) == cap(s.buf) {
+			// Grow into a new buffer, as slices of the old buffer may be
+			// referenced by the raw contents of parsed symbols.
+			size := 2 * cap(s.buf)
+			if size < n+minRead {
+				size = n + minRead
+			}
+			buf := make([]byte, len(s.buf), size)
+			copy(buf, s.buf)
+			s.buf = buf
+		}
+		m, err := s.r.Read(s.buf[len(s.buf):cap(s.buf)])
+		s.buf = s.buf[:len(s.buf)+m]
+		s.err = err
+	}
+}
+
+// discard discards the first n bytes of buffered contents.
+func (s *scanner) discard(n int) {
+	s.buf = s.buf[n:]
+	s.off += n
+}
+
+// readErr returns the error encountered when reading from the underlying
+// reader, if any; reaching the end of the reader is not an error.
+func (s *scanner) readErr() error {
+	if s.err != nil && s.err != io.EOF {
+		return errors.WithStack(s.err)
+	}
+	return nil
+}
+
+// reader returns a reader of the buffered contents, starting at the current
+// symbol and buffering more contents as needed.
+func (s *scanner) reader() *scanReader {
+	return &scanReader{s: s}
+}
+
+// nextSymbol returns the position in the buffered contents of the first valid
+// symbol located at or after the given position, or the end of the symbol file
+// if no valid symbol follows.
+func (s *scanner) nextSymbol(pos int, order binary.ByteOrder) int {
+	for ; ; pos++ {
+		s.fill(pos + symbolLookahead)
+		if pos >= len(s.buf) {
+			return len(s.buf)
+		}
+		// Only decode symbols at offsets with a plausible symbol header, as
+		// most offsets of corrupted regions are rejected by the header alone.
+		if isPlausibleHeader(s.buf, pos, order) && isValidSymbol(s.buf, pos, order) {
+			return pos
+		}
+	}
+}
+
+// A scanReader reads the buffered contents of a scanner.
+type scanReader struct {
+	// Scanner of buffered contents.
+	s *scanner
+	// Current position in the buffered contents.
+	pos int
+}
+
+// Read reads up to len(p) bytes into p.
+func (r *scanReader) Read(p []byte) (int, error) {
+	r.s.fill(r.pos + len(p))
+	if r.pos >= len(r.s.buf) {
+		if r.s.err == nil {
+			return 0, io.EOF
+		}
+		return 0, r.s.err
+	}
+	n := copy(p, r.s.buf[r.pos:])
+	r.pos += n
+	return n, nil
+}
@@ -0,0 +1,229 @@
+package sym
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Stats summarizes the symbols of a symbol file, to gauge the completeness and
+// size of the symbol set.
+type Stats struct {
+	// Totals of all symbols.
+	Total *StatGroup `json:"total"`
+	// Totals grouped by symbol kind, in ascending order of kind.
+	Kinds []*StatGroup `json:"kinds"`
+	// Totals of definitions (Def and Def2) grouped by definition class, in
+	// ascending order of class.
+	Classes []*StatGroup `json:"classes"`
+	// Totals grouped by overlay ID (0 for the default binary), in ascending
+	// order of overlay ID.
+	Overlays []*StatGroup `json:"overlays"`
+	// Totals of the symbols of functions grouped by source file, sorted by
+	// path.
+	Files []*StatGroup `json:"files"`
+	// Biggest functions, in descending order of size.
+	TopFuncs []*StatItem `json:"top_funcs"`
+	// Biggest structs, in descending order of size.
+	TopStructs []*StatItem `json:"top_structs"`
+}
+
+// A StatGroup records the totals of a group of symbols.
+type StatGroup struct {
+	// Group name; e.g. the DUMPSYM representation of a symbol kind ("8c"), the
+	// name of a definition class ("EXT"), the hexadecimal ID of an overlay
+	// ("4") or the path of a source file.
+	Name string `json:"name"`
+	// Number of symbols of the group.
+	Count int `json:"count"`
+	// Size in bytes of the symbols of the group, as stored in the SYM file.
+	Bytes int `json:"bytes"`
+	// Total size in bytes of the entities described by the symbols of the
+	// group; i.e. the size of definitions, the length of overlays and the
+	// address range of functions (see Symbol.SizeOf).
+	Size uint64 `json:"size"`
+}
+
+// String returns the string representation of the group.
+func (g *StatGroup) String() string {
+	// 8c: 12 symbols (624 bytes), size 0x1A40
+	return fmt.Sprintf("%s: %d symbols (%d bytes), size 0x%X", g.Name, g.Count, g.Bytes, g.Size)
+}
+
+// add adds the given symbol to the group, describing an entity of the given
+// size.
+func (g *StatGroup) add(s *Symbol, size uint32) {
+	g.Count++
+	g.Bytes += s.Size()
+	g.Size += uint64(size)
+}
+
+// A StatItem is a sized entity (i.e. a function or a struct) ranked by size.
+type StatItem struct {
+	// Name of function or struct tag.
+	Name string `json:"name"`
+	// Size in bytes.
+	Size uint32 `json:"size"`
+	// Address of function; or 0 for structs.
+	Addr uint32 `json:"addr,omitempty"`
+	// ID of the overlay containing the function (0 for the default binary).
+	Overlay uint32 `json:"overlay,omitempty"`
+}
+
+// String returns the string representation of the item.
+func (item *StatItem) String() string {
+	if item.Addr == 0 {
+		return fmt.Sprintf("%s (0x%X bytes)", item.Name, item.Size)
+	}
+	return fmt.Sprintf("%s (0x%08X, 0x%X bytes)", item.Name, item.Addr, item.Size)
+}
+
+// NewStats returns statistics of the given symbols, ranking the top n biggest
+// functions and structs.
+//
+// Symbols of functions are attributed to the source file of the function,
+// from the function start symbol up to and including the function end symbol.
+// Structs defined more than once (e.g. by multiple compilation units) are only
+// ranked once per tag and size.
+func NewStats(syms []*Symbol, n int) *Stats {
+	type funcKey struct {
+		overlay uint32
+		addr    uint32
+		name    string
+	}
+	// funcSizes maps from function to the size of its address range.
+	funcSizes := make(map[funcKey]uint32)
+	var funcs []*StatItem
+	for _, f := range Functions(syms) {
+		if f.EndAddr <= f.Addr {
+			continue
+		}
+		size := f.EndAddr - f.Addr
+		funcSizes[funcKey{overlay: f.Overlay, addr: f.Addr, name: f.Name}] = size
+		item := &StatItem{
+			Name:    f.Name,
+			Size:    size,
+			Addr:    f.Addr,
+			Overlay: f.Overlay,
+		}
+		funcs = append(funcs, item)
+	}
+	var (
+		total    = &StatGroup{Name: "total"}
+		kinds    = make(map[Kind]*StatGroup)
+		classes  = make(map[Class]*StatGroup)
+		overlays = make(map[uint32]*StatGroup)
+		files    = make(map[string]*StatGroup)
+	)
+	type structKey struct {
+		tag  string
+		size uint32
+	}
+	seen := make(map[structKey]bool)
+	var structs []*StatItem
+	var (
+		// Current overlay ID.
+		overlay uint32
+		// Source file of the current function.
+		path string
+	)
+	for _, s := range syms {
+		size := s.SizeOf()
+		cur := overlay
+		var class Class
+		var isDef bool
+		switch body := s.Body.(type) {
+		case *SetOverlay:
+			overlay = s.Hdr.Value
+			cur = overlay
+		case *Overlay:
+			cur = body.ID
+		case *FuncStart:
+			path = body.Path
+			size = funcSizes[funcKey{overlay: overlay, addr: s.Hdr.Value, name: body.Name}]
+		case *Def:
+			class, isDef = body.Class, true
+		case *Def2:
+			class, isDef = body.Class, true
+		}
+		total.add(s, size)
+		kind := s.Hdr.Kind
+		if kinds[kind] == nil {
+			kinds[kind] = &StatGroup{Name: kind.String()}
+		}
+		kinds[kind].add(s, size)
+		if isDef {
+			if classes[class] == nil {
+				classes[class] = &StatGroup{Name: class.String()}
+			}
+			classes[class].add(s, size)
+			if class == ClassSTRTAG && size > 0 {
+				key := structKey{tag: s.Name(), size: size}
+				if !seen[key] {
+					seen[key] = true
+					structs = append(structs, &StatItem{Name: key.tag, Size: size})
+				}
+			}
+		}
+		if overlays[cur] == nil {
+			overlays[cur] = &StatGroup{Name: fmt.Sprintf("%X", cur)}
+		}
+		overlays[cur].add(s, size)
+		if len(path) > 0 {
+			if files[path] == nil {
+				files[path] = &StatGroup{Name: path}
+			}
+			files[path].add(s, size)
+		}
+		if _, ok := s.Body.(*FuncEnd); ok {
+			path = ""
+		}
+	}
+	stats := &Stats{Total: total}
+	var kindKeys []Kind
+	for kind := range kinds {
+		kindKeys = append(kindKeys, kind)
+	}
+	sort.Slice(kindKeys, func(i, j int) bool { return kindKeys[i] < kindKeys[j] })
+	for _, kind := range kindKeys {
+		stats.Kinds = append(stats.Kinds, kinds[kind])
+	}
+	var classKeys []Class
+	for class := range classes {
+		classKeys = append(classKeys, class)
+	}
+	sort.Slice(classKeys, func(i, j int) bool { return classKeys[i] < classKeys[j] })
+	for _, class := range classKeys {
+		stats.Classes = append(stats.Classes, classes[class])
+	}
+	var overlayKeys []uint32
+	for id := range overlays {
+		overlayKeys = append(overlayKeys, id)
+	}
+	sort.Slice(overlayKeys, func(i, j int) bool { return overlayKeys[i] < overlayKeys[j] })
+	for _, id := range overlayKeys {
+		stats.Overlays = append(stats.Overlays, overlays[id])
+	}
+	var paths []string
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		stats.Files = append(stats.Files, files[path])
+	}
+	stats.TopFuncs = topItems(funcs, n)
+	stats.TopStructs = topItems(structs, n)
+	return stats
+}
+
+// topItems returns the n biggest of the given items, in descending order of
+// size. Items of equal size are kept in order of occurrence.
+func topItems(items []*StatItem, n int) []*StatItem {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Size > items[j].Size
+	})
+	if len(items) > n {
+		items = items[:n]
+	}
+	return items
+}
@@ -0,0 +1,109 @@
+package sym_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/sanctuary/sym"
+)
+
+func TestNewStats(t *testing.T) {
+	tag := func(name string, size uint32) *sym.Symbol {
+		s := newDef(0, sym.ClassSTRTAG, name)
+		s.Body.(*sym.Def).Size = size
+		return s
+	}
+	funcStart := func(addr uint32, path, name string) *sym.Symbol {
+		return &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: addr, Kind: sym.KindFuncStart},
+			Body: &sym.FuncStart{PathLen: uint8(len(path)), Path: path, NameLen: uint8(len(name)), Name: name},
+		}
+	}
+	funcEnd := func(addr uint32) *sym.Symbol {
+		return &sym.Symbol{
+			Hdr:  &sym.SymbolHeader{Value: addr, Kind: sym.KindFuncEnd},
+			Body: &sym.FuncEnd{},
+		}
+	}
+	syms := []*sym.Symbol{
+		{
+			Hdr:  &sym.SymbolHeader{Value: 0x800B0000, Kind: sym.KindOverlay},
+			Body: &sym.Overlay{Length: 0x100, ID: 4},
+		},
+		tag("small", 4),
+		tag("big", 0x20),
+		// Struct redefined by another compilation unit.
+		tag("big", 0x20),
+		newDef(0x80010000, sym.ClassEXT, "main"),
+		funcStart(0x80010000, "main.c", "main"),
+		newDef(16, sym.ClassAUTO, "x"),
+		funcEnd(0x80010040),
+		{
+			Hdr:  &sym.SymbolHeader{Value: 4, Kind: sym.KindSetOverlay},
+			Body: &sym.SetOverlay{},
+		},
+		funcStart(0x800B0000, "ovl.c", "ovl_main"),
+		funcEnd(0x800B0010),
+	}
+	stats := sym.NewStats(syms, 1)
+	if stats.Total.Count != len(syms) {
+		t.Errorf("total count mismatch; expected %d, got %d", len(syms), stats.Total.Count)
+	}
+	bytes := 0
+	for _, s := range syms {
+		bytes += s.Size()
+	}
+	if stats.Total.Bytes != bytes {
+		t.Errorf("total bytes mismatch; expected %d, got %d", bytes, stats.Total.Bytes)
+	}
+	// groups returns the string representation of the names, counts and sizes
+	// of the given groups.
+	groups := func(gs []*sym.StatGroup) []string {
+		var ss []string
+		for _, g := range gs {
+			ss = append(ss, fmt.Sprintf("%s %d 0x%X", g.Name, g.Count, g.Size))
+		}
+		return ss
+	}
+	golden := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{
+			name: "kinds",
+			got:  groups(stats.Kinds),
+			want: []string{"8c 2 0x50", "8e 2 0x0", "94 5 0x4C", "overlay 1 0x100", "set overlay 1 0x0"},
+		},
+		{
+			name: "classes",
+			got:  groups(stats.Classes),
+			want: []string{"AUTO 1 0x4", "EXT 1 0x4", "STRTAG 3 0x44"},
+		},
+		{
+			name: "overlays",
+			got:  groups(stats.Overlays),
+			want: []string{"0 7 0x8C", "4 4 0x110"},
+		},
+		{
+			name: "files",
+			got:  groups(stats.Files),
+			want: []string{"main.c 3 0x44", "ovl.c 2 0x10"},
+		},
+	}
+	for _, g := range golden {
+		if !reflect.DeepEqual(g.got, g.want) {
+			t.Errorf("%s mismatch; expected %q, got %q", g.name, g.want, g.got)
+		}
+	}
+	wantFuncs := []*sym.StatItem{{Name: "main", Size: 0x40, Addr: 0x80010000}}
+	if !reflect.DeepEqual(stats.TopFuncs, wantFuncs) {
+		t.Errorf("top functions mismatch; expected %v, got %v", wantFuncs, stats.TopFuncs)
+	}
+	stats = sym.NewStats(syms, 3)
+	wantStructs := []*sym.StatItem{{Name: "big", Size: 0x20}, {Name: "small", Size: 4}}
+	if !reflect.DeepEqual(stats.TopStructs, wantStructs) {
+		t.Errorf("top structs mismatch; expected %v, got %v", wantStructs, stats.TopStructs)
+	}
+}
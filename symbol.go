@@ -1,6 +1,7 @@
 package sym
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -17,6 +18,9 @@ type Symbol struct {
 	Hdr *SymbolHeader
 	// Symbol body.
 	Body SymbolBody
+	// Raw contents of the symbol as stored in the SYM file (optional); set
+	// when parsing.
+	Raw []byte
 }
 
 // String returns the string representation of the symbol.
@@ -50,18 +54,18 @@ type SymbolBody interface {
 	BodySize() int
 }
 
-// parseSymbol parses and returns a PS1 symbol.
-func parseSymbol(r io.Reader) (*Symbol, error) {
+// parseSymbol parses and returns a PS1 symbol, using the given byte order.
+func parseSymbol(r io.Reader, order binary.ByteOrder) (*Symbol, error) {
 	// Parse symbol header.
 	sym := &Symbol{}
-	hdr, err := parseSymbolHeader(r)
+	hdr, err := parseSymbolHeader(r, order)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 	sym.Hdr = hdr
 
 	// Parse symbol body.
-	body, err := parseSymbolBody(r, hdr.Kind)
+	body, err := parseSymbolBody(r, hdr.Kind, order)
 	if err != nil {
 		return sym, errors.WithStack(err)
 	}
@@ -69,66 +73,162 @@ func parseSymbol(r io.Reader) (*Symbol, error) {
 	return sym, nil
 }
 
-// parseSymbolHeader parses and returns a PS1 symbol header.
-func parseSymbolHeader(r io.Reader) (*SymbolHeader, error) {
+// parseSymbolHeader parses and returns a PS1 symbol header, using the given
+// byte order.
+func parseSymbolHeader(r io.Reader, order binary.ByteOrder) (*SymbolHeader, error) {
 	hdr := &SymbolHeader{}
-	if err := struc.Unpack(r, hdr); err != nil {
+	if err := struc.UnpackWithOrder(r, hdr, order); err != nil {
 		return nil, errors.WithStack(err)
 	}
 	return hdr, nil
 }
 
-// parseSymbolBody parses and returns a PS1 symbol body.
-func parseSymbolBody(r io.Reader, kind Kind) (SymbolBody, error) {
-	parse := func(body SymbolBody) (SymbolBody, error) {
-		if err := struc.Unpack(r, body); err != nil {
-			return nil, errors.WithStack(err)
-		}
-		return body, nil
+// parseSymbolBody parses and returns a PS1 symbol body, using the given byte
+// order.
+func parseSymbolBody(r io.Reader, kind Kind, order binary.ByteOrder) (SymbolBody, error) {
+	parse, ok := lookupKind(kind)
+	if !ok {
+		return nil, errors.Errorf("support for symbol kind 0x%02X not yet implemented", uint8(kind))
+	}
+	return parse(r, order)
+}
+
+// isPlausibleHeader reports whether a plausible symbol header is located at the
+// given offset in b; i.e. whether the symbol is of known kind and, for symbols
+// associated with code, whether its address is within the memory of the
+// target.
+func isPlausibleHeader(b []byte, offset int, order binary.ByteOrder) bool {
+	// Offset of kind within symbol header.
+	const kindOffset = 4
+	if offset+kindOffset >= len(b) {
+		return false
 	}
+	kind := Kind(b[offset+kindOffset])
 	switch kind {
-	case KindName1:
-		return parse(&Name1{})
-	case KindName2:
-		return parse(&Name2{})
-	case KindName5:
-		return parse(&Name2{})
-	case KindName6:
-		return parse(&Name2{})
-	case KindIncSLD:
-		// empty body.
-		return &IncSLD{}, nil
-	case KindIncSLDByte:
-		return parse(&IncSLDByte{})
-	case KindIncSLDWord:
-		return parse(&IncSLDWord{})
-	case KindSetSLD:
-		return parse(&SetSLD{})
-	case KindSetSLD2:
-		return parse(&SetSLD2{})
-	case KindEndSLD:
-		// empty body.
-		return &EndSLD{}, nil
-	case KindFuncStart:
-		return parse(&FuncStart{})
-	case KindFuncEnd:
-		return parse(&FuncEnd{})
-	case KindBlockStart:
-		return parse(&BlockStart{})
-	case KindBlockEnd:
-		return parse(&BlockEnd{})
-	case KindDef:
-		return parse(&Def{})
-	case KindDef2:
-		return parse(&Def2{})
-	case KindOverlay:
-		return parse(&Overlay{})
-	case KindSetOverlay:
-		// empty body.
-		return &SetOverlay{}, nil
-	default:
-		return nil, errors.Errorf("support for symbol kind 0x%02X not yet implemented", uint8(kind))
+	case KindIncSLD, KindIncSLDByte, KindIncSLDWord, KindSetSLD, KindSetSLD2, KindEndSLD, KindFuncStart, KindFuncEnd, KindBlockStart, KindBlockEnd, KindOverlay:
+		return isTargetAddr(order.Uint32(b[offset:]), order)
+	}
+	return isKnownKind(kind)
+}
+
+// isValidSymbol reports whether a plausible symbol of known kind is located at
+// the given offset in b, and is either followed by another symbol of known kind
+// or by the end of b.
+func isValidSymbol(b []byte, offset int, order binary.ByteOrder) bool {
+	r := bytes.NewReader(b[offset:])
+	sym, err := parseSymbol(r, order)
+	if err != nil {
+		return false
+	}
+	if !isPlausibleSymbol(sym, order) {
+		return false
+	}
+	end := offset + sym.Size()
+	if end == len(b) {
+		return true
+	}
+	// Kind of succeeding symbol.
+	const kindOffset = 4
+	if end+kindOffset >= len(b) {
+		return false
+	}
+	return isKnownKind(Kind(b[end+kindOffset]))
+}
+
+// isPlausibleSymbol reports whether the contents of the given symbol are
+// plausible; i.e. whether symbols associated with code have addresses within
+// the memory of the target (as determined by byte order), and whether
+// definitions have known classes.
+func isPlausibleSymbol(sym *Symbol, order binary.ByteOrder) bool {
+	switch body := sym.Body.(type) {
+	case *IncSLD, *IncSLDByte, *IncSLDWord, *SetSLD, *SetSLD2, *EndSLD, *FuncStart, *FuncEnd, *BlockStart, *BlockEnd, *Overlay:
+		return isTargetAddr(sym.Hdr.Value, order)
+	case *Name1:
+		return isPlausibleName(body.Name)
+	case *Name2:
+		return isPlausibleName(body.Name)
+	case *Name5:
+		return isPlausibleName(body.Name)
+	case *Name6:
+		return isPlausibleName(body.Name)
+	case *Def:
+		return isKnownClass(body.Class)
+	case *Def2:
+		return isKnownClass(body.Class)
+	}
+	return true
+}
+
+// isPlausibleName reports whether the given symbol name is plausible; i.e.
+// non-empty and made up of printable characters.
+func isPlausibleName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if name[i] < 0x20 || name[i] == 0x7F {
+			return false
+		}
+	}
+	return true
+}
+
+// isTargetAddr reports whether the given address is located within the memory
+// of the target of a symbol file of the given byte order; PSX memory for
+// little-endian symbol files, and Saturn or N64 memory for big-endian symbol
+// files.
+func isTargetAddr(addr uint32, order binary.ByteOrder) bool {
+	if order == binary.BigEndian {
+		return isSaturnAddr(addr) || isN64Addr(addr)
+	}
+	return IsPSXAddr(addr)
+}
+
+// isSaturnAddr reports whether the given address is located within the work
+// RAM of the Saturn, or one of its cache-through mirrors.
+func isSaturnAddr(addr uint32) bool {
+	// Strip cache-through bits.
+	addr &^= 0x20000000
+	switch {
+	// Low work RAM.
+	case 0x00200000 <= addr && addr < 0x00300000:
+		return true
+	// High work RAM.
+	case 0x06000000 <= addr && addr < 0x06100000:
+		return true
 	}
+	return false
+}
+
+// isN64Addr reports whether the given address is located within the RDRAM of
+// the N64 (including the expansion pak) in KSEG0 or KSEG1.
+func isN64Addr(addr uint32) bool {
+	switch {
+	case 0x80000000 <= addr && addr < 0x80800000,
+		0xA0000000 <= addr && addr < 0xA0800000:
+		return true
+	}
+	return false
+}
+
+// IsPSXAddr reports whether the given address is located within PSX memory;
+// i.e. main RAM (including the 8 MB of development units) or one of its
+// mirrors, the scratchpad or the BIOS.
+func IsPSXAddr(addr uint32) bool {
+	switch {
+	// Main RAM (KUSEG, KSEG0 and KSEG1).
+	case addr < 0x00800000,
+		0x80000000 <= addr && addr < 0x80800000,
+		0xA0000000 <= addr && addr < 0xA0800000:
+		return true
+	// Scratchpad.
+	case 0x1F800000 <= addr && addr < 0x1F800400:
+		return true
+	// BIOS.
+	case 0xBFC00000 <= addr && addr < 0xBFC80000:
+		return true
+	}
+	return false
 }
 
 // --- [ 0x01 ] ----------------------------------------------------------------
@@ -138,9 +238,9 @@ func parseSymbolBody(r io.Reader, kind Kind) (SymbolBody, error) {
 // Value of the symbol header specifies the associated address.
 type Name1 struct {
 	// Name length.
-	NameLen uint8 `struc:"uint8,sizeof=Name"`
+	NameLen uint8 `struc:"uint8,sizeof=Name" json:"-"`
 	// Symbol name,
-	Name string
+	Name string `json:"name"`
 }
 
 // String returns the string representation of the name symbol.
@@ -161,9 +261,9 @@ func (body *Name1) BodySize() int {
 // Value of the symbol header specifies the associated address.
 type Name2 struct {
 	// Name length.
-	NameLen uint8 `struc:"uint8,sizeof=Name"`
+	NameLen uint8 `struc:"uint8,sizeof=Name" json:"-"`
 	// Symbol name,
-	Name string
+	Name string `json:"name"`
 }
 
 // String returns the string representation of the name symbol.
@@ -184,9 +284,9 @@ func (body *Name2) BodySize() int {
 // Value of the symbol header specifies the associated address.
 type Name5 struct {
 	// Name length.
-	NameLen uint8 `struc:"uint8,sizeof=Name"`
+	NameLen uint8 `struc:"uint8,sizeof=Name" json:"-"`
 	// Symbol name,
-	Name string
+	Name string `json:"name"`
 }
 
 // String returns the string representation of the name symbol.
@@ -207,9 +307,9 @@ func (body *Name5) BodySize() int {
 // Value of the symbol header specifies the associated address.
 type Name6 struct {
 	// Name length.
-	NameLen uint8 `struc:"uint8,sizeof=Name"`
+	NameLen uint8 `struc:"uint8,sizeof=Name" json:"-"`
 	// Symbol name,
-	Name string
+	Name string `json:"name"`
 }
 
 // String returns the string representation of the name symbol.
@@ -248,7 +348,7 @@ func (body *IncSLD) BodySize() int {
 //
 // Value of the symbol header specifies the associated address.
 type IncSLDByte struct {
-	Inc uint8 `struc:"uint8"`
+	Inc uint8 `struc:"uint8" json:"inc"`
 }
 
 // String returns the string representation of the line number increment symbol.
@@ -268,7 +368,7 @@ func (body *IncSLDByte) BodySize() int {
 //
 // Value of the symbol header specifies the associated address.
 type IncSLDWord struct {
-	Inc uint16 `struc:"uint16,little"`
+	Inc uint16 `struc:"uint16,little" json:"inc"`
 }
 
 // String returns the string representation of the line number increment symbol.
@@ -289,7 +389,7 @@ func (body *IncSLDWord) BodySize() int {
 // Value of the symbol header specifies the associated address.
 type SetSLD struct {
 	// Line number.
-	Line uint32 `struc:"uint32,little"`
+	Line uint32 `struc:"uint32,little" json:"line"`
 }
 
 // String returns the string representation of the set line number symbol.
@@ -310,11 +410,11 @@ func (body *SetSLD) BodySize() int {
 // Value of the symbol header specifies the associated address.
 type SetSLD2 struct {
 	// Line number.
-	Line uint32 `struc:"uint32,little"`
+	Line uint32 `struc:"uint32,little" json:"line"`
 	// Path length.
-	PathLen uint8 `struc:"uint8,sizeof=Path"`
+	PathLen uint8 `struc:"uint8,sizeof=Path" json:"-"`
 	// Source file,
-	Path string
+	Path string `json:"path"`
 }
 
 // String returns the string representation of the set line number symbol.
@@ -354,25 +454,25 @@ func (body *EndSLD) BodySize() int {
 // Value of the symbol header specifies the associated address.
 type FuncStart struct {
 	// Frame pointer register.
-	FP uint16 `struc:"uint16,little"`
+	FP uint16 `struc:"uint16,little" json:"fp"`
 	// Function size.
-	FSize uint32 `struc:"uint32,little"`
+	FSize uint32 `struc:"uint32,little" json:"fsize"`
 	// Return address register.
-	RetReg uint16 `struc:"uint16,little"`
+	RetReg uint16 `struc:"uint16,little" json:"retreg"`
 	// Mask.
-	Mask uint32 `struc:"uint32,little"`
+	Mask uint32 `struc:"uint32,little" json:"mask"`
 	// Mask offset.
-	MaskOffset int32 `struc:"int32,little"`
+	MaskOffset int32 `struc:"int32,little" json:"mask_offset"`
 	// Line number.
-	Line uint32 `struc:"uint32,little"`
+	Line uint32 `struc:"uint32,little" json:"line"`
 	// Path length.
-	PathLen uint8 `struc:"uint8,sizeof=Path"`
+	PathLen uint8 `struc:"uint8,sizeof=Path" json:"-"`
 	// Source file.
-	Path string
+	Path string `json:"path"`
 	// Name length.
-	NameLen uint8 `struc:"uint8,sizeof=Name"`
+	NameLen uint8 `struc:"uint8,sizeof=Name" json:"-"`
 	// Symbol name.
-	Name string
+	Name string `json:"name"`
 }
 
 // String returns the string representation of the function start symbol.
@@ -410,7 +510,7 @@ func (body *FuncStart) BodySize() int {
 // Value of the symbol header specifies the associated address.
 type FuncEnd struct {
 	// Line number.
-	Line uint32 `struc:"uint32,little"`
+	Line uint32 `struc:"uint32,little" json:"line"`
 }
 
 // String returns the string representation of the function end symbol.
@@ -431,7 +531,7 @@ func (body *FuncEnd) BodySize() int {
 // Value of the symbol header specifies the associated address.
 type BlockStart struct {
 	// Line number.
-	Line uint32 `struc:"uint32,little"`
+	Line uint32 `struc:"uint32,little" json:"line"`
 }
 
 // String returns the string representation of the block start symbol.
@@ -452,7 +552,7 @@ func (body *BlockStart) BodySize() int {
 // Value of the symbol header specifies the associated address.
 type BlockEnd struct {
 	// Line number.
-	Line uint32 `struc:"uint32,little"`
+	Line uint32 `struc:"uint32,little" json:"line"`
 }
 
 // String returns the string representation of the block end symbol.
@@ -473,15 +573,15 @@ func (body *BlockEnd) BodySize() int {
 // Value of the symbol header specifies the associated address.
 type Def struct {
 	// Definition class.
-	Class Class `struc:"uint16,little"`
+	Class Class `struc:"uint16,little" json:"class"`
 	// Definition type.
-	Type Type `struc:"uint16,little"`
+	Type Type `struc:"uint16,little" json:"type"`
 	// Definition size.
-	Size uint32 `struc:"uint32,little"`
+	Size uint32 `struc:"uint32,little" json:"size"`
 	// Name length.
-	NameLen uint8 `struc:"uint8,sizeof=Name"`
+	NameLen uint8 `struc:"uint8,sizeof=Name" json:"-"`
 	// Definition name,
-	Name string
+	Name string `json:"name"`
 }
 
 // String returns the string representation of the definition symbol.
@@ -503,23 +603,23 @@ func (body *Def) BodySize() int {
 // Value of the symbol header specifies the associated address.
 type Def2 struct {
 	// Definition class.
-	Class Class `struc:"uint16,little"`
+	Class Class `struc:"uint16,little" json:"class"`
 	// Definition type.
-	Type Type `struc:"uint16,little"`
+	Type Type `struc:"uint16,little" json:"type"`
 	// Definition size.
-	Size uint32 `struc:"uint32,little"`
+	Size uint32 `struc:"uint32,little" json:"size"`
 	// Dimensions length.
-	DimsLen uint16 `struc:"uint16,little,sizeof=Dims"`
+	DimsLen uint16 `struc:"uint16,little,sizeof=Dims" json:"-"`
 	// Dimensions.
-	Dims []uint32 `struc:"[]uint32,little"`
+	Dims []uint32 `struc:"[]uint32,little" json:"dims,omitempty"`
 	// Tag length.
-	TagLen uint8 `struc:"uint8,sizeof=Tag"`
+	TagLen uint8 `struc:"uint8,sizeof=Tag" json:"-"`
 	// Definition tag,
-	Tag string
+	Tag string `json:"tag"`
 	// Name length.
-	NameLen uint8 `struc:"uint8,sizeof=Name"`
+	NameLen uint8 `struc:"uint8,sizeof=Name" json:"-"`
 	// Definition name,
-	Name string
+	Name string `json:"name"`
 }
 
 // String returns the string representation of the definition symbol.
@@ -541,6 +641,31 @@ func (body *Def2) BodySize() int {
 	return 2 + 2 + 4 + 2 + int(4*body.DimsLen) + 1 + int(body.TagLen) + 1 + int(body.NameLen)
 }
 
+// maxDims specifies the maximum number of array dimensions of a definition;
+// i.e. the maximum number of type modifiers.
+const maxDims = 6
+
+// parseDef2 parses and returns a Def2 symbol body, reading from r and using the
+// given byte order.
+func parseDef2(r io.Reader, order binary.ByteOrder) (SymbolBody, error) {
+	// Read fixed-size prefix of the body, to validate the dimensions length
+	// before allocating the dimensions.
+	const dimsLenOffset = 2 + 2 + 4
+	prefix := make([]byte, dimsLenOffset+2)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	dimsLen := order.Uint16(prefix[dimsLenOffset:])
+	if dimsLen > maxDims {
+		return nil, errors.Errorf("invalid dimensions length of Def2 symbol; expected <= %d, got %d", maxDims, dimsLen)
+	}
+	body := &Def2{}
+	if err := struc.UnpackWithOrder(io.MultiReader(bytes.NewReader(prefix), r), body, order); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return body, nil
+}
+
 // --- [ 0x98 ] ----------------------------------------------------------------
 
 // An Overlay symbol specifies the length and id of a file overlay (e.g. a
@@ -550,9 +675,9 @@ func (body *Def2) BodySize() int {
 // loaded.
 type Overlay struct {
 	// Overlay length in bytes.
-	Length uint32 `struc:"uint32,little"`
+	Length uint32 `struc:"uint32,little" json:"length"`
 	// Overlay ID.
-	ID uint32 `struc:"uint32,little"`
+	ID uint32 `struc:"uint32,little" json:"id"`
 }
 
 // String returns the string representation of the overlay symbol.
@@ -584,3 +709,28 @@ func (body *SetOverlay) String() string {
 func (body *SetOverlay) BodySize() int {
 	return 0
 }
+
+// --- [ Unknown ] -------------------------------------------------------------
+
+// An UnknownBody is the opaque body of a symbol of unknown kind, as captured
+// when parsing in lenient mode.
+//
+// The extent of the body is determined heuristically, and spans up until the
+// next valid symbol.
+type UnknownBody struct {
+	// Symbol kind.
+	Kind Kind
+	// Raw contents of the symbol body.
+	Raw []byte
+}
+
+// String returns the string representation of the unknown symbol.
+func (body *UnknownBody) String() string {
+	// $80010000 a0 Unknown symbol body (3 bytes) 01 02 03
+	return fmt.Sprintf("Unknown symbol body (%d bytes) % x", len(body.Raw), body.Raw)
+}
+
+// BodySize returns the size of the symbol body in bytes.
+func (body *UnknownBody) BodySize() int {
+	return len(body.Raw)
+}
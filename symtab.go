@@ -0,0 +1,103 @@
+package sym
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// A SymbolTable maps names to the symbols of the name, supporting exact, prefix
+// and regular expression queries. Names may repeat (e.g. across overlays or
+// scopes), and queries thus return every matching symbol.
+type SymbolTable struct {
+	// names maps from name to the symbols of the name, in order of occurrence.
+	names map[string][]*NameEntry
+	// Names of the symbol table, in sorted order.
+	sorted []string
+}
+
+// A NameEntry associates a name with a symbol.
+type NameEntry struct {
+	// Flattened view of the symbol, specifying its name, kind and address.
+	SymbolInfo
+	// ID of the overlay active at the symbol (0 for the default binary).
+	Overlay uint32
+	// Symbol of the name.
+	Sym *Symbol
+}
+
+// String returns the string representation of the symbol table entry.
+func (e *NameEntry) String() string {
+	if !e.HasAddr {
+		return fmt.Sprintf("%s (%v)", e.Name, e.Kind)
+	}
+	if e.Overlay != 0 {
+		return fmt.Sprintf("%s (%v 0x%08X overlay %X)", e.Name, e.Kind, e.Addr, e.Overlay)
+	}
+	return fmt.Sprintf("%s (%v 0x%08X)", e.Name, e.Kind, e.Addr)
+}
+
+// NewSymbolTable returns a new symbol table of the named symbols of the given
+// symbols.
+func NewSymbolTable(syms []*Symbol) *SymbolTable {
+	t := &SymbolTable{
+		names: make(map[string][]*NameEntry),
+	}
+	// Current overlay ID.
+	var overlay uint32
+	for _, s := range syms {
+		if _, ok := s.Body.(*SetOverlay); ok {
+			overlay = s.Hdr.Value
+			continue
+		}
+		info := s.Info()
+		if len(info.Name) == 0 {
+			continue
+		}
+		entry := &NameEntry{
+			SymbolInfo: info,
+			Overlay:    overlay,
+			Sym:        s,
+		}
+		if _, ok := t.names[info.Name]; !ok {
+			t.sorted = append(t.sorted, info.Name)
+		}
+		t.names[info.Name] = append(t.names[info.Name], entry)
+	}
+	sort.Strings(t.sorted)
+	return t
+}
+
+// Names returns the distinct names of the symbol table, in sorted order.
+func (t *SymbolTable) Names() []string {
+	return t.sorted
+}
+
+// Lookup returns the symbols of the given name, in order of occurrence.
+func (t *SymbolTable) Lookup(name string) []*NameEntry {
+	return t.names[name]
+}
+
+// Prefix returns the symbols with names starting with the given prefix, sorted
+// by name.
+func (t *SymbolTable) Prefix(prefix string) []*NameEntry {
+	var entries []*NameEntry
+	i := sort.SearchStrings(t.sorted, prefix)
+	for ; i < len(t.sorted) && strings.HasPrefix(t.sorted[i], prefix); i++ {
+		entries = append(entries, t.names[t.sorted[i]]...)
+	}
+	return entries
+}
+
+// Match returns the symbols with names matching the given regular expression,
+// sorted by name.
+func (t *SymbolTable) Match(re *regexp.Regexp) []*NameEntry {
+	var entries []*NameEntry
+	for _, name := range t.sorted {
+		if re.MatchString(name) {
+			entries = append(entries, t.names[name]...)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,414 @@
+package sym
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+//go:generate stringer -linecomment -type Severity
+
+// Severity specifies the severity of a diagnostic.
+type Severity uint8
+
+// Diagnostic severities.
+const (
+	// Informational note.
+	SeverityInfo Severity = iota + 1 // info
+	// Suspicious contents, which may still be processed.
+	SeverityWarning // warning
+	// Invalid contents, which violate invariants of the SYM format.
+	SeverityError // error
+)
+
+// MarshalText returns the textual representation of the severity, as used by
+// JSON encoded diagnostics.
+func (severity Severity) MarshalText() ([]byte, error) {
+	return []byte(severity.String()), nil
+}
+
+//go:generate stringer -linecomment -type DiagCode
+
+// DiagCode identifies the invariant violated by a diagnostic, in a stable
+// machine-readable form.
+type DiagCode uint8
+
+// Diagnostic codes.
+const (
+	// Struct, union or enum definition not terminated by EOS.
+	CodeUnterminatedTag DiagCode = iota + 1 // unterminated-tag
+	// EOS outside of struct, union or enum definition.
+	CodeStrayEOS // stray-eos
+	// Member outside of (or within mismatching) struct, union or enum
+	// definition.
+	CodeStrayMember // stray-member
+	// Struct member offset preceding the offset of the previous member.
+	CodeMemberOrder // member-order
+	// Struct or union member exceeding the size of its definition.
+	CodeMemberSize // member-size
+	// Union member at non-zero offset.
+	CodeUnionOffset // union-offset
+	// Definition of unknown class.
+	CodeUnknownClass // unknown-class
+	// Array dimensions inconsistent with the type of a definition.
+	CodeDims // dims
+	// Length field inconsistent with the contents of a symbol body.
+	CodeLength // length
+	// Function not terminated by function end symbol.
+	CodeUnterminatedFunc // unterminated-func
+	// Function end without matching function start.
+	CodeStrayFuncEnd // stray-func-end
+	// Block not terminated by block end symbol.
+	CodeUnterminatedBlock // unterminated-block
+	// Block start outside of function.
+	CodeStrayBlock // stray-block
+	// Block end without matching block start.
+	CodeStrayBlockEnd // stray-block-end
+	// Line number increment before line number assignment.
+	CodeLineIncrement // line-increment
+	// Address outside of target memory.
+	CodeAddr // addr
+	// Symbol of unknown kind.
+	CodeUnknownKind // unknown-kind
+	// Corrupted symbol skipped when parsing.
+	CodeCorrupt // corrupt
+)
+
+// MarshalText returns the textual representation of the diagnostic code, as
+// used by JSON encoded diagnostics.
+func (code DiagCode) MarshalText() ([]byte, error) {
+	return []byte(code.String()), nil
+}
+
+// A Diagnostic is a problem reported when validating a symbol file.
+type Diagnostic struct {
+	// Code of the violated invariant.
+	Code DiagCode `json:"code"`
+	// Severity of the diagnostic.
+	Severity Severity `json:"severity"`
+	// Index of the offending symbol in File.Syms; or -1 for corrupted regions
+	// of the file skipped when parsing.
+	Index int `json:"index"`
+	// Offset in bytes of the offending symbol from the start of the file.
+	Offset int `json:"offset"`
+	// Diagnostic message.
+	Msg string `json:"message"`
+}
+
+// String returns the string representation of the diagnostic.
+func (d *Diagnostic) String() string {
+	// 000056: error: struct member "x" outside of struct definition [stray-member]
+	return fmt.Sprintf("%06x: %v: %s [%v]", d.Offset, d.Severity, d.Msg, d.Code)
+}
+
+// Validate checks the invariants of the symbol file, and returns the
+// diagnostics of any violations found.
+//
+// The following invariants are checked:
+//
+//    * struct, union and enum definitions are terminated by EOS symbols;
+//    * member offsets are monotonically increasing within struct definitions;
+//    * length fields are consistent with the contents of symbol bodies;
+//    * function and block start and end symbols are balanced;
+//    * line number increments are preceded by a line number assignment;
+//    * addresses of code and global variables are located within PSX memory.
+//
+// Regions of the file skipped while recovering from corrupted symbols (see
+// ParseOptions.Recover) are reported as errors. Diagnostics are sorted by
+// offset.
+func (f *File) Validate() []*Diagnostic {
+	v := &validator{
+		offsets: f.symOffsets(),
+		order:   f.Order,
+	}
+	for i, sym := range f.Syms {
+		v.index = i
+		v.validateSymbol(sym)
+	}
+	v.index = len(f.Syms) - 1
+	if v.tag != nil {
+		v.errorf(CodeUnterminatedTag, "%s definition %q not terminated by EOS", v.tagKind, v.tag.Name)
+	}
+	if v.funcs > 0 {
+		v.errorf(CodeUnterminatedFunc, "function not terminated by function end symbol")
+	}
+	for _, r := range f.Skipped {
+		d := &Diagnostic{
+			Code:     CodeCorrupt,
+			Severity: SeverityError,
+			Index:    -1,
+			Offset:   r.Offset,
+			Msg:      fmt.Sprintf("corrupted symbol; %v", r),
+		}
+		v.diags = append(v.diags, d)
+	}
+	sort.SliceStable(v.diags, func(i, j int) bool {
+		return v.diags[i].Offset < v.diags[j].Offset
+	})
+	return v.diags
+}
+
+// validator tracks state used for validating symbol files.
+type validator struct {
+	// Diagnostics reported.
+	diags []*Diagnostic
+	// Offset of each symbol.
+	offsets []int
+	// Index of the current symbol.
+	index int
+	// Byte order of the symbol file.
+	order binary.ByteOrder
+
+	// Tag of the current struct, union or enum definition; or nil if not
+	// within a definition.
+	tag *Def
+	// Kind of the current tagged definition (struct, union or enum).
+	tagKind string
+	// Offset of the previous struct member.
+	prevOffset uint32
+
+	// Function nesting depth.
+	funcs int
+	// Block nesting depth.
+	blocks int
+	// Line number assigned.
+	hasLine bool
+}
+
+// validateSymbol validates the given symbol.
+func (v *validator) validateSymbol(sym *Symbol) {
+	v.validateLengths(sym)
+	switch body := sym.Body.(type) {
+	case *IncSLD, *IncSLDByte, *IncSLDWord:
+		if !v.hasLine {
+			v.errorf(CodeLineIncrement, "line number increment before line number assignment")
+		}
+		v.checkAddr(sym)
+	case *SetSLD, *SetSLD2:
+		v.hasLine = true
+		v.checkAddr(sym)
+	case *EndSLD:
+		v.hasLine = false
+		v.checkAddr(sym)
+	case *FuncStart:
+		if v.funcs > 0 {
+			v.errorf(CodeUnterminatedFunc, "function %q started before end of previous function", body.Name)
+		}
+		v.funcs++
+		v.blocks = 0
+		v.checkAddr(sym)
+	case *FuncEnd:
+		if v.funcs == 0 {
+			v.errorf(CodeStrayFuncEnd, "function end without matching function start")
+		} else {
+			v.funcs--
+		}
+		if v.blocks > 0 {
+			v.errorf(CodeUnterminatedBlock, "function end before end of %d nested blocks", v.blocks)
+			v.blocks = 0
+		}
+		v.checkAddr(sym)
+	case *BlockStart:
+		if v.funcs == 0 {
+			v.warnf(CodeStrayBlock, "block start outside of function")
+		}
+		v.blocks++
+		v.checkAddr(sym)
+	case *BlockEnd:
+		if v.blocks == 0 {
+			v.errorf(CodeStrayBlockEnd, "block end without matching block start")
+		} else {
+			v.blocks--
+		}
+		v.checkAddr(sym)
+	case *Def:
+		v.validateDef(sym, body.Class, body.Size, body.Name)
+		switch body.Class {
+		case ClassSTRTAG, ClassUNTAG, ClassENTAG:
+			if v.tag != nil {
+				v.errorf(CodeUnterminatedTag, "%s definition %q not terminated by EOS", v.tagKind, v.tag.Name)
+			}
+			v.tag = body
+			v.prevOffset = 0
+			switch body.Class {
+			case ClassSTRTAG:
+				v.tagKind = "struct"
+			case ClassUNTAG:
+				v.tagKind = "union"
+			case ClassENTAG:
+				v.tagKind = "enum"
+			}
+		}
+	case *Def2:
+		v.validateDef(sym, body.Class, body.Size, body.Name)
+		var arrays int
+		for _, mod := range body.Type.Mods() {
+			if mod == ModArray {
+				arrays++
+			}
+		}
+		if len(body.Dims) != arrays {
+			v.warnf(CodeDims, "%d dimensions of %q inconsistent with %d array modifiers of type %v", len(body.Dims), body.Name, arrays, body.Type)
+		}
+		if body.Class == ClassEOS {
+			if v.tag == nil {
+				v.errorf(CodeStrayEOS, "EOS outside of struct, union or enum definition")
+			}
+			v.tag = nil
+		}
+	case *Overlay:
+		v.checkAddr(sym)
+	case *UnknownBody:
+		v.warnf(CodeUnknownKind, "symbol of unknown kind 0x%02X", uint8(body.Kind))
+	}
+}
+
+// validateDef validates the definition symbol of the given class, size and
+// name.
+func (v *validator) validateDef(sym *Symbol, class Class, size uint32, name string) {
+	if !isKnownClass(class) {
+		v.errorf(CodeUnknownClass, "definition %q of unknown class 0x%04X", name, uint16(class))
+		return
+	}
+	// Validate members.
+	var memberKind string
+	switch class {
+	case ClassMOS, ClassFIELD:
+		memberKind = "struct"
+	case ClassMOU:
+		memberKind = "union"
+	case ClassMOE:
+		memberKind = "enum"
+	}
+	if len(memberKind) > 0 {
+		switch {
+		case v.tag == nil:
+			v.errorf(CodeStrayMember, "%s member %q outside of %s definition", memberKind, name, memberKind)
+		case v.tagKind != memberKind:
+			v.errorf(CodeStrayMember, "%s member %q within %s definition %q", memberKind, name, v.tagKind, v.tag.Name)
+		}
+	}
+	if v.tag == nil {
+		// Validate addresses of global declarations.
+		switch class {
+		case ClassEXT, ClassSTAT:
+			v.checkAddr(sym)
+		}
+		return
+	}
+	offset := sym.Hdr.Value
+	switch class {
+	case ClassMOS:
+		if offset < v.prevOffset {
+			v.errorf(CodeMemberOrder, "offset 0x%X of struct member %q precedes offset 0x%X of previous member", offset, name, v.prevOffset)
+		}
+		v.prevOffset = offset
+		if v.tag.Size > 0 && offset+size > v.tag.Size {
+			v.warnf(CodeMemberSize, "struct member %q at offset 0x%X (%d bytes) exceeds size 0x%X of struct %q", name, offset, size, v.tag.Size, v.tag.Name)
+		}
+	case ClassMOU:
+		if offset != 0 {
+			v.warnf(CodeUnionOffset, "union member %q at non-zero offset 0x%X", name, offset)
+		}
+		if v.tag.Size > 0 && size > v.tag.Size {
+			v.warnf(CodeMemberSize, "union member %q (%d bytes) exceeds size 0x%X of union %q", name, size, v.tag.Size, v.tag.Name)
+		}
+	}
+}
+
+// validateLengths validates that the length fields of the given symbol are
+// consistent with the contents of its body.
+func (v *validator) validateLengths(sym *Symbol) {
+	check := func(field string, n, want int) {
+		if n != want {
+			v.errorf(CodeLength, "%s %d of %v symbol inconsistent with body contents; expected %d", field, n, sym.Hdr.Kind, want)
+		}
+	}
+	names := bodyNames(sym.Body)
+	if sym.Raw != nil {
+		check("size", sym.Size(), len(sym.Raw))
+		// Names may have been decoded, and may thus differ in length from their
+		// raw encoded contents; validate length fields against the raw names.
+		if len(names) > 0 {
+			raw, err := parseSymbol(bytes.NewReader(sym.Raw), v.order)
+			if err != nil || raw.Hdr.Kind != sym.Hdr.Kind {
+				v.errorf(CodeLength, "raw contents of %v symbol inconsistent with body contents", sym.Hdr.Kind)
+				return
+			}
+			names = bodyNames(raw.Body)
+		}
+	}
+	for i, field := range lengthFields(sym.Body) {
+		check(field.name, field.n, len(*names[i]))
+	}
+	if body, ok := sym.Body.(*Def2); ok {
+		check("dimensions length", int(body.DimsLen), len(body.Dims))
+	}
+}
+
+// A lengthField is a length field of a name, tag or path of a symbol body.
+type lengthField struct {
+	// Field description (e.g. "name length").
+	name string
+	// Field value.
+	n int
+}
+
+// lengthFields returns the length fields of the names, tags and paths of the
+// given symbol body, in the order of bodyNames.
+func lengthFields(body SymbolBody) []lengthField {
+	switch body := body.(type) {
+	case *Name1:
+		return []lengthField{{"name length", int(body.NameLen)}}
+	case *Name2:
+		return []lengthField{{"name length", int(body.NameLen)}}
+	case *Name5:
+		return []lengthField{{"name length", int(body.NameLen)}}
+	case *Name6:
+		return []lengthField{{"name length", int(body.NameLen)}}
+	case *SetSLD2:
+		return []lengthField{{"path length", int(body.PathLen)}}
+	case *FuncStart:
+		return []lengthField{{"path length", int(body.PathLen)}, {"name length", int(body.NameLen)}}
+	case *Def:
+		return []lengthField{{"name length", int(body.NameLen)}}
+	case *Def2:
+		return []lengthField{{"tag length", int(body.TagLen)}, {"name length", int(body.NameLen)}}
+	}
+	return nil
+}
+
+// checkAddr reports a warning if the address of the given symbol is not
+// located within PSX memory (or the memory of the target of big-endian symbol
+// files).
+func (v *validator) checkAddr(sym *Symbol) {
+	if !isTargetAddr(sym.Hdr.Value, v.order) {
+		v.warnf(CodeAddr, "address 0x%08X of %v symbol outside of target memory", sym.Hdr.Value, sym.Hdr.Kind)
+	}
+}
+
+// errorf reports an error diagnostic of the given code for the current symbol.
+func (v *validator) errorf(code DiagCode, format string, args ...interface{}) {
+	v.report(code, SeverityError, format, args...)
+}
+
+// warnf reports a warning diagnostic of the given code for the current symbol.
+func (v *validator) warnf(code DiagCode, format string, args ...interface{}) {
+	v.report(code, SeverityWarning, format, args...)
+}
+
+// report reports a diagnostic of the given code and severity for the current
+// symbol.
+func (v *validator) report(code DiagCode, severity Severity, format string, args ...interface{}) {
+	d := &Diagnostic{
+		Code:     code,
+		Severity: severity,
+		Index:    v.index,
+		Msg:      fmt.Sprintf(format, args...),
+	}
+	if 0 <= v.index && v.index < len(v.offsets) {
+		d.Offset = v.offsets[v.index]
+	}
+	v.diags = append(v.diags, d)
+}
@@ -0,0 +1,118 @@
+package sym_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sanctuary/sym"
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestValidate(t *testing.T) {
+	// Struct member outside of struct definition, and unterminated struct
+	// definition.
+	f := &sym.File{
+		Hdr: &sym.FileHeader{},
+		Syms: []*sym.Symbol{
+			newDef(0, sym.ClassMOS, "x"),
+			newDef(0, sym.ClassSTRTAG, "foo"),
+			newDef(4, sym.ClassMOS, "a"),
+			newDef(0, sym.ClassMOS, "b"),
+		},
+	}
+	tag := f.Syms[1].Body.(*sym.Def)
+	tag.Type = sym.Type(sym.BaseStruct)
+	tag.Size = 16
+	diags := f.Validate()
+	want := []struct {
+		index    int
+		code     sym.DiagCode
+		severity sym.Severity
+	}{
+		{index: 0, code: sym.CodeStrayMember, severity: sym.SeverityError},     // member outside of struct
+		{index: 3, code: sym.CodeMemberOrder, severity: sym.SeverityError},     // decreasing offset
+		{index: 3, code: sym.CodeUnterminatedTag, severity: sym.SeverityError}, // missing EOS
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("number of diagnostics mismatch; expected %d, got %d (%v)", len(want), len(diags), diags)
+	}
+	for i, d := range diags {
+		if d.Index != want[i].index || d.Code != want[i].code || d.Severity != want[i].severity {
+			t.Errorf("diagnostic %d mismatch; expected %v %v at symbol %d, got %v", i, want[i].severity, want[i].code, want[i].index, d)
+		}
+	}
+}
+
+func TestValidateSkipped(t *testing.T) {
+	// Corrupted symbol preceding a struct member outside of struct definition.
+	f := &sym.File{
+		Hdr: &sym.FileHeader{},
+		Syms: []*sym.Symbol{
+			newDef(0, sym.ClassMOS, "x"),
+		},
+		Skipped: []*sym.SkippedRegion{
+			{Offset: 8, Size: 16, Err: errors.New("invalid symbol kind")},
+		},
+	}
+	diags := f.Validate()
+	want := []struct {
+		index  int
+		code   sym.DiagCode
+		offset int
+	}{
+		{index: -1, code: sym.CodeCorrupt, offset: 8},
+		{index: 0, code: sym.CodeStrayMember, offset: 8 + 16},
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("number of diagnostics mismatch; expected %d, got %d (%v)", len(want), len(diags), diags)
+	}
+	for i, d := range diags {
+		if d.Index != want[i].index || d.Code != want[i].code || d.Offset != want[i].offset {
+			t.Errorf("diagnostic %d mismatch; expected %v at symbol %d (offset 0x%x), got %v", i, want[i].code, want[i].index, want[i].offset, d)
+		}
+	}
+}
+
+func TestValidateLengths(t *testing.T) {
+	// "メイン" in Shift-JIS; decoded names differ in length from their raw
+	// contents.
+	b := newSymFile()
+	b = appendName(b, 0x80010000, "\x83\x81\x83\x43\x83\x93")
+	b = appendName(b, 0x80010010, "\x83\x81\x83\x43\x83\x93")
+	opts := &sym.ParseOptions{
+		Encoding: japanese.ShiftJIS,
+	}
+	f, err := sym.ParseBytesWithOptions(b, opts)
+	if err != nil {
+		t.Fatalf("unable to parse symbol file; %v", err)
+	}
+	// Inconsistent name length of second symbol.
+	f.Syms[1].Body.(*sym.Name2).NameLen = 5
+	diags := f.Validate()
+	if len(diags) != 2 {
+		t.Fatalf("number of diagnostics mismatch; expected 2, got %d (%v)", len(diags), diags)
+	}
+	for i, d := range diags {
+		if d.Index != 1 || d.Code != sym.CodeLength {
+			t.Errorf("diagnostic %d mismatch; expected %v at symbol 1, got %v", i, sym.CodeLength, d)
+		}
+	}
+}
+
+// newDef returns a new definition symbol of 4 bytes with the given value, class
+// and name.
+func newDef(value uint32, class sym.Class, name string) *sym.Symbol {
+	return &sym.Symbol{
+		Hdr: &sym.SymbolHeader{
+			Value: value,
+			Kind:  sym.KindDef,
+		},
+		Body: &sym.Def{
+			Class:   class,
+			Type:    sym.Type(sym.BaseInt),
+			Size:    4,
+			NameLen: uint8(len(name)),
+			Name:    name,
+		},
+	}
+}
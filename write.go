@@ -0,0 +1,71 @@
+package sym
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+
+	"github.com/lunixbochs/struc"
+	"github.com/pkg/errors"
+)
+
+// WriteFile writes the binary encoding of the symbol file to the given path.
+func (f *File) WriteFile(path string) error {
+	fw, err := os.Create(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fw.Close()
+	bw := bufio.NewWriter(fw)
+	if err := f.Write(bw); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := bw.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// Write writes the binary encoding of the symbol file to w, using the byte
+// order of the file (little-endian if not set).
+//
+// Names, tags and paths are written as is; regions skipped while recovering
+// from corrupted symbols are not retained.
+func (f *File) Write(w io.Writer) error {
+	order := f.Order
+	if order == nil {
+		order = binary.LittleEndian
+	}
+	if err := struc.PackWithOrder(w, f.Hdr, order); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, sym := range f.Syms {
+		if err := writeSymbol(w, sym, order); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// writeSymbol writes the binary encoding of the given symbol to w, using the
+// given byte order.
+func writeSymbol(w io.Writer, sym *Symbol, order binary.ByteOrder) error {
+	if err := struc.PackWithOrder(w, sym.Hdr, order); err != nil {
+		return errors.WithStack(err)
+	}
+	switch body := sym.Body.(type) {
+	case *UnknownBody:
+		if _, err := w.Write(body.Raw); err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	case *IncSLD, *EndSLD, *SetOverlay:
+		// Symbol without body.
+		return nil
+	}
+	if err := struc.PackWithOrder(w, sym.Body, order); err != nil {
+		return errors.Wrapf(err, "unable to encode %v symbol body", sym.Hdr.Kind)
+	}
+	return nil
+}